@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importRequest  domainChat.ImportChatRequest
+	importDeviceID string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <export.zip|export.txt>",
+	Short: "Import a WhatsApp chat export into chat storage",
+	Long: `Import a chat exported from a phone with WhatsApp's "Export chat", either the ZIP with media or the bare .txt,
+so history from before the device was linked becomes searchable. Messages already stored are skipped, so an export can be imported again.
+Senders are mapped to phone numbers with --participant. The WhatsApp connection is not needed.`,
+	Example: `  whatsapp import "WhatsApp Chat with Alice.zip" --chat-jid 6289685028129@s.whatsapp.net
+  whatsapp import _chat.txt --chat-jid 120363025246125486@g.us --participant "Alice=6289685028129" --participant "Bob=6281234567890" --self-name "Budi"`,
+	Args: cobra.ExactArgs(1),
+	Run:  importChat,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringVar(&importDeviceID, "device", "", "Device ID to import into (defaults to the only device)")
+	importCmd.Flags().StringVar(&importRequest.ChatJID, "chat-jid", "", "Chat the export belongs to")
+	importCmd.Flags().StringVar(&importRequest.ChatName, "chat-name", "", "Chat name (defaults to the name in the export file name)")
+	importCmd.Flags().StringVar(&importRequest.SelfName, "self-name", "", `Name the exporting phone used for its own messages (defaults to "You")`)
+	importCmd.Flags().StringToStringVar(&importRequest.Participants, "participant", nil, "Map a sender name to a phone number or JID (name=phone, repeatable)")
+	importCmd.Flags().StringVar(&importRequest.DateOrder, "date-order", "", "Date order of the export: dmy or mdy (detected when empty)")
+	importCmd.Flags().StringVar(&importRequest.Timezone, "timezone", "", "IANA time zone the phone was in (defaults to UTC)")
+	_ = importCmd.MarkFlagRequired("chat-jid")
+}
+
+func importChat(_ *cobra.Command, args []string) {
+	instance, _, err := whatsapp.GetDeviceManager().ResolveDevice(importDeviceID)
+	if err != nil {
+		logrus.Fatalf("Import failed: %v", err)
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		logrus.Fatalf("Import failed: %v", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		logrus.Fatalf("Import failed: %v", err)
+	}
+	importRequest.File = file
+	importRequest.FileName = info.Name()
+	importRequest.FileSize = info.Size()
+
+	response, err := chatUsecase.ImportChat(whatsapp.ContextWithDevice(context.Background(), instance), importRequest)
+	if err != nil {
+		logrus.Fatalf("Import failed: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Imported %d of %d messages into %s (%d duplicates, %d skipped, %d media files)\n",
+		response.Imported, response.Parsed, response.ChatJID, response.Duplicates, response.Skipped, response.MediaFiles)
+	if len(response.UnmappedSenders) > 0 {
+		fmt.Fprintf(os.Stderr, "Senders without a phone number: %v (map them with --participant)\n", response.UnmappedSenders)
+	}
+}
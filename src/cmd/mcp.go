@@ -54,6 +54,11 @@ func mcpServer(_ *cobra.Command, _ []string) {
 	groupHandler := mcp.InitMcpGroup(groupUsecase)
 	groupHandler.AddGroupTools(mcpServer)
 
+	if campaignUsecase != nil {
+		campaignHandler := mcp.InitMcpCampaign(campaignUsecase)
+		campaignHandler.AddCampaignTools(mcpServer)
+	}
+
 	// Create SSE server
 	sseServer := server.NewSSEServer(
 		mcpServer,
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	mediaStoreInfra "github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/mediastore"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/ui/rest"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/ui/rest/helpers"
@@ -65,6 +66,10 @@ func restServer(_ *cobra.Command, _ []string) {
 		PathPrefix: "views/assets",
 		Browse:     true,
 	}))
+	// Signed links to local media authorize themselves, ahead of basic auth
+	if localStore, ok := mediaStore.(*mediaStoreInfra.LocalStore); ok {
+		rest.InitRestMediaFiles(app.Group(config.AppBasePath), localStore)
+	}
 
 	app.Use(middleware.Recovery())
 	app.Use(middleware.RequestTimeout(middleware.DefaultRequestTimeout))
@@ -74,7 +79,7 @@ func restServer(_ *cobra.Command, _ []string) {
 	}
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept",
+		AllowHeaders: "Origin, Content-Type, Accept, Idempotency-Key",
 	}))
 
 	if len(config.AppBasicAuthCredential) > 0 {
@@ -104,7 +109,7 @@ func restServer(_ *cobra.Command, _ []string) {
 	registerDeviceScopedRoutes := func(r fiber.Router) {
 		rest.InitRestApp(r, appUsecase)
 		rest.InitRestChat(r, chatUsecase)
-		rest.InitRestSend(r, sendUsecase)
+		rest.InitRestSend(r, sendUsecase, sendQueueUsecase)
 		rest.InitRestUser(r, userUsecase)
 		rest.InitRestMessage(r, messageUsecase)
 		rest.InitRestGroup(r, groupUsecase)
@@ -118,6 +123,7 @@ func restServer(_ *cobra.Command, _ []string) {
 
 	// Device management routes (no device_id required)
 	rest.InitRestDevice(apiGroup, deviceUsecase)
+	rest.InitRestHealth(apiGroup, mediaProcessor)
 
 	// Device-scoped operations (header-based)
 	headerDeviceGroup := apiGroup.Group("", middleware.DeviceMiddleware(dm))
@@ -149,6 +155,14 @@ func restServer(_ *cobra.Command, _ []string) {
 		logrus.Info("Campaign queue worker started")
 	}
 
+	// Start outbound send queue worker
+	if sendQueueUsecase != nil {
+		go sendQueueUsecase.StartWorker(context.Background())
+	}
+
+	// Start chat storage retention worker
+	go chatUsecase.StartRetentionWorker(context.Background())
+
 	if err := app.Listen(config.AppHost + ":" + config.AppPort); err != nil {
 		logrus.Fatalln("Failed to start: ", err.Error())
 	}
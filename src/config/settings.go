@@ -46,9 +46,56 @@ var (
 	ChatStorageEnableForeignKeys = true
 	ChatStorageEnableWAL         = true
 
+	// Chat storage retention settings
+	ChatStorageRetentionDays        = 0     // Prune messages older than this many days (0 = keep forever)
+	ChatStorageRetentionMaxPerChat  = 0     // Keep only the newest N messages of each chat (0 = unlimited)
+	ChatStorageRetentionKeepStarred = true  // Never prune starred messages
+	ChatStorageRetentionPruneMedia  = false // Also delete downloaded media files older than the retention age
+	ChatStorageRetentionInterval    = 60    // Minutes between pruning runs
+	ChatStorageRetentionBatchSize   = 500   // Messages deleted per statement
+
+	ChatStorageRetentionDeviceOverrides []string // Per-device "device_id=days:max_per_chat" overrides
+
+	// Days deleted messages stay in the sync change feed. Sync cursors older than this expire.
+	ChatStorageSyncDeletionDays = 30
+
+	// Chat storage encryption at rest: base64 encoded 32 byte AES-256 keys. Content and media keys
+	// are encrypted with the current key; previous keys only decrypt, for key rotation.
+	ChatStorageEncryptionKey          = ""
+	ChatStorageEncryptionPreviousKeys []string
+
+	// Outbound send queue for /send requests made with async=true
+	SendQueueRatePerMinute = 20   // Messages each device sends per minute at most
+	SendQueueHourlyLimit   = 0    // Messages each device sends per hour at most (0 = unlimited)
+	SendQueueMaxAttempts   = 3    // Attempts before a queued message fails
+	SendQueueExpireMinutes = 1440 // Fail messages still waiting this long after they were due, e.g. while the device is offline (0 = never)
+	SendQueueRetentionDays = 7    // Days sent and failed messages, and their idempotency keys, are kept
+	SendBulkMaxRecipients  = 1000 // Recipients one /send/bulk request takes at most (0 = unlimited)
+
+	// Media storage for downloaded media: "local" keeps it under PathMedia, "s3" in an S3 compatible bucket
+	MediaStorage          = "local"
+	MediaS3Endpoint       = "" // e.g. https://s3.eu-west-1.amazonaws.com or http://minio:9000 (default: AWS S3 of the region)
+	MediaS3Region         = "us-east-1"
+	MediaS3Bucket         = ""
+	MediaS3AccessKey      = ""
+	MediaS3SecretKey      = ""
+	MediaS3Prefix         = ""     // Prepended to every object key
+	MediaS3PathStyle      = false  // Bucket in the URL path instead of the host name, as MinIO expects
+	MediaURLSecret        = ""     // Signs links to locally stored media (random per run when empty)
+	MediaURLExpiryMinutes = 60     // Lifetime of media links in webhooks and download responses
+	MediaPublicURL        = ""     // External URL of the app that local media links start with, e.g. https://wa.example.com
+	MediaProcessor        = "auto" // "auto" uses ffmpeg when installed, "ffmpeg" requires it, "native" processes media in pure Go
+
 	// Campaign settings
 	CampaignMinDelay     = 30  // Minimum delay between messages in seconds
 	CampaignMaxDelay     = 300 // Maximum delay between messages in seconds (5 min)
 	CampaignBatchSize    = 100 // Messages per queue poll
 	CampaignShortURLBase = ""  // Base URL for short links (e.g., https://yourdomain.com)
+
+	CampaignRevalidateAfterHours = 720 // Re-check customers whose last validation is older than this (0 = never)
+	CampaignMaxSendFailures      = 3   // Mark a customer invalid after this many consecutive "not on WhatsApp" send failures (0 = never)
+
+	CampaignOptOutKeywords = []string{"stop", "unsubscribe"} // Replies that opt a customer out of all campaigns
+	CampaignAutoTagClicked = "clicked"                       // Tag added when a customer opens a short link (empty = off)
+	CampaignAutoTagReplied = "replied"                       // Tag added when a customer replies (empty = off)
 )
@@ -2,6 +2,7 @@ package campaign
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -12,13 +13,29 @@ type ICampaignRepository interface {
 	CreateCustomer(ctx context.Context, customer *Customer) error
 	GetCustomer(ctx context.Context, deviceID string, id uuid.UUID) (*Customer, error)
 	GetCustomerByPhone(ctx context.Context, deviceID string, phone string) (*Customer, error)
-	ListCustomers(ctx context.Context, deviceID string, limit, offset int, search string, filterGroupID *uuid.UUID, filterType string) ([]*Customer, int, error)
+	ListCustomers(ctx context.Context, deviceID string, limit, offset int, search string, filterGroupID *uuid.UUID, filterType, filterTag string) ([]*Customer, int, error)
 	UpdateCustomer(ctx context.Context, customer *Customer) error
 	DeleteCustomer(ctx context.Context, deviceID string, id uuid.UUID) error
 	DeleteCustomers(ctx context.Context, deviceID string, ids []uuid.UUID) error
 	BulkCreateCustomers(ctx context.Context, customers []*Customer) (int, error)
 	GetCustomersForValidation(ctx context.Context, deviceID string, limit int) ([]*Customer, error)
-	UpdateCustomerValidation(ctx context.Context, id uuid.UUID, phoneValid, whatsappExists ValidationStatus) error
+	GetCustomersForRevalidation(ctx context.Context, deviceID string, validatedBefore time.Time, limit int) ([]*Customer, error)
+	UpdateCustomerValidation(ctx context.Context, id uuid.UUID, phoneValid, whatsappExists ValidationStatus, source ValidationSource, reason *string) error
+	GetCustomerValidationHistory(ctx context.Context, customerID uuid.UUID, limit int) ([]*ValidationRecord, error)
+	IncrementCustomerSendFailures(ctx context.Context, id uuid.UUID) (int, error)
+	ResetCustomerSendFailures(ctx context.Context, id uuid.UUID) error
+	SetCustomersOptedOut(ctx context.Context, deviceID, phone string) error
+	GetCustomersByPhone(ctx context.Context, deviceID, phone string) ([]*Customer, error)
+
+	// Tag and note operations
+	ListTags(ctx context.Context, deviceID string) ([]*Tag, error)
+	DeleteTag(ctx context.Context, deviceID, name string) error
+	AddCustomerTags(ctx context.Context, deviceID string, customerID uuid.UUID, names []string) error
+	RemoveCustomerTag(ctx context.Context, deviceID string, customerID uuid.UUID, name string) error
+	GetCustomersTags(ctx context.Context, customerIDs []uuid.UUID) (map[uuid.UUID][]string, error)
+	CreateCustomerNote(ctx context.Context, note *CustomerNote) error
+	ListCustomerNotes(ctx context.Context, customerID uuid.UUID) ([]*CustomerNote, error)
+	DeleteCustomerNote(ctx context.Context, customerID, noteID uuid.UUID) error
 
 	// Group operations
 	CreateGroup(ctx context.Context, group *Group) error
@@ -48,21 +65,43 @@ type ICampaignRepository interface {
 	SetCampaignTargets(ctx context.Context, campaignID uuid.UUID, customerIDs, groupIDs []uuid.UUID) error
 	GetCampaignTargetIDs(ctx context.Context, campaignID uuid.UUID) (customerIDs, groupIDs []uuid.UUID, err error)
 	GetCampaignTargetCustomers(ctx context.Context, campaignID uuid.UUID) ([]*Customer, error)
+	SetCampaignTargetTags(ctx context.Context, campaignID uuid.UUID, tags []string) error
+	GetCampaignTargetTags(ctx context.Context, campaignID uuid.UUID) ([]string, error)
 	GetCampaignStats(ctx context.Context, campaignID uuid.UUID) (*CampaignStats, error)
+	SetCampaignDevices(ctx context.Context, campaignID uuid.UUID, devices []CampaignDevice) error
+	GetCampaignDevices(ctx context.Context, campaignID uuid.UUID) ([]CampaignDevice, error)
+	SetCampaignSteps(ctx context.Context, campaignID uuid.UUID, steps []CampaignStep) error
+	GetCampaignSteps(ctx context.Context, campaignID uuid.UUID) ([]CampaignStep, error)
+
+	// Drip sequence operations
+	SaveEnrollment(ctx context.Context, enrollment *Enrollment) error
+	GetDueEnrollments(ctx context.Context, now time.Time, limit int) ([]*Enrollment, error)
+	GetEnrollment(ctx context.Context, campaignID, customerID uuid.UUID) (*Enrollment, error)
+	GetActiveEnrollmentsByPhone(ctx context.Context, deviceID, phone string) ([]*Enrollment, error)
+	RecordEnrollmentReply(ctx context.Context, campaignID, customerID uuid.UUID, reply string, nextStepAt *time.Time) error
+	StopEnrollment(ctx context.Context, campaignID, customerID uuid.UUID, reason string) error
+	EnqueueFollowUp(ctx context.Context, item *QueueItem) error
+
+	// Sender assignment (sticky customer -> device mapping)
+	GetCustomerSender(ctx context.Context, customerID uuid.UUID) (string, error)
+	SetCustomerSenderIfMissing(ctx context.Context, customerID uuid.UUID, deviceID string) error
 
 	// Queue operations
 	EnqueueMessages(ctx context.Context, items []*QueueItem) error
 	GetPendingMessages(ctx context.Context, deviceID string, limit int) ([]*QueueItem, error)
 	UpdateMessageStatus(ctx context.Context, id uuid.UUID, status MessageStatus, errorMsg *string) error
 	IsMessageQueued(ctx context.Context, campaignID, customerID uuid.UUID) (bool, error)
+	UpdateMessageSender(ctx context.Context, id uuid.UUID, senderDeviceID string) error
+	CountSentMessagesBySender(ctx context.Context, senderDeviceID string, since time.Time) (int, error)
 
 	// Short URL operations
 	CreateShortURL(ctx context.Context, shortURL *ShortURL) error
 	GetShortURLByCode(ctx context.Context, code string) (*ShortURL, error)
 	IncrementShortURLClicks(ctx context.Context, code string) error
 
-	// Device operations for queue worker
+	// Device operations for queue and validation workers
 	GetActiveDeviceIDs(ctx context.Context) ([]string, error)
+	GetCustomerDeviceIDs(ctx context.Context) ([]string, error)
 
 	// Schema
 	InitializeSchema() error
@@ -74,14 +113,24 @@ type ICampaignUsecase interface {
 	CreateCustomer(ctx context.Context, req CreateCustomerRequest) (*Customer, error)
 	ImportCustomersFromCSV(ctx context.Context, deviceID string, csvData []byte, groupID *uuid.UUID) (imported int, errors []string, err error)
 	GetCustomer(ctx context.Context, deviceID string, id uuid.UUID) (*Customer, error)
-	ListCustomers(ctx context.Context, deviceID string, page, pageSize int, search string, filterGroupID *uuid.UUID, filterType string) (*CustomerListResponse, error)
+	ListCustomers(ctx context.Context, deviceID string, page, pageSize int, search string, filterGroupID *uuid.UUID, filterType, filterTag string) (*CustomerListResponse, error)
 	UpdateCustomer(ctx context.Context, req UpdateCustomerRequest) (*Customer, error)
 	DeleteCustomer(ctx context.Context, deviceID string, id uuid.UUID) error
 	DeleteCustomers(ctx context.Context, deviceID string, ids []uuid.UUID) error
 	ValidateCustomer(ctx context.Context, deviceID string, id uuid.UUID) error // Manual validation trigger
 	ValidateCustomers(ctx context.Context, deviceID string, ids []uuid.UUID) error
 	ValidatePendingCustomers(ctx context.Context, deviceID string) error // Bulk manual validation trigger
-	StartValidationWorker(ctx context.Context)                           // Background validation
+	GetCustomerValidationHistory(ctx context.Context, deviceID string, id uuid.UUID) ([]*ValidationRecord, error)
+	StartValidationWorker(ctx context.Context) // Background validation and periodic re-validation
+
+	// Tags and notes
+	ListTags(ctx context.Context, deviceID string) ([]*Tag, error)
+	DeleteTag(ctx context.Context, deviceID, name string) error
+	AddCustomerTags(ctx context.Context, deviceID string, customerID uuid.UUID, tags []string) (*Customer, error)
+	RemoveCustomerTag(ctx context.Context, deviceID string, customerID uuid.UUID, tag string) (*Customer, error)
+	CreateCustomerNote(ctx context.Context, req CreateCustomerNoteRequest) (*CustomerNote, error)
+	ListCustomerNotes(ctx context.Context, deviceID string, customerID uuid.UUID) ([]*CustomerNote, error)
+	DeleteCustomerNote(ctx context.Context, deviceID string, customerID, noteID uuid.UUID) error
 
 	// Group management
 	CreateGroup(ctx context.Context, req CreateGroupRequest) (*Group, error)
@@ -118,4 +167,7 @@ type ICampaignUsecase interface {
 	// Queue worker
 	StartQueueWorker(ctx context.Context)
 	StopQueueWorker()
+
+	// Incoming replies (drip sequence stop/branch and opt-out)
+	HandleIncomingMessage(ctx context.Context, deviceID, phone, text string)
 }
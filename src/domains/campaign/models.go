@@ -34,6 +34,33 @@ const (
 	ValidationStatusInvalid ValidationStatus = "invalid"
 )
 
+// ValidationSource describes what triggered a customer validation
+type ValidationSource string
+
+const (
+	ValidationSourceManual       ValidationSource = "manual"       // Triggered through the API
+	ValidationSourceWorker       ValidationSource = "worker"       // First validation by the background worker
+	ValidationSourceRevalidation ValidationSource = "revalidation" // Periodic re-check of a stale validation
+	ValidationSourceSendFailure  ValidationSource = "send_failure" // Marked invalid after repeated send failures
+)
+
+// StepCondition controls when a follow-up step of a drip sequence fires
+type StepCondition string
+
+const (
+	StepConditionNoReply        StepCondition = "no_reply"        // Sent after the delay unless the customer replied
+	StepConditionRepliedKeyword StepCondition = "replied_keyword" // Sent after the delay once the customer replies with Keyword
+)
+
+// EnrollmentStatus tracks a customer's progress through a drip sequence
+type EnrollmentStatus string
+
+const (
+	EnrollmentStatusActive    EnrollmentStatus = "active"
+	EnrollmentStatusCompleted EnrollmentStatus = "completed"
+	EnrollmentStatusStopped   EnrollmentStatus = "stopped"
+)
+
 // Customer represents a campaign recipient
 type Customer struct {
 	ID             uuid.UUID        `json:"id"`
@@ -47,10 +74,25 @@ type Customer struct {
 	PhoneValid     ValidationStatus `json:"phone_valid"`     // Phone format validation status
 	WhatsAppExists ValidationStatus `json:"whatsapp_exists"` // WhatsApp account validation status
 	IsReady        bool             `json:"is_ready"`        // Computed: true if phone_valid=valid AND whatsapp_exists=valid
+	ValidatedAt    *time.Time       `json:"validated_at"`    // Last time WhatsApp existence was actually checked
+	SendFailures   int              `json:"send_failures"`   // Consecutive "not on WhatsApp" send failures
+	OptedOutAt     *time.Time       `json:"opted_out_at"`    // Set when the customer replied with an opt-out keyword
+	Tags           []string         `json:"tags"`            // Populated on demand
 	CreatedAt      time.Time        `json:"created_at"`
 	UpdatedAt      time.Time        `json:"updated_at"`
 }
 
+// ValidationRecord is one entry of a customer's validation history
+type ValidationRecord struct {
+	ID             uuid.UUID        `json:"id"`
+	CustomerID     uuid.UUID        `json:"customer_id"`
+	PhoneValid     ValidationStatus `json:"phone_valid"`
+	WhatsAppExists ValidationStatus `json:"whatsapp_exists"`
+	Source         ValidationSource `json:"source"`
+	Reason         *string          `json:"reason,omitempty"` // e.g. the send error that caused invalidation
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
 // Group represents a customer group for targeting
 type Group struct {
 	ID          uuid.UUID `json:"id"`
@@ -87,10 +129,21 @@ type Campaign struct {
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	// Populated on demand
-	Template    *Template      `json:"template,omitempty"`
-	Stats       *CampaignStats `json:"stats,omitempty"`
-	CustomerIDs []uuid.UUID    `json:"customer_ids,omitempty"`
-	GroupIDs    []uuid.UUID    `json:"group_ids,omitempty"`
+	Template    *Template        `json:"template,omitempty"`
+	Stats       *CampaignStats   `json:"stats,omitempty"`
+	CustomerIDs []uuid.UUID      `json:"customer_ids,omitempty"`
+	GroupIDs    []uuid.UUID      `json:"group_ids,omitempty"`
+	Tags        []string         `json:"tags,omitempty"`    // Target customers carrying any of these tags
+	Devices     []CampaignDevice `json:"devices,omitempty"` // Sender pool; empty means DeviceID sends everything
+	Steps       []CampaignStep   `json:"steps,omitempty"`   // Follow-up steps after the initial template
+}
+
+// CampaignDevice is a sender in a campaign's device pool
+type CampaignDevice struct {
+	DeviceID    string `json:"device_id" form:"device_id"`
+	HourlyLimit int    `json:"hourly_limit" form:"hourly_limit"` // Max messages sent per hour by this device (0 = unlimited)
+	DailyLimit  int    `json:"daily_limit" form:"daily_limit"`   // Max messages sent per day by this device (0 = unlimited)
+	Connected   bool   `json:"connected"`                        // Populated on demand
 }
 
 // CampaignStats holds campaign execution statistics
@@ -103,27 +156,30 @@ type CampaignStats struct {
 
 // QueueItem represents a message in the send queue
 type QueueItem struct {
-	ID         uuid.UUID     `json:"id"`
-	CampaignID uuid.UUID     `json:"campaign_id"`
-	CustomerID uuid.UUID     `json:"customer_id"`
-	DeviceID   string        `json:"device_id"`
-	Phone      string        `json:"phone"`   // Denormalized for quick access
-	Message    string        `json:"message"` // Processed message with placeholders replaced
-	Status     MessageStatus `json:"status"`
-	Error      *string       `json:"error,omitempty"`
-	SentAt     *time.Time    `json:"sent_at,omitempty"`
-	CreatedAt  time.Time     `json:"created_at"`
-	UpdatedAt  time.Time     `json:"updated_at"`
+	ID             uuid.UUID     `json:"id"`
+	CampaignID     uuid.UUID     `json:"campaign_id"`
+	CustomerID     uuid.UUID     `json:"customer_id"`
+	DeviceID       string        `json:"device_id"`        // Campaign owner device
+	SenderDeviceID string        `json:"sender_device_id"` // Device that sends the message (pool member or DeviceID)
+	Phone          string        `json:"phone"`            // Denormalized for quick access
+	Message        string        `json:"message"`          // Processed message with placeholders replaced
+	Step           int           `json:"step"`             // 0 = initial campaign message, N = follow-up step N
+	Status         MessageStatus `json:"status"`
+	Error          *string       `json:"error,omitempty"`
+	SentAt         *time.Time    `json:"sent_at,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
 }
 
 // ShortURL represents a shortened URL for tracking
 type ShortURL struct {
-	ID          uuid.UUID `json:"id"`
-	DeviceID    string    `json:"device_id"`
-	Code        string    `json:"code"`         // Short code (e.g., "abc123")
-	OriginalURL string    `json:"original_url"` // Full URL
-	Clicks      int       `json:"clicks"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          uuid.UUID  `json:"id"`
+	DeviceID    string     `json:"device_id"`
+	Code        string     `json:"code"`         // Short code (e.g., "abc123")
+	OriginalURL string     `json:"original_url"` // Full URL
+	CustomerID  *uuid.UUID `json:"customer_id"`  // Recipient the link was generated for, if any
+	Clicks      int        `json:"clicks"`
+	CreatedAt   time.Time  `json:"created_at"`
 }
 
 // GroupListResponse for pagination
@@ -152,3 +208,48 @@ type CampaignListResponse struct {
 	PageSize   int         `json:"page_size"`
 	TotalPages int         `json:"total_pages"`
 }
+
+// CampaignStep is a follow-up message in a drip sequence. The campaign's own
+// template is step 0; steps are numbered from 1 in the order they are sent.
+type CampaignStep struct {
+	ID           uuid.UUID     `json:"id"`
+	CampaignID   uuid.UUID     `json:"campaign_id"`
+	Position     int           `json:"position"`
+	TemplateID   uuid.UUID     `json:"template_id" form:"template_id"`
+	DelayMinutes int           `json:"delay_minutes" form:"delay_minutes"` // Wait after the previous step was sent (or the keyword reply arrived)
+	Condition    StepCondition `json:"condition" form:"condition"`
+	Keyword      *string       `json:"keyword,omitempty" form:"keyword"` // Required for replied_keyword
+}
+
+// Enrollment is a customer's position in a campaign's drip sequence
+type Enrollment struct {
+	CampaignID     uuid.UUID        `json:"campaign_id"`
+	CustomerID     uuid.UUID        `json:"customer_id"`
+	DeviceID       string           `json:"device_id"`
+	SenderDeviceID string           `json:"sender_device_id"`
+	Phone          string           `json:"phone"`
+	CurrentStep    int              `json:"current_step"`           // Last step delivered
+	NextStepAt     *time.Time       `json:"next_step_at,omitempty"` // Nil while waiting for a reply or once queued
+	Status         EnrollmentStatus `json:"status"`
+	StopReason     *string          `json:"stop_reason,omitempty"`
+	LastReply      *string          `json:"last_reply,omitempty"`
+	RepliedAt      *time.Time       `json:"replied_at,omitempty"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// Tag is a free-form label attached to customers
+type Tag struct {
+	ID            uuid.UUID `json:"id"`
+	DeviceID      string    `json:"device_id"`
+	Name          string    `json:"name"` // Stored lowercase
+	CustomerCount int       `json:"customer_count"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// CustomerNote is a timestamped free-text note on a customer
+type CustomerNote struct {
+	ID         uuid.UUID `json:"id"`
+	CustomerID uuid.UUID `json:"customer_id"`
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+}
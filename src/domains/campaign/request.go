@@ -38,6 +38,18 @@ type CustomerListResponse struct {
 	TotalPages int         `json:"total_pages"`
 }
 
+// CustomerTagsRequest is the request to add tags to a customer
+type CustomerTagsRequest struct {
+	Tags []string `json:"tags" form:"tags"`
+}
+
+// CreateCustomerNoteRequest is the request to add a note to a customer
+type CreateCustomerNoteRequest struct {
+	DeviceID   string    `json:"-"`
+	CustomerID uuid.UUID `json:"-"`
+	Content    string    `json:"content" form:"content"`
+}
+
 // CreateGroupRequest is the request to create a new group
 type CreateGroupRequest struct {
 	DeviceID    string  `json:"-"`
@@ -75,21 +87,27 @@ type UpdateTemplateRequest struct {
 
 // CreateCampaignRequest is the request to create a new campaign
 type CreateCampaignRequest struct {
-	DeviceID    string      `json:"-"`
-	Name        string      `json:"name" form:"name"`
-	TemplateID  uuid.UUID   `json:"template_id" form:"template_id"`
-	CustomerIDs []uuid.UUID `json:"customer_ids" form:"customer_ids"`
-	GroupIDs    []uuid.UUID `json:"group_ids" form:"group_ids"`
-	ScheduledAt *time.Time  `json:"scheduled_at" form:"scheduled_at"`
+	DeviceID    string           `json:"-"`
+	Name        string           `json:"name" form:"name"`
+	TemplateID  uuid.UUID        `json:"template_id" form:"template_id"`
+	CustomerIDs []uuid.UUID      `json:"customer_ids" form:"customer_ids"`
+	GroupIDs    []uuid.UUID      `json:"group_ids" form:"group_ids"`
+	Tags        []string         `json:"tags" form:"tags"`
+	ScheduledAt *time.Time       `json:"scheduled_at" form:"scheduled_at"`
+	Devices     []CampaignDevice `json:"devices" form:"devices"` // Optional sender pool
+	Steps       []CampaignStep   `json:"steps" form:"steps"`     // Optional follow-up steps
 }
 
 // UpdateCampaignRequest is the request to update a campaign
 type UpdateCampaignRequest struct {
-	DeviceID    string      `json:"-"`
-	ID          uuid.UUID   `json:"-"`
-	Name        string      `json:"name" form:"name"`
-	TemplateID  uuid.UUID   `json:"template_id" form:"template_id"`
-	CustomerIDs []uuid.UUID `json:"customer_ids" form:"customer_ids"`
-	GroupIDs    []uuid.UUID `json:"group_ids" form:"group_ids"`
-	ScheduledAt *time.Time  `json:"scheduled_at" form:"scheduled_at"`
+	DeviceID    string           `json:"-"`
+	ID          uuid.UUID        `json:"-"`
+	Name        string           `json:"name" form:"name"`
+	TemplateID  uuid.UUID        `json:"template_id" form:"template_id"`
+	CustomerIDs []uuid.UUID      `json:"customer_ids" form:"customer_ids"`
+	GroupIDs    []uuid.UUID      `json:"group_ids" form:"group_ids"`
+	Tags        []string         `json:"tags" form:"tags"`
+	ScheduledAt *time.Time       `json:"scheduled_at" form:"scheduled_at"`
+	Devices     []CampaignDevice `json:"devices" form:"devices"` // Optional sender pool
+	Steps       []CampaignStep   `json:"steps" form:"steps"`     // Optional follow-up steps
 }
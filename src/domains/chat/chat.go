@@ -1,5 +1,7 @@
 package chat
 
+import "io"
+
 // Request and Response structures for chat operations
 
 type ListChatsRequest struct {
@@ -7,6 +9,10 @@ type ListChatsRequest struct {
 	Offset   int    `json:"offset" query:"offset"`
 	Search   string `json:"search" query:"search"`
 	HasMedia bool   `json:"has_media" query:"has_media"`
+	Cursor   string `json:"cursor" query:"cursor"` // pagination.next_cursor of the previous page, replaces offset
+	Unread   *bool  `json:"unread" query:"unread"`
+	Archived *bool  `json:"archived" query:"archived"`
+	Pinned   *bool  `json:"pinned" query:"pinned"`
 }
 
 type ListChatsResponse struct {
@@ -23,6 +29,7 @@ type GetChatMessagesRequest struct {
 	MediaOnly bool    `json:"media_only" query:"media_only"`
 	IsFromMe  *bool   `json:"is_from_me" query:"is_from_me"`
 	Search    string  `json:"search" query:"search"`
+	Cursor    string  `json:"cursor" query:"cursor"` // pagination.next_cursor of the previous page, replaces offset
 }
 
 type GetChatMessagesResponse struct {
@@ -31,6 +38,29 @@ type GetChatMessagesResponse struct {
 	ChatInfo   ChatInfo           `json:"chat_info"`
 }
 
+// Message search operations
+type SearchMessagesRequest struct {
+	Query     string  `json:"query" query:"query"`
+	ChatJID   string  `json:"chat_jid" query:"chat_jid"`
+	Limit     int     `json:"limit" query:"limit"`
+	Offset    int     `json:"offset" query:"offset"`
+	StartTime *string `json:"start_time" query:"start_time"`
+	EndTime   *string `json:"end_time" query:"end_time"`
+	MediaOnly bool    `json:"media_only" query:"media_only"`
+}
+
+type SearchMessagesResponse struct {
+	Data       []SearchMessageResult `json:"data"`
+	Pagination PaginationResponse    `json:"pagination"`
+}
+
+type SearchMessageResult struct {
+	MessageInfo
+	ChatName string  `json:"chat_name"`
+	Snippet  string  `json:"snippet"`
+	Rank     float64 `json:"rank"`
+}
+
 // Pin Chat operations
 type PinChatRequest struct {
 	ChatJID string `json:"chat_jid" uri:"chat_jid"`
@@ -51,6 +81,21 @@ type ChatInfo struct {
 	EphemeralExpiration uint32 `json:"ephemeral_expiration"`
 	CreatedAt           string `json:"created_at"`
 	UpdatedAt           string `json:"updated_at"`
+	UnreadCount         int    `json:"unread_count"`
+	MarkedUnread        bool   `json:"marked_unread"`
+	Archived            bool   `json:"archived"`
+	Pinned              bool   `json:"pinned"`
+	// MutedUntil is empty when the chat is not muted
+	MutedUntil  string `json:"muted_until,omitempty"`
+	ContactName string `json:"contact_name,omitempty"`
+	// Participants are the stored members of a group chat
+	Participants []ParticipantInfo `json:"participants,omitempty"`
+}
+
+type ParticipantInfo struct {
+	JID          string `json:"jid"`
+	IsAdmin      bool   `json:"is_admin"`
+	IsSuperAdmin bool   `json:"is_super_admin"`
 }
 
 type MessageInfo struct {
@@ -66,12 +111,207 @@ type MessageInfo struct {
 	FileLength uint64 `json:"file_length"`
 	CreatedAt  string `json:"created_at"`
 	UpdatedAt  string `json:"updated_at"`
+	// Status is sent, delivered, read or played for own messages
+	Status      string            `json:"status,omitempty"`
+	IsEdited    bool              `json:"is_edited"`
+	EditedAt    string            `json:"edited_at,omitempty"`
+	EditHistory []MessageEditInfo `json:"edit_history,omitempty"`
+	IsRevoked   bool              `json:"is_revoked"`
+	RevokedAt   string            `json:"revoked_at,omitempty"`
+	Reactions   []ReactionInfo    `json:"reactions,omitempty"`
+	IsStarred   bool              `json:"is_starred"`
+}
+
+// ReactionInfo is one sender's current reaction to a message
+type ReactionInfo struct {
+	SenderJID string `json:"sender_jid"`
+	Reaction  string `json:"reaction"`
+	Timestamp string `json:"timestamp"`
+}
+
+// MessageEditInfo is a previous version of an edited message
+type MessageEditInfo struct {
+	Content  string `json:"content"`
+	EditedAt string `json:"edited_at"`
+}
+
+// RetentionPreviewResponse shows what the next pruning run would delete
+type RetentionPreviewResponse struct {
+	DeviceID           string                 `json:"device_id"`
+	Enabled            bool                   `json:"enabled"`
+	MaxAgeDays         int                    `json:"max_age_days"`
+	MaxMessagesPerChat int                    `json:"max_messages_per_chat"`
+	KeepStarred        bool                   `json:"keep_starred"`
+	Messages           int64                  `json:"messages"`
+	Chats              []RetentionChatPreview `json:"chats"`
+	// Media files are only counted when media pruning is enabled
+	MediaFiles int   `json:"media_files"`
+	MediaBytes int64 `json:"media_bytes"`
+}
+
+// RetentionChatPreview is the number of messages pruned from one chat
+type RetentionChatPreview struct {
+	ChatJID  string `json:"chat_jid"`
+	Messages int64  `json:"messages"`
+}
+
+// Periods GetChatAnalyticsRequest.Granularity groups message volume by
+const (
+	AnalyticsGranularityDay  = "day"
+	AnalyticsGranularityHour = "hour"
+)
+
+// GetChatAnalyticsRequest aggregates the stored messages of one chat, or of
+// every chat of the device when ChatJID is empty, between StartTime and EndTime
+type GetChatAnalyticsRequest struct {
+	ChatJID     string  `json:"chat_jid" query:"chat_jid"`
+	StartTime   *string `json:"start_time" query:"start_time"` // Defaults to 30 days before end_time
+	EndTime     *string `json:"end_time" query:"end_time"`     // Defaults to now
+	Granularity string  `json:"granularity" query:"granularity"`
+	Limit       int     `json:"limit" query:"limit"` // Entries in top_chats and top_senders
+}
+
+type GetChatAnalyticsResponse struct {
+	StartTime   string `json:"start_time"`
+	EndTime     string `json:"end_time"`
+	Granularity string `json:"granularity"`
+	Inbound     int64  `json:"inbound"`
+	Outbound    int64  `json:"outbound"`
+	// Volume has an entry for every period of the range, oldest first
+	Volume       []MessageVolumeInfo  `json:"volume"`
+	TopChats     []ChatActivityInfo   `json:"top_chats"`
+	ResponseTime ResponseTimeInfo     `json:"response_time"`
+	Media        []MediaUsageInfo     `json:"media"`
+	TopSenders   []SenderActivityInfo `json:"top_senders"`
+}
+
+// MessageVolumeInfo counts the messages of the day or hour starting at Period (UTC)
+type MessageVolumeInfo struct {
+	Period   string `json:"period"`
+	Inbound  int64  `json:"inbound"`
+	Outbound int64  `json:"outbound"`
+}
+
+type ChatActivityInfo struct {
+	ChatJID  string `json:"chat_jid"`
+	Name     string `json:"name"`
+	Inbound  int64  `json:"inbound"`
+	Outbound int64  `json:"outbound"`
+}
+
+// ResponseTimeInfo is the median time our side takes to answer an incoming
+// message in direct chats, measured over Samples answered turns
+type ResponseTimeInfo struct {
+	Samples       int64   `json:"samples"`
+	MedianSeconds float64 `json:"median_seconds"`
+}
+
+type MediaUsageInfo struct {
+	MediaType string `json:"media_type"`
+	Messages  int64  `json:"messages"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// SenderActivityInfo counts the messages one participant sent to a group
+type SenderActivityInfo struct {
+	ChatJID    string `json:"chat_jid"`
+	ChatName   string `json:"chat_name"`
+	SenderJID  string `json:"sender_jid"`
+	SenderName string `json:"sender_name"`
+	Messages   int64  `json:"messages"`
+}
+
+// Chat export formats
+const (
+	ExportFormatJSON = "json"
+	ExportFormatCSV  = "csv"
+	ExportFormatHTML = "html"
+	ExportFormatTXT  = "txt" // WhatsApp "Export chat" text format
+)
+
+// ExportChatRequest exports one chat, or every chat of the device between
+// StartTime and EndTime when ChatJID is empty
+type ExportChatRequest struct {
+	ChatJID      string  `json:"chat_jid" uri:"chat_jid"`
+	Format       string  `json:"format" query:"format"`
+	StartTime    *string `json:"start_time" query:"start_time"`
+	EndTime      *string `json:"end_time" query:"end_time"`
+	IncludeMedia bool    `json:"include_media" query:"include_media"` // Bundle downloaded media into a ZIP
+}
+
+// ExportChatResponse describes the export file. WriteTo streams its content
+// and may only be called once.
+type ExportChatResponse struct {
+	FileName    string
+	ContentType string
+	WriteTo     func(w io.Writer) error
+}
+
+// Date orders of imported chat exports, detected from the file when empty
+const (
+	ImportDateOrderDMY = "dmy"
+	ImportDateOrderMDY = "mdy"
+)
+
+// ImportChatRequest imports a WhatsApp "Export chat" archive, either the ZIP
+// with its media or the bare .txt transcript, into chat storage
+type ImportChatRequest struct {
+	ChatJID  string `json:"chat_jid" uri:"chat_jid"`
+	ChatName string `json:"chat_name"` // Defaults to the name in the export's file name
+	// Name the exporting phone used for its own messages
+	SelfName string `json:"self_name"`
+	// Sender names of the export mapped to phone numbers or JIDs
+	Participants map[string]string `json:"participants"`
+	DateOrder    string            `json:"date_order"`
+	Timezone     string            `json:"timezone"` // IANA zone the phone was in, defaults to UTC
+
+	File     io.ReaderAt `json:"-"`
+	FileName string      `json:"-"`
+	FileSize int64       `json:"-"`
+}
+
+type ImportChatResponse struct {
+	ChatJID    string `json:"chat_jid"`
+	Parsed     int    `json:"parsed"`
+	Imported   int    `json:"imported"`
+	Duplicates int    `json:"duplicates"` // Already in chat storage
+	// System notices, deleted messages and media left out of the export
+	Skipped    int `json:"skipped"`
+	MediaFiles int `json:"media_files"`
+	// Senders stored under their display name for lack of a participant mapping
+	UnmappedSenders []string `json:"unmapped_senders"`
 }
 
 type PaginationResponse struct {
 	Limit  int `json:"limit"`
 	Offset int `json:"offset"`
 	Total  int `json:"total"`
+	// Keyset cursor of the next page, stable while new messages arrive.
+	// Empty on the last page and for offset-only listings.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// GetMessageChangesRequest reads the change feed after Cursor. Without a
+// cursor the feed starts from the oldest stored message.
+type GetMessageChangesRequest struct {
+	ChatJID string `json:"chat_jid" query:"chat_jid"` // Empty for every chat of the device
+	Cursor  string `json:"cursor" query:"cursor"`
+	Limit   int    `json:"limit" query:"limit"`
+}
+
+type GetMessageChangesResponse struct {
+	// Messages stored or changed (edited, revoked, starred, status) since the cursor
+	Messages []MessageInfo `json:"messages"`
+	// Messages removed from chat storage since the cursor
+	Deleted    []DeletedMessageInfo `json:"deleted"`
+	NextCursor string               `json:"next_cursor"`
+	HasMore    bool                 `json:"has_more"`
+}
+
+type DeletedMessageInfo struct {
+	ID        string `json:"id"`
+	ChatJID   string `json:"chat_jid"`
+	DeletedAt string `json:"deleted_at"`
 }
 
 // Disappearing Messages operations
@@ -8,7 +8,14 @@ import (
 type IChatUsecase interface {
 	ListChats(ctx context.Context, request ListChatsRequest) (response ListChatsResponse, err error)
 	GetChatMessages(ctx context.Context, request GetChatMessagesRequest) (response GetChatMessagesResponse, err error)
+	SearchMessages(ctx context.Context, request SearchMessagesRequest) (response SearchMessagesResponse, err error)
+	GetMessageChanges(ctx context.Context, request GetMessageChangesRequest) (response GetMessageChangesResponse, err error)
 	PinChat(ctx context.Context, request PinChatRequest) (response PinChatResponse, err error)
 	SetDisappearingTimer(ctx context.Context, request SetDisappearingTimerRequest) (response SetDisappearingTimerResponse, err error)
 	ArchiveChat(ctx context.Context, request ArchiveChatRequest) (response ArchiveChatResponse, err error)
+	GetChatAnalytics(ctx context.Context, request GetChatAnalyticsRequest) (response GetChatAnalyticsResponse, err error)
+	PreviewRetention(ctx context.Context) (response RetentionPreviewResponse, err error)
+	ExportChat(ctx context.Context, request ExportChatRequest) (response ExportChatResponse, err error)
+	ImportChat(ctx context.Context, request ImportChatRequest) (response ImportChatResponse, err error)
+	StartRetentionWorker(ctx context.Context)
 }
@@ -11,26 +11,119 @@ type Chat struct {
 	EphemeralExpiration uint32    `db:"ephemeral_expiration"`
 	CreatedAt           time.Time `db:"created_at"`
 	UpdatedAt           time.Time `db:"updated_at"`
+	// Chat list state synced from the phone, changed with UpdateChatMetadata
+	UnreadCount  int        `db:"unread_count"`
+	MarkedUnread bool       `db:"marked_unread"` // Marked as unread by hand
+	Archived     bool       `db:"archived"`
+	Pinned       bool       `db:"pinned"`
+	MutedUntil   *time.Time `db:"muted_until"` // Nil when not muted, ChatMutedForever when muted without end
+	ContactName  string     `db:"-"`           // Saved or push name of a 1:1 chat from contacts, read only
+}
+
+// ChatMutedForever is the MutedUntil of chats muted without an end
+var ChatMutedForever = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// ChatMetadataUpdate changes the chat list state of a stored chat. Nil fields
+// are left as they are.
+type ChatMetadataUpdate struct {
+	Name         *string
+	Archived     *bool
+	Pinned       *bool
+	MutedUntil   *time.Time // Zero time unmutes
+	UnreadCount  *int       // Replaces the counter
+	AddUnread    int        // Added to the counter, ignored when UnreadCount is set
+	MarkedUnread *bool
+}
+
+// ChatReadUpdate clears the unread state of a chat
+func ChatReadUpdate() ChatMetadataUpdate {
+	read, markedUnread := 0, false
+	return ChatMetadataUpdate{UnreadCount: &read, MarkedUnread: &markedUnread}
+}
+
+// Contact is what is known about a user's name
+type Contact struct {
+	DeviceID     string    `db:"device_id"`
+	JID          string    `db:"jid"`
+	FullName     string    `db:"full_name"` // Name saved in the phone's address book
+	FirstName    string    `db:"first_name"`
+	PushName     string    `db:"push_name"` // Name the user chose for themselves
+	BusinessName string    `db:"business_name"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+// GroupParticipant is a member of a group chat
+type GroupParticipant struct {
+	DeviceID     string `db:"device_id"`
+	GroupJID     string `db:"group_jid"`
+	JID          string `db:"jid"`
+	IsAdmin      bool   `db:"is_admin"`
+	IsSuperAdmin bool   `db:"is_super_admin"`
+}
+
+// GroupParticipantChange lists the members that joined, left, were promoted
+// to admin or demoted in one group event
+type GroupParticipantChange struct {
+	Join    []string
+	Leave   []string
+	Promote []string
+	Demote  []string
 }
 
 // Message represents a WhatsApp message
 type Message struct {
-	ID            string    `db:"id"`
-	ChatJID       string    `db:"chat_jid"`
-	DeviceID      string    `db:"device_id"`
-	Sender        string    `db:"sender"`
-	Content       string    `db:"content"`
-	Timestamp     time.Time `db:"timestamp"`
-	IsFromMe      bool      `db:"is_from_me"`
-	MediaType     string    `db:"media_type"`
-	Filename      string    `db:"filename"`
-	URL           string    `db:"url"`
-	MediaKey      []byte    `db:"media_key"`
-	FileSHA256    []byte    `db:"file_sha256"`
-	FileEncSHA256 []byte    `db:"file_enc_sha256"`
-	FileLength    uint64    `db:"file_length"`
-	CreatedAt     time.Time `db:"created_at"`
-	UpdatedAt     time.Time `db:"updated_at"`
+	ID            string     `db:"id"`
+	ChatJID       string     `db:"chat_jid"`
+	DeviceID      string     `db:"device_id"`
+	Sender        string     `db:"sender"`
+	Content       string     `db:"content"`
+	Timestamp     time.Time  `db:"timestamp"`
+	IsFromMe      bool       `db:"is_from_me"`
+	MediaType     string     `db:"media_type"`
+	Filename      string     `db:"filename"`
+	URL           string     `db:"url"`
+	MediaKey      []byte     `db:"media_key"`
+	FileSHA256    []byte     `db:"file_sha256"`
+	FileEncSHA256 []byte     `db:"file_enc_sha256"`
+	FileLength    uint64     `db:"file_length"`
+	Mimetype      string     `db:"mimetype"` // As sent, empty for messages stored before it was kept
+	IsPTT         bool       `db:"is_ptt"`   // Audio recorded as a voice note
+	CreatedAt     time.Time  `db:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	EditedAt      *time.Time `db:"edited_at"`  // Set once the sender edited the message
+	IsRevoked     bool       `db:"is_revoked"` // Deleted for everyone by the sender
+	RevokedAt     *time.Time `db:"revoked_at"`
+	Status        string     `db:"status"` // One of the MessageStatus values, empty for incoming messages
+	IsStarred     bool       `db:"is_starred"`
+	MediaPath     string     `db:"media_path"` // Downloaded media file, empty until downloaded
+}
+
+// Delivery states of a message, in the order they are reached.
+// A message never moves back to an earlier state.
+const (
+	MessageStatusSent      = "sent"
+	MessageStatusDelivered = "delivered"
+	MessageStatusRead      = "read"
+	MessageStatusPlayed    = "played"
+)
+
+// MessageReaction is a sender's current reaction to a message
+type MessageReaction struct {
+	MessageID string    `db:"message_id"`
+	ChatJID   string    `db:"chat_jid"`
+	DeviceID  string    `db:"device_id"`
+	Sender    string    `db:"sender"`
+	Reaction  string    `db:"reaction"` // Empty removes the sender's reaction
+	Timestamp time.Time `db:"timestamp"`
+}
+
+// MessageEdit is a previous version of an edited message
+type MessageEdit struct {
+	MessageID string    `db:"message_id"`
+	ChatJID   string    `db:"chat_jid"`
+	DeviceID  string    `db:"device_id"`
+	Content   string    `db:"content"`   // Content before the edit
+	EditedAt  time.Time `db:"edited_at"` // When it was replaced
 }
 
 // MediaInfo represents downloadable media information
@@ -65,6 +158,174 @@ type MessageFilter struct {
 	EndTime   *time.Time
 	MediaOnly bool
 	IsFromMe  *bool
+	// Before pages by keyset instead of Offset: only messages older than the cursor
+	Before *MessageCursor
+}
+
+// MessageCursor is the position of a message in (timestamp, id) order
+type MessageCursor struct {
+	Timestamp time.Time
+	ID        string
+}
+
+// MessageChangeFilter selects the messages of a device stored, changed or
+// deleted after a sync cursor. An empty ChatJID covers every chat.
+type MessageChangeFilter struct {
+	DeviceID string
+	ChatJID  string
+	Since    ChangeCursor
+	// Changes after Until are left for the next call, so writes that are
+	// still being committed are not skipped
+	Until time.Time
+	Limit int
+}
+
+// ChangeCursor is a position in the change feed: the last message seen in
+// (updated_at, chat_jid, id) order and the last deletion seen. The zero
+// value starts from the beginning.
+type ChangeCursor struct {
+	UpdatedAt   time.Time
+	ChatJID     string
+	ID          string
+	DeletionSeq int64
+}
+
+// MessageDeletion records a message removed from chat storage
+type MessageDeletion struct {
+	Seq       int64
+	DeviceID  string
+	ChatJID   string
+	MessageID string
+	DeletedAt time.Time
+}
+
+// MessageChanges is one page of the change feed, each list in feed order
+type MessageChanges struct {
+	Messages  []*Message
+	Deletions []*MessageDeletion
+}
+
+// MessageSearchFilter represents a full-text search over stored messages.
+// An empty ChatJID searches every chat of the device.
+type MessageSearchFilter struct {
+	DeviceID  string
+	ChatJID   string
+	Query     string
+	Limit     int
+	Offset    int
+	StartTime *time.Time
+	EndTime   *time.Time
+	MediaOnly bool
+}
+
+// MessageSearchResult is a message matched by full-text search
+type MessageSearchResult struct {
+	Message *Message
+	Snippet string  // Matched text with hits wrapped in SearchHighlightStart/End
+	Rank    float64 // Higher is more relevant
+}
+
+// Markers wrapped around matched terms in MessageSearchResult.Snippet
+const (
+	SearchHighlightStart = "<mark>"
+	SearchHighlightEnd   = "</mark>"
+)
+
+// RetentionPolicy selects the messages of one device that pruning deletes.
+// A message is pruned when it is older than MaxAge or falls beyond the
+// newest MaxMessagesPerChat of its chat; zero disables either rule.
+type RetentionPolicy struct {
+	DeviceID           string
+	MaxAge             time.Duration
+	MaxMessagesPerChat int
+	KeepStarred        bool
+}
+
+// Enabled reports whether the policy prunes anything at all
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxMessagesPerChat > 0
+}
+
+// PrunePreview counts the messages a retention policy would delete
+type PrunePreview struct {
+	DeviceID string
+	Messages int64
+	Chats    []PruneChatPreview // Largest first
+}
+
+// PruneChatPreview is the part of a PrunePreview within one chat
+type PruneChatPreview struct {
+	ChatJID  string
+	Messages int64
+}
+
+// Periods message volume is grouped by
+const (
+	AnalyticsGranularityDay  = "day"
+	AnalyticsGranularityHour = "hour"
+)
+
+// AnalyticsFilter selects the messages of one device that analytics are
+// computed over. An empty ChatJID covers every chat of the device.
+type AnalyticsFilter struct {
+	DeviceID    string
+	ChatJID     string
+	StartTime   *time.Time
+	EndTime     *time.Time
+	Granularity string // AnalyticsGranularityDay or AnalyticsGranularityHour
+	Limit       int    // Entries in TopChats and TopSenders
+}
+
+// MessageAnalytics aggregates the stored messages selected by an AnalyticsFilter
+type MessageAnalytics struct {
+	Inbound  int64
+	Outbound int64
+	// Volume has an entry for every period with messages, oldest first
+	Volume       []MessageVolume
+	TopChats     []ChatActivity // Most messages first
+	ResponseTime ResponseTimeStats
+	Media        []MediaUsage     // Most messages first
+	TopSenders   []SenderActivity // Most messages first, group chats only
+}
+
+// MessageVolume counts the messages of one period. Period is the UTC start of
+// the period formatted as 2006-01-02 for days and 2006-01-02T15:00:00Z for hours.
+type MessageVolume struct {
+	Period   string
+	Inbound  int64
+	Outbound int64
+}
+
+// ChatActivity counts the messages of one chat
+type ChatActivity struct {
+	ChatJID  string
+	Name     string
+	Inbound  int64
+	Outbound int64
+}
+
+// ResponseTimeStats measures how long our side takes to answer in direct
+// chats: the time from the first message of an incoming turn to the first
+// message we send after it
+type ResponseTimeStats struct {
+	Samples       int64
+	MedianSeconds float64
+}
+
+// MediaUsage counts the messages and bytes of one media type
+type MediaUsage struct {
+	MediaType string
+	Messages  int64
+	Bytes     int64
+}
+
+// SenderActivity counts the messages one participant sent to a group
+type SenderActivity struct {
+	ChatJID    string
+	ChatName   string
+	SenderJID  string
+	SenderName string
+	Messages   int64
 }
 
 // ChatFilter represents query filters for chats
@@ -74,4 +335,18 @@ type ChatFilter struct {
 	Offset     int
 	SearchName string
 	HasMedia   bool
+	// Chat list state filters, nil matches either. Unread also matches chats
+	// marked as unread.
+	Unread   *bool
+	Archived *bool
+	Pinned   *bool
+	// Before pages by keyset instead of Offset: only chats after the cursor
+	// in last message order
+	Before *ChatCursor
+}
+
+// ChatCursor is the position of a chat in (last_message_time, jid) order
+type ChatCursor struct {
+	LastMessageTime time.Time
+	JID             string
 }
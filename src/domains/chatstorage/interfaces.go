@@ -23,12 +23,62 @@ type IChatStorageRepository interface {
 	StoreMessagesBatch(messages []*Message) error
 	GetMessageByID(id string) (*Message, error) // New method for efficient ID-only search
 	GetMessages(filter *MessageFilter) ([]*Message, error)
+	// IterateMessages calls fn for every message matched by filter, oldest first, without
+	// loading them all at once. An empty ChatJID covers every chat of the device, one chat
+	// after another. Limit and Offset are ignored; an error from fn stops the iteration.
+	IterateMessages(filter *MessageFilter, fn func(*Message) error) error
 	SearchMessages(deviceID, chatJID, searchText string, limit int) ([]*Message, error) // Database-level search with device isolation
+	// Ranked full-text search across the device's chats, also returns the total match count
+	SearchMessagesFullText(filter *MessageSearchFilter) ([]*MessageSearchResult, int64, error)
 	DeleteMessage(id, chatJID string) error
 	DeleteMessageByDevice(deviceID, id, chatJID string) error
 	StoreSentMessageWithContext(ctx context.Context, messageID string, senderJID string, recipientJID string, content string, timestamp time.Time) error
 
+	// Message lifecycle: edits, revokes, reactions and receipts. Updates for
+	// messages that are not stored are ignored.
+	ApplyMessageEdit(deviceID, chatJID, messageID, content string, editedAt time.Time) error
+	MarkMessageRevoked(deviceID, chatJID, messageID string, revokedAt time.Time) error
+	StoreReaction(reaction *MessageReaction) error
+	// Status only moves forward (sent -> delivered -> read -> played)
+	UpdateMessageStatus(deviceID, chatJID string, messageIDs []string, status string) error
+	GetMessageReactions(deviceID, chatJID string, messageIDs []string) ([]*MessageReaction, error)
+	GetMessageEdits(deviceID, chatJID string, messageIDs []string) ([]*MessageEdit, error)
+	SetMessageStarred(deviceID, chatJID, messageID string, starred bool) error
+	SetMessageMediaPath(deviceID, chatJID, messageID, mediaPath string) error
+
+	// Chat metadata, contacts and group members, kept in sync from app state and group
+	// events. Metadata updates for chats that are not stored are ignored.
+	UpdateChatMetadata(deviceID, chatJID string, update ChatMetadataUpdate) error
+	// StoreContact merges contact into the stored one, empty fields keep their stored value
+	StoreContact(contact *Contact) error
+	// SetGroupParticipants replaces the stored members of a group
+	SetGroupParticipants(deviceID, groupJID string, participants []*GroupParticipant) error
+	ApplyGroupParticipantChange(deviceID, groupJID string, change GroupParticipantChange) error
+	GetGroupParticipants(deviceID, groupJID string) ([]*GroupParticipant, error)
+
+	// Sync
+	// GetMessageChanges returns up to filter.Limit messages stored or changed and up to
+	// filter.Limit deletions after filter.Since, oldest change first
+	GetMessageChanges(filter *MessageChangeFilter) (*MessageChanges, error)
+	// PruneMessageDeletions forgets deletions recorded before olderThan
+	PruneMessageDeletions(olderThan time.Time) (int64, error)
+
+	// Retention
+	ListMessageDeviceIDs() ([]string, error)
+	PreviewPrune(policy RetentionPolicy) (*PrunePreview, error)
+	// PruneMessages deletes at most limit messages selected by policy and returns how many it deleted
+	PruneMessages(policy RetentionPolicy, limit int) (int64, error)
+
+	// Encryption
+	// ReencryptMessages rewrites stored message content and media keys that are not under
+	// the current encryption key, or still encrypted when encryption is being turned off,
+	// batchSize rows per transaction. It returns the number of rows rewritten.
+	ReencryptMessages(batchSize int) (int64, error)
+
 	// Statistics
+	// GetMessageAnalytics aggregates the messages of filter.DeviceID between
+	// filter.StartTime and filter.EndTime
+	GetMessageAnalytics(filter *AnalyticsFilter) (*MessageAnalytics, error)
 	GetChatMessageCount(chatJID string) (int64, error)
 	GetChatMessageCountByDevice(deviceID, chatJID string) (int64, error)
 	GetTotalMessageCount() (int64, error)
@@ -0,0 +1,22 @@
+package mediaproc
+
+import "context"
+
+// IMediaProcessor inspects and converts media before it is sent. Operations a
+// backend cannot perform on the given input return ErrUnsupported.
+type IMediaProcessor interface {
+	// Capabilities reports which operations are available and what backs them
+	Capabilities() Capabilities
+	// Probe reads the format, dimensions and duration of a media file
+	Probe(ctx context.Context, data []byte) (*MediaInfo, error)
+	// Thumbnail returns a JPEG of an image, or of a video frame, width pixels wide
+	Thumbnail(ctx context.Context, data []byte, width int) ([]byte, error)
+	// TranscodeOpus returns the audio as Ogg Opus, as WhatsApp expects voice notes
+	TranscodeOpus(ctx context.Context, data []byte) ([]byte, error)
+	// Waveform returns points amplitudes from 0 to 100 for the voice note UI
+	Waveform(ctx context.Context, data []byte, points int) ([]byte, error)
+	// WebP converts an image to a static WebP sticker
+	WebP(ctx context.Context, data []byte, options WebPOptions) (*WebPResult, error)
+	// CompressVideo re-encodes a video to H.264 at most 720 pixels wide
+	CompressVideo(ctx context.Context, data []byte) ([]byte, error)
+}
@@ -0,0 +1,69 @@
+package mediaproc
+
+import (
+	"errors"
+	"time"
+)
+
+// Backends media can be processed with
+const (
+	BackendAuto   = "auto"
+	BackendFFmpeg = "ffmpeg"
+	BackendNative = "native"
+)
+
+// Operations of IMediaProcessor, as reported in Capabilities
+const (
+	OperationProbe         = "probe"
+	OperationThumbnail     = "thumbnail"
+	OperationTranscodeOpus = "transcode_opus"
+	OperationWaveform      = "waveform"
+	OperationWebP          = "webp"
+	OperationCompressVideo = "compress_video"
+)
+
+// ErrUnsupported is returned for operations the backend cannot perform on the input
+var ErrUnsupported = errors.New("not supported by the media processor")
+
+// MediaInfo describes a media file
+type MediaInfo struct {
+	// Format is the container or image format, e.g. "png", "webp", "ogg", "mp4"
+	Format   string        `json:"format"`
+	Codec    string        `json:"codec,omitempty"`
+	Width    int           `json:"width,omitempty"`
+	Height   int           `json:"height,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Animated bool          `json:"animated,omitempty"`
+}
+
+// WebPOptions bounds a converted sticker
+type WebPOptions struct {
+	// MaxDimension scales larger images down to fit in a square of this size
+	MaxDimension int
+	// MaxBytes is the size the encoder tries to stay under, 0 for no limit
+	MaxBytes int
+}
+
+// WebPResult is a converted sticker and its dimensions
+type WebPResult struct {
+	Data   []byte
+	Width  int
+	Height int
+}
+
+// Capabilities reports the external tools found and what each operation runs on
+type Capabilities struct {
+	Backend    string       `json:"backend"`
+	FFmpeg     string       `json:"ffmpeg,omitempty"`
+	FFprobe    string       `json:"ffprobe,omitempty"`
+	Operations []Capability `json:"operations"`
+}
+
+// Capability is the support for one operation. Formats lists the inputs the
+// pure-Go backend understands; it is empty when ffmpeg reads any input.
+type Capability struct {
+	Operation string   `json:"operation"`
+	Available bool     `json:"available"`
+	Backend   string   `json:"backend,omitempty"`
+	Formats   []string `json:"formats,omitempty"`
+}
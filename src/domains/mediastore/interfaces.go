@@ -0,0 +1,25 @@
+package mediastore
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// IMediaStore keeps downloaded media. Keys are slash separated paths such as
+// "6289685028129/2025-03-04/3EB0C127D7BACC83D6A3.jpg".
+type IMediaStore interface {
+	// Put stores data under key, replacing what was stored there
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Open returns the stored file, ErrNotFound when there is none. The caller closes it.
+	Open(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// OpenRange returns length bytes of the stored file from offset on, or the rest
+	// of it when length is negative. The object describes the whole file.
+	OpenRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, *Object, error)
+	// Stat describes the stored file, ErrNotFound when there is none
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Delete removes the file, succeeding when there was none
+	Delete(ctx context.Context, key string) error
+	// URL returns a link that downloads the file without credentials until ttl passes
+	URL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
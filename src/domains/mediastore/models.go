@@ -0,0 +1,23 @@
+package mediastore
+
+import (
+	"errors"
+	"time"
+)
+
+// Backends the media store can run on
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// ErrNotFound is returned when no media is stored under a key
+var ErrNotFound = errors.New("media not found")
+
+// Object describes a stored media file
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
@@ -10,6 +10,7 @@ type IMessageActions interface {
 	ReactMessage(ctx context.Context, request ReactionRequest) (response GenericResponse, err error)
 	RevokeMessage(ctx context.Context, request RevokeRequest) (response GenericResponse, err error)
 	UpdateMessage(ctx context.Context, request UpdateMessageRequest) (response GenericResponse, err error)
+	ForwardMessage(ctx context.Context, request ForwardRequest) (response ForwardResponse, err error)
 }
 
 // IMessageManagement handles message management operations
@@ -17,6 +18,7 @@ type IMessageManagement interface {
 	DeleteMessage(ctx context.Context, request DeleteRequest) (err error)
 	StarMessage(ctx context.Context, request StarRequest) (err error)
 	DownloadMedia(ctx context.Context, request DownloadMediaRequest) (response DownloadMediaResponse, err error)
+	GetMedia(ctx context.Context, request MediaRequest) (response *MediaResponse, err error)
 }
 
 // IMessageUsecase combines all message interfaces
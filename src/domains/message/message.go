@@ -1,5 +1,11 @@
 package message
 
+import (
+	"context"
+	"io"
+	"time"
+)
+
 type GenericResponse struct {
 	MessageID string `json:"message_id"`
 	Status    string `json:"status"`
@@ -48,6 +54,48 @@ type DownloadMediaResponse struct {
 	Status    string `json:"status"`
 	MediaType string `json:"media_type"`
 	Filename  string `json:"filename"`
-	FilePath  string `json:"file_path"`
+	FilePath  string `json:"file_path"` // Key of the file in the media store
 	FileSize  int64  `json:"file_size"`
+	MediaURL  string `json:"media_url,omitempty"` // Expiring link that downloads the file without credentials
+}
+
+// MediaRequest looks up the media of a stored message by its ID
+type MediaRequest struct {
+	MessageID string `json:"message_id" uri:"message_id"`
+}
+
+// MediaResponse describes the media of a message, downloaded into the media store
+type MediaResponse struct {
+	MessageID   string    `json:"message_id"`
+	MediaType   string    `json:"media_type"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	FileSize    int64     `json:"file_size"`
+	ModTime     time.Time `json:"-"`
+	MediaURL    string    `json:"media_url"` // Expiring link that downloads the file without credentials
+	ExpiresAt   time.Time `json:"expires_at"`
+	// Open reads length bytes of the file from offset on, or the rest of it when length is negative
+	Open func(ctx context.Context, offset, length int64) (io.ReadCloser, error) `json:"-"`
+}
+
+// ForwardRequest forwards a stored message to one or more chats
+type ForwardRequest struct {
+	MessageID string   `json:"message_id" uri:"message_id"`
+	Phones    []string `json:"phones" form:"phones"`
+}
+
+type ForwardResult struct {
+	Phone     string `json:"phone"`
+	MessageID string `json:"message_id,omitempty"`
+	Status    string `json:"status"` // sent or failed
+	Error     string `json:"error,omitempty"`
+}
+
+// ForwardResponse reports each target of a forward in the order given
+type ForwardResponse struct {
+	MessageID string          `json:"message_id"`
+	Status    string          `json:"status"`
+	Sent      int             `json:"sent"`
+	Failed    int             `json:"failed"`
+	Results   []ForwardResult `json:"results"`
 }
@@ -0,0 +1,31 @@
+package send
+
+import "mime/multipart"
+
+// Media types an album item can be
+const (
+	AlbumItemImage = "image"
+	AlbumItemVideo = "video"
+)
+
+// AlbumItem is one image or video of an album, given as an upload or a URL
+type AlbumItem struct {
+	Type    string                `json:"type"`
+	URL     *string               `json:"url,omitempty"`
+	Caption string                `json:"caption,omitempty"`
+	File    *multipart.FileHeader `json:"-"`
+}
+
+// AlbumRequest sends several images and videos that WhatsApp shows grouped as one album
+type AlbumRequest struct {
+	BaseRequest
+	Items    []AlbumItem `json:"items" form:"-"`
+	Compress bool        `json:"compress" form:"compress"`
+}
+
+// AlbumResponse holds the album message and the message of each item, in the order given
+type AlbumResponse struct {
+	MessageID  string   `json:"message_id"`
+	MessageIDs []string `json:"message_ids"`
+	Status     string   `json:"status"`
+}
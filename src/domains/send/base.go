@@ -4,4 +4,7 @@ type BaseRequest struct {
 	Phone       string `json:"phone" form:"phone"`
 	Duration    *int   `json:"duration,omitempty" form:"duration"`
 	IsForwarded bool   `json:"is_forwarded,omitempty" form:"is_forwarded"`
+	// ReplyMessageID quotes a message from chat storage, with its media preview when known
+	ReplyMessageID *string  `json:"reply_message_id,omitempty" form:"reply_message_id"`
+	Mentions       []string `json:"mentions,omitempty" form:"mentions"` // List of phone numbers/JIDs to mention (ghost mentions)
 }
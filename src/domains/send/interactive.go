@@ -0,0 +1,69 @@
+package send
+
+// How interactive messages fall back to a numbered text menu
+const (
+	// InteractiveFallbackAuto sends the interactive message when the recipient is
+	// known to show it, and the numbered text menu otherwise or when WhatsApp
+	// refuses it
+	InteractiveFallbackAuto = "auto"
+	// InteractiveFallbackText always sends the numbered text menu
+	InteractiveFallbackText = "text"
+	// InteractiveFallbackNone sends the interactive message or fails
+	InteractiveFallbackNone = "none"
+)
+
+// ListRow is one choice of a list message. Its ID comes back in the webhook
+// when it is picked, and defaults to its number in the list.
+type ListRow struct {
+	ID          string `json:"id,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// ListSection groups the rows of a list message under a title
+type ListSection struct {
+	Title string    `json:"title,omitempty"`
+	Rows  []ListRow `json:"rows"`
+}
+
+// ListRequest sends a message with a button opening a list of choices
+type ListRequest struct {
+	BaseRequest
+	Title       string        `json:"title" form:"title"`
+	Description string        `json:"description" form:"description"`
+	ButtonText  string        `json:"button_text" form:"button_text"`
+	Footer      string        `json:"footer,omitempty" form:"footer"`
+	Sections    []ListSection `json:"sections" form:"-"`
+	Fallback    string        `json:"fallback,omitempty" form:"fallback"`
+}
+
+// Button is one reply button. Its ID comes back in the webhook when it is
+// tapped, and defaults to its number.
+type Button struct {
+	ID   string `json:"id,omitempty"`
+	Text string `json:"text"`
+}
+
+// ButtonsRequest sends a message with up to three reply buttons
+type ButtonsRequest struct {
+	BaseRequest
+	Header   string   `json:"header,omitempty" form:"header"`
+	Text     string   `json:"text" form:"text"`
+	Footer   string   `json:"footer,omitempty" form:"footer"`
+	Buttons  []Button `json:"buttons" form:"-"`
+	Fallback string   `json:"fallback,omitempty" form:"fallback"`
+}
+
+// EventRequest sends an event invitation that recipients can answer with going
+// or not going. Times are RFC3339.
+type EventRequest struct {
+	BaseRequest
+	Name               string `json:"name" form:"name"`
+	Description        string `json:"description,omitempty" form:"description"`
+	StartTime          string `json:"start_time" form:"start_time"`
+	EndTime            string `json:"end_time,omitempty" form:"end_time"`
+	Location           string `json:"location,omitempty" form:"location"`
+	JoinLink           string `json:"join_link,omitempty" form:"join_link"`
+	ExtraGuestsAllowed bool   `json:"extra_guests_allowed,omitempty" form:"extra_guests_allowed"`
+	Fallback           string `json:"fallback,omitempty" form:"fallback"`
+}
@@ -16,6 +16,7 @@ type IMediaSender interface {
 	SendVideo(ctx context.Context, request VideoRequest) (response GenericResponse, err error)
 	SendAudio(ctx context.Context, request AudioRequest) (response GenericResponse, err error)
 	SendSticker(ctx context.Context, request StickerRequest) (response GenericResponse, err error)
+	SendAlbum(ctx context.Context, request AlbumRequest) (response AlbumResponse, err error)
 }
 
 // IInteractionSender handles interaction message sending operations
@@ -24,6 +25,9 @@ type IInteractionSender interface {
 	SendLink(ctx context.Context, request LinkRequest) (response GenericResponse, err error)
 	SendLocation(ctx context.Context, request LocationRequest) (response GenericResponse, err error)
 	SendPoll(ctx context.Context, request PollRequest) (response GenericResponse, err error)
+	SendList(ctx context.Context, request ListRequest) (response GenericResponse, err error)
+	SendButtons(ctx context.Context, request ButtonsRequest) (response GenericResponse, err error)
+	SendEvent(ctx context.Context, request EventRequest) (response GenericResponse, err error)
 }
 
 // IPresenceSender handles presence-related operations
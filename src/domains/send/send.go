@@ -3,4 +3,6 @@ package send
 type GenericResponse struct {
 	MessageID string `json:"message_id"`
 	Status    string `json:"status"`
+	// Fallback is "text" when an interactive message went out as a numbered text menu
+	Fallback string `json:"fallback,omitempty"`
 }
@@ -0,0 +1,61 @@
+package sendqueue
+
+import (
+	"context"
+	"time"
+)
+
+// IQueueRepository defines database operations for the outbound send queue
+type IQueueRepository interface {
+	// Enqueue stores item. When the device already queued a request under the same
+	// idempotency key, the stored item is returned instead and created is false.
+	Enqueue(ctx context.Context, item *Item) (stored *Item, created bool, err error)
+	GetItem(ctx context.Context, deviceID, id string) (*Item, error)
+	ListItems(ctx context.Context, deviceID string, request ListItemsRequest) ([]*Item, int, error)
+	// GetBatchItems returns the items of a bulk send in the order they were queued
+	GetBatchItems(ctx context.Context, deviceID, batchID string) ([]*Item, error)
+	// Cancel cancels a queued item, reporting false when it is no longer queued
+	Cancel(ctx context.Context, deviceID, id string) (bool, error)
+	// Reschedule moves a queued item to scheduledAt, reporting false when it is no longer queued
+	Reschedule(ctx context.Context, deviceID, id, recurrence string, scheduledAt time.Time) (bool, error)
+
+	// Worker operations
+	GetDueDeviceIDs(ctx context.Context, now time.Time) ([]string, error)
+	// ClaimNext marks the oldest queued item of the device that is due as sending
+	// and returns it, or nil when nothing is due
+	ClaimNext(ctx context.Context, deviceID string, now time.Time) (*Item, error)
+	MarkSent(ctx context.Context, id, messageID string, sentAt time.Time) error
+	MarkFailed(ctx context.Context, id, errMsg string) error
+	// Requeue puts a claimed item back for another attempt at nextAttemptAt
+	Requeue(ctx context.Context, id, errMsg string, nextAttemptAt time.Time) error
+	// Rearm queues a recurring item again for its next occurrence
+	Rearm(ctx context.Context, id string, scheduledAt time.Time) error
+	CountSentSince(ctx context.Context, deviceID string, since time.Time) (int, error)
+	// FailInterrupted fails the items claimed before claimedBefore that are still
+	// sending, since their worker is gone and they may or may not have reached WhatsApp
+	FailInterrupted(ctx context.Context, claimedBefore time.Time, errMsg string) ([]*Item, error)
+	// ExpireQueued fails the queued items that have been due since before dueBefore
+	ExpireQueued(ctx context.Context, dueBefore time.Time, errMsg string) ([]*Item, error)
+	// DeleteFinished removes sent, failed and cancelled items last updated before before
+	DeleteFinished(ctx context.Context, before time.Time) (int64, error)
+
+	// Schema
+	InitializeSchema() error
+}
+
+// IQueueUsecase defines business logic for the outbound send queue
+type IQueueUsecase interface {
+	Enqueue(ctx context.Context, request EnqueueRequest) (response EnqueueResponse, err error)
+	EnqueueBulk(ctx context.Context, request BulkRequest) (response BulkResponse, err error)
+	// GetBatch reports the recipients of a bulk send, nil when it does not exist
+	GetBatch(ctx context.Context, batchID string) (*BulkResponse, error)
+	GetItem(ctx context.Context, id string) (*Item, error)
+	ListItems(ctx context.Context, request ListItemsRequest) (response ListItemsResponse, err error)
+	// CancelItem and RescheduleItem return nil when the item does not exist
+	CancelItem(ctx context.Context, id string) (*Item, error)
+	RescheduleItem(ctx context.Context, request RescheduleItemRequest) (*Item, error)
+
+	// Worker
+	StartWorker(ctx context.Context)
+	StopWorker()
+}
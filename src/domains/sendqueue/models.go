@@ -0,0 +1,80 @@
+package sendqueue
+
+import (
+	"time"
+)
+
+// Status represents where a queued send request is in its lifecycle
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusSending   Status = "sending"
+	StatusSent      Status = "sent"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+	// StatusRejected only appears in bulk results, for recipients that were not queued
+	StatusRejected Status = "rejected"
+)
+
+// Recurrences besides five field cron expressions
+const (
+	RecurrenceDaily  = "daily"
+	RecurrenceWeekly = "weekly"
+)
+
+// Kinds of send requests the queue accepts, one per /send endpoint
+const (
+	KindText     = "message"
+	KindImage    = "image"
+	KindFile     = "file"
+	KindVideo    = "video"
+	KindSticker  = "sticker"
+	KindContact  = "contact"
+	KindLink     = "link"
+	KindLocation = "location"
+	KindAudio    = "audio"
+	KindPoll     = "poll"
+	KindList     = "list"
+	KindButtons  = "buttons"
+	KindEvent    = "event"
+)
+
+// Item is a send request waiting on, or processed by, the outbound queue
+type Item struct {
+	ID             string `json:"id"`
+	DeviceID       string `json:"device_id"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	BatchID        string `json:"batch_id,omitempty"` // Set on the items of one bulk send
+	// BatchIndex is the position of the recipient in its bulk send, which keeps them in order
+	BatchIndex int    `json:"-"`
+	Kind       string `json:"kind"`
+	Phone      string `json:"phone"`
+	// Payload is the JSON encoded domainSend request, without its uploaded files
+	Payload     []byte `json:"-"`
+	RequestHash string `json:"-"`
+	// Files are the uploaded files of the request, staged on disk until it is processed
+	Files []File `json:"-"`
+	// Recurrence repeats the request: daily, weekly or a five field cron expression
+	// evaluated in the server time zone. A recurring item goes back to queued for
+	// its next occurrence after each one is sent or fails.
+	Recurrence string `json:"recurrence,omitempty"`
+	// ScheduledAt is when the current occurrence is due, nil for requests sent as soon as possible
+	ScheduledAt   *time.Time `json:"scheduled_at,omitempty"`
+	Status        Status     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	MessageID     string     `json:"message_id,omitempty"` // WhatsApp message ID once sent
+	Error         string     `json:"error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// File is an uploaded file of a queued request staged on disk
+type File struct {
+	Field       string `json:"field"` // Form field of the request, e.g. image
+	Path        string `json:"path"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+}
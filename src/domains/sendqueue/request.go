@@ -0,0 +1,83 @@
+package sendqueue
+
+// Schedule sends a queued request later, once or repeatedly
+type Schedule struct {
+	SendAt     string `json:"send_at" form:"send_at"`       // RFC3339, empty to send as soon as possible
+	Recurrence string `json:"recurrence" form:"recurrence"` // daily, weekly or a five field cron expression
+}
+
+// EnqueueRequest puts a send request on the outbound queue of the device in context
+type EnqueueRequest struct {
+	Schedule
+	Kind string
+	// IdempotencyKey makes retried requests return the item queued first
+	// instead of queueing the message again. Optional.
+	IdempotencyKey string
+	// Request is the domainSend request matching Kind, e.g. domainSend.MessageRequest
+	Request any
+}
+
+// EnqueueResponse is the queued item. Duplicate is true when the idempotency
+// key was seen before and nothing new was queued.
+type EnqueueResponse struct {
+	Item      *Item `json:"item"`
+	Duplicate bool  `json:"duplicate"`
+}
+
+type ListItemsRequest struct {
+	Status    Status `json:"status" query:"status"`       // Empty for every status
+	Scheduled bool   `json:"scheduled" query:"scheduled"` // Only items sent at a set time
+	Limit     int    `json:"limit" query:"limit"`
+	Offset    int    `json:"offset" query:"offset"`
+}
+
+type ListItemsResponse struct {
+	Items []*Item `json:"items"`
+	Total int     `json:"total"`
+}
+
+// RescheduleItemRequest moves a queued item to another time
+type RescheduleItemRequest struct {
+	ID     string `json:"-" uri:"queue_id"`
+	SendAt string `json:"send_at" form:"send_at"`
+	// Recurrence replaces the recurrence of the item when set, empty stops it repeating
+	Recurrence *string `json:"recurrence" form:"recurrence"`
+}
+
+// BulkRecipient is one recipient of a bulk send. Variables fill the [KEY]
+// placeholders of the text fields of the request, e.g. {"name": "Ann"} fills [NAME].
+type BulkRecipient struct {
+	Phone     string            `json:"phone"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// BulkRequest queues one send request for many recipients of the device in context
+type BulkRequest struct {
+	Kind       string          `json:"type" form:"type"`
+	Recipients []BulkRecipient `json:"recipients" form:"-"`
+	SendAt     string          `json:"send_at" form:"send_at"` // RFC3339, empty to start as soon as possible
+	// IntervalSeconds spaces consecutive recipients further apart than the queue rate does
+	IntervalSeconds int `json:"interval_seconds" form:"interval_seconds"`
+	// IdempotencyKey makes a retried bulk send return the batch queued first. Optional.
+	IdempotencyKey string `json:"-" form:"-"`
+	// Request is the domainSend request matching Kind, without a phone
+	Request any `json:"-" form:"-"`
+}
+
+// BulkResult is the outcome of one recipient of a bulk send
+type BulkResult struct {
+	Phone     string `json:"phone"`
+	QueueID   string `json:"queue_id,omitempty"`
+	Status    Status `json:"status"`
+	MessageID string `json:"message_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// BulkResponse reports every recipient of a bulk send in the order given.
+// Duplicate is true when the idempotency key was seen before.
+type BulkResponse struct {
+	BatchID   string         `json:"batch_id"`
+	Counts    map[Status]int `json:"counts"`
+	Results   []BulkResult   `json:"results"`
+	Duplicate bool           `json:"duplicate,omitempty"`
+}
@@ -39,8 +39,8 @@ func (r *Repository) InitializeSchema() error {
 	migrations := r.getMigrations()
 	for i, migration := range migrations {
 		if _, err := r.db.Exec(migration); err != nil {
-			// Ignore "already exists" errors for idempotent migrations
-			if !strings.Contains(err.Error(), "already exists") {
+			// Ignore "already exists" / "duplicate column" errors for idempotent migrations
+			if !strings.Contains(err.Error(), "already exists") && !strings.Contains(err.Error(), "duplicate column") {
 				return fmt.Errorf("migration %d failed: %w", i+1, err)
 			}
 		}
@@ -162,7 +162,143 @@ func (r *Repository) getMigrations() []string {
 		`CREATE INDEX IF NOT EXISTS idx_campaign_messages_device ON campaign_messages(device_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_campaign_messages_status ON campaign_messages(status)`,
 		`CREATE INDEX IF NOT EXISTS idx_campaign_messages_campaign ON campaign_messages(campaign_id)`,
+
+		// Migration 11: Validation tracking and history
+		`ALTER TABLE campaign_customers ADD COLUMN validated_at TIMESTAMP`,
+		`ALTER TABLE campaign_customers ADD COLUMN send_failure_count INTEGER DEFAULT 0`,
+		`CREATE TABLE IF NOT EXISTS campaign_customer_validations (
+			id VARCHAR(36) PRIMARY KEY,
+			customer_id VARCHAR(36) NOT NULL,
+			phone_valid VARCHAR(20) NOT NULL,
+			whatsapp_exists VARCHAR(20) NOT NULL,
+			source VARCHAR(20) NOT NULL,
+			reason TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_campaign_customer_validations_customer ON campaign_customer_validations(customer_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_campaign_customers_validated_at ON campaign_customers(validated_at)`,
+
+		// Migration 12: Multi-device sender pools
+		`CREATE TABLE IF NOT EXISTS campaign_devices (
+			campaign_id VARCHAR(36) NOT NULL,
+			device_id VARCHAR(255) NOT NULL,
+			hourly_limit INTEGER DEFAULT 0,
+			daily_limit INTEGER DEFAULT 0,
+			PRIMARY KEY (campaign_id, device_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS campaign_customer_senders (
+			customer_id VARCHAR(36) PRIMARY KEY,
+			device_id VARCHAR(255) NOT NULL,
+			assigned_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`ALTER TABLE campaign_messages ADD COLUMN sender_device_id VARCHAR(255)`,
+		`CREATE INDEX IF NOT EXISTS idx_campaign_messages_sender ON campaign_messages(sender_device_id)`,
+
+		// Migration 13: Drip sequences
+		`CREATE TABLE IF NOT EXISTS campaign_steps (
+			id VARCHAR(36) PRIMARY KEY,
+			campaign_id VARCHAR(36) NOT NULL,
+			position INTEGER NOT NULL,
+			template_id VARCHAR(36) NOT NULL,
+			delay_minutes INTEGER DEFAULT 0,
+			condition_type VARCHAR(30) NOT NULL DEFAULT 'no_reply',
+			keyword VARCHAR(255),
+			UNIQUE(campaign_id, position)
+		)`,
+		`CREATE TABLE IF NOT EXISTS campaign_enrollments (
+			campaign_id VARCHAR(36) NOT NULL,
+			customer_id VARCHAR(36) NOT NULL,
+			device_id VARCHAR(255) NOT NULL,
+			sender_device_id VARCHAR(255),
+			phone VARCHAR(50) NOT NULL,
+			current_step INTEGER DEFAULT 0,
+			next_step_at TIMESTAMP,
+			status VARCHAR(20) NOT NULL DEFAULT 'active',
+			stop_reason VARCHAR(50),
+			last_reply TEXT,
+			replied_at TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (campaign_id, customer_id)
+		)`,
+		// Follow-ups live beside campaign_messages, which only allows one row per customer
+		`CREATE TABLE IF NOT EXISTS campaign_followups (
+			id VARCHAR(36) PRIMARY KEY,
+			campaign_id VARCHAR(36) NOT NULL,
+			customer_id VARCHAR(36) NOT NULL,
+			step INTEGER NOT NULL,
+			device_id VARCHAR(255) NOT NULL,
+			sender_device_id VARCHAR(255),
+			phone VARCHAR(50) NOT NULL,
+			message TEXT NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			error TEXT,
+			sent_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(campaign_id, customer_id, step)
+		)`,
+		`ALTER TABLE campaign_customers ADD COLUMN opted_out_at TIMESTAMP`,
+		`CREATE INDEX IF NOT EXISTS idx_campaign_enrollments_due ON campaign_enrollments(status, next_step_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_campaign_enrollments_phone ON campaign_enrollments(phone)`,
+		`CREATE INDEX IF NOT EXISTS idx_campaign_followups_sender ON campaign_followups(sender_device_id, status)`,
+
+		// Migration 14: Customer tags and notes
+		`CREATE TABLE IF NOT EXISTS campaign_tags (
+			id VARCHAR(36) PRIMARY KEY,
+			device_id VARCHAR(255) NOT NULL,
+			name VARCHAR(100) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(device_id, name)
+		)`,
+		`CREATE TABLE IF NOT EXISTS campaign_customer_tags (
+			customer_id VARCHAR(36) NOT NULL,
+			tag_id VARCHAR(36) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (customer_id, tag_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS campaign_customer_notes (
+			id VARCHAR(36) PRIMARY KEY,
+			customer_id VARCHAR(36) NOT NULL,
+			content TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS campaign_target_tags (
+			campaign_id VARCHAR(36) NOT NULL,
+			tag_name VARCHAR(100) NOT NULL,
+			PRIMARY KEY (campaign_id, tag_name)
+		)`,
+		`ALTER TABLE campaign_short_urls ADD COLUMN customer_id VARCHAR(36)`,
+		`CREATE INDEX IF NOT EXISTS idx_campaign_customer_tags_tag ON campaign_customer_tags(tag_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_campaign_customer_notes_customer ON campaign_customer_notes(customer_id)`,
+	}
+}
+
+// customerColumns is the column list matching scanCustomer
+const customerColumns = `id, device_id, phone, full_name, company, country, gender, birth_year, phone_valid, whatsapp_exists,
+	validated_at, COALESCE(send_failure_count, 0), opted_out_at, created_at, updated_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanCustomer scans a row selected with customerColumns
+func scanCustomer(row rowScanner) (*domainCampaign.Customer, error) {
+	customer := &domainCampaign.Customer{}
+	var idStr string
+	var phoneValid, whatsappExists string
+	if err := row.Scan(&idStr, &customer.DeviceID, &customer.Phone, &customer.FullName,
+		&customer.Company, &customer.Country, &customer.Gender, &customer.BirthYear,
+		&phoneValid, &whatsappExists, &customer.ValidatedAt, &customer.SendFailures,
+		&customer.OptedOutAt, &customer.CreatedAt, &customer.UpdatedAt); err != nil {
+		return nil, err
 	}
+	customer.ID, _ = uuid.Parse(idStr)
+	customer.PhoneValid = domainCampaign.ValidationStatus(phoneValid)
+	customer.WhatsAppExists = domainCampaign.ValidationStatus(whatsappExists)
+	customer.IsReady = customer.PhoneValid == domainCampaign.ValidationStatusValid &&
+		customer.WhatsAppExists == domainCampaign.ValidationStatusValid
+	return customer, nil
 }
 
 // ============================================================================
@@ -186,116 +322,71 @@ func (r *Repository) CreateCustomer(ctx context.Context, customer *domainCampaig
 }
 
 func (r *Repository) GetCustomer(ctx context.Context, deviceID string, id uuid.UUID) (*domainCampaign.Customer, error) {
-	customer := &domainCampaign.Customer{}
-	var idStr string
-	var phoneValid, whatsappExists string
-	err := r.db.QueryRowContext(ctx, `
-		SELECT id, device_id, phone, full_name, company, country, gender, birth_year, phone_valid, whatsapp_exists, created_at, updated_at
+	customer, err := scanCustomer(r.db.QueryRowContext(ctx, `
+		SELECT `+customerColumns+`
 		FROM campaign_customers WHERE id = $1 AND device_id = $2
-	`, id.String(), deviceID).Scan(&idStr, &customer.DeviceID, &customer.Phone, &customer.FullName,
-		&customer.Company, &customer.Country, &customer.Gender, &customer.BirthYear,
-		&phoneValid, &whatsappExists, &customer.CreatedAt, &customer.UpdatedAt)
+	`, id.String(), deviceID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	customer.ID, _ = uuid.Parse(idStr)
-	customer.PhoneValid = domainCampaign.ValidationStatus(phoneValid)
-	customer.WhatsAppExists = domainCampaign.ValidationStatus(whatsappExists)
-	customer.IsReady = customer.PhoneValid == domainCampaign.ValidationStatusValid &&
-		customer.WhatsAppExists == domainCampaign.ValidationStatusValid
 	return customer, nil
 }
 
 func (r *Repository) GetCustomerByPhone(ctx context.Context, deviceID string, phone string) (*domainCampaign.Customer, error) {
-	customer := &domainCampaign.Customer{}
-	var idStr string
-	var phoneValid, whatsappExists string
-	err := r.db.QueryRowContext(ctx, `
-		SELECT id, device_id, phone, full_name, company, country, gender, birth_year, phone_valid, whatsapp_exists, created_at, updated_at
+	customer, err := scanCustomer(r.db.QueryRowContext(ctx, `
+		SELECT `+customerColumns+`
 		FROM campaign_customers WHERE phone = $1 AND device_id = $2
-	`, phone, deviceID).Scan(&idStr, &customer.DeviceID, &customer.Phone, &customer.FullName,
-		&customer.Company, &customer.Country, &customer.Gender, &customer.BirthYear,
-		&phoneValid, &whatsappExists, &customer.CreatedAt, &customer.UpdatedAt)
+	`, phone, deviceID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	customer.ID, _ = uuid.Parse(idStr)
-	customer.PhoneValid = domainCampaign.ValidationStatus(phoneValid)
-	customer.WhatsAppExists = domainCampaign.ValidationStatus(whatsappExists)
-	customer.IsReady = customer.PhoneValid == domainCampaign.ValidationStatusValid &&
-		customer.WhatsAppExists == domainCampaign.ValidationStatusValid
 	return customer, nil
 }
 
-func (r *Repository) ListCustomers(ctx context.Context, deviceID string, limit, offset int, search string, filterGroupID *uuid.UUID, filterType string) ([]*domainCampaign.Customer, int, error) {
-	// Build query
-	var countQuery, selectQuery string
-	var args []interface{}
+func (r *Repository) ListCustomers(ctx context.Context, deviceID string, limit, offset int, search string, filterGroupID *uuid.UUID, filterType, filterTag string) ([]*domainCampaign.Customer, int, error) {
+	where := `c.device_id = $1`
+	args := []interface{}{deviceID}
 
-	// JOIN logic based on filter
+	// Group filter
 	if filterGroupID != nil {
-		if filterType == "member" {
-			countQuery = `SELECT COUNT(*) FROM campaign_customers c 
-				JOIN campaign_group_members gm ON c.id = gm.customer_id 
-				WHERE c.device_id = $1 AND gm.group_id = $2`
-			selectQuery = `SELECT c.id, c.device_id, c.phone, c.full_name, c.company, c.country, c.gender, c.birth_year, c.phone_valid, c.whatsapp_exists, c.created_at, c.updated_at
-				FROM campaign_customers c 
-				JOIN campaign_group_members gm ON c.id = gm.customer_id 
-				WHERE c.device_id = $1 AND gm.group_id = $2`
-			args = []interface{}{deviceID, filterGroupID.String()}
-		} else if filterType == "non_member" {
-			countQuery = `SELECT COUNT(*) FROM campaign_customers c 
-				WHERE c.device_id = $1 AND c.id NOT IN (
-					SELECT customer_id FROM campaign_group_members WHERE group_id = $2
-				)`
-			selectQuery = `SELECT c.id, c.device_id, c.phone, c.full_name, c.company, c.country, c.gender, c.birth_year, c.phone_valid, c.whatsapp_exists, c.created_at, c.updated_at
-				FROM campaign_customers c 
-				WHERE c.device_id = $1 AND c.id NOT IN (
-					SELECT customer_id FROM campaign_group_members WHERE group_id = $2
-				)`
-			args = []interface{}{deviceID, filterGroupID.String()}
-		} else {
-			// fallback/all
-			countQuery = `SELECT COUNT(*) FROM campaign_customers WHERE device_id = $1`
-			selectQuery = `SELECT id, device_id, phone, full_name, company, country, gender, birth_year, phone_valid, whatsapp_exists, created_at, updated_at
-				FROM campaign_customers WHERE device_id = $1`
-			args = []interface{}{deviceID}
+		switch filterType {
+		case "member":
+			args = append(args, filterGroupID.String())
+			where += fmt.Sprintf(` AND c.id IN (SELECT customer_id FROM campaign_group_members WHERE group_id = $%d)`, len(args))
+		case "non_member":
+			args = append(args, filterGroupID.String())
+			where += fmt.Sprintf(` AND c.id NOT IN (SELECT customer_id FROM campaign_group_members WHERE group_id = $%d)`, len(args))
 		}
-	} else {
-		countQuery = `SELECT COUNT(*) FROM campaign_customers WHERE device_id = $1`
-		selectQuery = `SELECT id, device_id, phone, full_name, company, country, gender, birth_year, phone_valid, whatsapp_exists, created_at, updated_at
-			FROM campaign_customers WHERE device_id = $1`
-		args = []interface{}{deviceID}
 	}
 
-	if search != "" {
-		// PostgreSQL ILIKE for case-insensitive search
-		likeQuery := ""
-		// Adjust args index for search query
-		paramIdx := len(args) + 1
-		likeQuery = fmt.Sprintf(" AND (c.phone ILIKE $%d OR c.full_name ILIKE $%d)", paramIdx, paramIdx)
-
-		// If basic query, we might need alias adjustment or not using alias
-		if filterGroupID == nil {
-			likeQuery = fmt.Sprintf(" AND (phone ILIKE $%d OR full_name ILIKE $%d)", paramIdx, paramIdx)
-		}
+	// Tag filter
+	if filterTag != "" {
+		args = append(args, normalizeTag(filterTag))
+		where += fmt.Sprintf(` AND c.id IN (
+			SELECT ct.customer_id FROM campaign_customer_tags ct
+			JOIN campaign_tags t ON t.id = ct.tag_id
+			WHERE t.device_id = c.device_id AND t.name = $%d)`, len(args))
+	}
 
-		countQuery += likeQuery
-		selectQuery += likeQuery
+	if search != "" {
+		// PostgreSQL ILIKE for case-insensitive search; tags match too
 		args = append(args, "%"+search+"%")
+		paramIdx := len(args)
+		where += fmt.Sprintf(` AND (c.phone ILIKE $%d OR c.full_name ILIKE $%d OR c.company ILIKE $%d OR c.id IN (
+			SELECT ct.customer_id FROM campaign_customer_tags ct
+			JOIN campaign_tags t ON t.id = ct.tag_id
+			WHERE t.device_id = c.device_id AND t.name ILIKE $%d))`, paramIdx, paramIdx, paramIdx, paramIdx)
 	}
 
-	selectQuery += ` ORDER BY c.created_at DESC LIMIT $` + fmt.Sprintf("%d", len(args)+1) + ` OFFSET $` + fmt.Sprintf("%d", len(args)+2)
-	// If basic query, remove alias from ORDER BY if table not aliased
-	if filterGroupID == nil {
-		selectQuery = strings.Replace(selectQuery, "ORDER BY c.created_at", "ORDER BY created_at", 1)
-	}
+	countQuery := `SELECT COUNT(*) FROM campaign_customers c WHERE ` + where
+	selectQuery := `SELECT ` + customerColumns + ` FROM campaign_customers c WHERE ` + where +
+		fmt.Sprintf(` ORDER BY c.created_at DESC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
 
 	// Get total count
 	var total int
@@ -309,26 +400,11 @@ func (r *Repository) ListCustomers(ctx context.Context, deviceID string, limit,
 	if err != nil {
 		return nil, 0, err
 	}
-	defer rows.Close()
-
-	var customers []*domainCampaign.Customer
-	for rows.Next() {
-		customer := &domainCampaign.Customer{}
-		var idStr string
-		var phoneValid, whatsappExists string
-		if err := rows.Scan(&idStr, &customer.DeviceID, &customer.Phone, &customer.FullName,
-			&customer.Company, &customer.Country, &customer.Gender, &customer.BirthYear,
-			&phoneValid, &whatsappExists, &customer.CreatedAt, &customer.UpdatedAt); err != nil {
-			return nil, 0, err
-		}
-		customer.ID, _ = uuid.Parse(idStr)
-		customer.PhoneValid = domainCampaign.ValidationStatus(phoneValid)
-		customer.WhatsAppExists = domainCampaign.ValidationStatus(whatsappExists)
-		customer.IsReady = customer.PhoneValid == domainCampaign.ValidationStatusValid &&
-			customer.WhatsAppExists == domainCampaign.ValidationStatusValid
-		customers = append(customers, customer)
+	customers, err := r.collectCustomers(rows)
+	if err != nil {
+		return nil, 0, err
 	}
-	return customers, total, rows.Err()
+	return customers, total, nil
 }
 
 func (r *Repository) UpdateCustomer(ctx context.Context, customer *domainCampaign.Customer) error {
@@ -817,7 +893,12 @@ func (r *Repository) DeleteCampaign(ctx context.Context, deviceID string, id uui
 	// Delete targets and messages
 	_, _ = tx.ExecContext(ctx, `DELETE FROM campaign_target_customers WHERE campaign_id = $1`, id.String())
 	_, _ = tx.ExecContext(ctx, `DELETE FROM campaign_target_groups WHERE campaign_id = $1`, id.String())
+	_, _ = tx.ExecContext(ctx, `DELETE FROM campaign_target_tags WHERE campaign_id = $1`, id.String())
 	_, _ = tx.ExecContext(ctx, `DELETE FROM campaign_messages WHERE campaign_id = $1`, id.String())
+	_, _ = tx.ExecContext(ctx, `DELETE FROM campaign_devices WHERE campaign_id = $1`, id.String())
+	_, _ = tx.ExecContext(ctx, `DELETE FROM campaign_steps WHERE campaign_id = $1`, id.String())
+	_, _ = tx.ExecContext(ctx, `DELETE FROM campaign_enrollments WHERE campaign_id = $1`, id.String())
+	_, _ = tx.ExecContext(ctx, `DELETE FROM campaign_followups WHERE campaign_id = $1`, id.String())
 	_, err = tx.ExecContext(ctx, `DELETE FROM campaigns WHERE id = $1 AND device_id = $2`, id.String(), deviceID)
 	if err != nil {
 		return err
@@ -909,9 +990,9 @@ func (r *Repository) GetCampaignTargetIDs(ctx context.Context, campaignID uuid.U
 }
 
 func (r *Repository) GetCampaignTargetCustomers(ctx context.Context, campaignID uuid.UUID) ([]*domainCampaign.Customer, error) {
-	// Get directly targeted customers + customers from targeted groups
+	// Get directly targeted customers + customers from targeted groups + customers with targeted tags
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT DISTINCT c.id, c.device_id, c.phone, c.full_name, c.country, c.gender, c.birth_year, c.created_at, c.updated_at
+		SELECT DISTINCT `+customerColumns+`
 		FROM campaign_customers c
 		WHERE c.id IN (
 			SELECT customer_id FROM campaign_target_customers WHERE campaign_id = $1
@@ -919,25 +1000,62 @@ func (r *Repository) GetCampaignTargetCustomers(ctx context.Context, campaignID
 			SELECT gm.customer_id FROM campaign_group_members gm
 			INNER JOIN campaign_target_groups tg ON gm.group_id = tg.group_id
 			WHERE tg.campaign_id = $2
+			UNION
+			SELECT ct.customer_id FROM campaign_customer_tags ct
+			INNER JOIN campaign_tags t ON ct.tag_id = t.id
+			INNER JOIN campaign_target_tags tt ON tt.tag_name = t.name
+			INNER JOIN campaigns cp ON cp.id = tt.campaign_id AND cp.device_id = t.device_id
+			WHERE tt.campaign_id = $3
 		)
-	`, campaignID.String(), campaignID.String())
+	`, campaignID.String(), campaignID.String(), campaignID.String())
+	if err != nil {
+		return nil, err
+	}
+	return r.collectCustomers(rows)
+}
+
+func (r *Repository) SetCampaignTargetTags(ctx context.Context, campaignID uuid.UUID, tags []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM campaign_target_tags WHERE campaign_id = $1`, campaignID.String())
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO campaign_target_tags (campaign_id, tag_name) VALUES ($1, $2) ON CONFLICT DO NOTHING
+		`, campaignID.String(), normalizeTag(tag))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) GetCampaignTargetTags(ctx context.Context, campaignID uuid.UUID) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tag_name FROM campaign_target_tags WHERE campaign_id = $1 ORDER BY tag_name ASC
+	`, campaignID.String())
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var customers []*domainCampaign.Customer
+	var tags []string
 	for rows.Next() {
-		customer := &domainCampaign.Customer{}
-		var idStr string
-		if err := rows.Scan(&idStr, &customer.DeviceID, &customer.Phone, &customer.FullName,
-			&customer.Country, &customer.Gender, &customer.BirthYear, &customer.CreatedAt, &customer.UpdatedAt); err != nil {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
 			return nil, err
 		}
-		customer.ID, _ = uuid.Parse(idStr)
-		customers = append(customers, customer)
+		tags = append(tags, tag)
 	}
-	return customers, rows.Err()
+	return tags, rows.Err()
 }
 
 func (r *Repository) GetCampaignStats(ctx context.Context, campaignID uuid.UUID) (*domainCampaign.CampaignStats, error) {
@@ -956,184 +1074,892 @@ func (r *Repository) GetCampaignStats(ctx context.Context, campaignID uuid.UUID)
 	return stats, nil
 }
 
-// ============================================================================
-// Queue Operations
-// ============================================================================
-
-func (r *Repository) EnqueueMessages(ctx context.Context, items []*domainCampaign.QueueItem) error {
-	if len(items) == 0 {
-		return nil
-	}
-
+func (r *Repository) SetCampaignDevices(ctx context.Context, campaignID uuid.UUID, devices []domainCampaign.CampaignDevice) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO campaign_messages (id, campaign_id, customer_id, device_id, phone, message, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		ON CONFLICT(campaign_id, customer_id) DO NOTHING
-	`)
+	_, err = tx.ExecContext(ctx, `DELETE FROM campaign_devices WHERE campaign_id = $1`, campaignID.String())
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
 
-	now := time.Now()
-	for _, item := range items {
-		item.ID = uuid.New()
-		item.Status = domainCampaign.MessageStatusPending
-		item.CreatedAt = now
-		item.UpdatedAt = now
-		_, _ = stmt.ExecContext(ctx, item.ID.String(), item.CampaignID.String(), item.CustomerID.String(),
-			item.DeviceID, item.Phone, item.Message, string(item.Status), item.CreatedAt, item.UpdatedAt)
+	if len(devices) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO campaign_devices (campaign_id, device_id, hourly_limit, daily_limit) VALUES ($1, $2, $3, $4)
+		`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, device := range devices {
+			if _, err := stmt.ExecContext(ctx, campaignID.String(), device.DeviceID, device.HourlyLimit, device.DailyLimit); err != nil {
+				return err
+			}
+		}
 	}
 
 	return tx.Commit()
 }
 
-func (r *Repository) GetPendingMessages(ctx context.Context, deviceID string, limit int) ([]*domainCampaign.QueueItem, error) {
+func (r *Repository) GetCampaignDevices(ctx context.Context, campaignID uuid.UUID) ([]domainCampaign.CampaignDevice, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT m.id, m.campaign_id, m.customer_id, m.device_id, m.phone, m.message, m.status, m.error, m.sent_at, m.created_at, m.updated_at
-		FROM campaign_messages m
-		INNER JOIN campaigns c ON m.campaign_id = c.id
-		WHERE m.device_id = $1 AND m.status = 'pending' AND c.status = 'running'
-		ORDER BY m.created_at ASC
-		LIMIT $2
-	`, deviceID, limit)
+		SELECT device_id, COALESCE(hourly_limit, 0), COALESCE(daily_limit, 0)
+		FROM campaign_devices WHERE campaign_id = $1 ORDER BY device_id ASC
+	`, campaignID.String())
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var items []*domainCampaign.QueueItem
+	var devices []domainCampaign.CampaignDevice
 	for rows.Next() {
-		item := &domainCampaign.QueueItem{}
-		var idStr, campaignIDStr, customerIDStr, status string
-		if err := rows.Scan(&idStr, &campaignIDStr, &customerIDStr, &item.DeviceID, &item.Phone,
-			&item.Message, &status, &item.Error, &item.SentAt, &item.CreatedAt, &item.UpdatedAt); err != nil {
+		var device domainCampaign.CampaignDevice
+		if err := rows.Scan(&device.DeviceID, &device.HourlyLimit, &device.DailyLimit); err != nil {
 			return nil, err
 		}
-		item.ID, _ = uuid.Parse(idStr)
-		item.CampaignID, _ = uuid.Parse(campaignIDStr)
-		item.CustomerID, _ = uuid.Parse(customerIDStr)
-		item.Status = domainCampaign.MessageStatus(status)
-		items = append(items, item)
+		devices = append(devices, device)
 	}
-	return items, rows.Err()
+	return devices, rows.Err()
 }
 
-func (r *Repository) UpdateMessageStatus(ctx context.Context, id uuid.UUID, status domainCampaign.MessageStatus, errorMsg *string) error {
-	now := time.Now()
-	var sentAt *time.Time
-	if status == domainCampaign.MessageStatusSent {
-		sentAt = &now
+func (r *Repository) SetCampaignSteps(ctx context.Context, campaignID uuid.UUID, steps []domainCampaign.CampaignStep) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
 	}
-	_, err := r.db.ExecContext(ctx, `
-		UPDATE campaign_messages SET status = $1, error = $2, sent_at = $3, updated_at = $4
-		WHERE id = $5
-	`, string(status), errorMsg, sentAt, now, id.String())
-	return err
-}
-
-func (r *Repository) IsMessageQueued(ctx context.Context, campaignID, customerID uuid.UUID) (bool, error) {
-	var count int
-	err := r.db.QueryRowContext(ctx, `
-		SELECT COUNT(*) FROM campaign_messages WHERE campaign_id = $1 AND customer_id = $2
-	`, campaignID.String(), customerID.String()).Scan(&count)
-	return count > 0, err
-}
+	defer tx.Rollback()
 
-// ============================================================================
-// Short URL Operations
-// ============================================================================
+	_, err = tx.ExecContext(ctx, `DELETE FROM campaign_steps WHERE campaign_id = $1`, campaignID.String())
+	if err != nil {
+		return err
+	}
 
-func (r *Repository) CreateShortURL(ctx context.Context, shortURL *domainCampaign.ShortURL) error {
-	shortURL.ID = uuid.New()
-	shortURL.CreatedAt = time.Now()
-	shortURL.Clicks = 0
+	if len(steps) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO campaign_steps (id, campaign_id, position, template_id, delay_minutes, condition_type, keyword)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for i := range steps {
+			steps[i].ID = uuid.New()
+			steps[i].CampaignID = campaignID
+			steps[i].Position = i + 1
+			if _, err := stmt.ExecContext(ctx, steps[i].ID.String(), campaignID.String(), steps[i].Position,
+				steps[i].TemplateID.String(), steps[i].DelayMinutes, string(steps[i].Condition), steps[i].Keyword); err != nil {
+				return err
+			}
+		}
+	}
 
-	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO campaign_short_urls (id, device_id, code, original_url, clicks, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, shortURL.ID.String(), shortURL.DeviceID, shortURL.Code, shortURL.OriginalURL, shortURL.Clicks, shortURL.CreatedAt)
-	return err
+	return tx.Commit()
 }
 
-func (r *Repository) GetShortURLByCode(ctx context.Context, code string) (*domainCampaign.ShortURL, error) {
-	shortURL := &domainCampaign.ShortURL{}
-	var idStr string
-	err := r.db.QueryRowContext(ctx, `
-		SELECT id, device_id, code, original_url, clicks, created_at
-		FROM campaign_short_urls WHERE code = $1
-	`, code).Scan(&idStr, &shortURL.DeviceID, &shortURL.Code, &shortURL.OriginalURL, &shortURL.Clicks, &shortURL.CreatedAt)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
+func (r *Repository) GetCampaignSteps(ctx context.Context, campaignID uuid.UUID) ([]domainCampaign.CampaignStep, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, position, template_id, COALESCE(delay_minutes, 0), condition_type, keyword
+		FROM campaign_steps WHERE campaign_id = $1 ORDER BY position ASC
+	`, campaignID.String())
 	if err != nil {
 		return nil, err
 	}
-	shortURL.ID, _ = uuid.Parse(idStr)
-	return shortURL, nil
-}
+	defer rows.Close()
 
-func (r *Repository) IncrementShortURLClicks(ctx context.Context, code string) error {
-	_, err := r.db.ExecContext(ctx, `UPDATE campaign_short_urls SET clicks = clicks + 1 WHERE code = $1`, code)
-	return err
+	var steps []domainCampaign.CampaignStep
+	for rows.Next() {
+		step := domainCampaign.CampaignStep{CampaignID: campaignID}
+		var idStr, templateIDStr, condition string
+		if err := rows.Scan(&idStr, &step.Position, &templateIDStr, &step.DelayMinutes, &condition, &step.Keyword); err != nil {
+			return nil, err
+		}
+		step.ID, _ = uuid.Parse(idStr)
+		step.TemplateID, _ = uuid.Parse(templateIDStr)
+		step.Condition = domainCampaign.StepCondition(condition)
+		steps = append(steps, step)
+	}
+	return steps, rows.Err()
 }
 
 // ============================================================================
-// Validation Operations
+// Drip Sequence Operations
 // ============================================================================
 
-func (r *Repository) GetCustomersForValidation(ctx context.Context, deviceID string, limit int) ([]*domainCampaign.Customer, error) {
-	rows, err := r.db.QueryContext(ctx, `
-		SELECT id, device_id, phone, full_name, company, country, gender, birth_year, 
-			   phone_valid, whatsapp_exists, created_at, updated_at
-		FROM campaign_customers 
-		WHERE device_id = $1 AND (phone_valid = 'pending' OR whatsapp_exists = 'pending')
-		ORDER BY created_at ASC
-		LIMIT $2
-	`, deviceID, limit)
-	if err != nil {
+const enrollmentColumns = `e.campaign_id, e.customer_id, e.device_id, COALESCE(e.sender_device_id, e.device_id), e.phone,
+	COALESCE(e.current_step, 0), e.next_step_at, e.status, e.stop_reason, e.last_reply, e.replied_at, e.updated_at`
+
+func scanEnrollment(row rowScanner) (*domainCampaign.Enrollment, error) {
+	enrollment := &domainCampaign.Enrollment{}
+	var campaignIDStr, customerIDStr, status string
+	if err := row.Scan(&campaignIDStr, &customerIDStr, &enrollment.DeviceID, &enrollment.SenderDeviceID, &enrollment.Phone,
+		&enrollment.CurrentStep, &enrollment.NextStepAt, &status, &enrollment.StopReason, &enrollment.LastReply,
+		&enrollment.RepliedAt, &enrollment.UpdatedAt); err != nil {
+		return nil, err
+	}
+	enrollment.CampaignID, _ = uuid.Parse(campaignIDStr)
+	enrollment.CustomerID, _ = uuid.Parse(customerIDStr)
+	enrollment.Status = domainCampaign.EnrollmentStatus(status)
+	return enrollment, nil
+}
+
+func (r *Repository) queryEnrollments(ctx context.Context, query string, args ...any) ([]*domainCampaign.Enrollment, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var customers []*domainCampaign.Customer
+	var enrollments []*domainCampaign.Enrollment
 	for rows.Next() {
-		customer := &domainCampaign.Customer{}
+		enrollment, err := scanEnrollment(rows)
+		if err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, enrollment)
+	}
+	return enrollments, rows.Err()
+}
+
+// SaveEnrollment creates or advances an enrollment. Stopped enrollments are left untouched
+// so a follow-up that was already in flight cannot revive a sequence the customer ended.
+func (r *Repository) SaveEnrollment(ctx context.Context, enrollment *domainCampaign.Enrollment) error {
+	enrollment.UpdatedAt = time.Now()
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO campaign_enrollments (campaign_id, customer_id, device_id, sender_device_id, phone,
+			current_step, next_step_at, status, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT(campaign_id, customer_id) DO UPDATE SET
+			sender_device_id = excluded.sender_device_id,
+			current_step = excluded.current_step,
+			next_step_at = excluded.next_step_at,
+			status = excluded.status,
+			updated_at = excluded.updated_at
+		WHERE campaign_enrollments.status = 'active'
+	`, enrollment.CampaignID.String(), enrollment.CustomerID.String(), enrollment.DeviceID, enrollment.SenderDeviceID,
+		enrollment.Phone, enrollment.CurrentStep, enrollment.NextStepAt, string(enrollment.Status), enrollment.UpdatedAt)
+	return err
+}
+
+// GetDueEnrollments returns active enrollments of running campaigns whose next step is due
+func (r *Repository) GetDueEnrollments(ctx context.Context, now time.Time, limit int) ([]*domainCampaign.Enrollment, error) {
+	return r.queryEnrollments(ctx, `
+		SELECT `+enrollmentColumns+`
+		FROM campaign_enrollments e
+		INNER JOIN campaigns c ON e.campaign_id = c.id
+		WHERE e.status = 'active' AND e.next_step_at IS NOT NULL AND e.next_step_at <= $1 AND c.status = 'running'
+		ORDER BY e.next_step_at ASC
+		LIMIT $2
+	`, now, limit)
+}
+
+// GetEnrollment returns the sequence of a customer in a campaign, or nil when it has none
+func (r *Repository) GetEnrollment(ctx context.Context, campaignID, customerID uuid.UUID) (*domainCampaign.Enrollment, error) {
+	enrollments, err := r.queryEnrollments(ctx, `
+		SELECT `+enrollmentColumns+`
+		FROM campaign_enrollments e
+		WHERE e.campaign_id = $1 AND e.customer_id = $2
+	`, campaignID.String(), customerID.String())
+	if err != nil || len(enrollments) == 0 {
+		return nil, err
+	}
+	return enrollments[0], nil
+}
+
+// GetActiveEnrollmentsByPhone returns the running sequences of the phone that the
+// device owns or sends
+func (r *Repository) GetActiveEnrollmentsByPhone(ctx context.Context, deviceID, phone string) ([]*domainCampaign.Enrollment, error) {
+	return r.queryEnrollments(ctx, `
+		SELECT `+enrollmentColumns+`
+		FROM campaign_enrollments e
+		WHERE e.phone = $1 AND e.status = 'active' AND (e.device_id = $2 OR COALESCE(e.sender_device_id, e.device_id) = $2)
+	`, phone, deviceID)
+}
+
+// RecordEnrollmentReply stores the customer's latest reply and reschedules the next step
+func (r *Repository) RecordEnrollmentReply(ctx context.Context, campaignID, customerID uuid.UUID, reply string, nextStepAt *time.Time) error {
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE campaign_enrollments SET last_reply = $1, replied_at = $2, next_step_at = $3, updated_at = $4
+		WHERE campaign_id = $5 AND customer_id = $6 AND status = 'active'
+	`, reply, now, nextStepAt, now, campaignID.String(), customerID.String())
+	return err
+}
+
+// StopEnrollment ends a customer's sequence and drops any follow-up still waiting in the queue
+func (r *Repository) StopEnrollment(ctx context.Context, campaignID, customerID uuid.UUID, reason string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, `
+		UPDATE campaign_enrollments SET status = 'stopped', stop_reason = $1, next_step_at = NULL, updated_at = $2
+		WHERE campaign_id = $3 AND customer_id = $4 AND status = 'active'
+	`, reason, now, campaignID.String(), customerID.String())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM campaign_followups WHERE campaign_id = $1 AND customer_id = $2 AND status = 'pending'
+	`, campaignID.String(), customerID.String())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// EnqueueFollowUp queues a follow-up step and clears the enrollment's schedule so it is not picked up twice
+func (r *Repository) EnqueueFollowUp(ctx context.Context, item *domainCampaign.QueueItem) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	item.ID = uuid.New()
+	item.Status = domainCampaign.MessageStatusPending
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	if item.SenderDeviceID == "" {
+		item.SenderDeviceID = item.DeviceID
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO campaign_followups (id, campaign_id, customer_id, step, device_id, sender_device_id, phone, message, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT(campaign_id, customer_id, step) DO NOTHING
+	`, item.ID.String(), item.CampaignID.String(), item.CustomerID.String(), item.Step, item.DeviceID, item.SenderDeviceID,
+		item.Phone, item.Message, string(item.Status), item.CreatedAt, item.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE campaign_enrollments SET next_step_at = NULL, updated_at = $1
+		WHERE campaign_id = $2 AND customer_id = $3
+	`, now, item.CampaignID.String(), item.CustomerID.String())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ============================================================================
+// Sender Assignment Operations
+// ============================================================================
+
+// GetCustomerSender returns the device that first delivered a message to the customer, or "" if none
+func (r *Repository) GetCustomerSender(ctx context.Context, customerID uuid.UUID) (string, error) {
+	var deviceID string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT device_id FROM campaign_customer_senders WHERE customer_id = $1
+	`, customerID.String()).Scan(&deviceID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return deviceID, err
+}
+
+// SetCustomerSenderIfMissing records the sticky sender; an existing assignment is never overwritten
+func (r *Repository) SetCustomerSenderIfMissing(ctx context.Context, customerID uuid.UUID, deviceID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO campaign_customer_senders (customer_id, device_id, assigned_at)
+		VALUES ($1, $2, $3) ON CONFLICT DO NOTHING
+	`, customerID.String(), deviceID, time.Now())
+	return err
+}
+
+// ============================================================================
+// Queue Operations
+// ============================================================================
+
+func (r *Repository) EnqueueMessages(ctx context.Context, items []*domainCampaign.QueueItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO campaign_messages (id, campaign_id, customer_id, device_id, sender_device_id, phone, message, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT(campaign_id, customer_id) DO NOTHING
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, item := range items {
+		item.ID = uuid.New()
+		item.Status = domainCampaign.MessageStatusPending
+		item.CreatedAt = now
+		item.UpdatedAt = now
+		if item.SenderDeviceID == "" {
+			item.SenderDeviceID = item.DeviceID
+		}
+		_, _ = stmt.ExecContext(ctx, item.ID.String(), item.CampaignID.String(), item.CustomerID.String(),
+			item.DeviceID, item.SenderDeviceID, item.Phone, item.Message, string(item.Status), item.CreatedAt, item.UpdatedAt)
+	}
+
+	return tx.Commit()
+}
+
+// GetPendingMessages returns initial messages and follow-ups waiting on a sender device
+func (r *Repository) GetPendingMessages(ctx context.Context, deviceID string, limit int) ([]*domainCampaign.QueueItem, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT * FROM (
+			SELECT m.id, m.campaign_id, m.customer_id, m.device_id, COALESCE(m.sender_device_id, m.device_id) AS sender,
+				m.phone, m.message, 0 AS step, m.status, m.error, m.sent_at, m.created_at, m.updated_at
+			FROM campaign_messages m
+			INNER JOIN campaigns c ON m.campaign_id = c.id
+			WHERE COALESCE(m.sender_device_id, m.device_id) = $1 AND m.status = 'pending' AND c.status = 'running'
+			UNION ALL
+			SELECT f.id, f.campaign_id, f.customer_id, f.device_id, COALESCE(f.sender_device_id, f.device_id) AS sender,
+				f.phone, f.message, f.step, f.status, f.error, f.sent_at, f.created_at, f.updated_at
+			FROM campaign_followups f
+			INNER JOIN campaigns c ON f.campaign_id = c.id
+			WHERE COALESCE(f.sender_device_id, f.device_id) = $1 AND f.status = 'pending' AND c.status = 'running'
+		) q
+		ORDER BY created_at ASC
+		LIMIT $2
+	`, deviceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*domainCampaign.QueueItem
+	for rows.Next() {
+		item := &domainCampaign.QueueItem{}
+		var idStr, campaignIDStr, customerIDStr, status string
+		if err := rows.Scan(&idStr, &campaignIDStr, &customerIDStr, &item.DeviceID, &item.SenderDeviceID, &item.Phone,
+			&item.Message, &item.Step, &status, &item.Error, &item.SentAt, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		item.ID, _ = uuid.Parse(idStr)
+		item.CampaignID, _ = uuid.Parse(campaignIDStr)
+		item.CustomerID, _ = uuid.Parse(customerIDStr)
+		item.Status = domainCampaign.MessageStatus(status)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) UpdateMessageStatus(ctx context.Context, id uuid.UUID, status domainCampaign.MessageStatus, errorMsg *string) error {
+	now := time.Now()
+	var sentAt *time.Time
+	if status == domainCampaign.MessageStatusSent {
+		sentAt = &now
+	}
+	for _, table := range []string{"campaign_messages", "campaign_followups"} {
+		res, err := r.db.ExecContext(ctx, `
+			UPDATE `+table+` SET status = $1, error = $2, sent_at = $3, updated_at = $4
+			WHERE id = $5
+		`, string(status), errorMsg, sentAt, now, id.String())
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// UpdateMessageSender moves a queued message to another sender device and returns it to pending
+func (r *Repository) UpdateMessageSender(ctx context.Context, id uuid.UUID, senderDeviceID string) error {
+	for _, table := range []string{"campaign_messages", "campaign_followups"} {
+		res, err := r.db.ExecContext(ctx, `
+			UPDATE `+table+` SET sender_device_id = $1, status = 'pending', updated_at = $2
+			WHERE id = $3
+		`, senderDeviceID, time.Now(), id.String())
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// CountSentMessagesBySender counts messages sent by a device since the given time, across all campaigns
+func (r *Repository) CountSentMessagesBySender(ctx context.Context, senderDeviceID string, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM campaign_messages
+			 WHERE COALESCE(sender_device_id, device_id) = $1 AND status = 'sent' AND sent_at >= $2) +
+			(SELECT COUNT(*) FROM campaign_followups
+			 WHERE COALESCE(sender_device_id, device_id) = $1 AND status = 'sent' AND sent_at >= $2)
+	`, senderDeviceID, since).Scan(&count)
+	return count, err
+}
+
+func (r *Repository) IsMessageQueued(ctx context.Context, campaignID, customerID uuid.UUID) (bool, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM campaign_messages WHERE campaign_id = $1 AND customer_id = $2
+	`, campaignID.String(), customerID.String()).Scan(&count)
+	return count > 0, err
+}
+
+// ============================================================================
+// Tag and Note Operations
+// ============================================================================
+
+// normalizeTag trims and lowercases a tag name so "VIP" and " vip" are the same tag
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func nullableUUID(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+func (r *Repository) ListTags(ctx context.Context, deviceID string) ([]*domainCampaign.Tag, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.id, t.device_id, t.name, COUNT(ct.customer_id), t.created_at
+		FROM campaign_tags t
+		LEFT JOIN campaign_customer_tags ct ON ct.tag_id = t.id
+		WHERE t.device_id = $1
+		GROUP BY t.id, t.device_id, t.name, t.created_at
+		ORDER BY t.name ASC
+	`, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []*domainCampaign.Tag
+	for rows.Next() {
+		tag := &domainCampaign.Tag{}
 		var idStr string
-		var phoneValid, whatsappExists string
-		if err := rows.Scan(&idStr, &customer.DeviceID, &customer.Phone, &customer.FullName,
-			&customer.Company, &customer.Country, &customer.Gender, &customer.BirthYear,
-			&phoneValid, &whatsappExists, &customer.CreatedAt, &customer.UpdatedAt); err != nil {
+		if err := rows.Scan(&idStr, &tag.DeviceID, &tag.Name, &tag.CustomerCount, &tag.CreatedAt); err != nil {
+			return nil, err
+		}
+		tag.ID, _ = uuid.Parse(idStr)
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+func (r *Repository) DeleteTag(ctx context.Context, deviceID, name string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM campaign_customer_tags WHERE tag_id IN (
+			SELECT id FROM campaign_tags WHERE device_id = $1 AND name = $2
+		)
+	`, deviceID, normalizeTag(name))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM campaign_tags WHERE device_id = $1 AND name = $2`, deviceID, normalizeTag(name))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddCustomerTags attaches tags to a customer, creating tags that do not exist yet
+func (r *Repository) AddCustomerTags(ctx context.Context, deviceID string, customerID uuid.UUID, names []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, name := range names {
+		name = normalizeTag(name)
+		if name == "" {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO campaign_tags (id, device_id, name, created_at) VALUES ($1, $2, $3, $4)
+			ON CONFLICT(device_id, name) DO NOTHING
+		`, uuid.New().String(), deviceID, name, now)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO campaign_customer_tags (customer_id, tag_id, created_at)
+			SELECT $1, id, $2 FROM campaign_tags WHERE device_id = $3 AND name = $4
+			ON CONFLICT DO NOTHING
+		`, customerID.String(), now, deviceID, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) RemoveCustomerTag(ctx context.Context, deviceID string, customerID uuid.UUID, name string) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM campaign_customer_tags WHERE customer_id = $1 AND tag_id IN (
+			SELECT id FROM campaign_tags WHERE device_id = $2 AND name = $3
+		)
+	`, customerID.String(), deviceID, normalizeTag(name))
+	return err
+}
+
+// GetCustomersTags returns the tag names of each customer, keyed by customer ID
+func (r *Repository) GetCustomersTags(ctx context.Context, customerIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	result := make(map[uuid.UUID][]string)
+	if len(customerIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := make([]string, len(customerIDs))
+	args := make([]interface{}, len(customerIDs))
+	for i, id := range customerIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id.String()
+	}
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT ct.customer_id, t.name
+		FROM campaign_customer_tags ct
+		INNER JOIN campaign_tags t ON t.id = ct.tag_id
+		WHERE ct.customer_id IN (%s)
+		ORDER BY t.name ASC
+	`, strings.Join(placeholders, ",")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var customerIDStr, name string
+		if err := rows.Scan(&customerIDStr, &name); err != nil {
+			return nil, err
+		}
+		customerID, _ := uuid.Parse(customerIDStr)
+		result[customerID] = append(result[customerID], name)
+	}
+	return result, rows.Err()
+}
+
+func (r *Repository) CreateCustomerNote(ctx context.Context, note *domainCampaign.CustomerNote) error {
+	note.ID = uuid.New()
+	note.CreatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO campaign_customer_notes (id, customer_id, content, created_at) VALUES ($1, $2, $3, $4)
+	`, note.ID.String(), note.CustomerID.String(), note.Content, note.CreatedAt)
+	return err
+}
+
+func (r *Repository) ListCustomerNotes(ctx context.Context, customerID uuid.UUID) ([]*domainCampaign.CustomerNote, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, content, created_at FROM campaign_customer_notes
+		WHERE customer_id = $1 ORDER BY created_at DESC
+	`, customerID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []*domainCampaign.CustomerNote
+	for rows.Next() {
+		note := &domainCampaign.CustomerNote{CustomerID: customerID}
+		var idStr string
+		if err := rows.Scan(&idStr, &note.Content, &note.CreatedAt); err != nil {
+			return nil, err
+		}
+		note.ID, _ = uuid.Parse(idStr)
+		notes = append(notes, note)
+	}
+	return notes, rows.Err()
+}
+
+func (r *Repository) DeleteCustomerNote(ctx context.Context, customerID, noteID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM campaign_customer_notes WHERE id = $1 AND customer_id = $2
+	`, noteID.String(), customerID.String())
+	return err
+}
+
+// ============================================================================
+// Short URL Operations
+// ============================================================================
+
+func (r *Repository) CreateShortURL(ctx context.Context, shortURL *domainCampaign.ShortURL) error {
+	shortURL.ID = uuid.New()
+	shortURL.CreatedAt = time.Now()
+	shortURL.Clicks = 0
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO campaign_short_urls (id, device_id, code, original_url, clicks, customer_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, shortURL.ID.String(), shortURL.DeviceID, shortURL.Code, shortURL.OriginalURL, shortURL.Clicks, nullableUUID(shortURL.CustomerID), shortURL.CreatedAt)
+	return err
+}
+
+func (r *Repository) GetShortURLByCode(ctx context.Context, code string) (*domainCampaign.ShortURL, error) {
+	shortURL := &domainCampaign.ShortURL{}
+	var idStr string
+	var customerIDStr *string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, device_id, code, original_url, clicks, customer_id, created_at
+		FROM campaign_short_urls WHERE code = $1
+	`, code).Scan(&idStr, &shortURL.DeviceID, &shortURL.Code, &shortURL.OriginalURL, &shortURL.Clicks, &customerIDStr, &shortURL.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	shortURL.ID, _ = uuid.Parse(idStr)
+	if customerIDStr != nil {
+		if id, err := uuid.Parse(*customerIDStr); err == nil {
+			shortURL.CustomerID = &id
+		}
+	}
+	return shortURL, nil
+}
+
+func (r *Repository) IncrementShortURLClicks(ctx context.Context, code string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE campaign_short_urls SET clicks = clicks + 1 WHERE code = $1`, code)
+	return err
+}
+
+// ============================================================================
+// Validation Operations
+// ============================================================================
+
+func (r *Repository) GetCustomersForValidation(ctx context.Context, deviceID string, limit int) ([]*domainCampaign.Customer, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+customerColumns+`
+		FROM campaign_customers 
+		WHERE device_id = $1 AND (phone_valid = 'pending' OR whatsapp_exists = 'pending')
+		ORDER BY created_at ASC
+		LIMIT $2
+	`, deviceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return r.collectCustomers(rows)
+}
+
+// GetCustomersForRevalidation returns already-validated customers whose last check is older than validatedBefore.
+// Customers validated before validated_at was tracked are treated as stale.
+func (r *Repository) GetCustomersForRevalidation(ctx context.Context, deviceID string, validatedBefore time.Time, limit int) ([]*domainCampaign.Customer, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+customerColumns+`
+		FROM campaign_customers
+		WHERE device_id = $1 AND phone_valid = 'valid' AND whatsapp_exists != 'pending'
+			AND (validated_at IS NULL OR validated_at < $2)
+		ORDER BY validated_at ASC
+		LIMIT $3
+	`, deviceID, validatedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	return r.collectCustomers(rows)
+}
+
+// collectCustomers scans all rows selected with customerColumns and closes them
+func (r *Repository) collectCustomers(rows *sql.Rows) ([]*domainCampaign.Customer, error) {
+	defer rows.Close()
+
+	var customers []*domainCampaign.Customer
+	for rows.Next() {
+		customer, err := scanCustomer(rows)
+		if err != nil {
 			return nil, err
 		}
-		customer.ID, _ = uuid.Parse(idStr)
-		customer.PhoneValid = domainCampaign.ValidationStatus(phoneValid)
-		customer.WhatsAppExists = domainCampaign.ValidationStatus(whatsappExists)
-		customer.IsReady = customer.PhoneValid == domainCampaign.ValidationStatusValid &&
-			customer.WhatsAppExists == domainCampaign.ValidationStatusValid
 		customers = append(customers, customer)
 	}
 	return customers, rows.Err()
 }
 
-func (r *Repository) UpdateCustomerValidation(ctx context.Context, id uuid.UUID, phoneValid, whatsappExists domainCampaign.ValidationStatus) error {
+// maxValidationHistory is how many validation records are kept per customer
+const maxValidationHistory = 100
+
+// UpdateCustomerValidation stores the validation result and appends it to the validation history.
+// validated_at is only advanced when WhatsApp existence was actually determined. A history record
+// is only written when a check ran or the result changed, and the oldest records beyond
+// maxValidationHistory are pruned.
+func (r *Repository) UpdateCustomerValidation(ctx context.Context, id uuid.UUID, phoneValid, whatsappExists domainCampaign.ValidationStatus, source domainCampaign.ValidationSource, reason *string) error {
+	now := time.Now()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var previousPhoneValid, previousWhatsAppExists string
+	err = tx.QueryRowContext(ctx, `SELECT phone_valid, whatsapp_exists FROM campaign_customers WHERE id = $1`, id.String()).
+		Scan(&previousPhoneValid, &previousWhatsAppExists)
+	if err != nil {
+		return err
+	}
+
+	if whatsappExists == domainCampaign.ValidationStatusPending {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE campaign_customers SET phone_valid = $1, whatsapp_exists = $2, updated_at = $3
+			WHERE id = $4
+		`, string(phoneValid), string(whatsappExists), now, id.String())
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE campaign_customers SET phone_valid = $1, whatsapp_exists = $2, validated_at = $3, send_failure_count = 0, updated_at = $4
+			WHERE id = $5
+		`, string(phoneValid), string(whatsappExists), now, now, id.String())
+	}
+	if err != nil {
+		return err
+	}
+
+	changed := previousPhoneValid != string(phoneValid) || previousWhatsAppExists != string(whatsappExists)
+	if whatsappExists == domainCampaign.ValidationStatusPending && !changed {
+		return tx.Commit()
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO campaign_customer_validations (id, customer_id, phone_valid, whatsapp_exists, source, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, uuid.New().String(), id.String(), string(phoneValid), string(whatsappExists), string(source), reason, now)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM campaign_customer_validations
+		WHERE customer_id = $1 AND id NOT IN (
+			SELECT id FROM campaign_customer_validations
+			WHERE customer_id = $1
+			ORDER BY created_at DESC
+			LIMIT $2
+		)
+	`, id.String(), maxValidationHistory)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) GetCustomerValidationHistory(ctx context.Context, customerID uuid.UUID, limit int) ([]*domainCampaign.ValidationRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, customer_id, phone_valid, whatsapp_exists, source, reason, created_at
+		FROM campaign_customer_validations
+		WHERE customer_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, customerID.String(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*domainCampaign.ValidationRecord
+	for rows.Next() {
+		record := &domainCampaign.ValidationRecord{}
+		var idStr, customerIDStr, phoneValid, whatsappExists, source string
+		if err := rows.Scan(&idStr, &customerIDStr, &phoneValid, &whatsappExists, &source,
+			&record.Reason, &record.CreatedAt); err != nil {
+			return nil, err
+		}
+		record.ID, _ = uuid.Parse(idStr)
+		record.CustomerID, _ = uuid.Parse(customerIDStr)
+		record.PhoneValid = domainCampaign.ValidationStatus(phoneValid)
+		record.WhatsAppExists = domainCampaign.ValidationStatus(whatsappExists)
+		record.Source = domainCampaign.ValidationSource(source)
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// IncrementCustomerSendFailures bumps the consecutive send failure counter and returns the new value
+func (r *Repository) IncrementCustomerSendFailures(ctx context.Context, id uuid.UUID) (int, error) {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE campaign_customers SET send_failure_count = COALESCE(send_failure_count, 0) + 1, updated_at = $1
+		WHERE id = $2
+	`, time.Now(), id.String())
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(send_failure_count, 0) FROM campaign_customers WHERE id = $1
+	`, id.String()).Scan(&count)
+	return count, err
+}
+
+// customersOfDevice matches the customers a device owns or has been assigned to
+// send to, with the device ID bound to placeholder param. SQLite numbers $N
+// placeholders by first appearance, so param must follow the ones before it.
+func customersOfDevice(param int) string {
+	return fmt.Sprintf(`(device_id = $%[1]d OR id IN (SELECT customer_id FROM campaign_customer_senders WHERE device_id = $%[1]d))`, param)
+}
+
+// SetCustomersOptedOut flags the customer records with the phone that the device owns or sends to
+func (r *Repository) SetCustomersOptedOut(ctx context.Context, deviceID, phone string) error {
 	now := time.Now()
 	_, err := r.db.ExecContext(ctx, `
-		UPDATE campaign_customers SET phone_valid = $1, whatsapp_exists = $2, updated_at = $3
-		WHERE id = $4
-	`, string(phoneValid), string(whatsappExists), now, id.String())
+		UPDATE campaign_customers SET opted_out_at = $1, updated_at = $1
+		WHERE phone = $2 AND opted_out_at IS NULL AND `+customersOfDevice(3)+`
+	`, now, phone, deviceID)
+	return err
+}
+
+// GetCustomersByPhone returns the customer records with the phone that the device owns or sends to
+func (r *Repository) GetCustomersByPhone(ctx context.Context, deviceID, phone string) ([]*domainCampaign.Customer, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+customerColumns+`
+		FROM campaign_customers WHERE phone = $1 AND `+customersOfDevice(2)+`
+	`, phone, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	return r.collectCustomers(rows)
+}
+
+func (r *Repository) ResetCustomerSendFailures(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE campaign_customers SET send_failure_count = 0 WHERE id = $1 AND send_failure_count > 0
+	`, id.String())
 	return err
 }
 
 func (r *Repository) GetActiveDeviceIDs(ctx context.Context) ([]string, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT DISTINCT device_id FROM campaign_messages WHERE status = 'pending'
+		SELECT COALESCE(sender_device_id, device_id) FROM campaign_messages WHERE status = 'pending'
+		UNION
+		SELECT COALESCE(sender_device_id, device_id) FROM campaign_followups WHERE status = 'pending'
 	`)
 	if err != nil {
 		return nil, err
@@ -1150,3 +1976,21 @@ func (r *Repository) GetActiveDeviceIDs(ctx context.Context) ([]string, error) {
 	}
 	return deviceIDs, rows.Err()
 }
+
+func (r *Repository) GetCustomerDeviceIDs(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT DISTINCT device_id FROM campaign_customers`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deviceIDs []string
+	for rows.Next() {
+		var deviceID string
+		if err := rows.Scan(&deviceID); err != nil {
+			return nil, err
+		}
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	return deviceIDs, rows.Err()
+}
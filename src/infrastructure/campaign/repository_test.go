@@ -0,0 +1,66 @@
+package campaign
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	domainCampaign "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/campaign"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "campaign.db")+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	repo := &Repository{db: db}
+	if err := repo.InitializeSchema(); err != nil {
+		t.Fatalf("InitializeSchema() error = %v", err)
+	}
+	return repo
+}
+
+func TestRepositoryOptOutByPhone(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+	const phone = "+6281234567890"
+
+	owned := &domainCampaign.Customer{DeviceID: "dev1", Phone: phone}
+	assigned := &domainCampaign.Customer{DeviceID: "dev2", Phone: phone}
+	other := &domainCampaign.Customer{DeviceID: "dev3", Phone: phone}
+	for _, customer := range []*domainCampaign.Customer{owned, assigned, other} {
+		if err := repo.CreateCustomer(ctx, customer); err != nil {
+			t.Fatalf("CreateCustomer(%s) error = %v", customer.DeviceID, err)
+		}
+	}
+	// dev1 sends to the dev2 customer
+	if err := repo.SetCustomerSenderIfMissing(ctx, assigned.ID, "dev1"); err != nil {
+		t.Fatalf("SetCustomerSenderIfMissing() error = %v", err)
+	}
+
+	customers, err := repo.GetCustomersByPhone(ctx, "dev1", phone)
+	if err != nil || len(customers) != 2 {
+		t.Fatalf("GetCustomersByPhone() = %d customers, %v, want 2", len(customers), err)
+	}
+
+	if err := repo.SetCustomersOptedOut(ctx, "dev1", phone); err != nil {
+		t.Fatalf("SetCustomersOptedOut() error = %v", err)
+	}
+	for _, want := range []struct {
+		customer *domainCampaign.Customer
+		optedOut bool
+	}{{owned, true}, {assigned, true}, {other, false}} {
+		customer, err := repo.GetCustomer(ctx, want.customer.DeviceID, want.customer.ID)
+		if err != nil || customer == nil {
+			t.Fatalf("GetCustomer(%s) = %v, %v", want.customer.DeviceID, customer, err)
+		}
+		if (customer.OptedOutAt != nil) != want.optedOut {
+			t.Errorf("customer of %s opted out = %v, want %v", want.customer.DeviceID, customer.OptedOutAt != nil, want.optedOut)
+		}
+	}
+}
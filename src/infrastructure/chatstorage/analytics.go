@@ -0,0 +1,230 @@
+package chatstorage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+)
+
+// analyticsDialect holds the parts of the analytics queries that differ
+// between the backends. Everything else is shared and written with ?
+// placeholders passed through bind.
+type analyticsDialect struct {
+	bind func(string) string
+	// period formats a timestamp column as the UTC start of its day or hour
+	period func(column, granularity string) string
+	// epoch converts a timestamp column to whole seconds since 1970
+	epoch func(column string) string
+	// median is the median of a column over all rows of a table, NULL when it is empty
+	median func(column, table string) string
+}
+
+var sqliteAnalytics = analyticsDialect{
+	bind: sqliteBind,
+	period: func(column, granularity string) string {
+		if granularity == domainChatStorage.AnalyticsGranularityHour {
+			return "strftime('%Y-%m-%dT%H:00:00Z', " + column + ")"
+		}
+		return "strftime('%Y-%m-%d', " + column + ")"
+	},
+	epoch: func(column string) string {
+		return "CAST(strftime('%s', " + column + ") AS INTEGER)"
+	},
+	// SQLite has no percentile function: average the one or two middle rows
+	median: func(column, table string) string {
+		count := "(SELECT COUNT(*) FROM " + table + ")"
+		return "(SELECT AVG(" + column + ") FROM (SELECT " + column + " FROM " + table + " ORDER BY " + column +
+			" LIMIT 2 - " + count + " % 2 OFFSET (" + count + " - 1) / 2))"
+	},
+}
+
+var postgresAnalytics = analyticsDialect{
+	bind: rebindPostgres,
+	period: func(column, granularity string) string {
+		if granularity == domainChatStorage.AnalyticsGranularityHour {
+			return "to_char(" + column + " AT TIME ZONE 'UTC', 'YYYY-MM-DD\"T\"HH24:00:00\"Z\"')"
+		}
+		return "to_char(" + column + " AT TIME ZONE 'UTC', 'YYYY-MM-DD')"
+	},
+	epoch: func(column string) string {
+		return "CAST(EXTRACT(EPOCH FROM " + column + ") AS BIGINT)"
+	},
+	median: func(column, table string) string {
+		return "(SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY " + column + ") FROM " + table + ")"
+	},
+}
+
+const (
+	analyticsInbound  = "COALESCE(SUM(CASE WHEN m.is_from_me THEN 0 ELSE 1 END), 0)"
+	analyticsOutbound = "COALESCE(SUM(CASE WHEN m.is_from_me THEN 1 ELSE 0 END), 0)"
+)
+
+// getMessageAnalytics aggregates the messages selected by filter
+func getMessageAnalytics(db *sql.DB, d analyticsDialect, filter *domainChatStorage.AnalyticsFilter) (*domainChatStorage.MessageAnalytics, error) {
+	if filter.DeviceID == "" {
+		return nil, fmt.Errorf("device_id is required for message analytics (data isolation)")
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	conditions := []string{"m.device_id = ?"}
+	args := []any{filter.DeviceID}
+	if filter.ChatJID != "" {
+		conditions = append(conditions, "m.chat_jid = ?")
+		args = append(args, filter.ChatJID)
+	}
+	if filter.StartTime != nil {
+		conditions = append(conditions, "m.timestamp >= ?")
+		args = append(args, *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		conditions = append(conditions, "m.timestamp <= ?")
+		args = append(args, *filter.EndTime)
+	}
+	where := strings.Join(conditions, " AND ")
+	withArgs := func(extra ...any) []any {
+		return append(append([]any{}, args...), extra...)
+	}
+
+	analytics := &domainChatStorage.MessageAnalytics{}
+
+	err := db.QueryRow(d.bind(`SELECT `+analyticsInbound+`, `+analyticsOutbound+` FROM messages m WHERE `+where), args...).
+		Scan(&analytics.Inbound, &analytics.Outbound)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count messages: %w", err)
+	}
+
+	period := d.period("m.timestamp", filter.Granularity)
+	err = queryAnalyticsRows(db, d.bind(`
+		SELECT `+period+` AS period, `+analyticsInbound+`, `+analyticsOutbound+`
+		FROM messages m
+		WHERE `+where+`
+		GROUP BY `+period+`
+		ORDER BY period
+	`), args, func(rows *sql.Rows) error {
+		var volume domainChatStorage.MessageVolume
+		if err := rows.Scan(&volume.Period, &volume.Inbound, &volume.Outbound); err != nil {
+			return err
+		}
+		analytics.Volume = append(analytics.Volume, volume)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate message volume: %w", err)
+	}
+
+	err = queryAnalyticsRows(db, d.bind(`
+		SELECT m.chat_jid, COALESCE(MAX(c.name), ''), `+analyticsInbound+`, `+analyticsOutbound+`
+		FROM messages m
+		LEFT JOIN chats c ON c.jid = m.chat_jid AND c.device_id = m.device_id
+		WHERE `+where+`
+		GROUP BY m.chat_jid
+		ORDER BY COUNT(*) DESC, m.chat_jid
+		LIMIT ?
+	`), withArgs(limit), func(rows *sql.Rows) error {
+		var chat domainChatStorage.ChatActivity
+		if err := rows.Scan(&chat.ChatJID, &chat.Name, &chat.Inbound, &chat.Outbound); err != nil {
+			return err
+		}
+		analytics.TopChats = append(analytics.TopChats, chat)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate top chats: %w", err)
+	}
+
+	err = queryAnalyticsRows(db, d.bind(`
+		SELECT m.media_type, COUNT(*), COALESCE(SUM(m.file_length), 0)
+		FROM messages m
+		WHERE `+where+` AND COALESCE(m.media_type, '') != ''
+		GROUP BY m.media_type
+		ORDER BY COUNT(*) DESC, m.media_type
+	`), args, func(rows *sql.Rows) error {
+		var media domainChatStorage.MediaUsage
+		if err := rows.Scan(&media.MediaType, &media.Messages, &media.Bytes); err != nil {
+			return err
+		}
+		analytics.Media = append(analytics.Media, media)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate media: %w", err)
+	}
+
+	err = queryAnalyticsRows(db, d.bind(`
+		SELECT s.chat_jid, COALESCE(c.name, ''), s.sender,
+			COALESCE(NULLIF(ct.full_name, ''), NULLIF(ct.first_name, ''), NULLIF(ct.push_name, ''), ''), s.messages
+		FROM (
+			SELECT m.chat_jid, m.sender, COUNT(*) AS messages
+			FROM messages m
+			WHERE `+where+` AND m.chat_jid LIKE '%@g.us' AND m.is_from_me = FALSE
+			GROUP BY m.chat_jid, m.sender
+		) s
+		LEFT JOIN chats c ON c.jid = s.chat_jid AND c.device_id = ?
+		LEFT JOIN contacts ct ON ct.jid = s.sender AND ct.device_id = ?
+		ORDER BY s.messages DESC, s.chat_jid, s.sender
+		LIMIT ?
+	`), withArgs(filter.DeviceID, filter.DeviceID, limit), func(rows *sql.Rows) error {
+		var sender domainChatStorage.SenderActivity
+		if err := rows.Scan(&sender.ChatJID, &sender.ChatName, &sender.SenderJID, &sender.SenderName, &sender.Messages); err != nil {
+			return err
+		}
+		analytics.TopSenders = append(analytics.TopSenders, sender)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate group senders: %w", err)
+	}
+
+	analytics.ResponseTime, err = getResponseTimes(db, d, where, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure response times: %w", err)
+	}
+	return analytics, nil
+}
+
+// getResponseTimes collapses each direct chat into turns of consecutive
+// messages from the same side and measures from the start of every incoming
+// turn to the start of our reply. The median is computed by the database, so
+// the gaps never leave it.
+func getResponseTimes(db *sql.DB, d analyticsDialect, where string, args []any) (domainChatStorage.ResponseTimeStats, error) {
+	var stats domainChatStorage.ResponseTimeStats
+	err := db.QueryRow(d.bind(`
+		WITH ordered AS (
+			SELECT m.chat_jid, m.timestamp, m.is_from_me,
+				LAG(m.is_from_me) OVER (PARTITION BY m.chat_jid ORDER BY m.timestamp, m.id) AS previous_from_me
+			FROM messages m
+			WHERE `+where+` AND m.chat_jid NOT LIKE '%@g.us'
+		), turns AS (
+			SELECT chat_jid, timestamp, is_from_me,
+				LEAD(timestamp) OVER (PARTITION BY chat_jid ORDER BY timestamp) AS next_turn
+			FROM ordered
+			WHERE previous_from_me IS NULL OR previous_from_me != is_from_me
+		), gaps AS (
+			SELECT `+d.epoch("next_turn")+` - `+d.epoch("timestamp")+` AS gap
+			FROM turns
+			WHERE is_from_me = FALSE AND next_turn IS NOT NULL
+		)
+		SELECT COUNT(*), COALESCE(`+d.median("gap", "gaps")+`, 0) FROM gaps
+	`), args...).Scan(&stats.Samples, &stats.MedianSeconds)
+	return stats, err
+}
+
+func queryAnalyticsRows(db *sql.DB, query string, args []any, scan func(*sql.Rows) error) error {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
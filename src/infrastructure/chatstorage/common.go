@@ -0,0 +1,433 @@
+package chatstorage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Logic shared by every storage backend. These helpers only talk to the
+// repository through IChatStorageRepository so each backend keeps its SQL to itself.
+
+// chatNameWithPushName determines the appropriate name for a chat, preferring
+// the stored name unless it is just the phone number and a pushname is known
+func chatNameWithPushName(existingChat *domainChatStorage.Chat, jid types.JID, senderUser string, pushName string) string {
+	// First, check if chat already exists with a name
+	if existingChat != nil && existingChat.Name != "" {
+		// If we have a pushname and the existing name is just a phone number/JID user, update it
+		if pushName != "" && (existingChat.Name == jid.User || existingChat.Name == senderUser) {
+			return pushName
+		}
+		return existingChat.Name
+	}
+
+	// Determine chat type and name
+	var name string
+
+	switch jid.Server {
+	case "g.us":
+		// This is a group chat
+		// For now, use a generic name - this can be enhanced later with group info
+		name = fmt.Sprintf("Group %s", jid.User)
+	case "newsletter":
+		// This is a newsletter/channel
+		name = fmt.Sprintf("Newsletter %s", jid.User)
+	default:
+		// This is an individual contact
+		// Priority: pushName > senderUser > JID user
+		if pushName != "" && pushName != senderUser && pushName != jid.User {
+			name = pushName
+		} else if senderUser != "" {
+			name = senderUser
+		} else {
+			name = jid.User
+		}
+	}
+
+	return name
+}
+
+// chatColumns are the chats columns read by scanChatRow, aliased as c. The
+// contact name of 1:1 chats is looked up in contacts.
+const chatColumns = `c.device_id, c.jid, c.name, c.last_message_time, c.ephemeral_expiration,
+	c.created_at, c.updated_at, c.unread_count, c.marked_unread, c.archived, c.pinned, c.muted_until,
+	(SELECT COALESCE(NULLIF(ct.full_name, ''), NULLIF(ct.first_name, ''), ct.push_name)
+		FROM contacts ct WHERE ct.device_id = c.device_id AND ct.jid = c.jid)`
+
+// scanChatRow scans chatColumns
+func scanChatRow(scanner interface{ Scan(...any) error }) (*domainChatStorage.Chat, error) {
+	chat := &domainChatStorage.Chat{}
+	var (
+		unreadCount                    sql.NullInt64
+		markedUnread, archived, pinned sql.NullBool
+		mutedUntil                     sql.NullTime
+		contactName                    sql.NullString
+	)
+	err := scanner.Scan(
+		&chat.DeviceID, &chat.JID, &chat.Name, &chat.LastMessageTime, &chat.EphemeralExpiration,
+		&chat.CreatedAt, &chat.UpdatedAt, &unreadCount, &markedUnread, &archived, &pinned, &mutedUntil,
+		&contactName,
+	)
+	if err != nil {
+		return chat, err
+	}
+
+	chat.UnreadCount = int(unreadCount.Int64)
+	chat.MarkedUnread = markedUnread.Bool
+	chat.Archived = archived.Bool
+	chat.Pinned = pinned.Bool
+	if mutedUntil.Valid {
+		chat.MutedUntil = &mutedUntil.Time
+	}
+	chat.ContactName = contactName.String
+	return chat, nil
+}
+
+// messageColumns are the messages columns read by scanMessageRow, aliased as m
+const messageColumns = `m.id, m.chat_jid, m.device_id, m.sender, m.content, m.timestamp, m.is_from_me,
+	m.media_type, m.filename, m.url, m.media_key, m.file_sha256,
+	m.file_enc_sha256, m.file_length, m.created_at, m.updated_at,
+	m.edited_at, m.is_revoked, m.revoked_at, m.status, m.is_starred, m.media_path,
+	m.mimetype, m.is_ptt`
+
+// scanMessageRow scans messageColumns followed by any extra destinations and
+// decrypts the message with c
+func scanMessageRow(c *FieldCipher, scanner interface{ Scan(...any) error }, extra ...any) (*domainChatStorage.Message, error) {
+	message := &domainChatStorage.Message{}
+	var (
+		fileLength           int64
+		editedAt, revokedAt  sql.NullTime
+		isRevoked, isStarred sql.NullBool
+		isPTT                sql.NullBool
+		status, mediaPath    sql.NullString
+		mimetype             sql.NullString
+	)
+	dest := []any{
+		&message.ID, &message.ChatJID, &message.DeviceID, &message.Sender, &message.Content,
+		&message.Timestamp, &message.IsFromMe, &message.MediaType, &message.Filename,
+		&message.URL, &message.MediaKey, &message.FileSHA256, &message.FileEncSHA256,
+		&fileLength, &message.CreatedAt, &message.UpdatedAt,
+		&editedAt, &isRevoked, &revokedAt, &status, &isStarred, &mediaPath,
+		&mimetype, &isPTT,
+	}
+	if err := scanner.Scan(append(dest, extra...)...); err != nil {
+		return message, err
+	}
+
+	message.FileLength = uint64(fileLength)
+	if editedAt.Valid {
+		message.EditedAt = &editedAt.Time
+	}
+	if revokedAt.Valid {
+		message.RevokedAt = &revokedAt.Time
+	}
+	message.IsRevoked = isRevoked.Bool
+	message.Status = status.String
+	message.IsStarred = isStarred.Bool
+	message.MediaPath = mediaPath.String
+	message.Mimetype = mimetype.String
+	message.IsPTT = isPTT.Bool
+	return message, c.openMessage(message)
+}
+
+// messageStatusOrder lists delivery states from earliest to latest
+var messageStatusOrder = []string{
+	"",
+	domainChatStorage.MessageStatusSent,
+	domainChatStorage.MessageStatusDelivered,
+	domainChatStorage.MessageStatusRead,
+	domainChatStorage.MessageStatusPlayed,
+}
+
+// statusesBefore returns the states a message may move to status from,
+// or nil when status is unknown
+func statusesBefore(status string) []string {
+	for i, s := range messageStatusOrder {
+		if s == status && i > 0 {
+			return messageStatusOrder[:i]
+		}
+	}
+	return nil
+}
+
+// applyMessageUpdate stores reactions, edits and revokes carried by evt. It
+// reports false when evt is a regular message.
+func applyMessageUpdate(repo domainChatStorage.IChatStorageRepository, evt *events.Message, deviceID, chatJID, sender string) (bool, error) {
+	if reaction := evt.Message.GetReactionMessage(); reaction != nil {
+		timestamp := evt.Info.Timestamp
+		if ms := reaction.GetSenderTimestampMS(); ms > 0 {
+			timestamp = time.UnixMilli(ms)
+		}
+		return true, repo.StoreReaction(&domainChatStorage.MessageReaction{
+			MessageID: reaction.GetKey().GetID(),
+			ChatJID:   chatJID,
+			DeviceID:  deviceID,
+			Sender:    sender,
+			Reaction:  reaction.GetText(),
+			Timestamp: timestamp,
+		})
+	}
+
+	protocolMessage := evt.Message.GetProtocolMessage()
+	if protocolMessage == nil {
+		return false, nil
+	}
+
+	switch protocolMessage.GetType() {
+	case waE2E.ProtocolMessage_REVOKE:
+		return true, repo.MarkMessageRevoked(deviceID, chatJID, protocolMessage.GetKey().GetID(), evt.Info.Timestamp)
+	case waE2E.ProtocolMessage_MESSAGE_EDIT:
+		content := utils.ExtractMessageTextFromProto(protocolMessage.GetEditedMessage())
+		if content == "" {
+			return true, nil
+		}
+		return true, repo.ApplyMessageEdit(deviceID, chatJID, protocolMessage.GetKey().GetID(), content, evt.Info.Timestamp)
+	}
+	return false, nil
+}
+
+// createMessage stores an incoming message event and its chat
+func createMessage(ctx context.Context, repo domainChatStorage.IChatStorageRepository, evt *events.Message) error {
+	if evt == nil || evt.Message == nil {
+		return nil
+	}
+
+	// Get WhatsApp client for LID resolution (device-scoped if present in context)
+	client := whatsapp.ClientFromContext(ctx)
+	deviceID := whatsapp.StorageDeviceIDFromContext(ctx, client)
+
+	// Normalize chat and sender JIDs (convert @lid to @s.whatsapp.net)
+	normalizedChatJID := whatsapp.NormalizeJIDFromLID(ctx, evt.Info.Chat, client)
+	normalizedSender := whatsapp.NormalizeJIDFromLID(ctx, evt.Info.Sender, client)
+
+	chatJID := normalizedChatJID.String()
+	// Store the full sender JID (user@server) to ensure consistency between received and sent messages
+	sender := normalizedSender.ToNonAD().String()
+
+	// Reactions, edits and revokes update an existing message instead of adding one
+	if handled, err := applyMessageUpdate(repo, evt, deviceID, chatJID, sender); handled {
+		if err != nil {
+			return fmt.Errorf("failed to apply message update: %w", err)
+		}
+		return nil
+	}
+
+	// Get appropriate chat name using pushname if available
+	chatName := repo.GetChatNameWithPushName(normalizedChatJID, chatJID, normalizedSender.User, evt.Info.PushName)
+
+	// Get existing chat to preserve ephemeral_expiration if needed
+	existingChat, err := repo.GetChat(chatJID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing chat: %w", err)
+	}
+
+	// Extract ephemeral expiration from incoming message
+	ephemeralExpiration := utils.ExtractEphemeralExpiration(evt.Message)
+
+	// Create or update chat
+	chat := &domainChatStorage.Chat{
+		DeviceID:        deviceID,
+		JID:             chatJID,
+		Name:            chatName,
+		LastMessageTime: evt.Info.Timestamp,
+	}
+
+	// Set ephemeral expiration: use incoming message value if > 0, otherwise preserve existing
+	if ephemeralExpiration > 0 {
+		chat.EphemeralExpiration = ephemeralExpiration
+	} else if existingChat != nil {
+		// Preserve existing ephemeral_expiration if incoming message doesn't have one
+		chat.EphemeralExpiration = existingChat.EphemeralExpiration
+	}
+
+	// Store or update the chat
+	if err := repo.StoreChat(chat); err != nil {
+		return fmt.Errorf("failed to store chat: %w", err)
+	}
+
+	// Extract message content and media info
+	content := utils.ExtractMessageTextFromProto(evt.Message)
+	mediaType, filename, url, mediaKey, fileSHA256, fileEncSHA256, fileLength := utils.ExtractMediaInfo(evt.Message)
+
+	// Skip if there's no content and no media
+	if content == "" && mediaType == "" {
+		logrus.Debugf("Skipping message %s - no content or media", evt.Info.ID)
+		return nil
+	}
+
+	// Create message object
+	message := &domainChatStorage.Message{
+		ID:            evt.Info.ID,
+		ChatJID:       chatJID,
+		DeviceID:      deviceID,
+		Sender:        sender,
+		Content:       content,
+		Timestamp:     evt.Info.Timestamp,
+		IsFromMe:      evt.Info.IsFromMe,
+		MediaType:     mediaType,
+		Filename:      filename,
+		URL:           url,
+		MediaKey:      mediaKey,
+		FileSHA256:    fileSHA256,
+		FileEncSHA256: fileEncSHA256,
+		FileLength:    fileLength,
+	}
+	message.Mimetype, message.IsPTT = utils.ExtractMediaMimetype(evt.Message)
+	if evt.Info.IsFromMe {
+		message.Status = domainChatStorage.MessageStatusSent
+	}
+
+	// Store the message
+	if err := repo.StoreMessage(message); err != nil {
+		return err
+	}
+
+	// Incoming messages count as unread until the chat is read; writing from
+	// another device means the chat was read there
+	update := domainChatStorage.ChatMetadataUpdate{AddUnread: 1}
+	if evt.Info.IsFromMe {
+		update = domainChatStorage.ChatReadUpdate()
+	}
+	if err := repo.UpdateChatMetadata(deviceID, chatJID, update); err != nil {
+		return fmt.Errorf("failed to update unread count: %w", err)
+	}
+	return nil
+}
+
+// storeSentMessage stores a message that was sent by the user with context cancellation support
+func storeSentMessage(ctx context.Context, repo domainChatStorage.IChatStorageRepository, messageID string, senderJID string, recipientJID string, content string, timestamp time.Time) error {
+	// Check if context is already cancelled before starting
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Ensure JID is properly formatted
+	jid, err := types.ParseJID(recipientJID)
+	if err != nil {
+		return fmt.Errorf("invalid JID format: %w", err)
+	}
+
+	// Get WhatsApp client for LID resolution (device-scoped if present in context)
+	client := whatsapp.ClientFromContext(ctx)
+	deviceID := whatsapp.StorageDeviceIDFromContext(ctx, client)
+
+	// Normalize recipient JID (convert @lid to @s.whatsapp.net)
+	normalizedJID := whatsapp.NormalizeJIDFromLID(ctx, jid, client)
+	chatJID := normalizedJID.String()
+
+	// Get chat name (no pushname available for sent messages)
+	chatName := repo.GetChatNameWithPushName(normalizedJID, chatJID, normalizedJID.User, "")
+
+	// Check context again before database operations
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Get existing chat to preserve ephemeral_expiration
+	existingChat, err := repo.GetChat(chatJID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing chat: %w", err)
+	}
+
+	// Store or update chat, preserving existing ephemeral_expiration
+	chat := &domainChatStorage.Chat{
+		DeviceID:        deviceID,
+		JID:             chatJID,
+		Name:            chatName,
+		LastMessageTime: timestamp,
+	}
+
+	// Preserve existing ephemeral_expiration if chat exists
+	if existingChat != nil {
+		chat.EphemeralExpiration = existingChat.EphemeralExpiration
+	}
+
+	if err := repo.StoreChat(chat); err != nil {
+		return fmt.Errorf("failed to store chat: %w", err)
+	}
+
+	// Check context one more time before storing message
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// Store the sent message
+	message := &domainChatStorage.Message{
+		ID:        messageID,
+		ChatJID:   chatJID,
+		DeviceID:  deviceID,
+		Sender:    senderJID,
+		Content:   content,
+		Timestamp: timestamp,
+		IsFromMe:  true,
+		Status:    domainChatStorage.MessageStatusSent,
+	}
+
+	if err := repo.StoreMessage(message); err != nil {
+		return err
+	}
+	return repo.UpdateChatMetadata(deviceID, chatJID, domainChatStorage.ChatReadUpdate())
+}
+
+// storageStatistics returns current storage statistics for logging purposes
+func storageStatistics(repo domainChatStorage.IChatStorageRepository) (chatCount int64, messageCount int64, err error) {
+	// Count all chats using efficient query
+	chatCount, err = repo.GetTotalChatCount()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get chat count: %w", err)
+	}
+
+	// Count all messages
+	messageCount, err = repo.GetTotalMessageCount()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get message count: %w", err)
+	}
+
+	return chatCount, messageCount, nil
+}
+
+// truncateAllDataWithLogging performs truncation with detailed logging
+func truncateAllDataWithLogging(repo domainChatStorage.IChatStorageRepository, logPrefix string) error {
+	// Get statistics before truncation
+	chatCount, messageCount, err := repo.GetStorageStatistics()
+	if err != nil {
+		logrus.Warnf("[%s] Failed to get storage statistics before truncation: %v", logPrefix, err)
+	} else {
+		logrus.Infof("[%s] Storage before truncation: %d chats, %d messages", logPrefix, chatCount, messageCount)
+	}
+
+	// Perform truncation
+	if err := repo.TruncateAllChats(); err != nil {
+		return fmt.Errorf("failed to truncate chatstorage data: %w", err)
+	}
+
+	// Verify truncation
+	chatCountAfter, messageCountAfter, err := repo.GetStorageStatistics()
+	if err != nil {
+		logrus.Warnf("[%s] Failed to get storage statistics after truncation: %v", logPrefix, err)
+	} else {
+		logrus.Infof("[%s] Storage after truncation: %d chats, %d messages", logPrefix, chatCountAfter, messageCountAfter)
+		if chatCountAfter == 0 && messageCountAfter == 0 {
+			logrus.Infof("[%s] ✅ Chatstorage truncation completed successfully", logPrefix)
+		} else {
+			logrus.Warnf("[%s] ⚠️ Truncation may not have completed fully", logPrefix)
+		}
+	}
+
+	return nil
+}
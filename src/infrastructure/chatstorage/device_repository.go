@@ -84,6 +84,13 @@ func (r *DeviceRepository) GetMessages(filter *domainChatStorage.MessageFilter)
 	return r.base.GetMessages(filter)
 }
 
+func (r *DeviceRepository) IterateMessages(filter *domainChatStorage.MessageFilter, fn func(*domainChatStorage.Message) error) error {
+	if filter != nil && filter.DeviceID == "" {
+		filter.DeviceID = r.deviceID
+	}
+	return r.base.IterateMessages(filter, fn)
+}
+
 func (r *DeviceRepository) SearchMessages(deviceID, chatJID, searchText string, limit int) ([]*domainChatStorage.Message, error) {
 	targetDeviceID := deviceID
 	if targetDeviceID == "" {
@@ -92,6 +99,13 @@ func (r *DeviceRepository) SearchMessages(deviceID, chatJID, searchText string,
 	return r.base.SearchMessages(targetDeviceID, chatJID, searchText, limit)
 }
 
+func (r *DeviceRepository) SearchMessagesFullText(filter *domainChatStorage.MessageSearchFilter) ([]*domainChatStorage.MessageSearchResult, int64, error) {
+	if filter != nil && filter.DeviceID == "" {
+		filter.DeviceID = r.deviceID
+	}
+	return r.base.SearchMessagesFullText(filter)
+}
+
 func (r *DeviceRepository) DeleteMessage(id, chatJID string) error {
 	return r.base.DeleteMessageByDevice(r.deviceID, id, chatJID)
 }
@@ -104,6 +118,102 @@ func (r *DeviceRepository) StoreSentMessageWithContext(ctx context.Context, mess
 	return r.base.StoreSentMessageWithContext(ctx, messageID, senderJID, recipientJID, content, timestamp)
 }
 
+// withDevice falls back to the wrapped device when deviceID is empty
+func (r *DeviceRepository) withDevice(deviceID string) string {
+	if deviceID == "" {
+		return r.deviceID
+	}
+	return deviceID
+}
+
+func (r *DeviceRepository) ApplyMessageEdit(deviceID, chatJID, messageID, content string, editedAt time.Time) error {
+	return r.base.ApplyMessageEdit(r.withDevice(deviceID), chatJID, messageID, content, editedAt)
+}
+
+func (r *DeviceRepository) MarkMessageRevoked(deviceID, chatJID, messageID string, revokedAt time.Time) error {
+	return r.base.MarkMessageRevoked(r.withDevice(deviceID), chatJID, messageID, revokedAt)
+}
+
+func (r *DeviceRepository) StoreReaction(reaction *domainChatStorage.MessageReaction) error {
+	if reaction != nil {
+		reaction.DeviceID = r.withDevice(reaction.DeviceID)
+	}
+	return r.base.StoreReaction(reaction)
+}
+
+func (r *DeviceRepository) UpdateMessageStatus(deviceID, chatJID string, messageIDs []string, status string) error {
+	return r.base.UpdateMessageStatus(r.withDevice(deviceID), chatJID, messageIDs, status)
+}
+
+func (r *DeviceRepository) GetMessageReactions(deviceID, chatJID string, messageIDs []string) ([]*domainChatStorage.MessageReaction, error) {
+	return r.base.GetMessageReactions(r.withDevice(deviceID), chatJID, messageIDs)
+}
+
+func (r *DeviceRepository) GetMessageEdits(deviceID, chatJID string, messageIDs []string) ([]*domainChatStorage.MessageEdit, error) {
+	return r.base.GetMessageEdits(r.withDevice(deviceID), chatJID, messageIDs)
+}
+
+func (r *DeviceRepository) SetMessageStarred(deviceID, chatJID, messageID string, starred bool) error {
+	return r.base.SetMessageStarred(r.withDevice(deviceID), chatJID, messageID, starred)
+}
+
+func (r *DeviceRepository) SetMessageMediaPath(deviceID, chatJID, messageID, mediaPath string) error {
+	return r.base.SetMessageMediaPath(r.withDevice(deviceID), chatJID, messageID, mediaPath)
+}
+
+func (r *DeviceRepository) UpdateChatMetadata(deviceID, chatJID string, update domainChatStorage.ChatMetadataUpdate) error {
+	return r.base.UpdateChatMetadata(r.withDevice(deviceID), chatJID, update)
+}
+
+func (r *DeviceRepository) StoreContact(contact *domainChatStorage.Contact) error {
+	if contact != nil {
+		contact.DeviceID = r.withDevice(contact.DeviceID)
+	}
+	return r.base.StoreContact(contact)
+}
+
+func (r *DeviceRepository) SetGroupParticipants(deviceID, groupJID string, participants []*domainChatStorage.GroupParticipant) error {
+	return r.base.SetGroupParticipants(r.withDevice(deviceID), groupJID, participants)
+}
+
+func (r *DeviceRepository) ApplyGroupParticipantChange(deviceID, groupJID string, change domainChatStorage.GroupParticipantChange) error {
+	return r.base.ApplyGroupParticipantChange(r.withDevice(deviceID), groupJID, change)
+}
+
+func (r *DeviceRepository) GetGroupParticipants(deviceID, groupJID string) ([]*domainChatStorage.GroupParticipant, error) {
+	return r.base.GetGroupParticipants(r.withDevice(deviceID), groupJID)
+}
+
+func (r *DeviceRepository) GetMessageChanges(filter *domainChatStorage.MessageChangeFilter) (*domainChatStorage.MessageChanges, error) {
+	if filter != nil && filter.DeviceID == "" {
+		filter.DeviceID = r.deviceID
+	}
+	return r.base.GetMessageChanges(filter)
+}
+
+func (r *DeviceRepository) PruneMessageDeletions(olderThan time.Time) (int64, error) {
+	return r.base.PruneMessageDeletions(olderThan)
+}
+
+func (r *DeviceRepository) ListMessageDeviceIDs() ([]string, error) {
+	return r.base.ListMessageDeviceIDs()
+}
+
+func (r *DeviceRepository) PreviewPrune(policy domainChatStorage.RetentionPolicy) (*domainChatStorage.PrunePreview, error) {
+	policy.DeviceID = r.withDevice(policy.DeviceID)
+	return r.base.PreviewPrune(policy)
+}
+
+func (r *DeviceRepository) PruneMessages(policy domainChatStorage.RetentionPolicy, limit int) (int64, error) {
+	policy.DeviceID = r.withDevice(policy.DeviceID)
+	return r.base.PruneMessages(policy, limit)
+}
+
+// ReencryptMessages covers every device, encryption keys are not per device
+func (r *DeviceRepository) ReencryptMessages(batchSize int) (int64, error) {
+	return r.base.ReencryptMessages(batchSize)
+}
+
 func (r *DeviceRepository) GetChatMessageCount(chatJID string) (int64, error) {
 	return r.base.GetChatMessageCountByDevice(r.deviceID, chatJID)
 }
@@ -128,6 +238,13 @@ func (r *DeviceRepository) GetChatNameWithPushNameByDevice(deviceID string, jid
 	return r.base.GetChatNameWithPushNameByDevice(deviceID, jid, chatJID, senderUser, pushName)
 }
 
+func (r *DeviceRepository) GetMessageAnalytics(filter *domainChatStorage.AnalyticsFilter) (*domainChatStorage.MessageAnalytics, error) {
+	if filter != nil && filter.DeviceID == "" {
+		filter.DeviceID = r.deviceID
+	}
+	return r.base.GetMessageAnalytics(filter)
+}
+
 func (r *DeviceRepository) GetStorageStatistics() (chatCount int64, messageCount int64, err error) {
 	return r.base.GetStorageStatistics()
 }
@@ -0,0 +1,359 @@
+package chatstorage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+)
+
+// encryptedPrefix marks an encrypted value, stored as
+// enc:v1:<key id>:<base64 of nonce and ciphertext>
+const encryptedPrefix = "enc:v1:"
+
+// FieldCipher encrypts message content and media keys with AES-256-GCM before
+// they are written to chat storage, and decrypts them when they are read.
+// Values are tagged with the ID of their key, so values written under a
+// previous key stay readable until ReencryptMessages rewrites them, and
+// plaintext written before encryption was enabled is returned as is.
+//
+// A nil *FieldCipher stores plaintext. A cipher with only previous keys reads
+// encrypted values but writes plaintext, which is how encryption is turned off.
+type FieldCipher struct {
+	current *cipherKey
+	keys    map[string]*cipherKey
+}
+
+type cipherKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewFieldCipher builds a cipher from base64 encoded 32 byte keys. It returns
+// nil when no key is given.
+func NewFieldCipher(key string, previousKeys []string) (*FieldCipher, error) {
+	c := &FieldCipher{keys: map[string]*cipherKey{}}
+	for _, encoded := range previousKeys {
+		if strings.TrimSpace(encoded) == "" {
+			continue
+		}
+		k, err := newCipherKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid previous encryption key: %w", err)
+		}
+		c.keys[k.id] = k
+	}
+	if strings.TrimSpace(key) != "" {
+		k, err := newCipherKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key: %w", err)
+		}
+		c.current = k
+		c.keys[k.id] = k
+	}
+
+	if len(c.keys) == 0 {
+		return nil, nil
+	}
+	return c, nil
+}
+
+func newCipherKey(encoded string) (*cipherKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(raw) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes encoded as base64 (openssl rand -base64 32)")
+	}
+
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(raw)
+	return &cipherKey{id: hex.EncodeToString(sum[:4]), aead: aead}, nil
+}
+
+// encrypt seals value under the current key. column is authenticated with the
+// value, so a value copied into another column fails to decrypt.
+func (c *FieldCipher) encrypt(column string, value []byte) ([]byte, error) {
+	if c == nil || c.current == nil || len(value) == 0 {
+		return value, nil
+	}
+
+	nonce := make([]byte, c.current.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := c.current.aead.Seal(nonce, nonce, value, []byte(column))
+	return []byte(encryptedPrefix + c.current.id + ":" + base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// decrypt opens a value written by encrypt, other values are returned as is
+func (c *FieldCipher) decrypt(column string, value []byte) ([]byte, error) {
+	if c == nil || !bytes.HasPrefix(value, []byte(encryptedPrefix)) {
+		return value, nil
+	}
+
+	keyID, encoded, found := strings.Cut(string(value[len(encryptedPrefix):]), ":")
+	if !found {
+		return value, nil
+	}
+	key, ok := c.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%s is encrypted with unknown key %s", column, keyID)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < key.aead.NonceSize() {
+		return nil, fmt.Errorf("%s is not a valid encrypted value", column)
+	}
+
+	nonceSize := key.aead.NonceSize()
+	plain, err := key.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(column))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", column, err)
+	}
+	return plain, nil
+}
+
+func (c *FieldCipher) encryptString(column, value string) (string, error) {
+	sealed, err := c.encrypt(column, []byte(value))
+	return string(sealed), err
+}
+
+func (c *FieldCipher) decryptString(column, value string) (string, error) {
+	plain, err := c.decrypt(column, []byte(value))
+	return string(plain), err
+}
+
+// needsRewrite reports whether a stored value is not in the form the cipher
+// writes: under the current key, or plaintext when there is none
+func (c *FieldCipher) needsRewrite(value []byte) bool {
+	if len(value) == 0 {
+		return false
+	}
+	if c.current == nil {
+		return bytes.HasPrefix(value, []byte(encryptedPrefix))
+	}
+	return !bytes.HasPrefix(value, []byte(encryptedPrefix+c.current.id+":"))
+}
+
+// sealMessage returns a copy of message with its content and media keys encrypted
+func (c *FieldCipher) sealMessage(message *domainChatStorage.Message) (*domainChatStorage.Message, error) {
+	if c == nil {
+		return message, nil
+	}
+
+	sealed := *message
+	var err error
+	if sealed.Content, err = c.encryptString("content", message.Content); err != nil {
+		return nil, err
+	}
+	if sealed.MediaKey, err = c.encrypt("media_key", message.MediaKey); err != nil {
+		return nil, err
+	}
+	if sealed.FileSHA256, err = c.encrypt("file_sha256", message.FileSHA256); err != nil {
+		return nil, err
+	}
+	if sealed.FileEncSHA256, err = c.encrypt("file_enc_sha256", message.FileEncSHA256); err != nil {
+		return nil, err
+	}
+	return &sealed, nil
+}
+
+// openMessage decrypts the content and media keys of a scanned message in place
+func (c *FieldCipher) openMessage(message *domainChatStorage.Message) error {
+	if c == nil {
+		return nil
+	}
+
+	var err error
+	if message.Content, err = c.decryptString("content", message.Content); err != nil {
+		return err
+	}
+	if message.MediaKey, err = c.decrypt("media_key", message.MediaKey); err != nil {
+		return err
+	}
+	if message.FileSHA256, err = c.decrypt("file_sha256", message.FileSHA256); err != nil {
+		return err
+	}
+	message.FileEncSHA256, err = c.decrypt("file_enc_sha256", message.FileEncSHA256)
+	return err
+}
+
+// reencryptMessages rewrites the encrypted columns of messages and edit history
+// that are not in the form c writes, batchSize rows per transaction, so it can
+// run against a database in use
+func reencryptMessages(db *sql.DB, bind func(string) string, c *FieldCipher, batchSize int) (int64, error) {
+	if c == nil {
+		return 0, fmt.Errorf("chat storage encryption is not configured")
+	}
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	messages, err := reencryptMessageRows(db, bind, c, batchSize)
+	if err != nil {
+		return messages, err
+	}
+	edits, err := reencryptEditRows(db, bind, c, batchSize)
+	return messages + edits, err
+}
+
+// rewrite converts a stored value to the form c writes
+func (c *FieldCipher) rewrite(column string, value []byte) ([]byte, error) {
+	plain, err := c.decrypt(column, value)
+	if err != nil {
+		return nil, err
+	}
+	return c.encrypt(column, plain)
+}
+
+func reencryptMessageRows(db *sql.DB, bind func(string) string, c *FieldCipher, batchSize int) (int64, error) {
+	type messageRow struct {
+		deviceID, chatJID, id string
+		values                [4][]byte
+	}
+	columns := [4]string{"content", "media_key", "file_sha256", "file_enc_sha256"}
+
+	var rewritten int64
+	var after messageRow
+	for {
+		rows, err := db.Query(bind(`
+			SELECT device_id, chat_jid, id, content, media_key, file_sha256, file_enc_sha256
+			FROM messages
+			WHERE (device_id, chat_jid, id) > (?, ?, ?)
+			ORDER BY device_id, chat_jid, id
+			LIMIT ?
+		`), after.deviceID, after.chatJID, after.id, batchSize)
+		if err != nil {
+			return rewritten, err
+		}
+
+		var batch []messageRow
+		for rows.Next() {
+			var row messageRow
+			if err := rows.Scan(&row.deviceID, &row.chatJID, &row.id, &row.values[0], &row.values[1], &row.values[2], &row.values[3]); err != nil {
+				rows.Close()
+				return rewritten, err
+			}
+			batch = append(batch, row)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return rewritten, err
+		}
+		if len(batch) == 0 {
+			return rewritten, nil
+		}
+		after = batch[len(batch)-1]
+
+		tx, err := db.Begin()
+		if err != nil {
+			return rewritten, err
+		}
+		update := bind(`
+			UPDATE messages SET content = ?, media_key = ?, file_sha256 = ?, file_enc_sha256 = ?
+			WHERE device_id = ? AND chat_jid = ? AND id = ?
+		`)
+		var changed int64
+		for _, row := range batch {
+			dirty := false
+			for i, value := range row.values {
+				if !c.needsRewrite(value) {
+					continue
+				}
+				if row.values[i], err = c.rewrite(columns[i], value); err != nil {
+					tx.Rollback()
+					return rewritten, fmt.Errorf("message %s: %w", row.id, err)
+				}
+				dirty = true
+			}
+			if !dirty {
+				continue
+			}
+
+			content := sql.NullString{String: string(row.values[0]), Valid: row.values[0] != nil}
+			if _, err := tx.Exec(update, content, row.values[1], row.values[2], row.values[3], row.deviceID, row.chatJID, row.id); err != nil {
+				tx.Rollback()
+				return rewritten, err
+			}
+			changed++
+		}
+		if err := tx.Commit(); err != nil {
+			return rewritten, err
+		}
+		rewritten += changed
+	}
+}
+
+func reencryptEditRows(db *sql.DB, bind func(string) string, c *FieldCipher, batchSize int) (int64, error) {
+	type editRow struct {
+		id      int64
+		content []byte
+	}
+
+	var rewritten int64
+	var afterID int64
+	for {
+		rows, err := db.Query(bind(`SELECT id, content FROM message_edits WHERE id > ? ORDER BY id LIMIT ?`), afterID, batchSize)
+		if err != nil {
+			return rewritten, err
+		}
+
+		var batch []editRow
+		for rows.Next() {
+			var row editRow
+			if err := rows.Scan(&row.id, &row.content); err != nil {
+				rows.Close()
+				return rewritten, err
+			}
+			batch = append(batch, row)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return rewritten, err
+		}
+		if len(batch) == 0 {
+			return rewritten, nil
+		}
+		afterID = batch[len(batch)-1].id
+
+		tx, err := db.Begin()
+		if err != nil {
+			return rewritten, err
+		}
+		update := bind(`UPDATE message_edits SET content = ? WHERE id = ?`)
+		var changed int64
+		for _, row := range batch {
+			if !c.needsRewrite(row.content) {
+				continue
+			}
+			content, err := c.rewrite("content", row.content)
+			if err != nil {
+				tx.Rollback()
+				return rewritten, fmt.Errorf("edit %d: %w", row.id, err)
+			}
+			if _, err := tx.Exec(update, string(content), row.id); err != nil {
+				tx.Rollback()
+				return rewritten, err
+			}
+			changed++
+		}
+		if err := tx.Commit(); err != nil {
+			return rewritten, err
+		}
+		rewritten += changed
+	}
+}
@@ -0,0 +1,179 @@
+package chatstorage
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+)
+
+const (
+	testKey1 = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	testKey2 = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func TestFieldCipher(t *testing.T) {
+	if c, err := NewFieldCipher("", nil); c != nil || err != nil {
+		t.Fatalf("NewFieldCipher(no keys) = %v, %v", c, err)
+	}
+	if _, err := NewFieldCipher("c2hvcnQ=", nil); err == nil {
+		t.Fatal("NewFieldCipher(short key) should fail")
+	}
+
+	c, err := NewFieldCipher(testKey1, nil)
+	if err != nil {
+		t.Fatalf("NewFieldCipher() error = %v", err)
+	}
+	sealed, err := c.encrypt("content", []byte("hello"))
+	if err != nil || !bytes.HasPrefix(sealed, []byte(encryptedPrefix)) {
+		t.Fatalf("encrypt() = %s, %v", sealed, err)
+	}
+	if again, _ := c.encrypt("content", []byte("hello")); bytes.Equal(again, sealed) {
+		t.Error("encrypt() reused a nonce")
+	}
+	if plain, err := c.decrypt("content", sealed); err != nil || string(plain) != "hello" {
+		t.Errorf("decrypt() = %s, %v", plain, err)
+	}
+	if _, err := c.decrypt("media_key", sealed); err == nil {
+		t.Error("decrypt() of a value from another column should fail")
+	}
+	if plain, err := c.decrypt("content", []byte("enc:v1 is just text")); err != nil || string(plain) != "enc:v1 is just text" {
+		t.Errorf("decrypt(plaintext) = %s, %v", plain, err)
+	}
+	if empty, _ := c.encrypt("content", nil); empty != nil {
+		t.Errorf("encrypt(nil) = %v", empty)
+	}
+
+	other, _ := NewFieldCipher(testKey2, nil)
+	if _, err := other.decrypt("content", sealed); err == nil || !strings.Contains(err.Error(), "unknown key") {
+		t.Errorf("decrypt() with another key error = %v", err)
+	}
+	rotated, _ := NewFieldCipher(testKey2, []string{testKey1})
+	if plain, err := rotated.decrypt("content", sealed); err != nil || string(plain) != "hello" {
+		t.Errorf("decrypt() with previous key = %s, %v", plain, err)
+	}
+	if !rotated.needsRewrite(sealed) || c.needsRewrite(sealed) {
+		t.Error("needsRewrite() should only flag values under other keys")
+	}
+}
+
+// withCipher returns a repository on the same database that uses c
+func withCipher(t *testing.T, repo domainChatStorage.IChatStorageRepository, c *FieldCipher) (domainChatStorage.IChatStorageRepository, *sql.DB, func(string) string) {
+	t.Helper()
+	switch r := repo.(type) {
+	case *SQLiteRepository:
+		encrypted := *r
+		encrypted.cipher = c
+		return &encrypted, r.db, sqliteBind
+	case *PostgresRepository:
+		encrypted := *r
+		encrypted.cipher = c
+		return &encrypted, r.db, rebindPostgres
+	}
+	t.Fatalf("unknown repository %T", repo)
+	return nil, nil, nil
+}
+
+func testRepositoryEncryption(t *testing.T, repo domainChatStorage.IChatStorageRepository) {
+	seedMessages(t, repo)
+	chatJID := "111@s.whatsapp.net"
+	if err := repo.ApplyMessageEdit("dev1", chatJID, "m2", "Thanks, see you Monday", suiteBaseTime.Add(time.Hour)); err != nil {
+		t.Fatalf("ApplyMessageEdit() error = %v", err)
+	}
+
+	key1, _ := NewFieldCipher(testKey1, nil)
+	encrypted, db, bind := withCipher(t, repo, key1)
+	storedContent := func(id string) string {
+		t.Helper()
+		var content string
+		if err := db.QueryRow(bind("SELECT content FROM messages WHERE id = ? AND chat_jid = ? AND device_id = ?"), id, chatJID, "dev1").Scan(&content); err != nil {
+			t.Fatalf("read stored content: %v", err)
+		}
+		return content
+	}
+
+	// Plaintext rows stay readable until they are rewritten
+	if message, err := encrypted.GetMessageByID("m2"); err != nil || message.Content != "Thanks, see you Monday" {
+		t.Fatalf("GetMessageByID(plaintext) = %+v, %v", message, err)
+	}
+
+	// m1, m2, m3, m4 and dev2's m1 plus m2's edit history
+	if rewritten, err := encrypted.ReencryptMessages(2); err != nil || rewritten != 6 {
+		t.Fatalf("ReencryptMessages() = %d, %v", rewritten, err)
+	}
+	if rewritten, err := encrypted.ReencryptMessages(2); err != nil || rewritten != 0 {
+		t.Fatalf("second ReencryptMessages() = %d, %v", rewritten, err)
+	}
+	if content := storedContent("m1"); !strings.HasPrefix(content, encryptedPrefix) || strings.Contains(content, "invoice") {
+		t.Fatalf("stored content = %q", content)
+	}
+
+	// New messages and edits are written encrypted and read back in plaintext
+	if err := encrypted.StoreMessage(&domainChatStorage.Message{ID: "m5", ChatJID: chatJID, DeviceID: "dev1", Sender: chatJID, Content: "Secret plans", MediaKey: []byte{9, 9}, Timestamp: suiteBaseTime.Add(10 * time.Minute)}); err != nil {
+		t.Fatalf("StoreMessage() error = %v", err)
+	}
+	if err := encrypted.ApplyMessageEdit("dev1", chatJID, "m2", "Thanks, see you Tuesday", suiteBaseTime.Add(2*time.Hour)); err != nil {
+		t.Fatalf("ApplyMessageEdit(encrypted) error = %v", err)
+	}
+	if content := storedContent("m5"); !strings.HasPrefix(content, encryptedPrefix) {
+		t.Fatalf("stored content of new message = %q", content)
+	}
+	messages, err := encrypted.GetMessages(&domainChatStorage.MessageFilter{DeviceID: "dev1", ChatJID: chatJID})
+	if err != nil {
+		t.Fatalf("GetMessages() error = %v", err)
+	}
+	byID := map[string]*domainChatStorage.Message{}
+	for _, message := range messages {
+		byID[message.ID] = message
+	}
+	if byID["m5"].Content != "Secret plans" || !bytes.Equal(byID["m5"].MediaKey, []byte{9, 9}) || !bytes.Equal(byID["m3"].MediaKey, []byte{1, 2, 3}) {
+		t.Fatalf("decrypted messages = %+v", byID)
+	}
+	if byID["m2"].Content != "Thanks, see you Tuesday" {
+		t.Fatalf("edited content = %q", byID["m2"].Content)
+	}
+	edits, err := encrypted.GetMessageEdits("dev1", chatJID, []string{"m2"})
+	if err != nil || len(edits) != 2 || edits[0].Content != "Thanks, see you tomorrow" || edits[1].Content != "Thanks, see you Monday" {
+		t.Fatalf("GetMessageEdits() = %+v, %v", edits, err)
+	}
+
+	// Search matches the decrypted content
+	results, total, err := encrypted.SearchMessagesFullText(&domainChatStorage.MessageSearchFilter{DeviceID: "dev1", Query: "INVOICE"})
+	if err != nil || total != 2 || len(results) != 2 || results[0].Message.ID != "m3" || results[1].Message.ID != "m1" {
+		t.Fatalf("SearchMessagesFullText() = %d results, %d, %v", len(results), total, err)
+	}
+	if !strings.Contains(results[1].Snippet, domainChatStorage.SearchHighlightStart+"invoice") {
+		t.Errorf("snippet = %q", results[1].Snippet)
+	}
+
+	// Rotation: the new key rewrites everything, after which the old key is not needed
+	rotating, _ := NewFieldCipher(testKey2, []string{testKey1})
+	rotated, _, _ := withCipher(t, repo, rotating)
+	if rewritten, err := rotated.ReencryptMessages(100); err != nil || rewritten != 8 {
+		t.Fatalf("ReencryptMessages(rotate) = %d, %v", rewritten, err)
+	}
+	key2, _ := NewFieldCipher(testKey2, nil)
+	onlyKey2, _, _ := withCipher(t, repo, key2)
+	if message, err := onlyKey2.GetMessageByID("m5"); err != nil || message.Content != "Secret plans" {
+		t.Fatalf("GetMessageByID(new key) = %+v, %v", message, err)
+	}
+	if _, err := encrypted.GetMessageByID("m5"); err == nil {
+		t.Fatal("GetMessageByID() with the old key only should fail")
+	}
+
+	// Turning encryption off decrypts everything again
+	decrypting, _ := NewFieldCipher("", []string{testKey2})
+	decrypted, _, _ := withCipher(t, repo, decrypting)
+	if rewritten, err := decrypted.ReencryptMessages(100); err != nil || rewritten != 8 {
+		t.Fatalf("ReencryptMessages(decrypt) = %d, %v", rewritten, err)
+	}
+	if content := storedContent("m5"); content != "Secret plans" {
+		t.Fatalf("stored content after decrypting = %q", content)
+	}
+	if _, err := repo.ReencryptMessages(100); err == nil {
+		t.Fatal("ReencryptMessages() without keys should fail")
+	}
+}
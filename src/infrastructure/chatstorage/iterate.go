@@ -0,0 +1,61 @@
+package chatstorage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+)
+
+// iterateMessages streams the messages matched by filter to fn, oldest first.
+// The query is written with ? placeholders and passed through bind.
+func iterateMessages(db *sql.DB, bind func(string) string, c *FieldCipher, filter *domainChatStorage.MessageFilter, fn func(*domainChatStorage.Message) error) error {
+	if filter.DeviceID == "" {
+		return fmt.Errorf("device_id is required for iterating messages (data isolation)")
+	}
+
+	conditions := []string{"m.device_id = ?"}
+	args := []any{filter.DeviceID}
+	if filter.ChatJID != "" {
+		conditions = append(conditions, "m.chat_jid = ?")
+		args = append(args, filter.ChatJID)
+	}
+	if filter.StartTime != nil {
+		conditions = append(conditions, "m.timestamp >= ?")
+		args = append(args, *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		conditions = append(conditions, "m.timestamp <= ?")
+		args = append(args, *filter.EndTime)
+	}
+	if filter.MediaOnly {
+		conditions = append(conditions, "m.media_type != ''")
+	}
+	if filter.IsFromMe != nil {
+		conditions = append(conditions, "m.is_from_me = ?")
+		args = append(args, *filter.IsFromMe)
+	}
+
+	rows, err := db.Query(bind(`
+		SELECT `+messageColumns+`
+		FROM messages m
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY m.chat_jid, m.timestamp, m.id
+	`), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		message, err := scanMessageRow(c, rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(message); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
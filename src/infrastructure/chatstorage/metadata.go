@@ -0,0 +1,162 @@
+package chatstorage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+)
+
+// Chat metadata, contact and group member queries are shared by both backends.
+// They are written with ? placeholders and passed through the backend's bind
+// function. Both backends support INSERT ... ON CONFLICT upserts.
+
+// updateChatMetadata applies update to a stored chat
+func updateChatMetadata(db *sql.DB, bind func(string) string, deviceID, chatJID string, update domainChatStorage.ChatMetadataUpdate) error {
+	sets := []string{"updated_at = ?"}
+	args := []any{time.Now()}
+	set := func(assignment string, value any) {
+		sets = append(sets, assignment)
+		args = append(args, value)
+	}
+
+	if update.Name != nil {
+		set("name = ?", *update.Name)
+	}
+	if update.Archived != nil {
+		set("archived = ?", *update.Archived)
+	}
+	if update.Pinned != nil {
+		set("pinned = ?", *update.Pinned)
+	}
+	if update.MutedUntil != nil {
+		var mutedUntil any
+		if !update.MutedUntil.IsZero() {
+			mutedUntil = *update.MutedUntil
+		}
+		set("muted_until = ?", mutedUntil)
+	}
+	if update.UnreadCount != nil {
+		set("unread_count = ?", *update.UnreadCount)
+	} else if update.AddUnread != 0 {
+		set("unread_count = COALESCE(unread_count, 0) + ?", update.AddUnread)
+	}
+	if update.MarkedUnread != nil {
+		set("marked_unread = ?", *update.MarkedUnread)
+	}
+	if len(sets) == 1 {
+		return nil
+	}
+
+	query := "UPDATE chats SET " + strings.Join(sets, ", ") + " WHERE jid = ? AND device_id = ?"
+	_, err := db.Exec(bind(query), append(args, chatJID, deviceID)...)
+	return err
+}
+
+// storeContact upserts a contact, keeping stored names where contact has none
+func storeContact(db *sql.DB, bind func(string) string, contact *domainChatStorage.Contact) error {
+	if contact == nil || contact.DeviceID == "" || contact.JID == "" {
+		return fmt.Errorf("contact with device_id and jid is required")
+	}
+
+	contact.UpdatedAt = time.Now()
+	_, err := db.Exec(bind(`
+		INSERT INTO contacts (device_id, jid, full_name, first_name, push_name, business_name, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (device_id, jid) DO UPDATE SET
+			full_name = COALESCE(NULLIF(excluded.full_name, ''), contacts.full_name),
+			first_name = COALESCE(NULLIF(excluded.first_name, ''), contacts.first_name),
+			push_name = COALESCE(NULLIF(excluded.push_name, ''), contacts.push_name),
+			business_name = COALESCE(NULLIF(excluded.business_name, ''), contacts.business_name),
+			updated_at = excluded.updated_at
+	`), contact.DeviceID, contact.JID, contact.FullName, contact.FirstName, contact.PushName, contact.BusinessName, contact.UpdatedAt)
+	return err
+}
+
+// setGroupParticipants replaces the stored members of a group
+func setGroupParticipants(db *sql.DB, bind func(string) string, deviceID, groupJID string, participants []*domainChatStorage.GroupParticipant) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(bind("DELETE FROM group_participants WHERE device_id = ? AND group_jid = ?"), deviceID, groupJID); err != nil {
+		return err
+	}
+
+	insert := bind(`
+		INSERT INTO group_participants (device_id, group_jid, jid, is_admin, is_super_admin)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (device_id, group_jid, jid) DO UPDATE SET
+			is_admin = excluded.is_admin,
+			is_super_admin = excluded.is_super_admin
+	`)
+	for _, participant := range participants {
+		if _, err := tx.Exec(insert, deviceID, groupJID, participant.JID, participant.IsAdmin, participant.IsSuperAdmin); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// applyGroupParticipantChange adds, removes, promotes and demotes group members
+func applyGroupParticipantChange(db *sql.DB, bind func(string) string, deviceID, groupJID string, change domainChatStorage.GroupParticipantChange) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []struct {
+		query string
+		jids  []string
+	}{
+		{`INSERT INTO group_participants (device_id, group_jid, jid, is_admin, is_super_admin)
+			VALUES (?, ?, ?, FALSE, FALSE)
+			ON CONFLICT (device_id, group_jid, jid) DO NOTHING`, change.Join},
+		{`DELETE FROM group_participants WHERE device_id = ? AND group_jid = ? AND jid = ?`, change.Leave},
+		{`INSERT INTO group_participants (device_id, group_jid, jid, is_admin, is_super_admin)
+			VALUES (?, ?, ?, TRUE, FALSE)
+			ON CONFLICT (device_id, group_jid, jid) DO UPDATE SET is_admin = TRUE`, change.Promote},
+		{`UPDATE group_participants SET is_admin = FALSE, is_super_admin = FALSE
+			WHERE device_id = ? AND group_jid = ? AND jid = ?`, change.Demote},
+	}
+	for _, statement := range statements {
+		query := bind(statement.query)
+		for _, jid := range statement.jids {
+			if _, err := tx.Exec(query, deviceID, groupJID, jid); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// getGroupParticipants returns the stored members of a group, admins first
+func getGroupParticipants(db *sql.DB, bind func(string) string, deviceID, groupJID string) ([]*domainChatStorage.GroupParticipant, error) {
+	rows, err := db.Query(bind(`
+		SELECT device_id, group_jid, jid, is_admin, is_super_admin
+		FROM group_participants
+		WHERE device_id = ? AND group_jid = ?
+		ORDER BY is_super_admin DESC, is_admin DESC, jid
+	`), deviceID, groupJID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var participants []*domainChatStorage.GroupParticipant
+	for rows.Next() {
+		participant := &domainChatStorage.GroupParticipant{}
+		if err := rows.Scan(&participant.DeviceID, &participant.GroupJID, &participant.JID, &participant.IsAdmin, &participant.IsSuperAdmin); err != nil {
+			return nil, err
+		}
+		participants = append(participants, participant)
+	}
+	return participants, rows.Err()
+}
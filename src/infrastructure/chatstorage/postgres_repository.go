@@ -0,0 +1,1238 @@
+package chatstorage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// PostgresRepository implements IChatStorageRepository using PostgreSQL.
+// Writes are single-statement upserts so several replicas can share one database.
+type PostgresRepository struct {
+	db     *sql.DB
+	cipher *FieldCipher // nil stores message content in plaintext
+}
+
+// NewPostgresStorageRepository creates a new PostgreSQL repository
+func NewPostgresStorageRepository(db *sql.DB, cipher *FieldCipher) domainChatStorage.IChatStorageRepository {
+	return &PostgresRepository{db: db, cipher: cipher}
+}
+
+// IsPostgresURI reports whether a storage URI selects the PostgreSQL backend
+func IsPostgresURI(uri string) bool {
+	return strings.HasPrefix(uri, "postgres:") || strings.HasPrefix(uri, "postgresql:")
+}
+
+// pgArgs collects query arguments and hands out their $N placeholders
+type pgArgs []any
+
+func (a *pgArgs) add(value any) string {
+	*a = append(*a, value)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// inList renders values as a parenthesized list of placeholders
+func (a *pgArgs) inList(values []string) string {
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = a.add(value)
+	}
+	return "(" + strings.Join(placeholders, ", ") + ")"
+}
+
+// rebindPostgres rewrites ? placeholders of a query shared with SQLite as $N
+func rebindPostgres(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// StoreChat creates or updates a chat
+func (r *PostgresRepository) StoreChat(chat *domainChatStorage.Chat) error {
+	now := time.Now()
+	chat.UpdatedAt = now
+
+	_, err := r.db.Exec(`
+		INSERT INTO chats (jid, device_id, name, last_message_time, ephemeral_expiration, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (jid, device_id) DO UPDATE SET
+			name = EXCLUDED.name,
+			last_message_time = EXCLUDED.last_message_time,
+			ephemeral_expiration = EXCLUDED.ephemeral_expiration,
+			updated_at = EXCLUDED.updated_at
+	`, chat.JID, chat.DeviceID, chat.Name, chat.LastMessageTime, chat.EphemeralExpiration, now, chat.UpdatedAt)
+	return err
+}
+
+// GetChat retrieves a chat by JID
+func (r *PostgresRepository) GetChat(jid string) (*domainChatStorage.Chat, error) {
+	chat, err := r.scanChat(r.db.QueryRow(`
+		SELECT `+chatColumns+`
+		FROM chats c
+		WHERE c.jid = $1
+		LIMIT 1
+	`, jid))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return chat, err
+}
+
+// GetChatByDevice retrieves a chat by JID for a specific device
+func (r *PostgresRepository) GetChatByDevice(deviceID, jid string) (*domainChatStorage.Chat, error) {
+	chat, err := r.scanChat(r.db.QueryRow(`
+		SELECT `+chatColumns+`
+		FROM chats c
+		WHERE c.jid = $1 AND c.device_id = $2
+	`, jid, deviceID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return chat, err
+}
+
+// GetMessageByID retrieves a message by its ID from any chat
+func (r *PostgresRepository) GetMessageByID(id string) (*domainChatStorage.Message, error) {
+	message, err := r.scanMessage(r.db.QueryRow(`
+		SELECT `+messageColumns+`
+		FROM messages m
+		WHERE m.id = $1
+		LIMIT 1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return message, err
+}
+
+// GetChats retrieves chats with filtering
+func (r *PostgresRepository) GetChats(filter *domainChatStorage.ChatFilter) ([]*domainChatStorage.Chat, error) {
+	var conditions []string
+	var args pgArgs
+
+	if filter.SearchName != "" {
+		// Saved contact names match as well
+		search := args.add("%" + filter.SearchName + "%")
+		conditions = append(conditions, `(c.name ILIKE `+search+` OR EXISTS (
+			SELECT 1 FROM contacts ct
+			WHERE ct.device_id = c.device_id AND ct.jid = c.jid AND (ct.full_name ILIKE `+search+` OR ct.push_name ILIKE `+search+`)
+		))`)
+	}
+	if filter.Unread != nil {
+		if *filter.Unread {
+			conditions = append(conditions, "(c.unread_count > 0 OR c.marked_unread)")
+		} else {
+			conditions = append(conditions, "NOT (c.unread_count > 0 OR c.marked_unread)")
+		}
+	}
+	if filter.Archived != nil {
+		conditions = append(conditions, "c.archived = "+args.add(*filter.Archived))
+	}
+	if filter.Pinned != nil {
+		conditions = append(conditions, "c.pinned = "+args.add(*filter.Pinned))
+	}
+	if filter.HasMedia {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM messages m
+			WHERE m.chat_jid = c.jid AND m.device_id = c.device_id AND m.media_type != ''
+		)`)
+	}
+	if filter.DeviceID != "" {
+		conditions = append(conditions, "c.device_id = "+args.add(filter.DeviceID))
+	}
+	if filter.Before != nil {
+		conditions = append(conditions, "(c.last_message_time, c.jid) < ("+args.add(filter.Before.LastMessageTime)+", "+args.add(filter.Before.JID)+")")
+	}
+
+	query := `
+		SELECT ` + chatColumns + `
+		FROM chats c
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY c.last_message_time DESC, c.jid DESC"
+
+	if filter.Limit > 0 {
+		if filter.Limit > 1000 {
+			filter.Limit = 1000
+		}
+		query += " LIMIT " + args.add(filter.Limit)
+		if filter.Offset > 0 && filter.Before == nil {
+			query += " OFFSET " + args.add(filter.Offset)
+		}
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chats []*domainChatStorage.Chat
+	for rows.Next() {
+		chat, err := r.scanChat(rows)
+		if err != nil {
+			return nil, err
+		}
+		chats = append(chats, chat)
+	}
+
+	return chats, rows.Err()
+}
+
+// DeleteChat deletes a chat and all its messages
+func (r *PostgresRepository) DeleteChat(jid string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM messages WHERE chat_jid = $1", jid); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM chats WHERE jid = $1", jid); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteChatByDevice deletes a chat and all its messages for a specific device
+func (r *PostgresRepository) DeleteChatByDevice(deviceID, jid string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM messages WHERE chat_jid = $1 AND device_id = $2", jid, deviceID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM chats WHERE jid = $1 AND device_id = $2", jid, deviceID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const postgresUpsertMessage = `
+	INSERT INTO messages (
+		id, chat_jid, device_id, sender, content, timestamp, is_from_me,
+		media_type, filename, url, media_key, file_sha256,
+		file_enc_sha256, file_length, mimetype, is_ptt, created_at, updated_at, status
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+	ON CONFLICT (id, chat_jid, device_id) DO UPDATE SET
+		sender = EXCLUDED.sender,
+		content = EXCLUDED.content,
+		timestamp = EXCLUDED.timestamp,
+		is_from_me = EXCLUDED.is_from_me,
+		media_type = EXCLUDED.media_type,
+		filename = EXCLUDED.filename,
+		url = EXCLUDED.url,
+		media_key = EXCLUDED.media_key,
+		file_sha256 = EXCLUDED.file_sha256,
+		file_enc_sha256 = EXCLUDED.file_enc_sha256,
+		file_length = EXCLUDED.file_length,
+		mimetype = EXCLUDED.mimetype,
+		is_ptt = EXCLUDED.is_ptt,
+		updated_at = EXCLUDED.updated_at
+`
+
+func postgresMessageArgs(message *domainChatStorage.Message) []any {
+	return []any{
+		message.ID, message.ChatJID, message.DeviceID, message.Sender, message.Content,
+		message.Timestamp, message.IsFromMe, message.MediaType, message.Filename,
+		message.URL, message.MediaKey, message.FileSHA256, message.FileEncSHA256,
+		int64(message.FileLength), message.Mimetype, message.IsPTT, message.CreatedAt, message.UpdatedAt, message.Status,
+	}
+}
+
+// StoreMessage creates or updates a message
+func (r *PostgresRepository) StoreMessage(message *domainChatStorage.Message) error {
+	now := time.Now()
+	message.CreatedAt = now
+	message.UpdatedAt = now
+
+	// Skip empty messages
+	if message.Content == "" && message.MediaType == "" {
+		return nil
+	}
+
+	stored, err := r.cipher.sealMessage(message)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(postgresUpsertMessage, postgresMessageArgs(stored)...)
+	return err
+}
+
+// StoreMessagesBatch creates or updates multiple messages in a single transaction
+func (r *PostgresRepository) StoreMessagesBatch(messages []*domainChatStorage.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(postgresUpsertMessage)
+	if err != nil {
+		return fmt.Errorf("failed to prepare upsert statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, message := range messages {
+		if message.Content == "" && message.MediaType == "" {
+			continue
+		}
+
+		message.CreatedAt = now
+		message.UpdatedAt = now
+		stored, err := r.cipher.sealMessage(message)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt message %s: %w", message.ID, err)
+		}
+
+		if _, err := stmt.Exec(postgresMessageArgs(stored)...); err != nil {
+			return fmt.Errorf("failed to store message %s: %w", message.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetMessages retrieves messages with filtering
+func (r *PostgresRepository) GetMessages(filter *domainChatStorage.MessageFilter) ([]*domainChatStorage.Message, error) {
+	// Require device_id for data isolation - fail fast if missing
+	if filter.DeviceID == "" {
+		return nil, fmt.Errorf("device_id is required for message queries (data isolation)")
+	}
+
+	var args pgArgs
+	conditions := []string{
+		"m.chat_jid = " + args.add(filter.ChatJID),
+		"m.device_id = " + args.add(filter.DeviceID),
+	}
+
+	if filter.StartTime != nil {
+		conditions = append(conditions, "m.timestamp >= "+args.add(*filter.StartTime))
+	}
+	if filter.EndTime != nil {
+		conditions = append(conditions, "m.timestamp <= "+args.add(*filter.EndTime))
+	}
+	if filter.MediaOnly {
+		conditions = append(conditions, "m.media_type != ''")
+	}
+	if filter.IsFromMe != nil {
+		conditions = append(conditions, "m.is_from_me = "+args.add(*filter.IsFromMe))
+	}
+	if filter.Before != nil {
+		conditions = append(conditions, "(m.timestamp, m.id) < ("+args.add(filter.Before.Timestamp)+", "+args.add(filter.Before.ID)+")")
+	}
+
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages m
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY m.timestamp DESC, m.id DESC
+	`
+
+	if filter.Limit > 0 {
+		if filter.Limit > 1000 {
+			filter.Limit = 1000
+		}
+		query += " LIMIT " + args.add(filter.Limit)
+		if filter.Offset > 0 && filter.Before == nil {
+			query += " OFFSET " + args.add(filter.Offset)
+		}
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*domainChatStorage.Message
+	for rows.Next() {
+		message, err := r.scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
+
+// SearchMessages performs database-level search for messages in one chat,
+// most recent first
+func (r *PostgresRepository) SearchMessages(deviceID, chatJID, searchText string, limit int) ([]*domainChatStorage.Message, error) {
+	results, _, err := r.SearchMessagesFullText(&domainChatStorage.MessageSearchFilter{
+		DeviceID: deviceID,
+		ChatJID:  chatJID,
+		Query:    searchText,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*domainChatStorage.Message, len(results))
+	for i, result := range results {
+		messages[i] = result.Message
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Timestamp.After(messages[j].Timestamp)
+	})
+	return messages, nil
+}
+
+// buildTSQuery renders parsed terms as a to_tsquery expression. Words are
+// quoted lexemes, phrases use the followed-by operator and prefixes :*.
+func buildTSQuery(groups [][]searchTerm) string {
+	var parts []string
+	for _, group := range groups {
+		var terms []string
+		for _, term := range group {
+			words := strings.FieldsFunc(term.text, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+			})
+			if len(words) == 0 {
+				continue
+			}
+			for i, word := range words {
+				words[i] = "'" + strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(word) + "'"
+			}
+			if term.prefix {
+				words[len(words)-1] += ":*"
+			}
+			terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+		}
+		if len(terms) > 0 {
+			parts = append(parts, "("+strings.Join(terms, " & ")+")")
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// SearchMessagesFullText searches the search_vector column (message content
+// and filename) across the device's chats, best matches first
+func (r *PostgresRepository) SearchMessagesFullText(filter *domainChatStorage.MessageSearchFilter) ([]*domainChatStorage.MessageSearchResult, int64, error) {
+	// Require device_id for data isolation - fail fast if missing
+	if filter.DeviceID == "" {
+		return nil, 0, fmt.Errorf("device_id is required for message search (data isolation)")
+	}
+
+	groups := parseSearchQuery(filter.Query)
+	tsQuery := buildTSQuery(groups)
+	if tsQuery == "" {
+		return []*domainChatStorage.MessageSearchResult{}, 0, nil
+	}
+
+	if filter.Limit <= 0 || filter.Limit > 1000 {
+		filter.Limit = 1000
+	}
+	if r.cipher != nil {
+		return searchDecrypted(r.db, rebindPostgres, r.cipher, filter, groups)
+	}
+
+	var args pgArgs
+	from := "FROM messages m, to_tsquery('simple', " + args.add(tsQuery) + ") q"
+	conditions := []string{
+		"m.search_vector @@ q",
+		"m.device_id = " + args.add(filter.DeviceID),
+	}
+	if filter.ChatJID != "" {
+		conditions = append(conditions, "m.chat_jid = "+args.add(filter.ChatJID))
+	}
+	if filter.StartTime != nil {
+		conditions = append(conditions, "m.timestamp >= "+args.add(*filter.StartTime))
+	}
+	if filter.EndTime != nil {
+		conditions = append(conditions, "m.timestamp <= "+args.add(*filter.EndTime))
+	}
+	if filter.MediaOnly {
+		conditions = append(conditions, "m.media_type != ''")
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := r.db.QueryRow("SELECT COUNT(*) "+from+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	headlineOptions := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=24, MinWords=8, MaxFragments=2, FragmentDelimiter=\" … \"",
+		domainChatStorage.SearchHighlightStart, domainChatStorage.SearchHighlightEnd)
+	query := `
+		SELECT ` + messageColumns + `,
+			ts_headline('simple', CASE WHEN COALESCE(m.content, '') != '' THEN m.content ELSE COALESCE(m.filename, '') END, q, ` + args.add(headlineOptions) + `),
+			ts_rank_cd(m.search_vector, q)
+		` + from + where + `
+		ORDER BY ts_rank_cd(m.search_vector, q) DESC, m.timestamp DESC
+		LIMIT ` + args.add(filter.Limit) + ` OFFSET ` + args.add(filter.Offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search messages: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*domainChatStorage.MessageSearchResult{}
+	for rows.Next() {
+		result := &domainChatStorage.MessageSearchResult{}
+		message, err := scanMessageRow(r.cipher, rows, &result.Snippet, &result.Rank)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan message: %w", err)
+		}
+		result.Message = message
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating messages: %w", err)
+	}
+	return results, total, nil
+}
+
+// DeleteMessage deletes a specific message
+func (r *PostgresRepository) DeleteMessage(id, chatJID string) error {
+	_, err := r.db.Exec("DELETE FROM messages WHERE id = $1 AND chat_jid = $2", id, chatJID)
+	return err
+}
+
+// DeleteMessageByDevice deletes a specific message for a specific device
+func (r *PostgresRepository) DeleteMessageByDevice(deviceID, id, chatJID string) error {
+	_, err := r.db.Exec("DELETE FROM messages WHERE id = $1 AND chat_jid = $2 AND device_id = $3", id, chatJID, deviceID)
+	return err
+}
+
+func (r *PostgresRepository) getCount(query string, args ...any) (int64, error) {
+	var count int64
+	err := r.db.QueryRow(query, args...).Scan(&count)
+	return count, err
+}
+
+// scanMessage is a private helper for scanning message rows
+func (r *PostgresRepository) scanMessage(scanner interface{ Scan(...any) error }) (*domainChatStorage.Message, error) {
+	return scanMessageRow(r.cipher, scanner)
+}
+
+// scanChat is a private helper for scanning chat rows
+func (r *PostgresRepository) scanChat(scanner interface{ Scan(...any) error }) (*domainChatStorage.Chat, error) {
+	return scanChatRow(scanner)
+}
+
+// GetChatMessageCount returns the number of messages in a chat
+func (r *PostgresRepository) GetChatMessageCount(chatJID string) (int64, error) {
+	return r.getCount("SELECT COUNT(*) FROM messages WHERE chat_jid = $1", chatJID)
+}
+
+// GetChatMessageCountByDevice returns the number of messages in a chat for a specific device
+func (r *PostgresRepository) GetChatMessageCountByDevice(deviceID, chatJID string) (int64, error) {
+	return r.getCount("SELECT COUNT(*) FROM messages WHERE chat_jid = $1 AND device_id = $2", chatJID, deviceID)
+}
+
+// GetTotalMessageCount returns the total number of messages
+func (r *PostgresRepository) GetTotalMessageCount() (int64, error) {
+	return r.getCount("SELECT COUNT(*) FROM messages")
+}
+
+// GetTotalChatCount returns the total number of chats
+func (r *PostgresRepository) GetTotalChatCount() (int64, error) {
+	return r.getCount("SELECT COUNT(*) FROM chats")
+}
+
+// GetChatNameWithPushName determines the appropriate name for a chat with pushname support
+func (r *PostgresRepository) GetChatNameWithPushName(jid types.JID, chatJID string, senderUser string, pushName string) string {
+	existingChat, err := r.GetChat(chatJID)
+	if err != nil {
+		existingChat = nil
+	}
+	return chatNameWithPushName(existingChat, jid, senderUser, pushName)
+}
+
+// GetChatNameWithPushNameByDevice determines the appropriate name for a chat with pushname support (device-scoped)
+func (r *PostgresRepository) GetChatNameWithPushNameByDevice(deviceID string, jid types.JID, chatJID string, senderUser string, pushName string) string {
+	// Special handling for status@broadcast - always return "Status"
+	if chatJID == "status@broadcast" || jid.String() == "status@broadcast" {
+		return "Status"
+	}
+
+	existingChat, err := r.GetChatByDevice(deviceID, chatJID)
+	if err != nil {
+		existingChat = nil
+	}
+	return chatNameWithPushName(existingChat, jid, senderUser, pushName)
+}
+
+// GetMessageAnalytics aggregates the messages selected by filter
+func (r *PostgresRepository) GetMessageAnalytics(filter *domainChatStorage.AnalyticsFilter) (*domainChatStorage.MessageAnalytics, error) {
+	return getMessageAnalytics(r.db, postgresAnalytics, filter)
+}
+
+// GetStorageStatistics returns current storage statistics for logging purposes
+func (r *PostgresRepository) GetStorageStatistics() (chatCount int64, messageCount int64, err error) {
+	return storageStatistics(r)
+}
+
+func (r *PostgresRepository) CreateMessage(ctx context.Context, evt *events.Message) error {
+	return createMessage(ctx, r, evt)
+}
+
+// StoreSentMessageWithContext stores a message that was sent by the user with context cancellation support
+func (r *PostgresRepository) StoreSentMessageWithContext(ctx context.Context, messageID string, senderJID string, recipientJID string, content string, timestamp time.Time) error {
+	return storeSentMessage(ctx, r, messageID, senderJID, recipientJID, content, timestamp)
+}
+
+// ApplyMessageEdit replaces a message's content and keeps the previous version in message_edits
+func (r *PostgresRepository) ApplyMessageEdit(deviceID, chatJID, messageID, content string, editedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var previous sql.NullString
+	err = tx.QueryRow(
+		"SELECT content FROM messages WHERE id = $1 AND chat_jid = $2 AND device_id = $3 FOR UPDATE",
+		messageID, chatJID, deviceID,
+	).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// The same edit can arrive more than once
+	previousContent, err := r.cipher.decryptString("content", previous.String)
+	if err != nil {
+		return err
+	}
+	if previousContent == content {
+		return nil
+	}
+	storedContent, err := r.cipher.encryptString("content", content)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO message_edits (message_id, chat_jid, device_id, content, edited_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, messageID, chatJID, deviceID, previous.String, editedAt); err != nil {
+		return fmt.Errorf("failed to store edit history: %w", err)
+	}
+	if _, err := tx.Exec(`
+		UPDATE messages SET content = $1, edited_at = $2, updated_at = $3
+		WHERE id = $4 AND chat_jid = $5 AND device_id = $6
+	`, storedContent, editedAt, time.Now(), messageID, chatJID, deviceID); err != nil {
+		return fmt.Errorf("failed to update message: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// MarkMessageRevoked flags a message as deleted for everyone, keeping its content
+func (r *PostgresRepository) MarkMessageRevoked(deviceID, chatJID, messageID string, revokedAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE messages SET is_revoked = TRUE, revoked_at = $1, updated_at = $2
+		WHERE id = $3 AND chat_jid = $4 AND device_id = $5 AND NOT COALESCE(is_revoked, FALSE)
+	`, revokedAt, time.Now(), messageID, chatJID, deviceID)
+	return err
+}
+
+// SetMessageStarred records whether a message is starred
+func (r *PostgresRepository) SetMessageStarred(deviceID, chatJID, messageID string, starred bool) error {
+	_, err := r.db.Exec(`
+		UPDATE messages SET is_starred = $1, updated_at = $2
+		WHERE id = $3 AND chat_jid = $4 AND device_id = $5
+	`, starred, time.Now(), messageID, chatJID, deviceID)
+	return err
+}
+
+// SetMessageMediaPath records where the media of a message was downloaded to
+func (r *PostgresRepository) SetMessageMediaPath(deviceID, chatJID, messageID, mediaPath string) error {
+	_, err := r.db.Exec(`
+		UPDATE messages SET media_path = $1, updated_at = $2
+		WHERE id = $3 AND chat_jid = $4 AND device_id = $5
+	`, mediaPath, time.Now(), messageID, chatJID, deviceID)
+	return err
+}
+
+// StoreReaction sets or, when the reaction is empty, removes a sender's reaction
+func (r *PostgresRepository) StoreReaction(reaction *domainChatStorage.MessageReaction) error {
+	if reaction.Reaction == "" {
+		_, err := r.db.Exec(`
+			DELETE FROM message_reactions
+			WHERE message_id = $1 AND chat_jid = $2 AND device_id = $3 AND sender = $4
+		`, reaction.MessageID, reaction.ChatJID, reaction.DeviceID, reaction.Sender)
+		return err
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO message_reactions (message_id, chat_jid, device_id, sender, reaction, timestamp)
+		SELECT $1, $2, $3, $4, $5, $6
+		WHERE EXISTS (SELECT 1 FROM messages WHERE id = $1 AND chat_jid = $2 AND device_id = $3)
+		ON CONFLICT (message_id, chat_jid, device_id, sender) DO UPDATE SET
+			reaction = EXCLUDED.reaction,
+			timestamp = EXCLUDED.timestamp
+	`, reaction.MessageID, reaction.ChatJID, reaction.DeviceID, reaction.Sender, reaction.Reaction, reaction.Timestamp)
+	return err
+}
+
+// UpdateMessageStatus moves messages forward to status, never back
+func (r *PostgresRepository) UpdateMessageStatus(deviceID, chatJID string, messageIDs []string, status string) error {
+	previous := statusesBefore(status)
+	if previous == nil {
+		return fmt.Errorf("unknown message status %q", status)
+	}
+	if len(messageIDs) == 0 {
+		return nil
+	}
+
+	var args pgArgs
+	query := `UPDATE messages SET status = ` + args.add(status) + `, updated_at = ` + args.add(time.Now()) + `
+		WHERE device_id = ` + args.add(deviceID) + ` AND chat_jid = ` + args.add(chatJID) + `
+			AND id IN ` + args.inList(messageIDs) + `
+			AND COALESCE(status, '') IN ` + args.inList(previous)
+
+	_, err := r.db.Exec(query, args...)
+	return err
+}
+
+// GetMessageReactions returns the reactions to the given messages, oldest first
+func (r *PostgresRepository) GetMessageReactions(deviceID, chatJID string, messageIDs []string) ([]*domainChatStorage.MessageReaction, error) {
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
+
+	var args pgArgs
+	query := `
+		SELECT message_id, chat_jid, device_id, sender, reaction, timestamp
+		FROM message_reactions
+		WHERE device_id = ` + args.add(deviceID) + ` AND chat_jid = ` + args.add(chatJID) + `
+			AND message_id IN ` + args.inList(messageIDs) + `
+		ORDER BY timestamp ASC`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reactions []*domainChatStorage.MessageReaction
+	for rows.Next() {
+		reaction := &domainChatStorage.MessageReaction{}
+		if err := rows.Scan(&reaction.MessageID, &reaction.ChatJID, &reaction.DeviceID, &reaction.Sender, &reaction.Reaction, &reaction.Timestamp); err != nil {
+			return nil, err
+		}
+		reactions = append(reactions, reaction)
+	}
+
+	return reactions, rows.Err()
+}
+
+// GetMessageEdits returns the edit history of the given messages, oldest first
+func (r *PostgresRepository) GetMessageEdits(deviceID, chatJID string, messageIDs []string) ([]*domainChatStorage.MessageEdit, error) {
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
+
+	var args pgArgs
+	query := `
+		SELECT message_id, chat_jid, device_id, COALESCE(content, ''), edited_at
+		FROM message_edits
+		WHERE device_id = ` + args.add(deviceID) + ` AND chat_jid = ` + args.add(chatJID) + `
+			AND message_id IN ` + args.inList(messageIDs) + `
+		ORDER BY edited_at ASC, id ASC`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edits []*domainChatStorage.MessageEdit
+	for rows.Next() {
+		edit := &domainChatStorage.MessageEdit{}
+		if err := rows.Scan(&edit.MessageID, &edit.ChatJID, &edit.DeviceID, &edit.Content, &edit.EditedAt); err != nil {
+			return nil, err
+		}
+		if edit.Content, err = r.cipher.decryptString("content", edit.Content); err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit)
+	}
+
+	return edits, rows.Err()
+}
+
+// IterateMessages streams the messages matched by filter to fn, oldest first
+func (r *PostgresRepository) IterateMessages(filter *domainChatStorage.MessageFilter, fn func(*domainChatStorage.Message) error) error {
+	return iterateMessages(r.db, rebindPostgres, r.cipher, filter, fn)
+}
+
+// ListMessageDeviceIDs returns every device that has stored messages
+func (r *PostgresRepository) ListMessageDeviceIDs() ([]string, error) {
+	return listMessageDeviceIDs(r.db)
+}
+
+// PreviewPrune counts the messages PruneMessages would delete for policy
+func (r *PostgresRepository) PreviewPrune(policy domainChatStorage.RetentionPolicy) (*domainChatStorage.PrunePreview, error) {
+	return previewPrune(r.db, rebindPostgres, policy)
+}
+
+// PruneMessages deletes at most limit messages selected by policy
+func (r *PostgresRepository) PruneMessages(policy domainChatStorage.RetentionPolicy, limit int) (int64, error) {
+	return pruneMessages(r.db, rebindPostgres, policy, limit)
+}
+
+// UpdateChatMetadata applies a chat list state change to a stored chat
+func (r *PostgresRepository) UpdateChatMetadata(deviceID, chatJID string, update domainChatStorage.ChatMetadataUpdate) error {
+	return updateChatMetadata(r.db, rebindPostgres, deviceID, chatJID, update)
+}
+
+// StoreContact merges a contact into the stored one
+func (r *PostgresRepository) StoreContact(contact *domainChatStorage.Contact) error {
+	return storeContact(r.db, rebindPostgres, contact)
+}
+
+// SetGroupParticipants replaces the stored members of a group
+func (r *PostgresRepository) SetGroupParticipants(deviceID, groupJID string, participants []*domainChatStorage.GroupParticipant) error {
+	return setGroupParticipants(r.db, rebindPostgres, deviceID, groupJID, participants)
+}
+
+// ApplyGroupParticipantChange updates the stored members of a group
+func (r *PostgresRepository) ApplyGroupParticipantChange(deviceID, groupJID string, change domainChatStorage.GroupParticipantChange) error {
+	return applyGroupParticipantChange(r.db, rebindPostgres, deviceID, groupJID, change)
+}
+
+// GetGroupParticipants returns the stored members of a group
+func (r *PostgresRepository) GetGroupParticipants(deviceID, groupJID string) ([]*domainChatStorage.GroupParticipant, error) {
+	return getGroupParticipants(r.db, rebindPostgres, deviceID, groupJID)
+}
+
+// ReencryptMessages rewrites encrypted columns to the current encryption key
+func (r *PostgresRepository) ReencryptMessages(batchSize int) (int64, error) {
+	return reencryptMessages(r.db, rebindPostgres, r.cipher, batchSize)
+}
+
+// GetMessageChanges returns the messages and deletions after filter.Since
+func (r *PostgresRepository) GetMessageChanges(filter *domainChatStorage.MessageChangeFilter) (*domainChatStorage.MessageChanges, error) {
+	return getMessageChanges(r.db, rebindPostgres, r.cipher, filter)
+}
+
+// PruneMessageDeletions forgets deletions recorded before olderThan
+func (r *PostgresRepository) PruneMessageDeletions(olderThan time.Time) (int64, error) {
+	return pruneMessageDeletions(r.db, rebindPostgres, olderThan)
+}
+
+// TruncateAllChats deletes all chats and messages
+func (r *PostgresRepository) TruncateAllChats() error {
+	_, err := r.db.Exec("TRUNCATE TABLE message_reactions, message_edits, message_deletions, messages, chats, contacts, group_participants")
+	if err != nil {
+		return fmt.Errorf("failed to truncate chats: %w", err)
+	}
+	return nil
+}
+
+// TruncateAllDataWithLogging performs truncation with detailed logging
+func (r *PostgresRepository) TruncateAllDataWithLogging(logPrefix string) error {
+	return truncateAllDataWithLogging(r, logPrefix)
+}
+
+// DeleteDeviceData deletes all chats and messages for a specific device_id.
+func (r *PostgresRepository) DeleteDeviceData(deviceID string) error {
+	if deviceID == "" {
+		return fmt.Errorf("device id is required")
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM messages WHERE device_id = $1", deviceID); err != nil {
+		return fmt.Errorf("failed to delete device messages: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM chats WHERE device_id = $1", deviceID); err != nil {
+		return fmt.Errorf("failed to delete device chats: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM message_deletions WHERE device_id = $1", deviceID); err != nil {
+		return fmt.Errorf("failed to delete device message deletions: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM contacts WHERE device_id = $1", deviceID); err != nil {
+		return fmt.Errorf("failed to delete device contacts: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM group_participants WHERE device_id = $1", deviceID); err != nil {
+		return fmt.Errorf("failed to delete device group participants: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// SaveDeviceRecord upserts a device registration for persistence across restarts.
+func (r *PostgresRepository) SaveDeviceRecord(record *domainChatStorage.DeviceRecord) error {
+	if record == nil || strings.TrimSpace(record.DeviceID) == "" {
+		return fmt.Errorf("device record with id is required")
+	}
+
+	now := time.Now()
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = now
+	}
+	record.UpdatedAt = now
+
+	_, err := r.db.Exec(`
+		INSERT INTO devices (device_id, display_name, jid, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (device_id) DO UPDATE SET
+			display_name = EXCLUDED.display_name,
+			jid = EXCLUDED.jid,
+			updated_at = EXCLUDED.updated_at
+	`, record.DeviceID, record.DisplayName, record.JID, record.CreatedAt, record.UpdatedAt)
+	return err
+}
+
+// ListDeviceRecords returns all registered devices.
+func (r *PostgresRepository) ListDeviceRecords() ([]*domainChatStorage.DeviceRecord, error) {
+	rows, err := r.db.Query(`
+		SELECT device_id, display_name, jid, created_at, updated_at
+		FROM devices
+		ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*domainChatStorage.DeviceRecord
+	for rows.Next() {
+		var rec domainChatStorage.DeviceRecord
+		if err := rows.Scan(&rec.DeviceID, &rec.DisplayName, &rec.JID, &rec.CreatedAt, &rec.UpdatedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, &rec)
+	}
+
+	return records, rows.Err()
+}
+
+// GetDeviceRecord fetches a device registration by id.
+func (r *PostgresRepository) GetDeviceRecord(deviceID string) (*domainChatStorage.DeviceRecord, error) {
+	if strings.TrimSpace(deviceID) == "" {
+		return nil, fmt.Errorf("device id is required")
+	}
+
+	rec := &domainChatStorage.DeviceRecord{}
+	err := r.db.QueryRow(`
+		SELECT device_id, display_name, jid, created_at, updated_at
+		FROM devices
+		WHERE device_id = $1
+	`, deviceID).Scan(&rec.DeviceID, &rec.DisplayName, &rec.JID, &rec.CreatedAt, &rec.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// DeleteDeviceRecord removes a device registration entry.
+func (r *PostgresRepository) DeleteDeviceRecord(deviceID string) error {
+	if strings.TrimSpace(deviceID) == "" {
+		return fmt.Errorf("device id is required")
+	}
+	_, err := r.db.Exec("DELETE FROM devices WHERE device_id = $1", deviceID)
+	return err
+}
+
+// _____________________________________________________________________________________________________________________
+
+// InitializeSchema creates or migrates the database schema
+func (r *PostgresRepository) InitializeSchema() error {
+	if _, err := r.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_info (
+			version INTEGER PRIMARY KEY,
+			updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return err
+	}
+
+	var version int
+	if err := r.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_info").Scan(&version); err != nil {
+		return err
+	}
+
+	migrations := r.getMigrations()
+	for i := version; i < len(migrations); i++ {
+		if err := r.runMigration(migrations[i], i+1); err != nil {
+			return fmt.Errorf("failed to run migration %d: %w", i+1, err)
+		}
+	}
+
+	return r.ensureSearchIndex()
+}
+
+// ensureSearchIndex keeps the search_vector column and its index only while
+// message content is stored in plaintext. With encryption on they would only
+// index ciphertext, so they are dropped, and added back when it is turned off.
+func (r *PostgresRepository) ensureSearchIndex() error {
+	if r.cipher != nil {
+		if _, err := r.db.Exec(`ALTER TABLE messages DROP COLUMN IF EXISTS search_vector`); err != nil {
+			return fmt.Errorf("failed to drop search index: %w", err)
+		}
+		return nil
+	}
+
+	statements := []string{
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+			setweight(to_tsvector('simple', COALESCE(content, '')), 'A') ||
+			setweight(to_tsvector('simple', COALESCE(filename, '')), 'B')
+		) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_messages_search ON messages USING GIN (search_vector)`,
+	}
+	for _, statement := range statements {
+		if _, err := r.db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to create search index: %w", err)
+		}
+	}
+	return nil
+}
+
+// runMigration executes a migration and records its version in one transaction,
+// so replicas starting together can't apply the same migration twice
+func (r *PostgresRepository) runMigration(migration string, version int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Serialize concurrent migrators on the schema_info table
+	if _, err := tx.Exec("LOCK TABLE schema_info IN EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+
+	var applied bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM schema_info WHERE version = $1)", version).Scan(&applied); err != nil {
+		return err
+	}
+	if applied {
+		return tx.Commit()
+	}
+
+	if _, err := tx.Exec(migration); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_info (version) VALUES ($1)", version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// getMigrations returns all PostgreSQL database migrations
+func (r *PostgresRepository) getMigrations() []string {
+	return []string{
+		// Migration 1: Create chats table
+		`CREATE TABLE IF NOT EXISTS chats (
+			jid VARCHAR(255) NOT NULL,
+			device_id VARCHAR(255) NOT NULL DEFAULT '',
+			name VARCHAR(255) NOT NULL,
+			last_message_time TIMESTAMPTZ NOT NULL,
+			ephemeral_expiration BIGINT DEFAULT 0,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (jid, device_id)
+		)`,
+
+		// Migration 2: Create messages table, search_vector backs full-text search
+		`CREATE TABLE IF NOT EXISTS messages (
+			id VARCHAR(255) NOT NULL,
+			chat_jid VARCHAR(255) NOT NULL,
+			device_id VARCHAR(255) NOT NULL DEFAULT '',
+			sender VARCHAR(255) NOT NULL,
+			content TEXT,
+			timestamp TIMESTAMPTZ NOT NULL,
+			is_from_me BOOLEAN DEFAULT FALSE,
+			media_type VARCHAR(50),
+			filename VARCHAR(255),
+			url TEXT,
+			media_key BYTEA,
+			file_sha256 BYTEA,
+			file_enc_sha256 BYTEA,
+			file_length BIGINT DEFAULT 0,
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			search_vector TSVECTOR GENERATED ALWAYS AS (
+				setweight(to_tsvector('simple', COALESCE(content, '')), 'A') ||
+				setweight(to_tsvector('simple', COALESCE(filename, '')), 'B')
+			) STORED,
+			PRIMARY KEY (id, chat_jid, device_id)
+		)`,
+
+		// Migration 3: Create devices table
+		`CREATE TABLE IF NOT EXISTS devices (
+			device_id VARCHAR(255) PRIMARY KEY,
+			display_name VARCHAR(255) DEFAULT '',
+			jid VARCHAR(255) DEFAULT '',
+			created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Migration 4: Messages of a chat in time order
+		`CREATE INDEX IF NOT EXISTS idx_messages_device_chat_timestamp ON messages(device_id, chat_jid, timestamp DESC)`,
+
+		// Migration 5
+		`CREATE INDEX IF NOT EXISTS idx_messages_id ON messages(id)`,
+
+		// Migration 6
+		`CREATE INDEX IF NOT EXISTS idx_messages_sender ON messages(sender)`,
+
+		// Migration 7: Full-text search
+		`CREATE INDEX IF NOT EXISTS idx_messages_search ON messages USING GIN (search_vector)`,
+
+		// Migration 8: Create indexes for chats
+		`CREATE INDEX IF NOT EXISTS idx_chats_device_last_message ON chats(device_id, last_message_time DESC)`,
+
+		// Migration 9
+		`CREATE INDEX IF NOT EXISTS idx_chats_jid ON chats(jid)`,
+
+		// Migration 10: Create index for devices
+		`CREATE INDEX IF NOT EXISTS idx_devices_created_at ON devices(created_at)`,
+
+		// Migration 11: Message lifecycle - edits, revokes and delivery status
+		`ALTER TABLE messages
+			ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ,
+			ADD COLUMN IF NOT EXISTS is_revoked BOOLEAN DEFAULT FALSE,
+			ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMPTZ,
+			ADD COLUMN IF NOT EXISTS status VARCHAR(20) DEFAULT ''`,
+
+		// Migration 12: One current reaction per sender and message
+		`CREATE TABLE IF NOT EXISTS message_reactions (
+			message_id VARCHAR(255) NOT NULL,
+			chat_jid VARCHAR(255) NOT NULL,
+			device_id VARCHAR(255) NOT NULL DEFAULT '',
+			sender VARCHAR(255) NOT NULL,
+			reaction VARCHAR(32) NOT NULL,
+			timestamp TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (message_id, chat_jid, device_id, sender),
+			FOREIGN KEY (message_id, chat_jid, device_id) REFERENCES messages(id, chat_jid, device_id) ON DELETE CASCADE
+		)`,
+
+		// Migration 13: Previous versions of edited messages
+		`CREATE TABLE IF NOT EXISTS message_edits (
+			id BIGSERIAL PRIMARY KEY,
+			message_id VARCHAR(255) NOT NULL,
+			chat_jid VARCHAR(255) NOT NULL,
+			device_id VARCHAR(255) NOT NULL DEFAULT '',
+			content TEXT,
+			edited_at TIMESTAMPTZ NOT NULL,
+			FOREIGN KEY (message_id, chat_jid, device_id) REFERENCES messages(id, chat_jid, device_id) ON DELETE CASCADE
+		)`,
+
+		// Migration 14
+		`CREATE INDEX IF NOT EXISTS idx_message_edits_message ON message_edits(device_id, chat_jid, message_id)`,
+
+		// Migration 15: Starred messages are exempt from retention pruning
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS is_starred BOOLEAN DEFAULT FALSE`,
+
+		// Migration 16
+		`CREATE INDEX IF NOT EXISTS idx_messages_device_timestamp ON messages(device_id, timestamp)`,
+
+		// Migration 17: Where downloaded media was saved
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS media_path TEXT DEFAULT ''`,
+
+		// Migration 18: Deleted messages for the sync change feed
+		`CREATE TABLE IF NOT EXISTS message_deletions (
+			seq BIGSERIAL PRIMARY KEY,
+			message_id VARCHAR(255) NOT NULL,
+			chat_jid VARCHAR(255) NOT NULL,
+			device_id VARCHAR(255) NOT NULL DEFAULT '',
+			deleted_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Migration 19
+		`CREATE INDEX IF NOT EXISTS idx_message_deletions_device ON message_deletions(device_id, seq)`,
+
+		// Migration 20
+		`CREATE OR REPLACE FUNCTION record_message_deletion() RETURNS TRIGGER AS $$
+		BEGIN
+			INSERT INTO message_deletions (message_id, chat_jid, device_id)
+			VALUES (OLD.id, OLD.chat_jid, OLD.device_id);
+			RETURN OLD;
+		END;
+		$$ LANGUAGE plpgsql`,
+
+		// Migration 21
+		`CREATE TRIGGER messages_deletions_ad AFTER DELETE ON messages
+			FOR EACH ROW EXECUTE FUNCTION record_message_deletion()`,
+
+		// Migration 22: Change feed order
+		`CREATE INDEX IF NOT EXISTS idx_messages_device_updated ON messages(device_id, updated_at)`,
+
+		// Migration 23: Chat list state synced from the phone
+		`ALTER TABLE chats
+			ADD COLUMN IF NOT EXISTS unread_count INTEGER DEFAULT 0,
+			ADD COLUMN IF NOT EXISTS marked_unread BOOLEAN DEFAULT FALSE,
+			ADD COLUMN IF NOT EXISTS archived BOOLEAN DEFAULT FALSE,
+			ADD COLUMN IF NOT EXISTS pinned BOOLEAN DEFAULT FALSE,
+			ADD COLUMN IF NOT EXISTS muted_until TIMESTAMPTZ`,
+
+		// Migration 24: Contact names from the address book and push names
+		`CREATE TABLE IF NOT EXISTS contacts (
+			device_id VARCHAR(255) NOT NULL DEFAULT '',
+			jid VARCHAR(255) NOT NULL,
+			full_name VARCHAR(255) DEFAULT '',
+			first_name VARCHAR(255) DEFAULT '',
+			push_name VARCHAR(255) DEFAULT '',
+			business_name VARCHAR(255) DEFAULT '',
+			updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (device_id, jid)
+		)`,
+
+		// Migration 25: Members of group chats
+		`CREATE TABLE IF NOT EXISTS group_participants (
+			device_id VARCHAR(255) NOT NULL DEFAULT '',
+			group_jid VARCHAR(255) NOT NULL,
+			jid VARCHAR(255) NOT NULL,
+			is_admin BOOLEAN DEFAULT FALSE,
+			is_super_admin BOOLEAN DEFAULT FALSE,
+			PRIMARY KEY (device_id, group_jid, jid)
+		)`,
+
+		// Migration 26: Media details needed to send a message again
+		`ALTER TABLE messages
+			ADD COLUMN IF NOT EXISTS mimetype TEXT DEFAULT '',
+			ADD COLUMN IF NOT EXISTS is_ptt BOOLEAN DEFAULT FALSE`,
+	}
+}
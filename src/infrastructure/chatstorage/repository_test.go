@@ -0,0 +1,892 @@
+package chatstorage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The same suite runs against every backend. PostgreSQL runs when
+// CHATSTORAGE_TEST_POSTGRES_URI points at a disposable database, which the test
+// workflow provides with a service container; in CI a missing URI fails the test
+// instead of skipping it.
+
+func TestSQLiteRepository(t *testing.T) {
+	runRepositorySuite(t, func(t *testing.T) domainChatStorage.IChatStorageRepository {
+		db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "chatstorage.db")+"?_journal_mode=WAL")
+		if err != nil {
+			t.Fatalf("open sqlite: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return NewStorageRepository(db, nil)
+	})
+}
+
+func TestPostgresRepository(t *testing.T) {
+	uri := os.Getenv("CHATSTORAGE_TEST_POSTGRES_URI")
+	if uri == "" {
+		if os.Getenv("CI") != "" {
+			t.Fatal("CHATSTORAGE_TEST_POSTGRES_URI must be set in CI")
+		}
+		t.Skip("CHATSTORAGE_TEST_POSTGRES_URI not set")
+	}
+
+	runRepositorySuite(t, func(t *testing.T) domainChatStorage.IChatStorageRepository {
+		db, err := sql.Open("postgres", uri)
+		if err != nil {
+			t.Fatalf("open postgres: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		for _, table := range []string{"message_reactions", "message_edits", "message_deletions", "messages", "chats", "contacts", "group_participants", "devices", "schema_info"} {
+			if _, err := db.Exec("DROP TABLE IF EXISTS " + table); err != nil {
+				t.Fatalf("reset %s: %v", table, err)
+			}
+		}
+		return NewPostgresStorageRepository(db, nil)
+	})
+}
+
+func runRepositorySuite(t *testing.T, newRepo func(t *testing.T) domainChatStorage.IChatStorageRepository) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo domainChatStorage.IChatStorageRepository)
+	}{
+		{"Chats", testRepositoryChats},
+		{"Messages", testRepositoryMessages},
+		{"Search", testRepositorySearch},
+		{"Delete", testRepositoryDelete},
+		{"Lifecycle", testRepositoryLifecycle},
+		{"Retention", testRepositoryRetention},
+		{"Iterate", testRepositoryIterate},
+		{"Pagination", testRepositoryPagination},
+		{"Changes", testRepositoryChanges},
+		{"Metadata", testRepositoryMetadata},
+		{"Analytics", testRepositoryAnalytics},
+		{"Encryption", testRepositoryEncryption},
+		{"DeviceRecords", testRepositoryDeviceRecords},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRepo(t)
+			if err := repo.InitializeSchema(); err != nil {
+				t.Fatalf("InitializeSchema() error = %v", err)
+			}
+			// Migrations must be safe to run again on every start
+			if err := repo.InitializeSchema(); err != nil {
+				t.Fatalf("second InitializeSchema() error = %v", err)
+			}
+			tt.run(t, repo)
+		})
+	}
+}
+
+var suiteBaseTime = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func seedMessages(t *testing.T, repo domainChatStorage.IChatStorageRepository) {
+	t.Helper()
+
+	chats := []*domainChatStorage.Chat{
+		{DeviceID: "dev1", JID: "111@s.whatsapp.net", Name: "Alice", LastMessageTime: suiteBaseTime.Add(3 * time.Minute)},
+		{DeviceID: "dev1", JID: "222@g.us", Name: "Project Group", LastMessageTime: suiteBaseTime.Add(5 * time.Minute)},
+		{DeviceID: "dev2", JID: "111@s.whatsapp.net", Name: "Alice (other phone)", LastMessageTime: suiteBaseTime},
+	}
+	for _, chat := range chats {
+		if err := repo.StoreChat(chat); err != nil {
+			t.Fatalf("StoreChat(%s) error = %v", chat.JID, err)
+		}
+	}
+
+	messages := []*domainChatStorage.Message{
+		{ID: "m1", ChatJID: "111@s.whatsapp.net", DeviceID: "dev1", Sender: "111@s.whatsapp.net", Content: "Your invoice is attached", Timestamp: suiteBaseTime.Add(1 * time.Minute)},
+		{ID: "m2", ChatJID: "111@s.whatsapp.net", DeviceID: "dev1", Sender: "me@s.whatsapp.net", Content: "Thanks, see you tomorrow", Timestamp: suiteBaseTime.Add(2 * time.Minute), IsFromMe: true},
+		{ID: "m3", ChatJID: "111@s.whatsapp.net", DeviceID: "dev1", Sender: "111@s.whatsapp.net", MediaType: "document", Filename: "invoice-march.pdf", URL: "https://example.com/f", MediaKey: []byte{1, 2, 3}, FileLength: 2048, Mimetype: "application/pdf", Timestamp: suiteBaseTime.Add(3 * time.Minute)},
+		{ID: "m4", ChatJID: "222@g.us", DeviceID: "dev1", Sender: "333@s.whatsapp.net", Content: "Meeting moved to Friday", Timestamp: suiteBaseTime.Add(5 * time.Minute)},
+		{ID: "m1", ChatJID: "111@s.whatsapp.net", DeviceID: "dev2", Sender: "111@s.whatsapp.net", Content: "Invoice for the other device", Timestamp: suiteBaseTime},
+		{ID: "empty", ChatJID: "111@s.whatsapp.net", DeviceID: "dev1", Sender: "111@s.whatsapp.net", Timestamp: suiteBaseTime},
+	}
+	if err := repo.StoreMessagesBatch(messages); err != nil {
+		t.Fatalf("StoreMessagesBatch() error = %v", err)
+	}
+}
+
+func testRepositoryChats(t *testing.T, repo domainChatStorage.IChatStorageRepository) {
+	seedMessages(t, repo)
+
+	chat, err := repo.GetChatByDevice("dev1", "111@s.whatsapp.net")
+	if err != nil || chat == nil {
+		t.Fatalf("GetChatByDevice() = %v, %v", chat, err)
+	}
+	if chat.Name != "Alice" || !chat.LastMessageTime.Equal(suiteBaseTime.Add(3*time.Minute)) {
+		t.Fatalf("GetChatByDevice() = %+v", chat)
+	}
+
+	// Storing again updates in place
+	chat.Name = "Alice Smith"
+	chat.EphemeralExpiration = 86400
+	if err := repo.StoreChat(chat); err != nil {
+		t.Fatalf("StoreChat() update error = %v", err)
+	}
+	chat, _ = repo.GetChatByDevice("dev1", "111@s.whatsapp.net")
+	if chat.Name != "Alice Smith" || chat.EphemeralExpiration != 86400 {
+		t.Fatalf("updated chat = %+v", chat)
+	}
+
+	if missing, err := repo.GetChatByDevice("dev1", "999@s.whatsapp.net"); err != nil || missing != nil {
+		t.Fatalf("GetChatByDevice(missing) = %v, %v", missing, err)
+	}
+
+	chats, err := repo.GetChats(&domainChatStorage.ChatFilter{DeviceID: "dev1"})
+	if err != nil {
+		t.Fatalf("GetChats() error = %v", err)
+	}
+	if len(chats) != 2 || chats[0].JID != "222@g.us" {
+		t.Fatalf("GetChats() should list the device's chats newest first, got %d chats", len(chats))
+	}
+
+	chats, _ = repo.GetChats(&domainChatStorage.ChatFilter{DeviceID: "dev1", SearchName: "alice"})
+	if len(chats) != 1 || chats[0].JID != "111@s.whatsapp.net" {
+		t.Fatalf("GetChats(SearchName) = %d chats", len(chats))
+	}
+
+	chats, _ = repo.GetChats(&domainChatStorage.ChatFilter{DeviceID: "dev1", HasMedia: true})
+	if len(chats) != 1 || chats[0].JID != "111@s.whatsapp.net" {
+		t.Fatalf("GetChats(HasMedia) = %d chats", len(chats))
+	}
+
+	if count, _ := repo.GetTotalChatCount(); count != 3 {
+		t.Fatalf("GetTotalChatCount() = %d, want 3", count)
+	}
+}
+
+func testRepositoryMessages(t *testing.T, repo domainChatStorage.IChatStorageRepository) {
+	seedMessages(t, repo)
+
+	if _, err := repo.GetMessages(&domainChatStorage.MessageFilter{ChatJID: "111@s.whatsapp.net"}); err == nil {
+		t.Fatal("GetMessages() without device id should fail")
+	}
+
+	messages, err := repo.GetMessages(&domainChatStorage.MessageFilter{DeviceID: "dev1", ChatJID: "111@s.whatsapp.net"})
+	if err != nil {
+		t.Fatalf("GetMessages() error = %v", err)
+	}
+	if len(messages) != 3 || messages[0].ID != "m3" || messages[2].ID != "m1" {
+		t.Fatalf("GetMessages() should return 3 messages newest first, got %d", len(messages))
+	}
+
+	media := messages[0]
+	if media.MediaType != "document" || media.Filename != "invoice-march.pdf" || media.FileLength != 2048 || media.Mimetype != "application/pdf" || string(media.MediaKey) != "\x01\x02\x03" {
+		t.Fatalf("media message round trip = %+v", media)
+	}
+
+	fromMe := true
+	messages, _ = repo.GetMessages(&domainChatStorage.MessageFilter{DeviceID: "dev1", ChatJID: "111@s.whatsapp.net", IsFromMe: &fromMe})
+	if len(messages) != 1 || messages[0].ID != "m2" {
+		t.Fatalf("GetMessages(IsFromMe) = %d messages", len(messages))
+	}
+
+	messages, _ = repo.GetMessages(&domainChatStorage.MessageFilter{DeviceID: "dev1", ChatJID: "111@s.whatsapp.net", MediaOnly: true})
+	if len(messages) != 1 || messages[0].ID != "m3" {
+		t.Fatalf("GetMessages(MediaOnly) = %d messages", len(messages))
+	}
+
+	start, end := suiteBaseTime.Add(90*time.Second), suiteBaseTime.Add(150*time.Second)
+	messages, _ = repo.GetMessages(&domainChatStorage.MessageFilter{DeviceID: "dev1", ChatJID: "111@s.whatsapp.net", StartTime: &start, EndTime: &end})
+	if len(messages) != 1 || messages[0].ID != "m2" {
+		t.Fatalf("GetMessages(time range) = %d messages", len(messages))
+	}
+
+	messages, _ = repo.GetMessages(&domainChatStorage.MessageFilter{DeviceID: "dev1", ChatJID: "111@s.whatsapp.net", Limit: 1, Offset: 1})
+	if len(messages) != 1 || messages[0].ID != "m2" {
+		t.Fatalf("GetMessages(limit/offset) = %d messages", len(messages))
+	}
+
+	// Storing the same message again updates it rather than duplicating
+	if err := repo.StoreMessage(&domainChatStorage.Message{ID: "m2", ChatJID: "111@s.whatsapp.net", DeviceID: "dev1", Sender: "me@s.whatsapp.net", Content: "Thanks, see you Monday", Timestamp: suiteBaseTime.Add(2 * time.Minute), IsFromMe: true}); err != nil {
+		t.Fatalf("StoreMessage() update error = %v", err)
+	}
+	if count, _ := repo.GetChatMessageCountByDevice("dev1", "111@s.whatsapp.net"); count != 3 {
+		t.Fatalf("GetChatMessageCountByDevice() = %d, want 3", count)
+	}
+
+	message, err := repo.GetMessageByID("m4")
+	if err != nil || message == nil || message.Content != "Meeting moved to Friday" {
+		t.Fatalf("GetMessageByID() = %v, %v", message, err)
+	}
+	if missing, err := repo.GetMessageByID("nope"); err != nil || missing != nil {
+		t.Fatalf("GetMessageByID(missing) = %v, %v", missing, err)
+	}
+
+	if count, _ := repo.GetChatMessageCount("111@s.whatsapp.net"); count != 4 {
+		t.Fatalf("GetChatMessageCount() = %d, want 4", count)
+	}
+	if count, _ := repo.GetTotalMessageCount(); count != 5 {
+		t.Fatalf("GetTotalMessageCount() = %d, want 5", count)
+	}
+}
+
+func testRepositorySearch(t *testing.T, repo domainChatStorage.IChatStorageRepository) {
+	seedMessages(t, repo)
+
+	results, total, err := repo.SearchMessagesFullText(&domainChatStorage.MessageSearchFilter{DeviceID: "dev1", Query: "invoice"})
+	if err != nil {
+		t.Fatalf("SearchMessagesFullText() error = %v", err)
+	}
+	if total != 2 || len(results) != 2 {
+		t.Fatalf("search invoice = %d results (total %d), want 2", len(results), total)
+	}
+	for _, result := range results {
+		if result.Message.DeviceID != "dev1" {
+			t.Fatalf("search leaked a message from device %s", result.Message.DeviceID)
+		}
+	}
+
+	results, _, _ = repo.SearchMessagesFullText(&domainChatStorage.MessageSearchFilter{DeviceID: "dev1", Query: "meet*"})
+	if len(results) != 1 || results[0].Message.ID != "m4" {
+		t.Fatalf("prefix search = %d results", len(results))
+	}
+
+	results, _, _ = repo.SearchMessagesFullText(&domainChatStorage.MessageSearchFilter{DeviceID: "dev1", Query: `"see you"`})
+	if len(results) != 1 || results[0].Message.ID != "m2" {
+		t.Fatalf("phrase search = %d results", len(results))
+	}
+
+	results, _, _ = repo.SearchMessagesFullText(&domainChatStorage.MessageSearchFilter{DeviceID: "dev1", Query: "friday OR tomorrow"})
+	if len(results) != 2 {
+		t.Fatalf("OR search = %d results, want 2", len(results))
+	}
+
+	results, _, _ = repo.SearchMessagesFullText(&domainChatStorage.MessageSearchFilter{DeviceID: "dev1", Query: "invoice", MediaOnly: true})
+	if len(results) != 1 || results[0].Message.ID != "m3" {
+		t.Fatalf("media-only search = %d results", len(results))
+	}
+
+	results, _, _ = repo.SearchMessagesFullText(&domainChatStorage.MessageSearchFilter{DeviceID: "dev1", Query: "invoice", ChatJID: "222@g.us"})
+	if len(results) != 0 {
+		t.Fatalf("chat-scoped search = %d results, want 0", len(results))
+	}
+
+	messages, err := repo.SearchMessages("dev1", "111@s.whatsapp.net", "invoice", 10)
+	if err != nil {
+		t.Fatalf("SearchMessages() error = %v", err)
+	}
+	if len(messages) != 2 || messages[0].ID != "m3" {
+		t.Fatalf("SearchMessages() should return newest first, got %d", len(messages))
+	}
+}
+
+func testRepositoryDelete(t *testing.T, repo domainChatStorage.IChatStorageRepository) {
+	seedMessages(t, repo)
+
+	if err := repo.DeleteMessageByDevice("dev1", "m2", "111@s.whatsapp.net"); err != nil {
+		t.Fatalf("DeleteMessageByDevice() error = %v", err)
+	}
+	if count, _ := repo.GetChatMessageCountByDevice("dev1", "111@s.whatsapp.net"); count != 2 {
+		t.Fatalf("count after DeleteMessageByDevice() = %d, want 2", count)
+	}
+
+	// Deleting one device's chat leaves the other device's copy alone
+	if err := repo.DeleteChatByDevice("dev1", "111@s.whatsapp.net"); err != nil {
+		t.Fatalf("DeleteChatByDevice() error = %v", err)
+	}
+	if chat, _ := repo.GetChatByDevice("dev1", "111@s.whatsapp.net"); chat != nil {
+		t.Fatal("chat still present after DeleteChatByDevice()")
+	}
+	if count, _ := repo.GetChatMessageCountByDevice("dev2", "111@s.whatsapp.net"); count != 1 {
+		t.Fatalf("other device count = %d, want 1", count)
+	}
+
+	// Search must not return deleted rows
+	results, _, err := repo.SearchMessagesFullText(&domainChatStorage.MessageSearchFilter{DeviceID: "dev1", Query: "invoice"})
+	if err != nil || len(results) != 0 {
+		t.Fatalf("search after delete = %d results, %v", len(results), err)
+	}
+
+	if err := repo.DeleteDeviceData("dev2"); err != nil {
+		t.Fatalf("DeleteDeviceData() error = %v", err)
+	}
+	if chats, messages, _ := repo.GetStorageStatistics(); chats != 1 || messages != 1 {
+		t.Fatalf("statistics after DeleteDeviceData() = %d chats, %d messages", chats, messages)
+	}
+
+	if err := repo.TruncateAllDataWithLogging("test"); err != nil {
+		t.Fatalf("TruncateAllDataWithLogging() error = %v", err)
+	}
+	if chats, messages, _ := repo.GetStorageStatistics(); chats != 0 || messages != 0 {
+		t.Fatalf("statistics after truncate = %d chats, %d messages", chats, messages)
+	}
+}
+
+func testRepositoryDeviceRecords(t *testing.T, repo domainChatStorage.IChatStorageRepository) {
+	if err := repo.SaveDeviceRecord(&domainChatStorage.DeviceRecord{}); err == nil {
+		t.Fatal("SaveDeviceRecord() without id should fail")
+	}
+
+	for _, id := range []string{"dev1", "dev2"} {
+		if err := repo.SaveDeviceRecord(&domainChatStorage.DeviceRecord{DeviceID: id, DisplayName: id}); err != nil {
+			t.Fatalf("SaveDeviceRecord(%s) error = %v", id, err)
+		}
+	}
+	if err := repo.SaveDeviceRecord(&domainChatStorage.DeviceRecord{DeviceID: "dev1", DisplayName: "Sales", JID: "111@s.whatsapp.net"}); err != nil {
+		t.Fatalf("SaveDeviceRecord() update error = %v", err)
+	}
+
+	record, err := repo.GetDeviceRecord("dev1")
+	if err != nil || record == nil || record.DisplayName != "Sales" || record.JID != "111@s.whatsapp.net" {
+		t.Fatalf("GetDeviceRecord() = %+v, %v", record, err)
+	}
+
+	records, err := repo.ListDeviceRecords()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("ListDeviceRecords() = %d records, %v", len(records), err)
+	}
+
+	if err := repo.DeleteDeviceRecord("dev1"); err != nil {
+		t.Fatalf("DeleteDeviceRecord() error = %v", err)
+	}
+	if record, err := repo.GetDeviceRecord("dev1"); err != nil || record != nil {
+		t.Fatalf("GetDeviceRecord() after delete = %+v, %v", record, err)
+	}
+}
+
+func testRepositoryLifecycle(t *testing.T, repo domainChatStorage.IChatStorageRepository) {
+	seedMessages(t, repo)
+	chatJID := "111@s.whatsapp.net"
+	editedAt := suiteBaseTime.Add(10 * time.Minute)
+
+	// Edits replace the content and keep the previous version
+	for _, content := range []string{"Thanks, see you Monday", "Thanks, see you Monday", "Thanks, see you Tuesday"} {
+		if err := repo.ApplyMessageEdit("dev1", chatJID, "m2", content, editedAt); err != nil {
+			t.Fatalf("ApplyMessageEdit() error = %v", err)
+		}
+		editedAt = editedAt.Add(time.Minute)
+	}
+	if err := repo.ApplyMessageEdit("dev1", chatJID, "unknown", "ignored", editedAt); err != nil {
+		t.Fatalf("ApplyMessageEdit(unknown) error = %v", err)
+	}
+	edits, err := repo.GetMessageEdits("dev1", chatJID, []string{"m1", "m2"})
+	if err != nil {
+		t.Fatalf("GetMessageEdits() error = %v", err)
+	}
+	if len(edits) != 2 || edits[0].Content != "Thanks, see you tomorrow" || edits[1].Content != "Thanks, see you Monday" {
+		t.Fatalf("GetMessageEdits() = %d edits", len(edits))
+	}
+	message, _ := repo.GetMessageByID("m4")
+	if message.EditedAt != nil || message.IsRevoked || message.Status != "" {
+		t.Fatalf("untouched message = %+v", message)
+	}
+	messages, _ := repo.GetMessages(&domainChatStorage.MessageFilter{DeviceID: "dev1", ChatJID: chatJID})
+	edited := messages[1]
+	if edited.ID != "m2" || edited.Content != "Thanks, see you Tuesday" || edited.EditedAt == nil {
+		t.Fatalf("edited message = %+v", edited)
+	}
+
+	// Search sees the edited content
+	if results, _, _ := repo.SearchMessagesFullText(&domainChatStorage.MessageSearchFilter{DeviceID: "dev1", Query: "tuesday"}); len(results) != 1 {
+		t.Fatalf("search for edited content = %d results", len(results))
+	}
+
+	// Revokes keep the content but flag the message
+	if err := repo.MarkMessageRevoked("dev1", chatJID, "m1", editedAt); err != nil {
+		t.Fatalf("MarkMessageRevoked() error = %v", err)
+	}
+	messages, _ = repo.GetMessages(&domainChatStorage.MessageFilter{DeviceID: "dev1", ChatJID: chatJID})
+	if revoked := messages[2]; !revoked.IsRevoked || revoked.RevokedAt == nil || revoked.Content == "" {
+		t.Fatalf("revoked message = %+v", revoked)
+	}
+	if other, _ := repo.GetMessages(&domainChatStorage.MessageFilter{DeviceID: "dev2", ChatJID: chatJID}); other[0].IsRevoked {
+		t.Fatal("revoke leaked to another device")
+	}
+
+	// One reaction per sender, an empty reaction removes it
+	reactions := []*domainChatStorage.MessageReaction{
+		{MessageID: "m1", ChatJID: chatJID, DeviceID: "dev1", Sender: "111@s.whatsapp.net", Reaction: "👍", Timestamp: suiteBaseTime.Add(time.Minute)},
+		{MessageID: "m1", ChatJID: chatJID, DeviceID: "dev1", Sender: "111@s.whatsapp.net", Reaction: "❤️", Timestamp: suiteBaseTime.Add(2 * time.Minute)},
+		{MessageID: "m1", ChatJID: chatJID, DeviceID: "dev1", Sender: "me@s.whatsapp.net", Reaction: "😂", Timestamp: suiteBaseTime.Add(3 * time.Minute)},
+		{MessageID: "m3", ChatJID: chatJID, DeviceID: "dev1", Sender: "me@s.whatsapp.net", Reaction: "🔥", Timestamp: suiteBaseTime.Add(4 * time.Minute)},
+		{MessageID: "m3", ChatJID: chatJID, DeviceID: "dev1", Sender: "me@s.whatsapp.net", Timestamp: suiteBaseTime.Add(5 * time.Minute)},
+		{MessageID: "unknown", ChatJID: chatJID, DeviceID: "dev1", Sender: "me@s.whatsapp.net", Reaction: "👀", Timestamp: suiteBaseTime},
+	}
+	for _, reaction := range reactions {
+		if err := repo.StoreReaction(reaction); err != nil {
+			t.Fatalf("StoreReaction() error = %v", err)
+		}
+	}
+	stored, err := repo.GetMessageReactions("dev1", chatJID, []string{"m1", "m3", "unknown"})
+	if err != nil {
+		t.Fatalf("GetMessageReactions() error = %v", err)
+	}
+	if len(stored) != 2 || stored[0].Reaction != "❤️" || stored[1].Reaction != "😂" {
+		t.Fatalf("GetMessageReactions() = %d reactions", len(stored))
+	}
+
+	// Status only moves forward
+	for _, status := range []string{domainChatStorage.MessageStatusRead, domainChatStorage.MessageStatusDelivered} {
+		if err := repo.UpdateMessageStatus("dev1", chatJID, []string{"m2", "m3"}, status); err != nil {
+			t.Fatalf("UpdateMessageStatus(%s) error = %v", status, err)
+		}
+	}
+	if err := repo.UpdateMessageStatus("dev1", chatJID, []string{"m2"}, "bogus"); err == nil {
+		t.Fatal("UpdateMessageStatus() with an unknown status should fail")
+	}
+	messages, _ = repo.GetMessages(&domainChatStorage.MessageFilter{DeviceID: "dev1", ChatJID: chatJID})
+	for _, message := range messages[:2] {
+		if message.Status != domainChatStorage.MessageStatusRead {
+			t.Fatalf("message %s status = %q, want read", message.ID, message.Status)
+		}
+	}
+
+	// Reactions and edit history go with their message
+	if err := repo.DeleteChatByDevice("dev1", chatJID); err != nil {
+		t.Fatalf("DeleteChatByDevice() error = %v", err)
+	}
+	if stored, _ := repo.GetMessageReactions("dev1", chatJID, []string{"m1"}); len(stored) != 0 {
+		t.Fatalf("reactions after delete = %d", len(stored))
+	}
+	if edits, _ := repo.GetMessageEdits("dev1", chatJID, []string{"m2"}); len(edits) != 0 {
+		t.Fatalf("edits after delete = %d", len(edits))
+	}
+	if err := repo.TruncateAllChats(); err != nil {
+		t.Fatalf("TruncateAllChats() error = %v", err)
+	}
+}
+
+func testRepositoryRetention(t *testing.T, repo domainChatStorage.IChatStorageRepository) {
+	seedMessages(t, repo)
+	chatJID := "111@s.whatsapp.net"
+	if err := repo.StoreMessage(&domainChatStorage.Message{ID: "fresh", ChatJID: chatJID, DeviceID: "dev1", Sender: chatJID, Content: "Just now", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("StoreMessage() error = %v", err)
+	}
+	if err := repo.SetMessageStarred("dev1", chatJID, "m1", true); err != nil {
+		t.Fatalf("SetMessageStarred() error = %v", err)
+	}
+
+	deviceIDs, err := repo.ListMessageDeviceIDs()
+	if err != nil || len(deviceIDs) != 2 || deviceIDs[0] != "dev1" || deviceIDs[1] != "dev2" {
+		t.Fatalf("ListMessageDeviceIDs() = %v, %v", deviceIDs, err)
+	}
+
+	// Everything but the fresh and the starred message is older than 30 days
+	byAge := domainChatStorage.RetentionPolicy{DeviceID: "dev1", MaxAge: 30 * 24 * time.Hour, KeepStarred: true}
+	preview, err := repo.PreviewPrune(byAge)
+	if err != nil {
+		t.Fatalf("PreviewPrune() error = %v", err)
+	}
+	if preview.Messages != 3 || len(preview.Chats) != 2 || preview.Chats[0].ChatJID != chatJID || preview.Chats[0].Messages != 2 {
+		t.Fatalf("PreviewPrune(age) = %+v", preview)
+	}
+
+	// Only the two newest messages of each chat are kept
+	byCount := domainChatStorage.RetentionPolicy{DeviceID: "dev1", MaxMessagesPerChat: 2}
+	if preview, _ := repo.PreviewPrune(byCount); preview.Messages != 2 || len(preview.Chats) != 1 {
+		t.Fatalf("PreviewPrune(count) = %+v", preview)
+	}
+	if preview, _ := repo.PreviewPrune(domainChatStorage.RetentionPolicy{DeviceID: "dev1"}); preview.Messages != 0 {
+		t.Fatalf("PreviewPrune(disabled) = %+v", preview)
+	}
+
+	// Batches stop when nothing is left to prune
+	var batches []int64
+	for {
+		deleted, err := repo.PruneMessages(byAge, 2)
+		if err != nil {
+			t.Fatalf("PruneMessages() error = %v", err)
+		}
+		batches = append(batches, deleted)
+		if deleted == 0 {
+			break
+		}
+	}
+	if len(batches) != 3 || batches[0] != 2 || batches[1] != 1 {
+		t.Fatalf("PruneMessages() batches = %v", batches)
+	}
+
+	messages, _ := repo.GetMessages(&domainChatStorage.MessageFilter{DeviceID: "dev1", ChatJID: chatJID})
+	if len(messages) != 2 || messages[0].ID != "fresh" || messages[1].ID != "m1" || !messages[1].IsStarred {
+		t.Fatalf("messages after pruning = %d", len(messages))
+	}
+	if other, _ := repo.GetMessages(&domainChatStorage.MessageFilter{DeviceID: "dev2", ChatJID: chatJID}); len(other) != 1 {
+		t.Fatal("pruning leaked to another device")
+	}
+
+	if _, err := repo.PruneMessages(domainChatStorage.RetentionPolicy{MaxAge: time.Hour}, 10); err == nil {
+		t.Fatal("PruneMessages() without device_id should fail")
+	}
+}
+
+func testRepositoryIterate(t *testing.T, repo domainChatStorage.IChatStorageRepository) {
+	seedMessages(t, repo)
+	if err := repo.SetMessageMediaPath("dev1", "111@s.whatsapp.net", "m3", "statics/media/m3.pdf"); err != nil {
+		t.Fatalf("SetMessageMediaPath() error = %v", err)
+	}
+
+	collect := func(filter *domainChatStorage.MessageFilter) []*domainChatStorage.Message {
+		var messages []*domainChatStorage.Message
+		if err := repo.IterateMessages(filter, func(message *domainChatStorage.Message) error {
+			messages = append(messages, message)
+			return nil
+		}); err != nil {
+			t.Fatalf("IterateMessages() error = %v", err)
+		}
+		return messages
+	}
+
+	// One chat, oldest first
+	messages := collect(&domainChatStorage.MessageFilter{DeviceID: "dev1", ChatJID: "111@s.whatsapp.net"})
+	if len(messages) != 3 || messages[0].ID != "m1" || messages[2].ID != "m3" || messages[2].MediaPath != "statics/media/m3.pdf" {
+		t.Fatalf("IterateMessages(chat) = %d messages", len(messages))
+	}
+
+	// Every chat of the device, chat by chat, within the time range
+	start, end := suiteBaseTime.Add(2*time.Minute), suiteBaseTime.Add(time.Hour)
+	messages = collect(&domainChatStorage.MessageFilter{DeviceID: "dev1", StartTime: &start, EndTime: &end})
+	var ids []string
+	for _, message := range messages {
+		ids = append(ids, message.ChatJID+"/"+message.ID)
+	}
+	if len(ids) != 3 || ids[0] != "111@s.whatsapp.net/m2" || ids[1] != "111@s.whatsapp.net/m3" || ids[2] != "222@g.us/m4" {
+		t.Fatalf("IterateMessages(device) = %v", ids)
+	}
+
+	// An error from the callback stops the iteration
+	calls := 0
+	err := repo.IterateMessages(&domainChatStorage.MessageFilter{DeviceID: "dev1"}, func(*domainChatStorage.Message) error {
+		calls++
+		return os.ErrClosed
+	})
+	if err != os.ErrClosed || calls != 1 {
+		t.Fatalf("IterateMessages() stopping = %v after %d calls", err, calls)
+	}
+
+	if err := repo.IterateMessages(&domainChatStorage.MessageFilter{}, func(*domainChatStorage.Message) error { return nil }); err == nil {
+		t.Fatal("IterateMessages() without device_id should fail")
+	}
+}
+
+func testRepositoryPagination(t *testing.T, repo domainChatStorage.IChatStorageRepository) {
+	seedMessages(t, repo)
+	// Same timestamp as m4, the ID breaks the tie
+	if err := repo.StoreMessage(&domainChatStorage.Message{ID: "m5", ChatJID: "222@g.us", DeviceID: "dev1", Sender: "444@s.whatsapp.net", Content: "Fine by me", Timestamp: suiteBaseTime.Add(5 * time.Minute)}); err != nil {
+		t.Fatalf("StoreMessage() error = %v", err)
+	}
+
+	page := func(chatJID string, before *domainChatStorage.MessageCursor) []string {
+		messages, err := repo.GetMessages(&domainChatStorage.MessageFilter{DeviceID: "dev1", ChatJID: chatJID, Limit: 2, Before: before})
+		if err != nil {
+			t.Fatalf("GetMessages() error = %v", err)
+		}
+		var ids []string
+		for _, message := range messages {
+			ids = append(ids, message.ID)
+		}
+		return ids
+	}
+
+	if ids := page("111@s.whatsapp.net", nil); len(ids) != 2 || ids[0] != "m3" || ids[1] != "m2" {
+		t.Fatalf("first page = %v", ids)
+	}
+	if ids := page("111@s.whatsapp.net", &domainChatStorage.MessageCursor{Timestamp: suiteBaseTime.Add(2 * time.Minute), ID: "m2"}); len(ids) != 1 || ids[0] != "m1" {
+		t.Fatalf("page before m2 = %v", ids)
+	}
+	if ids := page("222@g.us", &domainChatStorage.MessageCursor{Timestamp: suiteBaseTime.Add(5 * time.Minute), ID: "m5"}); len(ids) != 1 || ids[0] != "m4" {
+		t.Fatalf("page before m5 = %v", ids)
+	}
+
+	chats, err := repo.GetChats(&domainChatStorage.ChatFilter{DeviceID: "dev1", Limit: 1})
+	if err != nil || len(chats) != 1 || chats[0].JID != "222@g.us" {
+		t.Fatalf("GetChats() first page = %v, %v", chats, err)
+	}
+	chats, err = repo.GetChats(&domainChatStorage.ChatFilter{
+		DeviceID: "dev1",
+		Limit:    1,
+		Offset:   5, // Ignored with a cursor
+		Before:   &domainChatStorage.ChatCursor{LastMessageTime: chats[0].LastMessageTime, JID: chats[0].JID},
+	})
+	if err != nil || len(chats) != 1 || chats[0].JID != "111@s.whatsapp.net" {
+		t.Fatalf("GetChats() second page = %v, %v", chats, err)
+	}
+}
+
+func testRepositoryChanges(t *testing.T, repo domainChatStorage.IChatStorageRepository) {
+	seedMessages(t, repo)
+	future := time.Now().Add(time.Hour)
+
+	feed := func(since domainChatStorage.ChangeCursor, until time.Time) (*domainChatStorage.MessageChanges, []string) {
+		changes, err := repo.GetMessageChanges(&domainChatStorage.MessageChangeFilter{DeviceID: "dev1", Since: since, Until: until, Limit: 2})
+		if err != nil {
+			t.Fatalf("GetMessageChanges() error = %v", err)
+		}
+		var ids []string
+		for _, message := range changes.Messages {
+			ids = append(ids, message.ChatJID+"/"+message.ID)
+		}
+		return changes, ids
+	}
+	next := func(since domainChatStorage.ChangeCursor, changes *domainChatStorage.MessageChanges) domainChatStorage.ChangeCursor {
+		if n := len(changes.Messages); n > 0 {
+			last := changes.Messages[n-1]
+			since.UpdatedAt, since.ChatJID, since.ID = last.UpdatedAt, last.ChatJID, last.ID
+		}
+		if n := len(changes.Deletions); n > 0 {
+			since.DeletionSeq = changes.Deletions[n-1].Seq
+		}
+		return since
+	}
+
+	// The seed is stored in one batch, so chat and ID order the feed
+	changes, ids := feed(domainChatStorage.ChangeCursor{}, future)
+	if len(ids) != 2 || ids[0] != "111@s.whatsapp.net/m1" || ids[1] != "111@s.whatsapp.net/m2" || len(changes.Deletions) != 0 {
+		t.Fatalf("first page = %v", ids)
+	}
+	cursor := next(domainChatStorage.ChangeCursor{}, changes)
+	changes, ids = feed(cursor, future)
+	if len(ids) != 2 || ids[0] != "111@s.whatsapp.net/m3" || ids[1] != "222@g.us/m4" {
+		t.Fatalf("second page = %v", ids)
+	}
+	cursor = next(cursor, changes)
+	if _, ids = feed(cursor, future); len(ids) != 0 {
+		t.Fatalf("caught up page = %v", ids)
+	}
+
+	// Edits and deletions show up after the cursor
+	if err := repo.ApplyMessageEdit("dev1", "111@s.whatsapp.net", "m1", "Corrected invoice", time.Now()); err != nil {
+		t.Fatalf("ApplyMessageEdit() error = %v", err)
+	}
+	if err := repo.DeleteMessageByDevice("dev1", "m2", "111@s.whatsapp.net"); err != nil {
+		t.Fatalf("DeleteMessageByDevice() error = %v", err)
+	}
+	if err := repo.DeleteMessageByDevice("dev2", "m1", "111@s.whatsapp.net"); err != nil {
+		t.Fatalf("DeleteMessageByDevice(dev2) error = %v", err)
+	}
+
+	if _, ids = feed(cursor, suiteBaseTime); len(ids) != 0 {
+		t.Fatalf("changes after Until = %v", ids)
+	}
+	changes, ids = feed(cursor, future)
+	if len(ids) != 1 || changes.Messages[0].Content != "Corrected invoice" {
+		t.Fatalf("changes after edit = %v", ids)
+	}
+	if len(changes.Deletions) != 1 || changes.Deletions[0].MessageID != "m2" || changes.Deletions[0].DeviceID != "dev1" {
+		t.Fatalf("deletions = %+v", changes.Deletions)
+	}
+	cursor = next(cursor, changes)
+	if changes, _ = feed(cursor, future); len(changes.Deletions) != 0 {
+		t.Fatalf("deletions after cursor = %+v", changes.Deletions)
+	}
+
+	if pruned, err := repo.PruneMessageDeletions(time.Now().Add(-time.Hour)); err != nil || pruned != 0 {
+		t.Fatalf("PruneMessageDeletions(past) = %d, %v", pruned, err)
+	}
+	if pruned, err := repo.PruneMessageDeletions(future); err != nil || pruned != 2 {
+		t.Fatalf("PruneMessageDeletions(future) = %d, %v", pruned, err)
+	}
+}
+
+func testRepositoryMetadata(t *testing.T, repo domainChatStorage.IChatStorageRepository) {
+	seedMessages(t, repo)
+	aliceJID, groupJID := "111@s.whatsapp.net", "222@g.us"
+
+	archived, pinned := true, true
+	mutedUntil := suiteBaseTime.Add(8 * time.Hour)
+	updates := []struct {
+		chatJID string
+		update  domainChatStorage.ChatMetadataUpdate
+	}{
+		{aliceJID, domainChatStorage.ChatMetadataUpdate{AddUnread: 1}},
+		{aliceJID, domainChatStorage.ChatMetadataUpdate{AddUnread: 2}},
+		{aliceJID, domainChatStorage.ChatMetadataUpdate{MutedUntil: &mutedUntil}},
+		{groupJID, domainChatStorage.ChatMetadataUpdate{Archived: &archived, Pinned: &pinned}},
+		// Chats that are not stored are left alone
+		{"999@s.whatsapp.net", domainChatStorage.ChatMetadataUpdate{AddUnread: 1}},
+	}
+	for _, u := range updates {
+		if err := repo.UpdateChatMetadata("dev1", u.chatJID, u.update); err != nil {
+			t.Fatalf("UpdateChatMetadata(%s) error = %v", u.chatJID, err)
+		}
+	}
+	if missing, _ := repo.GetChatByDevice("dev1", "999@s.whatsapp.net"); missing != nil {
+		t.Errorf("metadata update created chat %+v", missing)
+	}
+
+	chat, _ := repo.GetChatByDevice("dev1", aliceJID)
+	if chat.UnreadCount != 3 || chat.Archived || chat.MutedUntil == nil || !chat.MutedUntil.Equal(mutedUntil) {
+		t.Fatalf("chat after updates = %+v", chat)
+	}
+	// Storing the chat again keeps its state
+	if err := repo.StoreChat(chat); err != nil {
+		t.Fatalf("StoreChat() error = %v", err)
+	}
+
+	filterJIDs := func(filter domainChatStorage.ChatFilter) []string {
+		t.Helper()
+		filter.DeviceID = "dev1"
+		chats, err := repo.GetChats(&filter)
+		if err != nil {
+			t.Fatalf("GetChats(%+v) error = %v", filter, err)
+		}
+		var jids []string
+		for _, chat := range chats {
+			jids = append(jids, chat.JID)
+		}
+		return jids
+	}
+	yes, no := true, false
+	if jids := filterJIDs(domainChatStorage.ChatFilter{Unread: &yes}); len(jids) != 1 || jids[0] != aliceJID {
+		t.Errorf("unread chats = %v", jids)
+	}
+	if jids := filterJIDs(domainChatStorage.ChatFilter{Archived: &yes, Pinned: &yes}); len(jids) != 1 || jids[0] != groupJID {
+		t.Errorf("archived and pinned chats = %v", jids)
+	}
+	if jids := filterJIDs(domainChatStorage.ChatFilter{Archived: &no}); len(jids) != 1 || jids[0] != aliceJID {
+		t.Errorf("chats not archived = %v", jids)
+	}
+
+	// Reading resets the counter, marking unread keeps the chat unread
+	if err := repo.UpdateChatMetadata("dev1", aliceJID, domainChatStorage.ChatReadUpdate()); err != nil {
+		t.Fatalf("UpdateChatMetadata(read) error = %v", err)
+	}
+	if jids := filterJIDs(domainChatStorage.ChatFilter{Unread: &yes}); len(jids) != 0 {
+		t.Errorf("unread chats after read = %v", jids)
+	}
+	if err := repo.UpdateChatMetadata("dev1", groupJID, domainChatStorage.ChatMetadataUpdate{MarkedUnread: &yes}); err != nil {
+		t.Fatalf("UpdateChatMetadata(marked unread) error = %v", err)
+	}
+	if jids := filterJIDs(domainChatStorage.ChatFilter{Unread: &yes}); len(jids) != 1 || jids[0] != groupJID {
+		t.Errorf("unread chats after marking = %v", jids)
+	}
+
+	// Contact names merge and are found by chat search
+	for _, contact := range []*domainChatStorage.Contact{
+		{DeviceID: "dev1", JID: aliceJID, PushName: "Ali"},
+		{DeviceID: "dev1", JID: aliceJID, FullName: "Alice Cooper"},
+	} {
+		if err := repo.StoreContact(contact); err != nil {
+			t.Fatalf("StoreContact() error = %v", err)
+		}
+	}
+	chat, _ = repo.GetChatByDevice("dev1", aliceJID)
+	if chat.ContactName != "Alice Cooper" {
+		t.Errorf("contact name = %q", chat.ContactName)
+	}
+	if jids := filterJIDs(domainChatStorage.ChatFilter{SearchName: "Cooper"}); len(jids) != 1 || jids[0] != aliceJID {
+		t.Errorf("chats matching contact name = %v", jids)
+	}
+	if err := repo.StoreContact(&domainChatStorage.Contact{DeviceID: "dev1"}); err == nil {
+		t.Error("StoreContact() without jid should fail")
+	}
+
+	// Group members
+	if err := repo.SetGroupParticipants("dev1", groupJID, []*domainChatStorage.GroupParticipant{
+		{JID: "333@s.whatsapp.net", IsAdmin: true, IsSuperAdmin: true},
+		{JID: "444@s.whatsapp.net"},
+		{JID: "555@s.whatsapp.net"},
+	}); err != nil {
+		t.Fatalf("SetGroupParticipants() error = %v", err)
+	}
+	if err := repo.ApplyGroupParticipantChange("dev1", groupJID, domainChatStorage.GroupParticipantChange{
+		Join:    []string{"666@s.whatsapp.net"},
+		Leave:   []string{"555@s.whatsapp.net"},
+		Promote: []string{"444@s.whatsapp.net"},
+		Demote:  []string{"333@s.whatsapp.net"},
+	}); err != nil {
+		t.Fatalf("ApplyGroupParticipantChange() error = %v", err)
+	}
+	participants, err := repo.GetGroupParticipants("dev1", groupJID)
+	if err != nil {
+		t.Fatalf("GetGroupParticipants() error = %v", err)
+	}
+	var got []string
+	for _, participant := range participants {
+		got = append(got, fmt.Sprintf("%s admin=%v super=%v", participant.JID, participant.IsAdmin, participant.IsSuperAdmin))
+	}
+	want := []string{
+		"444@s.whatsapp.net admin=true super=false",
+		"333@s.whatsapp.net admin=false super=false",
+		"666@s.whatsapp.net admin=false super=false",
+	}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("participants = %v, want %v", got, want)
+	}
+	if other, _ := repo.GetGroupParticipants("dev2", groupJID); len(other) != 0 {
+		t.Errorf("participants of other device = %v", other)
+	}
+}
+
+func testRepositoryAnalytics(t *testing.T, repo domainChatStorage.IChatStorageRepository) {
+	seedMessages(t, repo)
+	aliceJID, groupJID := "111@s.whatsapp.net", "222@g.us"
+	err := repo.StoreMessagesBatch([]*domainChatStorage.Message{
+		{ID: "m5", ChatJID: aliceJID, DeviceID: "dev1", Sender: "me@s.whatsapp.net", Content: "Paid", Timestamp: suiteBaseTime.Add(6 * time.Minute), IsFromMe: true},
+		{ID: "m6", ChatJID: aliceJID, DeviceID: "dev1", Sender: aliceJID, Content: "Any update?", Timestamp: suiteBaseTime.Add(25 * time.Hour)},
+	})
+	if err != nil {
+		t.Fatalf("StoreMessagesBatch() error = %v", err)
+	}
+	if err := repo.StoreContact(&domainChatStorage.Contact{DeviceID: "dev1", JID: "333@s.whatsapp.net", FullName: "Bob"}); err != nil {
+		t.Fatalf("StoreContact() error = %v", err)
+	}
+
+	analytics, err := repo.GetMessageAnalytics(&domainChatStorage.AnalyticsFilter{DeviceID: "dev1", Granularity: domainChatStorage.AnalyticsGranularityDay})
+	if err != nil {
+		t.Fatalf("GetMessageAnalytics() error = %v", err)
+	}
+	if analytics.Inbound != 4 || analytics.Outbound != 2 {
+		t.Fatalf("inbound/outbound = %d/%d, want 4/2", analytics.Inbound, analytics.Outbound)
+	}
+	wantVolume := []domainChatStorage.MessageVolume{{Period: "2025-03-01", Inbound: 3, Outbound: 2}, {Period: "2025-03-02", Inbound: 1}}
+	if fmt.Sprint(analytics.Volume) != fmt.Sprint(wantVolume) {
+		t.Fatalf("Volume = %+v, want %+v", analytics.Volume, wantVolume)
+	}
+	if len(analytics.TopChats) != 2 || analytics.TopChats[0] != (domainChatStorage.ChatActivity{ChatJID: aliceJID, Name: "Alice", Inbound: 3, Outbound: 2}) {
+		t.Fatalf("TopChats = %+v", analytics.TopChats)
+	}
+	if len(analytics.Media) != 1 || analytics.Media[0] != (domainChatStorage.MediaUsage{MediaType: "document", Messages: 1, Bytes: 2048}) {
+		t.Fatalf("Media = %+v", analytics.Media)
+	}
+	wantSender := domainChatStorage.SenderActivity{ChatJID: groupJID, ChatName: "Project Group", SenderJID: "333@s.whatsapp.net", SenderName: "Bob", Messages: 1}
+	if len(analytics.TopSenders) != 1 || analytics.TopSenders[0] != wantSender {
+		t.Fatalf("TopSenders = %+v, want %+v", analytics.TopSenders, wantSender)
+	}
+	// Replies after 1 and 3 minutes, the last incoming turn is unanswered
+	if analytics.ResponseTime != (domainChatStorage.ResponseTimeStats{Samples: 2, MedianSeconds: 120}) {
+		t.Fatalf("ResponseTime = %+v", analytics.ResponseTime)
+	}
+
+	start, end := suiteBaseTime.Add(2*time.Minute), suiteBaseTime.Add(10*time.Minute)
+	analytics, err = repo.GetMessageAnalytics(&domainChatStorage.AnalyticsFilter{
+		DeviceID: "dev1", StartTime: &start, EndTime: &end, Granularity: domainChatStorage.AnalyticsGranularityHour, Limit: 1,
+	})
+	if err != nil {
+		t.Fatalf("GetMessageAnalytics(range) error = %v", err)
+	}
+	wantVolume = []domainChatStorage.MessageVolume{{Period: "2025-03-01T12:00:00Z", Inbound: 2, Outbound: 2}}
+	if fmt.Sprint(analytics.Volume) != fmt.Sprint(wantVolume) {
+		t.Fatalf("hourly Volume = %+v, want %+v", analytics.Volume, wantVolume)
+	}
+	if len(analytics.TopChats) != 1 || analytics.TopChats[0].ChatJID != aliceJID {
+		t.Fatalf("limited TopChats = %+v", analytics.TopChats)
+	}
+	if analytics.ResponseTime != (domainChatStorage.ResponseTimeStats{Samples: 1, MedianSeconds: 180}) {
+		t.Fatalf("ranged ResponseTime = %+v", analytics.ResponseTime)
+	}
+
+	analytics, err = repo.GetMessageAnalytics(&domainChatStorage.AnalyticsFilter{DeviceID: "dev1", ChatJID: groupJID})
+	if err != nil {
+		t.Fatalf("GetMessageAnalytics(group) error = %v", err)
+	}
+	if analytics.Inbound != 1 || len(analytics.TopChats) != 1 || analytics.ResponseTime.Samples != 0 || len(analytics.Media) != 0 {
+		t.Fatalf("group analytics = %+v", analytics)
+	}
+
+	if _, err := repo.GetMessageAnalytics(&domainChatStorage.AnalyticsFilter{}); err == nil {
+		t.Fatalf("GetMessageAnalytics() without device should fail")
+	}
+}
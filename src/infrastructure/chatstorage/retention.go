@@ -0,0 +1,145 @@
+package chatstorage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+)
+
+// Retention queries are shared by both backends. They are written with ?
+// placeholders and passed through the backend's bind function.
+
+// pruneCandidatesQuery selects id and chat_jid of the messages policy would delete
+func pruneCandidatesQuery(policy domainChatStorage.RetentionPolicy, now time.Time) (string, []any) {
+	var conditions []string
+	var args []any
+
+	if policy.MaxAge > 0 {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, now.Add(-policy.MaxAge))
+	}
+
+	if policy.MaxMessagesPerChat <= 0 {
+		query := "SELECT id, chat_jid FROM messages WHERE device_id = ? AND " + strings.Join(conditions, " AND ")
+		if policy.KeepStarred {
+			query += " AND NOT COALESCE(is_starred, FALSE)"
+		}
+		return query, append([]any{policy.DeviceID}, args...)
+	}
+
+	conditions = append(conditions, "position > ?")
+	args = append(args, policy.MaxMessagesPerChat)
+
+	query := `
+		SELECT id, chat_jid FROM (
+			SELECT id, chat_jid, timestamp, COALESCE(is_starred, FALSE) AS is_starred,
+				ROW_NUMBER() OVER (PARTITION BY chat_jid ORDER BY timestamp DESC, id DESC) AS position
+			FROM messages
+			WHERE device_id = ?
+		) ranked
+		WHERE (` + strings.Join(conditions, " OR ") + `)`
+	if policy.KeepStarred {
+		query += " AND NOT is_starred"
+	}
+	return query, append([]any{policy.DeviceID}, args...)
+}
+
+// listMessageDeviceIDs returns every device that has stored messages
+func listMessageDeviceIDs(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT DISTINCT device_id FROM messages ORDER BY device_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deviceIDs []string
+	for rows.Next() {
+		var deviceID string
+		if err := rows.Scan(&deviceID); err != nil {
+			return nil, err
+		}
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	return deviceIDs, rows.Err()
+}
+
+// previewPrune counts the messages policy would delete, per chat
+func previewPrune(db *sql.DB, bind func(string) string, policy domainChatStorage.RetentionPolicy) (*domainChatStorage.PrunePreview, error) {
+	if policy.DeviceID == "" {
+		return nil, fmt.Errorf("device_id is required for pruning (data isolation)")
+	}
+
+	preview := &domainChatStorage.PrunePreview{DeviceID: policy.DeviceID, Chats: []domainChatStorage.PruneChatPreview{}}
+	if !policy.Enabled() {
+		return preview, nil
+	}
+
+	candidates, args := pruneCandidatesQuery(policy, time.Now())
+	rows, err := db.Query(bind(`
+		SELECT chat_jid, COUNT(*) FROM (`+candidates+`) candidates
+		GROUP BY chat_jid
+		ORDER BY COUNT(*) DESC, chat_jid
+	`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to preview pruning: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var chat domainChatStorage.PruneChatPreview
+		if err := rows.Scan(&chat.ChatJID, &chat.Messages); err != nil {
+			return nil, err
+		}
+		preview.Messages += chat.Messages
+		preview.Chats = append(preview.Chats, chat)
+	}
+	return preview, rows.Err()
+}
+
+// pruneMessages deletes one batch of at most limit messages selected by policy.
+// Candidates are read first so the write lock is only held for the delete.
+func pruneMessages(db *sql.DB, bind func(string) string, policy domainChatStorage.RetentionPolicy, limit int) (int64, error) {
+	if policy.DeviceID == "" {
+		return 0, fmt.Errorf("device_id is required for pruning (data isolation)")
+	}
+	if !policy.Enabled() || limit <= 0 {
+		return 0, nil
+	}
+
+	candidates, args := pruneCandidatesQuery(policy, time.Now())
+	rows, err := db.Query(bind(candidates+" LIMIT ?"), append(args, limit)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select messages to prune: %w", err)
+	}
+
+	var keys []string
+	deleteArgs := []any{policy.DeviceID}
+	for rows.Next() {
+		var id, chatJID string
+		if err := rows.Scan(&id, &chatJID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		keys = append(keys, "(?, ?)")
+		deleteArgs = append(deleteArgs, id, chatJID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	result, err := db.Exec(bind(`
+		DELETE FROM messages
+		WHERE device_id = ? AND (id, chat_jid) IN (VALUES `+strings.Join(keys, ", ")+`)
+	`), deleteArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune messages: %w", err)
+	}
+	return result.RowsAffected()
+}
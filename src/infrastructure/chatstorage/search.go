@@ -0,0 +1,463 @@
+package chatstorage
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	"github.com/sirupsen/logrus"
+)
+
+// searchTerm is a single word or quoted phrase from a search query
+type searchTerm struct {
+	text   string
+	prefix bool
+}
+
+// parseSearchQuery splits user input into OR-separated groups of terms that
+// must all match. Quoted text is kept as a phrase, a trailing * makes a term a
+// prefix match and a bare uppercase OR starts a new group.
+func parseSearchQuery(input string) [][]searchTerm {
+	var groups [][]searchTerm
+	var current []searchTerm
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var text string
+		if runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			text = string(runes[i+1 : end])
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+				end++
+			}
+			text = string(runes[i:end])
+			i = end
+
+			if text == "OR" {
+				if len(current) > 0 {
+					groups = append(groups, current)
+					current = nil
+				}
+				continue
+			}
+		}
+
+		prefix := false
+		if i < len(runes) && runes[i] == '*' {
+			prefix = true
+			i++
+		}
+		if strings.HasSuffix(text, "*") {
+			prefix = true
+			text = strings.TrimRight(text, "*")
+		}
+
+		text = strings.Join(strings.Fields(text), " ")
+		if text == "" {
+			continue
+		}
+		current = append(current, searchTerm{text: text, prefix: prefix})
+	}
+
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+// buildFTSQuery renders parsed terms as an FTS5 MATCH expression. Every term
+// is quoted so FTS syntax characters in user input can't break the query.
+func buildFTSQuery(groups [][]searchTerm) string {
+	parts := make([]string, 0, len(groups))
+	for _, group := range groups {
+		terms := make([]string, 0, len(group))
+		for _, term := range group {
+			quoted := `"` + strings.ReplaceAll(term.text, `"`, `""`) + `"`
+			if term.prefix {
+				quoted += "*"
+			}
+			terms = append(terms, quoted)
+		}
+		parts = append(parts, strings.Join(terms, " "))
+	}
+	return strings.Join(parts, " OR ")
+}
+
+// ensureSearchIndex creates the FTS5 index over message content and filenames
+// when the SQLite build supports it. The index is an external content table
+// kept in sync by triggers, so it is rebuilt whenever the triggers are
+// (re)created. Without FTS5 the triggers are dropped and search falls back to
+// LIKE, which keeps a database usable by binaries built with and without FTS5.
+// With encryption on the index could only hold ciphertext, so it is dropped.
+func (r *SQLiteRepository) ensureSearchIndex() error {
+	r.ftsEnabled = false
+	if r.cipher != nil {
+		return r.dropSearchIndex(true)
+	}
+
+	var enabled bool
+	if err := r.db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled); err != nil {
+		return err
+	}
+
+	if !enabled {
+		if err := r.dropSearchIndex(false); err != nil {
+			return err
+		}
+		logrus.Warn("Chat storage: SQLite was built without FTS5 (build tag sqlite_fts5), message search uses LIKE")
+		return nil
+	}
+
+	var triggers int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'messages_fts_%'`).Scan(&triggers); err != nil {
+		return err
+	}
+
+	statements := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
+			content, filename,
+			content='messages', content_rowid='rowid',
+			tokenize='unicode61 remove_diacritics 2'
+		)`,
+		`CREATE TRIGGER IF NOT EXISTS messages_fts_ai AFTER INSERT ON messages BEGIN
+			INSERT INTO messages_fts(rowid, content, filename) VALUES (new.rowid, new.content, new.filename);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS messages_fts_ad AFTER DELETE ON messages BEGIN
+			INSERT INTO messages_fts(messages_fts, rowid, content, filename) VALUES ('delete', old.rowid, old.content, old.filename);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS messages_fts_au AFTER UPDATE OF content, filename ON messages BEGIN
+			INSERT INTO messages_fts(messages_fts, rowid, content, filename) VALUES ('delete', old.rowid, old.content, old.filename);
+			INSERT INTO messages_fts(rowid, content, filename) VALUES (new.rowid, new.content, new.filename);
+		END`,
+	}
+	for _, statement := range statements {
+		if _, err := r.db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to create search index: %w", err)
+		}
+	}
+
+	// Triggers were missing, so the index may be empty or stale
+	if triggers < 3 {
+		logrus.Info("Chat storage: building message search index")
+		if _, err := r.db.Exec(`INSERT INTO messages_fts(messages_fts) VALUES ('rebuild')`); err != nil {
+			return fmt.Errorf("failed to rebuild search index: %w", err)
+		}
+	}
+
+	r.ftsEnabled = true
+	return nil
+}
+
+// dropSearchIndex removes the triggers keeping the FTS5 index in sync and, with
+// table set, the index itself
+func (r *SQLiteRepository) dropSearchIndex(table bool) error {
+	for _, trigger := range []string{"messages_fts_ai", "messages_fts_ad", "messages_fts_au"} {
+		if _, err := r.db.Exec("DROP TRIGGER IF EXISTS " + trigger); err != nil {
+			return err
+		}
+	}
+	if table {
+		if _, err := r.db.Exec("DROP TABLE IF EXISTS messages_fts"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchMessagesFullText searches message content and media captions/filenames
+// across the device's chats, best matches first
+func (r *SQLiteRepository) SearchMessagesFullText(filter *domainChatStorage.MessageSearchFilter) ([]*domainChatStorage.MessageSearchResult, int64, error) {
+	// Require device_id for data isolation - fail fast if missing
+	if filter.DeviceID == "" {
+		return nil, 0, fmt.Errorf("device_id is required for message search (data isolation)")
+	}
+
+	groups := parseSearchQuery(filter.Query)
+	if len(groups) == 0 {
+		return []*domainChatStorage.MessageSearchResult{}, 0, nil
+	}
+
+	if filter.Limit <= 0 || filter.Limit > 1000 {
+		filter.Limit = 1000
+	}
+
+	if r.cipher != nil {
+		return searchDecrypted(r.db, sqliteBind, r.cipher, filter, groups)
+	}
+	if r.ftsEnabled {
+		return r.searchFTS(filter, groups)
+	}
+	return r.searchLike(filter, groups)
+}
+
+// messageSearchConditions returns the non-text filters shared by both search paths
+func messageSearchConditions(filter *domainChatStorage.MessageSearchFilter) ([]string, []any) {
+	conditions := []string{"m.device_id = ?"}
+	args := []any{filter.DeviceID}
+
+	if filter.ChatJID != "" {
+		conditions = append(conditions, "m.chat_jid = ?")
+		args = append(args, filter.ChatJID)
+	}
+	if filter.StartTime != nil {
+		conditions = append(conditions, "m.timestamp >= ?")
+		args = append(args, *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		conditions = append(conditions, "m.timestamp <= ?")
+		args = append(args, *filter.EndTime)
+	}
+	if filter.MediaOnly {
+		conditions = append(conditions, "m.media_type != ''")
+	}
+
+	return conditions, args
+}
+
+func (r *SQLiteRepository) searchFTS(filter *domainChatStorage.MessageSearchFilter, groups [][]searchTerm) ([]*domainChatStorage.MessageSearchResult, int64, error) {
+	conditions, args := messageSearchConditions(filter)
+	conditions = append([]string{"messages_fts MATCH ?"}, conditions...)
+	args = append([]any{buildFTSQuery(groups)}, args...)
+	where := strings.Join(conditions, " AND ")
+
+	total, err := r.getCount(`
+		SELECT COUNT(*) FROM messages_fts
+		JOIN messages m ON m.rowid = messages_fts.rowid
+		WHERE `+where, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	// Content matches weigh more than filename matches
+	query := `
+		SELECT ` + messageColumns + `,
+			snippet(messages_fts, -1, ?, ?, '…', 16), bm25(messages_fts, 1.0, 0.5)
+		FROM messages_fts
+		JOIN messages m ON m.rowid = messages_fts.rowid
+		WHERE ` + where + `
+		ORDER BY bm25(messages_fts, 1.0, 0.5), m.timestamp DESC
+		LIMIT ? OFFSET ?
+	`
+	queryArgs := append([]any{domainChatStorage.SearchHighlightStart, domainChatStorage.SearchHighlightEnd}, args...)
+	queryArgs = append(queryArgs, filter.Limit, filter.Offset)
+
+	results, err := r.querySearchResults(query, queryArgs...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// bm25 is lower-is-better, expose it as higher-is-better
+	for _, result := range results {
+		result.Rank = -result.Rank
+	}
+	return results, total, nil
+}
+
+// searchLike is the fallback when FTS5 is unavailable: substring matching
+// ordered by recency, with snippets built in Go
+func (r *SQLiteRepository) searchLike(filter *domainChatStorage.MessageSearchFilter, groups [][]searchTerm) ([]*domainChatStorage.MessageSearchResult, int64, error) {
+	conditions, args := messageSearchConditions(filter)
+
+	var groupConditions []string
+	for _, group := range groups {
+		var termConditions []string
+		for _, term := range group {
+			termConditions = append(termConditions, "(LOWER(m.content) LIKE ? OR LOWER(m.filename) LIKE ?)")
+			pattern := "%" + strings.ToLower(term.text) + "%"
+			args = append(args, pattern, pattern)
+		}
+		groupConditions = append(groupConditions, "("+strings.Join(termConditions, " AND ")+")")
+	}
+	conditions = append(conditions, "("+strings.Join(groupConditions, " OR ")+")")
+	where := strings.Join(conditions, " AND ")
+
+	total, err := r.getCount(`SELECT COUNT(*) FROM messages m WHERE `+where, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	query := `
+		SELECT ` + messageColumns + `,
+			'', 0
+		FROM messages m
+		WHERE ` + where + `
+		ORDER BY m.timestamp DESC
+		LIMIT ? OFFSET ?
+	`
+	args = append(args, filter.Limit, filter.Offset)
+
+	results, err := r.querySearchResults(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, result := range results {
+		text := result.Message.Content
+		if text == "" {
+			text = result.Message.Filename
+		}
+		result.Snippet = highlightSnippet(text, groups, 64)
+	}
+	return results, total, nil
+}
+
+// maxDecryptedSearchRows caps how many messages an encrypted search decrypts
+const maxDecryptedSearchRows = 20000
+
+// searchDecrypted matches content that no search index can read because it is
+// encrypted. The newest messages passing the other filters are decrypted and
+// matched in Go like the LIKE fallback, at most maxDecryptedSearchRows of them,
+// so older messages are only found by narrowing the search to a chat or dates.
+func searchDecrypted(db *sql.DB, bind func(string) string, c *FieldCipher, filter *domainChatStorage.MessageSearchFilter, groups [][]searchTerm) ([]*domainChatStorage.MessageSearchResult, int64, error) {
+	conditions, args := messageSearchConditions(filter)
+	rows, err := db.Query(bind(`
+		SELECT `+messageColumns+`
+		FROM messages m
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY m.timestamp DESC
+		LIMIT ?
+	`), append(args, maxDecryptedSearchRows)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search messages: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*domainChatStorage.MessageSearchResult{}
+	var total, scanned int64
+	for rows.Next() {
+		scanned++
+		message, err := scanMessageRow(c, rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan message: %w", err)
+		}
+		if !matchesSearch(message, groups) {
+			continue
+		}
+
+		total++
+		if total <= int64(filter.Offset) || len(results) >= filter.Limit {
+			continue
+		}
+		text := message.Content
+		if text == "" {
+			text = message.Filename
+		}
+		results = append(results, &domainChatStorage.MessageSearchResult{
+			Message: message,
+			Snippet: highlightSnippet(text, groups, 64),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating messages: %w", err)
+	}
+	if scanned == maxDecryptedSearchRows {
+		logrus.Infof("Chat storage: encrypted search of device %s stopped at the newest %d messages, filter by chat or date to search older ones",
+			filter.DeviceID, maxDecryptedSearchRows)
+	}
+	return results, total, nil
+}
+
+// matchesSearch reports whether every term of any group occurs in the
+// message content or filename, ignoring case
+func matchesSearch(message *domainChatStorage.Message, groups [][]searchTerm) bool {
+	content, filename := strings.ToLower(message.Content), strings.ToLower(message.Filename)
+	for _, group := range groups {
+		matched := true
+		for _, term := range group {
+			text := strings.ToLower(term.text)
+			if !strings.Contains(content, text) && !strings.Contains(filename, text) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *SQLiteRepository) querySearchResults(query string, args ...any) ([]*domainChatStorage.MessageSearchResult, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*domainChatStorage.MessageSearchResult{}
+	for rows.Next() {
+		result := &domainChatStorage.MessageSearchResult{}
+		message, err := scanMessageRow(r.cipher, rows, &result.Snippet, &result.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		result.Message = message
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+	return results, nil
+}
+
+// highlightSnippet cuts a window of roughly width bytes around the first match
+// and wraps every term occurrence with the highlight markers
+func highlightSnippet(text string, groups [][]searchTerm, width int) string {
+	var patterns []string
+	for _, group := range groups {
+		for _, term := range group {
+			patterns = append(patterns, regexp.QuoteMeta(term.text))
+		}
+	}
+	if len(patterns) == 0 || text == "" {
+		return text
+	}
+
+	re := regexp.MustCompile("(?i)" + strings.Join(patterns, "|"))
+	first := re.FindStringIndex(text)
+
+	start, end := 0, len(text)
+	if first != nil && len(text) > width {
+		start = max(first[0]-width/4, 0)
+		end = min(start+width, len(text))
+		for start > 0 && !utf8.RuneStart(text[start]) {
+			start--
+		}
+		for end < len(text) && !utf8.RuneStart(text[end]) {
+			end++
+		}
+	} else if len(text) > width {
+		end = width
+		for end < len(text) && !utf8.RuneStart(text[end]) {
+			end++
+		}
+	}
+
+	window := re.ReplaceAllStringFunc(text[start:end], func(match string) string {
+		return domainChatStorage.SearchHighlightStart + match + domainChatStorage.SearchHighlightEnd
+	})
+	if start > 0 {
+		window = "…" + window
+	}
+	if end < len(text) {
+		window += "…"
+	}
+	return window
+}
@@ -0,0 +1,112 @@
+package chatstorage
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildFTSQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "invoice", want: `"invoice"`},
+		{input: "invoice*", want: `"invoice"*`},
+		{input: `"see you"  tomorrow`, want: `"see you" "tomorrow"`},
+		{input: `"see you"*`, want: `"see you"*`},
+		{input: "meeting OR call", want: `"meeting" OR "call"`},
+		{input: "OR meeting OR", want: `"meeting"`},
+		{input: `a-b (c) NEAR`, want: `"a-b" "(c)" "NEAR"`},
+		{input: `  "" * `, want: ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := buildFTSQuery(parseSearchQuery(tt.input)); got != tt.want {
+				t.Fatalf("buildFTSQuery(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	groups := parseSearchQuery("invoice")
+
+	if got, want := highlightSnippet("Your Invoice is ready", groups, 64), "Your <mark>Invoice</mark> is ready"; got != want {
+		t.Fatalf("highlightSnippet() = %q, want %q", got, want)
+	}
+
+	long := "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do the invoice eiusmod tempor incididunt ut labore"
+	got := highlightSnippet(long, groups, 40)
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Fatalf("highlightSnippet() = %q, want ellipsis on both ends", got)
+	}
+	if !strings.Contains(got, "<mark>invoice</mark>") {
+		t.Fatalf("highlightSnippet() = %q, want highlighted match", got)
+	}
+}
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "invoice", want: `(('invoice'))`},
+		{input: "invoice*", want: `(('invoice':*))`},
+		{input: `"see you" tomorrow`, want: `(('see' <-> 'you') & ('tomorrow'))`},
+		{input: "meeting OR call", want: `(('meeting')) | (('call'))`},
+		{input: `it's a\b`, want: `(('it' <-> 's') & ('a' <-> 'b'))`},
+		{input: `!!! ""`, want: ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := buildTSQuery(parseSearchQuery(tt.input)); got != tt.want {
+				t.Fatalf("buildTSQuery(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLiteSearchIndexWithEncryption(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "chatstorage.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	indexTables := func() int {
+		t.Helper()
+		var count int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name LIKE 'messages_fts%'`).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	key, _ := NewFieldCipher(testKey1, nil)
+	encrypted := &SQLiteRepository{db: db, cipher: key}
+	if err := encrypted.InitializeSchema(); err != nil {
+		t.Fatalf("InitializeSchema() error = %v", err)
+	}
+	if encrypted.ftsEnabled || indexTables() != 0 {
+		t.Fatalf("search index built with encryption on: ftsEnabled = %t, %d tables", encrypted.ftsEnabled, indexTables())
+	}
+
+	plain := &SQLiteRepository{db: db}
+	if err := plain.InitializeSchema(); err != nil {
+		t.Fatalf("InitializeSchema() error = %v", err)
+	}
+	if plain.ftsEnabled != (indexTables() > 0) {
+		t.Errorf("ftsEnabled = %t with %d index tables", plain.ftsEnabled, indexTables())
+	}
+
+	// Turning encryption on drops the index that was built without it
+	if err := encrypted.InitializeSchema(); err != nil {
+		t.Fatalf("InitializeSchema() error = %v", err)
+	}
+	if indexTables() != 0 {
+		t.Errorf("search index kept after turning encryption on")
+	}
+}
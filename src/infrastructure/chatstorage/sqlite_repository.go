@@ -4,12 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
-	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
-	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
@@ -17,12 +16,14 @@ import (
 
 // SQLiteRepository implements Repository using SQLite
 type SQLiteRepository struct {
-	db *sql.DB
+	db         *sql.DB
+	ftsEnabled bool         // FTS5 message index available, see ensureSearchIndex
+	cipher     *FieldCipher // nil stores message content in plaintext
 }
 
 // NewSQLiteRepository creates a new SQLite repository
-func NewStorageRepository(db *sql.DB) domainChatStorage.IChatStorageRepository {
-	return &SQLiteRepository{db: db}
+func NewStorageRepository(db *sql.DB, cipher *FieldCipher) domainChatStorage.IChatStorageRepository {
+	return &SQLiteRepository{db: db, cipher: cipher}
 }
 
 // StoreChat creates or updates a chat
@@ -52,9 +53,9 @@ func (r *SQLiteRepository) StoreChat(chat *domainChatStorage.Chat) error {
 // GetChat retrieves a chat by JID
 func (r *SQLiteRepository) GetChat(jid string) (*domainChatStorage.Chat, error) {
 	query := `
-		SELECT device_id, jid, name, last_message_time, ephemeral_expiration, created_at, updated_at
-		FROM chats
-		WHERE jid = ?
+		SELECT ` + chatColumns + `
+		FROM chats c
+		WHERE c.jid = ?
 	`
 
 	chat, err := r.scanChat(r.db.QueryRow(query, jid))
@@ -68,9 +69,9 @@ func (r *SQLiteRepository) GetChat(jid string) (*domainChatStorage.Chat, error)
 // GetChatByDevice retrieves a chat by JID for a specific device
 func (r *SQLiteRepository) GetChatByDevice(deviceID, jid string) (*domainChatStorage.Chat, error) {
 	query := `
-		SELECT device_id, jid, name, last_message_time, ephemeral_expiration, created_at, updated_at
-		FROM chats
-		WHERE jid = ? AND device_id = ?
+		SELECT ` + chatColumns + `
+		FROM chats c
+		WHERE c.jid = ? AND c.device_id = ?
 	`
 
 	chat, err := r.scanChat(r.db.QueryRow(query, jid, deviceID))
@@ -85,10 +86,8 @@ func (r *SQLiteRepository) GetChatByDevice(deviceID, jid string) (*domainChatSto
 // This is more efficient than searching through all chats
 func (r *SQLiteRepository) GetMessageByID(id string) (*domainChatStorage.Message, error) {
 	query := `
-		SELECT id, chat_jid, device_id, sender, content, timestamp, is_from_me,
-			media_type, filename, url, media_key, file_sha256,
-			file_enc_sha256, file_length, created_at, updated_at
-		FROM messages
+		SELECT ` + messageColumns + `
+		FROM messages m
 		WHERE id = ?
 		LIMIT 1
 	`
@@ -107,13 +106,34 @@ func (r *SQLiteRepository) GetChats(filter *domainChatStorage.ChatFilter) ([]*do
 	var args []any
 
 	query := `
-		SELECT c.device_id, c.jid, c.name, c.last_message_time, c.ephemeral_expiration, c.created_at, c.updated_at
+		SELECT ` + chatColumns + `
 		FROM chats c
 	`
 
 	if filter.SearchName != "" {
-		conditions = append(conditions, "c.name LIKE ?")
-		args = append(args, "%"+filter.SearchName+"%")
+		// Saved contact names match as well
+		conditions = append(conditions, `(c.name LIKE ? OR EXISTS (
+			SELECT 1 FROM contacts ct
+			WHERE ct.device_id = c.device_id AND ct.jid = c.jid AND (ct.full_name LIKE ? OR ct.push_name LIKE ?)
+		))`)
+		search := "%" + filter.SearchName + "%"
+		args = append(args, search, search, search)
+	}
+
+	if filter.Unread != nil {
+		if *filter.Unread {
+			conditions = append(conditions, "(c.unread_count > 0 OR c.marked_unread)")
+		} else {
+			conditions = append(conditions, "NOT (c.unread_count > 0 OR c.marked_unread)")
+		}
+	}
+	if filter.Archived != nil {
+		conditions = append(conditions, "c.archived = ?")
+		args = append(args, *filter.Archived)
+	}
+	if filter.Pinned != nil {
+		conditions = append(conditions, "c.pinned = ?")
+		args = append(args, *filter.Pinned)
 	}
 
 	if filter.HasMedia {
@@ -126,11 +146,16 @@ func (r *SQLiteRepository) GetChats(filter *domainChatStorage.ChatFilter) ([]*do
 		args = append(args, filter.DeviceID)
 	}
 
+	if filter.Before != nil {
+		conditions = append(conditions, "(c.last_message_time, c.jid) < (?, ?)")
+		args = append(args, filter.Before.LastMessageTime, filter.Before.JID)
+	}
+
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	query += " ORDER BY c.last_message_time DESC"
+	query += " ORDER BY c.last_message_time DESC, c.jid DESC"
 
 	// Safely add LIMIT and OFFSET using parameterized values
 	if filter.Limit > 0 {
@@ -141,7 +166,7 @@ func (r *SQLiteRepository) GetChats(filter *domainChatStorage.ChatFilter) ([]*do
 		query += " LIMIT ?"
 		args = append(args, filter.Limit)
 
-		if filter.Offset > 0 {
+		if filter.Offset > 0 && filter.Before == nil {
 			query += " OFFSET ?"
 			args = append(args, filter.Offset)
 		}
@@ -222,15 +247,20 @@ func (r *SQLiteRepository) StoreMessage(message *domainChatStorage.Message) erro
 		return nil
 	}
 
+	stored, err := r.cipher.sealMessage(message)
+	if err != nil {
+		return err
+	}
+
 	// Try update first, then insert if no rows affected (cross-db compatible)
 	result, err := r.db.Exec(`
 		UPDATE messages SET sender = ?, content = ?, timestamp = ?, is_from_me = ?,
 			media_type = ?, filename = ?, url = ?, media_key = ?, file_sha256 = ?,
-			file_enc_sha256 = ?, file_length = ?, updated_at = ?
+			file_enc_sha256 = ?, file_length = ?, mimetype = ?, is_ptt = ?, updated_at = ?
 		WHERE id = ? AND chat_jid = ? AND device_id = ?
-	`, message.Sender, message.Content, message.Timestamp, message.IsFromMe,
-		message.MediaType, message.Filename, message.URL, message.MediaKey, message.FileSHA256,
-		message.FileEncSHA256, message.FileLength, message.UpdatedAt,
+	`, message.Sender, stored.Content, message.Timestamp, message.IsFromMe,
+		message.MediaType, message.Filename, message.URL, stored.MediaKey, stored.FileSHA256,
+		stored.FileEncSHA256, message.FileLength, message.Mimetype, message.IsPTT, message.UpdatedAt,
 		message.ID, message.ChatJID, message.DeviceID)
 	if err != nil {
 		return err
@@ -242,12 +272,12 @@ func (r *SQLiteRepository) StoreMessage(message *domainChatStorage.Message) erro
 			INSERT INTO messages (
 				id, chat_jid, device_id, sender, content, timestamp, is_from_me,
 				media_type, filename, url, media_key, file_sha256,
-				file_enc_sha256, file_length, created_at, updated_at
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		`, message.ID, message.ChatJID, message.DeviceID, message.Sender, message.Content,
+				file_enc_sha256, file_length, mimetype, is_ptt, created_at, updated_at, status
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, message.ID, message.ChatJID, message.DeviceID, message.Sender, stored.Content,
 			message.Timestamp, message.IsFromMe, message.MediaType, message.Filename,
-			message.URL, message.MediaKey, message.FileSHA256, message.FileEncSHA256,
-			message.FileLength, message.CreatedAt, message.UpdatedAt)
+			message.URL, stored.MediaKey, stored.FileSHA256, stored.FileEncSHA256,
+			message.FileLength, message.Mimetype, message.IsPTT, message.CreatedAt, message.UpdatedAt, message.Status)
 	}
 	return err
 }
@@ -268,7 +298,7 @@ func (r *SQLiteRepository) StoreMessagesBatch(messages []*domainChatStorage.Mess
 	updateStmt, err := tx.Prepare(`
 		UPDATE messages SET sender = ?, content = ?, timestamp = ?, is_from_me = ?,
 			media_type = ?, filename = ?, url = ?, media_key = ?, file_sha256 = ?,
-			file_enc_sha256 = ?, file_length = ?, updated_at = ?
+			file_enc_sha256 = ?, file_length = ?, mimetype = ?, is_ptt = ?, updated_at = ?
 		WHERE id = ? AND chat_jid = ? AND device_id = ?
 	`)
 	if err != nil {
@@ -280,8 +310,8 @@ func (r *SQLiteRepository) StoreMessagesBatch(messages []*domainChatStorage.Mess
 		INSERT INTO messages (
 			id, chat_jid, device_id, sender, content, timestamp, is_from_me,
 			media_type, filename, url, media_key, file_sha256,
-			file_enc_sha256, file_length, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			file_enc_sha256, file_length, mimetype, is_ptt, created_at, updated_at, status
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare insert statement: %w", err)
@@ -296,11 +326,15 @@ func (r *SQLiteRepository) StoreMessagesBatch(messages []*domainChatStorage.Mess
 
 		message.CreatedAt = now
 		message.UpdatedAt = now
+		stored, err := r.cipher.sealMessage(message)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt message %s: %w", message.ID, err)
+		}
 
 		result, err := updateStmt.Exec(
-			message.Sender, message.Content, message.Timestamp, message.IsFromMe,
-			message.MediaType, message.Filename, message.URL, message.MediaKey, message.FileSHA256,
-			message.FileEncSHA256, message.FileLength, message.UpdatedAt,
+			message.Sender, stored.Content, message.Timestamp, message.IsFromMe,
+			message.MediaType, message.Filename, message.URL, stored.MediaKey, stored.FileSHA256,
+			stored.FileEncSHA256, message.FileLength, message.Mimetype, message.IsPTT, message.UpdatedAt,
 			message.ID, message.ChatJID, message.DeviceID,
 		)
 		if err != nil {
@@ -310,10 +344,10 @@ func (r *SQLiteRepository) StoreMessagesBatch(messages []*domainChatStorage.Mess
 		rowsAffected, _ := result.RowsAffected()
 		if rowsAffected == 0 {
 			_, err = insertStmt.Exec(
-				message.ID, message.ChatJID, message.DeviceID, message.Sender, message.Content,
+				message.ID, message.ChatJID, message.DeviceID, message.Sender, stored.Content,
 				message.Timestamp, message.IsFromMe, message.MediaType, message.Filename,
-				message.URL, message.MediaKey, message.FileSHA256, message.FileEncSHA256,
-				message.FileLength, message.CreatedAt, message.UpdatedAt,
+				message.URL, stored.MediaKey, stored.FileSHA256, stored.FileEncSHA256,
+				message.FileLength, message.Mimetype, message.IsPTT, message.CreatedAt, message.UpdatedAt, message.Status,
 			)
 			if err != nil {
 				return fmt.Errorf("failed to insert message %s: %w", message.ID, err)
@@ -360,13 +394,16 @@ func (r *SQLiteRepository) GetMessages(filter *domainChatStorage.MessageFilter)
 		args = append(args, *filter.IsFromMe)
 	}
 
+	if filter.Before != nil {
+		conditions = append(conditions, "(timestamp, id) < (?, ?)")
+		args = append(args, filter.Before.Timestamp, filter.Before.ID)
+	}
+
 	query := `
-		SELECT id, chat_jid, device_id, sender, content, timestamp, is_from_me,
-			media_type, filename, url, media_key, file_sha256,
-			file_enc_sha256, file_length, created_at, updated_at
-		FROM messages
+		SELECT ` + messageColumns + `
+		FROM messages m
 		WHERE ` + strings.Join(conditions, " AND ") + `
-		ORDER BY timestamp DESC
+		ORDER BY timestamp DESC, id DESC
 	`
 
 	// Safely add LIMIT and OFFSET using parameterized values
@@ -378,7 +415,7 @@ func (r *SQLiteRepository) GetMessages(filter *domainChatStorage.MessageFilter)
 		query += " LIMIT ?"
 		args = append(args, filter.Limit)
 
-		if filter.Offset > 0 {
+		if filter.Offset > 0 && filter.Before == nil {
 			query += " OFFSET ?"
 			args = append(args, filter.Offset)
 		}
@@ -402,68 +439,26 @@ func (r *SQLiteRepository) GetMessages(filter *domainChatStorage.MessageFilter)
 	return messages, rows.Err()
 }
 
-// SearchMessages performs database-level search for messages containing specific text
+// SearchMessages performs database-level search for messages in one chat,
+// most recent first
 func (r *SQLiteRepository) SearchMessages(deviceID, chatJID, searchText string, limit int) ([]*domainChatStorage.Message, error) {
-	// Require device_id for data isolation - fail fast if missing
-	if deviceID == "" {
-		return nil, fmt.Errorf("device_id is required for message search (data isolation)")
-	}
-
-	if strings.TrimSpace(searchText) == "" {
-		return []*domainChatStorage.Message{}, nil
-	}
-
-	var conditions []string
-	var args []any
-
-	conditions = append(conditions, "chat_jid = ?")
-	args = append(args, chatJID)
-
-	conditions = append(conditions, "device_id = ?")
-	args = append(args, deviceID)
-
-	// Add search condition using LIKE operator for case-insensitive search
-	conditions = append(conditions, "LOWER(content) LIKE ?")
-	args = append(args, "%"+strings.ToLower(searchText)+"%")
-
-	query := `
-		SELECT id, chat_jid, device_id, sender, content, timestamp, is_from_me,
-			media_type, filename, url, media_key, file_sha256,
-			file_enc_sha256, file_length, created_at, updated_at
-		FROM messages
-		WHERE ` + strings.Join(conditions, " AND ") + `
-		ORDER BY timestamp DESC
-	`
-
-	// Add limit with validation
-	if limit > 0 {
-		// Validate limit to prevent abuse
-		if limit > 1000 {
-			limit = 1000
-		}
-		query += " LIMIT ?"
-		args = append(args, limit)
-	}
-
-	rows, err := r.db.Query(query, args...)
+	results, _, err := r.SearchMessagesFullText(&domainChatStorage.MessageSearchFilter{
+		DeviceID: deviceID,
+		ChatJID:  chatJID,
+		Query:    searchText,
+		Limit:    limit,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to search messages: %w", err)
-	}
-	defer rows.Close()
-
-	var messages []*domainChatStorage.Message
-	for rows.Next() {
-		message, err := r.scanMessage(rows)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan message: %w", err)
-		}
-		messages = append(messages, message)
+		return nil, err
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating messages: %w", err)
+	messages := make([]*domainChatStorage.Message, len(results))
+	for i, result := range results {
+		messages[i] = result.Message
 	}
-
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Timestamp.After(messages[j].Timestamp)
+	})
 	return messages, nil
 }
 
@@ -488,24 +483,12 @@ func (r *SQLiteRepository) getCount(query string, args ...any) (int64, error) {
 
 // scanMessage is a private helper for scanning message rows
 func (r *SQLiteRepository) scanMessage(scanner interface{ Scan(...any) error }) (*domainChatStorage.Message, error) {
-	message := &domainChatStorage.Message{}
-	err := scanner.Scan(
-		&message.ID, &message.ChatJID, &message.DeviceID, &message.Sender, &message.Content,
-		&message.Timestamp, &message.IsFromMe, &message.MediaType, &message.Filename,
-		&message.URL, &message.MediaKey, &message.FileSHA256, &message.FileEncSHA256,
-		&message.FileLength, &message.CreatedAt, &message.UpdatedAt,
-	)
-	return message, err
+	return scanMessageRow(r.cipher, scanner)
 }
 
 // scanChat is a private helper for scanning chat rows
 func (r *SQLiteRepository) scanChat(scanner interface{ Scan(...any) error }) (*domainChatStorage.Chat, error) {
-	chat := &domainChatStorage.Chat{}
-	err := scanner.Scan(
-		&chat.DeviceID, &chat.JID, &chat.Name, &chat.LastMessageTime, &chat.EphemeralExpiration,
-		&chat.CreatedAt, &chat.UpdatedAt,
-	)
-	return chat, err
+	return scanChatRow(scanner)
 }
 
 // GetChatMessageCount returns the number of messages in a chat
@@ -549,6 +532,19 @@ func (r *SQLiteRepository) TruncateAllChats() error {
 		return fmt.Errorf("failed to delete chats: %w", err)
 	}
 
+	// Nothing is left to sync deletions against
+	if _, err := tx.Exec("DELETE FROM message_deletions"); err != nil {
+		return fmt.Errorf("failed to delete message deletions: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM contacts"); err != nil {
+		return fmt.Errorf("failed to delete contacts: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM group_participants"); err != nil {
+		return fmt.Errorf("failed to delete group participants: %w", err)
+	}
+
 	return tx.Commit()
 }
 
@@ -574,6 +570,18 @@ func (r *SQLiteRepository) DeleteDeviceData(deviceID string) error {
 		return fmt.Errorf("failed to delete device chats: %w", err)
 	}
 
+	if _, err := tx.Exec("DELETE FROM message_deletions WHERE device_id = ?", deviceID); err != nil {
+		return fmt.Errorf("failed to delete device message deletions: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM contacts WHERE device_id = ?", deviceID); err != nil {
+		return fmt.Errorf("failed to delete device contacts: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM group_participants WHERE device_id = ?", deviceID); err != nil {
+		return fmt.Errorf("failed to delete device group participants: %w", err)
+	}
+
 	return tx.Commit()
 }
 
@@ -665,40 +673,11 @@ func (r *SQLiteRepository) DeleteDeviceRecord(deviceID string) error {
 
 // GetChatNameWithPushName determines the appropriate name for a chat with pushname support
 func (r *SQLiteRepository) GetChatNameWithPushName(jid types.JID, chatJID string, senderUser string, pushName string) string {
-	// First, check if chat already exists with a name
 	existingChat, err := r.GetChat(chatJID)
-	if err == nil && existingChat != nil && existingChat.Name != "" {
-		// If we have a pushname and the existing name is just a phone number/JID user, update it
-		if pushName != "" && (existingChat.Name == jid.User || existingChat.Name == senderUser) {
-			return pushName
-		}
-		return existingChat.Name
-	}
-
-	// Determine chat type and name
-	var name string
-
-	switch jid.Server {
-	case "g.us":
-		// This is a group chat
-		// For now, use a generic name - this can be enhanced later with group info
-		name = fmt.Sprintf("Group %s", jid.User)
-	case "newsletter":
-		// This is a newsletter/channel
-		name = fmt.Sprintf("Newsletter %s", jid.User)
-	default:
-		// This is an individual contact
-		// Priority: pushName > senderUser > JID user
-		if pushName != "" && pushName != senderUser && pushName != jid.User {
-			name = pushName
-		} else if senderUser != "" {
-			name = senderUser
-		} else {
-			name = jid.User
-		}
+	if err != nil {
+		existingChat = nil
 	}
-
-	return name
+	return chatNameWithPushName(existingChat, jid, senderUser, pushName)
 }
 
 // GetChatNameWithPushNameByDevice determines the appropriate name for a chat with pushname support (device-scoped)
@@ -708,265 +687,308 @@ func (r *SQLiteRepository) GetChatNameWithPushNameByDevice(deviceID string, jid
 		return "Status"
 	}
 
-	// First, check if chat already exists with a name (device-scoped!)
 	existingChat, err := r.GetChatByDevice(deviceID, chatJID)
-	if err == nil && existingChat != nil && existingChat.Name != "" {
-		// If we have a pushname and the existing name is just a phone number/JID user, update it
-		if pushName != "" && (existingChat.Name == jid.User || existingChat.Name == senderUser) {
-			return pushName
-		}
-		return existingChat.Name
-	}
-
-	// Determine chat type and name
-	var name string
-
-	switch jid.Server {
-	case "g.us":
-		// This is a group chat
-		// For now, use a generic name - this can be enhanced later with group info
-		name = fmt.Sprintf("Group %s", jid.User)
-	case "newsletter":
-		// This is a newsletter/channel
-		name = fmt.Sprintf("Newsletter %s", jid.User)
-	default:
-		// This is an individual contact
-		// Priority: pushName > senderUser > JID user
-		if pushName != "" && pushName != senderUser && pushName != jid.User {
-			name = pushName
-		} else if senderUser != "" {
-			name = senderUser
-		} else {
-			name = jid.User
-		}
+	if err != nil {
+		existingChat = nil
 	}
-
-	return name
+	return chatNameWithPushName(existingChat, jid, senderUser, pushName)
 }
 
 func (r *SQLiteRepository) CreateMessage(ctx context.Context, evt *events.Message) error {
-	if evt == nil || evt.Message == nil {
-		return nil
-	}
+	return createMessage(ctx, r, evt)
+}
 
-	// Get WhatsApp client for LID resolution (device-scoped if present in context)
-	client := whatsapp.ClientFromContext(ctx)
-	deviceID := ""
-	if inst, ok := whatsapp.DeviceFromContext(ctx); ok && inst != nil {
-		deviceID = inst.JID()
-		if deviceID == "" {
-			deviceID = inst.ID()
-		}
-	}
-	if deviceID == "" && client != nil && client.Store != nil && client.Store.ID != nil {
-		deviceID = client.Store.ID.ToNonAD().String()
-	}
+// GetMessageAnalytics aggregates the messages selected by filter
+func (r *SQLiteRepository) GetMessageAnalytics(filter *domainChatStorage.AnalyticsFilter) (*domainChatStorage.MessageAnalytics, error) {
+	return getMessageAnalytics(r.db, sqliteAnalytics, filter)
+}
 
-	// Normalize chat and sender JIDs (convert @lid to @s.whatsapp.net)
-	normalizedChatJID := whatsapp.NormalizeJIDFromLID(ctx, evt.Info.Chat, client)
-	normalizedSender := whatsapp.NormalizeJIDFromLID(ctx, evt.Info.Sender, client)
+// GetStorageStatistics returns current storage statistics for logging purposes
+func (r *SQLiteRepository) GetStorageStatistics() (chatCount int64, messageCount int64, err error) {
+	return storageStatistics(r)
+}
 
-	chatJID := normalizedChatJID.String()
-	// Store the full sender JID (user@server) to ensure consistency between received and sent messages
-	sender := normalizedSender.ToNonAD().String()
+// TruncateAllDataWithLogging performs truncation with detailed logging
+func (r *SQLiteRepository) TruncateAllDataWithLogging(logPrefix string) error {
+	return truncateAllDataWithLogging(r, logPrefix)
+}
+
+// StoreSentMessageWithContext stores a message that was sent by the user with context cancellation support
+func (r *SQLiteRepository) StoreSentMessageWithContext(ctx context.Context, messageID string, senderJID string, recipientJID string, content string, timestamp time.Time) error {
+	return storeSentMessage(ctx, r, messageID, senderJID, recipientJID, content, timestamp)
+}
 
-	// Get appropriate chat name using pushname if available
-	chatName := r.GetChatNameWithPushName(normalizedChatJID, chatJID, normalizedSender.User, evt.Info.PushName)
+// sqlitePlaceholders returns n comma separated ? placeholders
+func sqlitePlaceholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
 
-	// Get existing chat to preserve ephemeral_expiration if needed
-	existingChat, err := r.GetChat(chatJID)
+// ApplyMessageEdit replaces a message's content and keeps the previous version in message_edits
+func (r *SQLiteRepository) ApplyMessageEdit(deviceID, chatJID, messageID, content string, editedAt time.Time) error {
+	tx, err := r.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to get existing chat: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	// Extract ephemeral expiration from incoming message
-	ephemeralExpiration := utils.ExtractEphemeralExpiration(evt.Message)
-
-	// Create or update chat
-	chat := &domainChatStorage.Chat{
-		DeviceID:        deviceID,
-		JID:             chatJID,
-		Name:            chatName,
-		LastMessageTime: evt.Info.Timestamp,
+	var previous sql.NullString
+	err = tx.QueryRow(
+		"SELECT content FROM messages WHERE id = ? AND chat_jid = ? AND device_id = ?",
+		messageID, chatJID, deviceID,
+	).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return nil
 	}
-
-	// Set ephemeral expiration: use incoming message value if > 0, otherwise preserve existing
-	if ephemeralExpiration > 0 {
-		chat.EphemeralExpiration = ephemeralExpiration
-	} else if existingChat != nil {
-		// Preserve existing ephemeral_expiration if incoming message doesn't have one
-		chat.EphemeralExpiration = existingChat.EphemeralExpiration
+	if err != nil {
+		return err
+	}
+	// The same edit can arrive more than once
+	previousContent, err := r.cipher.decryptString("content", previous.String)
+	if err != nil {
+		return err
+	}
+	if previousContent == content {
+		return nil
+	}
+	storedContent, err := r.cipher.encryptString("content", content)
+	if err != nil {
+		return err
 	}
 
-	// Store or update the chat
-	if err := r.StoreChat(chat); err != nil {
-		return fmt.Errorf("failed to store chat: %w", err)
+	if _, err := tx.Exec(`
+		INSERT INTO message_edits (message_id, chat_jid, device_id, content, edited_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, messageID, chatJID, deviceID, previous.String, editedAt); err != nil {
+		return fmt.Errorf("failed to store edit history: %w", err)
+	}
+	if _, err := tx.Exec(`
+		UPDATE messages SET content = ?, edited_at = ?, updated_at = ?
+		WHERE id = ? AND chat_jid = ? AND device_id = ?
+	`, storedContent, editedAt, time.Now(), messageID, chatJID, deviceID); err != nil {
+		return fmt.Errorf("failed to update message: %w", err)
 	}
 
-	// Extract message content and media info
-	content := utils.ExtractMessageTextFromProto(evt.Message)
-	mediaType, filename, url, mediaKey, fileSHA256, fileEncSHA256, fileLength := utils.ExtractMediaInfo(evt.Message)
+	return tx.Commit()
+}
 
-	// Skip if there's no content and no media
-	if content == "" && mediaType == "" {
-		logrus.Debugf("Skipping message %s - no content or media", evt.Info.ID)
-		return nil
-	}
+// MarkMessageRevoked flags a message as deleted for everyone, keeping its content
+func (r *SQLiteRepository) MarkMessageRevoked(deviceID, chatJID, messageID string, revokedAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE messages SET is_revoked = TRUE, revoked_at = ?, updated_at = ?
+		WHERE id = ? AND chat_jid = ? AND device_id = ? AND is_revoked = FALSE
+	`, revokedAt, time.Now(), messageID, chatJID, deviceID)
+	return err
+}
+
+// SetMessageStarred records whether a message is starred
+func (r *SQLiteRepository) SetMessageStarred(deviceID, chatJID, messageID string, starred bool) error {
+	_, err := r.db.Exec(`
+		UPDATE messages SET is_starred = ?, updated_at = ?
+		WHERE id = ? AND chat_jid = ? AND device_id = ?
+	`, starred, time.Now(), messageID, chatJID, deviceID)
+	return err
+}
 
-	// Create message object
-	message := &domainChatStorage.Message{
-		ID:            evt.Info.ID,
-		ChatJID:       chatJID,
-		DeviceID:      deviceID,
-		Sender:        sender,
-		Content:       content,
-		Timestamp:     evt.Info.Timestamp,
-		IsFromMe:      evt.Info.IsFromMe,
-		MediaType:     mediaType,
-		Filename:      filename,
-		URL:           url,
-		MediaKey:      mediaKey,
-		FileSHA256:    fileSHA256,
-		FileEncSHA256: fileEncSHA256,
-		FileLength:    fileLength,
-	}
-
-	// Store the message
-	return r.StoreMessage(message)
+// SetMessageMediaPath records where the media of a message was downloaded to
+func (r *SQLiteRepository) SetMessageMediaPath(deviceID, chatJID, messageID, mediaPath string) error {
+	_, err := r.db.Exec(`
+		UPDATE messages SET media_path = ?, updated_at = ?
+		WHERE id = ? AND chat_jid = ? AND device_id = ?
+	`, mediaPath, time.Now(), messageID, chatJID, deviceID)
+	return err
 }
 
-// GetStorageStatistics returns current storage statistics for logging purposes
-func (r *SQLiteRepository) GetStorageStatistics() (chatCount int64, messageCount int64, err error) {
-	// Count all chats using efficient query
-	chatCount, err = r.GetTotalChatCount()
-	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get chat count: %w", err)
+// StoreReaction sets or, when the reaction is empty, removes a sender's reaction
+func (r *SQLiteRepository) StoreReaction(reaction *domainChatStorage.MessageReaction) error {
+	if reaction.Reaction == "" {
+		_, err := r.db.Exec(`
+			DELETE FROM message_reactions
+			WHERE message_id = ? AND chat_jid = ? AND device_id = ? AND sender = ?
+		`, reaction.MessageID, reaction.ChatJID, reaction.DeviceID, reaction.Sender)
+		return err
 	}
 
-	// Count all messages
-	messageCount, err = r.GetTotalMessageCount()
+	// Try update first, then insert if no rows affected (cross-db compatible)
+	result, err := r.db.Exec(`
+		UPDATE message_reactions SET reaction = ?, timestamp = ?
+		WHERE message_id = ? AND chat_jid = ? AND device_id = ? AND sender = ?
+	`, reaction.Reaction, reaction.Timestamp, reaction.MessageID, reaction.ChatJID, reaction.DeviceID, reaction.Sender)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get message count: %w", err)
+		return err
 	}
 
-	return chatCount, messageCount, nil
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		_, err = r.db.Exec(`
+			INSERT INTO message_reactions (message_id, chat_jid, device_id, sender, reaction, timestamp)
+			SELECT ?, ?, ?, ?, ?, ?
+			WHERE EXISTS (SELECT 1 FROM messages WHERE id = ? AND chat_jid = ? AND device_id = ?)
+		`, reaction.MessageID, reaction.ChatJID, reaction.DeviceID, reaction.Sender, reaction.Reaction, reaction.Timestamp,
+			reaction.MessageID, reaction.ChatJID, reaction.DeviceID)
+	}
+	return err
 }
 
-// TruncateAllDataWithLogging performs truncation with detailed logging
-func (r *SQLiteRepository) TruncateAllDataWithLogging(logPrefix string) error {
-	// Get statistics before truncation
-	chatCount, messageCount, err := r.GetStorageStatistics()
-	if err != nil {
-		logrus.Warnf("[%s] Failed to get storage statistics before truncation: %v", logPrefix, err)
-	} else {
-		logrus.Infof("[%s] Storage before truncation: %d chats, %d messages", logPrefix, chatCount, messageCount)
+// UpdateMessageStatus moves messages forward to status, never back
+func (r *SQLiteRepository) UpdateMessageStatus(deviceID, chatJID string, messageIDs []string, status string) error {
+	previous := statusesBefore(status)
+	if previous == nil {
+		return fmt.Errorf("unknown message status %q", status)
 	}
-
-	// Perform truncation
-	if err := r.TruncateAllChats(); err != nil {
-		return fmt.Errorf("failed to truncate chatstorage data: %w", err)
+	if len(messageIDs) == 0 {
+		return nil
 	}
 
-	// Verify truncation
-	chatCountAfter, messageCountAfter, err := r.GetStorageStatistics()
-	if err != nil {
-		logrus.Warnf("[%s] Failed to get storage statistics after truncation: %v", logPrefix, err)
-	} else {
-		logrus.Infof("[%s] Storage after truncation: %d chats, %d messages", logPrefix, chatCountAfter, messageCountAfter)
-		if chatCountAfter == 0 && messageCountAfter == 0 {
-			logrus.Infof("[%s] ✅ Chatstorage truncation completed successfully", logPrefix)
-		} else {
-			logrus.Warnf("[%s] ⚠️ Truncation may not have completed fully", logPrefix)
-		}
+	args := []any{status, time.Now(), deviceID, chatJID}
+	for _, id := range messageIDs {
+		args = append(args, id)
+	}
+	for _, s := range previous {
+		args = append(args, s)
 	}
 
-	return nil
+	_, err := r.db.Exec(`
+		UPDATE messages SET status = ?, updated_at = ?
+		WHERE device_id = ? AND chat_jid = ? AND id IN (`+sqlitePlaceholders(len(messageIDs))+`)
+			AND COALESCE(status, '') IN (`+sqlitePlaceholders(len(previous))+`)
+	`, args...)
+	return err
 }
 
-// StoreSentMessageWithContext stores a message that was sent by the user with context cancellation support
-func (r *SQLiteRepository) StoreSentMessageWithContext(ctx context.Context, messageID string, senderJID string, recipientJID string, content string, timestamp time.Time) error {
-	// Check if context is already cancelled before starting
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
+// GetMessageReactions returns the reactions to the given messages, oldest first
+func (r *SQLiteRepository) GetMessageReactions(deviceID, chatJID string, messageIDs []string) ([]*domainChatStorage.MessageReaction, error) {
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
+
+	args := []any{deviceID, chatJID}
+	for _, id := range messageIDs {
+		args = append(args, id)
 	}
 
-	// Ensure JID is properly formatted
-	jid, err := types.ParseJID(recipientJID)
+	rows, err := r.db.Query(`
+		SELECT message_id, chat_jid, device_id, sender, reaction, timestamp
+		FROM message_reactions
+		WHERE device_id = ? AND chat_jid = ? AND message_id IN (`+sqlitePlaceholders(len(messageIDs))+`)
+		ORDER BY timestamp ASC
+	`, args...)
 	if err != nil {
-		return fmt.Errorf("invalid JID format: %w", err)
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Get WhatsApp client for LID resolution (device-scoped if present in context)
-	client := whatsapp.ClientFromContext(ctx)
-	deviceID := ""
-	if inst, ok := whatsapp.DeviceFromContext(ctx); ok && inst != nil {
-		deviceID = inst.JID()
-		if deviceID == "" {
-			deviceID = inst.ID()
+	var reactions []*domainChatStorage.MessageReaction
+	for rows.Next() {
+		reaction := &domainChatStorage.MessageReaction{}
+		if err := rows.Scan(&reaction.MessageID, &reaction.ChatJID, &reaction.DeviceID, &reaction.Sender, &reaction.Reaction, &reaction.Timestamp); err != nil {
+			return nil, err
 		}
-	}
-	if deviceID == "" && client != nil && client.Store != nil && client.Store.ID != nil {
-		deviceID = client.Store.ID.ToNonAD().String()
+		reactions = append(reactions, reaction)
 	}
 
-	// Normalize recipient JID (convert @lid to @s.whatsapp.net)
-	normalizedJID := whatsapp.NormalizeJIDFromLID(ctx, jid, client)
-	chatJID := normalizedJID.String()
+	return reactions, rows.Err()
+}
 
-	// Get chat name (no pushname available for sent messages)
-	chatName := r.GetChatNameWithPushName(normalizedJID, chatJID, normalizedJID.User, "")
+// GetMessageEdits returns the edit history of the given messages, oldest first
+func (r *SQLiteRepository) GetMessageEdits(deviceID, chatJID string, messageIDs []string) ([]*domainChatStorage.MessageEdit, error) {
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
 
-	// Check context again before database operations
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
+	args := []any{deviceID, chatJID}
+	for _, id := range messageIDs {
+		args = append(args, id)
 	}
 
-	// Get existing chat to preserve ephemeral_expiration
-	existingChat, err := r.GetChat(chatJID)
+	rows, err := r.db.Query(`
+		SELECT message_id, chat_jid, device_id, COALESCE(content, ''), edited_at
+		FROM message_edits
+		WHERE device_id = ? AND chat_jid = ? AND message_id IN (`+sqlitePlaceholders(len(messageIDs))+`)
+		ORDER BY edited_at ASC, id ASC
+	`, args...)
 	if err != nil {
-		return fmt.Errorf("failed to get existing chat: %w", err)
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Store or update chat, preserving existing ephemeral_expiration
-	chat := &domainChatStorage.Chat{
-		DeviceID:        deviceID,
-		JID:             chatJID,
-		Name:            chatName,
-		LastMessageTime: timestamp,
+	var edits []*domainChatStorage.MessageEdit
+	for rows.Next() {
+		edit := &domainChatStorage.MessageEdit{}
+		if err := rows.Scan(&edit.MessageID, &edit.ChatJID, &edit.DeviceID, &edit.Content, &edit.EditedAt); err != nil {
+			return nil, err
+		}
+		if edit.Content, err = r.cipher.decryptString("content", edit.Content); err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit)
 	}
 
-	// Preserve existing ephemeral_expiration if chat exists
-	if existingChat != nil {
-		chat.EphemeralExpiration = existingChat.EphemeralExpiration
-	}
+	return edits, rows.Err()
+}
 
-	if err := r.StoreChat(chat); err != nil {
-		return fmt.Errorf("failed to store chat: %w", err)
-	}
+// IterateMessages streams the messages matched by filter to fn, oldest first
+func (r *SQLiteRepository) IterateMessages(filter *domainChatStorage.MessageFilter, fn func(*domainChatStorage.Message) error) error {
+	return iterateMessages(r.db, sqliteBind, r.cipher, filter, fn)
+}
 
-	// Check context one more time before storing message
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
-	}
+// ListMessageDeviceIDs returns every device that has stored messages
+func (r *SQLiteRepository) ListMessageDeviceIDs() ([]string, error) {
+	return listMessageDeviceIDs(r.db)
+}
 
-	// Store the sent message
-	message := &domainChatStorage.Message{
-		ID:        messageID,
-		ChatJID:   chatJID,
-		DeviceID:  deviceID,
-		Sender:    senderJID,
-		Content:   content,
-		Timestamp: timestamp,
-		IsFromMe:  true,
-	}
+// PreviewPrune counts the messages PruneMessages would delete for policy
+func (r *SQLiteRepository) PreviewPrune(policy domainChatStorage.RetentionPolicy) (*domainChatStorage.PrunePreview, error) {
+	return previewPrune(r.db, sqliteBind, policy)
+}
 
-	return r.StoreMessage(message)
+// PruneMessages deletes at most limit messages selected by policy
+func (r *SQLiteRepository) PruneMessages(policy domainChatStorage.RetentionPolicy, limit int) (int64, error) {
+	return pruneMessages(r.db, sqliteBind, policy, limit)
+}
+
+// UpdateChatMetadata applies a chat list state change to a stored chat
+func (r *SQLiteRepository) UpdateChatMetadata(deviceID, chatJID string, update domainChatStorage.ChatMetadataUpdate) error {
+	return updateChatMetadata(r.db, sqliteBind, deviceID, chatJID, update)
+}
+
+// StoreContact merges a contact into the stored one
+func (r *SQLiteRepository) StoreContact(contact *domainChatStorage.Contact) error {
+	return storeContact(r.db, sqliteBind, contact)
+}
+
+// SetGroupParticipants replaces the stored members of a group
+func (r *SQLiteRepository) SetGroupParticipants(deviceID, groupJID string, participants []*domainChatStorage.GroupParticipant) error {
+	return setGroupParticipants(r.db, sqliteBind, deviceID, groupJID, participants)
+}
+
+// ApplyGroupParticipantChange updates the stored members of a group
+func (r *SQLiteRepository) ApplyGroupParticipantChange(deviceID, groupJID string, change domainChatStorage.GroupParticipantChange) error {
+	return applyGroupParticipantChange(r.db, sqliteBind, deviceID, groupJID, change)
+}
+
+// GetGroupParticipants returns the stored members of a group
+func (r *SQLiteRepository) GetGroupParticipants(deviceID, groupJID string) ([]*domainChatStorage.GroupParticipant, error) {
+	return getGroupParticipants(r.db, sqliteBind, deviceID, groupJID)
+}
+
+// ReencryptMessages rewrites encrypted columns to the current encryption key
+func (r *SQLiteRepository) ReencryptMessages(batchSize int) (int64, error) {
+	return reencryptMessages(r.db, sqliteBind, r.cipher, batchSize)
+}
+
+// GetMessageChanges returns the messages and deletions after filter.Since
+func (r *SQLiteRepository) GetMessageChanges(filter *domainChatStorage.MessageChangeFilter) (*domainChatStorage.MessageChanges, error) {
+	return getMessageChanges(r.db, sqliteBind, r.cipher, filter)
+}
+
+// PruneMessageDeletions forgets deletions recorded before olderThan
+func (r *SQLiteRepository) PruneMessageDeletions(olderThan time.Time) (int64, error) {
+	return pruneMessageDeletions(r.db, sqliteBind, olderThan)
+}
+
+// sqliteBind leaves ? placeholders as they are
+func sqliteBind(query string) string {
+	return query
 }
 
 // _____________________________________________________________________________________________________________________
@@ -987,6 +1009,10 @@ func (r *SQLiteRepository) InitializeSchema() error {
 		}
 	}
 
+	if err := r.ensureSearchIndex(); err != nil {
+		logrus.Warnf("Chat storage: full-text search index unavailable, falling back to LIKE: %v", err)
+	}
+
 	return nil
 }
 
@@ -1101,5 +1127,122 @@ func (r *SQLiteRepository) getMigrations() []string {
 
 		// Migration 12: Create index for devices
 		`CREATE INDEX IF NOT EXISTS idx_devices_created_at ON devices(created_at)`,
+
+		// Migration 13: Message lifecycle - edits, revokes and delivery status
+		`ALTER TABLE messages ADD COLUMN edited_at TIMESTAMP`,
+
+		// Migration 14
+		`ALTER TABLE messages ADD COLUMN is_revoked BOOLEAN DEFAULT FALSE`,
+
+		// Migration 15
+		`ALTER TABLE messages ADD COLUMN revoked_at TIMESTAMP`,
+
+		// Migration 16
+		`ALTER TABLE messages ADD COLUMN status VARCHAR(20) DEFAULT ''`,
+
+		// Migration 17: One current reaction per sender and message
+		`CREATE TABLE IF NOT EXISTS message_reactions (
+			message_id VARCHAR(255) NOT NULL,
+			chat_jid VARCHAR(255) NOT NULL,
+			device_id VARCHAR(255) NOT NULL DEFAULT '',
+			sender VARCHAR(255) NOT NULL,
+			reaction VARCHAR(32) NOT NULL,
+			timestamp TIMESTAMP NOT NULL,
+			PRIMARY KEY (message_id, chat_jid, device_id, sender)
+		)`,
+
+		// Migration 18: Previous versions of edited messages
+		`CREATE TABLE IF NOT EXISTS message_edits (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			message_id VARCHAR(255) NOT NULL,
+			chat_jid VARCHAR(255) NOT NULL,
+			device_id VARCHAR(255) NOT NULL DEFAULT '',
+			content TEXT,
+			edited_at TIMESTAMP NOT NULL
+		)`,
+
+		// Migration 19
+		`CREATE INDEX IF NOT EXISTS idx_message_edits_message ON message_edits(device_id, chat_jid, message_id)`,
+
+		// Migration 20: Reactions and edit history go with their message
+		`CREATE TRIGGER IF NOT EXISTS messages_children_ad AFTER DELETE ON messages BEGIN
+			DELETE FROM message_reactions
+			WHERE message_id = old.id AND chat_jid = old.chat_jid AND device_id = old.device_id;
+			DELETE FROM message_edits
+			WHERE message_id = old.id AND chat_jid = old.chat_jid AND device_id = old.device_id;
+		END`,
+
+		// Migration 21: Starred messages are exempt from retention pruning
+		`ALTER TABLE messages ADD COLUMN is_starred BOOLEAN DEFAULT FALSE`,
+
+		// Migration 22
+		`CREATE INDEX IF NOT EXISTS idx_messages_device_timestamp ON messages(device_id, timestamp)`,
+
+		// Migration 23: Where downloaded media was saved
+		`ALTER TABLE messages ADD COLUMN media_path TEXT DEFAULT ''`,
+
+		// Migration 24: Deleted messages for the sync change feed
+		`CREATE TABLE IF NOT EXISTS message_deletions (
+			seq INTEGER PRIMARY KEY AUTOINCREMENT,
+			message_id VARCHAR(255) NOT NULL,
+			chat_jid VARCHAR(255) NOT NULL,
+			device_id VARCHAR(255) NOT NULL DEFAULT '',
+			deleted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Migration 25
+		`CREATE INDEX IF NOT EXISTS idx_message_deletions_device ON message_deletions(device_id, seq)`,
+
+		// Migration 26
+		`CREATE TRIGGER IF NOT EXISTS messages_deletions_ad AFTER DELETE ON messages BEGIN
+			INSERT INTO message_deletions (message_id, chat_jid, device_id)
+			VALUES (old.id, old.chat_jid, old.device_id);
+		END`,
+
+		// Migration 27: Change feed order
+		`CREATE INDEX IF NOT EXISTS idx_messages_device_updated ON messages(device_id, updated_at)`,
+
+		// Migration 28: Chat list state synced from the phone
+		`ALTER TABLE chats ADD COLUMN unread_count INTEGER DEFAULT 0`,
+
+		// Migration 29
+		`ALTER TABLE chats ADD COLUMN marked_unread BOOLEAN DEFAULT FALSE`,
+
+		// Migration 30
+		`ALTER TABLE chats ADD COLUMN archived BOOLEAN DEFAULT FALSE`,
+
+		// Migration 31
+		`ALTER TABLE chats ADD COLUMN pinned BOOLEAN DEFAULT FALSE`,
+
+		// Migration 32
+		`ALTER TABLE chats ADD COLUMN muted_until TIMESTAMP`,
+
+		// Migration 33: Contact names from the address book and push names
+		`CREATE TABLE IF NOT EXISTS contacts (
+			device_id VARCHAR(255) NOT NULL DEFAULT '',
+			jid VARCHAR(255) NOT NULL,
+			full_name VARCHAR(255) DEFAULT '',
+			first_name VARCHAR(255) DEFAULT '',
+			push_name VARCHAR(255) DEFAULT '',
+			business_name VARCHAR(255) DEFAULT '',
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (device_id, jid)
+		)`,
+
+		// Migration 34: Members of group chats
+		`CREATE TABLE IF NOT EXISTS group_participants (
+			device_id VARCHAR(255) NOT NULL DEFAULT '',
+			group_jid VARCHAR(255) NOT NULL,
+			jid VARCHAR(255) NOT NULL,
+			is_admin BOOLEAN DEFAULT FALSE,
+			is_super_admin BOOLEAN DEFAULT FALSE,
+			PRIMARY KEY (device_id, group_jid, jid)
+		)`,
+
+		// Migration 35: Media details needed to send a message again
+		`ALTER TABLE messages ADD COLUMN mimetype TEXT DEFAULT ''`,
+
+		// Migration 36
+		`ALTER TABLE messages ADD COLUMN is_ptt BOOLEAN DEFAULT FALSE`,
 	}
 }
@@ -0,0 +1,86 @@
+package chatstorage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+)
+
+// Change feed queries are shared by both backends. They are written with ?
+// placeholders and passed through the backend's bind function. Deleted
+// messages are recorded in message_deletions by a trigger on messages.
+
+// getMessageChanges returns the messages and deletions after filter.Since
+func getMessageChanges(db *sql.DB, bind func(string) string, c *FieldCipher, filter *domainChatStorage.MessageChangeFilter) (*domainChatStorage.MessageChanges, error) {
+	if filter.DeviceID == "" {
+		return nil, fmt.Errorf("device_id is required for message changes (data isolation)")
+	}
+
+	changes := &domainChatStorage.MessageChanges{}
+
+	query := `SELECT ` + messageColumns + ` FROM messages m WHERE m.device_id = ? AND m.updated_at <= ?`
+	args := []any{filter.DeviceID, filter.Until}
+	if filter.ChatJID != "" {
+		query += " AND m.chat_jid = ?"
+		args = append(args, filter.ChatJID)
+	}
+	if since := filter.Since; !since.UpdatedAt.IsZero() {
+		query += " AND (m.updated_at, m.chat_jid, m.id) > (?, ?, ?)"
+		args = append(args, since.UpdatedAt, since.ChatJID, since.ID)
+	}
+	query += " ORDER BY m.updated_at, m.chat_jid, m.id LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := db.Query(bind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		message, err := scanMessageRow(c, rows)
+		if err != nil {
+			return nil, err
+		}
+		changes.Messages = append(changes.Messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query = `SELECT seq, device_id, chat_jid, message_id, deleted_at FROM message_deletions WHERE device_id = ? AND seq > ?`
+	args = []any{filter.DeviceID, filter.Since.DeletionSeq}
+	if filter.ChatJID != "" {
+		query += " AND chat_jid = ?"
+		args = append(args, filter.ChatJID)
+	}
+	query += " ORDER BY seq LIMIT ?"
+	args = append(args, filter.Limit)
+
+	deletions, err := db.Query(bind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer deletions.Close()
+
+	for deletions.Next() {
+		deletion := &domainChatStorage.MessageDeletion{}
+		if err := deletions.Scan(&deletion.Seq, &deletion.DeviceID, &deletion.ChatJID, &deletion.MessageID, &deletion.DeletedAt); err != nil {
+			return nil, err
+		}
+		changes.Deletions = append(changes.Deletions, deletion)
+	}
+	return changes, deletions.Err()
+}
+
+// pruneMessageDeletions forgets deletions recorded before olderThan. The
+// trigger stamps deleted_at in UTC, so compare in UTC as well.
+func pruneMessageDeletions(db *sql.DB, bind func(string) string, olderThan time.Time) (int64, error) {
+	result, err := db.Exec(bind("DELETE FROM message_deletions WHERE deleted_at < ?"), olderThan.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
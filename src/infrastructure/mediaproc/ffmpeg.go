@@ -0,0 +1,262 @@
+package mediaproc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	domainMediaProc "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediaproc"
+	"github.com/sirupsen/logrus"
+)
+
+// FFmpegProcessor runs ffmpeg and ffprobe for what the pure-Go processor
+// cannot do, such as video frames, transcoding and lossy WebP, and falls back
+// to it when they fail or are missing
+type FFmpegProcessor struct {
+	native  *NativeProcessor
+	ffmpeg  string
+	ffprobe string
+	tempDir string
+}
+
+// NewFFmpegProcessor runs the given ffmpeg and ffprobe binaries, either of
+// which may be empty, with input and output files in tempDir
+func NewFFmpegProcessor(ffmpeg, ffprobe, tempDir string) *FFmpegProcessor {
+	return &FFmpegProcessor{native: NewNativeProcessor(), ffmpeg: ffmpeg, ffprobe: ffprobe, tempDir: tempDir}
+}
+
+func (p *FFmpegProcessor) Capabilities() domainMediaProc.Capabilities {
+	capabilities := p.native.Capabilities()
+	capabilities.Backend = domainMediaProc.BackendFFmpeg
+	capabilities.FFmpeg = p.ffmpeg
+	capabilities.FFprobe = p.ffprobe
+	for i, capability := range capabilities.Operations {
+		if capability.Operation == domainMediaProc.OperationProbe && p.ffprobe == "" || capability.Operation != domainMediaProc.OperationProbe && p.ffmpeg == "" {
+			continue
+		}
+		capabilities.Operations[i] = domainMediaProc.Capability{Operation: capability.Operation, Available: true, Backend: domainMediaProc.BackendFFmpeg}
+	}
+	return capabilities
+}
+
+// Probe reads the formats the pure-Go processor knows itself and asks ffprobe
+// about the others
+func (p *FFmpegProcessor) Probe(ctx context.Context, data []byte) (*domainMediaProc.MediaInfo, error) {
+	info, err := p.native.Probe(ctx, data)
+	if err == nil || p.ffprobe == "" {
+		return info, err
+	}
+
+	input, cleanup, err := p.tempFile(data)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	output, err := p.run(ctx, p.ffprobe, "-v", "error", "-print_format", "json", "-show_format", "-show_streams", input)
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			CodecName string `json:"codec_name"`
+			Width     int    `json:"width"`
+			Height    int    `json:"height"`
+		} `json:"streams"`
+		Format struct {
+			FormatName string `json:"format_name"`
+			Duration   string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	info = &domainMediaProc.MediaInfo{Format: strings.Split(probe.Format.FormatName, ",")[0]}
+	if seconds, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
+		info.Duration = time.Duration(seconds * float64(time.Second))
+	}
+	for _, stream := range probe.Streams {
+		if info.Codec == "" {
+			info.Codec = stream.CodecName
+		}
+		if stream.CodecType == "video" && info.Width == 0 {
+			info.Codec, info.Width, info.Height = stream.CodecName, stream.Width, stream.Height
+		}
+	}
+	return info, nil
+}
+
+// Thumbnail scales images itself and grabs the frame one second into videos,
+// or the first frame of shorter ones
+func (p *FFmpegProcessor) Thumbnail(ctx context.Context, data []byte, width int) ([]byte, error) {
+	thumbnail, err := p.native.Thumbnail(ctx, data, width)
+	if !errors.Is(err, domainMediaProc.ErrUnsupported) || p.ffmpeg == "" {
+		return thumbnail, err
+	}
+
+	input, cleanup, err := p.tempFile(data)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	frame, err := p.run(ctx, p.ffmpeg, "-v", "error", "-ss", "1", "-i", input, "-frames:v", "1", "-f", "image2pipe", "-vcodec", "png", "pipe:1")
+	if err == nil && len(frame) == 0 {
+		frame, err = p.run(ctx, p.ffmpeg, "-v", "error", "-i", input, "-frames:v", "1", "-f", "image2pipe", "-vcodec", "png", "pipe:1")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create thumbnail: %w", err)
+	}
+	if len(frame) == 0 {
+		return nil, fmt.Errorf("failed to create thumbnail: no video frame")
+	}
+	return p.native.Thumbnail(ctx, frame, width)
+}
+
+func (p *FFmpegProcessor) TranscodeOpus(ctx context.Context, data []byte) ([]byte, error) {
+	opus, err := p.native.TranscodeOpus(ctx, data)
+	if err == nil || p.ffmpeg == "" {
+		return opus, err
+	}
+
+	input, cleanup, err := p.tempFile(data)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	// Mono 48kHz Opus tuned for speech, as WhatsApp records voice notes
+	opus, err = p.run(ctx, p.ffmpeg, "-v", "error", "-i", input, "-vn", "-map_metadata", "-1",
+		"-ac", "1", "-ar", "48000", "-c:a", "libopus", "-b:a", "32k", "-application", "voip", "-f", "ogg", "pipe:1")
+	if err != nil {
+		return nil, fmt.Errorf("failed to transcode audio to opus: %w", err)
+	}
+	return opus, nil
+}
+
+// Waveform decodes the audio to 8kHz mono samples, and falls back to the
+// pure-Go processor when ffmpeg fails
+func (p *FFmpegProcessor) Waveform(ctx context.Context, data []byte, points int) ([]byte, error) {
+	if p.ffmpeg == "" {
+		return p.native.Waveform(ctx, data, points)
+	}
+
+	input, cleanup, err := p.tempFile(data)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	samples, err := p.run(ctx, p.ffmpeg, "-v", "error", "-i", input, "-ac", "1", "-ar", "8000", "-f", "s8", "-acodec", "pcm_s8", "pipe:1")
+	if err != nil || len(samples) == 0 {
+		logrus.Debugf("ffmpeg could not decode audio for the waveform (%v), trying without it", err)
+		return p.native.Waveform(ctx, data, points)
+	}
+	return waveform(len(samples), func(i int) float64 {
+		return float64(int8(samples[i])) / 128
+	}, points), nil
+}
+
+// WebP encodes stickers lossy at quality 60 with libwebp, and falls back to the
+// lossless pure-Go encoder when ffmpeg was built without it
+func (p *FFmpegProcessor) WebP(ctx context.Context, data []byte, options domainMediaProc.WebPOptions) (*domainMediaProc.WebPResult, error) {
+	if p.ffmpeg == "" {
+		return p.native.WebP(ctx, data, options)
+	}
+	srcImage, err := decodeStickerImage(data, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, srcImage); err != nil {
+		return nil, err
+	}
+	input, cleanup, err := p.tempFile(pngData.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	output := input + ".webp"
+	defer os.Remove(output)
+
+	_, err = p.run(ctx, p.ffmpeg, "-v", "error", "-y", "-i", input, "-vcodec", "libwebp", "-lossless", "0", "-compression_level", "6",
+		"-q:v", "60", "-preset", "default", "-loop", "0", "-an", "-vsync", "0", output)
+	if err == nil {
+		var encoded []byte
+		if encoded, err = os.ReadFile(output); err == nil {
+			bounds := srcImage.Bounds()
+			return &domainMediaProc.WebPResult{Data: encoded, Width: bounds.Dx(), Height: bounds.Dy()}, nil
+		}
+	}
+	logrus.Warnf("ffmpeg could not convert the sticker to WebP (%v), using the built-in encoder", err)
+	return p.native.WebP(ctx, data, options)
+}
+
+// CompressVideo re-encodes to H.264 with AAC audio at most 720 pixels wide
+func (p *FFmpegProcessor) CompressVideo(ctx context.Context, data []byte) ([]byte, error) {
+	if p.ffmpeg == "" {
+		return nil, domainMediaProc.ErrUnsupported
+	}
+	input, cleanup, err := p.tempFile(data)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	output := input + ".mp4"
+	defer os.Remove(output)
+
+	// -crf 28: Constant Rate Factor (18-28 is good range, higher = smaller file)
+	// -movflags +faststart: Optimize for web streaming
+	// -vf scale=720:-2: Scale video to max width 720px, maintain aspect ratio
+	if _, err := p.run(ctx, p.ffmpeg, "-v", "error", "-y", "-i", input,
+		"-c:v", "libx264", "-crf", "28", "-preset", "fast", "-vf", "scale=720:-2",
+		"-c:a", "aac", "-b:a", "128k", "-movflags", "+faststart", output); err != nil {
+		return nil, fmt.Errorf("failed to compress video: %w", err)
+	}
+	return os.ReadFile(output)
+}
+
+// run executes a tool and returns its standard output, or an error carrying
+// the last line it printed
+func (p *FFmpegProcessor) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		return nil, fmt.Errorf("%s: %w: %s", filepath.Base(name), err, lines[len(lines)-1])
+	}
+	return output, nil
+}
+
+// tempFile writes data where the tools can read it, as several formats need a
+// seekable input
+func (p *FFmpegProcessor) tempFile(data []byte) (path string, cleanup func(), err error) {
+	file, err := os.CreateTemp(p.tempDir, "mediaproc-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { _ = os.Remove(file.Name()) }
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return file.Name(), cleanup, nil
+}
@@ -0,0 +1,29 @@
+package mediaproc
+
+import (
+	"fmt"
+	"os/exec"
+
+	domainMediaProc "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediaproc"
+)
+
+// New returns the processor of a backend. "auto" runs ffmpeg when it is
+// installed and pure Go otherwise, "ffmpeg" requires it and "native" never
+// runs it. Temporary files go to tempDir.
+func New(backend, tempDir string) (domainMediaProc.IMediaProcessor, error) {
+	switch backend {
+	case domainMediaProc.BackendNative:
+		return NewNativeProcessor(), nil
+	case domainMediaProc.BackendAuto, domainMediaProc.BackendFFmpeg:
+		ffmpeg, _ := exec.LookPath("ffmpeg")
+		ffprobe, _ := exec.LookPath("ffprobe")
+		if ffmpeg != "" || ffprobe != "" {
+			return NewFFmpegProcessor(ffmpeg, ffprobe, tempDir), nil
+		}
+		if backend == domainMediaProc.BackendFFmpeg {
+			return nil, fmt.Errorf("ffmpeg and ffprobe were not found in PATH")
+		}
+		return NewNativeProcessor(), nil
+	}
+	return nil, fmt.Errorf("unknown media processor %q, use auto, ffmpeg or native", backend)
+}
@@ -0,0 +1,180 @@
+package mediaproc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"math"
+
+	domainMediaProc "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediaproc"
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp" // Register WebP format
+)
+
+var (
+	nativeImageFormats = []string{"jpeg", "png", "gif", "webp"}
+	nativeProbeFormats = []string{"jpeg", "png", "gif", "webp", "wav", "ogg", "mp4"}
+	nativeAudioFormats = []string{"wav", "ogg"}
+)
+
+// NativeProcessor processes media in pure Go. It reads images, WAV files, Ogg
+// Opus and Vorbis streams and MP4 headers; it cannot decode compressed audio
+// or video, so video thumbnails, transcoding and compression are unsupported.
+type NativeProcessor struct{}
+
+func NewNativeProcessor() *NativeProcessor {
+	return &NativeProcessor{}
+}
+
+func (p *NativeProcessor) Capabilities() domainMediaProc.Capabilities {
+	return domainMediaProc.Capabilities{
+		Backend: domainMediaProc.BackendNative,
+		Operations: []domainMediaProc.Capability{
+			{Operation: domainMediaProc.OperationProbe, Available: true, Backend: domainMediaProc.BackendNative, Formats: nativeProbeFormats},
+			{Operation: domainMediaProc.OperationThumbnail, Available: true, Backend: domainMediaProc.BackendNative, Formats: nativeImageFormats},
+			{Operation: domainMediaProc.OperationTranscodeOpus, Available: true, Backend: domainMediaProc.BackendNative, Formats: []string{"opus"}},
+			{Operation: domainMediaProc.OperationWaveform, Available: true, Backend: domainMediaProc.BackendNative, Formats: nativeAudioFormats},
+			{Operation: domainMediaProc.OperationWebP, Available: true, Backend: domainMediaProc.BackendNative, Formats: nativeImageFormats},
+			{Operation: domainMediaProc.OperationCompressVideo},
+		},
+	}
+}
+
+func (p *NativeProcessor) Probe(_ context.Context, data []byte) (*domainMediaProc.MediaInfo, error) {
+	return probeData(data)
+}
+
+// Thumbnail scales images; videos need ffmpeg
+func (p *NativeProcessor) Thumbnail(_ context.Context, data []byte, width int) ([]byte, error) {
+	srcImage, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, domainMediaProc.ErrUnsupported
+	}
+	var thumbnail bytes.Buffer
+	if err = imaging.Encode(&thumbnail, imaging.Resize(srcImage, width, 0, imaging.Lanczos), imaging.JPEG); err != nil {
+		return nil, err
+	}
+	return thumbnail.Bytes(), nil
+}
+
+// TranscodeOpus passes Ogg Opus through; anything else needs ffmpeg
+func (p *NativeProcessor) TranscodeOpus(_ context.Context, data []byte) ([]byte, error) {
+	if stream, err := parseOgg(data); err == nil && stream.codec == "opus" {
+		return data, nil
+	}
+	return nil, domainMediaProc.ErrUnsupported
+}
+
+// Waveform measures the samples of WAV files. Ogg streams are not decoded: the
+// size of their packets, which grows with loudness, stands in for amplitude.
+func (p *NativeProcessor) Waveform(_ context.Context, data []byte, points int) ([]byte, error) {
+	if isRIFF(data, "WAVE") {
+		wav, err := parseWAV(data)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := wav.sample(0); !ok || wav.frames() == 0 {
+			return nil, domainMediaProc.ErrUnsupported
+		}
+		return waveform(wav.frames(), func(i int) float64 {
+			sample, _ := wav.sample(i)
+			return sample
+		}, points), nil
+	}
+
+	stream, err := parseOgg(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(stream.packets) == 0 {
+		return nil, fmt.Errorf("ogg stream has no audio packets")
+	}
+	smallest := stream.packets[0]
+	for _, size := range stream.packets {
+		smallest = min(smallest, size)
+	}
+	return waveform(len(stream.packets), func(i int) float64 {
+		return float64(stream.packets[i] - smallest)
+	}, points), nil
+}
+
+// WebP scales the image to fit the options and encodes it losslessly, dropping
+// color precision step by step while the file is over the size limit
+func (p *NativeProcessor) WebP(ctx context.Context, data []byte, options domainMediaProc.WebPOptions) (*domainMediaProc.WebPResult, error) {
+	srcImage, err := decodeStickerImage(data, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var encoded []byte
+	for dropBits := uint(0); dropBits <= 4; dropBits++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		encoded = encodeWebP(srcImage, dropBits)
+		if options.MaxBytes <= 0 || len(encoded) <= options.MaxBytes {
+			break
+		}
+	}
+	bounds := srcImage.Bounds()
+	return &domainMediaProc.WebPResult{Data: encoded, Width: bounds.Dx(), Height: bounds.Dy()}, nil
+}
+
+func (p *NativeProcessor) CompressVideo(context.Context, []byte) ([]byte, error) {
+	return nil, domainMediaProc.ErrUnsupported
+}
+
+// decodeStickerImage decodes an image and scales it down to fit the options.
+// Animated images become a still of their first frame.
+func decodeStickerImage(data []byte, options domainMediaProc.WebPOptions) (*image.NRGBA, error) {
+	var srcImage image.Image
+	if info, err := probeData(data); err == nil && info.Animated {
+		srcImage, err = webpFirstFrame(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the first frame of the animated image: %w", err)
+		}
+	} else {
+		srcImage, err = imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode image: %w", err)
+		}
+	}
+	size := options.MaxDimension
+	if size <= 0 || size > vp8lMaxDimension {
+		size = vp8lMaxDimension
+	}
+	return imaging.Fit(srcImage, size, size, imaging.Lanczos), nil
+}
+
+// waveform reduces n samples to points amplitudes from 0 to 100 for the voice
+// note UI: the RMS of each stretch relative to the loudest one, on a curve
+// that keeps quiet parts visible
+func waveform(n int, sample func(i int) float64, points int) []byte {
+	result := make([]byte, points)
+	perPoint := max(n/points, 1)
+
+	rmsValues := make([]float64, points)
+	maxRMS := 0.0
+	for i := range rmsValues {
+		start, end := i*perPoint, min((i+1)*perPoint, n)
+		if start >= end {
+			continue
+		}
+		var sumSquares float64
+		for j := start; j < end; j++ {
+			amplitude := sample(j)
+			sumSquares += amplitude * amplitude
+		}
+		rmsValues[i] = math.Sqrt(sumSquares / float64(end-start))
+		maxRMS = max(maxRMS, rmsValues[i])
+	}
+	if maxRMS == 0 {
+		maxRMS = 1
+	}
+
+	for i, rms := range rmsValues {
+		result[i] = byte(math.Pow(rms/maxRMS, 0.7) * 100)
+	}
+	return result
+}
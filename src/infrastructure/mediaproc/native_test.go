@@ -0,0 +1,301 @@
+package mediaproc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"math"
+	"testing"
+	"time"
+
+	domainMediaProc "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediaproc"
+	"golang.org/x/image/webp"
+)
+
+// wavFixture is one second of 16-bit mono PCM at 8kHz that is silent for the
+// first half and loud for the second
+func wavFixture() []byte {
+	samples := make([]byte, 2*8000)
+	for i := 4000; i < 8000; i++ {
+		binary.LittleEndian.PutUint16(samples[2*i:], uint16(int16(20000*math.Sin(float64(i)/5))))
+	}
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(36+len(samples)))
+	buf.WriteString("WAVEfmt ")
+	for _, field := range []any{uint32(16), uint16(1), uint16(1), uint32(8000), uint32(16000), uint16(2), uint16(16)} {
+		_ = binary.Write(&buf, binary.LittleEndian, field)
+	}
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(samples)))
+	buf.Write(samples)
+	return buf.Bytes()
+}
+
+// oggFixture is an Opus stream with a pre-skip of 312 samples whose last page
+// ends at granule position 48312, one second in
+func oggFixture() []byte {
+	head := append([]byte("OpusHead\x01\x01"), 0x38, 0x01, 0x80, 0xbb, 0, 0, 0, 0, 0)
+	var buf bytes.Buffer
+	page := func(granule uint64, packets ...[]byte) {
+		var table, body []byte
+		for _, packet := range packets {
+			size := len(packet)
+			for ; size >= 255; size -= 255 {
+				table = append(table, 255)
+			}
+			table = append(table, byte(size))
+			body = append(body, packet...)
+		}
+		header := make([]byte, 27)
+		copy(header, "OggS")
+		binary.LittleEndian.PutUint64(header[6:], granule)
+		binary.LittleEndian.PutUint32(header[14:], 7)
+		header[26] = byte(len(table))
+		buf.Write(header)
+		buf.Write(table)
+		buf.Write(body)
+	}
+	page(0, head)
+	page(0, []byte("OpusTags"))
+	page(24312, make([]byte, 3), make([]byte, 3), make([]byte, 300))
+	page(48312, make([]byte, 300), make([]byte, 3))
+	return buf.Bytes()
+}
+
+func mp4Fixture() []byte {
+	box := func(kind string, body []byte) []byte {
+		out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+		return append(append(out, kind...), body...)
+	}
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], 12500)
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], 640<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], 360<<16)
+	moov := box("moov", append(box("mvhd", mvhd), box("trak", box("tkhd", tkhd))...))
+	return append(box("ftyp", []byte("isom\x00\x00\x02\x00")), moov...)
+}
+
+func pngFixture(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 90, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestNativeProbe(t *testing.T) {
+	animated := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x02\x00\x00\x00\xff\x01\x00\xff\x01\x00")
+	tests := []struct {
+		name string
+		data []byte
+		want domainMediaProc.MediaInfo
+	}{
+		{name: "wav", data: wavFixture(), want: domainMediaProc.MediaInfo{Format: "wav", Codec: "pcm", Duration: time.Second}},
+		{name: "ogg", data: oggFixture(), want: domainMediaProc.MediaInfo{Format: "ogg", Codec: "opus", Duration: time.Second}},
+		{name: "mp4", data: mp4Fixture(), want: domainMediaProc.MediaInfo{Format: "mp4", Width: 640, Height: 360, Duration: 12500 * time.Millisecond}},
+		{name: "png", data: pngFixture(t, 30, 20), want: domainMediaProc.MediaInfo{Format: "png", Width: 30, Height: 20}},
+		{name: "animated webp", data: animated, want: domainMediaProc.MediaInfo{Format: "webp", Width: 512, Height: 512, Animated: true}},
+	}
+	processor := NewNativeProcessor()
+	for _, tt := range tests {
+		info, err := processor.Probe(context.Background(), tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if *info != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, *info, tt.want)
+		}
+	}
+
+	if _, err := processor.Probe(context.Background(), []byte("ID3\x04 not parsed")); !errors.Is(err, domainMediaProc.ErrUnsupported) {
+		t.Errorf("mp3 probe error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestNativeWaveform(t *testing.T) {
+	processor := NewNativeProcessor()
+
+	points, err := processor.Waveform(context.Background(), wavFixture(), 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 64 || points[0] != 0 || points[31] != 0 || points[40] < 90 {
+		t.Errorf("wav waveform = %v", points)
+	}
+
+	points, err = processor.Waveform(context.Background(), oggFixture(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0, 0, 100, 100, 0}; !bytes.Equal(points, want) {
+		t.Errorf("ogg waveform = %v, want %v", points, want)
+	}
+
+	if _, err := processor.Waveform(context.Background(), mp4Fixture(), 64); !errors.Is(err, domainMediaProc.ErrUnsupported) {
+		t.Errorf("mp4 waveform error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestNativeTranscodeOpus(t *testing.T) {
+	processor := NewNativeProcessor()
+	if opus, err := processor.TranscodeOpus(context.Background(), oggFixture()); err != nil || !bytes.Equal(opus, oggFixture()) {
+		t.Errorf("opus passthrough = %d bytes, %v", len(opus), err)
+	}
+	if _, err := processor.TranscodeOpus(context.Background(), wavFixture()); !errors.Is(err, domainMediaProc.ErrUnsupported) {
+		t.Errorf("wav transcode error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestNativeWebP(t *testing.T) {
+	processor := NewNativeProcessor()
+	result, err := processor.WebP(context.Background(), pngFixture(t, 1024, 256), domainMediaProc.WebPOptions{MaxDimension: 512})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Width != 512 || result.Height != 128 {
+		t.Errorf("sticker size = %dx%d, want 512x128", result.Width, result.Height)
+	}
+	decoded, err := webp.Decode(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatalf("decode sticker: %v", err)
+	}
+	if decoded.Bounds().Dx() != 512 || decoded.Bounds().Dy() != 128 {
+		t.Errorf("decoded sticker bounds = %v", decoded.Bounds())
+	}
+
+	thumbnail, err := processor.Thumbnail(context.Background(), result.Data, 100)
+	if err != nil {
+		t.Fatalf("thumbnail of webp: %v", err)
+	}
+	if config, format, err := image.DecodeConfig(bytes.NewReader(thumbnail)); err != nil || format != "jpeg" || config.Width != 100 {
+		t.Errorf("thumbnail = %s %dx%d, %v", format, config.Width, config.Height, err)
+	}
+
+	if _, err := processor.Thumbnail(context.Background(), mp4Fixture(), 100); !errors.Is(err, domainMediaProc.ErrUnsupported) {
+		t.Errorf("video thumbnail error = %v, want ErrUnsupported", err)
+	}
+}
+
+// animatedWebPFixture is a 600x300 animation whose first frame is a 200x100
+// red box at (100, 50) and whose second frame fills the canvas with blue
+func animatedWebPFixture() []byte {
+	chunk := func(id string, body []byte) []byte {
+		out := binary.LittleEndian.AppendUint32([]byte(id), uint32(len(body)))
+		out = append(out, body...)
+		if len(body)&1 == 1 {
+			out = append(out, 0)
+		}
+		return out
+	}
+	uint24 := func(out []byte, v int) []byte {
+		return append(out, byte(v), byte(v>>8), byte(v>>16))
+	}
+	frame := func(x, y, width, height int, fill color.NRGBA) []byte {
+		img := image.NewNRGBA(image.Rect(0, 0, width, height))
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = fill.R, fill.G, fill.B, fill.A
+		}
+		body := uint24(uint24(uint24(uint24(uint24(nil, x/2), y/2), width-1), height-1), 100)
+		body = append(body, 0)
+		// The still encoding without its RIFF header is the VP8L chunk
+		return chunk("ANMF", append(body, encodeWebP(img, 0)[12:]...))
+	}
+
+	vp8x := uint24(uint24(append([]byte{0x12}, 0, 0, 0), 599), 299)
+	body := append([]byte("WEBP"), chunk("VP8X", vp8x)...)
+	body = append(body, chunk("ANIM", []byte{0, 0, 0, 0, 0, 0})...)
+	body = append(body, frame(100, 50, 200, 100, color.NRGBA{R: 255, A: 255})...)
+	body = append(body, frame(0, 0, 600, 300, color.NRGBA{B: 255, A: 255})...)
+	return append(binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body))), body...)
+}
+
+func TestNativeWebPAnimated(t *testing.T) {
+	data := animatedWebPFixture()
+	processor := NewNativeProcessor()
+	if info, err := processor.Probe(context.Background(), data); err != nil || !info.Animated || info.Width != 600 || info.Height != 300 {
+		t.Fatalf("Probe() = %+v, %v", info, err)
+	}
+
+	result, err := processor.WebP(context.Background(), data, domainMediaProc.WebPOptions{MaxDimension: 512})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Width != 512 || result.Height != 256 {
+		t.Errorf("sticker size = %dx%d, want 512x256", result.Width, result.Height)
+	}
+	decoded, err := webp.Decode(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatalf("decode sticker: %v", err)
+	}
+
+	// The first frame is kept at its offset, the rest of the canvas stays clear
+	if r, g, b, a := decoded.At(170, 85).RGBA(); r>>8 != 255 || g != 0 || b != 0 || a>>8 != 255 {
+		t.Errorf("first frame pixel = %d %d %d %d, want opaque red", r>>8, g>>8, b>>8, a>>8)
+	}
+	if _, _, _, a := decoded.At(10, 10).RGBA(); a != 0 {
+		t.Errorf("canvas outside the first frame has alpha %d, want transparent", a>>8)
+	}
+}
+
+func TestNativeWebPAnimatedGIF(t *testing.T) {
+	palette := color.Palette{color.NRGBA{R: 255, A: 255}, color.NRGBA{B: 255, A: 255}}
+	animation := &gif.GIF{Delay: []int{10, 10}}
+	for i := range palette {
+		frame := image.NewPaletted(image.Rect(0, 0, 64, 64), palette)
+		for j := range frame.Pix {
+			frame.Pix[j] = uint8(i)
+		}
+		animation.Image = append(animation.Image, frame)
+	}
+	var data bytes.Buffer
+	if err := gif.EncodeAll(&data, animation); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewNativeProcessor().WebP(context.Background(), data.Bytes(), domainMediaProc.WebPOptions{MaxDimension: 512})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := webp.Decode(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatalf("decode sticker: %v", err)
+	}
+	if r, _, b, _ := decoded.At(32, 32).RGBA(); r>>8 != 255 || b != 0 {
+		t.Errorf("sticker pixel = %d red %d blue, want the red first frame", r>>8, b>>8)
+	}
+}
+
+func TestNew(t *testing.T) {
+	processor, err := New(domainMediaProc.BackendNative, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	capabilities := processor.Capabilities()
+	if capabilities.Backend != domainMediaProc.BackendNative {
+		t.Errorf("backend = %s", capabilities.Backend)
+	}
+	for _, capability := range capabilities.Operations {
+		if capability.Available != (capability.Operation != domainMediaProc.OperationCompressVideo) {
+			t.Errorf("%s available = %v", capability.Operation, capability.Available)
+		}
+	}
+
+	if _, err := New("gstreamer", t.TempDir()); err == nil {
+		t.Error("unknown backend was accepted")
+	}
+}
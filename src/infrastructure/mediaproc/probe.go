@@ -0,0 +1,365 @@
+package mediaproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"math"
+	"time"
+
+	domainMediaProc "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediaproc"
+	"golang.org/x/image/webp"
+)
+
+// This file reads what the pure-Go processor needs from media containers:
+// the canvas and first frame of WebP files, the samples of WAV files, the packets of Ogg
+// streams and the movie header of MP4 files.
+
+var errTruncated = errors.New("media file is truncated")
+
+// probeData identifies a media file by its leading bytes and describes it
+func probeData(data []byte) (*domainMediaProc.MediaInfo, error) {
+	switch {
+	case isRIFF(data, "WEBP"):
+		return probeWebP(data)
+	case isRIFF(data, "WAVE"):
+		wav, err := parseWAV(data)
+		if err != nil {
+			return nil, err
+		}
+		return &domainMediaProc.MediaInfo{Format: "wav", Codec: wav.codec(), Duration: wav.duration()}, nil
+	case bytes.HasPrefix(data, []byte("OggS")):
+		ogg, err := parseOgg(data)
+		if err != nil {
+			return nil, err
+		}
+		return &domainMediaProc.MediaInfo{Format: "ogg", Codec: ogg.codec, Duration: ogg.duration()}, nil
+	case len(data) >= 8 && string(data[4:8]) == "ftyp":
+		return parseMP4(data)
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, domainMediaProc.ErrUnsupported
+	}
+	return &domainMediaProc.MediaInfo{Format: format, Width: config.Width, Height: config.Height}, nil
+}
+
+func isRIFF(data []byte, form string) bool {
+	return len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == form
+}
+
+// probeWebP reads the canvas of animated WebP files from their VP8X header,
+// which the image decoders do not support
+func probeWebP(data []byte) (*domainMediaProc.MediaInfo, error) {
+	if len(data) >= 30 && string(data[12:16]) == "VP8X" && data[20]&0x02 != 0 {
+		return &domainMediaProc.MediaInfo{
+			Format:   "webp",
+			Width:    1 + int(uint24(data[24:27])),
+			Height:   1 + int(uint24(data[27:30])),
+			Animated: true,
+		}, nil
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return &domainMediaProc.MediaInfo{Format: "webp", Width: config.Width, Height: config.Height}, nil
+}
+
+// webpFirstFrame decodes the first frame of an animated WebP file onto a
+// transparent canvas. The frame is rewrapped as a still image, since the
+// image decoders stop at the animation chunks.
+func webpFirstFrame(data []byte) (image.Image, error) {
+	if len(data) < 30 {
+		return nil, errTruncated
+	}
+	canvas := image.NewNRGBA(image.Rect(0, 0, 1+int(uint24(data[24:27])), 1+int(uint24(data[27:30]))))
+
+	for pos := 12; pos+8 <= len(data); {
+		id, size := string(data[pos:pos+4]), int(binary.LittleEndian.Uint32(data[pos+4:]))
+		body := data[pos+8:]
+		if size < 0 || size > len(body) {
+			return nil, errTruncated
+		}
+		body = body[:size]
+		pos += 8 + size + size&1
+		if id != "ANMF" {
+			continue
+		}
+		if len(body) < 16 {
+			return nil, errTruncated
+		}
+
+		// The frame header is followed by the ALPH, VP8 or VP8L chunks of a still image
+		x, y := 2*int(uint24(body[0:3])), 2*int(uint24(body[3:6]))
+		frameData := body[16:]
+		var flags byte
+		if len(frameData) >= 4 && string(frameData[0:4]) == "ALPH" {
+			flags |= 0x10
+		}
+		still := make([]byte, 0, 30+len(frameData))
+		still = append(still, "RIFF"...)
+		still = binary.LittleEndian.AppendUint32(still, uint32(22+len(frameData)))
+		still = append(still, "WEBPVP8X"...)
+		still = binary.LittleEndian.AppendUint32(still, 10)
+		still = append(still, flags, 0, 0, 0)
+		still = append(still, body[6:12]...)
+		still = append(still, frameData...)
+
+		frame, err := webp.Decode(bytes.NewReader(still))
+		if err != nil {
+			return nil, err
+		}
+		bounds := frame.Bounds()
+		draw.Draw(canvas, image.Rect(x, y, x+bounds.Dx(), y+bounds.Dy()), frame, bounds.Min, draw.Src)
+		return canvas, nil
+	}
+	return nil, errors.New("animated webp has no frames")
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+// wavFile is the format and sample data of a WAV file
+type wavFile struct {
+	format        uint16
+	channels      int
+	sampleRate    int
+	bitsPerSample int
+	blockAlign    int
+	data          []byte
+}
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xfffe
+)
+
+func parseWAV(data []byte) (*wavFile, error) {
+	wav := &wavFile{}
+	foundFormat := false
+	for pos := 12; pos+8 <= len(data); {
+		id, size := string(data[pos:pos+4]), int(binary.LittleEndian.Uint32(data[pos+4:]))
+		body := data[pos+8:]
+		if size < 0 || size > len(body) {
+			// Streamed files leave the size of the last chunk unset
+			size = len(body)
+		}
+		body = body[:size]
+
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return nil, errTruncated
+			}
+			wav.format = binary.LittleEndian.Uint16(body[0:])
+			wav.channels = int(binary.LittleEndian.Uint16(body[2:]))
+			wav.sampleRate = int(binary.LittleEndian.Uint32(body[4:]))
+			wav.blockAlign = int(binary.LittleEndian.Uint16(body[12:]))
+			wav.bitsPerSample = int(binary.LittleEndian.Uint16(body[14:]))
+			if wav.format == wavFormatExtensible && len(body) >= 26 {
+				wav.format = binary.LittleEndian.Uint16(body[24:])
+			}
+			foundFormat = true
+		case "data":
+			wav.data = body
+		}
+		pos += 8 + size + size&1
+	}
+	if !foundFormat || wav.data == nil || wav.sampleRate == 0 || wav.blockAlign == 0 {
+		return nil, errTruncated
+	}
+	return wav, nil
+}
+
+func (wav *wavFile) frames() int {
+	return len(wav.data) / wav.blockAlign
+}
+
+func (wav *wavFile) duration() time.Duration {
+	return time.Duration(wav.frames()) * time.Second / time.Duration(wav.sampleRate)
+}
+
+func (wav *wavFile) codec() string {
+	switch wav.format {
+	case wavFormatPCM:
+		return "pcm"
+	case wavFormatFloat:
+		return "pcm_float"
+	}
+	return ""
+}
+
+// sample returns the first channel of frame i from -1 to 1, and false for
+// sample formats it cannot read
+func (wav *wavFile) sample(i int) (float64, bool) {
+	b := wav.data[i*wav.blockAlign:]
+	switch {
+	case wav.format == wavFormatPCM && wav.bitsPerSample == 8:
+		return (float64(b[0]) - 128) / 128, true
+	case wav.format == wavFormatPCM && wav.bitsPerSample == 16:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15), true
+	case wav.format == wavFormatPCM && wav.bitsPerSample == 24:
+		return float64(int32(uint24(b)<<8)>>8) / (1 << 23), true
+	case wav.format == wavFormatPCM && wav.bitsPerSample == 32:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31), true
+	case wav.format == wavFormatFloat && wav.bitsPerSample == 32:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), true
+	case wav.format == wavFormatFloat && wav.bitsPerSample == 64:
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), true
+	}
+	return 0, false
+}
+
+// oggStream is the first logical stream of an Ogg file
+type oggStream struct {
+	codec      string
+	sampleRate int
+	preSkip    int64
+	granule    int64
+	// packets holds the size of every audio packet, after the headers
+	packets []int
+}
+
+func parseOgg(data []byte) (*oggStream, error) {
+	stream := &oggStream{granule: -1}
+	var (
+		serial  uint32
+		first   []byte
+		packet  int
+		packets []int
+	)
+	for pos := 0; pos+27 <= len(data) && string(data[pos:pos+4]) == "OggS"; {
+		segments := int(data[pos+26])
+		payload := pos + 27 + segments
+		if payload > len(data) {
+			break
+		}
+		pageSerial := binary.LittleEndian.Uint32(data[pos+14:])
+		if pos == 0 {
+			serial = pageSerial
+		}
+		table := data[pos+27 : payload]
+		size := 0
+		for _, segment := range table {
+			size += int(segment)
+		}
+		if payload+size > len(data) {
+			break
+		}
+
+		if pageSerial == serial {
+			if granule := int64(binary.LittleEndian.Uint64(data[pos+6:])); granule >= 0 {
+				stream.granule = granule
+			}
+			offset := payload
+			for _, segment := range table {
+				if len(packets) == 0 {
+					first = append(first, data[offset:offset+int(segment)]...)
+				}
+				offset += int(segment)
+				packet += int(segment)
+				if segment < 255 {
+					packets = append(packets, packet)
+					packet = 0
+				}
+			}
+		}
+		pos = payload + size
+	}
+
+	headers := 0
+	switch {
+	case bytes.HasPrefix(first, []byte("OpusHead")) && len(first) >= 12:
+		stream.codec, stream.sampleRate, headers = "opus", 48000, 2
+		stream.preSkip = int64(binary.LittleEndian.Uint16(first[10:]))
+	case bytes.HasPrefix(first, []byte("\x01vorbis")) && len(first) >= 16:
+		stream.codec, headers = "vorbis", 3
+		stream.sampleRate = int(binary.LittleEndian.Uint32(first[12:]))
+	default:
+		return nil, domainMediaProc.ErrUnsupported
+	}
+	if len(packets) > headers {
+		stream.packets = packets[headers:]
+	}
+	return stream, nil
+}
+
+func (stream *oggStream) duration() time.Duration {
+	if stream.sampleRate == 0 || stream.granule <= stream.preSkip {
+		return 0
+	}
+	return time.Duration(stream.granule-stream.preSkip) * time.Second / time.Duration(stream.sampleRate)
+}
+
+// parseMP4 reads the duration from the movie header and the dimensions from
+// the first track header that has them
+func parseMP4(data []byte) (*domainMediaProc.MediaInfo, error) {
+	info := &domainMediaProc.MediaInfo{Format: "mp4"}
+	found := false
+	var walk func(data []byte)
+	walk = func(data []byte) {
+		for len(data) >= 8 {
+			size, kind, header := uint64(binary.BigEndian.Uint32(data)), string(data[4:8]), 8
+			switch size {
+			case 0:
+				size = uint64(len(data))
+			case 1:
+				if len(data) < 16 {
+					return
+				}
+				size, header = binary.BigEndian.Uint64(data[8:]), 16
+			}
+			if size < uint64(header) || size > uint64(len(data)) {
+				return
+			}
+			body := data[header:size]
+			switch kind {
+			case "moov", "trak":
+				walk(body)
+			case "mvhd":
+				if timescale, duration, ok := movieDuration(body); ok && timescale > 0 {
+					info.Duration = time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+					found = true
+				}
+			case "tkhd":
+				if info.Width == 0 {
+					info.Width, info.Height = trackDimensions(body)
+				}
+			}
+			data = data[size:]
+		}
+	}
+	walk(data)
+	if !found {
+		return nil, domainMediaProc.ErrUnsupported
+	}
+	return info, nil
+}
+
+func movieDuration(body []byte) (timescale uint32, duration uint64, ok bool) {
+	if len(body) >= 32 && body[0] == 1 {
+		return binary.BigEndian.Uint32(body[20:]), binary.BigEndian.Uint64(body[24:]), true
+	}
+	if len(body) >= 20 {
+		return binary.BigEndian.Uint32(body[12:]), uint64(binary.BigEndian.Uint32(body[16:])), true
+	}
+	return 0, 0, false
+}
+
+// trackDimensions reads the 16.16 fixed point width and height of a track header
+func trackDimensions(body []byte) (width, height int) {
+	offset := 76
+	if len(body) > 0 && body[0] == 1 {
+		offset = 88
+	}
+	if len(body) < offset+8 {
+		return 0, 0
+	}
+	return int(binary.BigEndian.Uint32(body[offset:]) >> 16), int(binary.BigEndian.Uint32(body[offset+4:]) >> 16)
+}
@@ -0,0 +1,595 @@
+package mediaproc
+
+import (
+	"encoding/binary"
+	"image"
+	"math/bits"
+
+	"github.com/disintegration/imaging"
+)
+
+// This file is a lossless WebP (VP8L) encoder, enough to turn stickers into
+// WebP without cwebp or ffmpeg. It applies the subtract-green and predictor
+// transforms, finds backward references and writes one set of prefix codes
+// for the whole image. See https://developers.google.com/speed/webp/docs/webp_lossless_bitstream_specification
+
+const (
+	vp8lSignature    = 0x2f
+	vp8lMaxDimension = 1 << 14
+	predictorBits    = 5 // 32x32 pixel tiles share a predictor
+	maxMatchLength   = 4096
+	maxChainLength   = 16
+	hashBits         = 16
+	maxCodeLength    = 15
+	maxCodeLengthLen = 7
+	numLengthCodes   = 24
+	numDistanceCodes = 40
+)
+
+// codeLengthOrder is the order the code lengths of the code length code are sent in
+var codeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// encodeWebP encodes an image as a lossless WebP file. dropBits clears the low
+// bits of every color channel first, trading fidelity for size.
+func encodeWebP(img image.Image, dropBits uint) []byte {
+	nrgba := imaging.Clone(img)
+	width, height := nrgba.Rect.Dx(), nrgba.Rect.Dy()
+
+	mask := byte(0xff << dropBits)
+	pix := make([]uint32, width*height)
+	alphaUsed := false
+	for y := 0; y < height; y++ {
+		row := nrgba.Pix[y*nrgba.Stride:]
+		for x := 0; x < width; x++ {
+			r, g, b, a := row[4*x], row[4*x+1], row[4*x+2], row[4*x+3]
+			if a != 0xff {
+				alphaUsed = true
+			}
+			if a == 0 {
+				// Hidden colors compress better as black
+				continue
+			}
+			pix[y*width+x] = uint32(a)<<24 | uint32(r&mask)<<16 | uint32(g&mask)<<8 | uint32(b&mask)
+		}
+	}
+
+	var w bitWriter
+	w.write(vp8lSignature, 8)
+	w.write(uint32(width-1), 14)
+	w.write(uint32(height-1), 14)
+	w.writeBool(alphaUsed)
+	w.write(0, 3) // version
+
+	subtractGreen(pix)
+	w.writeBool(true)
+	w.write(2, 2) // subtract green transform
+
+	residuals, modes, tilesX := predict(pix, width, height)
+	w.writeBool(true)
+	w.write(0, 2) // predictor transform
+	w.write(predictorBits-2, 3)
+	w.writeEntropyImage(modes, tilesX, false)
+	w.writeBool(false) // no more transforms
+
+	w.writeEntropyImage(residuals, width, true)
+	return riffWebP(w.bytes())
+}
+
+// riffWebP wraps a VP8L bitstream in the RIFF container of a WebP file
+func riffWebP(vp8l []byte) []byte {
+	padded := len(vp8l) + len(vp8l)&1
+	out := make([]byte, 0, 20+padded)
+	out = append(out, "RIFF"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(12+padded))
+	out = append(out, "WEBPVP8L"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(vp8l)))
+	out = append(out, vp8l...)
+	if len(vp8l)&1 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+// subtractGreen stores red and blue as their difference to green
+func subtractGreen(pix []uint32) {
+	for i, argb := range pix {
+		green := (argb >> 8) & 0xff
+		redBlue := ((argb | 0xff00ff00) - (green<<16 | green)) & 0x00ff00ff
+		pix[i] = argb&0xff00ff00 | redBlue
+	}
+}
+
+// predict replaces every pixel with its difference to a prediction from its
+// neighbours, picking for each tile the predictor mode that leaves the
+// smallest differences. It returns the differences and the tile modes.
+func predict(pix []uint32, width, height int) (residuals, modes []uint32, tilesX int) {
+	tileSize := 1 << predictorBits
+	tilesX = (width + tileSize - 1) >> predictorBits
+	tilesY := (height + tileSize - 1) >> predictorBits
+	modes = make([]uint32, tilesX*tilesY)
+	residuals = make([]uint32, len(pix))
+
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			bestMode, bestCost := 1, -1
+			for mode := 1; mode <= 10; mode++ {
+				cost := 0
+				for y := max(ty*tileSize, 1); y < min((ty+1)*tileSize, height); y++ {
+					for x := max(tx*tileSize, 1); x < min((tx+1)*tileSize, width); x++ {
+						i := y*width + x
+						cost += residualCost(subPixels(pix[i], predictPixel(pix, i, width, mode)))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					bestMode, bestCost = mode, cost
+				}
+			}
+			modes[ty*tilesX+tx] = 0xff000000 | uint32(bestMode)<<8
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			var prediction uint32
+			switch {
+			case x == 0 && y == 0:
+				prediction = 0xff000000
+			case y == 0:
+				prediction = pix[i-1]
+			case x == 0:
+				prediction = pix[i-width]
+			default:
+				mode := int(modes[(y>>predictorBits)*tilesX+x>>predictorBits]>>8) & 0x0f
+				prediction = predictPixel(pix, i, width, mode)
+			}
+			residuals[i] = subPixels(pix[i], prediction)
+		}
+	}
+	return residuals, modes, tilesX
+}
+
+// predictPixel predicts pixel i, which is not in the first row or column, with
+// one of the predictor modes 1 to 10. The top right pixel of the last column
+// is the first pixel of the current row, as in the decoder.
+func predictPixel(pix []uint32, i, width, mode int) uint32 {
+	left, top, topLeft, topRight := pix[i-1], pix[i-width], pix[i-width-1], pix[i-width+1]
+	switch mode {
+	case 1:
+		return left
+	case 2:
+		return top
+	case 3:
+		return topRight
+	case 4:
+		return topLeft
+	case 5:
+		return average2(average2(left, topRight), top)
+	case 6:
+		return average2(left, topLeft)
+	case 7:
+		return average2(left, top)
+	case 8:
+		return average2(topLeft, top)
+	case 9:
+		return average2(top, topRight)
+	default:
+		return average2(average2(left, topLeft), average2(top, topRight))
+	}
+}
+
+// average2 averages two pixels channel by channel, rounding down
+func average2(a, b uint32) uint32 {
+	return (((a ^ b) & 0xfefefefe) >> 1) + (a & b)
+}
+
+// subPixels subtracts two pixels channel by channel, modulo 256
+func subPixels(a, b uint32) uint32 {
+	alphaGreen := 0x00ff00ff + (a & 0xff00ff00) - (b & 0xff00ff00)
+	redBlue := 0xff00ff00 + (a & 0x00ff00ff) - (b & 0x00ff00ff)
+	return alphaGreen&0xff00ff00 | redBlue&0x00ff00ff
+}
+
+// residualCost estimates how costly a difference is to encode: the sum of its
+// channels as signed values
+func residualCost(argb uint32) int {
+	cost := 0
+	for shift := 0; shift < 32; shift += 8 {
+		value := int(int8(argb >> shift))
+		if value < 0 {
+			value = -value
+		}
+		cost += value
+	}
+	return cost
+}
+
+// pixelToken is a literal pixel, or a copy of length pixels from further back
+type pixelToken struct {
+	argb     uint32
+	length   int
+	distance int
+}
+
+// backwardReferences splits the pixels into literals and copies of earlier
+// runs, preferring the pixel to the left and the one above and otherwise
+// searching a hash chain of pixel pairs
+func backwardReferences(pix []uint32, width int) []pixelToken {
+	tokens := make([]pixelToken, 0, len(pix)/2)
+	head := make([]int32, 1<<hashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	chain := make([]int32, len(pix))
+	insert := func(i int) {
+		if i+1 < len(pix) {
+			h := pairHash(pix[i], pix[i+1])
+			chain[i] = head[h]
+			head[h] = int32(i)
+		}
+	}
+
+	for i := 0; i < len(pix); {
+		limit := min(maxMatchLength, len(pix)-i)
+		bestLength, bestDistance := 0, 0
+		try := func(j int) {
+			if j < 0 || j >= i {
+				return
+			}
+			length := 0
+			for length < limit && pix[j+length] == pix[i+length] {
+				length++
+			}
+			if length > bestLength {
+				bestLength, bestDistance = length, i-j
+			}
+		}
+		try(i - 1)
+		try(i - width)
+		if i+1 < len(pix) {
+			candidate := head[pairHash(pix[i], pix[i+1])]
+			for n := 0; candidate >= 0 && n < maxChainLength && bestLength < limit; n++ {
+				try(int(candidate))
+				candidate = chain[candidate]
+			}
+		}
+
+		if bestLength < 3 {
+			tokens = append(tokens, pixelToken{argb: pix[i]})
+			insert(i)
+			i++
+			continue
+		}
+		tokens = append(tokens, pixelToken{length: bestLength, distance: bestDistance})
+		for end := i + bestLength; i < end; i++ {
+			insert(i)
+		}
+	}
+	return tokens
+}
+
+func pairHash(a, b uint32) uint32 {
+	return (a*0x1e35a7bd ^ b*0x9e3779b1) >> (32 - hashBits)
+}
+
+// distanceCode maps a copy distance to the code the decoder expects; the
+// pixel above and the one to the left have short codes of their own
+func distanceCode(distance, width int) int {
+	switch distance {
+	case width:
+		return 1
+	case 1:
+		return 2
+	}
+	return distance + 120
+}
+
+// prefixEncode splits a length or distance code into a prefix symbol and the
+// extra bits that follow it
+func prefixEncode(value int) (symbol int, extraBits uint, extra uint32) {
+	d := value - 1
+	if d < 4 {
+		return d, 0, 0
+	}
+	highest := bits.Len(uint(d)) - 1
+	second := (d >> (highest - 1)) & 1
+	extraBits = uint(highest - 1)
+	return 2*highest + second, extraBits, uint32(d) & (1<<extraBits - 1)
+}
+
+// writeEntropyImage writes pixels with one group of prefix codes and no color
+// cache. The main image also says it uses no meta prefix codes.
+func (w *bitWriter) writeEntropyImage(pix []uint32, width int, main bool) {
+	w.writeBool(false) // no color cache
+	if main {
+		w.writeBool(false) // one prefix code group for the whole image
+	}
+
+	tokens := backwardReferences(pix, width)
+	green := make([]uint32, 256+numLengthCodes)
+	red := make([]uint32, 256)
+	blue := make([]uint32, 256)
+	alpha := make([]uint32, 256)
+	distance := make([]uint32, numDistanceCodes)
+	for _, token := range tokens {
+		if token.length == 0 {
+			green[(token.argb>>8)&0xff]++
+			red[(token.argb>>16)&0xff]++
+			blue[token.argb&0xff]++
+			alpha[token.argb>>24]++
+			continue
+		}
+		lengthSymbol, _, _ := prefixEncode(token.length)
+		green[256+lengthSymbol]++
+		distanceSymbol, _, _ := prefixEncode(distanceCode(token.distance, width))
+		distance[distanceSymbol]++
+	}
+
+	codes := [5]huffmanCode{}
+	for i, histogram := range [][]uint32{green, red, blue, alpha, distance} {
+		codes[i] = newHuffmanCode(histogram, maxCodeLength)
+		w.writeHuffmanCode(&codes[i])
+	}
+
+	for _, token := range tokens {
+		if token.length == 0 {
+			w.writeSymbol(&codes[0], int(token.argb>>8)&0xff)
+			w.writeSymbol(&codes[1], int(token.argb>>16)&0xff)
+			w.writeSymbol(&codes[2], int(token.argb)&0xff)
+			w.writeSymbol(&codes[3], int(token.argb>>24))
+			continue
+		}
+		symbol, extraBits, extra := prefixEncode(token.length)
+		w.writeSymbol(&codes[0], 256+symbol)
+		w.write(extra, extraBits)
+		symbol, extraBits, extra = prefixEncode(distanceCode(token.distance, width))
+		w.writeSymbol(&codes[4], symbol)
+		w.write(extra, extraBits)
+	}
+}
+
+// huffmanCode is a canonical prefix code. A code with a single symbol takes
+// no bits, as the decoder reads nothing for it.
+type huffmanCode struct {
+	lengths []uint8
+	codes   []uint16 // bit reversed, as codes are read most significant bit first
+	single  bool
+}
+
+func newHuffmanCode(histogram []uint32, limit int) huffmanCode {
+	code := huffmanCode{lengths: huffmanLengths(histogram, limit), codes: make([]uint16, len(histogram))}
+
+	var count [maxCodeLength + 1]int
+	used := 0
+	for _, length := range code.lengths {
+		if length > 0 {
+			count[length]++
+			used++
+		}
+	}
+	code.single = used == 1
+
+	var next [maxCodeLength + 1]int
+	value := 0
+	for length := 1; length <= maxCodeLength; length++ {
+		value = (value + count[length-1]) << 1
+		next[length] = value
+	}
+	for symbol, length := range code.lengths {
+		if length > 0 {
+			code.codes[symbol] = uint16(bits.Reverse16(uint16(next[length])) >> (16 - length))
+			next[length]++
+		}
+	}
+	return code
+}
+
+// huffmanLengths returns the code length of every symbol of a histogram,
+// flattening the histogram until no code is longer than limit
+func huffmanLengths(histogram []uint32, limit int) []uint8 {
+	lengths := make([]uint8, len(histogram))
+	weights := append([]uint32(nil), histogram...)
+	for {
+		var symbols []int
+		for symbol, weight := range weights {
+			if weight > 0 {
+				symbols = append(symbols, symbol)
+			}
+		}
+		switch len(symbols) {
+		case 0:
+			return lengths
+		case 1:
+			lengths[symbols[0]] = 1
+			return lengths
+		}
+
+		// Two queue Huffman construction over the leaves sorted by weight
+		sortByWeight(symbols, weights)
+		n := len(symbols)
+		weight := make([]uint64, n, 2*n-1)
+		parent := make([]int, 2*n-1)
+		for i, symbol := range symbols {
+			weight[i] = uint64(weights[symbol])
+		}
+		leaf, internal := 0, n
+		pop := func() int {
+			if leaf < n && (internal >= len(weight) || weight[leaf] <= weight[internal]) {
+				leaf++
+				return leaf - 1
+			}
+			internal++
+			return internal - 1
+		}
+		for len(weight) < 2*n-1 {
+			a, b := pop(), pop()
+			parent[a], parent[b] = len(weight), len(weight)
+			weight = append(weight, weight[a]+weight[b])
+		}
+		depth := make([]int, 2*n-1)
+		for i := 2*n - 3; i >= 0; i-- {
+			depth[i] = depth[parent[i]] + 1
+		}
+
+		deepest := 0
+		for i := range symbols {
+			deepest = max(deepest, depth[i])
+		}
+		if deepest <= limit {
+			for i, symbol := range symbols {
+				lengths[symbol] = uint8(depth[i])
+			}
+			return lengths
+		}
+		for symbol, weight := range weights {
+			if weight > 0 {
+				weights[symbol] = weight>>1 | 1
+			}
+		}
+	}
+}
+
+// sortByWeight orders symbols by ascending weight, ties by symbol
+func sortByWeight(symbols []int, weights []uint32) {
+	for i := 1; i < len(symbols); i++ {
+		for j := i; j > 0; j-- {
+			a, b := symbols[j-1], symbols[j]
+			if weights[a] < weights[b] || weights[a] == weights[b] && a < b {
+				break
+			}
+			symbols[j-1], symbols[j] = b, a
+		}
+	}
+}
+
+// writeHuffmanCode sends a prefix code as its code lengths, themselves coded
+// with a prefix code of their own
+func (w *bitWriter) writeHuffmanCode(code *huffmanCode) {
+	used := 0
+	for _, length := range code.lengths {
+		if length > 0 {
+			used++
+		}
+	}
+	if used == 0 {
+		// A simple code of the single symbol 0, which is never written
+		w.writeBool(true)
+		w.write(0, 3)
+		return
+	}
+
+	w.writeBool(false)
+	tokens := codeLengthTokens(code.lengths)
+	histogram := make([]uint32, len(codeLengthOrder))
+	for _, token := range tokens {
+		histogram[token.symbol]++
+	}
+	lengthCode := newHuffmanCode(histogram, maxCodeLengthLen)
+
+	count := len(codeLengthOrder)
+	for count > 4 && lengthCode.lengths[codeLengthOrder[count-1]] == 0 {
+		count--
+	}
+	w.write(uint32(count-4), 4)
+	for _, symbol := range codeLengthOrder[:count] {
+		w.write(uint32(lengthCode.lengths[symbol]), 3)
+	}
+	w.writeBool(false) // every code length is sent
+
+	for _, token := range tokens {
+		w.writeSymbol(&lengthCode, token.symbol)
+		w.write(token.extra, token.extraBits)
+	}
+}
+
+// codeLengthToken is a code length, or a run of repeated ones (16 to 18)
+type codeLengthToken struct {
+	symbol    int
+	extraBits uint
+	extra     uint32
+}
+
+func codeLengthTokens(lengths []uint8) []codeLengthToken {
+	var tokens []codeLengthToken
+	previous := uint8(8) // what a leading repeat code repeats
+	for i := 0; i < len(lengths); {
+		value := lengths[i]
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == value {
+			run++
+		}
+		i += run
+
+		if value == 0 {
+			for run >= 3 {
+				if run >= 11 {
+					n := min(run, 138)
+					tokens = append(tokens, codeLengthToken{symbol: 18, extraBits: 7, extra: uint32(n - 11)})
+					run -= n
+				} else {
+					n := min(run, 10)
+					tokens = append(tokens, codeLengthToken{symbol: 17, extraBits: 3, extra: uint32(n - 3)})
+					run -= n
+				}
+			}
+			for ; run > 0; run-- {
+				tokens = append(tokens, codeLengthToken{symbol: 0})
+			}
+			continue
+		}
+
+		if value != previous {
+			tokens = append(tokens, codeLengthToken{symbol: int(value)})
+			previous = value
+			run--
+		}
+		for run >= 3 {
+			n := min(run, 6)
+			tokens = append(tokens, codeLengthToken{symbol: 16, extraBits: 2, extra: uint32(n - 3)})
+			run -= n
+		}
+		for ; run > 0; run-- {
+			tokens = append(tokens, codeLengthToken{symbol: int(value)})
+		}
+	}
+	return tokens
+}
+
+// bitWriter writes the least significant bit first, as VP8L is read
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nBits uint
+}
+
+func (w *bitWriter) write(value uint32, n uint) {
+	w.acc |= uint64(value) << w.nBits
+	w.nBits += n
+	for w.nBits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nBits -= 8
+	}
+}
+
+func (w *bitWriter) writeBool(value bool) {
+	if value {
+		w.write(1, 1)
+	} else {
+		w.write(0, 1)
+	}
+}
+
+func (w *bitWriter) writeSymbol(code *huffmanCode, symbol int) {
+	if !code.single {
+		w.write(uint32(code.codes[symbol]), uint(code.lengths[symbol]))
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nBits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nBits = 0, 0
+	}
+	return w.buf
+}
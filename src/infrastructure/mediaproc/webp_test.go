@@ -0,0 +1,66 @@
+package mediaproc
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	noise := image.NewNRGBA(image.Rect(0, 0, 67, 45))
+	random.Read(noise.Pix)
+
+	gradient := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			alpha := uint8(255)
+			if (x-150)*(x-150)+(y-100)*(y-100) > 90*90 {
+				alpha = 0
+			}
+			gradient.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: uint8(x + y), A: alpha})
+		}
+	}
+
+	flat := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	flat.SetNRGBA(0, 0, color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+
+	for name, img := range map[string]*image.NRGBA{"noise": noise, "gradient": gradient, "flat": flat} {
+		t.Run(name, func(t *testing.T) {
+			decoded, err := webp.Decode(bytes.NewReader(encodeWebP(img, 0)))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if decoded.Bounds() != img.Bounds() {
+				t.Fatalf("bounds = %v, want %v", decoded.Bounds(), img.Bounds())
+			}
+			for y := 0; y < img.Rect.Dy(); y++ {
+				for x := 0; x < img.Rect.Dx(); x++ {
+					want := img.NRGBAAt(x, y)
+					if want.A == 0 {
+						want = color.NRGBA{}
+					}
+					if got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA); got != want {
+						t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeWebPDropBits(t *testing.T) {
+	random := rand.New(rand.NewSource(2))
+	img := image.NewNRGBA(image.Rect(0, 0, 128, 128))
+	random.Read(img.Pix)
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	if full, reduced := encodeWebP(img, 0), encodeWebP(img, 3); len(reduced) >= len(full) {
+		t.Errorf("dropping bits did not shrink the image: %d >= %d", len(reduced), len(full))
+	}
+}
@@ -0,0 +1,193 @@
+package mediastore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+)
+
+// LocalRoute is where the app serves signed links to locally stored media
+const LocalRoute = "/media/"
+
+// LocalStore keeps media on the local disk under root. Its links point at the
+// app itself and are signed, so they work without the API credentials.
+type LocalStore struct {
+	root    string
+	baseURL string
+	secret  []byte
+	now     func() time.Time
+}
+
+// NewLocalStore stores media under root. baseURL is prepended to links, e.g.
+// "https://wa.example.com/gowa"; without it links are relative to the app.
+func NewLocalStore(root, baseURL string, secret []byte) *LocalStore {
+	return &LocalStore{
+		root:    filepath.Clean(root),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		secret:  secret,
+		now:     time.Now,
+	}
+}
+
+// path returns the file of a key. Media downloaded before the store existed was
+// recorded with its path relative to the working directory, which still resolves.
+func (s *LocalStore) path(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	key = strings.TrimPrefix(key, strings.Trim(filepath.ToSlash(s.root), "/")+"/")
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+func (s *LocalStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	file, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	// Written aside and renamed so readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+func (s *LocalStore) Open(_ context.Context, key string) (io.ReadCloser, *domainMediaStore.Object, error) {
+	file, err := s.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, localError(err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, nil, domainMediaStore.ErrNotFound
+	}
+	return f, localObject(key, info), nil
+}
+
+func (s *LocalStore) OpenRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, *domainMediaStore.Object, error) {
+	reader, object, err := s.Open(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	file := reader.(*os.File)
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if length < 0 {
+		return file, object, nil
+	}
+	return limitedReadCloser{io.LimitReader(file, length), file}, object, nil
+}
+
+func (s *LocalStore) Stat(_ context.Context, key string) (*domainMediaStore.Object, error) {
+	file, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, localError(err)
+	}
+	if info.IsDir() {
+		return nil, domainMediaStore.ErrNotFound
+	}
+	return localObject(key, info), nil
+}
+
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	file, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) URL(_ context.Context, key string, ttl time.Duration) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(s.now().Add(ttl).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {s.signature(key, expires)}}
+	return s.baseURL + LocalRoute + escapeKey(key) + "?" + query.Encode(), nil
+}
+
+// Verify checks the expiry and signature of a link made by URL
+func (s *LocalStore) Verify(key, expires, signature string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expiry")
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signature(key, expires))) {
+		return fmt.Errorf("invalid signature")
+	}
+	if s.now().Unix() > expiresAt {
+		return fmt.Errorf("link expired")
+	}
+	return nil
+}
+
+func (s *LocalStore) signature(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func localObject(key string, info os.FileInfo) *domainMediaStore.Object {
+	return &domainMediaStore.Object{
+		Key:         key,
+		Size:        info.Size(),
+		ContentType: utils.MediaContentType(key),
+		ModTime:     info.ModTime(),
+	}
+}
+
+func localError(err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return domainMediaStore.ErrNotFound
+	}
+	return err
+}
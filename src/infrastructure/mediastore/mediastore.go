@@ -0,0 +1,51 @@
+package mediastore
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// limitedReadCloser closes the file a range of it is read from
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// cleanKey normalizes a key and rejects the ones that would escape the store
+func cleanKey(key string) (string, error) {
+	normalized := strings.Trim(strings.ReplaceAll(key, "\\", "/"), "/")
+	for _, segment := range strings.Split(normalized, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("invalid media key %q", key)
+		}
+	}
+	cleaned := path.Clean(normalized)
+	if cleaned == "." {
+		return "", fmt.Errorf("invalid media key %q", key)
+	}
+	return cleaned, nil
+}
+
+// escapeKey percent-encodes every segment of a key the way S3 signatures
+// expect: everything but letters, digits and "-._~" is escaped
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func uriEncode(value string) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		if 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z' || '0' <= b && b <= '9' || b == '-' || b == '.' || b == '_' || b == '~' {
+			builder.WriteByte(b)
+		} else {
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+	return builder.String()
+}
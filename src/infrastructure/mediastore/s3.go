@@ -0,0 +1,348 @@
+package mediastore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
+)
+
+const (
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3TimeFormat    = "20060102T150405Z"
+	s3DateFormat    = "20060102"
+	s3EmptyHash     = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	s3MaxURLExpiry  = 7 * 24 * time.Hour
+	s3UnsignedHash  = "UNSIGNED-PAYLOAD"
+	s3DefaultRegion = "us-east-1"
+)
+
+// S3Config configures an S3 compatible bucket: AWS S3, MinIO, Cloudflare R2, ...
+type S3Config struct {
+	Endpoint  string // e.g. "https://s3.eu-west-1.amazonaws.com" or "http://minio:9000"
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Prefix    string // Prepended to every key, e.g. "whatsapp/"
+	PathStyle bool   // Address the bucket in the path instead of the host name, as MinIO expects
+}
+
+// S3Store keeps media in an S3 compatible bucket. Requests are signed with AWS
+// Signature Version 4 and links are presigned GET URLs.
+type S3Store struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+func NewS3Store(config S3Config) (*S3Store, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("s3 media storage needs a bucket")
+	}
+	if config.AccessKey == "" || config.SecretKey == "" {
+		return nil, fmt.Errorf("s3 media storage needs an access key and a secret key")
+	}
+	if config.Region == "" {
+		config.Region = s3DefaultRegion
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", config.Endpoint)
+	}
+	config.Prefix = strings.Trim(config.Prefix, "/")
+
+	// Bodies are streamed to clients for as long as they take, so only waiting
+	// for the response is bounded; requests are cancelled through their context
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = time.Minute
+
+	return &S3Store{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{Transport: transport},
+		now:      time.Now,
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, data, header)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Store) Open(ctx context.Context, key string) (io.ReadCloser, *domainMediaStore.Object, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Body, s.object(key, resp), nil
+}
+
+func (s *S3Store) OpenRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, *domainMediaStore.Object, error) {
+	if offset == 0 && length < 0 {
+		return s.Open(ctx, key)
+	}
+	if length == 0 {
+		object, err := s.Stat(ctx, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		return io.NopCloser(strings.NewReader("")), object, nil
+	}
+
+	byteRange := fmt.Sprintf("bytes=%d-", offset)
+	if length > 0 {
+		byteRange += strconv.FormatInt(offset+length-1, 10)
+	}
+	resp, err := s.do(ctx, http.MethodGet, key, nil, http.Header{"Range": {byteRange}})
+	if err != nil {
+		return nil, nil, err
+	}
+	object := s.object(key, resp)
+	if resp.StatusCode == http.StatusPartialContent {
+		object.Size = contentRangeSize(resp.Header.Get("Content-Range"), object.Size)
+		return resp.Body, object, nil
+	}
+
+	// The server ignored the range and sent the whole file
+	if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+		resp.Body.Close()
+		return nil, nil, err
+	}
+	if length < 0 {
+		return resp.Body, object, nil
+	}
+	return limitedReadCloser{io.LimitReader(resp.Body, length), resp.Body}, object, nil
+}
+
+func (s *S3Store) Stat(ctx context.Context, key string) (*domainMediaStore.Object, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return s.object(key, resp), nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		if errors.Is(err, domainMediaStore.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// URL presigns a GET of the object. S3 caps presigned links at seven days.
+func (s *S3Store) URL(_ context.Context, key string, ttl time.Duration) (string, error) {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	ttl = min(max(ttl, time.Second), s3MaxURLExpiry)
+
+	now := s.now().UTC()
+	query := url.Values{
+		"X-Amz-Algorithm":     {s3Algorithm},
+		"X-Amz-Credential":    {s.config.AccessKey + "/" + s.scope(now)},
+		"X-Amz-Date":          {now.Format(s3TimeFormat)},
+		"X-Amz-Expires":       {strconv.Itoa(int(ttl.Seconds()))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	canonicalQuery := canonicalQueryString(query)
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		objectURL.EscapedPath(),
+		canonicalQuery,
+		"host:" + objectURL.Host + "\n",
+		"host",
+		s3UnsignedHash,
+	}, "\n")
+
+	return objectURL.String() + "?" + canonicalQuery + "&X-Amz-Signature=" + s.signature(now, canonicalRequest), nil
+}
+
+// do sends a signed request for an object and turns error statuses into errors
+func (s *S3Store) do(ctx context.Context, method, key string, body []byte, header http.Header) (*http.Response, error) {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, objectURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body == nil {
+		req.Body = http.NoBody
+		req.ContentLength = 0
+	}
+	s.sign(req, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, domainMediaStore.ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s: %s %s", method, key, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return resp, nil
+}
+
+// sign adds the Authorization header of Signature Version 4, covering the host
+// and the x-amz-* headers
+func (s *S3Store) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	payloadHash := s3EmptyHash
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	req.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + now.Format(s3TimeFormat) + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQueryString(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.config.AccessKey, s.scope(now), signedHeaders, s.signature(now, canonicalRequest)))
+}
+
+func (s *S3Store) scope(now time.Time) string {
+	return now.Format(s3DateFormat) + "/" + s.config.Region + "/s3/aws4_request"
+}
+
+func (s *S3Store) signature(now time.Time, canonicalRequest string) string {
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3TimeFormat),
+		s.scope(now),
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), now.Format(s3DateFormat))
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// objectURL addresses the object of a key, with the bucket in the host name or,
+// for path style, in the path
+func (s *S3Store) objectURL(key string) (*url.URL, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.Prefix != "" {
+		key = s.config.Prefix + "/" + key
+	}
+
+	objectURL := *s.endpoint
+	objectPath := "/" + key
+	if s.config.PathStyle {
+		objectPath = "/" + s.config.Bucket + objectPath
+	} else {
+		objectURL.Host = s.config.Bucket + "." + objectURL.Host
+	}
+	objectURL.Path = s.endpoint.Path + objectPath
+	objectURL.RawPath = s.endpoint.EscapedPath() + escapeKey(objectPath)
+	return &objectURL, nil
+}
+
+func (s *S3Store) object(key string, resp *http.Response) *domainMediaStore.Object {
+	object := &domainMediaStore.Object{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		object.ModTime = modified
+	}
+	return object
+}
+
+// contentRangeSize reads the size of the whole object from a Content-Range
+// header such as "bytes 0-99/1234"
+func contentRangeSize(contentRange string, fallback int64) int64 {
+	_, total, ok := strings.Cut(contentRange, "/")
+	if !ok {
+		return fallback
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return size
+}
+
+// canonicalQueryString sorts and encodes query parameters for signing
+func canonicalQueryString(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,359 @@
+package sendqueue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	domainSendQueue "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/sendqueue"
+)
+
+// Repository implements IQueueRepository on the chat storage database. Queries
+// use $n placeholders, which both SQLite and PostgreSQL accept.
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new send queue repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// InitializeSchema runs send queue migrations
+func (r *Repository) InitializeSchema() error {
+	migrations := []string{
+		// Migration 1: Outbound send queue
+		`CREATE TABLE IF NOT EXISTS send_queue (
+			id VARCHAR(36) PRIMARY KEY,
+			device_id VARCHAR(255) NOT NULL,
+			idempotency_key VARCHAR(255),
+			kind VARCHAR(20) NOT NULL,
+			phone VARCHAR(255) NOT NULL DEFAULT '',
+			payload TEXT NOT NULL,
+			request_hash VARCHAR(64) NOT NULL DEFAULT '',
+			files TEXT NOT NULL DEFAULT '',
+			status VARCHAR(20) NOT NULL DEFAULT 'queued',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			message_id VARCHAR(255) NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '',
+			next_attempt_at TIMESTAMP NOT NULL,
+			sent_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			UNIQUE(device_id, idempotency_key)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_send_queue_due ON send_queue(status, next_attempt_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_send_queue_device ON send_queue(device_id, created_at)`,
+
+		// Migration 2: Scheduled and recurring messages
+		`ALTER TABLE send_queue ADD COLUMN recurrence VARCHAR(100) NOT NULL DEFAULT ''`,
+		`ALTER TABLE send_queue ADD COLUMN scheduled_at TIMESTAMP`,
+
+		// Migration 3: Bulk sends
+		`ALTER TABLE send_queue ADD COLUMN batch_id VARCHAR(36) NOT NULL DEFAULT ''`,
+		`ALTER TABLE send_queue ADD COLUMN batch_index INTEGER NOT NULL DEFAULT 0`,
+		`CREATE INDEX IF NOT EXISTS idx_send_queue_batch ON send_queue(device_id, batch_id)`,
+	}
+
+	for i, migration := range migrations {
+		if _, err := r.db.Exec(migration); err != nil {
+			// Ignore "duplicate column" errors of columns added by an earlier run
+			if !strings.Contains(err.Error(), "already exists") && !strings.Contains(err.Error(), "duplicate column") {
+				return fmt.Errorf("send queue migration %d failed: %w", i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+const itemColumns = `id, device_id, idempotency_key, batch_id, batch_index, kind, phone, payload, request_hash, files, recurrence, scheduled_at,
+	status, attempts, message_id, error, next_attempt_at, sent_at, created_at, updated_at`
+
+func scanItem(scanner interface{ Scan(...any) error }) (*domainSendQueue.Item, error) {
+	var (
+		item           domainSendQueue.Item
+		idempotencyKey sql.NullString
+		payload, files string
+		scheduledAt    sql.NullTime
+		sentAt         sql.NullTime
+	)
+	err := scanner.Scan(&item.ID, &item.DeviceID, &idempotencyKey, &item.BatchID, &item.BatchIndex, &item.Kind, &item.Phone, &payload, &item.RequestHash,
+		&files, &item.Recurrence, &scheduledAt, &item.Status, &item.Attempts, &item.MessageID, &item.Error, &item.NextAttemptAt, &sentAt,
+		&item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	item.IdempotencyKey = idempotencyKey.String
+	item.Payload = []byte(payload)
+	if files != "" {
+		if err := json.Unmarshal([]byte(files), &item.Files); err != nil {
+			return nil, fmt.Errorf("invalid staged files of queued item %s: %w", item.ID, err)
+		}
+	}
+	if scheduledAt.Valid {
+		item.ScheduledAt = &scheduledAt.Time
+	}
+	if sentAt.Valid {
+		item.SentAt = &sentAt.Time
+	}
+	return &item, nil
+}
+
+func (r *Repository) queryItems(ctx context.Context, query string, args ...any) ([]*domainSendQueue.Item, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*domainSendQueue.Item
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) Enqueue(ctx context.Context, item *domainSendQueue.Item) (*domainSendQueue.Item, bool, error) {
+	files := ""
+	if len(item.Files) > 0 {
+		data, err := json.Marshal(item.Files)
+		if err != nil {
+			return nil, false, err
+		}
+		files = string(data)
+	}
+	idempotencyKey := sql.NullString{String: item.IdempotencyKey, Valid: item.IdempotencyKey != ""}
+	var scheduledAt sql.NullTime
+	if item.ScheduledAt != nil {
+		scheduledAt = sql.NullTime{Time: item.ScheduledAt.UTC(), Valid: true}
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO send_queue (`+itemColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		ON CONFLICT (device_id, idempotency_key) DO NOTHING
+	`, item.ID, item.DeviceID, idempotencyKey, item.BatchID, item.BatchIndex, item.Kind, item.Phone, string(item.Payload), item.RequestHash, files,
+		item.Recurrence, scheduledAt, item.Status, item.Attempts, item.MessageID, item.Error, item.NextAttemptAt.UTC(), nil,
+		item.CreatedAt.UTC(), item.UpdatedAt.UTC())
+	if err != nil {
+		return nil, false, err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 1 {
+		return item, err == nil, err
+	}
+
+	stored, err := scanItem(r.db.QueryRowContext(ctx, `
+		SELECT `+itemColumns+` FROM send_queue WHERE device_id = $1 AND idempotency_key = $2
+	`, item.DeviceID, item.IdempotencyKey))
+	if err != nil {
+		return nil, false, err
+	}
+	return stored, false, nil
+}
+
+func (r *Repository) GetItem(ctx context.Context, deviceID, id string) (*domainSendQueue.Item, error) {
+	item, err := scanItem(r.db.QueryRowContext(ctx, `
+		SELECT `+itemColumns+` FROM send_queue WHERE id = $1 AND device_id = $2
+	`, id, deviceID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return item, err
+}
+
+func (r *Repository) ListItems(ctx context.Context, deviceID string, request domainSendQueue.ListItemsRequest) ([]*domainSendQueue.Item, int, error) {
+	where := "device_id = $1"
+	args := []any{deviceID}
+	if request.Status != "" {
+		where += " AND status = $2"
+		args = append(args, request.Status)
+	}
+	if request.Scheduled {
+		where += " AND scheduled_at IS NOT NULL"
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM send_queue WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	n := len(args)
+	items, err := r.queryItems(ctx, fmt.Sprintf(`
+		SELECT %s FROM send_queue WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, itemColumns, where, n+1, n+2), append(args, request.Limit, request.Offset)...)
+	return items, total, err
+}
+
+func (r *Repository) GetBatchItems(ctx context.Context, deviceID, batchID string) ([]*domainSendQueue.Item, error) {
+	return r.queryItems(ctx, `
+		SELECT `+itemColumns+` FROM send_queue WHERE device_id = $1 AND batch_id = $2
+		ORDER BY batch_index
+	`, deviceID, batchID)
+}
+
+func (r *Repository) Cancel(ctx context.Context, deviceID, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE send_queue SET status = $1, updated_at = $2 WHERE id = $3 AND device_id = $4 AND status = $5
+	`, domainSendQueue.StatusCancelled, time.Now().UTC(), id, deviceID, domainSendQueue.StatusQueued)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r *Repository) Reschedule(ctx context.Context, deviceID, id, recurrence string, scheduledAt time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE send_queue SET recurrence = $1, scheduled_at = $2, next_attempt_at = $2, attempts = 0, error = '', updated_at = $3
+		WHERE id = $4 AND device_id = $5 AND status = $6
+	`, recurrence, scheduledAt.UTC(), time.Now().UTC(), id, deviceID, domainSendQueue.StatusQueued)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r *Repository) GetDueDeviceIDs(ctx context.Context, now time.Time) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT device_id FROM send_queue WHERE status = $1 AND next_attempt_at <= $2
+	`, domainSendQueue.StatusQueued, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deviceIDs []string
+	for rows.Next() {
+		var deviceID string
+		if err := rows.Scan(&deviceID); err != nil {
+			return nil, err
+		}
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	return deviceIDs, rows.Err()
+}
+
+func (r *Repository) ClaimNext(ctx context.Context, deviceID string, now time.Time) (*domainSendQueue.Item, error) {
+	item, err := scanItem(r.db.QueryRowContext(ctx, `
+		SELECT `+itemColumns+` FROM send_queue
+		WHERE device_id = $1 AND status = $2 AND next_attempt_at <= $3
+		ORDER BY next_attempt_at, created_at, batch_index, id
+		LIMIT 1
+	`, deviceID, domainSendQueue.StatusQueued, now.UTC()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Only one worker drains a device, the status check guards against anything else
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE send_queue SET status = $1, attempts = attempts + 1, updated_at = $2
+		WHERE id = $3 AND status = $4
+	`, domainSendQueue.StatusSending, now.UTC(), item.ID, domainSendQueue.StatusQueued)
+	if err != nil {
+		return nil, err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return nil, err
+	}
+
+	item.Status = domainSendQueue.StatusSending
+	item.Attempts++
+	item.UpdatedAt = now
+	return item, nil
+}
+
+func (r *Repository) MarkSent(ctx context.Context, id, messageID string, sentAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE send_queue SET status = $1, message_id = $2, error = '', sent_at = $3, updated_at = $3 WHERE id = $4
+	`, domainSendQueue.StatusSent, messageID, sentAt.UTC(), id)
+	return err
+}
+
+func (r *Repository) MarkFailed(ctx context.Context, id, errMsg string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE send_queue SET status = $1, error = $2, updated_at = $3 WHERE id = $4
+	`, domainSendQueue.StatusFailed, errMsg, time.Now().UTC(), id)
+	return err
+}
+
+func (r *Repository) Requeue(ctx context.Context, id, errMsg string, nextAttemptAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE send_queue SET status = $1, error = $2, next_attempt_at = $3, updated_at = $4 WHERE id = $5
+	`, domainSendQueue.StatusQueued, errMsg, nextAttemptAt.UTC(), time.Now().UTC(), id)
+	return err
+}
+
+func (r *Repository) Rearm(ctx context.Context, id string, scheduledAt time.Time) error {
+	// The outcome of the previous occurrence stays readable until the next one
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE send_queue SET status = $1, attempts = 0, scheduled_at = $2, next_attempt_at = $2, updated_at = $3 WHERE id = $4
+	`, domainSendQueue.StatusQueued, scheduledAt.UTC(), time.Now().UTC(), id)
+	return err
+}
+
+func (r *Repository) CountSentSince(ctx context.Context, deviceID string, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM send_queue WHERE device_id = $1 AND sent_at >= $2
+	`, deviceID, since.UTC()).Scan(&count)
+	return count, err
+}
+
+func (r *Repository) FailInterrupted(ctx context.Context, claimedBefore time.Time, errMsg string) ([]*domainSendQueue.Item, error) {
+	// ClaimNext stamps updated_at, and nothing else touches an item while it is sending
+	return r.failItems(ctx, domainSendQueue.StatusSending, "updated_at", claimedBefore, errMsg)
+}
+
+func (r *Repository) ExpireQueued(ctx context.Context, dueBefore time.Time, errMsg string) ([]*domainSendQueue.Item, error) {
+	return r.failItems(ctx, domainSendQueue.StatusQueued, "next_attempt_at", dueBefore, errMsg)
+}
+
+// failItems fails the items in status whose column is before before, and
+// returns them as they were before
+func (r *Repository) failItems(ctx context.Context, status domainSendQueue.Status, column string, before time.Time, errMsg string) ([]*domainSendQueue.Item, error) {
+	items, err := r.queryItems(ctx, `
+		SELECT `+itemColumns+` FROM send_queue WHERE status = $1 AND `+column+` < $2
+	`, status, before.UTC())
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+
+	failed := make([]*domainSendQueue.Item, 0, len(items))
+	for _, item := range items {
+		// The status check skips items the worker claimed in the meantime
+		result, err := r.db.ExecContext(ctx, `
+			UPDATE send_queue SET status = $1, error = $2, updated_at = $3 WHERE id = $4 AND status = $5 AND `+column+` < $6
+		`, domainSendQueue.StatusFailed, errMsg, time.Now().UTC(), item.ID, status, before.UTC())
+		if err != nil {
+			return failed, err
+		}
+		if affected, _ := result.RowsAffected(); affected == 1 {
+			failed = append(failed, item)
+		}
+	}
+	return failed, nil
+}
+
+func (r *Repository) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM send_queue WHERE status IN ($1, $2, $3) AND updated_at < $4
+	`, domainSendQueue.StatusSent, domainSendQueue.StatusFailed, domainSendQueue.StatusCancelled, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
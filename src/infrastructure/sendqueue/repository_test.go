@@ -0,0 +1,270 @@
+package sendqueue
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	domainSendQueue "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/sendqueue"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "queue.db")+"?_journal_mode=WAL")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	repo := NewRepository(db)
+	if err := repo.InitializeSchema(); err != nil {
+		t.Fatalf("InitializeSchema() error = %v", err)
+	}
+	// Migrations are idempotent
+	if err := repo.InitializeSchema(); err != nil {
+		t.Fatalf("InitializeSchema() second run error = %v", err)
+	}
+	return repo
+}
+
+func testItem(id, deviceID, key string, due time.Time) *domainSendQueue.Item {
+	return &domainSendQueue.Item{
+		ID:             id,
+		DeviceID:       deviceID,
+		IdempotencyKey: key,
+		Kind:           domainSendQueue.KindText,
+		Phone:          "6281234567890",
+		Payload:        []byte(`{"phone":"6281234567890","message":"hi"}`),
+		RequestHash:    "hash-" + id,
+		Status:         domainSendQueue.StatusQueued,
+		NextAttemptAt:  due,
+		CreatedAt:      due,
+		UpdatedAt:      due,
+	}
+}
+
+func TestRepositoryEnqueueAndClaim(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC)
+
+	first := testItem("a", "dev1", "key-1", now)
+	first.Files = []domainSendQueue.File{{Field: "image", Path: "/tmp/a/image", Filename: "photo.png", ContentType: "image/png"}}
+	if _, created, err := repo.Enqueue(ctx, first); err != nil || !created {
+		t.Fatalf("Enqueue(a) = %v, %v", created, err)
+	}
+
+	// The same key on the same device returns the stored item
+	stored, created, err := repo.Enqueue(ctx, testItem("b", "dev1", "key-1", now))
+	if err != nil || created || stored.ID != "a" || stored.RequestHash != "hash-a" {
+		t.Fatalf("Enqueue(duplicate) = %+v, %v, %v", stored, created, err)
+	}
+	// Items without a key never collide
+	for _, item := range []*domainSendQueue.Item{testItem("c", "dev1", "", now.Add(time.Second)), testItem("d", "dev1", "", now.Add(time.Hour)), testItem("e", "dev2", "key-1", now)} {
+		if _, created, err := repo.Enqueue(ctx, item); err != nil || !created {
+			t.Fatalf("Enqueue(%s) = %v, %v", item.ID, created, err)
+		}
+	}
+
+	got, err := repo.GetItem(ctx, "dev1", "a")
+	if err != nil || got == nil || len(got.Files) != 1 || got.Files[0].Filename != "photo.png" || string(got.Payload) != string(first.Payload) {
+		t.Fatalf("GetItem(a) = %+v, %v", got, err)
+	}
+	if other, err := repo.GetItem(ctx, "dev2", "a"); err != nil || other != nil {
+		t.Errorf("GetItem(other device) = %+v, %v", other, err)
+	}
+
+	deviceIDs, err := repo.GetDueDeviceIDs(ctx, now.Add(time.Minute))
+	if err != nil || len(deviceIDs) != 2 {
+		t.Errorf("GetDueDeviceIDs() = %v, %v", deviceIDs, err)
+	}
+
+	// Items are claimed oldest due first, the one due in an hour stays queued
+	later := now.Add(time.Minute)
+	var claimed []string
+	for {
+		item, err := repo.ClaimNext(ctx, "dev1", later)
+		if err != nil {
+			t.Fatalf("ClaimNext() error = %v", err)
+		}
+		if item == nil {
+			break
+		}
+		if item.Status != domainSendQueue.StatusSending || item.Attempts != 1 {
+			t.Errorf("claimed %+v", item)
+		}
+		claimed = append(claimed, item.ID)
+	}
+	if len(claimed) != 2 || claimed[0] != "a" || claimed[1] != "c" {
+		t.Fatalf("claimed = %v, want [a c]", claimed)
+	}
+
+	if err := repo.MarkSent(ctx, "a", "WAMSG1", later); err != nil {
+		t.Fatalf("MarkSent() error = %v", err)
+	}
+	if err := repo.Requeue(ctx, "c", "timeout", later.Add(30*time.Second)); err != nil {
+		t.Fatalf("Requeue() error = %v", err)
+	}
+	if sent, err := repo.CountSentSince(ctx, "dev1", now); err != nil || sent != 1 {
+		t.Errorf("CountSentSince() = %d, %v", sent, err)
+	}
+
+	retried, err := repo.ClaimNext(ctx, "dev1", later.Add(time.Minute))
+	if err != nil || retried == nil || retried.ID != "c" || retried.Attempts != 2 || retried.Error != "timeout" {
+		t.Fatalf("ClaimNext(retry) = %+v, %v", retried, err)
+	}
+
+	items, total, err := repo.ListItems(ctx, "dev1", domainSendQueue.ListItemsRequest{Status: domainSendQueue.StatusSent, Limit: 10})
+	if err != nil || total != 1 || len(items) != 1 || items[0].MessageID != "WAMSG1" || items[0].SentAt == nil {
+		t.Errorf("ListItems(sent) = %+v, %d, %v", items, total, err)
+	}
+	if _, total, _ := repo.ListItems(ctx, "dev1", domainSendQueue.ListItemsRequest{Limit: 1}); total != 3 {
+		t.Errorf("ListItems() total = %d, want 3", total)
+	}
+}
+
+func TestRepositoryFailAndCleanup(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC)
+
+	for _, item := range []*domainSendQueue.Item{testItem("a", "dev1", "", now), testItem("b", "dev1", "", now.Add(-2*time.Hour)), testItem("c", "dev1", "", now)} {
+		if _, _, err := repo.Enqueue(ctx, item); err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", item.ID, err)
+		}
+	}
+	if _, err := repo.ClaimNext(ctx, "dev1", now.Add(-time.Hour)); err != nil {
+		t.Fatalf("ClaimNext() error = %v", err)
+	}
+
+	// b was claimed an hour ago, a worker holding it for less time is left alone
+	interrupted, err := repo.FailInterrupted(ctx, now.Add(-2*time.Hour), "interrupted")
+	if err != nil || len(interrupted) != 0 {
+		t.Fatalf("FailInterrupted() before the lease = %+v, %v", interrupted, err)
+	}
+	interrupted, err = repo.FailInterrupted(ctx, now.Add(-30*time.Minute), "interrupted")
+	if err != nil || len(interrupted) != 1 || interrupted[0].ID != "b" {
+		t.Fatalf("FailInterrupted() = %+v, %v", interrupted, err)
+	}
+
+	expired, err := repo.ExpireQueued(ctx, now.Add(time.Second), "expired")
+	if err != nil || len(expired) != 2 {
+		t.Fatalf("ExpireQueued() = %+v, %v", expired, err)
+	}
+	item, _ := repo.GetItem(ctx, "dev1", "a")
+	if item.Status != domainSendQueue.StatusFailed || item.Error != "expired" {
+		t.Errorf("expired item = %+v", item)
+	}
+
+	deleted, err := repo.DeleteFinished(ctx, time.Now().Add(time.Minute))
+	if err != nil || deleted != 3 {
+		t.Errorf("DeleteFinished() = %d, %v", deleted, err)
+	}
+}
+
+func TestRepositorySchedule(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC)
+
+	tomorrow := now.AddDate(0, 0, 1)
+	scheduled := testItem("a", "dev1", "", tomorrow)
+	scheduled.ScheduledAt = &tomorrow
+	scheduled.Recurrence = "daily"
+	for _, item := range []*domainSendQueue.Item{scheduled, testItem("b", "dev1", "", now)} {
+		if _, _, err := repo.Enqueue(ctx, item); err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", item.ID, err)
+		}
+	}
+
+	items, total, err := repo.ListItems(ctx, "dev1", domainSendQueue.ListItemsRequest{Scheduled: true, Limit: 10})
+	if err != nil || total != 1 || items[0].ID != "a" || items[0].Recurrence != "daily" || !items[0].ScheduledAt.Equal(tomorrow) {
+		t.Fatalf("ListItems(scheduled) = %+v, %d, %v", items, total, err)
+	}
+	if item, _ := repo.ClaimNext(ctx, "dev1", now); item == nil || item.ID != "b" {
+		t.Errorf("ClaimNext() = %+v, want b while a waits for tomorrow", item)
+	}
+
+	// Only queued items can be rescheduled or cancelled
+	later := now.Add(2 * time.Hour)
+	if ok, err := repo.Reschedule(ctx, "dev1", "a", "", later); err != nil || !ok {
+		t.Fatalf("Reschedule(a) = %v, %v", ok, err)
+	}
+	if ok, _ := repo.Reschedule(ctx, "dev1", "b", "", later); ok {
+		t.Errorf("Reschedule(sending item) succeeded")
+	}
+	item, _ := repo.GetItem(ctx, "dev1", "a")
+	if !item.NextAttemptAt.Equal(later) || item.Recurrence != "" {
+		t.Errorf("rescheduled item = %+v", item)
+	}
+
+	// A recurring item goes back to queued after it was sent
+	if err := repo.MarkSent(ctx, "b", "WAMSG1", now); err != nil {
+		t.Fatalf("MarkSent() error = %v", err)
+	}
+	if err := repo.Rearm(ctx, "b", tomorrow); err != nil {
+		t.Fatalf("Rearm() error = %v", err)
+	}
+	item, _ = repo.GetItem(ctx, "dev1", "b")
+	if item.Status != domainSendQueue.StatusQueued || item.Attempts != 0 || !item.NextAttemptAt.Equal(tomorrow) || item.MessageID != "WAMSG1" {
+		t.Errorf("rearmed item = %+v", item)
+	}
+	if sent, _ := repo.CountSentSince(ctx, "dev1", now.Add(-time.Minute)); sent != 1 {
+		t.Errorf("CountSentSince() = %d, want the rearmed item counted", sent)
+	}
+
+	if ok, err := repo.Cancel(ctx, "dev1", "a"); err != nil || !ok {
+		t.Fatalf("Cancel(a) = %v, %v", ok, err)
+	}
+	if ok, _ := repo.Cancel(ctx, "dev1", "a"); ok {
+		t.Errorf("Cancel(cancelled item) succeeded")
+	}
+	if deleted, _ := repo.DeleteFinished(ctx, time.Now().Add(time.Minute)); deleted != 1 {
+		t.Errorf("DeleteFinished() = %d, want the cancelled item deleted", deleted)
+	}
+}
+
+func TestRepositoryBatch(t *testing.T) {
+	repo := newTestRepository(t)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC)
+
+	// Batch items due at the same time are claimed in recipient order
+	for i, id := range []string{"z", "y", "x"} {
+		item := testItem(id, "dev1", "", now)
+		item.BatchID = "batch-1"
+		item.BatchIndex = i
+		if _, _, err := repo.Enqueue(ctx, item); err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", id, err)
+		}
+	}
+	if _, _, err := repo.Enqueue(ctx, testItem("w", "dev1", "", now)); err != nil {
+		t.Fatalf("Enqueue(w) error = %v", err)
+	}
+
+	items, err := repo.GetBatchItems(ctx, "dev1", "batch-1")
+	if err != nil || len(items) != 3 || items[0].ID != "z" || items[2].ID != "x" || items[1].BatchIndex != 1 {
+		t.Fatalf("GetBatchItems() = %+v, %v", items, err)
+	}
+	if items, err := repo.GetBatchItems(ctx, "dev2", "batch-1"); err != nil || len(items) != 0 {
+		t.Errorf("GetBatchItems(other device) = %+v, %v", items, err)
+	}
+
+	var claimed []string
+	for {
+		item, err := repo.ClaimNext(ctx, "dev1", now)
+		if err != nil {
+			t.Fatalf("ClaimNext() error = %v", err)
+		}
+		if item == nil {
+			break
+		}
+		claimed = append(claimed, item.ID)
+	}
+	if len(claimed) != 4 || claimed[1] != "z" || claimed[2] != "y" || claimed[3] != "x" {
+		t.Errorf("claimed = %v, want [w z y x]", claimed)
+	}
+}
@@ -86,6 +86,13 @@ func (r *deviceChatStorage) GetMessages(filter *domainChatStorage.MessageFilter)
 	return r.base.GetMessages(filter)
 }
 
+func (r *deviceChatStorage) IterateMessages(filter *domainChatStorage.MessageFilter, fn func(*domainChatStorage.Message) error) error {
+	if filter != nil && filter.DeviceID == "" {
+		filter.DeviceID = r.deviceID
+	}
+	return r.base.IterateMessages(filter, fn)
+}
+
 func (r *deviceChatStorage) SearchMessages(deviceID, chatJID, searchText string, limit int) ([]*domainChatStorage.Message, error) {
 	targetDeviceID := deviceID
 	if targetDeviceID == "" {
@@ -94,6 +101,13 @@ func (r *deviceChatStorage) SearchMessages(deviceID, chatJID, searchText string,
 	return r.base.SearchMessages(targetDeviceID, chatJID, searchText, limit)
 }
 
+func (r *deviceChatStorage) SearchMessagesFullText(filter *domainChatStorage.MessageSearchFilter) ([]*domainChatStorage.MessageSearchResult, int64, error) {
+	if filter != nil && filter.DeviceID == "" {
+		filter.DeviceID = r.deviceID
+	}
+	return r.base.SearchMessagesFullText(filter)
+}
+
 func (r *deviceChatStorage) DeleteMessage(id, chatJID string) error {
 	return r.base.DeleteMessageByDevice(r.deviceID, id, chatJID)
 }
@@ -106,6 +120,102 @@ func (r *deviceChatStorage) StoreSentMessageWithContext(ctx context.Context, mes
 	return r.base.StoreSentMessageWithContext(ctx, messageID, senderJID, recipientJID, content, timestamp)
 }
 
+// withDevice falls back to the wrapped device when deviceID is empty
+func (r *deviceChatStorage) withDevice(deviceID string) string {
+	if deviceID == "" {
+		return r.deviceID
+	}
+	return deviceID
+}
+
+func (r *deviceChatStorage) ApplyMessageEdit(deviceID, chatJID, messageID, content string, editedAt time.Time) error {
+	return r.base.ApplyMessageEdit(r.withDevice(deviceID), chatJID, messageID, content, editedAt)
+}
+
+func (r *deviceChatStorage) MarkMessageRevoked(deviceID, chatJID, messageID string, revokedAt time.Time) error {
+	return r.base.MarkMessageRevoked(r.withDevice(deviceID), chatJID, messageID, revokedAt)
+}
+
+func (r *deviceChatStorage) StoreReaction(reaction *domainChatStorage.MessageReaction) error {
+	if reaction != nil {
+		reaction.DeviceID = r.withDevice(reaction.DeviceID)
+	}
+	return r.base.StoreReaction(reaction)
+}
+
+func (r *deviceChatStorage) UpdateMessageStatus(deviceID, chatJID string, messageIDs []string, status string) error {
+	return r.base.UpdateMessageStatus(r.withDevice(deviceID), chatJID, messageIDs, status)
+}
+
+func (r *deviceChatStorage) GetMessageReactions(deviceID, chatJID string, messageIDs []string) ([]*domainChatStorage.MessageReaction, error) {
+	return r.base.GetMessageReactions(r.withDevice(deviceID), chatJID, messageIDs)
+}
+
+func (r *deviceChatStorage) GetMessageEdits(deviceID, chatJID string, messageIDs []string) ([]*domainChatStorage.MessageEdit, error) {
+	return r.base.GetMessageEdits(r.withDevice(deviceID), chatJID, messageIDs)
+}
+
+func (r *deviceChatStorage) SetMessageStarred(deviceID, chatJID, messageID string, starred bool) error {
+	return r.base.SetMessageStarred(r.withDevice(deviceID), chatJID, messageID, starred)
+}
+
+func (r *deviceChatStorage) SetMessageMediaPath(deviceID, chatJID, messageID, mediaPath string) error {
+	return r.base.SetMessageMediaPath(r.withDevice(deviceID), chatJID, messageID, mediaPath)
+}
+
+func (r *deviceChatStorage) UpdateChatMetadata(deviceID, chatJID string, update domainChatStorage.ChatMetadataUpdate) error {
+	return r.base.UpdateChatMetadata(r.withDevice(deviceID), chatJID, update)
+}
+
+func (r *deviceChatStorage) StoreContact(contact *domainChatStorage.Contact) error {
+	if contact != nil {
+		contact.DeviceID = r.withDevice(contact.DeviceID)
+	}
+	return r.base.StoreContact(contact)
+}
+
+func (r *deviceChatStorage) SetGroupParticipants(deviceID, groupJID string, participants []*domainChatStorage.GroupParticipant) error {
+	return r.base.SetGroupParticipants(r.withDevice(deviceID), groupJID, participants)
+}
+
+func (r *deviceChatStorage) ApplyGroupParticipantChange(deviceID, groupJID string, change domainChatStorage.GroupParticipantChange) error {
+	return r.base.ApplyGroupParticipantChange(r.withDevice(deviceID), groupJID, change)
+}
+
+func (r *deviceChatStorage) GetGroupParticipants(deviceID, groupJID string) ([]*domainChatStorage.GroupParticipant, error) {
+	return r.base.GetGroupParticipants(r.withDevice(deviceID), groupJID)
+}
+
+func (r *deviceChatStorage) GetMessageChanges(filter *domainChatStorage.MessageChangeFilter) (*domainChatStorage.MessageChanges, error) {
+	if filter != nil && filter.DeviceID == "" {
+		filter.DeviceID = r.deviceID
+	}
+	return r.base.GetMessageChanges(filter)
+}
+
+func (r *deviceChatStorage) PruneMessageDeletions(olderThan time.Time) (int64, error) {
+	return r.base.PruneMessageDeletions(olderThan)
+}
+
+func (r *deviceChatStorage) ListMessageDeviceIDs() ([]string, error) {
+	return r.base.ListMessageDeviceIDs()
+}
+
+func (r *deviceChatStorage) PreviewPrune(policy domainChatStorage.RetentionPolicy) (*domainChatStorage.PrunePreview, error) {
+	policy.DeviceID = r.withDevice(policy.DeviceID)
+	return r.base.PreviewPrune(policy)
+}
+
+func (r *deviceChatStorage) PruneMessages(policy domainChatStorage.RetentionPolicy, limit int) (int64, error) {
+	policy.DeviceID = r.withDevice(policy.DeviceID)
+	return r.base.PruneMessages(policy, limit)
+}
+
+// ReencryptMessages covers every device, encryption keys are not per device
+func (r *deviceChatStorage) ReencryptMessages(batchSize int) (int64, error) {
+	return r.base.ReencryptMessages(batchSize)
+}
+
 func (r *deviceChatStorage) GetChatMessageCount(chatJID string) (int64, error) {
 	return r.base.GetChatMessageCountByDevice(r.deviceID, chatJID)
 }
@@ -130,6 +240,13 @@ func (r *deviceChatStorage) GetChatNameWithPushNameByDevice(deviceID string, jid
 	return r.base.GetChatNameWithPushNameByDevice(deviceID, jid, chatJID, senderUser, pushName)
 }
 
+func (r *deviceChatStorage) GetMessageAnalytics(filter *domainChatStorage.AnalyticsFilter) (*domainChatStorage.MessageAnalytics, error) {
+	if filter != nil && filter.DeviceID == "" {
+		filter.DeviceID = r.deviceID
+	}
+	return r.base.GetMessageAnalytics(filter)
+}
+
 func (r *deviceChatStorage) GetStorageStatistics() (chatCount int64, messageCount int64, err error) {
 	return r.base.GetStorageStatistics()
 }
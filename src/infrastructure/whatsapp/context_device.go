@@ -40,3 +40,19 @@ func ClientFromContext(ctx context.Context) *whatsmeow.Client {
 	// No device in context - fall back to global client for backward compatibility
 	return GetClient()
 }
+
+// StorageDeviceIDFromContext resolves the device id chat storage rows are keyed by:
+// the device JID from context, then the instance id, then the client's own JID.
+func StorageDeviceIDFromContext(ctx context.Context, client *whatsmeow.Client) string {
+	deviceID := ""
+	if inst, ok := DeviceFromContext(ctx); ok && inst != nil {
+		deviceID = inst.JID()
+		if deviceID == "" {
+			deviceID = inst.ID()
+		}
+	}
+	if deviceID == "" && client != nil && client.Store != nil && client.Store.ID != nil {
+		deviceID = client.Store.ID.ToNonAD().String()
+	}
+	return deviceID
+}
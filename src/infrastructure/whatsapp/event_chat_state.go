@@ -0,0 +1,93 @@
+package whatsapp
+
+import (
+	"context"
+	"time"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Chat list state and contact names arrive as app state events whenever they
+// change on another device, and all at once on a full app state sync. They are
+// kept in chat storage so chats can be listed and filtered without asking WhatsApp.
+
+// storeChatMetadata applies a chat list state change to the stored chat
+func storeChatMetadata(ctx context.Context, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client, jid types.JID, update domainChatStorage.ChatMetadataUpdate) {
+	if chatStorageRepo == nil || jid.IsEmpty() {
+		return
+	}
+
+	chatJID := NormalizeJIDFromLID(ctx, jid, client).String()
+	deviceID := StorageDeviceIDFromContext(ctx, client)
+	if err := chatStorageRepo.UpdateChatMetadata(deviceID, chatJID, update); err != nil {
+		log.Errorf("Failed to store chat state of %s: %v", chatJID, err)
+	}
+}
+
+func handleArchive(ctx context.Context, evt *events.Archive, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
+	archived := evt.Action.GetArchived()
+	storeChatMetadata(ctx, chatStorageRepo, client, evt.JID, domainChatStorage.ChatMetadataUpdate{Archived: &archived})
+}
+
+func handlePin(ctx context.Context, evt *events.Pin, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
+	pinned := evt.Action.GetPinned()
+	storeChatMetadata(ctx, chatStorageRepo, client, evt.JID, domainChatStorage.ChatMetadataUpdate{Pinned: &pinned})
+}
+
+func handleMute(ctx context.Context, evt *events.Mute, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
+	mutedUntil := chatMutedUntil(evt.Action.GetMuted(), evt.Action.GetMuteEndTimestamp())
+	storeChatMetadata(ctx, chatStorageRepo, client, evt.JID, domainChatStorage.ChatMetadataUpdate{MutedUntil: &mutedUntil})
+}
+
+// chatMutedUntil converts a mute end in Unix milliseconds, negative for no end.
+// The zero time means not muted.
+func chatMutedUntil(muted bool, endMillis int64) time.Time {
+	switch {
+	case !muted:
+		return time.Time{}
+	case endMillis < 0:
+		return domainChatStorage.ChatMutedForever
+	default:
+		return time.UnixMilli(endMillis)
+	}
+}
+
+func handleMarkChatAsRead(ctx context.Context, evt *events.MarkChatAsRead, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
+	update := domainChatStorage.ChatReadUpdate()
+	if !evt.Action.GetRead() {
+		markedUnread := true
+		update = domainChatStorage.ChatMetadataUpdate{MarkedUnread: &markedUnread}
+	}
+	storeChatMetadata(ctx, chatStorageRepo, client, evt.JID, update)
+}
+
+// storeContactName merges a name learned from app state or a message into the stored contact
+func storeContactName(ctx context.Context, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client, jid types.JID, contact domainChatStorage.Contact) {
+	if chatStorageRepo == nil || jid.IsEmpty() {
+		return
+	}
+
+	contact.DeviceID = StorageDeviceIDFromContext(ctx, client)
+	contact.JID = NormalizeJIDFromLID(ctx, jid, client).ToNonAD().String()
+	if err := chatStorageRepo.StoreContact(&contact); err != nil {
+		log.Errorf("Failed to store contact %s: %v", contact.JID, err)
+	}
+}
+
+func handleContact(ctx context.Context, evt *events.Contact, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
+	storeContactName(ctx, chatStorageRepo, client, evt.JID, domainChatStorage.Contact{
+		FullName:  evt.Action.GetFullName(),
+		FirstName: evt.Action.GetFirstName(),
+	})
+}
+
+func handlePushName(ctx context.Context, evt *events.PushName, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
+	storeContactName(ctx, chatStorageRepo, client, evt.JID, domainChatStorage.Contact{PushName: evt.NewPushName})
+}
+
+func handleBusinessName(ctx context.Context, evt *events.BusinessName, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
+	storeContactName(ctx, chatStorageRepo, client, evt.JID, domainChatStorage.Contact{BusinessName: evt.NewBusinessName})
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
 	"github.com/sirupsen/logrus"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
@@ -81,9 +82,11 @@ func forwardGroupInfoToWebhook(ctx context.Context, evt *events.GroupInfo, devic
 }
 
 // handleJoinedGroup handles the event when the connected device is added to a new group
-func handleJoinedGroup(ctx context.Context, evt *events.JoinedGroup, deviceID string, client *whatsmeow.Client) {
+func handleJoinedGroup(ctx context.Context, evt *events.JoinedGroup, deviceID string, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
 	log.Infof("Joined group %s (reason: %s, type: %s)", evt.JID, evt.Reason, evt.Type)
 
+	storeJoinedGroup(ctx, evt, chatStorageRepo, client)
+
 	if len(config.WhatsappWebhook) > 0 {
 		go func(e *events.JoinedGroup, c *whatsmeow.Client) {
 			webhookCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -126,3 +129,67 @@ func forwardJoinedGroupToWebhook(ctx context.Context, evt *events.JoinedGroup, d
 
 	return forwardPayloadToConfiguredWebhooks(ctx, body, "group.joined")
 }
+
+// storeGroupInfo keeps the stored group name and members in sync with a group change
+func storeGroupInfo(ctx context.Context, evt *events.GroupInfo, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
+	if chatStorageRepo == nil {
+		return
+	}
+
+	if evt.Name != nil {
+		storeChatMetadata(ctx, chatStorageRepo, client, evt.JID, domainChatStorage.ChatMetadataUpdate{Name: &evt.Name.Name})
+	}
+
+	normalize := func(jids []types.JID) []string {
+		normalized := make([]string, 0, len(jids))
+		for _, jid := range jids {
+			normalized = append(normalized, NormalizeJIDFromLID(ctx, jid, client).ToNonAD().String())
+		}
+		return normalized
+	}
+	change := domainChatStorage.GroupParticipantChange{
+		Join:    normalize(evt.Join),
+		Leave:   normalize(evt.Leave),
+		Promote: normalize(evt.Promote),
+		Demote:  normalize(evt.Demote),
+	}
+	if len(change.Join)+len(change.Leave)+len(change.Promote)+len(change.Demote) == 0 {
+		return
+	}
+
+	deviceID := StorageDeviceIDFromContext(ctx, client)
+	groupJID := evt.JID.ToNonAD().String()
+	if err := chatStorageRepo.ApplyGroupParticipantChange(deviceID, groupJID, change); err != nil {
+		log.Errorf("Failed to store member changes of group %s: %v", groupJID, err)
+	}
+}
+
+// storeJoinedGroup stores the name and members of a group the device was added to
+func storeJoinedGroup(ctx context.Context, evt *events.JoinedGroup, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
+	if chatStorageRepo == nil {
+		return
+	}
+
+	if evt.GroupName.Name != "" {
+		storeChatMetadata(ctx, chatStorageRepo, client, evt.JID, domainChatStorage.ChatMetadataUpdate{Name: &evt.GroupName.Name})
+	}
+
+	participants := make([]*domainChatStorage.GroupParticipant, 0, len(evt.Participants))
+	for _, participant := range evt.Participants {
+		jid := participant.PhoneNumber
+		if jid.IsEmpty() {
+			jid = NormalizeJIDFromLID(ctx, participant.JID, client)
+		}
+		participants = append(participants, &domainChatStorage.GroupParticipant{
+			JID:          jid.ToNonAD().String(),
+			IsAdmin:      participant.IsAdmin || participant.IsSuperAdmin,
+			IsSuperAdmin: participant.IsSuperAdmin,
+		})
+	}
+
+	deviceID := StorageDeviceIDFromContext(ctx, client)
+	groupJID := evt.JID.ToNonAD().String()
+	if err := chatStorageRepo.SetGroupParticipants(deviceID, groupJID, participants); err != nil {
+		log.Errorf("Failed to store members of group %s: %v", groupJID, err)
+	}
+}
@@ -33,6 +33,8 @@ func handler(ctx context.Context, instance *DeviceInstance, rawEvt any) {
 	switch evt := rawEvt.(type) {
 	case *events.DeleteForMe:
 		handleDeleteForMe(ctx, evt, chatStorageRepo, instance.JID(), client)
+	case *events.Star:
+		handleStar(ctx, evt, chatStorageRepo, client)
 	case *events.AppStateSyncComplete:
 		handleAppStateSyncComplete(ctx, client, evt)
 	case *events.PairSuccess:
@@ -46,17 +48,31 @@ func handler(ctx context.Context, instance *DeviceInstance, rawEvt any) {
 	case *events.Message:
 		handleMessage(ctx, evt, chatStorageRepo, client)
 	case *events.Receipt:
-		handleReceipt(ctx, evt, instance.JID(), client)
+		handleReceipt(ctx, evt, instance.JID(), chatStorageRepo, client)
 	case *events.Presence:
 		handlePresence(ctx, evt)
 	case *events.HistorySync:
 		handleHistorySync(ctx, evt, chatStorageRepo, client)
 	case *events.AppState:
 		handleAppState(ctx, evt)
+	case *events.Archive:
+		handleArchive(ctx, evt, chatStorageRepo, client)
+	case *events.Pin:
+		handlePin(ctx, evt, chatStorageRepo, client)
+	case *events.Mute:
+		handleMute(ctx, evt, chatStorageRepo, client)
+	case *events.MarkChatAsRead:
+		handleMarkChatAsRead(ctx, evt, chatStorageRepo, client)
+	case *events.Contact:
+		handleContact(ctx, evt, chatStorageRepo, client)
+	case *events.PushName:
+		handlePushName(ctx, evt, chatStorageRepo, client)
+	case *events.BusinessName:
+		handleBusinessName(ctx, evt, chatStorageRepo, client)
 	case *events.GroupInfo:
-		handleGroupInfo(ctx, evt, instance.JID(), client)
+		handleGroupInfo(ctx, evt, instance.JID(), chatStorageRepo, client)
 	case *events.JoinedGroup:
-		handleJoinedGroup(ctx, evt, instance.JID(), client)
+		handleJoinedGroup(ctx, evt, instance.JID(), chatStorageRepo, client)
 	case *events.NewsletterJoin:
 		handleNewsletterJoin(ctx, evt, instance.JID(), client)
 	case *events.NewsletterLeave:
@@ -104,6 +120,20 @@ func handleDeleteForMe(ctx context.Context, evt *events.DeleteForMe, chatStorage
 	}
 }
 
+// handleStar keeps the starred flag of stored messages in sync, since starred
+// messages are exempt from retention pruning
+func handleStar(ctx context.Context, evt *events.Star, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
+	if chatStorageRepo == nil || evt.Action == nil {
+		return
+	}
+
+	chatJID := NormalizeJIDFromLID(ctx, evt.ChatJID, client).String()
+	deviceID := StorageDeviceIDFromContext(ctx, client)
+	if err := chatStorageRepo.SetMessageStarred(deviceID, chatJID, evt.MessageID, evt.Action.GetStarred()); err != nil {
+		log.Errorf("Failed to store star state of message %s: %v", evt.MessageID, err)
+	}
+}
+
 func handleAppStateSyncComplete(_ context.Context, client *whatsmeow.Client, evt *events.AppStateSyncComplete) {
 	if client == nil {
 		return
@@ -192,7 +222,9 @@ func handleStreamReplaced(_ context.Context) {
 	os.Exit(0)
 }
 
-func handleReceipt(ctx context.Context, evt *events.Receipt, deviceID string, client *whatsmeow.Client) {
+func handleReceipt(ctx context.Context, evt *events.Receipt, deviceID string, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
+	storeReceiptStatus(ctx, evt, chatStorageRepo, client)
+
 	sendReceipt := false
 	switch evt.Type {
 	case types.ReceiptTypeRead, types.ReceiptTypeReadSelf:
@@ -232,7 +264,7 @@ func handleAppState(_ context.Context, evt *events.AppState) {
 	log.Debugf("App state event: %+v / %+v", evt.Index, evt.SyncActionValue)
 }
 
-func handleGroupInfo(ctx context.Context, evt *events.GroupInfo, deviceID string, client *whatsmeow.Client) {
+func handleGroupInfo(ctx context.Context, evt *events.GroupInfo, deviceID string, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
 	// Only process events that have actual changes
 	hasChanges := len(evt.Join) > 0 || len(evt.Leave) > 0 || len(evt.Promote) > 0 || len(evt.Demote) > 0 ||
 		evt.Name != nil || evt.Topic != nil || evt.Locked != nil || evt.Announce != nil
@@ -241,6 +273,8 @@ func handleGroupInfo(ctx context.Context, evt *events.GroupInfo, deviceID string
 		return
 	}
 
+	storeGroupInfo(ctx, evt, chatStorageRepo, client)
+
 	// Log group events for debugging
 	if len(evt.Join) > 0 {
 		log.Infof("Group %s: %d users joined at %s", evt.JID, len(evt.Join), evt.Timestamp)
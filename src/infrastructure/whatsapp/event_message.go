@@ -214,15 +214,23 @@ func buildOptionalFields(ctx context.Context, client *whatsmeow.Client, evt *eve
 	return nil
 }
 
+// storeWebhookMediaPath links media downloaded for the webhook to its stored message
+func storeWebhookMediaPath(ctx context.Context, client *whatsmeow.Client, evt *events.Message, mediaPath string) {
+	if inst, ok := DeviceFromContext(ctx); ok && inst != nil {
+		storeMediaPath(ctx, inst.GetChatStorage(), client, evt, mediaPath)
+	}
+}
+
 func buildMediaFields(ctx context.Context, client *whatsmeow.Client, evt *events.Message, payload map[string]any) error {
 	if audioMedia := evt.Message.GetAudioMessage(); audioMedia != nil {
 		if config.WhatsappAutoDownloadMedia {
-			path, err := utils.ExtractMedia(ctx, client, config.PathMedia, audioMedia)
+			path, err := extractMessageMedia(ctx, client, evt, audioMedia)
 			if err != nil {
 				logrus.Errorf("Failed to download audio from %s: %v", evt.Info.SourceString(), err)
 				return pkgError.WebhookError(fmt.Sprintf("Failed to download audio: %v", err))
 			}
-			payload["audio"] = path
+			payload["audio"] = withMediaURL(ctx, path)
+			storeWebhookMediaPath(ctx, client, evt, path.MediaPath)
 		} else {
 			payload["audio"] = map[string]any{
 				"url": audioMedia.GetURL(),
@@ -232,12 +240,13 @@ func buildMediaFields(ctx context.Context, client *whatsmeow.Client, evt *events
 
 	if documentMedia := evt.Message.GetDocumentMessage(); documentMedia != nil {
 		if config.WhatsappAutoDownloadMedia {
-			path, err := utils.ExtractMedia(ctx, client, config.PathMedia, documentMedia)
+			path, err := extractMessageMedia(ctx, client, evt, documentMedia)
 			if err != nil {
 				logrus.Errorf("Failed to download document from %s: %v", evt.Info.SourceString(), err)
 				return pkgError.WebhookError(fmt.Sprintf("Failed to download document: %v", err))
 			}
-			payload["document"] = path
+			payload["document"] = withMediaURL(ctx, path)
+			storeWebhookMediaPath(ctx, client, evt, path.MediaPath)
 		} else {
 			payload["document"] = map[string]any{
 				"url":      documentMedia.GetURL(),
@@ -248,12 +257,13 @@ func buildMediaFields(ctx context.Context, client *whatsmeow.Client, evt *events
 
 	if imageMedia := evt.Message.GetImageMessage(); imageMedia != nil {
 		if config.WhatsappAutoDownloadMedia {
-			path, err := utils.ExtractMedia(ctx, client, config.PathMedia, imageMedia)
+			path, err := extractMessageMedia(ctx, client, evt, imageMedia)
 			if err != nil {
 				logrus.Errorf("Failed to download image from %s: %v", evt.Info.SourceString(), err)
 				return pkgError.WebhookError(fmt.Sprintf("Failed to download image: %v", err))
 			}
-			payload["image"] = path
+			payload["image"] = withMediaURL(ctx, path)
+			storeWebhookMediaPath(ctx, client, evt, path.MediaPath)
 		} else {
 			payload["image"] = map[string]any{
 				"url":     imageMedia.GetURL(),
@@ -264,12 +274,13 @@ func buildMediaFields(ctx context.Context, client *whatsmeow.Client, evt *events
 
 	if stickerMedia := evt.Message.GetStickerMessage(); stickerMedia != nil {
 		if config.WhatsappAutoDownloadMedia {
-			path, err := utils.ExtractMedia(ctx, client, config.PathMedia, stickerMedia)
+			path, err := extractMessageMedia(ctx, client, evt, stickerMedia)
 			if err != nil {
 				logrus.Errorf("Failed to download sticker from %s: %v", evt.Info.SourceString(), err)
 				return pkgError.WebhookError(fmt.Sprintf("Failed to download sticker: %v", err))
 			}
-			payload["sticker"] = path
+			payload["sticker"] = withMediaURL(ctx, path)
+			storeWebhookMediaPath(ctx, client, evt, path.MediaPath)
 		} else {
 			payload["sticker"] = map[string]any{
 				"url": stickerMedia.GetURL(),
@@ -279,12 +290,13 @@ func buildMediaFields(ctx context.Context, client *whatsmeow.Client, evt *events
 
 	if videoMedia := evt.Message.GetVideoMessage(); videoMedia != nil {
 		if config.WhatsappAutoDownloadMedia {
-			path, err := utils.ExtractMedia(ctx, client, config.PathMedia, videoMedia)
+			path, err := extractMessageMedia(ctx, client, evt, videoMedia)
 			if err != nil {
 				logrus.Errorf("Failed to download video from %s: %v", evt.Info.SourceString(), err)
 				return pkgError.WebhookError(fmt.Sprintf("Failed to download video: %v", err))
 			}
-			payload["video"] = path
+			payload["video"] = withMediaURL(ctx, path)
+			storeWebhookMediaPath(ctx, client, evt, path.MediaPath)
 		} else {
 			payload["video"] = map[string]any{
 				"url":     videoMedia.GetURL(),
@@ -295,12 +307,13 @@ func buildMediaFields(ctx context.Context, client *whatsmeow.Client, evt *events
 
 	if ptvMedia := evt.Message.GetPtvMessage(); ptvMedia != nil {
 		if config.WhatsappAutoDownloadMedia {
-			path, err := utils.ExtractMedia(ctx, client, config.PathMedia, ptvMedia)
+			path, err := extractMessageMedia(ctx, client, evt, ptvMedia)
 			if err != nil {
 				logrus.Errorf("Failed to download video note from %s: %v", evt.Info.SourceString(), err)
 				return pkgError.WebhookError(fmt.Sprintf("Failed to download video note: %v", err))
 			}
-			payload["video_note"] = path
+			payload["video_note"] = withMediaURL(ctx, path)
+			storeWebhookMediaPath(ctx, client, evt, path.MediaPath)
 		} else {
 			payload["video_note"] = map[string]any{
 				"url":     ptvMedia.GetURL(),
@@ -321,6 +334,31 @@ func buildOtherMessageTypes(evt *events.Message, payload map[string]any) {
 		payload["list"] = listMessage
 	}
 
+	buildInteractiveResponses(evt, payload)
+
+	if eventMessage := evt.Message.GetEventMessage(); eventMessage != nil {
+		event := map[string]any{
+			"name":       eventMessage.GetName(),
+			"start_time": time.Unix(eventMessage.GetStartTime(), 0).UTC().Format(time.RFC3339),
+		}
+		if eventMessage.GetDescription() != "" {
+			event["description"] = eventMessage.GetDescription()
+		}
+		if eventMessage.EndTime != nil {
+			event["end_time"] = time.Unix(eventMessage.GetEndTime(), 0).UTC().Format(time.RFC3339)
+		}
+		if location := eventMessage.GetLocation().GetName(); location != "" {
+			event["location"] = location
+		}
+		if eventMessage.GetJoinLink() != "" {
+			event["join_link"] = eventMessage.GetJoinLink()
+		}
+		if eventMessage.GetIsCanceled() {
+			event["canceled"] = true
+		}
+		payload["event"] = event
+	}
+
 	if liveLocationMessage := evt.Message.GetLiveLocationMessage(); liveLocationMessage != nil {
 		payload["live_location"] = liveLocationMessage
 	}
@@ -333,3 +371,51 @@ func buildOtherMessageTypes(evt *events.Message, payload map[string]any) {
 		payload["order"] = orderMessage
 	}
 }
+
+// buildInteractiveResponses adds the choice made on a buttons, list or template
+// message: the ID given when it was sent, the text shown for it and the message
+// it answers. The text also becomes the body when the message has none.
+func buildInteractiveResponses(evt *events.Message, payload map[string]any) {
+	var (
+		field     string
+		response  map[string]any
+		text      string
+		contextID string
+	)
+	if buttonsResponse := evt.Message.GetButtonsResponseMessage(); buttonsResponse != nil {
+		field, text, contextID = "buttons_response", buttonsResponse.GetSelectedDisplayText(), buttonsResponse.GetContextInfo().GetStanzaID()
+		response = map[string]any{
+			"selected_id":   buttonsResponse.GetSelectedButtonID(),
+			"selected_text": text,
+		}
+	} else if listResponse := evt.Message.GetListResponseMessage(); listResponse != nil {
+		field, text, contextID = "list_response", listResponse.GetTitle(), listResponse.GetContextInfo().GetStanzaID()
+		response = map[string]any{
+			"selected_id":   listResponse.GetSingleSelectReply().GetSelectedRowID(),
+			"selected_text": text,
+		}
+		if listResponse.GetDescription() != "" {
+			response["selected_description"] = listResponse.GetDescription()
+		}
+	} else if templateReply := evt.Message.GetTemplateButtonReplyMessage(); templateReply != nil {
+		field, text, contextID = "template_button_reply", templateReply.GetSelectedDisplayText(), templateReply.GetContextInfo().GetStanzaID()
+		response = map[string]any{
+			"selected_id":    templateReply.GetSelectedID(),
+			"selected_text":  text,
+			"selected_index": templateReply.GetSelectedIndex(),
+		}
+	} else {
+		return
+	}
+
+	if contextID != "" {
+		response["message_id"] = contextID
+		if _, ok := payload["replied_to_id"]; !ok {
+			payload["replied_to_id"] = contextID
+		}
+	}
+	payload[field] = response
+	if _, ok := payload["body"]; !ok && text != "" {
+		payload["body"] = text
+	}
+}
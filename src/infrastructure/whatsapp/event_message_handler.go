@@ -8,7 +8,6 @@ import (
 
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
 	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
-	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
 	"github.com/sirupsen/logrus"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
@@ -31,7 +30,7 @@ func handleMessage(ctx context.Context, evt *events.Message, chatStorageRepo dom
 	}
 
 	// Handle image message if present
-	handleImageMessage(ctx, evt, client)
+	handleImageMessage(ctx, evt, chatStorageRepo, client)
 
 	// Auto-mark message as read if configured
 	handleAutoMarkRead(ctx, evt, client)
@@ -39,6 +38,9 @@ func handleMessage(ctx context.Context, evt *events.Message, chatStorageRepo dom
 	// Handle auto-reply if configured
 	handleAutoReply(ctx, evt, chatStorageRepo, client)
 
+	// Notify registered hooks (e.g. campaign reply tracking)
+	handleIncomingMessageHooks(ctx, evt, client)
+
 	// Forward to webhook if configured
 	handleWebhookForward(ctx, evt, client)
 }
@@ -60,7 +62,7 @@ func buildMessageMetaParts(evt *events.Message) []string {
 	return metaParts
 }
 
-func handleImageMessage(ctx context.Context, evt *events.Message, client *whatsmeow.Client) {
+func handleImageMessage(ctx context.Context, evt *events.Message, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
 	if !config.WhatsappAutoDownloadMedia {
 		return
 	}
@@ -68,14 +70,26 @@ func handleImageMessage(ctx context.Context, evt *events.Message, client *whatsm
 		return
 	}
 	if img := evt.Message.GetImageMessage(); img != nil {
-		if path, err := utils.ExtractMedia(ctx, client, config.PathStorages, img); err != nil {
+		if path, err := extractMessageMedia(ctx, client, evt, img); err != nil {
 			log.Errorf("Failed to download image: %v", err)
 		} else {
 			log.Infof("Image downloaded to %s", path)
+			storeMediaPath(ctx, chatStorageRepo, client, evt, path.MediaPath)
 		}
 	}
 }
 
+// storeMediaPath links a downloaded media file to its stored message
+func storeMediaPath(ctx context.Context, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client, evt *events.Message, mediaPath string) {
+	if chatStorageRepo == nil || mediaPath == "" {
+		return
+	}
+	chatJID := NormalizeJIDFromLID(ctx, evt.Info.Chat, client).String()
+	if err := chatStorageRepo.SetMessageMediaPath(StorageDeviceIDFromContext(ctx, client), chatJID, evt.Info.ID, mediaPath); err != nil {
+		log.Warnf("Failed to store media path of message %s: %v", evt.Info.ID, err)
+	}
+}
+
 func handleAutoMarkRead(ctx context.Context, evt *events.Message, client *whatsmeow.Client) {
 	// Only mark read if auto-mark read is enabled and message is incoming
 	if !config.WhatsappAutoMarkRead || evt.Info.IsFromMe {
@@ -126,7 +140,8 @@ func handleWebhookForward(ctx context.Context, evt *events.Message, client *what
 	if len(config.WhatsappWebhook) > 0 &&
 		!strings.Contains(evt.Info.SourceString(), "broadcast") {
 		go func(e *events.Message, c *whatsmeow.Client) {
-			webhookCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			// Keep the device in context so downloaded media can be linked to the message
+			webhookCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
 			defer cancel()
 			if err := forwardMessageToWebhook(webhookCtx, c, e); err != nil {
 				logrus.Error("Failed forward to webhook: ", err)
@@ -0,0 +1,60 @@
+package whatsapp
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBuildInteractiveResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		message *waE2E.Message
+		field   string
+		want    map[string]any
+	}{
+		{
+			name: "buttons response",
+			message: &waE2E.Message{ButtonsResponseMessage: &waE2E.ButtonsResponseMessage{
+				SelectedButtonID: proto.String("yes"),
+				Response:         &waE2E.ButtonsResponseMessage_SelectedDisplayText{SelectedDisplayText: "Yes"},
+				ContextInfo:      &waE2E.ContextInfo{StanzaID: proto.String("3EB0BUTTONS")},
+			}},
+			field: "buttons_response",
+			want:  map[string]any{"selected_id": "yes", "selected_text": "Yes", "message_id": "3EB0BUTTONS"},
+		},
+		{
+			name: "list response",
+			message: &waE2E.Message{ListResponseMessage: &waE2E.ListResponseMessage{
+				Title:             proto.String("Track"),
+				Description:       proto.String("Where is my order"),
+				SingleSelectReply: &waE2E.ListResponseMessage_SingleSelectReply{SelectedRowID: proto.String("track")},
+				ContextInfo:       &waE2E.ContextInfo{StanzaID: proto.String("3EB0LIST")},
+			}},
+			field: "list_response",
+			want:  map[string]any{"selected_id": "track", "selected_text": "Track", "selected_description": "Where is my order", "message_id": "3EB0LIST"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := map[string]any{}
+			buildOtherMessageTypes(&events.Message{Message: tt.message}, payload)
+
+			response, ok := payload[tt.field].(map[string]any)
+			if !ok {
+				t.Fatalf("payload has no %s: %v", tt.field, payload)
+			}
+			for key, want := range tt.want {
+				if response[key] != want {
+					t.Errorf("%s.%s = %v, want %v", tt.field, key, response[key], want)
+				}
+			}
+			if payload["body"] != tt.want["selected_text"] || payload["replied_to_id"] != tt.want["message_id"] {
+				t.Errorf("body = %v, replied_to_id = %v", payload["body"], payload["replied_to_id"])
+			}
+		})
+	}
+}
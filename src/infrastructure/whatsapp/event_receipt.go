@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
 	"github.com/sirupsen/logrus"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
@@ -34,6 +35,39 @@ func getReceiptTypeDescription(evt types.ReceiptType) string {
 	}
 }
 
+// receiptMessageStatus maps a receipt to the message status it proves, or "" when it proves none
+func receiptMessageStatus(receiptType types.ReceiptType) string {
+	switch receiptType {
+	case types.ReceiptTypeDelivered:
+		return domainChatStorage.MessageStatusDelivered
+	case types.ReceiptTypeRead, types.ReceiptTypeReadSelf:
+		return domainChatStorage.MessageStatusRead
+	case types.ReceiptTypePlayed, types.ReceiptTypePlayedSelf:
+		return domainChatStorage.MessageStatusPlayed
+	default:
+		return ""
+	}
+}
+
+// storeReceiptStatus records delivery and read receipts on the stored messages
+func storeReceiptStatus(ctx context.Context, evt *events.Receipt, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) {
+	status := receiptMessageStatus(evt.Type)
+	if chatStorageRepo == nil || status == "" || len(evt.MessageIDs) == 0 {
+		return
+	}
+
+	chatJID := NormalizeJIDFromLID(ctx, evt.Chat, client).String()
+	deviceID := StorageDeviceIDFromContext(ctx, client)
+	if err := chatStorageRepo.UpdateMessageStatus(deviceID, chatJID, evt.MessageIDs, status); err != nil {
+		log.Errorf("Failed to store %s receipt for %v: %v", status, evt.MessageIDs, err)
+	}
+
+	// Reading the chat on another device clears its unread state here too
+	if evt.IsFromMe && status == domainChatStorage.MessageStatusRead {
+		storeChatMetadata(ctx, chatStorageRepo, client, evt.Chat, domainChatStorage.ChatReadUpdate())
+	}
+}
+
 // createReceiptPayload creates a webhook payload for message acknowledgement (receipt) events
 func createReceiptPayload(ctx context.Context, evt *events.Receipt, deviceID string, client *whatsmeow.Client) map[string]any {
 	body := make(map[string]any)
@@ -87,16 +87,7 @@ func processConversationMessages(ctx context.Context, data *waHistorySync.Histor
 
 	// Prioritize device JID from context (set by event handler with correct device instance)
 	// over client.Store.ID which may point to a different device in multi-device scenarios
-	deviceID := ""
-	if inst, ok := DeviceFromContext(ctx); ok && inst != nil {
-		deviceID = inst.JID()
-		if deviceID == "" {
-			deviceID = inst.ID()
-		}
-	}
-	if deviceID == "" && client != nil && client.Store != nil && client.Store.ID != nil {
-		deviceID = client.Store.ID.ToNonAD().String()
-	}
+	deviceID := StorageDeviceIDFromContext(ctx, client)
 
 	for _, conv := range conversations {
 		rawChatJID := conv.GetID()
@@ -218,6 +209,7 @@ func processConversationMessages(ctx context.Context, data *waHistorySync.Histor
 				FileEncSHA256: fileEncSHA256,
 				FileLength:    fileLength,
 			}
+			message.Mimetype, message.IsPTT = utils.ExtractMediaMimetype(msg.GetMessage())
 
 			messageBatch = append(messageBatch, message)
 		}
@@ -237,6 +229,7 @@ func processConversationMessages(ctx context.Context, data *waHistorySync.Histor
 				log.Warnf("Failed to store chat %s: %v", chatJID, err)
 				continue
 			}
+			storeHistoryChatState(ctx, conv, chatStorageRepo, client, deviceID, chatJID)
 
 			// Store messages in batch
 			if err := chatStorageRepo.StoreMessagesBatch(messageBatch); err != nil {
@@ -250,22 +243,52 @@ func processConversationMessages(ctx context.Context, data *waHistorySync.Histor
 	return nil
 }
 
+// storeHistoryChatState stores the chat list state and group members a conversation carries
+func storeHistoryChatState(ctx context.Context, conv *waHistorySync.Conversation, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client, deviceID, chatJID string) {
+	archived := conv.GetArchived()
+	pinned := conv.GetPinned() > 0
+	// A mute without end is sent as the largest timestamp, which wraps to -1
+	mutedUntil := chatMutedUntil(conv.GetMuteEndTime() != 0, int64(conv.GetMuteEndTime()))
+	unreadCount := int(conv.GetUnreadCount())
+	markedUnread := conv.GetMarkedAsUnread()
+	if err := chatStorageRepo.UpdateChatMetadata(deviceID, chatJID, domainChatStorage.ChatMetadataUpdate{
+		Archived:     &archived,
+		Pinned:       &pinned,
+		MutedUntil:   &mutedUntil,
+		UnreadCount:  &unreadCount,
+		MarkedUnread: &markedUnread,
+	}); err != nil {
+		log.Warnf("Failed to store chat state of %s: %v", chatJID, err)
+	}
+
+	if len(conv.GetParticipant()) == 0 {
+		return
+	}
+	participants := make([]*domainChatStorage.GroupParticipant, 0, len(conv.GetParticipant()))
+	for _, participant := range conv.GetParticipant() {
+		jid, err := types.ParseJID(participant.GetUserJID())
+		if err != nil {
+			continue
+		}
+		rank := participant.GetRank()
+		participants = append(participants, &domainChatStorage.GroupParticipant{
+			JID:          NormalizeJIDFromLID(ctx, jid, client).ToNonAD().String(),
+			IsAdmin:      rank == waHistorySync.GroupParticipant_ADMIN || rank == waHistorySync.GroupParticipant_SUPERADMIN,
+			IsSuperAdmin: rank == waHistorySync.GroupParticipant_SUPERADMIN,
+		})
+	}
+	if err := chatStorageRepo.SetGroupParticipants(deviceID, chatJID, participants); err != nil {
+		log.Warnf("Failed to store members of group %s: %v", chatJID, err)
+	}
+}
+
 // processPushNames processes push names from history sync to update chat names
 func processPushNames(ctx context.Context, data *waHistorySync.HistorySync, chatStorageRepo domainChatStorage.IChatStorageRepository, client *whatsmeow.Client) error {
 	pushnames := data.GetPushnames()
 	log.Infof("Processing %d push names from history sync", len(pushnames))
 
 	// Extract device ID from context (same pattern as processConversationMessages)
-	deviceID := ""
-	if inst, ok := DeviceFromContext(ctx); ok && inst != nil {
-		deviceID = inst.JID()
-		if deviceID == "" {
-			deviceID = inst.ID()
-		}
-	}
-	if deviceID == "" && client != nil && client.Store != nil && client.Store.ID != nil {
-		deviceID = client.Store.ID.ToNonAD().String()
-	}
+	deviceID := StorageDeviceIDFromContext(ctx, client)
 
 	for _, pushname := range pushnames {
 		rawJIDStr := pushname.GetID()
@@ -284,6 +307,10 @@ func processPushNames(ctx context.Context, data *waHistorySync.HistorySync, chat
 		jid = NormalizeJIDFromLID(ctx, jid, client)
 		jidStr := jid.String()
 
+		if err := chatStorageRepo.StoreContact(&domainChatStorage.Contact{DeviceID: deviceID, JID: jid.ToNonAD().String(), PushName: name}); err != nil {
+			log.Warnf("Failed to store contact %s: %v", jidStr, err)
+		}
+
 		// Check if chat exists (device-scoped to avoid cross-device data leak)
 		existingChat, err := chatStorageRepo.GetChatByDevice(deviceID, jidStr)
 		if err != nil || existingChat == nil {
@@ -0,0 +1,60 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+var (
+	mediaStoreMu sync.RWMutex
+	mediaStore   domainMediaStore.IMediaStore
+)
+
+// SetMediaStore sets where media downloaded from incoming messages is kept
+func SetMediaStore(store domainMediaStore.IMediaStore) {
+	mediaStoreMu.Lock()
+	defer mediaStoreMu.Unlock()
+	mediaStore = store
+}
+
+func getMediaStore() domainMediaStore.IMediaStore {
+	mediaStoreMu.RLock()
+	defer mediaStoreMu.RUnlock()
+	return mediaStore
+}
+
+// MediaURLTTL is how long links to stored media stay valid
+func MediaURLTTL() time.Duration {
+	return time.Duration(config.MediaURLExpiryMinutes) * time.Minute
+}
+
+// extractMessageMedia downloads the media of a message into the media store,
+// keyed by its chat, day and ID so every download of it shares one file
+func extractMessageMedia(ctx context.Context, client *whatsmeow.Client, evt *events.Message, mediaFile whatsmeow.DownloadableMessage) (utils.ExtractedMedia, error) {
+	store := getMediaStore()
+	if store == nil {
+		return utils.ExtractedMedia{}, fmt.Errorf("media storage is not configured")
+	}
+	chat := NormalizeJIDFromLID(ctx, evt.Info.Chat, client)
+	return utils.ExtractMedia(ctx, client, store, utils.MediaKeyPrefix(chat.String(), evt.Info.Timestamp, evt.Info.ID), mediaFile)
+}
+
+// withMediaURL links downloaded media for webhook receivers, who cannot read the media store
+func withMediaURL(ctx context.Context, media utils.ExtractedMedia) utils.ExtractedMedia {
+	if store := getMediaStore(); store != nil && media.MediaPath != "" {
+		if link, err := store.URL(ctx, media.MediaPath, MediaURLTTL()); err == nil {
+			media.MediaURL = link
+		} else {
+			log.Warnf("Failed to link media %s: %v", media.MediaPath, err)
+		}
+	}
+	return media
+}
@@ -0,0 +1,63 @@
+package whatsapp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// IncomingMessageHook receives direct messages from other users. phone is the
+// sender's number without the JID suffix and deviceID is the receiving device.
+type IncomingMessageHook func(ctx context.Context, deviceID, phone, text string)
+
+var (
+	incomingHooksMu sync.RWMutex
+	incomingHooks   []IncomingMessageHook
+)
+
+// RegisterIncomingMessageHook adds a hook called for every incoming 1:1 message.
+func RegisterIncomingMessageHook(hook IncomingMessageHook) {
+	incomingHooksMu.Lock()
+	defer incomingHooksMu.Unlock()
+	incomingHooks = append(incomingHooks, hook)
+}
+
+func handleIncomingMessageHooks(ctx context.Context, evt *events.Message, client *whatsmeow.Client) {
+	incomingHooksMu.RLock()
+	hooks := incomingHooks
+	incomingHooksMu.RUnlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	// Only direct messages from other users; protocol messages (edits, revokes) are not replies
+	if evt.Info.IsFromMe || evt.Info.IsIncomingBroadcast() || utils.IsGroupJID(evt.Info.Chat.String()) {
+		return
+	}
+	if evt.Message.GetProtocolMessage() != nil {
+		return
+	}
+
+	chat := NormalizeJIDFromLID(ctx, evt.Info.Chat, client)
+	if chat.Server != types.DefaultUserServer {
+		return
+	}
+
+	text := utils.ExtractMessageTextFromEvent(evt)
+	if text == "" {
+		return
+	}
+
+	deviceID := ""
+	if inst, ok := DeviceFromContext(ctx); ok && inst != nil {
+		deviceID = inst.ID()
+	}
+
+	for _, hook := range hooks {
+		hook(ctx, deviceID, chat.User, text)
+	}
+}
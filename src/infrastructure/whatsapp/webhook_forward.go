@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
@@ -58,6 +59,21 @@ func forwardPayloadToConfiguredWebhooks(ctx context.Context, payload map[string]
 	return nil
 }
 
+// ForwardEventToWebhook delivers an event raised outside the WhatsApp event handlers,
+// such as outbound queue updates, in the same envelope as the other webhook events
+func ForwardEventToWebhook(ctx context.Context, eventName, deviceID string, payload map[string]any) error {
+	body := map[string]any{
+		"event":     eventName,
+		"payload":   payload,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	if deviceID != "" {
+		body["device_id"] = deviceID
+	}
+
+	return forwardPayloadToConfiguredWebhooks(ctx, body, eventName)
+}
+
 // isEventWhitelisted checks if the given event name is in the configured whitelist
 func isEventWhitelisted(eventName string) bool {
 	for _, allowed := range config.WhatsappWebhookEvents {
@@ -7,19 +7,19 @@ import (
 	"encoding/hex"
 	"fmt"
 	"mime"
-	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
 	"go.mau.fi/whatsmeow"
 )
@@ -35,11 +35,34 @@ var knownDocumentMIMEByExtension = map[string]string{
 
 var knownDocumentExtensionByMIME map[string]string
 
+// mediaExtensionByMIME is the usual extension of the media types WhatsApp sends.
+// mime.ExtensionsByType lists extensions alphabetically, e.g. ".jfif" for JPEG,
+// and knows few of them on systems without a mime.types file.
+var mediaExtensionByMIME = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"image/gif":       ".gif",
+	"video/mp4":       ".mp4",
+	"video/3gpp":      ".3gp",
+	"audio/ogg":       ".ogg",
+	"audio/mpeg":      ".mp3",
+	"audio/mp4":       ".m4a",
+	"audio/aac":       ".aac",
+	"audio/amr":       ".amr",
+	"application/pdf": ".pdf",
+}
+
+var mediaMIMEByExtension = map[string]string{".opus": "audio/ogg", ".jpeg": "image/jpeg"}
+
 func init() {
 	knownDocumentExtensionByMIME = make(map[string]string, len(knownDocumentMIMEByExtension))
 	for ext, mimeType := range knownDocumentMIMEByExtension {
 		knownDocumentExtensionByMIME[strings.ToLower(mimeType)] = ext
 	}
+	for mimeType, ext := range mediaExtensionByMIME {
+		mediaMIMEByExtension[ext] = mimeType
+	}
 }
 
 func resolveKnownDocumentMIME(ext string) (string, bool) {
@@ -66,6 +89,21 @@ func KnownDocumentExtensionByMIME(mimeType string) (string, bool) {
 	return resolveKnownDocumentExtension(mimeType)
 }
 
+// MediaContentType returns the content type of a media file from its name
+func MediaContentType(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if mimeType, ok := mediaMIMEByExtension[ext]; ok {
+		return mimeType
+	}
+	if mimeType, ok := resolveKnownDocumentMIME(ext); ok {
+		return mimeType
+	}
+	if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+		return mimeType
+	}
+	return "application/octet-stream"
+}
+
 func determineMediaExtension(originalFilename, mimeType string) string {
 	if originalFilename != "" {
 		if ext := filepath.Ext(originalFilename); ext != "" {
@@ -73,10 +111,19 @@ func determineMediaExtension(originalFilename, mimeType string) string {
 		}
 	}
 
+	// Parameters such as "; codecs=opus" are not part of the type
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		mimeType = mediaType
+	}
+
 	if ext, ok := resolveKnownDocumentExtension(mimeType); ok {
 		return ext
 	}
 
+	if ext, ok := mediaExtensionByMIME[mimeType]; ok {
+		return ext
+	}
+
 	if ext, err := mime.ExtensionsByType(mimeType); err == nil && len(ext) > 0 {
 		return ext[0]
 	}
@@ -205,6 +252,14 @@ func ExtractMessageTextFromEvent(evt *events.Message) string {
 		} else {
 			messageText = "📝 " + messageText
 		}
+	} else if buttonsMessage := evt.Message.GetButtonsMessage(); buttonsMessage != nil {
+		messageText = "🔘 " + buttonsMessage.GetContentText()
+	} else if eventMessage := evt.Message.GetEventMessage(); eventMessage != nil {
+		messageText = "📅 " + eventMessage.GetName()
+	} else if buttonsResponse := evt.Message.GetButtonsResponseMessage(); buttonsResponse != nil {
+		messageText = buttonsResponse.GetSelectedDisplayText()
+	} else if listResponse := evt.Message.GetListResponseMessage(); listResponse != nil {
+		messageText = listResponse.GetTitle()
 	} else if orderMessage := evt.Message.GetOrderMessage(); orderMessage != nil {
 		messageText = orderMessage.GetOrderTitle()
 		if messageText == "" {
@@ -315,6 +370,28 @@ func ExtractMediaInfo(msg *waE2E.Message) (mediaType string, filename string, ur
 	return "", "", "", nil, nil, nil, 0
 }
 
+// ExtractMediaMimetype returns the mimetype of the media in a WhatsApp message and
+// whether audio was recorded as a voice note
+func ExtractMediaMimetype(msg *waE2E.Message) (mimetype string, ptt bool) {
+	switch {
+	case msg == nil:
+		return "", false
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetMimetype(), false
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetMimetype(), false
+	case msg.GetPtvMessage() != nil:
+		return msg.GetPtvMessage().GetMimetype(), false
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage().GetMimetype(), msg.GetAudioMessage().GetPTT()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetMimetype(), false
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage().GetMimetype(), false
+	}
+	return "", false
+}
+
 // ExtractEphemeralExpiration extracts ephemeral expiration from a WhatsApp message
 func ExtractEphemeralExpiration(msg *waE2E.Message) uint32 {
 	logrus.Debug("ExtractEphemeralExpiration: Starting extraction process")
@@ -542,29 +619,26 @@ func FormatJID(jid string) types.JID {
 
 // ExtractedMedia represents extracted media information
 type ExtractedMedia struct {
-	MediaPath string `json:"media_path"`
+	MediaPath string `json:"media_path"` // Key of the file in the media store
+	MediaURL  string `json:"media_url,omitempty"`
 	MimeType  string `json:"mime_type"`
 	Caption   string `json:"caption"`
 }
 
-// ExtractMedia is a helper function to extract media from whatsapp
-func ExtractMedia(ctx context.Context, client *whatsmeow.Client, storageLocation string, mediaFile whatsmeow.DownloadableMessage) (extractedMedia ExtractedMedia, err error) {
+// MediaKeyPrefix is where the media of a message is kept in the media store,
+// grouped by chat and day. ExtractMedia appends the file extension.
+func MediaKeyPrefix(chatJID string, timestamp time.Time, messageID string) string {
+	return path.Join(ExtractPhoneNumber(chatJID), timestamp.Format("2006-01-02"), messageID)
+}
+
+// ExtractMedia downloads media from whatsapp into the media store under
+// keyPrefix. Media that is already stored there is not downloaded again.
+func ExtractMedia(ctx context.Context, client *whatsmeow.Client, store domainMediaStore.IMediaStore, keyPrefix string, mediaFile whatsmeow.DownloadableMessage) (extractedMedia ExtractedMedia, err error) {
 	if mediaFile == nil {
 		logrus.Info("Skip download because data is nil")
 		return extractedMedia, nil
 	}
 
-	data, err := client.Download(ctx, mediaFile)
-	if err != nil {
-		return extractedMedia, err
-	}
-
-	// Validate file size before writing to disk
-	maxFileSize := config.WhatsappSettingMaxDownloadSize
-	if int64(len(data)) > maxFileSize {
-		return extractedMedia, fmt.Errorf("file size exceeds the maximum limit of %d bytes", maxFileSize)
-	}
-
 	var originalFilename string
 
 	switch media := mediaFile.(type) {
@@ -584,13 +658,25 @@ func ExtractMedia(ctx context.Context, client *whatsmeow.Client, storageLocation
 		originalFilename = media.GetFileName()
 	}
 
-	extension := determineMediaExtension(originalFilename, extractedMedia.MimeType)
+	extractedMedia.MediaPath = keyPrefix + determineMediaExtension(originalFilename, extractedMedia.MimeType)
+	if _, err := store.Stat(ctx, extractedMedia.MediaPath); err == nil {
+		return extractedMedia, nil
+	}
 
-	extractedMedia.MediaPath = fmt.Sprintf("%s/%d-%s%s", storageLocation, time.Now().Unix(), uuid.NewString(), extension)
-	err = os.WriteFile(extractedMedia.MediaPath, data, 0600)
+	data, err := client.Download(ctx, mediaFile)
 	if err != nil {
 		return extractedMedia, err
 	}
+
+	// Validate file size before storing
+	maxFileSize := config.WhatsappSettingMaxDownloadSize
+	if int64(len(data)) > maxFileSize {
+		return extractedMedia, fmt.Errorf("file size exceeds the maximum limit of %d bytes", maxFileSize)
+	}
+
+	if err = store.Put(ctx, extractedMedia.MediaPath, data, extractedMedia.MimeType); err != nil {
+		return extractedMedia, err
+	}
 	return extractedMedia, nil
 }
 
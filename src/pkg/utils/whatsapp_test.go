@@ -39,6 +39,24 @@ func TestDetermineMediaExtension(t *testing.T) {
 			mimeType:   "application/octet-stream",
 			wantSuffix: ".exe",
 		},
+		{
+			name:       "VoiceNoteWithCodecs",
+			filename:   "",
+			mimeType:   "audio/ogg; codecs=opus",
+			wantSuffix: ".ogg",
+		},
+		{
+			name:       "UsualVideoExtension",
+			filename:   "",
+			mimeType:   "video/mp4",
+			wantSuffix: ".mp4",
+		},
+		{
+			name:       "UsualImageExtension",
+			filename:   "",
+			mimeType:   "image/jpeg",
+			wantSuffix: ".jpg",
+		},
 	}
 
 	for _, tt := range tests {
@@ -50,3 +68,18 @@ func TestDetermineMediaExtension(t *testing.T) {
 		})
 	}
 }
+
+func TestMediaContentType(t *testing.T) {
+	tests := map[string]string{
+		"628/2025-03-04/3EB0.ogg":  "audio/ogg",
+		"628/2025-03-04/3EB0.OPUS": "audio/ogg",
+		"628/2025-03-04/3EB0.mp4":  "video/mp4",
+		"report.xlsx":              "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"628/2025-03-04/3EB0":      "application/octet-stream",
+	}
+	for name, want := range tests {
+		if got := MediaContentType(name); got != want {
+			t.Errorf("MediaContentType(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,527 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	domainCampaign "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/campaign"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type CampaignHandler struct {
+	campaignService domainCampaign.ICampaignUsecase
+}
+
+func InitMcpCampaign(campaignService domainCampaign.ICampaignUsecase) *CampaignHandler {
+	return &CampaignHandler{campaignService: campaignService}
+}
+
+func (h *CampaignHandler) AddCampaignTools(mcpServer *server.MCPServer) {
+	mcpServer.AddTool(h.toolListCustomers(), h.handleListCustomers)
+	mcpServer.AddTool(h.toolListGroups(), h.handleListGroups)
+	mcpServer.AddTool(h.toolListTemplates(), h.handleListTemplates)
+	mcpServer.AddTool(h.toolPreviewTemplate(), h.handlePreviewTemplate)
+	mcpServer.AddTool(h.toolListCampaigns(), h.handleListCampaigns)
+	mcpServer.AddTool(h.toolGetCampaign(), h.handleGetCampaign)
+	mcpServer.AddTool(h.toolCreateCampaign(), h.handleCreateCampaign)
+	mcpServer.AddTool(h.toolCampaignStats(), h.handleCampaignStats)
+	mcpServer.AddTool(h.toolStartCampaign(), h.handleStartCampaign)
+	mcpServer.AddTool(h.toolPauseCampaign(), h.handlePauseCampaign)
+}
+
+func withDeviceID() mcp.ToolOption {
+	return mcp.WithString("device_id",
+		mcp.Description("Device that owns the campaign data. Optional when only one device is registered."),
+	)
+}
+
+func withPagination() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithNumber("page",
+			mcp.Description("Page number starting at 1 (default 1)."),
+			mcp.DefaultNumber(1),
+		),
+		mcp.WithNumber("page_size",
+			mcp.Description("Items per page (default 20, max 100)."),
+			mcp.DefaultNumber(20),
+		),
+	}
+}
+
+func (h *CampaignHandler) toolListCustomers() mcp.Tool {
+	opts := []mcp.ToolOption{
+		mcp.WithDescription("List campaign customers with optional search, group and tag filters."),
+		mcp.WithTitleAnnotation("List Campaign Customers"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		withDeviceID(),
+		mcp.WithString("search",
+			mcp.Description("Filter by name, phone, company or tag."),
+		),
+		mcp.WithString("tag",
+			mcp.Description("Only return customers carrying this tag."),
+		),
+		mcp.WithString("group_id",
+			mcp.Description("Only return customers in this campaign group (UUID)."),
+		),
+	}
+	return mcp.NewTool("whatsapp_campaign_list_customers", append(opts, withPagination()...)...)
+}
+
+func (h *CampaignHandler) handleListCustomers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	deviceID, err := resolveCampaignDevice(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var groupID *uuid.UUID
+	if raw := strings.TrimSpace(request.GetString("group_id", "")); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid group_id: %w", err)
+		}
+		groupID = &parsed
+	}
+
+	resp, err := h.campaignService.ListCustomers(ctx, deviceID,
+		request.GetInt("page", 1), request.GetInt("page_size", 20),
+		request.GetString("search", ""), groupID, "", request.GetString("tag", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := fmt.Sprintf("Found %d customers (page %d of %d)", resp.Total, resp.Page, resp.TotalPages)
+	return mcp.NewToolResultStructured(resp, fallback), nil
+}
+
+func (h *CampaignHandler) toolListGroups() mcp.Tool {
+	opts := []mcp.ToolOption{
+		mcp.WithDescription("List campaign customer groups."),
+		mcp.WithTitleAnnotation("List Campaign Groups"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		withDeviceID(),
+	}
+	return mcp.NewTool("whatsapp_campaign_list_groups", append(opts, withPagination()...)...)
+}
+
+func (h *CampaignHandler) handleListGroups(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	deviceID, err := resolveCampaignDevice(request)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.campaignService.ListGroups(ctx, deviceID, request.GetInt("page", 1), request.GetInt("page_size", 20))
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := fmt.Sprintf("Found %d groups (page %d of %d)", resp.Total, resp.Page, resp.TotalPages)
+	return mcp.NewToolResultStructured(resp, fallback), nil
+}
+
+func (h *CampaignHandler) toolListTemplates() mcp.Tool {
+	opts := []mcp.ToolOption{
+		mcp.WithDescription("List campaign message templates."),
+		mcp.WithTitleAnnotation("List Campaign Templates"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		withDeviceID(),
+	}
+	return mcp.NewTool("whatsapp_campaign_list_templates", append(opts, withPagination()...)...)
+}
+
+func (h *CampaignHandler) handleListTemplates(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	deviceID, err := resolveCampaignDevice(request)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.campaignService.ListTemplates(ctx, deviceID, request.GetInt("page", 1), request.GetInt("page_size", 20))
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := fmt.Sprintf("Found %d templates (page %d of %d)", resp.Total, resp.Page, resp.TotalPages)
+	return mcp.NewToolResultStructured(resp, fallback), nil
+}
+
+func (h *CampaignHandler) toolPreviewTemplate() mcp.Tool {
+	return mcp.NewTool(
+		"whatsapp_campaign_preview_template",
+		mcp.WithDescription("Render a campaign template for a specific customer without sending anything."),
+		mcp.WithTitleAnnotation("Preview Campaign Template"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		withDeviceID(),
+		mcp.WithString("template_id",
+			mcp.Description("Template UUID."),
+			mcp.Required(),
+		),
+		mcp.WithString("customer_id",
+			mcp.Description("Customer UUID used to fill the placeholders."),
+			mcp.Required(),
+		),
+	)
+}
+
+func (h *CampaignHandler) handlePreviewTemplate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	deviceID, err := resolveCampaignDevice(request)
+	if err != nil {
+		return nil, err
+	}
+
+	templateID, err := requireUUID(request, "template_id")
+	if err != nil {
+		return nil, err
+	}
+	customerID, err := requireUUID(request, "customer_id")
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := h.campaignService.GetTemplate(ctx, deviceID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, fmt.Errorf("template %s not found", templateID)
+	}
+
+	customer, err := h.campaignService.GetCustomer(ctx, deviceID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, fmt.Errorf("customer %s not found", customerID)
+	}
+
+	preview := h.campaignService.PreviewTemplate(ctx, template.Content, customer)
+
+	structured := map[string]any{
+		"template_id": templateID,
+		"customer_id": customerID,
+		"phone":       customer.Phone,
+		"preview":     preview,
+	}
+	return mcp.NewToolResultStructured(structured, preview), nil
+}
+
+func (h *CampaignHandler) toolListCampaigns() mcp.Tool {
+	opts := []mcp.ToolOption{
+		mcp.WithDescription("List campaigns with their delivery stats."),
+		mcp.WithTitleAnnotation("List Campaigns"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		withDeviceID(),
+	}
+	return mcp.NewTool("whatsapp_campaign_list", append(opts, withPagination()...)...)
+}
+
+func (h *CampaignHandler) handleListCampaigns(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	deviceID, err := resolveCampaignDevice(request)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.campaignService.ListCampaigns(ctx, deviceID, request.GetInt("page", 1), request.GetInt("page_size", 20))
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := fmt.Sprintf("Found %d campaigns (page %d of %d)", resp.Total, resp.Page, resp.TotalPages)
+	return mcp.NewToolResultStructured(resp, fallback), nil
+}
+
+func (h *CampaignHandler) toolGetCampaign() mcp.Tool {
+	return mcp.NewTool(
+		"whatsapp_campaign_get",
+		mcp.WithDescription("Get a campaign including its template, targets, sender devices and stats."),
+		mcp.WithTitleAnnotation("Get Campaign"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		withDeviceID(),
+		mcp.WithString("campaign_id",
+			mcp.Description("Campaign UUID."),
+			mcp.Required(),
+		),
+	)
+}
+
+func (h *CampaignHandler) handleGetCampaign(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	deviceID, err := resolveCampaignDevice(request)
+	if err != nil {
+		return nil, err
+	}
+	campaignID, err := requireUUID(request, "campaign_id")
+	if err != nil {
+		return nil, err
+	}
+
+	campaign, err := h.campaignService.GetCampaign(ctx, deviceID, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil {
+		return nil, fmt.Errorf("campaign %s not found", campaignID)
+	}
+
+	fallback := fmt.Sprintf("Campaign %s is %s", campaign.Name, campaign.Status)
+	return mcp.NewToolResultStructured(campaign, fallback), nil
+}
+
+func (h *CampaignHandler) toolCreateCampaign() mcp.Tool {
+	return mcp.NewTool(
+		"whatsapp_campaign_create_draft",
+		mcp.WithDescription("Create a draft campaign. Nothing is sent until the campaign is started."),
+		mcp.WithTitleAnnotation("Create Draft Campaign"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(false),
+		withDeviceID(),
+		mcp.WithString("name",
+			mcp.Description("Campaign name."),
+			mcp.Required(),
+		),
+		mcp.WithString("template_id",
+			mcp.Description("Template UUID used for the message body."),
+			mcp.Required(),
+		),
+		mcp.WithArray("customer_ids",
+			mcp.Description("Customer UUIDs to target."),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("group_ids",
+			mcp.Description("Campaign group UUIDs to target."),
+			mcp.WithStringItems(),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Target every customer carrying any of these tags."),
+			mcp.WithStringItems(),
+		),
+	)
+}
+
+func (h *CampaignHandler) handleCreateCampaign(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	deviceID, err := resolveCampaignDevice(request)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := request.RequireString("name")
+	if err != nil {
+		return nil, err
+	}
+	templateID, err := requireUUID(request, "template_id")
+	if err != nil {
+		return nil, err
+	}
+
+	args := request.GetArguments()
+	customerIDs, err := toUUIDSlice(args["customer_ids"], "customer_ids")
+	if err != nil {
+		return nil, err
+	}
+	groupIDs, err := toUUIDSlice(args["group_ids"], "group_ids")
+	if err != nil {
+		return nil, err
+	}
+	tags, err := toStringSlice(args["tags"])
+	if err != nil {
+		return nil, err
+	}
+	if len(customerIDs) == 0 && len(groupIDs) == 0 && len(tags) == 0 {
+		return nil, fmt.Errorf("at least one of customer_ids, group_ids or tags is required")
+	}
+
+	campaign, err := h.campaignService.CreateCampaign(ctx, domainCampaign.CreateCampaignRequest{
+		DeviceID:    deviceID,
+		Name:        strings.TrimSpace(name),
+		TemplateID:  templateID,
+		CustomerIDs: customerIDs,
+		GroupIDs:    groupIDs,
+		Tags:        tags,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := fmt.Sprintf("Created draft campaign %s (%s)", campaign.Name, campaign.ID)
+	return mcp.NewToolResultStructured(campaign, fallback), nil
+}
+
+func (h *CampaignHandler) toolCampaignStats() mcp.Tool {
+	return mcp.NewTool(
+		"whatsapp_campaign_stats",
+		mcp.WithDescription("Get delivery stats for a campaign."),
+		mcp.WithTitleAnnotation("Campaign Stats"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		withDeviceID(),
+		mcp.WithString("campaign_id",
+			mcp.Description("Campaign UUID."),
+			mcp.Required(),
+		),
+	)
+}
+
+func (h *CampaignHandler) handleCampaignStats(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	deviceID, err := resolveCampaignDevice(request)
+	if err != nil {
+		return nil, err
+	}
+	campaignID, err := requireUUID(request, "campaign_id")
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := h.campaignService.GetCampaignStats(ctx, deviceID, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := fmt.Sprintf(
+		"Campaign %s: %d total, %d sent, %d failed, %d pending",
+		campaignID, stats.TotalMessages, stats.SentMessages, stats.FailedMessages, stats.PendingMessages,
+	)
+	return mcp.NewToolResultStructured(stats, fallback), nil
+}
+
+func (h *CampaignHandler) toolStartCampaign() mcp.Tool {
+	return mcp.NewTool(
+		"whatsapp_campaign_start",
+		mcp.WithDescription("Start or resume a campaign. This queues real WhatsApp messages to every target, so confirm must be set to true."),
+		mcp.WithTitleAnnotation("Start Campaign"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(false),
+		withDeviceID(),
+		mcp.WithString("campaign_id",
+			mcp.Description("Campaign UUID."),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("confirm",
+			mcp.Description("Must be true to actually start sending."),
+			mcp.Required(),
+		),
+	)
+}
+
+func (h *CampaignHandler) handleStartCampaign(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	deviceID, err := resolveCampaignDevice(request)
+	if err != nil {
+		return nil, err
+	}
+	campaignID, err := requireUUID(request, "campaign_id")
+	if err != nil {
+		return nil, err
+	}
+
+	confirmed := false
+	if raw, ok := request.GetArguments()["confirm"]; ok {
+		confirmed, err = toBool(raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !confirmed {
+		return mcp.NewToolResultError("campaign not started: set confirm to true to queue messages to all targets"), nil
+	}
+
+	if err := h.campaignService.StartCampaign(ctx, deviceID, campaignID); err != nil {
+		return nil, err
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Campaign %s started", campaignID)), nil
+}
+
+func (h *CampaignHandler) toolPauseCampaign() mcp.Tool {
+	return mcp.NewTool(
+		"whatsapp_campaign_pause",
+		mcp.WithDescription("Pause a running campaign. Queued messages stay pending until it is started again."),
+		mcp.WithTitleAnnotation("Pause Campaign"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		withDeviceID(),
+		mcp.WithString("campaign_id",
+			mcp.Description("Campaign UUID."),
+			mcp.Required(),
+		),
+	)
+}
+
+func (h *CampaignHandler) handlePauseCampaign(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	deviceID, err := resolveCampaignDevice(request)
+	if err != nil {
+		return nil, err
+	}
+	campaignID, err := requireUUID(request, "campaign_id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.campaignService.PauseCampaign(ctx, deviceID, campaignID); err != nil {
+		return nil, err
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Campaign %s paused", campaignID)), nil
+}
+
+// resolveCampaignDevice picks the device whose campaign data a tool operates on,
+// falling back to the only registered device when device_id is omitted.
+func resolveCampaignDevice(request mcp.CallToolRequest) (string, error) {
+	_, deviceID, err := whatsapp.GetDeviceManager().ResolveDevice(request.GetString("device_id", ""))
+	if err != nil {
+		return "", err
+	}
+	return deviceID, nil
+}
+
+func requireUUID(request mcp.CallToolRequest, key string) (uuid.UUID, error) {
+	raw, err := request.RequireString(key)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	id, err := uuid.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return id, nil
+}
+
+func toUUIDSlice(raw any, key string) ([]uuid.UUID, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an array of strings", key)
+	}
+
+	result := make([]uuid.UUID, 0, len(items))
+	for i, item := range items {
+		str, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s[%d] is not a string", key, i)
+		}
+		id, err := uuid.Parse(strings.TrimSpace(str))
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", key, i, err)
+		}
+		result = append(result, id)
+	}
+	return result, nil
+}
@@ -32,6 +32,7 @@ func (h *QueryHandler) AddQueryTools(mcpServer *server.MCPServer) {
 	mcpServer.AddTool(h.toolListContacts(), h.handleListContacts)
 	mcpServer.AddTool(h.toolListChats(), h.handleListChats)
 	mcpServer.AddTool(h.toolGetChatMessages(), h.handleGetChatMessages)
+	mcpServer.AddTool(h.toolSearchMessages(), h.handleSearchMessages)
 	mcpServer.AddTool(h.toolDownloadMedia(), h.handleDownloadMedia)
 	mcpServer.AddTool(h.toolArchiveChat(), h.handleArchiveChat)
 }
@@ -222,6 +223,94 @@ func (h *QueryHandler) handleGetChatMessages(ctx context.Context, request mcp.Ca
 	return mcp.NewToolResultStructured(resp, fallback), nil
 }
 
+func (h *QueryHandler) toolSearchMessages() mcp.Tool {
+	return mcp.NewTool(
+		"whatsapp_search_messages",
+		mcp.WithDescription("Full-text search across stored messages and media captions, best matches first. Supports \"exact phrases\", prefix* terms and OR."),
+		mcp.WithTitleAnnotation("Search Messages"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+		mcp.WithString("query",
+			mcp.Description("Search query, e.g. invoice*, \"see you tomorrow\" or meeting OR call."),
+			mcp.Required(),
+		),
+		mcp.WithString("chat_jid",
+			mcp.Description("Restrict the search to one chat JID. Leave empty to search every chat."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of results to return (default 20, max 100)."),
+			mcp.DefaultNumber(20),
+		),
+		mcp.WithNumber("offset",
+			mcp.Description("Number of results to skip from the start (default 0)."),
+			mcp.DefaultNumber(0),
+		),
+		mcp.WithString("start_time",
+			mcp.Description("Only match messages sent after this RFC3339 timestamp."),
+		),
+		mcp.WithString("end_time",
+			mcp.Description("Only match messages sent before this RFC3339 timestamp."),
+		),
+		mcp.WithBoolean("media_only",
+			mcp.Description("If true, only match messages containing media."),
+			mcp.DefaultBool(false),
+		),
+	)
+}
+
+func (h *QueryHandler) handleSearchMessages(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := request.RequireString("query")
+	if err != nil {
+		return nil, err
+	}
+
+	var startTimePtr *string
+	startTime := strings.TrimSpace(request.GetString("start_time", ""))
+	if startTime != "" {
+		startTimePtr = &startTime
+	}
+
+	var endTimePtr *string
+	endTime := strings.TrimSpace(request.GetString("end_time", ""))
+	if endTime != "" {
+		endTimePtr = &endTime
+	}
+
+	mediaOnly := false
+	if args := request.GetArguments(); args != nil {
+		if value, ok := args["media_only"]; ok {
+			parsed, err := toBool(value)
+			if err != nil {
+				return nil, err
+			}
+			mediaOnly = parsed
+		}
+	}
+
+	req := domainChat.SearchMessagesRequest{
+		Query:     query,
+		ChatJID:   strings.TrimSpace(request.GetString("chat_jid", "")),
+		Limit:     request.GetInt("limit", 20),
+		Offset:    request.GetInt("offset", 0),
+		StartTime: startTimePtr,
+		EndTime:   endTimePtr,
+		MediaOnly: mediaOnly,
+	}
+
+	resp, err := h.chatService.SearchMessages(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := fmt.Sprintf(
+		"Found %d matching messages (showing %d)",
+		resp.Pagination.Total,
+		len(resp.Data),
+	)
+	return mcp.NewToolResultStructured(resp, fallback), nil
+}
+
 func (h *QueryHandler) toolDownloadMedia() mcp.Tool {
 	return mcp.NewTool(
 		"whatsapp_download_message_media",
@@ -265,6 +354,9 @@ func (h *QueryHandler) handleDownloadMedia(ctx context.Context, request mcp.Call
 	}
 
 	fallback := fmt.Sprintf("Media saved to %s (%s)", resp.FilePath, resp.MediaType)
+	if resp.MediaURL != "" {
+		fallback += ", available at " + resp.MediaURL
+	}
 	return mcp.NewToolResultStructured(resp, fallback), nil
 }
 
@@ -74,11 +74,11 @@ func (s *SendHandler) handleSendText(ctx context.Context, request mcp.CallToolRe
 
 	res, err := s.sendService.SendText(ctx, domainSend.MessageRequest{
 		BaseRequest: domainSend.BaseRequest{
-			Phone:       phone,
-			IsForwarded: isForwarded,
+			Phone:          phone,
+			IsForwarded:    isForwarded,
+			ReplyMessageID: &replyMessageId,
 		},
-		Message:        message,
-		ReplyMessageID: &replyMessageId,
+		Message: message,
 	})
 
 	if err != nil {
@@ -33,8 +33,18 @@ func InitRestCampaign(app fiber.Router, service domainCampaign.ICampaignUsecase)
 	campaign.Delete("/customers/:id", rest.DeleteCustomer)
 	campaign.Post("/customers/bulk-delete", rest.DeleteCustomers)
 	campaign.Post("/customers/:id/validate", rest.ValidateCustomer)
+	campaign.Get("/customers/:id/validations", rest.GetCustomerValidationHistory)
 	campaign.Post("/customers/validate-pending", rest.ValidatePendingCustomers)
 	campaign.Post("/customers/validate-bulk", rest.ValidateBulk)
+	campaign.Post("/customers/:id/tags", rest.AddCustomerTags)
+	campaign.Delete("/customers/:id/tags/:tag", rest.RemoveCustomerTag)
+	campaign.Get("/customers/:id/notes", rest.ListCustomerNotes)
+	campaign.Post("/customers/:id/notes", rest.CreateCustomerNote)
+	campaign.Delete("/customers/:id/notes/:noteId", rest.DeleteCustomerNote)
+
+	// Tags
+	campaign.Get("/tags", rest.ListTags)
+	campaign.Delete("/tags/:name", rest.DeleteTag)
 
 	// Groups
 	campaign.Get("/groups", rest.ListGroups)
@@ -107,6 +117,7 @@ func (h *Campaign) ListCustomers(c *fiber.Ctx) error {
 	search := c.Query("search", "")
 	filterGroupIDStr := c.Query("filter_group_id")
 	filterType := c.Query("filter_type")
+	filterTag := c.Query("tag")
 
 	var filterGroupID *uuid.UUID
 	if filterGroupIDStr != "" {
@@ -115,7 +126,7 @@ func (h *Campaign) ListCustomers(c *fiber.Ctx) error {
 		}
 	}
 
-	result, err := h.Service.ListCustomers(c.UserContext(), deviceID, page, pageSize, search, filterGroupID, filterType)
+	result, err := h.Service.ListCustomers(c.UserContext(), deviceID, page, pageSize, search, filterGroupID, filterType, filterTag)
 	if err != nil {
 		return c.Status(500).JSON(utils.ResponseData{Status: 500, Code: "ERROR", Message: err.Error()})
 	}
@@ -305,6 +316,25 @@ func (h *Campaign) ValidateCustomer(c *fiber.Ctx) error {
 	return c.JSON(utils.ResponseData{Status: 200, Code: "SUCCESS", Message: "Customer validated"})
 }
 
+func (h *Campaign) GetCustomerValidationHistory(c *fiber.Ctx) error {
+	deviceID, err := h.checkDeviceConnected(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: "Invalid customer ID"})
+	}
+
+	history, err := h.Service.GetCustomerValidationHistory(c.UserContext(), deviceID, id)
+	if err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: err.Error()})
+	}
+
+	return c.JSON(utils.ResponseData{Status: 200, Code: "SUCCESS", Message: "Validation history retrieved", Results: history})
+}
+
 func (h *Campaign) ValidatePendingCustomers(c *fiber.Ctx) error {
 	deviceID, err := h.checkDeviceConnected(c)
 	if err != nil {
@@ -355,6 +385,148 @@ func (h *Campaign) ValidateBulk(c *fiber.Ctx) error {
 	return c.JSON(utils.ResponseData{Status: 200, Code: "SUCCESS", Message: "Validation processed"})
 }
 
+// ============================================================================
+// Tag and Note Endpoints
+// ============================================================================
+
+func (h *Campaign) ListTags(c *fiber.Ctx) error {
+	deviceID, err := h.checkDeviceConnected(c)
+	if err != nil {
+		return err
+	}
+
+	tags, err := h.Service.ListTags(c.UserContext(), deviceID)
+	if err != nil {
+		return c.Status(500).JSON(utils.ResponseData{Status: 500, Code: "ERROR", Message: err.Error()})
+	}
+
+	return c.JSON(utils.ResponseData{Status: 200, Code: "SUCCESS", Message: "Tags retrieved", Results: tags})
+}
+
+func (h *Campaign) DeleteTag(c *fiber.Ctx) error {
+	deviceID, err := h.checkDeviceConnected(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.Service.DeleteTag(c.UserContext(), deviceID, c.Params("name")); err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: err.Error()})
+	}
+
+	return c.JSON(utils.ResponseData{Status: 200, Code: "SUCCESS", Message: "Tag deleted"})
+}
+
+func (h *Campaign) AddCustomerTags(c *fiber.Ctx) error {
+	deviceID, err := h.checkDeviceConnected(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: "Invalid customer ID"})
+	}
+
+	var req domainCampaign.CustomerTagsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: "Invalid request body"})
+	}
+
+	customer, err := h.Service.AddCustomerTags(c.UserContext(), deviceID, id, req.Tags)
+	if err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: err.Error()})
+	}
+
+	return c.JSON(utils.ResponseData{Status: 200, Code: "SUCCESS", Message: "Tags added", Results: customer})
+}
+
+func (h *Campaign) RemoveCustomerTag(c *fiber.Ctx) error {
+	deviceID, err := h.checkDeviceConnected(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: "Invalid customer ID"})
+	}
+
+	customer, err := h.Service.RemoveCustomerTag(c.UserContext(), deviceID, id, c.Params("tag"))
+	if err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: err.Error()})
+	}
+
+	return c.JSON(utils.ResponseData{Status: 200, Code: "SUCCESS", Message: "Tag removed", Results: customer})
+}
+
+func (h *Campaign) ListCustomerNotes(c *fiber.Ctx) error {
+	deviceID, err := h.checkDeviceConnected(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: "Invalid customer ID"})
+	}
+
+	notes, err := h.Service.ListCustomerNotes(c.UserContext(), deviceID, id)
+	if err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: err.Error()})
+	}
+
+	return c.JSON(utils.ResponseData{Status: 200, Code: "SUCCESS", Message: "Notes retrieved", Results: notes})
+}
+
+func (h *Campaign) CreateCustomerNote(c *fiber.Ctx) error {
+	deviceID, err := h.checkDeviceConnected(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: "Invalid customer ID"})
+	}
+
+	var req domainCampaign.CreateCustomerNoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: "Invalid request body"})
+	}
+	req.DeviceID = deviceID
+	req.CustomerID = id
+
+	note, err := h.Service.CreateCustomerNote(c.UserContext(), req)
+	if err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: err.Error()})
+	}
+
+	return c.JSON(utils.ResponseData{Status: 200, Code: "SUCCESS", Message: "Note created", Results: note})
+}
+
+func (h *Campaign) DeleteCustomerNote(c *fiber.Ctx) error {
+	deviceID, err := h.checkDeviceConnected(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: "Invalid customer ID"})
+	}
+
+	noteID, err := uuid.Parse(c.Params("noteId"))
+	if err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: "Invalid note ID"})
+	}
+
+	if err := h.Service.DeleteCustomerNote(c.UserContext(), deviceID, id, noteID); err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "ERROR", Message: err.Error()})
+	}
+
+	return c.JSON(utils.ResponseData{Status: 200, Code: "SUCCESS", Message: "Note deleted"})
+}
+
 // ============================================================================
 // Group Endpoints
 // ============================================================================
@@ -1,10 +1,15 @@
 package rest
 
 import (
+	"bufio"
+	"fmt"
+	"strings"
+
 	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
 	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
 )
 
 type Chat struct {
@@ -17,6 +22,13 @@ func InitRestChat(app fiber.Router, service domainChat.IChatUsecase) Chat {
 	// Chat endpoints
 	app.Get("/chats", rest.ListChats)
 	app.Get("/chat/:chat_jid/messages", rest.GetChatMessages)
+	app.Get("/messages/search", rest.SearchMessages)
+	app.Get("/messages/changes", rest.GetMessageChanges)
+	app.Get("/chats/analytics", rest.GetChatAnalytics)
+	app.Get("/chats/retention/preview", rest.PreviewRetention)
+	app.Get("/chats/export", rest.ExportChat)
+	app.Get("/chat/:chat_jid/export", rest.ExportChat)
+	app.Post("/chat/:chat_jid/import", rest.ImportChat)
 	app.Post("/chat/:chat_jid/pin", rest.PinChat)
 	app.Post("/chat/:chat_jid/disappearing", rest.SetDisappearingTimer)
 	app.Post("/chat/:chat_jid/archive", rest.ArchiveChat)
@@ -32,6 +44,15 @@ func (controller *Chat) ListChats(c *fiber.Ctx) error {
 	request.Offset = c.QueryInt("offset", 0)
 	request.Search = c.Query("search", "")
 	request.HasMedia = c.QueryBool("has_media", false)
+	request.Cursor = c.Query("cursor", "")
+
+	// Parse optional chat state filters
+	for param, target := range map[string]**bool{"unread": &request.Unread, "archived": &request.Archived, "pinned": &request.Pinned} {
+		if c.Query(param) != "" {
+			value := c.QueryBool(param)
+			*target = &value
+		}
+	}
 
 	response, err := controller.Service.ListChats(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
 	utils.PanicIfNeeded(err)
@@ -55,6 +76,7 @@ func (controller *Chat) GetChatMessages(c *fiber.Ctx) error {
 	request.Offset = c.QueryInt("offset", 0)
 	request.MediaOnly = c.QueryBool("media_only", false)
 	request.Search = c.Query("search", "")
+	request.Cursor = c.Query("cursor", "")
 
 	// Parse time filters
 	if startTime := c.Query("start_time"); startTime != "" {
@@ -81,6 +103,162 @@ func (controller *Chat) GetChatMessages(c *fiber.Ctx) error {
 	})
 }
 
+func (controller *Chat) SearchMessages(c *fiber.Ctx) error {
+	var request domainChat.SearchMessagesRequest
+
+	// Parse query parameters
+	request.Query = c.Query("query", "")
+	request.ChatJID = c.Query("chat_jid", "")
+	request.Limit = c.QueryInt("limit", 20)
+	request.Offset = c.QueryInt("offset", 0)
+	request.MediaOnly = c.QueryBool("media_only", false)
+
+	// Parse time filters
+	if startTime := c.Query("start_time"); startTime != "" {
+		request.StartTime = &startTime
+	}
+	if endTime := c.Query("end_time"); endTime != "" {
+		request.EndTime = &endTime
+	}
+
+	response, err := controller.Service.SearchMessages(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success search messages",
+		Results: response,
+	})
+}
+
+// GetMessageChanges returns the messages stored, changed or deleted since a sync cursor
+func (controller *Chat) GetMessageChanges(c *fiber.Ctx) error {
+	var request domainChat.GetMessageChangesRequest
+
+	// Parse query parameters
+	request.ChatJID = c.Query("chat_jid", "")
+	request.Cursor = c.Query("cursor", "")
+	request.Limit = c.QueryInt("limit", 100)
+
+	response, err := controller.Service.GetMessageChanges(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get message changes",
+		Results: response,
+	})
+}
+
+// GetChatAnalytics aggregates message volume, top chats, response time, media and group senders
+func (controller *Chat) GetChatAnalytics(c *fiber.Ctx) error {
+	var request domainChat.GetChatAnalyticsRequest
+
+	// Parse query parameters
+	request.ChatJID = c.Query("chat_jid", "")
+	request.Granularity = c.Query("granularity", "")
+	request.Limit = c.QueryInt("limit", 10)
+	if startTime := c.Query("start_time"); startTime != "" {
+		request.StartTime = &startTime
+	}
+	if endTime := c.Query("end_time"); endTime != "" {
+		request.EndTime = &endTime
+	}
+
+	response, err := controller.Service.GetChatAnalytics(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get chat analytics",
+		Results: response,
+	})
+}
+
+func (controller *Chat) PreviewRetention(c *fiber.Ctx) error {
+	response, err := controller.Service.PreviewRetention(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)))
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success preview retention",
+		Results: response,
+	})
+}
+
+// ExportChat streams a chat transcript, or a date range of every chat when no chat_jid is given
+func (controller *Chat) ExportChat(c *fiber.Ctx) error {
+	var request domainChat.ExportChatRequest
+
+	// The transcript is written after the handler returns, so copy the request values
+	request.ChatJID = strings.Clone(c.Params("chat_jid"))
+	request.Format = strings.Clone(c.Query("format", ""))
+	request.IncludeMedia = c.QueryBool("include_media", false)
+	if startTime := c.Query("start_time"); startTime != "" {
+		startTime = strings.Clone(startTime)
+		request.StartTime = &startTime
+	}
+	if endTime := c.Query("end_time"); endTime != "" {
+		endTime = strings.Clone(endTime)
+		request.EndTime = &endTime
+	}
+
+	response, err := controller.Service.ExportChat(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	utils.PanicIfNeeded(err)
+
+	c.Attachment(response.FileName)
+	c.Set(fiber.HeaderContentType, response.ContentType)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := response.WriteTo(w); err != nil {
+			logrus.Errorf("Failed to export %s: %v", response.FileName, err)
+		}
+	})
+	return nil
+}
+
+// ImportChat imports a WhatsApp "Export chat" ZIP or .txt uploaded as the file form field
+func (controller *Chat) ImportChat(c *fiber.Ctx) error {
+	var request domainChat.ImportChatRequest
+
+	request.ChatJID = c.Params("chat_jid")
+	request.ChatName = c.FormValue("chat_name")
+	request.SelfName = c.FormValue("self_name")
+	request.DateOrder = c.FormValue("date_order")
+	request.Timezone = c.FormValue("timezone")
+
+	// Participants are sent as name=phone pairs separated by commas
+	request.Participants = map[string]string{}
+	for _, pair := range strings.Split(c.FormValue("participants"), ",") {
+		if name, phone, ok := strings.Cut(pair, "="); ok {
+			request.Participants[strings.TrimSpace(name)] = strings.TrimSpace(phone)
+		}
+	}
+
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		utils.PanicIfNeeded(err)
+		defer f.Close()
+
+		request.File = f
+		request.FileName = file.Filename
+		request.FileSize = file.Size
+	}
+
+	response, err := controller.Service.ImportChat(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: fmt.Sprintf("Imported %d messages into %s", response.Imported, response.ChatJID),
+		Results: response,
+	})
+}
+
 func (controller *Chat) PinChat(c *fiber.Ctx) error {
 	var request domainChat.PinChatRequest
 
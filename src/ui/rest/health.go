@@ -0,0 +1,28 @@
+package rest
+
+import (
+	domainMediaProc "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediaproc"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Health struct {
+	MediaProcessor domainMediaProc.IMediaProcessor
+}
+
+func InitRestHealth(app fiber.Router, mediaProcessor domainMediaProc.IMediaProcessor) Health {
+	rest := Health{MediaProcessor: mediaProcessor}
+	app.Get("/health/media", rest.Media)
+	return rest
+}
+
+// Media reports the media processing backend and which operations it can run,
+// e.g. whether videos get thumbnails without ffmpeg
+func (handler *Health) Media(c *fiber.Ctx) error {
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Media processing capabilities",
+		Results: handler.MediaProcessor.Capabilities(),
+	})
+}
@@ -0,0 +1,145 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/mediastore"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type MediaFiles struct {
+	Store *mediastore.LocalStore
+}
+
+// InitRestMediaFiles serves the signed links of locally stored media. The links
+// carry their own authorization, so the route is registered before basic auth.
+func InitRestMediaFiles(app fiber.Router, store *mediastore.LocalStore) MediaFiles {
+	rest := MediaFiles{Store: store}
+	app.Get(strings.TrimSuffix(mediastore.LocalRoute, "/")+"/*", rest.ServeMedia)
+	return rest
+}
+
+func (controller *MediaFiles) ServeMedia(c *fiber.Ctx) error {
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return c.Status(400).JSON(utils.ResponseData{Status: 400, Code: "BAD_REQUEST", Message: "invalid media key"})
+	}
+	if err := controller.Store.Verify(key, c.Query("expires"), c.Query("signature")); err != nil {
+		return c.Status(403).JSON(utils.ResponseData{Status: 403, Code: "FORBIDDEN", Message: err.Error()})
+	}
+
+	object, err := controller.Store.Stat(c.UserContext(), key)
+	if errors.Is(err, domainMediaStore.ErrNotFound) {
+		return c.Status(404).JSON(utils.ResponseData{Status: 404, Code: "NOT_FOUND", Message: "media not found"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(utils.ResponseData{Status: 500, Code: "INTERNAL_SERVER_ERROR", Message: err.Error()})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+	return sendMedia(c, object.Size, object.ContentType, object.ModTime, func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		reader, _, err := controller.Store.OpenRange(ctx, key, offset, length)
+		return reader, err
+	})
+}
+
+// sendMedia streams a media file, or the byte range the Range header asks for
+// so players can seek in audio and video
+func sendMedia(c *fiber.Ctx, size int64, contentType string, modTime time.Time, open func(ctx context.Context, offset, length int64) (io.ReadCloser, error)) error {
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	c.Set(fiber.HeaderContentType, contentType)
+	if !modTime.IsZero() {
+		c.Set(fiber.HeaderLastModified, modTime.UTC().Format(http.TimeFormat))
+	}
+
+	status, offset, length := fiber.StatusOK, int64(0), size
+	if header := c.Get(fiber.HeaderRange); header != "" {
+		start, end, ok, err := parseByteRange(header, size)
+		if err != nil {
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", size))
+			return c.Status(fiber.StatusRequestedRangeNotSatisfiable).JSON(utils.ResponseData{
+				Status:  fiber.StatusRequestedRangeNotSatisfiable,
+				Code:    "RANGE_NOT_SATISFIABLE",
+				Message: err.Error(),
+			})
+		}
+		if ok {
+			status, offset, length = fiber.StatusPartialContent, start, end-start+1
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+		}
+	}
+
+	// The body is streamed after the handler returns, past the request timeout
+	reader, err := open(context.WithoutCancel(c.UserContext()), offset, length)
+	if err != nil {
+		return c.Status(500).JSON(utils.ResponseData{Status: 500, Code: "INTERNAL_SERVER_ERROR", Message: err.Error()})
+	}
+	c.Status(status)
+	return c.SendStream(reader, int(length))
+}
+
+// parseByteRange reads a single range of a Range header as the first and last
+// byte it asks for. Headers it cannot serve as one range, such as several
+// ranges or other units, are ignored and the whole file is sent; a range
+// starting past the end of the file is an error.
+func parseByteRange(header string, size int64) (start, end int64, ok bool, err error) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false, nil
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false, nil
+	}
+
+	if first == "" {
+		// The last n bytes
+		suffix, parseErr := strconv.ParseInt(last, 10, 64)
+		if parseErr != nil || suffix < 0 {
+			return 0, 0, false, nil
+		}
+		if suffix == 0 || size == 0 {
+			return 0, 0, false, fmt.Errorf("range %s is not satisfiable", header)
+		}
+		return max(size-suffix, 0), size - 1, true, nil
+	}
+
+	start, parseErr := strconv.ParseInt(first, 10, 64)
+	if parseErr != nil || start < 0 {
+		return 0, 0, false, nil
+	}
+	end = size - 1
+	if last != "" {
+		if end, parseErr = strconv.ParseInt(last, 10, 64); parseErr != nil || end < start {
+			return 0, 0, false, nil
+		}
+		end = min(end, size-1)
+	}
+	if start >= size {
+		return 0, 0, false, fmt.Errorf("range %s is not satisfiable", header)
+	}
+	return start, end, true, nil
+}
+
+// contentDisposition shows media inline, or as a download when asked to
+func contentDisposition(filename string, download bool) string {
+	disposition := "inline"
+	if download {
+		disposition = "attachment"
+	}
+	if value := mime.FormatMediaType(disposition, map[string]string{"filename": filename}); value != "" {
+		return value
+	}
+	return disposition
+}
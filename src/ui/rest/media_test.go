@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/mediastore"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestParseByteRange(t *testing.T) {
+	tests := []struct {
+		header     string
+		start, end int64
+		ok         bool
+		err        bool
+	}{
+		{header: "bytes=0-99", start: 0, end: 99, ok: true},
+		{header: "bytes=100-", start: 100, end: 999, ok: true},
+		{header: "bytes=900-5000", start: 900, end: 999, ok: true},
+		{header: "bytes=-100", start: 900, end: 999, ok: true},
+		{header: "bytes=-5000", start: 0, end: 999, ok: true},
+		{header: "bytes=1000-", err: true},
+		{header: "bytes=-0", err: true},
+		{header: "bytes=0-99,200-299"},
+		{header: "bytes=99-0"},
+		{header: "items=0-5"},
+		{header: "bytes=abc"},
+	}
+	for _, tt := range tests {
+		start, end, ok, err := parseByteRange(tt.header, 1000)
+		if (err != nil) != tt.err || ok != tt.ok || start != tt.start || end != tt.end {
+			t.Errorf("parseByteRange(%q) = %d, %d, %v, %v", tt.header, start, end, ok, err)
+		}
+	}
+}
+
+func TestServeMedia(t *testing.T) {
+	store := mediastore.NewLocalStore(t.TempDir(), "", []byte("secret"))
+	key := "628/2025-03-04/3EB0C127D7BACC83D6A3.ogg"
+	if err := store.Put(context.Background(), key, []byte("0123456789"), "audio/ogg"); err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	InitRestMediaFiles(app, store)
+
+	link, err := store.URL(context.Background(), key, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := httptest.NewRequest("GET", link, nil)
+	request.Header.Set("Range", "bytes=2-5")
+	resp, err := app.Test(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 206 || string(body) != "2345" {
+		t.Fatalf("range request = %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get("Content-Range") != "bytes 2-5/10" || resp.Header.Get("Content-Type") != "audio/ogg" || resp.Header.Get("Accept-Ranges") != "bytes" {
+		t.Errorf("range headers = %v", resp.Header)
+	}
+
+	request = httptest.NewRequest("GET", link, nil)
+	request.Header.Set("Range", "bytes=10-")
+	if resp, _ := app.Test(request); resp.StatusCode != 416 || resp.Header.Get("Content-Range") != "bytes */10" {
+		t.Errorf("unsatisfiable range = %d %s", resp.StatusCode, resp.Header.Get("Content-Range"))
+	}
+
+	if resp, _ := app.Test(httptest.NewRequest("GET", link, nil)); resp.StatusCode != 200 {
+		t.Errorf("full request = %d", resp.StatusCode)
+	} else if body, _ := io.ReadAll(resp.Body); string(body) != "0123456789" {
+		t.Errorf("full request body = %q", body)
+	}
+
+	tampered, _ := url.Parse(link)
+	query := tampered.Query()
+	query.Set("expires", "9999999999")
+	tampered.RawQuery = query.Encode()
+	if resp, _ := app.Test(httptest.NewRequest("GET", tampered.String(), nil)); resp.StatusCode != 403 {
+		t.Errorf("tampered link = %d, want 403", resp.StatusCode)
+	}
+}
@@ -19,10 +19,13 @@ func InitRestMessage(app fiber.Router, service domainMessage.IMessageUsecase) Me
 	app.Post("/message/:message_id/revoke", rest.RevokeMessage)
 	app.Post("/message/:message_id/delete", rest.DeleteMessage)
 	app.Post("/message/:message_id/update", rest.UpdateMessage)
+	app.Post("/message/:message_id/forward", rest.ForwardMessage)
 	app.Post("/message/:message_id/read", rest.MarkAsRead)
 	app.Post("/message/:message_id/star", rest.StarMessage)
 	app.Post("/message/:message_id/unstar", rest.UnstarMessage)
 	app.Get("/message/:message_id/download", rest.DownloadMedia)
+	app.Get("/message/:message_id/media", rest.StreamMedia)
+	app.Get("/message/:message_id/media/link", rest.MediaLink)
 	return rest
 }
 
@@ -45,6 +48,27 @@ func (controller *Message) RevokeMessage(c *fiber.Ctx) error {
 	})
 }
 
+func (controller *Message) ForwardMessage(c *fiber.Ctx) error {
+	var request domainMessage.ForwardRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	request.MessageID = c.Params("message_id")
+	for i := range request.Phones {
+		utils.SanitizePhone(&request.Phones[i])
+	}
+
+	response, err := controller.Service.ForwardMessage(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
 func (controller *Message) DeleteMessage(c *fiber.Ctx) error {
 	var request domainMessage.DeleteRequest
 	err := c.BodyParser(&request)
@@ -182,3 +206,38 @@ func (controller *Message) DownloadMedia(c *fiber.Ctx) error {
 		Results: response,
 	})
 }
+
+// StreamMedia streams the media of a message, downloading it first when needed,
+// and answers range requests so audio and video can be seeked
+func (controller *Message) StreamMedia(c *fiber.Ctx) error {
+	request := domainMessage.MediaRequest{MessageID: c.Params("message_id")}
+
+	response, err := controller.Service.GetMedia(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	utils.PanicIfNeeded(err)
+	if response == nil {
+		return c.Status(404).JSON(utils.ResponseData{Status: 404, Code: "NOT_FOUND", Message: "Message not found"})
+	}
+
+	c.Set(fiber.HeaderContentDisposition, contentDisposition(response.Filename, c.QueryBool("download", false)))
+	c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+	return sendMedia(c, response.FileSize, response.ContentType, response.ModTime, response.Open)
+}
+
+// MediaLink returns a short-lived link to the media of a message that works
+// without credentials, e.g. as the source of an audio or video element
+func (controller *Message) MediaLink(c *fiber.Ctx) error {
+	request := domainMessage.MediaRequest{MessageID: c.Params("message_id")}
+
+	response, err := controller.Service.GetMedia(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	utils.PanicIfNeeded(err)
+	if response == nil {
+		return c.Status(404).JSON(utils.ResponseData{Status: 404, Code: "NOT_FOUND", Message: "Message not found"})
+	}
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get media link",
+		Results: response,
+	})
+}
@@ -1,18 +1,25 @@
 package rest
 
 import (
+	"encoding/json"
+	"errors"
+	"strings"
+
 	domainSend "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/send"
+	domainSendQueue "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/sendqueue"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
 type Send struct {
 	Service domainSend.ISendUsecase
+	Queue   domainSendQueue.IQueueUsecase
 }
 
-func InitRestSend(app fiber.Router, service domainSend.ISendUsecase) Send {
-	rest := Send{Service: service}
+func InitRestSend(app fiber.Router, service domainSend.ISendUsecase, queue domainSendQueue.IQueueUsecase) Send {
+	rest := Send{Service: service, Queue: queue}
 	app.Post("/send/message", rest.SendText)
 	app.Post("/send/image", rest.SendImage)
 	app.Post("/send/file", rest.SendFile)
@@ -23,8 +30,18 @@ func InitRestSend(app fiber.Router, service domainSend.ISendUsecase) Send {
 	app.Post("/send/location", rest.SendLocation)
 	app.Post("/send/audio", rest.SendAudio)
 	app.Post("/send/poll", rest.SendPoll)
+	app.Post("/send/list", rest.SendList)
+	app.Post("/send/buttons", rest.SendButtons)
+	app.Post("/send/event", rest.SendEvent)
+	app.Post("/send/album", rest.SendAlbum)
 	app.Post("/send/presence", rest.SendPresence)
 	app.Post("/send/chat-presence", rest.SendChatPresence)
+	app.Post("/send/bulk", rest.SendBulk)
+	app.Get("/send/bulk/:batch_id", rest.GetBulk)
+	app.Get("/send/queue", rest.ListQueue)
+	app.Get("/send/queue/:queue_id", rest.GetQueueItem)
+	app.Post("/send/queue/:queue_id/cancel", rest.CancelQueueItem)
+	app.Post("/send/queue/:queue_id/reschedule", rest.RescheduleQueueItem)
 	return rest
 }
 
@@ -35,6 +52,10 @@ func (controller *Send) SendText(c *fiber.Ctx) error {
 
 	utils.SanitizePhone(&request.Phone)
 
+	if schedule, queued := queuedSend(c); queued {
+		return controller.enqueue(c, domainSendQueue.KindText, request, schedule)
+	}
+
 	response, err := controller.Service.SendText(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
 	utils.PanicIfNeeded(err)
 
@@ -60,6 +81,10 @@ func (controller *Send) SendImage(c *fiber.Ctx) error {
 
 	utils.SanitizePhone(&request.Phone)
 
+	if schedule, queued := queuedSend(c); queued {
+		return controller.enqueue(c, domainSendQueue.KindImage, request, schedule)
+	}
+
 	response, err := controller.Service.SendImage(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
 	utils.PanicIfNeeded(err)
 
@@ -82,6 +107,10 @@ func (controller *Send) SendFile(c *fiber.Ctx) error {
 	request.File = file
 	utils.SanitizePhone(&request.Phone)
 
+	if schedule, queued := queuedSend(c); queued {
+		return controller.enqueue(c, domainSendQueue.KindFile, request, schedule)
+	}
+
 	response, err := controller.Service.SendFile(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
 	utils.PanicIfNeeded(err)
 
@@ -105,6 +134,10 @@ func (controller *Send) SendVideo(c *fiber.Ctx) error {
 
 	utils.SanitizePhone(&request.Phone)
 
+	if schedule, queued := queuedSend(c); queued {
+		return controller.enqueue(c, domainSendQueue.KindVideo, request, schedule)
+	}
+
 	response, err := controller.Service.SendVideo(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
 	utils.PanicIfNeeded(err)
 
@@ -128,6 +161,10 @@ func (controller *Send) SendSticker(c *fiber.Ctx) error {
 
 	utils.SanitizePhone(&request.Phone)
 
+	if schedule, queued := queuedSend(c); queued {
+		return controller.enqueue(c, domainSendQueue.KindSticker, request, schedule)
+	}
+
 	response, err := controller.Service.SendSticker(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
 	utils.PanicIfNeeded(err)
 
@@ -146,6 +183,10 @@ func (controller *Send) SendContact(c *fiber.Ctx) error {
 
 	utils.SanitizePhone(&request.Phone)
 
+	if schedule, queued := queuedSend(c); queued {
+		return controller.enqueue(c, domainSendQueue.KindContact, request, schedule)
+	}
+
 	response, err := controller.Service.SendContact(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
 	utils.PanicIfNeeded(err)
 
@@ -164,6 +205,10 @@ func (controller *Send) SendLink(c *fiber.Ctx) error {
 
 	utils.SanitizePhone(&request.Phone)
 
+	if schedule, queued := queuedSend(c); queued {
+		return controller.enqueue(c, domainSendQueue.KindLink, request, schedule)
+	}
+
 	response, err := controller.Service.SendLink(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
 	utils.PanicIfNeeded(err)
 
@@ -182,6 +227,10 @@ func (controller *Send) SendLocation(c *fiber.Ctx) error {
 
 	utils.SanitizePhone(&request.Phone)
 
+	if schedule, queued := queuedSend(c); queued {
+		return controller.enqueue(c, domainSendQueue.KindLocation, request, schedule)
+	}
+
 	response, err := controller.Service.SendLocation(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
 	utils.PanicIfNeeded(err)
 
@@ -205,6 +254,10 @@ func (controller *Send) SendAudio(c *fiber.Ctx) error {
 
 	utils.SanitizePhone(&request.Phone)
 
+	if schedule, queued := queuedSend(c); queued {
+		return controller.enqueue(c, domainSendQueue.KindAudio, request, schedule)
+	}
+
 	response, err := controller.Service.SendAudio(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
 	utils.PanicIfNeeded(err)
 
@@ -223,6 +276,10 @@ func (controller *Send) SendPoll(c *fiber.Ctx) error {
 
 	utils.SanitizePhone(&request.Phone)
 
+	if schedule, queued := queuedSend(c); queued {
+		return controller.enqueue(c, domainSendQueue.KindPoll, request, schedule)
+	}
+
 	response, err := controller.Service.SendPoll(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
 	utils.PanicIfNeeded(err)
 
@@ -234,6 +291,120 @@ func (controller *Send) SendPoll(c *fiber.Ctx) error {
 	})
 }
 
+func (controller *Send) SendList(c *fiber.Ctx) error {
+	var request domainSend.ListRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	utils.SanitizePhone(&request.Phone)
+
+	if schedule, queued := queuedSend(c); queued {
+		return controller.enqueue(c, domainSendQueue.KindList, request, schedule)
+	}
+
+	response, err := controller.Service.SendList(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
+func (controller *Send) SendButtons(c *fiber.Ctx) error {
+	var request domainSend.ButtonsRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	utils.SanitizePhone(&request.Phone)
+
+	if schedule, queued := queuedSend(c); queued {
+		return controller.enqueue(c, domainSendQueue.KindButtons, request, schedule)
+	}
+
+	response, err := controller.Service.SendButtons(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
+func (controller *Send) SendEvent(c *fiber.Ctx) error {
+	var request domainSend.EventRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	utils.SanitizePhone(&request.Phone)
+
+	if schedule, queued := queuedSend(c); queued {
+		return controller.enqueue(c, domainSendQueue.KindEvent, request, schedule)
+	}
+
+	response, err := controller.Service.SendEvent(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
+// SendAlbum sends images and videos grouped as one album. JSON requests list the
+// items with their URLs; multipart requests upload them as repeated "files" with
+// the "captions" fields matched by position.
+func (controller *Send) SendAlbum(c *fiber.Ctx) error {
+	request := domainSend.AlbumRequest{Compress: true}
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	if form, err := c.MultipartForm(); err == nil {
+		captions := form.Value["captions"]
+		for i, file := range form.File["files"] {
+			item := domainSend.AlbumItem{File: file}
+			switch contentType := file.Header.Get("Content-Type"); {
+			case strings.HasPrefix(contentType, "image/"):
+				item.Type = domainSend.AlbumItemImage
+			case strings.HasPrefix(contentType, "video/"):
+				item.Type = domainSend.AlbumItemVideo
+			}
+			if i < len(captions) {
+				item.Caption = captions[i]
+			}
+			request.Items = append(request.Items, item)
+		}
+	}
+
+	utils.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.SendAlbum(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	var sendErr pkgError.GenericError
+	if errors.As(err, &sendErr) && response.MessageID != "" {
+		// Part of the album went out; report which messages were sent with the error
+		return c.Status(sendErr.StatusCode()).JSON(utils.ResponseData{
+			Status:  sendErr.StatusCode(),
+			Code:    sendErr.ErrCode(),
+			Message: sendErr.Error(),
+			Results: response,
+		})
+	}
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
 func (controller *Send) SendPresence(c *fiber.Ctx) error {
 	var request domainSend.PresenceRequest
 	err := c.BodyParser(&request)
@@ -267,3 +438,256 @@ func (controller *Send) SendChatPresence(c *fiber.Ctx) error {
 		Results: response,
 	})
 }
+
+// queuedSend reports whether the request asked to go through the send queue
+// instead of being sent right away: with async=true, or a send_at or
+// recurrence to send it later
+func queuedSend(c *fiber.Ctx) (domainSendQueue.Schedule, bool) {
+	var schedule domainSendQueue.Schedule
+	if err := c.BodyParser(&schedule); err != nil {
+		utils.PanicIfNeeded(pkgError.ValidationError(err.Error()))
+	}
+	return schedule, c.QueryBool("async") || schedule.SendAt != "" || schedule.Recurrence != ""
+}
+
+// enqueue puts the request on the send queue and answers with the queued item.
+// Retries carrying the same Idempotency-Key get the first item back.
+func (controller *Send) enqueue(c *fiber.Ctx, kind string, request any, schedule domainSendQueue.Schedule) error {
+	if controller.Queue == nil {
+		utils.PanicIfNeeded(pkgError.InternalServerError("send queue is not available"))
+	}
+
+	response, err := controller.Queue.Enqueue(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), domainSendQueue.EnqueueRequest{
+		Schedule:       schedule,
+		Kind:           kind,
+		IdempotencyKey: c.Get("Idempotency-Key"),
+		Request:        request,
+	})
+	utils.PanicIfNeeded(err)
+
+	message := "Message queued"
+	if response.Item.ScheduledAt != nil {
+		message = "Message scheduled"
+	}
+	if response.Duplicate {
+		message = "Message was already queued with this idempotency key"
+	}
+	return c.Status(202).JSON(utils.ResponseData{
+		Status:  202,
+		Code:    "QUEUED",
+		Message: message,
+		Results: response,
+	})
+}
+
+// SendBulk queues one send request for many recipients. The request fields of the
+// chosen type sit next to the recipients; multipart requests carry the
+// recipients as a JSON encoded form field.
+func (controller *Send) SendBulk(c *fiber.Ctx) error {
+	if controller.Queue == nil {
+		utils.PanicIfNeeded(pkgError.InternalServerError("send queue is not available"))
+	}
+
+	var request domainSendQueue.BulkRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	if recipients := c.FormValue("recipients"); recipients != "" && request.Recipients == nil {
+		if err := json.Unmarshal([]byte(recipients), &request.Recipients); err != nil {
+			utils.PanicIfNeeded(pkgError.ValidationError("recipients must be a JSON array of {\"phone\", \"variables\"} objects"))
+		}
+	}
+	for i := range request.Recipients {
+		utils.SanitizePhone(&request.Recipients[i].Phone)
+	}
+
+	request.Request, err = parseBulkSendRequest(c, request.Kind)
+	utils.PanicIfNeeded(err)
+	request.IdempotencyKey = c.Get("Idempotency-Key")
+
+	response, err := controller.Queue.EnqueueBulk(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	utils.PanicIfNeeded(err)
+
+	message := "Bulk send queued"
+	if response.Duplicate {
+		message = "Bulk send was already queued with this idempotency key"
+	}
+	return c.Status(202).JSON(utils.ResponseData{
+		Status:  202,
+		Code:    "QUEUED",
+		Message: message,
+		Results: response,
+	})
+}
+
+// parseBulkSendRequest parses the request of a bulk send the way the /send
+// endpoint of its type does
+func parseBulkSendRequest(c *fiber.Ctx, kind string) (any, error) {
+	switch kind {
+	case domainSendQueue.KindText:
+		var request domainSend.MessageRequest
+		return request, c.BodyParser(&request)
+	case domainSendQueue.KindImage:
+		request := domainSend.ImageRequest{Compress: true}
+		err := c.BodyParser(&request)
+		if file, errFile := c.FormFile("image"); errFile == nil {
+			request.Image = file
+		}
+		return request, err
+	case domainSendQueue.KindFile:
+		var request domainSend.FileRequest
+		err := c.BodyParser(&request)
+		if file, errFile := c.FormFile("file"); errFile == nil {
+			request.File = file
+		}
+		return request, err
+	case domainSendQueue.KindVideo:
+		var request domainSend.VideoRequest
+		err := c.BodyParser(&request)
+		if file, errFile := c.FormFile("video"); errFile == nil {
+			request.Video = file
+		}
+		return request, err
+	case domainSendQueue.KindSticker:
+		var request domainSend.StickerRequest
+		err := c.BodyParser(&request)
+		if file, errFile := c.FormFile("sticker"); errFile == nil {
+			request.Sticker = file
+		}
+		return request, err
+	case domainSendQueue.KindContact:
+		var request domainSend.ContactRequest
+		return request, c.BodyParser(&request)
+	case domainSendQueue.KindLink:
+		var request domainSend.LinkRequest
+		return request, c.BodyParser(&request)
+	case domainSendQueue.KindLocation:
+		var request domainSend.LocationRequest
+		return request, c.BodyParser(&request)
+	case domainSendQueue.KindAudio:
+		var request domainSend.AudioRequest
+		err := c.BodyParser(&request)
+		if file, errFile := c.FormFile("audio"); errFile == nil {
+			request.Audio = file
+		}
+		return request, err
+	case domainSendQueue.KindPoll:
+		var request domainSend.PollRequest
+		return request, c.BodyParser(&request)
+	case domainSendQueue.KindList:
+		var request domainSend.ListRequest
+		return request, c.BodyParser(&request)
+	case domainSendQueue.KindButtons:
+		var request domainSend.ButtonsRequest
+		return request, c.BodyParser(&request)
+	case domainSendQueue.KindEvent:
+		var request domainSend.EventRequest
+		return request, c.BodyParser(&request)
+	}
+	return nil, pkgError.ValidationError("type must be one of message, image, file, video, sticker, contact, link, location, audio, poll, list, buttons or event")
+}
+
+func (controller *Send) GetBulk(c *fiber.Ctx) error {
+	if controller.Queue == nil {
+		utils.PanicIfNeeded(pkgError.InternalServerError("send queue is not available"))
+	}
+
+	response, err := controller.Queue.GetBatch(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), c.Params("batch_id"))
+	utils.PanicIfNeeded(err)
+
+	if response == nil {
+		return c.Status(404).JSON(utils.ResponseData{Status: 404, Code: "NOT_FOUND", Message: "Bulk send not found"})
+	}
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get bulk send",
+		Results: response,
+	})
+}
+
+func (controller *Send) ListQueue(c *fiber.Ctx) error {
+	if controller.Queue == nil {
+		utils.PanicIfNeeded(pkgError.InternalServerError("send queue is not available"))
+	}
+
+	var request domainSendQueue.ListItemsRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	response, err := controller.Queue.ListItems(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get send queue",
+		Results: response,
+	})
+}
+
+func (controller *Send) GetQueueItem(c *fiber.Ctx) error {
+	if controller.Queue == nil {
+		utils.PanicIfNeeded(pkgError.InternalServerError("send queue is not available"))
+	}
+
+	item, err := controller.Queue.GetItem(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), c.Params("queue_id"))
+	utils.PanicIfNeeded(err)
+
+	if item == nil {
+		return c.Status(404).JSON(utils.ResponseData{Status: 404, Code: "NOT_FOUND", Message: "Queued message not found"})
+	}
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get queued message",
+		Results: item,
+	})
+}
+
+func (controller *Send) CancelQueueItem(c *fiber.Ctx) error {
+	if controller.Queue == nil {
+		utils.PanicIfNeeded(pkgError.InternalServerError("send queue is not available"))
+	}
+
+	item, err := controller.Queue.CancelItem(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), c.Params("queue_id"))
+	utils.PanicIfNeeded(err)
+
+	if item == nil {
+		return c.Status(404).JSON(utils.ResponseData{Status: 404, Code: "NOT_FOUND", Message: "Queued message not found"})
+	}
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Queued message cancelled",
+		Results: item,
+	})
+}
+
+func (controller *Send) RescheduleQueueItem(c *fiber.Ctx) error {
+	if controller.Queue == nil {
+		utils.PanicIfNeeded(pkgError.InternalServerError("send queue is not available"))
+	}
+
+	var request domainSendQueue.RescheduleItemRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+	request.ID = c.Params("queue_id")
+
+	item, err := controller.Queue.RescheduleItem(whatsapp.ContextWithDevice(c.UserContext(), getDeviceFromCtx(c)), request)
+	utils.PanicIfNeeded(err)
+
+	if item == nil {
+		return c.Status(404).JSON(utils.ResponseData{Status: 404, Code: "NOT_FOUND", Message: "Queued message not found"})
+	}
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Queued message rescheduled",
+		Results: item,
+	})
+}
@@ -23,6 +23,7 @@ import (
 	domainCampaign "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/campaign"
 	domainSend "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/send"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
 )
@@ -38,6 +39,10 @@ type CampaignService struct {
 	workerCancel context.CancelFunc
 	workerWg     sync.WaitGroup
 	workerMu     sync.Mutex
+
+	// Sender devices currently draining their queue
+	busyMu      sync.Mutex
+	busyDevices map[string]bool
 }
 
 // NewCampaignService creates a new campaign service
@@ -46,6 +51,7 @@ func NewCampaignService(repo domainCampaign.ICampaignRepository, sendService dom
 		repo:        repo,
 		sendService: sendService,
 		basePath:    basePath,
+		busyDevices: make(map[string]bool),
 	}
 }
 
@@ -246,10 +252,15 @@ func (s *CampaignService) ImportCustomersFromCSV(ctx context.Context, deviceID s
 }
 
 func (s *CampaignService) GetCustomer(ctx context.Context, deviceID string, id uuid.UUID) (*domainCampaign.Customer, error) {
-	return s.repo.GetCustomer(ctx, deviceID, id)
+	customer, err := s.repo.GetCustomer(ctx, deviceID, id)
+	if err != nil || customer == nil {
+		return customer, err
+	}
+	s.attachTags(ctx, customer)
+	return customer, nil
 }
 
-func (s *CampaignService) ListCustomers(ctx context.Context, deviceID string, page, pageSize int, search string, filterGroupID *uuid.UUID, filterType string) (*domainCampaign.CustomerListResponse, error) {
+func (s *CampaignService) ListCustomers(ctx context.Context, deviceID string, page, pageSize int, search string, filterGroupID *uuid.UUID, filterType, filterTag string) (*domainCampaign.CustomerListResponse, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -258,10 +269,11 @@ func (s *CampaignService) ListCustomers(ctx context.Context, deviceID string, pa
 	}
 
 	offset := (page - 1) * pageSize
-	customers, total, err := s.repo.ListCustomers(ctx, deviceID, pageSize, offset, search, filterGroupID, filterType)
+	customers, total, err := s.repo.ListCustomers(ctx, deviceID, pageSize, offset, search, filterGroupID, filterType, filterTag)
 	if err != nil {
 		return nil, err
 	}
+	s.attachTags(ctx, customers...)
 
 	totalPages := (total + pageSize - 1) / pageSize
 
@@ -326,6 +338,163 @@ func (s *CampaignService) DeleteCustomers(ctx context.Context, deviceID string,
 	return s.repo.DeleteCustomers(ctx, deviceID, ids)
 }
 
+// ============================================================================
+// Tags and Notes
+// ============================================================================
+
+// attachTags fills Customer.Tags for the given customers
+func (s *CampaignService) attachTags(ctx context.Context, customers ...*domainCampaign.Customer) {
+	if len(customers) == 0 {
+		return
+	}
+
+	ids := make([]uuid.UUID, len(customers))
+	for i, customer := range customers {
+		ids[i] = customer.ID
+	}
+
+	tags, err := s.repo.GetCustomersTags(ctx, ids)
+	if err != nil {
+		logrus.Warnf("Failed to load customer tags: %v", err)
+		return
+	}
+	for _, customer := range customers {
+		customer.Tags = tags[customer.ID]
+		if customer.Tags == nil {
+			customer.Tags = []string{}
+		}
+	}
+}
+
+// normalizeTags trims, lowercases and de-duplicates tag names
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+func (s *CampaignService) ListTags(ctx context.Context, deviceID string) ([]*domainCampaign.Tag, error) {
+	return s.repo.ListTags(ctx, deviceID)
+}
+
+func (s *CampaignService) DeleteTag(ctx context.Context, deviceID, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("tag name is required")
+	}
+	return s.repo.DeleteTag(ctx, deviceID, name)
+}
+
+func (s *CampaignService) AddCustomerTags(ctx context.Context, deviceID string, customerID uuid.UUID, tags []string) (*domainCampaign.Customer, error) {
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return nil, errors.New("at least one tag is required")
+	}
+
+	customer, err := s.repo.GetCustomer(ctx, deviceID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, errors.New("customer not found")
+	}
+
+	if err := s.repo.AddCustomerTags(ctx, deviceID, customerID, tags); err != nil {
+		return nil, err
+	}
+
+	s.attachTags(ctx, customer)
+	return customer, nil
+}
+
+func (s *CampaignService) RemoveCustomerTag(ctx context.Context, deviceID string, customerID uuid.UUID, tag string) (*domainCampaign.Customer, error) {
+	customer, err := s.repo.GetCustomer(ctx, deviceID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, errors.New("customer not found")
+	}
+
+	if err := s.repo.RemoveCustomerTag(ctx, deviceID, customerID, tag); err != nil {
+		return nil, err
+	}
+
+	s.attachTags(ctx, customer)
+	return customer, nil
+}
+
+func (s *CampaignService) CreateCustomerNote(ctx context.Context, req domainCampaign.CreateCustomerNoteRequest) (*domainCampaign.CustomerNote, error) {
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, errors.New("note content is required")
+	}
+
+	customer, err := s.repo.GetCustomer(ctx, req.DeviceID, req.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, errors.New("customer not found")
+	}
+
+	note := &domainCampaign.CustomerNote{
+		CustomerID: req.CustomerID,
+		Content:    content,
+	}
+	if err := s.repo.CreateCustomerNote(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (s *CampaignService) ListCustomerNotes(ctx context.Context, deviceID string, customerID uuid.UUID) ([]*domainCampaign.CustomerNote, error) {
+	customer, err := s.repo.GetCustomer(ctx, deviceID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, errors.New("customer not found")
+	}
+	return s.repo.ListCustomerNotes(ctx, customerID)
+}
+
+func (s *CampaignService) DeleteCustomerNote(ctx context.Context, deviceID string, customerID, noteID uuid.UUID) error {
+	customer, err := s.repo.GetCustomer(ctx, deviceID, customerID)
+	if err != nil {
+		return err
+	}
+	if customer == nil {
+		return errors.New("customer not found")
+	}
+	return s.repo.DeleteCustomerNote(ctx, customerID, noteID)
+}
+
+// autoTagCustomers tags the customer records with the phone that the device owns
+// or sends to, used for behaviour-driven tags such as replied
+func (s *CampaignService) autoTagCustomers(ctx context.Context, deviceID, phone, tag string) {
+	if tag == "" {
+		return
+	}
+	customers, err := s.repo.GetCustomersByPhone(ctx, deviceID, phone)
+	if err != nil {
+		logrus.Warnf("Campaign: Failed to look up customers for auto-tag: %v", err)
+		return
+	}
+	for _, customer := range customers {
+		if err := s.repo.AddCustomerTags(ctx, customer.DeviceID, customer.ID, []string{tag}); err != nil {
+			logrus.Warnf("Campaign: Failed to auto-tag customer %s: %v", customer.ID, err)
+		}
+	}
+}
+
 // ============================================================================
 // Group Management
 // ============================================================================
@@ -577,6 +746,16 @@ func (s *CampaignService) CreateCampaign(ctx context.Context, req domainCampaign
 		return nil, errors.New("campaign name is required")
 	}
 
+	devices, err := normalizeCampaignDevices(req.Devices)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := s.normalizeCampaignSteps(ctx, req.DeviceID, req.Steps)
+	if err != nil {
+		return nil, err
+	}
+
 	// Verify template exists
 	template, err := s.repo.GetTemplate(ctx, req.DeviceID, req.TemplateID)
 	if err != nil {
@@ -604,6 +783,28 @@ func (s *CampaignService) CreateCampaign(ctx context.Context, req domainCampaign
 			return nil, err
 		}
 	}
+	if tags := normalizeTags(req.Tags); len(tags) > 0 {
+		if err := s.repo.SetCampaignTargetTags(ctx, campaign.ID, tags); err != nil {
+			return nil, err
+		}
+		campaign.Tags = tags
+	}
+
+	// Set sender pool
+	if len(devices) > 0 {
+		if err := s.repo.SetCampaignDevices(ctx, campaign.ID, devices); err != nil {
+			return nil, err
+		}
+		campaign.Devices = devices
+	}
+
+	// Set follow-up steps
+	if len(steps) > 0 {
+		if err := s.repo.SetCampaignSteps(ctx, campaign.ID, steps); err != nil {
+			return nil, err
+		}
+		campaign.Steps = steps
+	}
 
 	return campaign, nil
 }
@@ -635,6 +836,25 @@ func (s *CampaignService) GetCampaign(ctx context.Context, deviceID string, id u
 		campaign.CustomerIDs = customerIDs
 		campaign.GroupIDs = groupIDs
 	}
+	tags, err := s.repo.GetCampaignTargetTags(ctx, id)
+	if err == nil {
+		campaign.Tags = tags
+	}
+
+	// Load sender pool with live connection state
+	devices, err := s.repo.GetCampaignDevices(ctx, id)
+	if err == nil {
+		for i := range devices {
+			devices[i].Connected = isSenderConnected(devices[i].DeviceID)
+		}
+		campaign.Devices = devices
+	}
+
+	// Load follow-up steps
+	steps, err := s.repo.GetCampaignSteps(ctx, id)
+	if err == nil {
+		campaign.Steps = steps
+	}
 
 	return campaign, nil
 }
@@ -688,6 +908,16 @@ func (s *CampaignService) UpdateCampaign(ctx context.Context, req domainCampaign
 		return nil, errors.New("cannot update running campaign, pause it first")
 	}
 
+	devices, err := normalizeCampaignDevices(req.Devices)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := s.normalizeCampaignSteps(ctx, req.DeviceID, req.Steps)
+	if err != nil {
+		return nil, err
+	}
+
 	campaign.Name = req.Name
 	campaign.TemplateID = req.TemplateID
 	campaign.ScheduledAt = req.ScheduledAt
@@ -700,6 +930,22 @@ func (s *CampaignService) UpdateCampaign(ctx context.Context, req domainCampaign
 	if err := s.repo.SetCampaignTargets(ctx, campaign.ID, req.CustomerIDs, req.GroupIDs); err != nil {
 		return nil, err
 	}
+	campaign.Tags = normalizeTags(req.Tags)
+	if err := s.repo.SetCampaignTargetTags(ctx, campaign.ID, campaign.Tags); err != nil {
+		return nil, err
+	}
+
+	// Update sender pool
+	if err := s.repo.SetCampaignDevices(ctx, campaign.ID, devices); err != nil {
+		return nil, err
+	}
+	campaign.Devices = devices
+
+	// Update follow-up steps
+	if err := s.repo.SetCampaignSteps(ctx, campaign.ID, steps); err != nil {
+		return nil, err
+	}
+	campaign.Steps = steps
 
 	return campaign, nil
 }
@@ -764,23 +1010,31 @@ func (s *CampaignService) StartCampaign(ctx context.Context, deviceID string, id
 
 	logrus.WithField("target_customers", len(customers)).Info("Campaign: Found target customers")
 
-	// Get customer groups for [GROUP] placeholder
-	customerGroups := make(map[uuid.UUID]string)
-	for _, customer := range customers {
-		groups, err := s.repo.GetCustomerGroups(ctx, customer.ID)
-		if err == nil && len(groups) > 0 {
-			var groupNames []string
-			for _, g := range groups {
-				groupNames = append(groupNames, g.Name)
-			}
-			customerGroups[customer.ID] = strings.Join(groupNames, ", ")
-		}
+	// Load sender pool; without one every message goes out from the owner device
+	pool, err := s.repo.GetCampaignDevices(ctx, id)
+	if err != nil {
+		return err
 	}
+	assigner := newSenderAssigner(pool)
 
 	// Generate queue items
 	var queueItems []*domainCampaign.QueueItem
 	skippedAlreadyQueued := 0
+	skippedInvalid := 0
+	skippedOptedOut := 0
 	for _, customer := range customers {
+		// Skip numbers known not to be on WhatsApp
+		if customer.WhatsAppExists == domainCampaign.ValidationStatusInvalid {
+			skippedInvalid++
+			continue
+		}
+
+		// Skip customers who opted out
+		if customer.OptedOutAt != nil {
+			skippedOptedOut++
+			continue
+		}
+
 		// Check if already queued
 		queued, err := s.repo.IsMessageQueued(ctx, id, customer.ID)
 		if err != nil {
@@ -792,35 +1046,34 @@ func (s *CampaignService) StartCampaign(ctx context.Context, deviceID string, id
 			continue
 		}
 
-		// Process template
-		message := s.PreviewTemplate(ctx, template.Content, customer)
-
-		// Replace [GROUP] placeholder
-		if groupName, ok := customerGroups[customer.ID]; ok {
-			message = strings.ReplaceAll(message, "[GROUP]", groupName)
-		} else {
-			message = strings.ReplaceAll(message, "[GROUP]", "")
-		}
+		message := s.renderMessage(ctx, deviceID, template.Content, customer)
 
-		// Shorten URLs
-		message, err = s.ShortenURLsInText(ctx, deviceID, message)
-		if err != nil {
-			logrus.Warnf("Failed to shorten URLs: %v", err)
+		senderID := deviceID
+		if len(pool) > 0 {
+			sticky, err := s.repo.GetCustomerSender(ctx, customer.ID)
+			if err != nil {
+				logrus.Warnf("Failed to get customer sender: %v", err)
+			}
+			senderID = assigner.assign(sticky)
 		}
 
 		queueItems = append(queueItems, &domainCampaign.QueueItem{
-			CampaignID: id,
-			CustomerID: customer.ID,
-			DeviceID:   deviceID,
-			Phone:      customer.Phone,
-			Message:    message,
+			CampaignID:     id,
+			CustomerID:     customer.ID,
+			DeviceID:       deviceID,
+			SenderDeviceID: senderID,
+			Phone:          customer.Phone,
+			Message:        message,
 		})
 	}
 
 	logrus.WithFields(logrus.Fields{
 		"new_messages":    len(queueItems),
 		"already_queued":  skippedAlreadyQueued,
+		"skipped_invalid": skippedInvalid,
+		"opted_out":       skippedOptedOut,
 		"total_customers": len(customers),
+		"sender_devices":  len(pool),
 	}).Info("Campaign: Prepared messages for queue")
 
 	// Enqueue messages
@@ -853,6 +1106,28 @@ func (s *CampaignService) StartCampaign(ctx context.Context, deviceID string, id
 	return nil
 }
 
+// renderMessage fills template placeholders for a customer, including [GROUP], and shortens URLs
+func (s *CampaignService) renderMessage(ctx context.Context, deviceID, content string, customer *domainCampaign.Customer) string {
+	message := s.PreviewTemplate(ctx, content, customer)
+
+	// Replace [GROUP] placeholder
+	var groupNames []string
+	if groups, err := s.repo.GetCustomerGroups(ctx, customer.ID); err == nil {
+		for _, g := range groups {
+			groupNames = append(groupNames, g.Name)
+		}
+	}
+	message = strings.ReplaceAll(message, "[GROUP]", strings.Join(groupNames, ", "))
+
+	// Shorten URLs, linked to the customer so clicks can be attributed
+	message, err := s.shortenURLs(ctx, deviceID, message, &customer.ID)
+	if err != nil {
+		logrus.Warnf("Failed to shorten URLs: %v", err)
+	}
+
+	return message
+}
+
 func (s *CampaignService) PauseCampaign(ctx context.Context, deviceID string, id uuid.UUID) error {
 	campaign, err := s.repo.GetCampaign(ctx, deviceID, id)
 	if err != nil {
@@ -890,6 +1165,10 @@ func (s *CampaignService) GetCampaignStats(ctx context.Context, deviceID string,
 var urlRegex = regexp.MustCompile(`https?://[^\s]+`)
 
 func (s *CampaignService) ShortenURLsInText(ctx context.Context, deviceID string, text string) (string, error) {
+	return s.shortenURLs(ctx, deviceID, text, nil)
+}
+
+func (s *CampaignService) shortenURLs(ctx context.Context, deviceID, text string, customerID *uuid.UUID) (string, error) {
 	if config.CampaignShortURLBase == "" {
 		return text, nil // URL shortening disabled
 	}
@@ -905,6 +1184,7 @@ func (s *CampaignService) ShortenURLsInText(ctx context.Context, deviceID string
 			DeviceID:    deviceID,
 			Code:        code,
 			OriginalURL: url,
+			CustomerID:  customerID,
 		}
 
 		if err := s.repo.CreateShortURL(ctx, shortURL); err != nil {
@@ -931,6 +1211,13 @@ func (s *CampaignService) HandleShortURLRedirect(ctx context.Context, code strin
 	// Increment click count
 	_ = s.repo.IncrementShortURLClicks(ctx, code)
 
+	// Tag the recipient the link was generated for
+	if shortURL.CustomerID != nil && config.CampaignAutoTagClicked != "" {
+		if err := s.repo.AddCustomerTags(ctx, shortURL.DeviceID, *shortURL.CustomerID, []string{config.CampaignAutoTagClicked}); err != nil {
+			logrus.Warnf("Campaign: Failed to auto-tag customer %s: %v", *shortURL.CustomerID, err)
+		}
+	}
+
 	return shortURL.OriginalURL, nil
 }
 
@@ -985,6 +1272,7 @@ func (s *CampaignService) runQueueWorker() {
 			logrus.Info("Campaign: Queue worker context done, exiting")
 			return
 		case <-ticker.C:
+			s.scheduleFollowUps()
 			s.processQueueBatch()
 		}
 	}
@@ -993,7 +1281,7 @@ func (s *CampaignService) runQueueWorker() {
 func (s *CampaignService) processQueueBatch() {
 	ctx := s.workerCtx
 
-	// Get all devices that have pending messages
+	// Get all sender devices that have pending messages
 	deviceIDs, err := s.repo.GetActiveDeviceIDs(ctx)
 	if err != nil {
 		logrus.Errorf("Campaign: Failed to get active device IDs: %v", err)
@@ -1007,53 +1295,171 @@ func (s *CampaignService) processQueueBatch() {
 
 	logrus.WithField("devices", deviceIDs).Info("Campaign: Processing queue for devices")
 
+	// Each sender device drains its own queue so a pool sends in parallel;
+	// a device still waiting out its delay from the previous tick is skipped
 	for _, deviceID := range deviceIDs {
+		if !s.markDeviceBusy(deviceID) {
+			continue
+		}
+
+		s.workerWg.Add(1)
+		go func(deviceID string) {
+			defer s.workerWg.Done()
+			defer s.markDeviceIdle(deviceID)
+			s.processDeviceQueue(ctx, deviceID)
+		}(deviceID)
+	}
+}
+
+func (s *CampaignService) markDeviceBusy(deviceID string) bool {
+	s.busyMu.Lock()
+	defer s.busyMu.Unlock()
+	if s.busyDevices[deviceID] {
+		return false
+	}
+	s.busyDevices[deviceID] = true
+	return true
+}
+
+func (s *CampaignService) markDeviceIdle(deviceID string) {
+	s.busyMu.Lock()
+	defer s.busyMu.Unlock()
+	delete(s.busyDevices, deviceID)
+}
+
+func (s *CampaignService) processDeviceQueue(ctx context.Context, deviceID string) {
+	if !isSenderConnected(deviceID) {
+		s.failoverDeviceQueue(ctx, deviceID)
+		return
+	}
+
+	// Get one pending message for this device
+	messages, err := s.repo.GetPendingMessages(ctx, deviceID, 1)
+	if err != nil {
+		logrus.Errorf("Campaign: Failed to get pending messages for device %s: %v", deviceID, err)
+		return
+	}
+
+	if len(messages) == 0 {
+		logrus.WithField("device_id", deviceID).Debug("Campaign: No pending messages for device")
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"device_id": deviceID,
+		"count":     len(messages),
+	}).Info("Campaign: Found pending messages")
+
+	for _, msg := range messages {
 		select {
 		case <-ctx.Done():
 			return
 		default:
 		}
 
-		// Get one pending message for this device
-		messages, err := s.repo.GetPendingMessages(ctx, deviceID, 1)
-		if err != nil {
-			logrus.Errorf("Campaign: Failed to get pending messages for device %s: %v", deviceID, err)
-			continue
+		if !s.senderHasCapacity(ctx, msg.CampaignID, deviceID) {
+			logrus.WithField("device_id", deviceID).Debug("Campaign: Sender device reached its limit, waiting")
+			return
 		}
 
-		if len(messages) == 0 {
-			logrus.WithField("device_id", deviceID).Debug("Campaign: No pending messages for device")
-			continue
-		}
+		s.sendMessage(ctx, msg)
 
+		// Random delay between 30 seconds and 5 minutes
+		delay := s.randomDelay(config.CampaignMinDelay, config.CampaignMaxDelay)
 		logrus.WithFields(logrus.Fields{
 			"device_id": deviceID,
-			"count":     len(messages),
-		}).Info("Campaign: Found pending messages")
+			"delay":     delay,
+		}).Info("Campaign: Waiting before next message")
 
-		for _, msg := range messages {
-			select {
-			case <-ctx.Done():
-				return
-			default:
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// failoverDeviceQueue moves pending messages off a disconnected sender onto
+// another connected device from the same campaign's pool. Messages from
+// campaigns without a pool keep the single-device behaviour and fail.
+func (s *CampaignService) failoverDeviceQueue(ctx context.Context, deviceID string) {
+	messages, err := s.repo.GetPendingMessages(ctx, deviceID, 100)
+	if err != nil {
+		logrus.Errorf("Campaign: Failed to get pending messages for device %s: %v", deviceID, err)
+		return
+	}
+
+	pools := make(map[uuid.UUID][]domainCampaign.CampaignDevice)
+	for _, msg := range messages {
+		pool, ok := pools[msg.CampaignID]
+		if !ok {
+			pool, err = s.repo.GetCampaignDevices(ctx, msg.CampaignID)
+			if err != nil {
+				logrus.Errorf("Campaign: Failed to get devices for campaign %s: %v", msg.CampaignID, err)
+				continue
 			}
+			pools[msg.CampaignID] = pool
+		}
 
+		if len(pool) == 0 {
 			s.sendMessage(ctx, msg)
+			continue
+		}
 
-			// Random delay between 30 seconds and 5 minutes
-			delay := s.randomDelay(config.CampaignMinDelay, config.CampaignMaxDelay)
-			logrus.WithFields(logrus.Fields{
-				"device_id": deviceID,
-				"delay":     delay,
-			}).Info("Campaign: Waiting before next message")
+		target := ""
+		for _, device := range pool {
+			if device.DeviceID != deviceID && isSenderConnected(device.DeviceID) &&
+				s.senderHasCapacity(ctx, msg.CampaignID, device.DeviceID) {
+				target = device.DeviceID
+				break
+			}
+		}
+		if target == "" {
+			// Nothing to fail over to; keep the message pending until a device reconnects
+			continue
+		}
 
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(delay):
+		if err := s.repo.UpdateMessageSender(ctx, msg.ID, target); err != nil {
+			logrus.Errorf("Campaign: Failed to reassign message %s: %v", msg.ID, err)
+			continue
+		}
+		logrus.WithFields(logrus.Fields{
+			"message_id": msg.ID,
+			"from":       deviceID,
+			"to":         target,
+		}).Info("Campaign: Sender device disconnected, message reassigned")
+	}
+}
+
+// senderHasCapacity reports whether the device is still under the hourly and
+// daily limits configured for it in the campaign's pool
+func (s *CampaignService) senderHasCapacity(ctx context.Context, campaignID uuid.UUID, deviceID string) bool {
+	pool, err := s.repo.GetCampaignDevices(ctx, campaignID)
+	if err != nil {
+		return true
+	}
+
+	for _, device := range pool {
+		if device.DeviceID != deviceID {
+			continue
+		}
+		now := time.Now()
+		if device.HourlyLimit > 0 {
+			sent, err := s.repo.CountSentMessagesBySender(ctx, deviceID, now.Add(-time.Hour))
+			if err == nil && sent >= device.HourlyLimit {
+				return false
 			}
 		}
+		if device.DailyLimit > 0 {
+			sent, err := s.repo.CountSentMessagesBySender(ctx, deviceID, now.Add(-24*time.Hour))
+			if err == nil && sent >= device.DailyLimit {
+				return false
+			}
+		}
+		return true
 	}
+
+	return true
 }
 
 func (s *CampaignService) sendMessage(ctx context.Context, msg *domainCampaign.QueueItem) {
@@ -1062,8 +1468,20 @@ func (s *CampaignService) sendMessage(ctx context.Context, msg *domainCampaign.Q
 		"campaign_id": msg.CampaignID,
 		"phone":       msg.Phone,
 		"device_id":   msg.DeviceID,
+		"sender_id":   msg.SenderDeviceID,
 	}).Info("Campaign: Sending message")
 
+	// The customer may have opted out or ended the sequence since the message was queued
+	if reason := s.dispatchBlockedReason(ctx, msg); reason != "" {
+		_ = s.repo.UpdateMessageStatus(ctx, msg.ID, domainCampaign.MessageStatusFailed, &reason)
+		logrus.WithFields(logrus.Fields{
+			"message_id": msg.ID,
+			"phone":      msg.Phone,
+			"reason":     reason,
+		}).Info("Campaign: Message skipped")
+		return
+	}
+
 	// Mark as sending
 	if err := s.repo.UpdateMessageStatus(ctx, msg.ID, domainCampaign.MessageStatusSending, nil); err != nil {
 		logrus.Errorf("Campaign: Failed to update message status to sending: %v", err)
@@ -1078,9 +1496,14 @@ func (s *CampaignService) sendMessage(ctx context.Context, msg *domainCampaign.Q
 		return
 	}
 
-	device, ok := dm.GetDevice(msg.DeviceID)
+	senderID := msg.SenderDeviceID
+	if senderID == "" {
+		senderID = msg.DeviceID
+	}
+
+	device, ok := dm.GetDevice(senderID)
 	if !ok || device == nil {
-		errMsg := fmt.Sprintf("Device %s not found", msg.DeviceID)
+		errMsg := fmt.Sprintf("Device %s not found", senderID)
 		_ = s.repo.UpdateMessageStatus(ctx, msg.ID, domainCampaign.MessageStatusFailed, &errMsg)
 		logrus.Warn("Campaign: " + errMsg)
 		return
@@ -1088,7 +1511,7 @@ func (s *CampaignService) sendMessage(ctx context.Context, msg *domainCampaign.Q
 
 	client := device.GetClient()
 	if client == nil || !client.IsLoggedIn() {
-		errMsg := fmt.Sprintf("Device %s not connected", msg.DeviceID)
+		errMsg := fmt.Sprintf("Device %s not connected", senderID)
 		_ = s.repo.UpdateMessageStatus(ctx, msg.ID, domainCampaign.MessageStatusFailed, &errMsg)
 		logrus.Warn("Campaign: " + errMsg)
 		return
@@ -1116,19 +1539,59 @@ func (s *CampaignService) sendMessage(ctx context.Context, msg *domainCampaign.Q
 			"phone": msg.Phone,
 			"error": err,
 		}).Warn("Campaign: Failed to send message")
+		s.recordSendFailure(ctx, msg, err)
 		return
 	}
 
 	_ = s.repo.UpdateMessageStatus(ctx, msg.ID, domainCampaign.MessageStatusSent, nil)
+	_ = s.repo.ResetCustomerSendFailures(ctx, msg.CustomerID)
+	_ = s.repo.SetCustomerSenderIfMissing(ctx, msg.CustomerID, senderID)
+	s.advanceEnrollment(ctx, msg, senderID)
 	logrus.WithFields(logrus.Fields{
 		"phone":       msg.Phone,
 		"campaign_id": msg.CampaignID,
+		"step":        msg.Step,
 	}).Info("Campaign: Message sent successfully")
 
 	// Check if campaign is complete
 	s.checkCampaignCompletion(ctx, msg.CampaignID, msg.DeviceID)
 }
 
+// dispatchBlockedReason tells why a queued message must no longer be sent: the
+// customer was deleted or opted out, or the sequence of a follow-up was stopped,
+// for example by a reply. It returns an empty string when the message can go out.
+func (s *CampaignService) dispatchBlockedReason(ctx context.Context, msg *domainCampaign.QueueItem) string {
+	customer, err := s.repo.GetCustomer(ctx, msg.DeviceID, msg.CustomerID)
+	if err != nil {
+		// Let the send go ahead rather than drop it on a transient error
+		logrus.Warnf("Campaign: Failed to check customer %s before sending: %v", msg.CustomerID, err)
+		return ""
+	}
+	if customer == nil {
+		return "customer deleted"
+	}
+	if customer.OptedOutAt != nil {
+		return "customer opted out"
+	}
+
+	if msg.Step == 0 {
+		return ""
+	}
+	enrollment, err := s.repo.GetEnrollment(ctx, msg.CampaignID, msg.CustomerID)
+	if err != nil {
+		logrus.Warnf("Campaign: Failed to check sequence of customer %s before sending: %v", msg.CustomerID, err)
+		return ""
+	}
+	if enrollment != nil && enrollment.Status == domainCampaign.EnrollmentStatusStopped {
+		reason := "sequence stopped"
+		if enrollment.StopReason != nil {
+			reason += ": " + *enrollment.StopReason
+		}
+		return reason
+	}
+	return ""
+}
+
 func (s *CampaignService) checkCampaignCompletion(ctx context.Context, campaignID uuid.UUID, deviceID string) {
 	stats, err := s.repo.GetCampaignStats(ctx, campaignID)
 	if err != nil {
@@ -1152,6 +1615,314 @@ func (s *CampaignService) checkCampaignCompletion(ctx context.Context, campaignI
 	}
 }
 
+// ============================================================================
+// Drip Sequences
+// ============================================================================
+
+// normalizeCampaignSteps validates follow-up steps and fills in the default condition
+func (s *CampaignService) normalizeCampaignSteps(ctx context.Context, deviceID string, steps []domainCampaign.CampaignStep) ([]domainCampaign.CampaignStep, error) {
+	result := make([]domainCampaign.CampaignStep, 0, len(steps))
+	for i, step := range steps {
+		if step.DelayMinutes < 0 {
+			return nil, fmt.Errorf("step %d: delay must not be negative", i+1)
+		}
+
+		switch step.Condition {
+		case "":
+			step.Condition = domainCampaign.StepConditionNoReply
+		case domainCampaign.StepConditionNoReply:
+		case domainCampaign.StepConditionRepliedKeyword:
+			if step.Keyword == nil || strings.TrimSpace(*step.Keyword) == "" {
+				return nil, fmt.Errorf("step %d: keyword is required for condition %s", i+1, step.Condition)
+			}
+			keyword := strings.TrimSpace(*step.Keyword)
+			step.Keyword = &keyword
+		default:
+			return nil, fmt.Errorf("step %d: unknown condition %q", i+1, step.Condition)
+		}
+		if step.Condition != domainCampaign.StepConditionRepliedKeyword {
+			step.Keyword = nil
+		}
+
+		template, err := s.repo.GetTemplate(ctx, deviceID, step.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		if template == nil {
+			return nil, fmt.Errorf("step %d: template not found", i+1)
+		}
+
+		result = append(result, step)
+	}
+	return result, nil
+}
+
+// nextStepAt returns when a step becomes due after its trigger, or nil when it waits for a keyword reply
+func nextStepAt(step domainCampaign.CampaignStep, from time.Time) *time.Time {
+	if step.Condition == domainCampaign.StepConditionRepliedKeyword {
+		return nil
+	}
+	at := from.Add(time.Duration(step.DelayMinutes) * time.Minute)
+	return &at
+}
+
+// advanceEnrollment moves a customer past the step that was just delivered and schedules the next one
+func (s *CampaignService) advanceEnrollment(ctx context.Context, msg *domainCampaign.QueueItem, senderID string) {
+	steps, err := s.repo.GetCampaignSteps(ctx, msg.CampaignID)
+	if err != nil || len(steps) == 0 {
+		return
+	}
+
+	enrollment := &domainCampaign.Enrollment{
+		CampaignID:     msg.CampaignID,
+		CustomerID:     msg.CustomerID,
+		DeviceID:       msg.DeviceID,
+		SenderDeviceID: senderID,
+		Phone:          msg.Phone,
+		CurrentStep:    msg.Step,
+		Status:         domainCampaign.EnrollmentStatusActive,
+	}
+	if msg.Step >= len(steps) {
+		enrollment.Status = domainCampaign.EnrollmentStatusCompleted
+	} else {
+		enrollment.NextStepAt = nextStepAt(steps[msg.Step], time.Now())
+	}
+
+	if err := s.repo.SaveEnrollment(ctx, enrollment); err != nil {
+		logrus.Errorf("Campaign: Failed to save enrollment for customer %s: %v", msg.CustomerID, err)
+	}
+}
+
+// scheduleFollowUps queues the next step for every enrollment that has become due
+func (s *CampaignService) scheduleFollowUps() {
+	ctx := s.workerCtx
+
+	enrollments, err := s.repo.GetDueEnrollments(ctx, time.Now(), config.CampaignBatchSize)
+	if err != nil {
+		logrus.Errorf("Campaign: Failed to get due follow-ups: %v", err)
+		return
+	}
+
+	stepsByCampaign := make(map[uuid.UUID][]domainCampaign.CampaignStep)
+	for _, enrollment := range enrollments {
+		steps, ok := stepsByCampaign[enrollment.CampaignID]
+		if !ok {
+			steps, err = s.repo.GetCampaignSteps(ctx, enrollment.CampaignID)
+			if err != nil {
+				logrus.Errorf("Campaign: Failed to get steps for campaign %s: %v", enrollment.CampaignID, err)
+				continue
+			}
+			stepsByCampaign[enrollment.CampaignID] = steps
+		}
+
+		// Steps may have been removed after the customer enrolled
+		if enrollment.CurrentStep >= len(steps) {
+			enrollment.Status = domainCampaign.EnrollmentStatusCompleted
+			enrollment.NextStepAt = nil
+			_ = s.repo.SaveEnrollment(ctx, enrollment)
+			continue
+		}
+		step := steps[enrollment.CurrentStep]
+
+		customer, err := s.repo.GetCustomer(ctx, enrollment.DeviceID, enrollment.CustomerID)
+		if err != nil {
+			continue
+		}
+		if customer == nil {
+			_ = s.repo.StopEnrollment(ctx, enrollment.CampaignID, enrollment.CustomerID, "customer_deleted")
+			continue
+		}
+		if customer.OptedOutAt != nil {
+			_ = s.repo.StopEnrollment(ctx, enrollment.CampaignID, enrollment.CustomerID, "opted_out")
+			continue
+		}
+
+		template, err := s.repo.GetTemplate(ctx, enrollment.DeviceID, step.TemplateID)
+		if err != nil {
+			continue
+		}
+		if template == nil {
+			_ = s.repo.StopEnrollment(ctx, enrollment.CampaignID, enrollment.CustomerID, "template_missing")
+			continue
+		}
+
+		item := &domainCampaign.QueueItem{
+			CampaignID:     enrollment.CampaignID,
+			CustomerID:     enrollment.CustomerID,
+			DeviceID:       enrollment.DeviceID,
+			SenderDeviceID: enrollment.SenderDeviceID,
+			Phone:          customer.Phone,
+			Message:        s.renderMessage(ctx, enrollment.DeviceID, template.Content, customer),
+			Step:           step.Position,
+		}
+		if err := s.repo.EnqueueFollowUp(ctx, item); err != nil {
+			logrus.Errorf("Campaign: Failed to queue follow-up for customer %s: %v", enrollment.CustomerID, err)
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"campaign_id": enrollment.CampaignID,
+			"customer_id": enrollment.CustomerID,
+			"step":        step.Position,
+		}).Info("Campaign: Follow-up queued")
+	}
+}
+
+// HandleIncomingMessage reacts to a customer's reply on the device that received
+// it: opt-out keywords end the number's sequences of that device, a reply matching
+// the next step's keyword schedules that step, and any other reply stops the
+// sequence. Campaigns of other devices are left alone.
+func (s *CampaignService) HandleIncomingMessage(ctx context.Context, deviceID, phone, text string) {
+	text = strings.TrimSpace(text)
+	if deviceID == "" || phone == "" || text == "" {
+		return
+	}
+	phone = "+" + strings.TrimPrefix(phone, "+")
+
+	s.autoTagCustomers(ctx, deviceID, phone, config.CampaignAutoTagReplied)
+
+	if isOptOutMessage(text) {
+		if err := s.repo.SetCustomersOptedOut(ctx, deviceID, phone); err != nil {
+			logrus.Errorf("Campaign: Failed to opt out %s: %v", phone, err)
+		}
+	}
+
+	enrollments, err := s.repo.GetActiveEnrollmentsByPhone(ctx, deviceID, phone)
+	if err != nil {
+		logrus.Errorf("Campaign: Failed to get enrollments for %s: %v", phone, err)
+		return
+	}
+
+	for _, enrollment := range enrollments {
+		if isOptOutMessage(text) {
+			_ = s.repo.StopEnrollment(ctx, enrollment.CampaignID, enrollment.CustomerID, "opted_out")
+			continue
+		}
+
+		steps, err := s.repo.GetCampaignSteps(ctx, enrollment.CampaignID)
+		if err != nil {
+			continue
+		}
+
+		if enrollment.CurrentStep < len(steps) {
+			next := steps[enrollment.CurrentStep]
+			if next.Condition == domainCampaign.StepConditionRepliedKeyword && next.Keyword != nil &&
+				strings.Contains(strings.ToLower(text), strings.ToLower(*next.Keyword)) {
+				at := time.Now().Add(time.Duration(next.DelayMinutes) * time.Minute)
+				_ = s.repo.RecordEnrollmentReply(ctx, enrollment.CampaignID, enrollment.CustomerID, text, &at)
+				continue
+			}
+		}
+
+		_ = s.repo.RecordEnrollmentReply(ctx, enrollment.CampaignID, enrollment.CustomerID, text, nil)
+		_ = s.repo.StopEnrollment(ctx, enrollment.CampaignID, enrollment.CustomerID, "replied")
+	}
+
+	if len(enrollments) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"phone":       phone,
+			"device_id":   deviceID,
+			"enrollments": len(enrollments),
+		}).Info("Campaign: Processed customer reply")
+	}
+}
+
+func isOptOutMessage(text string) bool {
+	normalized := strings.ToLower(strings.Trim(strings.TrimSpace(text), ".!"))
+	for _, keyword := range config.CampaignOptOutKeywords {
+		if normalized == strings.ToLower(strings.TrimSpace(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================================
+// Sender Pool
+// ============================================================================
+
+// normalizeCampaignDevices trims and de-duplicates a sender pool and rejects
+// negative limits or devices the device manager does not know about
+func normalizeCampaignDevices(devices []domainCampaign.CampaignDevice) ([]domainCampaign.CampaignDevice, error) {
+	dm := whatsapp.GetDeviceManager()
+	seen := make(map[string]bool)
+	var result []domainCampaign.CampaignDevice
+	for _, device := range devices {
+		device.DeviceID = strings.TrimSpace(device.DeviceID)
+		if device.DeviceID == "" || seen[device.DeviceID] {
+			continue
+		}
+		if device.HourlyLimit < 0 || device.DailyLimit < 0 {
+			return nil, fmt.Errorf("device %s: limits must not be negative", device.DeviceID)
+		}
+		if dm != nil {
+			if _, ok := dm.GetDevice(device.DeviceID); !ok {
+				return nil, fmt.Errorf("device %s not found", device.DeviceID)
+			}
+		}
+		seen[device.DeviceID] = true
+		device.Connected = false
+		result = append(result, device)
+	}
+	return result, nil
+}
+
+func isSenderConnected(deviceID string) bool {
+	dm := whatsapp.GetDeviceManager()
+	if dm == nil {
+		return false
+	}
+	device, ok := dm.GetDevice(deviceID)
+	if !ok || device == nil {
+		return false
+	}
+	client := device.GetClient()
+	return client != nil && client.IsLoggedIn()
+}
+
+// senderAssigner distributes customers over a campaign's device pool. A
+// customer keeps the device that first reached them while it is still in the
+// pool; everyone else goes to the least loaded device, connected ones first.
+type senderAssigner struct {
+	pool      []domainCampaign.CampaignDevice
+	load      map[string]int
+	connected func(deviceID string) bool
+}
+
+func newSenderAssigner(pool []domainCampaign.CampaignDevice) *senderAssigner {
+	return &senderAssigner{
+		pool:      pool,
+		load:      make(map[string]int),
+		connected: isSenderConnected,
+	}
+}
+
+func (a *senderAssigner) assign(sticky string) string {
+	if sticky != "" {
+		for _, device := range a.pool {
+			if device.DeviceID == sticky {
+				a.load[sticky]++
+				return sticky
+			}
+		}
+	}
+
+	best := ""
+	bestConnected := false
+	for _, device := range a.pool {
+		connected := a.connected(device.DeviceID)
+		switch {
+		case best == "",
+			connected && !bestConnected,
+			connected == bestConnected && a.load[device.DeviceID] < a.load[best]:
+			best = device.DeviceID
+			bestConnected = connected
+		}
+	}
+	a.load[best]++
+	return best
+}
+
 func (s *CampaignService) randomDelay(minSeconds, maxSeconds int) time.Duration {
 	if minSeconds >= maxSeconds {
 		return time.Duration(minSeconds) * time.Second
@@ -1165,28 +1936,33 @@ func (s *CampaignService) randomDelay(minSeconds, maxSeconds int) time.Duration
 // Validation Worker
 // ============================================================================
 
-func (s *CampaignService) ValidateCustomer(ctx context.Context, deviceID string, id uuid.UUID) error {
-	customer, err := s.repo.GetCustomer(ctx, deviceID, id)
-	if err != nil {
-		return err
+// resolveValidationClient returns the WhatsApp client used for validation,
+// preferring the one attached to ctx and falling back to the device manager
+func (s *CampaignService) resolveValidationClient(ctx context.Context, deviceID string) *whatsmeow.Client {
+	if client := whatsapp.ClientFromContext(ctx); client != nil {
+		return client
 	}
-	if customer == nil {
-		return errors.New("customer not found")
+	if dm := whatsapp.GetDeviceManager(); dm != nil {
+		if device, ok := dm.GetDevice(deviceID); ok && device != nil {
+			return device.GetClient()
+		}
 	}
+	return nil
+}
 
-	// Validate phone format using the standard validation function
-	phoneValid := domainCampaign.ValidationStatusValid
+// checkCustomer validates the phone format and, when a logged-in client is available,
+// whether the number is registered on WhatsApp. Without a client the existence stays pending;
+// a malformed number cannot be on WhatsApp, so it is invalid either way.
+func (s *CampaignService) checkCustomer(client *whatsmeow.Client, customer *domainCampaign.Customer) (phoneValid, whatsappExists domainCampaign.ValidationStatus) {
+	phoneValid = domainCampaign.ValidationStatusValid
 	if err := validations.ValidatePhoneNumber(customer.Phone); err != nil {
 		phoneValid = domainCampaign.ValidationStatusInvalid
 	}
 
-	// Check if phone exists on WhatsApp
-	whatsappExists := domainCampaign.ValidationStatusPending
-
-	// Try to get WhatsApp client from context
-	client := whatsapp.ClientFromContext(ctx)
-	if client != nil && client.IsLoggedIn() && phoneValid == domainCampaign.ValidationStatusValid {
-		// Use the IsOnWhatsapp check - remove + prefix for JID format
+	whatsappExists = domainCampaign.ValidationStatusPending
+	if phoneValid == domainCampaign.ValidationStatusInvalid {
+		whatsappExists = domainCampaign.ValidationStatusInvalid
+	} else if client != nil && client.IsLoggedIn() {
 		phone := strings.TrimPrefix(customer.Phone, "+")
 		jid := phone + "@s.whatsapp.net"
 		if utils.IsOnWhatsapp(client, jid) {
@@ -1195,9 +1971,22 @@ func (s *CampaignService) ValidateCustomer(ctx context.Context, deviceID string,
 			whatsappExists = domainCampaign.ValidationStatusInvalid
 		}
 	}
+	return phoneValid, whatsappExists
+}
+
+func (s *CampaignService) ValidateCustomer(ctx context.Context, deviceID string, id uuid.UUID) error {
+	customer, err := s.repo.GetCustomer(ctx, deviceID, id)
+	if err != nil {
+		return err
+	}
+	if customer == nil {
+		return errors.New("customer not found")
+	}
+
+	phoneValid, whatsappExists := s.checkCustomer(whatsapp.ClientFromContext(ctx), customer)
 
 	// Update validation status
-	if err := s.repo.UpdateCustomerValidation(ctx, id, phoneValid, whatsappExists); err != nil {
+	if err := s.repo.UpdateCustomerValidation(ctx, id, phoneValid, whatsappExists, domainCampaign.ValidationSourceManual, nil); err != nil {
 		return err
 	}
 
@@ -1225,21 +2014,7 @@ func (s *CampaignService) ValidatePendingCustomers(ctx context.Context, deviceID
 			return nil
 		}
 
-		// Try to get WhatsApp client from context or device manager
-		var client *whatsmeow.Client
-
-		// Check context first
-		client = whatsapp.ClientFromContext(ctx)
-
-		// Fallback to device manager
-		if client == nil {
-			if dm := whatsapp.GetDeviceManager(); dm != nil {
-				if device, ok := dm.GetDevice(deviceID); ok && device != nil {
-					client = device.GetClient()
-				}
-			}
-		}
-
+		client := s.resolveValidationClient(ctx, deviceID)
 		if client == nil || !client.IsLoggedIn() {
 			return errors.New("whatsapp client not connected")
 		}
@@ -1250,26 +2025,10 @@ func (s *CampaignService) ValidatePendingCustomers(ctx context.Context, deviceID
 		}).Info("Campaign: Starting batch validation")
 
 		for _, customer := range customers {
-			// Validate phone format
-			phoneValid := domainCampaign.ValidationStatusValid
-			if err := validations.ValidatePhoneNumber(customer.Phone); err != nil {
-				phoneValid = domainCampaign.ValidationStatusInvalid
-			}
-
-			// Check WhatsApp existence
-			whatsappExists := domainCampaign.ValidationStatusPending
-			if phoneValid == domainCampaign.ValidationStatusValid {
-				phone := strings.TrimPrefix(customer.Phone, "+")
-				jid := phone + "@s.whatsapp.net"
-				if utils.IsOnWhatsapp(client, jid) {
-					whatsappExists = domainCampaign.ValidationStatusValid
-				} else {
-					whatsappExists = domainCampaign.ValidationStatusInvalid
-				}
-			}
+			phoneValid, whatsappExists := s.checkCustomer(client, customer)
 
 			// Update validation status
-			if err := s.repo.UpdateCustomerValidation(ctx, customer.ID, phoneValid, whatsappExists); err != nil {
+			if err := s.repo.UpdateCustomerValidation(ctx, customer.ID, phoneValid, whatsappExists, domainCampaign.ValidationSourceManual, nil); err != nil {
 				logrus.Errorf("Campaign: Failed to update customer validation %s: %v", customer.ID, err)
 			}
 
@@ -1287,14 +2046,7 @@ func (s *CampaignService) ValidateCustomers(ctx context.Context, deviceID string
 		return nil
 	}
 
-	// Try to get WhatsApp client
-	var client *whatsmeow.Client
-	if dm := whatsapp.GetDeviceManager(); dm != nil {
-		if device, ok := dm.GetDevice(deviceID); ok && device != nil {
-			client = device.GetClient()
-		}
-	}
-
+	client := s.resolveValidationClient(ctx, deviceID)
 	if client == nil || !client.IsLoggedIn() {
 		return errors.New("whatsapp client not connected")
 	}
@@ -1309,26 +2061,10 @@ func (s *CampaignService) ValidateCustomers(ctx context.Context, deviceID string
 			continue
 		}
 
-		// Validate phone format
-		phoneValid := domainCampaign.ValidationStatusValid
-		if err := validations.ValidatePhoneNumber(customer.Phone); err != nil {
-			phoneValid = domainCampaign.ValidationStatusInvalid
-		}
-
-		// Check WhatsApp existence
-		whatsappExists := domainCampaign.ValidationStatusPending
-		if phoneValid == domainCampaign.ValidationStatusValid {
-			phone := strings.TrimPrefix(customer.Phone, "+")
-			jid := phone + "@s.whatsapp.net"
-			if utils.IsOnWhatsapp(client, jid) {
-				whatsappExists = domainCampaign.ValidationStatusValid
-			} else {
-				whatsappExists = domainCampaign.ValidationStatusInvalid
-			}
-		}
+		phoneValid, whatsappExists := s.checkCustomer(client, customer)
 
 		// Update validation status
-		if err := s.repo.UpdateCustomerValidation(ctx, id, phoneValid, whatsappExists); err != nil {
+		if err := s.repo.UpdateCustomerValidation(ctx, id, phoneValid, whatsappExists, domainCampaign.ValidationSourceManual, nil); err != nil {
 			logrus.Errorf("Campaign: Failed to update customer validation %s: %v", id, err)
 		}
 
@@ -1338,6 +2074,18 @@ func (s *CampaignService) ValidateCustomers(ctx context.Context, deviceID string
 	return nil
 }
 
+func (s *CampaignService) GetCustomerValidationHistory(ctx context.Context, deviceID string, id uuid.UUID) ([]*domainCampaign.ValidationRecord, error) {
+	customer, err := s.repo.GetCustomer(ctx, deviceID, id)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, errors.New("customer not found")
+	}
+
+	return s.repo.GetCustomerValidationHistory(ctx, id, 100)
+}
+
 func (s *CampaignService) StartValidationWorker(ctx context.Context) {
 	// Validation worker runs less frequently than message worker
 	go func() {
@@ -1352,49 +2100,111 @@ func (s *CampaignService) StartValidationWorker(ctx context.Context) {
 				logrus.Info("Campaign: Validation worker stopped")
 				return
 			case <-ticker.C:
-				// Get devices with customers needing validation
-				deviceIDs, err := s.repo.GetActiveDeviceIDs(ctx)
+				// Get devices owning campaign customers
+				deviceIDs, err := s.repo.GetCustomerDeviceIDs(ctx)
 				if err != nil {
 					continue
 				}
 
 				for _, deviceID := range deviceIDs {
-					customers, err := s.repo.GetCustomersForValidation(ctx, deviceID, 10)
-					if err != nil {
-						continue
-					}
-
-					// Try to get WhatsApp client for this device
-					var client *whatsmeow.Client
-					if dm := whatsapp.GetDeviceManager(); dm != nil {
-						if device, ok := dm.GetDevice(deviceID); ok && device != nil {
-							client = device.GetClient()
-						}
-					}
-
-					for _, customer := range customers {
-						// Validate phone format using shared validation
-						phoneValid := domainCampaign.ValidationStatusValid
-						if err := validations.ValidatePhoneNumber(customer.Phone); err != nil {
-							phoneValid = domainCampaign.ValidationStatusInvalid
-						}
-
-						// Check WhatsApp existence if client available and phone is valid
-						whatsappExists := customer.WhatsAppExists
-						if client != nil && client.IsLoggedIn() && phoneValid == domainCampaign.ValidationStatusValid {
-							phone := strings.TrimPrefix(customer.Phone, "+")
-							jid := phone + "@s.whatsapp.net"
-							if utils.IsOnWhatsapp(client, jid) {
-								whatsappExists = domainCampaign.ValidationStatusValid
-							} else {
-								whatsappExists = domainCampaign.ValidationStatusInvalid
-							}
-						}
-
-						_ = s.repo.UpdateCustomerValidation(ctx, customer.ID, phoneValid, whatsappExists)
-					}
+					s.validateDeviceBatch(ctx, deviceID)
 				}
 			}
 		}
 	}()
 }
+
+// validateDeviceBatch validates a small batch of pending customers and re-validates
+// customers whose last check is older than CampaignRevalidateAfterHours
+func (s *CampaignService) validateDeviceBatch(ctx context.Context, deviceID string) {
+	// Nothing can be checked without a live client; the customers stay pending
+	// until the device is logged in instead of being selected on every tick
+	client := s.resolveValidationClient(ctx, deviceID)
+	if client == nil || !client.IsLoggedIn() {
+		return
+	}
+
+	customers, err := s.repo.GetCustomersForValidation(ctx, deviceID, 10)
+	if err != nil {
+		logrus.Errorf("Campaign: Failed to get customers for validation on device %s: %v", deviceID, err)
+	}
+	for _, customer := range customers {
+		phoneValid, whatsappExists := s.checkCustomer(client, customer)
+		if err := s.repo.UpdateCustomerValidation(ctx, customer.ID, phoneValid, whatsappExists, domainCampaign.ValidationSourceWorker, nil); err != nil {
+			logrus.Errorf("Campaign: Failed to update customer validation %s: %v", customer.ID, err)
+		}
+	}
+
+	if config.CampaignRevalidateAfterHours <= 0 {
+		return
+	}
+
+	cutoff := time.Now().Add(-time.Duration(config.CampaignRevalidateAfterHours) * time.Hour)
+	stale, err := s.repo.GetCustomersForRevalidation(ctx, deviceID, cutoff, 10)
+	if err != nil {
+		logrus.Errorf("Campaign: Failed to get customers for revalidation on device %s: %v", deviceID, err)
+		return
+	}
+
+	for _, customer := range stale {
+		phoneValid, whatsappExists := s.checkCustomer(client, customer)
+		if err := s.repo.UpdateCustomerValidation(ctx, customer.ID, phoneValid, whatsappExists, domainCampaign.ValidationSourceRevalidation, nil); err != nil {
+			logrus.Errorf("Campaign: Failed to update customer revalidation %s: %v", customer.ID, err)
+			continue
+		}
+
+		if whatsappExists != customer.WhatsAppExists {
+			logrus.WithFields(logrus.Fields{
+				"customer_id": customer.ID,
+				"phone":       customer.Phone,
+				"previous":    customer.WhatsAppExists,
+				"current":     whatsappExists,
+			}).Info("Campaign: Customer WhatsApp status changed on revalidation")
+		}
+
+		// Small delay to avoid rate limiting
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// isNotOnWhatsAppError reports whether a send error means the recipient has no WhatsApp account
+func isNotOnWhatsAppError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var invalidJID pkgError.InvalidJID
+	if errors.As(err, &invalidJID) {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "not on whatsapp")
+}
+
+// recordSendFailure tracks consecutive "not on WhatsApp" failures and marks the
+// customer invalid once CampaignMaxSendFailures is reached
+func (s *CampaignService) recordSendFailure(ctx context.Context, msg *domainCampaign.QueueItem, sendErr error) {
+	if config.CampaignMaxSendFailures <= 0 || !isNotOnWhatsAppError(sendErr) {
+		return
+	}
+
+	failures, err := s.repo.IncrementCustomerSendFailures(ctx, msg.CustomerID)
+	if err != nil {
+		logrus.Errorf("Campaign: Failed to record send failure for customer %s: %v", msg.CustomerID, err)
+		return
+	}
+	if failures < config.CampaignMaxSendFailures {
+		return
+	}
+
+	reason := sendErr.Error()
+	if err := s.repo.UpdateCustomerValidation(ctx, msg.CustomerID, domainCampaign.ValidationStatusValid,
+		domainCampaign.ValidationStatusInvalid, domainCampaign.ValidationSourceSendFailure, &reason); err != nil {
+		logrus.Errorf("Campaign: Failed to mark customer %s invalid: %v", msg.CustomerID, err)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"customer_id": msg.CustomerID,
+		"phone":       msg.Phone,
+		"failures":    failures,
+	}).Warn("Campaign: Customer marked invalid after repeated send failures")
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	domainCampaign "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/campaign"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+)
+
+func TestIsNotOnWhatsAppError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "Nil",
+			err:  nil,
+			want: false,
+		},
+		{
+			name: "InvalidJID",
+			err:  pkgError.InvalidJID("Phone 628123@s.whatsapp.net is not on whatsapp"),
+			want: true,
+		},
+		{
+			name: "WrappedInvalidJID",
+			err:  fmt.Errorf("send failed: %w", pkgError.ErrUserNotRegistered),
+			want: true,
+		},
+		{
+			name: "PlainMessage",
+			err:  errors.New("recipient is Not On WhatsApp"),
+			want: true,
+		},
+		{
+			name: "Unrelated",
+			err:  errors.New("websocket not connected"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNotOnWhatsAppError(tt.err); got != tt.want {
+				t.Fatalf("isNotOnWhatsAppError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSenderAssigner(t *testing.T) {
+	pool := []domainCampaign.CampaignDevice{{DeviceID: "a"}, {DeviceID: "b"}, {DeviceID: "c"}}
+	online := map[string]bool{"a": true, "b": true, "c": false}
+
+	tests := []struct {
+		name    string
+		stickys []string
+		want    []string
+	}{
+		{
+			name:    "BalancesConnectedDevices",
+			stickys: []string{"", "", "", ""},
+			want:    []string{"a", "b", "a", "b"},
+		},
+		{
+			name:    "KeepsStickySenderInPool",
+			stickys: []string{"c", "", "c"},
+			want:    []string{"c", "a", "c"},
+		},
+		{
+			name:    "IgnoresStickySenderOutsidePool",
+			stickys: []string{"z", ""},
+			want:    []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newSenderAssigner(pool)
+			a.connected = func(id string) bool { return online[id] }
+			for i, sticky := range tt.stickys {
+				if got := a.assign(sticky); got != tt.want[i] {
+					t.Fatalf("assign(%q) #%d = %q, want %q", sticky, i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIsOptOutMessage(t *testing.T) {
+	original := config.CampaignOptOutKeywords
+	defer func() { config.CampaignOptOutKeywords = original }()
+	config.CampaignOptOutKeywords = []string{"stop", " Unsubscribe "}
+
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{text: "STOP", want: true},
+		{text: "  stop! ", want: true},
+		{text: "unsubscribe", want: true},
+		{text: "please stop", want: false},
+		{text: "yes", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := isOptOutMessage(tt.text); got != tt.want {
+				t.Fatalf("isOptOutMessage(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextStepAt(t *testing.T) {
+	from := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	got := nextStepAt(domainCampaign.CampaignStep{DelayMinutes: 90, Condition: domainCampaign.StepConditionNoReply}, from)
+	if got == nil || !got.Equal(from.Add(90*time.Minute)) {
+		t.Fatalf("nextStepAt(no_reply) = %v, want %v", got, from.Add(90*time.Minute))
+	}
+
+	if got := nextStepAt(domainCampaign.CampaignStep{DelayMinutes: 90, Condition: domainCampaign.StepConditionRepliedKeyword}, from); got != nil {
+		t.Fatalf("nextStepAt(replied_keyword) = %v, want nil", got)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := normalizeTags([]string{" VIP ", "vip", "", "Clicked", "  "})
+	want := []string{"vip", "clicked"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("normalizeTags() = %v, want %v", got, want)
+	}
+}
@@ -3,10 +3,12 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
 	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
@@ -17,11 +19,13 @@ import (
 
 type serviceChat struct {
 	chatStorageRepo domainChatStorage.IChatStorageRepository
+	mediaStore      domainMediaStore.IMediaStore
 }
 
-func NewChatService(chatStorageRepo domainChatStorage.IChatStorageRepository) domainChat.IChatUsecase {
+func NewChatService(chatStorageRepo domainChatStorage.IChatStorageRepository, mediaStore domainMediaStore.IMediaStore) domainChat.IChatUsecase {
 	return &serviceChat{
 		chatStorageRepo: chatStorageRepo,
+		mediaStore:      mediaStore,
 	}
 }
 
@@ -37,6 +41,16 @@ func (service serviceChat) ListChats(ctx context.Context, request domainChat.Lis
 		Offset:     request.Offset,
 		SearchName: request.Search,
 		HasMedia:   request.HasMedia,
+		Unread:     request.Unread,
+		Archived:   request.Archived,
+		Pinned:     request.Pinned,
+	}
+	if request.Cursor != "" {
+		var cursor chatPageCursor
+		if err := decodeCursor(request.Cursor, &cursor); err != nil {
+			return response, err
+		}
+		filter.Before = &domainChatStorage.ChatCursor{LastMessageTime: cursorTime(cursor.LastMessageTime), JID: cursor.JID}
 	}
 
 	// Get chats from storage
@@ -57,15 +71,7 @@ func (service serviceChat) ListChats(ctx context.Context, request domainChat.Lis
 	// Convert entities to domain objects
 	chatInfos := make([]domainChat.ChatInfo, 0, len(chats))
 	for _, chat := range chats {
-		chatInfo := domainChat.ChatInfo{
-			JID:                 chat.JID,
-			Name:                chat.Name,
-			LastMessageTime:     chat.LastMessageTime.Format(time.RFC3339),
-			EphemeralExpiration: chat.EphemeralExpiration,
-			CreatedAt:           chat.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:           chat.UpdatedAt.Format(time.RFC3339),
-		}
-		chatInfos = append(chatInfos, chatInfo)
+		chatInfos = append(chatInfos, toChatInfo(chat))
 	}
 
 	// Create pagination response
@@ -74,6 +80,10 @@ func (service serviceChat) ListChats(ctx context.Context, request domainChat.Lis
 		Offset: request.Offset,
 		Total:  int(totalCount),
 	}
+	if len(chats) == request.Limit {
+		last := chats[len(chats)-1]
+		pagination.NextCursor = encodeCursor(chatPageCursor{LastMessageTime: cursorNanos(last.LastMessageTime), JID: last.JID})
+	}
 
 	response.Data = chatInfos
 	response.Pagination = pagination
@@ -114,6 +124,13 @@ func (service serviceChat) GetChatMessages(ctx context.Context, request domainCh
 		MediaOnly: request.MediaOnly,
 		IsFromMe:  request.IsFromMe,
 	}
+	if request.Cursor != "" {
+		var cursor messagePageCursor
+		if err := decodeCursor(request.Cursor, &cursor); err != nil {
+			return response, err
+		}
+		filter.Before = &domainChatStorage.MessageCursor{Timestamp: cursorTime(cursor.Timestamp), ID: cursor.ID}
+	}
 
 	// Parse time filters if provided
 	if request.StartTime != nil && *request.StartTime != "" {
@@ -161,32 +178,27 @@ func (service serviceChat) GetChatMessages(ctx context.Context, request domainCh
 
 	// Convert entities to domain objects
 	messageInfos := make([]domainChat.MessageInfo, 0, len(messages))
+	messageIDs := make([]string, 0, len(messages))
 	for _, message := range messages {
-		messageInfo := domainChat.MessageInfo{
-			ID:         message.ID,
-			ChatJID:    message.ChatJID,
-			SenderJID:  message.Sender,
-			Content:    message.Content,
-			Timestamp:  message.Timestamp.Format(time.RFC3339),
-			IsFromMe:   message.IsFromMe,
-			MediaType:  message.MediaType,
-			Filename:   message.Filename,
-			URL:        message.URL,
-			FileLength: message.FileLength,
-			CreatedAt:  message.CreatedAt.Format(time.RFC3339),
-			UpdatedAt:  message.UpdatedAt.Format(time.RFC3339),
-		}
-		messageInfos = append(messageInfos, messageInfo)
+		messageInfos = append(messageInfos, toMessageInfo(message))
+		messageIDs = append(messageIDs, message.ID)
 	}
+	service.attachMessageActivity(deviceID, request.ChatJID, messageIDs, messageInfos)
 
-	// Create chat info for response
-	chatInfo := domainChat.ChatInfo{
-		JID:                 chat.JID,
-		Name:                chat.Name,
-		LastMessageTime:     chat.LastMessageTime.Format(time.RFC3339),
-		EphemeralExpiration: chat.EphemeralExpiration,
-		CreatedAt:           chat.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:           chat.UpdatedAt.Format(time.RFC3339),
+	// Create chat info for response, with the stored members of groups
+	chatInfo := toChatInfo(chat)
+	if strings.HasSuffix(chat.JID, "@g.us") {
+		participants, err := service.chatStorageRepo.GetGroupParticipants(deviceID, chat.JID)
+		if err != nil {
+			logrus.WithError(err).WithField("chat_jid", request.ChatJID).Warn("Failed to get group participants")
+		}
+		for _, participant := range participants {
+			chatInfo.Participants = append(chatInfo.Participants, domainChat.ParticipantInfo{
+				JID:          participant.JID,
+				IsAdmin:      participant.IsAdmin,
+				IsSuperAdmin: participant.IsSuperAdmin,
+			})
+		}
 	}
 
 	// Create pagination response
@@ -195,6 +207,10 @@ func (service serviceChat) GetChatMessages(ctx context.Context, request domainCh
 		Offset: request.Offset,
 		Total:  int(totalCount),
 	}
+	if request.Search == "" && len(messages) == request.Limit {
+		last := messages[len(messages)-1]
+		pagination.NextCursor = encodeCursor(messagePageCursor{Timestamp: cursorNanos(last.Timestamp), ID: last.ID})
+	}
 
 	response.Data = messageInfos
 	response.Pagination = pagination
@@ -210,6 +226,176 @@ func (service serviceChat) GetChatMessages(ctx context.Context, request domainCh
 	return response, nil
 }
 
+func (service serviceChat) SearchMessages(ctx context.Context, request domainChat.SearchMessagesRequest) (response domainChat.SearchMessagesResponse, err error) {
+	if err = validations.ValidateSearchMessages(ctx, &request); err != nil {
+		return response, err
+	}
+
+	deviceID := deviceIDFromContext(ctx)
+	if deviceID == "" {
+		return response, fmt.Errorf("device identification required")
+	}
+
+	filter := &domainChatStorage.MessageSearchFilter{
+		DeviceID:  deviceID,
+		ChatJID:   request.ChatJID,
+		Query:     request.Query,
+		Limit:     request.Limit,
+		Offset:    request.Offset,
+		MediaOnly: request.MediaOnly,
+	}
+
+	if request.StartTime != nil && *request.StartTime != "" {
+		startTime, err := time.Parse(time.RFC3339, *request.StartTime)
+		if err != nil {
+			return response, fmt.Errorf("invalid start_time format: %v", err)
+		}
+		filter.StartTime = &startTime
+	}
+
+	if request.EndTime != nil && *request.EndTime != "" {
+		endTime, err := time.Parse(time.RFC3339, *request.EndTime)
+		if err != nil {
+			return response, fmt.Errorf("invalid end_time format: %v", err)
+		}
+		filter.EndTime = &endTime
+	}
+
+	results, total, err := service.chatStorageRepo.SearchMessagesFullText(filter)
+	if err != nil {
+		logrus.WithError(err).WithField("query", request.Query).Error("Failed to search messages")
+		return response, err
+	}
+
+	// Results span chats, resolve each chat name once
+	chatNames := make(map[string]string)
+	data := make([]domainChat.SearchMessageResult, 0, len(results))
+	for _, result := range results {
+		message := result.Message
+
+		chatName, ok := chatNames[message.ChatJID]
+		if !ok {
+			if chat, err := service.chatStorageRepo.GetChatByDevice(message.DeviceID, message.ChatJID); err == nil && chat != nil {
+				chatName = chat.Name
+			}
+			chatNames[message.ChatJID] = chatName
+		}
+
+		data = append(data, domainChat.SearchMessageResult{
+			MessageInfo: toMessageInfo(message),
+			ChatName:    chatName,
+			Snippet:     result.Snippet,
+			Rank:        result.Rank,
+		})
+	}
+
+	response.Data = data
+	response.Pagination = domainChat.PaginationResponse{
+		Limit:  request.Limit,
+		Offset: request.Offset,
+		Total:  int(total),
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"results": len(data),
+		"total":   total,
+		"limit":   request.Limit,
+		"offset":  request.Offset,
+	}).Info("Searched messages successfully")
+
+	return response, nil
+}
+
+// toChatInfo converts a stored chat to its API representation
+func toChatInfo(chat *domainChatStorage.Chat) domainChat.ChatInfo {
+	info := domainChat.ChatInfo{
+		JID:                 chat.JID,
+		Name:                chat.Name,
+		LastMessageTime:     chat.LastMessageTime.Format(time.RFC3339),
+		EphemeralExpiration: chat.EphemeralExpiration,
+		CreatedAt:           chat.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:           chat.UpdatedAt.Format(time.RFC3339),
+		UnreadCount:         chat.UnreadCount,
+		MarkedUnread:        chat.MarkedUnread,
+		Archived:            chat.Archived,
+		Pinned:              chat.Pinned,
+		ContactName:         chat.ContactName,
+	}
+	if chat.MutedUntil != nil {
+		info.MutedUntil = chat.MutedUntil.Format(time.RFC3339)
+	}
+	return info
+}
+
+// toMessageInfo converts a stored message to its API representation
+func toMessageInfo(message *domainChatStorage.Message) domainChat.MessageInfo {
+	info := domainChat.MessageInfo{
+		ID:         message.ID,
+		ChatJID:    message.ChatJID,
+		SenderJID:  message.Sender,
+		Content:    message.Content,
+		Timestamp:  message.Timestamp.Format(time.RFC3339),
+		IsFromMe:   message.IsFromMe,
+		MediaType:  message.MediaType,
+		Filename:   message.Filename,
+		URL:        message.URL,
+		FileLength: message.FileLength,
+		CreatedAt:  message.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  message.UpdatedAt.Format(time.RFC3339),
+		Status:     message.Status,
+		IsRevoked:  message.IsRevoked,
+		IsStarred:  message.IsStarred,
+	}
+	if message.EditedAt != nil {
+		info.IsEdited = true
+		info.EditedAt = message.EditedAt.Format(time.RFC3339)
+	}
+	if message.RevokedAt != nil {
+		info.RevokedAt = message.RevokedAt.Format(time.RFC3339)
+	}
+	return info
+}
+
+// attachMessageActivity adds reactions and edit history to messages of one chat.
+// Failures are logged, the messages are still returned without them.
+func (service serviceChat) attachMessageActivity(deviceID, chatJID string, messageIDs []string, infos []domainChat.MessageInfo) {
+	if len(messageIDs) == 0 {
+		return
+	}
+
+	byID := make(map[string]*domainChat.MessageInfo, len(infos))
+	for i := range infos {
+		byID[infos[i].ID] = &infos[i]
+	}
+
+	reactions, err := service.chatStorageRepo.GetMessageReactions(deviceID, chatJID, messageIDs)
+	if err != nil {
+		logrus.WithError(err).WithField("chat_jid", chatJID).Warn("Failed to get message reactions")
+	}
+	for _, reaction := range reactions {
+		if info, ok := byID[reaction.MessageID]; ok {
+			info.Reactions = append(info.Reactions, domainChat.ReactionInfo{
+				SenderJID: reaction.Sender,
+				Reaction:  reaction.Reaction,
+				Timestamp: reaction.Timestamp.Format(time.RFC3339),
+			})
+		}
+	}
+
+	edits, err := service.chatStorageRepo.GetMessageEdits(deviceID, chatJID, messageIDs)
+	if err != nil {
+		logrus.WithError(err).WithField("chat_jid", chatJID).Warn("Failed to get message edit history")
+	}
+	for _, edit := range edits {
+		if info, ok := byID[edit.MessageID]; ok {
+			info.EditHistory = append(info.EditHistory, domainChat.MessageEditInfo{
+				Content:  edit.Content,
+				EditedAt: edit.EditedAt.Format(time.RFC3339),
+			})
+		}
+	}
+}
+
 func deviceIDFromContext(ctx context.Context) string {
 	if inst, ok := whatsapp.DeviceFromContext(ctx); ok && inst != nil {
 		if jid := inst.JID(); jid != "" {
@@ -248,6 +434,9 @@ func (service serviceChat) PinChat(ctx context.Context, request domainChat.PinCh
 		return response, err
 	}
 
+	// Update local storage immediately for consistency
+	_ = service.chatStorageRepo.UpdateChatMetadata(deviceIDFromContext(ctx), request.ChatJID, domainChatStorage.ChatMetadataUpdate{Pinned: &request.Pinned})
+
 	// Build response
 	response.Status = "success"
 	response.ChatJID = request.ChatJID
@@ -345,6 +534,14 @@ func (service serviceChat) ArchiveChat(ctx context.Context, request domainChat.A
 		return response, err
 	}
 
+	// Update local storage immediately for consistency, archiving also unpins
+	update := domainChatStorage.ChatMetadataUpdate{Archived: &request.Archived}
+	if request.Archived {
+		pinned := false
+		update.Pinned = &pinned
+	}
+	_ = service.chatStorageRepo.UpdateChatMetadata(deviceIDFromContext(ctx), request.ChatJID, update)
+
 	// Build response
 	response.Status = "success"
 	response.ChatJID = request.ChatJID
@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// analyticsDefaultRange is used when start_time is not given
+	analyticsDefaultRange = 30 * 24 * time.Hour
+	// Longest ranges per granularity, so the volume series stays a sensible size
+	analyticsMaxHourRange = 31 * 24 * time.Hour
+	analyticsMaxDayRange  = 3660 * 24 * time.Hour
+)
+
+// Period labels of the volume series, matching the ones chat storage groups by
+const (
+	analyticsDayLayout  = "2006-01-02"
+	analyticsHourLayout = "2006-01-02T15:00:00Z"
+)
+
+func (service serviceChat) GetChatAnalytics(ctx context.Context, request domainChat.GetChatAnalyticsRequest) (response domainChat.GetChatAnalyticsResponse, err error) {
+	if err = validations.ValidateGetChatAnalytics(ctx, &request); err != nil {
+		return response, err
+	}
+
+	deviceID := deviceIDFromContext(ctx)
+	if deviceID == "" {
+		return response, fmt.Errorf("device identification required")
+	}
+
+	return service.getChatAnalytics(deviceID, request, time.Now())
+}
+
+func (service serviceChat) getChatAnalytics(deviceID string, request domainChat.GetChatAnalyticsRequest, now time.Time) (response domainChat.GetChatAnalyticsResponse, err error) {
+	endTime := now.UTC()
+	if request.EndTime != nil {
+		if endTime, err = time.Parse(time.RFC3339, *request.EndTime); err != nil {
+			return response, fmt.Errorf("invalid end_time format: %v", err)
+		}
+	}
+	startTime := endTime.Add(-analyticsDefaultRange)
+	if request.StartTime != nil {
+		if startTime, err = time.Parse(time.RFC3339, *request.StartTime); err != nil {
+			return response, fmt.Errorf("invalid start_time format: %v", err)
+		}
+	}
+	startTime, endTime = startTime.UTC(), endTime.UTC()
+
+	if startTime.After(endTime) {
+		return response, pkgError.ValidationError("start_time: must be before end_time.")
+	}
+	granularity, layout, step, maxRange := domainChatStorage.AnalyticsGranularityDay, analyticsDayLayout, 24*time.Hour, analyticsMaxDayRange
+	if request.Granularity == domainChat.AnalyticsGranularityHour {
+		granularity, layout, step, maxRange = domainChatStorage.AnalyticsGranularityHour, analyticsHourLayout, time.Hour, analyticsMaxHourRange
+	}
+	if endTime.Sub(startTime) > maxRange {
+		return response, pkgError.ValidationError(fmt.Sprintf("start_time: %s volume covers at most %d days.", request.Granularity, int(maxRange.Hours()/24)))
+	}
+
+	analytics, err := service.chatStorageRepo.GetMessageAnalytics(&domainChatStorage.AnalyticsFilter{
+		DeviceID:    deviceID,
+		ChatJID:     request.ChatJID,
+		StartTime:   &startTime,
+		EndTime:     &endTime,
+		Granularity: granularity,
+		Limit:       request.Limit,
+	})
+	if err != nil {
+		logrus.WithError(err).Error("Failed to get message analytics")
+		return response, err
+	}
+
+	response = domainChat.GetChatAnalyticsResponse{
+		StartTime:   startTime.Format(time.RFC3339),
+		EndTime:     endTime.Format(time.RFC3339),
+		Granularity: request.Granularity,
+		Inbound:     analytics.Inbound,
+		Outbound:    analytics.Outbound,
+		ResponseTime: domainChat.ResponseTimeInfo{
+			Samples:       analytics.ResponseTime.Samples,
+			MedianSeconds: analytics.ResponseTime.MedianSeconds,
+		},
+	}
+
+	// Storage only returns periods with messages, fill the gaps with zeros
+	volumes := make(map[string]domainChatStorage.MessageVolume, len(analytics.Volume))
+	for _, volume := range analytics.Volume {
+		volumes[volume.Period] = volume
+	}
+	response.Volume = []domainChat.MessageVolumeInfo{}
+	for period := startTime.Truncate(step); !period.After(endTime); period = period.Add(step) {
+		label := period.Format(layout)
+		response.Volume = append(response.Volume, domainChat.MessageVolumeInfo{
+			Period:   label,
+			Inbound:  volumes[label].Inbound,
+			Outbound: volumes[label].Outbound,
+		})
+	}
+
+	response.TopChats = make([]domainChat.ChatActivityInfo, 0, len(analytics.TopChats))
+	for _, chat := range analytics.TopChats {
+		response.TopChats = append(response.TopChats, domainChat.ChatActivityInfo{
+			ChatJID:  chat.ChatJID,
+			Name:     chat.Name,
+			Inbound:  chat.Inbound,
+			Outbound: chat.Outbound,
+		})
+	}
+
+	response.Media = make([]domainChat.MediaUsageInfo, 0, len(analytics.Media))
+	for _, media := range analytics.Media {
+		response.Media = append(response.Media, domainChat.MediaUsageInfo{
+			MediaType: media.MediaType,
+			Messages:  media.Messages,
+			Bytes:     media.Bytes,
+		})
+	}
+
+	response.TopSenders = make([]domainChat.SenderActivityInfo, 0, len(analytics.TopSenders))
+	for _, sender := range analytics.TopSenders {
+		response.TopSenders = append(response.TopSenders, domainChat.SenderActivityInfo{
+			ChatJID:    sender.ChatJID,
+			ChatName:   sender.ChatName,
+			SenderJID:  sender.SenderJID,
+			SenderName: sender.SenderName,
+			Messages:   sender.Messages,
+		})
+	}
+
+	return response, nil
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+)
+
+// analyticsTestRepo returns fixed analytics and records the filter it was asked for
+type analyticsTestRepo struct {
+	domainChatStorage.IChatStorageRepository
+	analytics *domainChatStorage.MessageAnalytics
+	filter    *domainChatStorage.AnalyticsFilter
+}
+
+func (r *analyticsTestRepo) GetMessageAnalytics(filter *domainChatStorage.AnalyticsFilter) (*domainChatStorage.MessageAnalytics, error) {
+	r.filter = filter
+	return r.analytics, nil
+}
+
+func TestGetChatAnalytics(t *testing.T) {
+	now := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC)
+	repo := &analyticsTestRepo{analytics: &domainChatStorage.MessageAnalytics{
+		Inbound:  5,
+		Outbound: 2,
+		Volume: []domainChatStorage.MessageVolume{
+			{Period: "2025-03-02", Inbound: 4, Outbound: 2},
+			{Period: "2025-03-04", Inbound: 1},
+		},
+		TopChats:     []domainChatStorage.ChatActivity{{ChatJID: "111@s.whatsapp.net", Name: "Alice", Inbound: 4, Outbound: 2}},
+		ResponseTime: domainChatStorage.ResponseTimeStats{Samples: 2, MedianSeconds: 90},
+		Media:        []domainChatStorage.MediaUsage{{MediaType: "image", Messages: 1, Bytes: 2048}},
+	}}
+	service := serviceChat{chatStorageRepo: repo}
+
+	start := "2025-03-01T08:00:00Z"
+	response, err := service.getChatAnalytics("dev1", domainChat.GetChatAnalyticsRequest{StartTime: &start, Granularity: domainChat.AnalyticsGranularityDay, Limit: 10}, now)
+	if err != nil {
+		t.Fatalf("getChatAnalytics() error = %v", err)
+	}
+	if repo.filter.DeviceID != "dev1" || !repo.filter.EndTime.Equal(now) || repo.filter.Granularity != domainChatStorage.AnalyticsGranularityDay {
+		t.Errorf("filter = %+v", repo.filter)
+	}
+
+	// Every day of the range is listed, days without messages as zero
+	wantPeriods := []string{"2025-03-01", "2025-03-02", "2025-03-03", "2025-03-04"}
+	if len(response.Volume) != len(wantPeriods) {
+		t.Fatalf("Volume = %+v", response.Volume)
+	}
+	for i, period := range wantPeriods {
+		if response.Volume[i].Period != period {
+			t.Errorf("Volume[%d].Period = %s, want %s", i, response.Volume[i].Period, period)
+		}
+	}
+	if response.Volume[0].Inbound != 0 || response.Volume[1].Inbound != 4 || response.Volume[1].Outbound != 2 || response.Volume[3].Inbound != 1 {
+		t.Errorf("Volume = %+v", response.Volume)
+	}
+	if response.Inbound != 5 || response.ResponseTime.MedianSeconds != 90 || len(response.TopChats) != 1 || len(response.Media) != 1 {
+		t.Errorf("response = %+v", response)
+	}
+	if response.TopSenders == nil {
+		t.Errorf("TopSenders should be an empty list, not null")
+	}
+
+	// Without a start the last 30 days are covered
+	response, err = service.getChatAnalytics("dev1", domainChat.GetChatAnalyticsRequest{Granularity: domainChat.AnalyticsGranularityDay, Limit: 10}, now)
+	if err != nil {
+		t.Fatalf("getChatAnalytics(default range) error = %v", err)
+	}
+	if !repo.filter.StartTime.Equal(now.Add(-analyticsDefaultRange)) || len(response.Volume) != 31 {
+		t.Errorf("default range start = %v, %d periods", repo.filter.StartTime, len(response.Volume))
+	}
+
+	// Hourly volume is limited to a month
+	_, err = service.getChatAnalytics("dev1", domainChat.GetChatAnalyticsRequest{Granularity: domainChat.AnalyticsGranularityHour, StartTime: &start, Limit: 10}, now.AddDate(0, 2, 0))
+	if _, ok := err.(pkgError.ValidationError); !ok {
+		t.Errorf("hourly range error = %v, want a validation error", err)
+	}
+
+	end := "2025-02-01T00:00:00Z"
+	_, err = service.getChatAnalytics("dev1", domainChat.GetChatAnalyticsRequest{StartTime: &start, EndTime: &end, Granularity: domainChat.AnalyticsGranularityDay}, now)
+	if _, ok := err.(pkgError.ValidationError); !ok {
+		t.Errorf("reversed range error = %v, want a validation error", err)
+	}
+}
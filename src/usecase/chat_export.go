@@ -0,0 +1,475 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+)
+
+// exportContentTypes maps export formats to the content type of the transcript
+var exportContentTypes = map[string]string{
+	domainChat.ExportFormatJSON: "application/json",
+	domainChat.ExportFormatCSV:  "text/csv; charset=utf-8",
+	domainChat.ExportFormatHTML: "text/html; charset=utf-8",
+	domainChat.ExportFormatTXT:  "text/plain; charset=utf-8",
+}
+
+func (service serviceChat) ExportChat(ctx context.Context, request domainChat.ExportChatRequest) (response domainChat.ExportChatResponse, err error) {
+	if err = validations.ValidateExportChat(ctx, &request); err != nil {
+		return response, err
+	}
+
+	deviceID := deviceIDFromContext(ctx)
+	if deviceID == "" {
+		return response, fmt.Errorf("device identification required")
+	}
+
+	filter := &domainChatStorage.MessageFilter{DeviceID: deviceID, ChatJID: request.ChatJID}
+	if request.StartTime != nil && *request.StartTime != "" {
+		startTime, err := time.Parse(time.RFC3339, *request.StartTime)
+		if err != nil {
+			return response, fmt.Errorf("invalid start_time format: %v", err)
+		}
+		filter.StartTime = &startTime
+	}
+	if request.EndTime != nil && *request.EndTime != "" {
+		endTime, err := time.Parse(time.RFC3339, *request.EndTime)
+		if err != nil {
+			return response, fmt.Errorf("invalid end_time format: %v", err)
+		}
+		filter.EndTime = &endTime
+	}
+
+	if request.ChatJID != "" {
+		chat, err := service.chatStorageRepo.GetChatByDevice(deviceID, request.ChatJID)
+		if err != nil {
+			return response, err
+		}
+		if chat == nil {
+			return response, fmt.Errorf("chat with JID %s not found", request.ChatJID)
+		}
+	}
+
+	response.FileName, response.ContentType = exportFileName(deviceID, filter, request)
+	response.WriteTo = func(w io.Writer) error {
+		return service.writeExport(deviceID, filter, request, w)
+	}
+	return response, nil
+}
+
+// exportFileName names the export after its chat, or its device and date range
+func exportFileName(deviceID string, filter *domainChatStorage.MessageFilter, request domainChat.ExportChatRequest) (string, string) {
+	safe := strings.NewReplacer("@", "_", ":", "_", "/", "_", ".", "_")
+
+	name := "chat-" + safe.Replace(request.ChatJID)
+	if request.ChatJID == "" {
+		name = "chats-" + safe.Replace(deviceID)
+	}
+	if filter.StartTime != nil {
+		name += "-" + filter.StartTime.Format("20060102")
+	}
+	if filter.EndTime != nil {
+		name += "-" + filter.EndTime.Format("20060102")
+	}
+
+	if request.IncludeMedia {
+		return name + ".zip", "application/zip"
+	}
+	return name + "." + request.Format, exportContentTypes[request.Format]
+}
+
+// exportMessage is a stored message with the names and media file it is exported with
+type exportMessage struct {
+	message    *domainChatStorage.Message
+	chatName   string
+	senderName string
+	mediaFile  string // Path of the bundled media inside the ZIP
+}
+
+// exportWriter renders messages in one export format
+type exportWriter interface {
+	writeMessage(m exportMessage) error
+	close() error
+}
+
+// writeExport streams the transcript, and when requested the media it refers to, to w
+func (service serviceChat) writeExport(deviceID string, filter *domainChatStorage.MessageFilter, request domainChat.ExportChatRequest, w io.Writer) error {
+	var archive *zip.Writer
+	transcript := w
+	if request.IncludeMedia {
+		archive = zip.NewWriter(w)
+		var err error
+		if transcript, err = archive.Create("chat." + request.Format); err != nil {
+			return err
+		}
+	}
+
+	writer, err := newExportWriter(request.Format, transcript, deviceID, request.ChatJID == "")
+	if err != nil {
+		return err
+	}
+
+	names := exportNames{repo: service.chatStorageRepo, deviceID: deviceID, cache: map[string]string{}}
+	media := exportMedia{store: service.mediaStore, files: map[string]string{}}
+
+	err = service.chatStorageRepo.IterateMessages(filter, func(message *domainChatStorage.Message) error {
+		m := exportMessage{
+			message:    message,
+			chatName:   names.lookup(message.ChatJID),
+			senderName: names.sender(message),
+		}
+		if archive != nil {
+			m.mediaFile = media.add(message)
+		}
+		return writer.writeMessage(m)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export messages: %w", err)
+	}
+	if err := writer.close(); err != nil {
+		return err
+	}
+
+	if archive == nil {
+		return nil
+	}
+	if err := media.writeTo(archive); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func newExportWriter(format string, w io.Writer, deviceID string, multipleChats bool) (exportWriter, error) {
+	switch format {
+	case domainChat.ExportFormatJSON:
+		return newJSONExportWriter(w, deviceID)
+	case domainChat.ExportFormatCSV:
+		return newCSVExportWriter(w)
+	case domainChat.ExportFormatHTML:
+		return newHTMLExportWriter(w, deviceID)
+	case domainChat.ExportFormatTXT:
+		return &txtExportWriter{w: w, multipleChats: multipleChats}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// exportNames resolves chat and sender names from stored chats, once per JID
+type exportNames struct {
+	repo     domainChatStorage.IChatStorageRepository
+	deviceID string
+	cache    map[string]string
+}
+
+func (n exportNames) lookup(jid string) string {
+	if name, ok := n.cache[jid]; ok {
+		return name
+	}
+	name := ""
+	if chat, err := n.repo.GetChatByDevice(n.deviceID, jid); err == nil && chat != nil {
+		name = chat.Name
+	}
+	n.cache[jid] = name
+	return name
+}
+
+func (n exportNames) sender(message *domainChatStorage.Message) string {
+	if message.IsFromMe {
+		return "You"
+	}
+	if name := n.lookup(message.Sender); name != "" {
+		return name
+	}
+	user, _, _ := strings.Cut(message.Sender, "@")
+	return "+" + user
+}
+
+// exportMedia collects the downloaded files referenced by the transcript. It
+// reads with a background context since the export streams after the request.
+type exportMedia struct {
+	store domainMediaStore.IMediaStore
+	files map[string]string // Name inside the ZIP -> media store key
+	order []string
+}
+
+// add returns the ZIP path of a message's downloaded media, or "" when there is none
+func (e *exportMedia) add(message *domainChatStorage.Message) string {
+	if message.MediaPath == "" || e.store == nil {
+		return ""
+	}
+	if _, err := e.store.Stat(context.Background(), message.MediaPath); err != nil {
+		return ""
+	}
+
+	name := "media/" + path.Base(filepath.ToSlash(message.MediaPath))
+	if existing, ok := e.files[name]; ok && existing != message.MediaPath {
+		name = "media/" + message.ID + "-" + path.Base(filepath.ToSlash(message.MediaPath))
+	}
+	if _, ok := e.files[name]; !ok {
+		e.files[name] = message.MediaPath
+		e.order = append(e.order, name)
+	}
+	return name
+}
+
+func (e *exportMedia) writeTo(archive *zip.Writer) error {
+	for _, name := range e.order {
+		if err := e.copyIntoZip(archive, name, e.files[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *exportMedia) copyIntoZip(archive *zip.Writer, name, key string) error {
+	file, _, err := e.store.Open(context.Background(), key)
+	if err != nil {
+		return fmt.Errorf("failed to bundle %s: %w", key, err)
+	}
+	defer file.Close()
+
+	entry, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, file)
+	return err
+}
+
+// _____________________________________________________________________________________________________________________
+
+// exportedMessage is the JSON representation of an exported message
+type exportedMessage struct {
+	domainChat.MessageInfo
+	ChatName   string `json:"chat_name,omitempty"`
+	SenderName string `json:"sender_name"`
+	MediaFile  string `json:"media_file,omitempty"`
+}
+
+// jsonExportWriter writes one JSON document, encoding messages as they arrive
+type jsonExportWriter struct {
+	w     io.Writer
+	count int
+}
+
+func newJSONExportWriter(w io.Writer, deviceID string) (*jsonExportWriter, error) {
+	header, err := json.Marshal(map[string]string{
+		"device_id":   deviceID,
+		"exported_at": time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Reopen the header object to append the messages array
+	if _, err := fmt.Fprintf(w, "%s,\"messages\":[", header[:len(header)-1]); err != nil {
+		return nil, err
+	}
+	return &jsonExportWriter{w: w}, nil
+}
+
+func (j *jsonExportWriter) writeMessage(m exportMessage) error {
+	data, err := json.Marshal(exportedMessage{
+		MessageInfo: toMessageInfo(m.message),
+		ChatName:    m.chatName,
+		SenderName:  m.senderName,
+		MediaFile:   m.mediaFile,
+	})
+	if err != nil {
+		return err
+	}
+	if j.count > 0 {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.count++
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonExportWriter) close() error {
+	_, err := fmt.Fprintf(j.w, "],\"total\":%d}\n", j.count)
+	return err
+}
+
+// csvExportWriter writes one row per message
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func newCSVExportWriter(w io.Writer) (*csvExportWriter, error) {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{
+		"chat_jid", "chat_name", "message_id", "timestamp", "sender_jid", "sender_name", "is_from_me",
+		"content", "media_type", "filename", "media_file", "status", "edited_at", "is_revoked", "is_starred",
+	})
+	return &csvExportWriter{w: writer}, err
+}
+
+func (c *csvExportWriter) writeMessage(m exportMessage) error {
+	info := toMessageInfo(m.message)
+	return c.w.Write([]string{
+		info.ChatJID, m.chatName, info.ID, info.Timestamp, info.SenderJID, m.senderName, strconv.FormatBool(info.IsFromMe),
+		info.Content, info.MediaType, info.Filename, m.mediaFile, info.Status, info.EditedAt,
+		strconv.FormatBool(info.IsRevoked), strconv.FormatBool(info.IsStarred),
+	})
+}
+
+func (c *csvExportWriter) close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// txtExportWriter writes the text format of WhatsApp's "Export chat"
+type txtExportWriter struct {
+	w             io.Writer
+	multipleChats bool
+	chatJID       string
+}
+
+func (t *txtExportWriter) writeMessage(m exportMessage) error {
+	message := m.message
+	if t.multipleChats && message.ChatJID != t.chatJID {
+		separator := "\n"
+		if t.chatJID == "" {
+			separator = ""
+		}
+		if _, err := fmt.Fprintf(t.w, "%s=== %s (%s) ===\n", separator, m.chatName, message.ChatJID); err != nil {
+			return err
+		}
+	}
+	t.chatJID = message.ChatJID
+
+	var body string
+	switch {
+	case message.IsRevoked:
+		body = "This message was deleted"
+	case message.MediaType != "" && m.mediaFile != "":
+		body = filepath.Base(m.mediaFile) + " (file attached)"
+	case message.MediaType != "":
+		body = "<Media omitted>"
+	}
+	if !message.IsRevoked && message.Content != "" {
+		if body != "" {
+			body += "\n"
+		}
+		body += message.Content
+	}
+	if message.EditedAt != nil && !message.IsRevoked {
+		body += " <This message was edited>"
+	}
+
+	_, err := fmt.Fprintf(t.w, "%s - %s: %s\n", message.Timestamp.Format("02/01/2006, 15:04"), m.senderName, body)
+	return err
+}
+
+func (t *txtExportWriter) close() error {
+	return nil
+}
+
+var htmlExportTemplates = template.Must(template.New("export").Parse(`
+{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Chat export {{.DeviceID}}</title>
+<style>
+body{font-family:sans-serif;background:#efeae2;margin:0 auto;max-width:860px;padding:16px}
+h2{text-align:center;font-size:16px;color:#54656f}
+.msg{background:#fff;border-radius:8px;margin:6px 0;padding:6px 10px;max-width:70%;word-wrap:break-word}
+.me{background:#d9fdd3;margin-left:auto}
+.meta{color:#667781;font-size:12px}
+.sender{font-weight:bold;font-size:13px}
+.revoked{color:#667781;font-style:italic}
+img,video{max-width:100%}
+</style>
+</head>
+<body>
+<p class="meta">Exported {{.ExportedAt}}</p>
+{{end}}
+{{define "chat"}}<h2>{{.Name}} ({{.JID}})</h2>
+{{end}}
+{{define "message"}}<div class="msg{{if .IsFromMe}} me{{end}}" id="{{.ID}}">
+<div class="sender">{{.SenderName}}</div>
+{{- if .IsRevoked}}
+<div class="revoked">This message was deleted</div>
+{{- else}}
+{{- if .MediaFile}}
+{{- if or (eq .MediaType "image") (eq .MediaType "sticker")}}
+<img src="{{.MediaFile}}" alt="{{.Filename}}">
+{{- else if or (eq .MediaType "video") (eq .MediaType "video_note")}}
+<video controls src="{{.MediaFile}}"></video>
+{{- else if eq .MediaType "audio"}}
+<audio controls src="{{.MediaFile}}"></audio>
+{{- else}}
+<a href="{{.MediaFile}}">{{.Filename}}</a>
+{{- end}}
+{{- else if .MediaType}}
+<div class="meta">[{{.MediaType}}{{if .Filename}}: {{.Filename}}{{end}}]</div>
+{{- end}}
+{{- if .Content}}
+<div>{{.Content}}</div>
+{{- end}}
+{{- end}}
+<div class="meta">{{.Time}}{{if .Edited}} · edited{{end}}{{if .Status}} · {{.Status}}{{end}}</div>
+</div>
+{{end}}
+{{define "footer"}}</body>
+</html>
+{{end}}`))
+
+// htmlExportWriter writes a self-contained page that links bundled media by relative path
+type htmlExportWriter struct {
+	w       io.Writer
+	chatJID string
+}
+
+func newHTMLExportWriter(w io.Writer, deviceID string) (*htmlExportWriter, error) {
+	err := htmlExportTemplates.ExecuteTemplate(w, "header", map[string]string{
+		"DeviceID":   deviceID,
+		"ExportedAt": time.Now().Format(time.RFC3339),
+	})
+	return &htmlExportWriter{w: w}, err
+}
+
+func (h *htmlExportWriter) writeMessage(m exportMessage) error {
+	message := m.message
+	if message.ChatJID != h.chatJID {
+		h.chatJID = message.ChatJID
+		if err := htmlExportTemplates.ExecuteTemplate(h.w, "chat", map[string]string{"Name": m.chatName, "JID": message.ChatJID}); err != nil {
+			return err
+		}
+	}
+
+	return htmlExportTemplates.ExecuteTemplate(h.w, "message", map[string]any{
+		"ID":         message.ID,
+		"IsFromMe":   message.IsFromMe,
+		"SenderName": m.senderName,
+		"IsRevoked":  message.IsRevoked,
+		"MediaType":  message.MediaType,
+		"MediaFile":  m.mediaFile,
+		"Filename":   message.Filename,
+		"Content":    message.Content,
+		"Time":       message.Timestamp.Format("2006-01-02 15:04"),
+		"Edited":     message.EditedAt != nil,
+		"Status":     message.Status,
+	})
+}
+
+func (h *htmlExportWriter) close() error {
+	return htmlExportTemplates.ExecuteTemplate(h.w, "footer", nil)
+}
@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/mediastore"
+)
+
+// exportTestRepo serves a fixed set of chats and messages to the export
+type exportTestRepo struct {
+	domainChatStorage.IChatStorageRepository
+	chats    map[string]string
+	messages []*domainChatStorage.Message
+}
+
+func (r exportTestRepo) GetChatByDevice(_, jid string) (*domainChatStorage.Chat, error) {
+	if name, ok := r.chats[jid]; ok {
+		return &domainChatStorage.Chat{JID: jid, Name: name}, nil
+	}
+	return nil, nil
+}
+
+func (r exportTestRepo) IterateMessages(filter *domainChatStorage.MessageFilter, fn func(*domainChatStorage.Message) error) error {
+	for _, message := range r.messages {
+		if filter.ChatJID != "" && message.ChatJID != filter.ChatJID {
+			continue
+		}
+		if err := fn(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newExportTestService(t *testing.T) (serviceChat, string) {
+	t.Helper()
+
+	store := mediastore.NewLocalStore(t.TempDir(), "", []byte("secret"))
+	mediaPath := "111/2025-03-01/1700000000-photo.jpg"
+	if err := store.Put(context.Background(), mediaPath, []byte("jpeg"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2025, 3, 1, 9, 5, 0, 0, time.UTC)
+	editedAt := base.Add(time.Hour)
+	repo := exportTestRepo{
+		chats: map[string]string{
+			"111@s.whatsapp.net": "Alice",
+			"222@g.us":           "Project <Group>",
+		},
+		messages: []*domainChatStorage.Message{
+			{ID: "m1", ChatJID: "111@s.whatsapp.net", Sender: "111@s.whatsapp.net", Content: "Hi, here is the photo", Timestamp: base},
+			{ID: "m2", ChatJID: "111@s.whatsapp.net", Sender: "111@s.whatsapp.net", MediaType: "image", Filename: "photo.jpg", MediaPath: mediaPath, Content: "Beach", Timestamp: base.Add(time.Minute)},
+			{ID: "m3", ChatJID: "111@s.whatsapp.net", Sender: "me@s.whatsapp.net", IsFromMe: true, Content: "Nice,\nthanks", Timestamp: base.Add(2 * time.Minute), EditedAt: &editedAt},
+			{ID: "m4", ChatJID: "222@g.us", Sender: "333@s.whatsapp.net", Content: "<b>secret</b>", Timestamp: base.Add(3 * time.Minute), IsRevoked: true},
+			{ID: "m5", ChatJID: "222@g.us", Sender: "333@s.whatsapp.net", MediaType: "document", Filename: "plan.pdf", Timestamp: base.Add(4 * time.Minute)},
+		},
+	}
+	return serviceChat{chatStorageRepo: repo, mediaStore: store}, mediaPath
+}
+
+func runExport(t *testing.T, service serviceChat, request domainChat.ExportChatRequest) string {
+	t.Helper()
+	var buffer bytes.Buffer
+	filter := &domainChatStorage.MessageFilter{DeviceID: "dev1", ChatJID: request.ChatJID}
+	if err := service.writeExport("dev1", filter, request, &buffer); err != nil {
+		t.Fatalf("writeExport() error = %v", err)
+	}
+	return buffer.String()
+}
+
+func TestExportTXT(t *testing.T) {
+	service, _ := newExportTestService(t)
+
+	got := runExport(t, service, domainChat.ExportChatRequest{ChatJID: "111@s.whatsapp.net", Format: domainChat.ExportFormatTXT})
+	want := "01/03/2025, 09:05 - Alice: Hi, here is the photo\n" +
+		"01/03/2025, 09:06 - Alice: <Media omitted>\nBeach\n" +
+		"01/03/2025, 09:07 - You: Nice,\nthanks <This message was edited>\n"
+	if got != want {
+		t.Errorf("txt export =\n%s\nwant\n%s", got, want)
+	}
+
+	// Exports of several chats get a header per chat
+	got = runExport(t, service, domainChat.ExportChatRequest{Format: domainChat.ExportFormatTXT})
+	if !strings.HasPrefix(got, "=== Alice (111@s.whatsapp.net) ===\n") ||
+		!strings.Contains(got, "\n\n=== Project <Group> (222@g.us) ===\n01/03/2025, 09:08 - +333: This message was deleted\n") {
+		t.Errorf("txt export of all chats =\n%s", got)
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	service, _ := newExportTestService(t)
+
+	var document struct {
+		DeviceID string            `json:"device_id"`
+		Total    int               `json:"total"`
+		Messages []exportedMessage `json:"messages"`
+	}
+	got := runExport(t, service, domainChat.ExportChatRequest{Format: domainChat.ExportFormatJSON})
+	if err := json.Unmarshal([]byte(got), &document); err != nil {
+		t.Fatalf("json export is invalid: %v\n%s", err, got)
+	}
+	if document.DeviceID != "dev1" || document.Total != 5 || len(document.Messages) != 5 {
+		t.Fatalf("json export = %+v", document)
+	}
+	if m := document.Messages[2]; m.SenderName != "You" || !m.IsEdited || m.ChatName != "Alice" {
+		t.Errorf("json message = %+v", m)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	service, _ := newExportTestService(t)
+
+	records, err := csv.NewReader(strings.NewReader(runExport(t, service, domainChat.ExportChatRequest{Format: domainChat.ExportFormatCSV}))).ReadAll()
+	if err != nil {
+		t.Fatalf("csv export is invalid: %v", err)
+	}
+	if len(records) != 6 || records[0][0] != "chat_jid" || records[3][7] != "Nice,\nthanks" || records[4][13] != "true" {
+		t.Errorf("csv export = %v", records)
+	}
+}
+
+func TestExportHTML(t *testing.T) {
+	service, _ := newExportTestService(t)
+
+	got := runExport(t, service, domainChat.ExportChatRequest{Format: domainChat.ExportFormatHTML})
+	if strings.Contains(got, "<b>secret</b>") || strings.Contains(got, "Project <Group>") {
+		t.Error("html export does not escape content")
+	}
+	if !strings.Contains(got, "Project &lt;Group&gt;") || !strings.Contains(got, "This message was deleted") || !strings.HasSuffix(got, "</html>\n") {
+		t.Errorf("html export =\n%s", got)
+	}
+}
+
+func TestExportZipBundlesMedia(t *testing.T) {
+	service, _ := newExportTestService(t)
+
+	got := runExport(t, service, domainChat.ExportChatRequest{ChatJID: "111@s.whatsapp.net", Format: domainChat.ExportFormatTXT, IncludeMedia: true})
+	archive, err := zip.NewReader(strings.NewReader(got), int64(len(got)))
+	if err != nil {
+		t.Fatalf("zip export is invalid: %v", err)
+	}
+
+	files := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(reader)
+		reader.Close()
+		files[file.Name] = string(data)
+	}
+	if len(files) != 2 || files["media/1700000000-photo.jpg"] != "jpeg" {
+		t.Fatalf("zip entries = %v", files)
+	}
+	if !strings.Contains(files["chat.txt"], "Alice: 1700000000-photo.jpg (file attached)\nBeach\n") {
+		t.Errorf("chat.txt =\n%s", files["chat.txt"])
+	}
+}
+
+func TestExportFileName(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	name, contentType := exportFileName("dev1", &domainChatStorage.MessageFilter{}, domainChat.ExportChatRequest{ChatJID: "111@s.whatsapp.net", Format: domainChat.ExportFormatCSV})
+	if name != "chat-111_s_whatsapp_net.csv" || contentType != "text/csv; charset=utf-8" {
+		t.Errorf("exportFileName(chat) = %s, %s", name, contentType)
+	}
+
+	name, contentType = exportFileName("628@s.whatsapp.net", &domainChatStorage.MessageFilter{StartTime: &start, EndTime: &end}, domainChat.ExportChatRequest{Format: domainChat.ExportFormatHTML, IncludeMedia: true})
+	if name != "chats-628_s_whatsapp_net-20250101-20250201.zip" || contentType != "application/zip" {
+		t.Errorf("exportFileName(device) = %s, %s", name, contentType)
+	}
+}
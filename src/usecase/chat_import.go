@@ -0,0 +1,504 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+	"github.com/sirupsen/logrus"
+)
+
+// importBatchSize is the number of messages stored per transaction
+const importBatchSize = 500
+
+// Bodies WhatsApp writes in place of deleted messages
+var importDeletedBodies = map[string]bool{
+	"This message was deleted": true,
+	"You deleted this message": true,
+}
+
+var (
+	importPhonePattern    = regexp.MustCompile(`^\+?[\d\s().-]{7,}$`)
+	importNonDigitPattern = regexp.MustCompile(`\D`)
+)
+
+func (service serviceChat) ImportChat(ctx context.Context, request domainChat.ImportChatRequest) (response domainChat.ImportChatResponse, err error) {
+	if err = validations.ValidateImportChat(ctx, &request); err != nil {
+		return response, err
+	}
+
+	deviceID := deviceIDFromContext(ctx)
+	if deviceID == "" {
+		return response, fmt.Errorf("device identification required")
+	}
+	return service.importChat(deviceID, request)
+}
+
+// importChat stores the messages of a validated import request for deviceID
+func (service serviceChat) importChat(deviceID string, request domainChat.ImportChatRequest) (response domainChat.ImportChatResponse, err error) {
+	chatJID, err := utils.ParseJID(request.ChatJID)
+	if err != nil {
+		return response, err
+	}
+	response.ChatJID = chatJID.String()
+
+	loc, err := time.LoadLocation(request.Timezone)
+	if err != nil {
+		return response, err
+	}
+
+	export, err := openChatExport(request.File, request.FileSize)
+	if err != nil {
+		return response, err
+	}
+	defer export.transcript.Close()
+
+	entries, err := parseWhatsAppExport(export.transcript)
+	if err != nil {
+		return response, err
+	}
+	order := detectExportDateOrder(entries, request.DateOrder)
+
+	chatName := request.ChatName
+	if chatName == "" {
+		chatName = importChatName(request.FileName)
+	}
+	if chatName == "" {
+		chatName = importChatName(export.transcriptName)
+	}
+
+	importer := &chatImporter{
+		repo:       service.chatStorageRepo,
+		mediaStore: service.mediaStore,
+		deviceID:   deviceID,
+		chatJID:    response.ChatJID,
+		media:      export.media,
+		senders:    newImportSenders(request, entries, deviceID, response.ChatJID, chatName),
+		seen:       map[string]int{},
+		response:   &response,
+	}
+
+	messages := make([]*domainChatStorage.Message, 0, len(entries))
+	for _, entry := range entries {
+		response.Parsed++
+		message, fileName, err := importer.message(entry, order, loc)
+		if err != nil {
+			logrus.Debugf("Skipping imported message: %v", err)
+		}
+		if message == nil {
+			response.Skipped++
+			continue
+		}
+		importer.fileNames = append(importer.fileNames, fileName)
+		messages = append(messages, message)
+	}
+
+	if err := importer.dropExisting(&messages); err != nil {
+		return response, fmt.Errorf("failed to load stored messages: %w", err)
+	}
+	if err := importer.store(messages); err != nil {
+		return response, err
+	}
+	if err := importer.ensureChat(chatName, messages); err != nil {
+		return response, err
+	}
+
+	response.UnmappedSenders = importer.senders.unmappedNames()
+	return response, nil
+}
+
+// chatExport is an opened export: its transcript and, for ZIPs, the attached media by name
+type chatExport struct {
+	transcript     io.ReadCloser
+	transcriptName string
+	media          map[string]*zip.File
+}
+
+// openChatExport opens a ZIP exported with media, or a bare transcript
+func openChatExport(file io.ReaderAt, size int64) (*chatExport, error) {
+	archive, err := zip.NewReader(file, size)
+	if errors.Is(err, zip.ErrFormat) {
+		return &chatExport{transcript: io.NopCloser(io.NewSectionReader(file, 0, size))}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chat export: %w", err)
+	}
+
+	export := &chatExport{media: map[string]*zip.File{}}
+	var transcript *zip.File
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		name := filepath.Base(entry.Name)
+		export.media[name] = entry
+
+		// Android names the transcript after the chat, iOS calls it _chat.txt.
+		// Attached documents may be text files too, so prefer those names.
+		if strings.EqualFold(filepath.Ext(name), ".txt") {
+			if transcript == nil || name == "_chat.txt" || strings.HasPrefix(name, "WhatsApp Chat") {
+				transcript = entry
+			}
+		}
+	}
+	if transcript == nil {
+		return nil, fmt.Errorf("chat export contains no .txt transcript")
+	}
+	delete(export.media, filepath.Base(transcript.Name))
+
+	if export.transcript, err = transcript.Open(); err != nil {
+		return nil, fmt.Errorf("failed to open chat transcript: %w", err)
+	}
+	export.transcriptName = filepath.Base(transcript.Name)
+	return export, nil
+}
+
+// importChatName takes the chat name from export file names such as
+// "WhatsApp Chat with Alice.txt" or "WhatsApp Chat - Alice.zip"
+func importChatName(fileName string) string {
+	name := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	for _, prefix := range []string{"WhatsApp Chat with ", "WhatsApp Chat - "} {
+		if strings.HasPrefix(name, prefix) {
+			return strings.TrimSpace(strings.TrimPrefix(name, prefix))
+		}
+	}
+	return ""
+}
+
+// importSenders maps the display names of an export to JIDs
+type importSenders struct {
+	participants map[string]string
+	selfName     string
+	selfJID      string
+	// A 1:1 chat only has two parties: the peer is the chat itself and
+	// every other unmapped name is the exporting phone
+	direct   bool
+	peerName string
+	chatJID  string
+	unmapped map[string]bool
+}
+
+func newImportSenders(request domainChat.ImportChatRequest, entries []*exportEntry, deviceID, chatJID, chatName string) *importSenders {
+	senders := &importSenders{
+		participants: map[string]string{},
+		selfName:     request.SelfName,
+		selfJID:      deviceID,
+		direct:       !utils.IsGroupJID(chatJID),
+		peerName:     chatName,
+		chatJID:      chatJID,
+		unmapped:     map[string]bool{},
+	}
+	if senders.selfName == "" {
+		senders.selfName = "You"
+	}
+	if jid, err := utils.ParseJID(deviceID); err == nil && strings.Contains(deviceID, "@") {
+		senders.selfJID = jid.ToNonAD().String()
+	}
+	for name, phone := range request.Participants {
+		if jid := importJID(phone); jid != "" {
+			senders.participants[strings.TrimSpace(name)] = jid
+		}
+	}
+
+	// Fall back to the first unmapped sender when the chat name is not one of them
+	if senders.direct && senders.peerName != "" {
+		found := false
+		for _, entry := range entries {
+			found = found || entry.sender == senders.peerName
+		}
+		if !found {
+			senders.peerName = ""
+		}
+	}
+	return senders
+}
+
+// importJID turns a phone number, written in any notation, or a JID into a JID
+func importJID(value string) string {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "@") {
+		value = importNonDigitPattern.ReplaceAllString(value, "")
+	}
+	if value == "" {
+		return ""
+	}
+	jid, err := utils.ParseJID(value)
+	if err != nil {
+		return ""
+	}
+	return jid.String()
+}
+
+// resolve returns the sender JID of a display name and whether it is the exporting phone.
+// Names that cannot be mapped are kept as they are.
+func (s *importSenders) resolve(name string) (string, bool) {
+	if jid, ok := s.participants[name]; ok {
+		return jid, jid == s.selfJID
+	}
+	if name == s.selfName {
+		return s.selfJID, true
+	}
+	if importPhonePattern.MatchString(name) {
+		return importJID(name), false
+	}
+	if s.direct {
+		if s.peerName == "" {
+			s.peerName = name
+		}
+		if name == s.peerName {
+			return s.chatJID, false
+		}
+		return s.selfJID, true
+	}
+
+	s.unmapped[name] = true
+	return name, false
+}
+
+func (s *importSenders) unmappedNames() []string {
+	names := make([]string, 0, len(s.unmapped))
+	for name := range s.unmapped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// chatImporter turns the entries of one export into stored messages
+type chatImporter struct {
+	repo       domainChatStorage.IChatStorageRepository
+	mediaStore domainMediaStore.IMediaStore
+	deviceID   string
+	chatJID    string
+	media      map[string]*zip.File
+	senders    *importSenders
+	// Occurrences of identical messages, to keep their IDs apart
+	seen map[string]int
+	// Attached file of each message, in the order of the messages
+	fileNames []string
+	response  *domainChat.ImportChatResponse
+}
+
+// message converts an entry, returning nil for entries that are not imported
+func (i *chatImporter) message(entry *exportEntry, order string, loc *time.Location) (*domainChatStorage.Message, string, error) {
+	if entry.sender == "" {
+		return nil, "", nil
+	}
+	timestamp, err := entry.timestamp(order, loc)
+	if err != nil {
+		return nil, "", err
+	}
+
+	body := strings.TrimSpace(strings.ReplaceAll(entry.body, "<This message was edited>", ""))
+	if importDeletedBodies[body] {
+		return nil, "", nil
+	}
+	fileName, omitted, caption := exportAttachment(body)
+	caption = strings.TrimSpace(caption)
+	if omitted && caption == "" {
+		return nil, "", nil
+	}
+
+	sender, isFromMe := i.senders.resolve(entry.sender)
+	message := &domainChatStorage.Message{
+		ChatJID:  i.chatJID,
+		DeviceID: i.deviceID,
+		Sender:   sender,
+		Content:  caption,
+		// Live messages carry local timestamps, keep imported ones comparable
+		Timestamp: timestamp.Local(),
+		IsFromMe:  isFromMe,
+	}
+	if fileName != "" {
+		message.MediaType = exportMediaType(fileName)
+		message.Filename = fileName
+	}
+
+	// Importing the same export again yields the same IDs
+	key := strings.Join([]string{i.chatJID, strconv.FormatInt(timestamp.Unix(), 10), sender, caption, fileName}, "\x00")
+	sum := sha256.Sum256([]byte(key + "\x00" + strconv.Itoa(i.seen[key])))
+	i.seen[key]++
+	message.ID = "import-" + hex.EncodeToString(sum[:12])
+
+	return message, fileName, nil
+}
+
+// importMatchKey identifies a message across an export and live storage, which
+// differ in timestamp precision and media details
+func importMatchKey(message *domainChatStorage.Message) string {
+	sender := message.Sender
+	if message.IsFromMe {
+		sender = "me"
+	}
+	return strings.Join([]string{
+		sender,
+		strconv.FormatInt(message.Timestamp.Truncate(time.Minute).Unix(), 10),
+		message.Content,
+		strconv.FormatBool(message.MediaType != ""),
+	}, "\x00")
+}
+
+// dropExisting removes messages already in chat storage, either from an earlier
+// import or received live by the device
+func (i *chatImporter) dropExisting(messages *[]*domainChatStorage.Message) error {
+	if len(*messages) == 0 {
+		return nil
+	}
+
+	start, end := (*messages)[0].Timestamp, (*messages)[0].Timestamp
+	for _, message := range *messages {
+		if message.Timestamp.Before(start) {
+			start = message.Timestamp
+		}
+		if message.Timestamp.After(end) {
+			end = message.Timestamp
+		}
+	}
+	start = start.Truncate(time.Minute)
+	end = end.Truncate(time.Minute).Add(time.Minute)
+
+	// Stored rows by ID with their match key, and how many rows share each key
+	ids := map[string]string{}
+	stored := map[string]int{}
+	err := i.repo.IterateMessages(&domainChatStorage.MessageFilter{
+		DeviceID:  i.deviceID,
+		ChatJID:   i.chatJID,
+		StartTime: &start,
+		EndTime:   &end,
+	}, func(message *domainChatStorage.Message) error {
+		key := importMatchKey(message)
+		ids[message.ID] = key
+		stored[key]++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Rows found by ID are taken out first, each from its own key, which an edit
+	// may have made differ from the key of the exported message
+	byID := make([]bool, len(*messages))
+	for n, message := range *messages {
+		if key, ok := ids[message.ID]; ok {
+			byID[n] = true
+			stored[key]--
+			delete(ids, message.ID)
+		}
+	}
+
+	kept := (*messages)[:0]
+	fileNames := i.fileNames[:0]
+	for n, message := range *messages {
+		key := importMatchKey(message)
+		switch {
+		case byID[n]:
+		case stored[key] > 0:
+			stored[key]--
+		default:
+			kept = append(kept, message)
+			fileNames = append(fileNames, i.fileNames[n])
+			continue
+		}
+		i.response.Duplicates++
+	}
+	*messages = kept
+	i.fileNames = fileNames
+	return nil
+}
+
+// store writes the messages in batches, then extracts their media from the export
+func (i *chatImporter) store(messages []*domainChatStorage.Message) error {
+	for start := 0; start < len(messages); start += importBatchSize {
+		end := min(start+importBatchSize, len(messages))
+		if err := i.repo.StoreMessagesBatch(messages[start:end]); err != nil {
+			return fmt.Errorf("failed to store imported messages: %w", err)
+		}
+		i.response.Imported += end - start
+
+		for n := start; n < end; n++ {
+			file, ok := i.media[filepath.Base(i.fileNames[n])]
+			if !ok {
+				continue
+			}
+			key, err := i.extractMedia(messages[n], file)
+			if err != nil {
+				logrus.Warnf("Failed to extract imported media %s: %v", file.Name, err)
+				continue
+			}
+			if err := i.repo.SetMessageMediaPath(i.deviceID, i.chatJID, messages[n].ID, key); err != nil {
+				return fmt.Errorf("failed to store media path: %w", err)
+			}
+			i.response.MediaFiles++
+		}
+	}
+	return nil
+}
+
+// extractMedia copies an attached file to the media store, next to the media
+// downloaded for the chat
+func (i *chatImporter) extractMedia(message *domainChatStorage.Message, file *zip.File) (string, error) {
+	if i.mediaStore == nil {
+		return "", fmt.Errorf("media storage is not available")
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+
+	name := filepath.Base(file.Name)
+	key := path.Join(utils.ExtractPhoneNumber(i.chatJID), message.Timestamp.Format("2006-01-02"), name)
+	if err := i.mediaStore.Put(context.Background(), key, data, mime.TypeByExtension(filepath.Ext(name))); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// ensureChat creates the chat when the device has not stored it yet
+func (i *chatImporter) ensureChat(name string, messages []*domainChatStorage.Message) error {
+	chat, err := i.repo.GetChatByDevice(i.deviceID, i.chatJID)
+	if err != nil {
+		return err
+	}
+	if chat != nil || len(messages) == 0 {
+		return nil
+	}
+
+	if name == "" {
+		name = utils.ExtractPhoneNumber(i.chatJID)
+	}
+	lastMessageTime := messages[0].Timestamp
+	for _, message := range messages {
+		if message.Timestamp.After(lastMessageTime) {
+			lastMessageTime = message.Timestamp
+		}
+	}
+	return i.repo.StoreChat(&domainChatStorage.Chat{
+		DeviceID:        i.deviceID,
+		JID:             i.chatJID,
+		Name:            name,
+		LastMessageTime: lastMessageTime,
+	})
+}
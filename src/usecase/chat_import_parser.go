@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parser for the text files of WhatsApp's "Export chat". Android writes
+//
+//	01/03/2025, 09:05 - Alice: Hello
+//	1/3/25, 9:05 AM - Alice: IMG-20250301-WA0001.jpg (file attached)
+//
+// and iOS writes
+//
+//	[01/03/2025, 09:05:12] Alice: Hello
+//	[1/3/25, 9:05:12 AM] Alice: <attached: 00000012-PHOTO-2025-03-01-09-05-12.jpg>
+//
+// with the date order, separators and clock following the phone's locale.
+// Lines without a timestamp continue the previous message.
+
+// Date component orders of exported timestamps
+const (
+	exportDateOrderDMY = "dmy"
+	exportDateOrderMDY = "mdy"
+	exportDateOrderYMD = "ymd"
+)
+
+var (
+	exportLinePattern = regexp.MustCompile(`^\[?(\d{1,4})[./-](\d{1,2})[./-](\d{1,4}),?\s+(\d{1,2})[:.](\d{2})(?:[:.](\d{2}))?\s*([AaPp]\.?\s?[Mm]\.?)?\]?\s*(?:-\s+)?(.*)$`)
+	// Senders are separated by ": "; lines without one are system notices
+	exportSenderPattern   = regexp.MustCompile(`^([^:]{1,100}?): (.*)$`)
+	exportAttachedPattern = regexp.MustCompile(`^<attached: (.+)>$`)
+	exportFilePattern     = regexp.MustCompile(`^(.+\.\w{2,5}) \(file attached\)$`)
+)
+
+// Invisible marks WhatsApp puts around names, times and attachments
+var exportInvisibleChars = strings.NewReplacer("\u200e", "", "\u200f", "", "\u202a", "", "\u202c", "", "\ufeff", "", "\u202f", " ", "\u00a0", " ")
+
+// exportEntry is one message of an export before its date is resolved
+type exportEntry struct {
+	date     [3]int
+	hour     int
+	minute   int
+	second   int
+	meridiem string // "am", "pm" or empty for 24 hour clocks
+	sender   string // Empty for system notices
+	body     string
+}
+
+// parseWhatsAppExport splits an exported chat into entries
+func parseWhatsAppExport(r io.Reader) ([]*exportEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var entries []*exportEntry
+	for scanner.Scan() {
+		line := exportInvisibleChars.Replace(scanner.Text())
+
+		match := exportLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			if len(entries) > 0 {
+				entries[len(entries)-1].body += "\n" + line
+			}
+			continue
+		}
+
+		entry := &exportEntry{}
+		for i := 0; i < 3; i++ {
+			entry.date[i], _ = strconv.Atoi(match[i+1])
+		}
+		entry.hour, _ = strconv.Atoi(match[4])
+		entry.minute, _ = strconv.Atoi(match[5])
+		entry.second, _ = strconv.Atoi(match[6])
+		if meridiem := strings.ToLower(strings.NewReplacer(".", "", " ", "").Replace(match[7])); meridiem != "" {
+			entry.meridiem = meridiem
+		}
+
+		entry.body = match[8]
+		if sender := exportSenderPattern.FindStringSubmatch(match[8]); sender != nil {
+			entry.sender = strings.TrimSpace(sender[1])
+			entry.body = sender[2]
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read chat export: %w", err)
+	}
+	return entries, nil
+}
+
+// detectExportDateOrder picks the date order of an export. A day above 12 settles
+// between day and month first; exports that never show one use fallback.
+func detectExportDateOrder(entries []*exportEntry, fallback string) string {
+	for _, entry := range entries {
+		switch {
+		case entry.date[0] > 31:
+			return exportDateOrderYMD
+		case entry.date[0] > 12:
+			return exportDateOrderDMY
+		case entry.date[1] > 12:
+			return exportDateOrderMDY
+		}
+	}
+	if fallback == "" {
+		return exportDateOrderDMY
+	}
+	return fallback
+}
+
+// timestamp resolves the entry's time in loc
+func (e *exportEntry) timestamp(order string, loc *time.Location) (time.Time, error) {
+	var year, month, day int
+	switch order {
+	case exportDateOrderYMD:
+		year, month, day = e.date[0], e.date[1], e.date[2]
+	case exportDateOrderMDY:
+		month, day, year = e.date[0], e.date[1], e.date[2]
+	default:
+		day, month, year = e.date[0], e.date[1], e.date[2]
+	}
+	if year < 100 {
+		year += 2000
+	}
+
+	hour := e.hour
+	switch e.meridiem {
+	case "am":
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+
+	if month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || e.minute > 59 || e.second > 59 {
+		return time.Time{}, fmt.Errorf("invalid timestamp %v %02d:%02d", e.date, e.hour, e.minute)
+	}
+	return time.Date(year, time.Month(month), day, hour, e.minute, e.second, 0, loc), nil
+}
+
+// exportAttachment splits a media marker off the body: the attached file name,
+// whether media was left out of the export, and the remaining caption
+func exportAttachment(body string) (fileName string, omitted bool, caption string) {
+	first, rest, _ := strings.Cut(body, "\n")
+	first = strings.TrimSpace(first)
+
+	switch {
+	case first == "<Media omitted>" || first == "<media omitted>":
+		return "", true, rest
+	case exportAttachedPattern.MatchString(first):
+		return exportAttachedPattern.FindStringSubmatch(first)[1], false, rest
+	case exportFilePattern.MatchString(first):
+		return exportFilePattern.FindStringSubmatch(first)[1], false, rest
+	}
+	return "", false, body
+}
+
+// exportMediaType guesses the stored media type from an attachment's file name
+func exportMediaType(fileName string) string {
+	name := strings.ToLower(fileName)
+	extension := name[strings.LastIndex(name, ".")+1:]
+	switch extension {
+	case "webp":
+		return "sticker"
+	case "jpg", "jpeg", "png", "gif", "heic":
+		return "image"
+	case "mp4", "3gp", "mov", "mkv":
+		return "video"
+	case "opus", "ogg", "m4a", "mp3", "aac", "amr", "wav":
+		return "audio"
+	}
+	return "document"
+}
@@ -0,0 +1,324 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/mediastore"
+)
+
+func TestParseWhatsAppExport(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		want     time.Time
+		sender   string
+		body     string
+		fallback string
+	}{
+		{
+			name:   "android 24 hour",
+			line:   "13/03/2025, 09:05 - Alice: Hello",
+			want:   time.Date(2025, 3, 13, 9, 5, 0, 0, time.UTC),
+			sender: "Alice",
+			body:   "Hello",
+		},
+		{
+			name:   "android 12 hour with narrow space",
+			line:   "3/13/25, 9:05\u202fPM - Bob Smith: Hi: there",
+			want:   time.Date(2025, 3, 13, 21, 5, 0, 0, time.UTC),
+			sender: "Bob Smith",
+			body:   "Hi: there",
+		},
+		{
+			name:   "ios with seconds and marks",
+			line:   "\u200e[13.03.25, 12:05:09\u202fa.m.] Alice: \u200e<attached: 00000012-PHOTO.jpg>",
+			want:   time.Date(2025, 3, 13, 0, 5, 9, 0, time.UTC),
+			sender: "Alice",
+			body:   "<attached: 00000012-PHOTO.jpg>",
+		},
+		{
+			name:   "year first",
+			line:   "[2025-03-01 09:05:00] Alice: Hello",
+			want:   time.Date(2025, 3, 1, 9, 5, 0, 0, time.UTC),
+			sender: "Alice",
+			body:   "Hello",
+		},
+		{
+			name:     "ambiguous date uses fallback",
+			line:     "01/02/2025, 09:05 - Alice: Hello",
+			fallback: exportDateOrderMDY,
+			want:     time.Date(2025, 1, 2, 9, 5, 0, 0, time.UTC),
+			sender:   "Alice",
+			body:     "Hello",
+		},
+		{
+			name: "system notice",
+			line: "01/02/2025, 09:05 - Messages and calls are end-to-end encrypted.",
+			want: time.Date(2025, 2, 1, 9, 5, 0, 0, time.UTC),
+			body: "Messages and calls are end-to-end encrypted.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := parseWhatsAppExport(strings.NewReader(tt.line))
+			if err != nil || len(entries) != 1 {
+				t.Fatalf("parseWhatsAppExport() = %v, %v", entries, err)
+			}
+			entry := entries[0]
+			got, err := entry.timestamp(detectExportDateOrder(entries, tt.fallback), time.UTC)
+			if err != nil || !got.Equal(tt.want) {
+				t.Errorf("timestamp() = %v, %v, want %v", got, err, tt.want)
+			}
+			if entry.sender != tt.sender || entry.body != tt.body {
+				t.Errorf("entry = %q: %q, want %q: %q", entry.sender, entry.body, tt.sender, tt.body)
+			}
+		})
+	}
+}
+
+func TestParseWhatsAppExportMultiline(t *testing.T) {
+	export := "01/03/2025, 09:05 - Alice: First line\nsecond line\n\nthird line\n01/03/2025, 09:06 - Bob: Next"
+	entries, err := parseWhatsAppExport(strings.NewReader(export))
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("parseWhatsAppExport() = %v, %v", entries, err)
+	}
+	if entries[0].body != "First line\nsecond line\n\nthird line" {
+		t.Errorf("multiline body = %q", entries[0].body)
+	}
+}
+
+func TestExportAttachment(t *testing.T) {
+	tests := []struct {
+		body     string
+		fileName string
+		omitted  bool
+		caption  string
+	}{
+		{body: "<attached: 00000012-PHOTO-2025-03-01.jpg>", fileName: "00000012-PHOTO-2025-03-01.jpg"},
+		{body: "IMG-20250301-WA0001.jpg (file attached)\nBeach", fileName: "IMG-20250301-WA0001.jpg", caption: "Beach"},
+		{body: "<Media omitted>", omitted: true},
+		{body: "Just text\n<attached: not a marker>", caption: "Just text\n<attached: not a marker>"},
+	}
+
+	for _, tt := range tests {
+		fileName, omitted, caption := exportAttachment(tt.body)
+		if fileName != tt.fileName || omitted != tt.omitted || caption != tt.caption {
+			t.Errorf("exportAttachment(%q) = %q, %v, %q", tt.body, fileName, omitted, caption)
+		}
+	}
+}
+
+// importTestRepo records the messages, media paths and chats an import stores
+type importTestRepo struct {
+	domainChatStorage.IChatStorageRepository
+	messages   map[string]*domainChatStorage.Message
+	mediaPaths map[string]string
+	chats      map[string]*domainChatStorage.Chat
+}
+
+func newImportTestRepo() *importTestRepo {
+	return &importTestRepo{
+		messages:   map[string]*domainChatStorage.Message{},
+		mediaPaths: map[string]string{},
+		chats:      map[string]*domainChatStorage.Chat{},
+	}
+}
+
+func (r *importTestRepo) IterateMessages(filter *domainChatStorage.MessageFilter, fn func(*domainChatStorage.Message) error) error {
+	for _, message := range r.messages {
+		if message.ChatJID != filter.ChatJID || message.Timestamp.Before(*filter.StartTime) || message.Timestamp.After(*filter.EndTime) {
+			continue
+		}
+		if err := fn(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *importTestRepo) StoreMessagesBatch(messages []*domainChatStorage.Message) error {
+	for _, message := range messages {
+		r.messages[message.ID] = message
+	}
+	return nil
+}
+
+func (r *importTestRepo) SetMessageMediaPath(_, _, messageID, mediaPath string) error {
+	r.mediaPaths[messageID] = mediaPath
+	return nil
+}
+
+func (r *importTestRepo) GetChatByDevice(_, jid string) (*domainChatStorage.Chat, error) {
+	return r.chats[jid], nil
+}
+
+func (r *importTestRepo) StoreChat(chat *domainChatStorage.Chat) error {
+	r.chats[chat.JID] = chat
+	return nil
+}
+
+func importTestZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for name, content := range files {
+		writer, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestImportChat(t *testing.T) {
+	mediaDir := t.TempDir()
+	store := mediastore.NewLocalStore(mediaDir, "", []byte("secret"))
+
+	transcript := "13/03/2025, 09:00 - Messages and calls are end-to-end encrypted.\n" +
+		"13/03/2025, 09:05 - Alice: Hi\nhow are you?\n" +
+		"13/03/2025, 09:06 - Budi: Fine <This message was edited>\n" +
+		"13/03/2025, 09:07 - Alice: IMG-20250313-WA0001.jpg (file attached)\nBeach\n" +
+		"13/03/2025, 09:08 - Alice: <Media omitted>\n" +
+		"13/03/2025, 09:09 - Budi: This message was deleted\n" +
+		"13/03/2025, 09:10 - Budi: ok\n" +
+		"13/03/2025, 09:10 - Budi: ok\n"
+	data := importTestZip(t, map[string]string{
+		"WhatsApp Chat with Alice.txt": transcript,
+		"IMG-20250313-WA0001.jpg":      "jpeg",
+	})
+
+	repo := newImportTestRepo()
+	// Received live before the export was made
+	repo.messages["live"] = &domainChatStorage.Message{
+		ID: "live", ChatJID: "628111@s.whatsapp.net", Sender: "628111@s.whatsapp.net",
+		Content: "Hi\nhow are you?", Timestamp: time.Date(2025, 3, 13, 9, 5, 42, 0, time.UTC),
+	}
+	service := serviceChat{chatStorageRepo: repo, mediaStore: store}
+
+	request := domainChat.ImportChatRequest{
+		ChatJID:  "628111",
+		File:     bytes.NewReader(data),
+		FileName: "WhatsApp Chat with Alice.zip",
+		FileSize: int64(len(data)),
+	}
+	response, err := service.importChat("628999@s.whatsapp.net", request)
+	if err != nil {
+		t.Fatalf("ImportChat() error = %v", err)
+	}
+
+	if response.Parsed != 8 || response.Imported != 4 || response.Duplicates != 1 || response.Skipped != 3 || response.MediaFiles != 1 {
+		t.Fatalf("ImportChat() = %+v", response)
+	}
+	if len(response.UnmappedSenders) != 0 {
+		t.Errorf("unmapped senders = %v", response.UnmappedSenders)
+	}
+
+	var ownMessages, photos int
+	for id, message := range repo.messages {
+		if message.IsFromMe {
+			ownMessages++
+			if message.Sender != "628999@s.whatsapp.net" {
+				t.Errorf("own message sender = %s", message.Sender)
+			}
+		}
+		if message.MediaType == "image" {
+			photos++
+			if message.Content != "Beach" || repo.mediaPaths[id] != "628111/2025-03-13/IMG-20250313-WA0001.jpg" {
+				t.Errorf("photo = %+v, media path %q", message, repo.mediaPaths[id])
+			}
+			if data, err := os.ReadFile(filepath.Join(mediaDir, "628111", "2025-03-13", "IMG-20250313-WA0001.jpg")); err != nil || string(data) != "jpeg" {
+				t.Errorf("extracted media = %q, %v", data, err)
+			}
+		}
+	}
+	if ownMessages != 3 || photos != 1 {
+		t.Errorf("stored %d own messages and %d photos", ownMessages, photos)
+	}
+	if chat := repo.chats["628111@s.whatsapp.net"]; chat == nil || chat.Name != "Alice" {
+		t.Errorf("chat = %+v", chat)
+	}
+
+	// Importing the same export again stores nothing new
+	request.File = bytes.NewReader(data)
+	response, err = service.importChat("628999@s.whatsapp.net", request)
+	if err != nil || response.Imported != 0 || response.Duplicates != 5 {
+		t.Errorf("second ImportChat() = %+v, %v", response, err)
+	}
+}
+
+func TestImportMatchesEditedMessagesByID(t *testing.T) {
+	repo := newImportTestRepo()
+	service := serviceChat{chatStorageRepo: repo}
+	importTranscript := func(transcript string) domainChat.ImportChatResponse {
+		t.Helper()
+		data := importTestZip(t, map[string]string{"WhatsApp Chat with Alice.txt": transcript})
+		response, err := service.importChat("628999@s.whatsapp.net", domainChat.ImportChatRequest{
+			ChatJID:  "628111",
+			File:     bytes.NewReader(data),
+			FileName: "WhatsApp Chat with Alice.zip",
+			FileSize: int64(len(data)),
+		})
+		if err != nil {
+			t.Fatalf("ImportChat() error = %v", err)
+		}
+		return response
+	}
+
+	if response := importTranscript("13/03/2025, 09:10 - Alice: ok\n"); response.Imported != 1 {
+		t.Fatalf("first ImportChat() = %+v", response)
+	}
+	// The imported message was edited afterwards, and a second "ok" arrived live
+	for _, message := range repo.messages {
+		message.Content = "okay"
+	}
+	repo.messages["live"] = &domainChatStorage.Message{
+		ID: "live", ChatJID: "628111@s.whatsapp.net", Sender: "628111@s.whatsapp.net",
+		Content: "ok", Timestamp: time.Date(2025, 3, 13, 9, 10, 42, 0, time.UTC),
+	}
+
+	// The edited message is found by its ID and the live one by its content
+	if response := importTranscript("13/03/2025, 09:10 - Alice: ok\n13/03/2025, 09:10 - Alice: ok\n"); response.Imported != 0 || response.Duplicates != 2 {
+		t.Errorf("second ImportChat() = %+v", response)
+	}
+}
+
+func TestImportSendersInGroup(t *testing.T) {
+	request := domainChat.ImportChatRequest{
+		SelfName:     "Budi",
+		Participants: map[string]string{"Alice": "+62 811-1000"},
+	}
+	senders := newImportSenders(request, nil, "628999@s.whatsapp.net", "1203630@g.us", "Team")
+
+	tests := []struct {
+		name     string
+		jid      string
+		isFromMe bool
+	}{
+		{name: "Alice", jid: "628111000@s.whatsapp.net"},
+		{name: "Budi", jid: "628999@s.whatsapp.net", isFromMe: true},
+		{name: "+62 812-3456-7890", jid: "6281234567890@s.whatsapp.net"},
+		{name: "Carol", jid: "Carol"},
+	}
+	for _, tt := range tests {
+		jid, isFromMe := senders.resolve(tt.name)
+		if jid != tt.jid || isFromMe != tt.isFromMe {
+			t.Errorf("resolve(%q) = %s, %v", tt.name, jid, isFromMe)
+		}
+	}
+	if names := senders.unmappedNames(); len(names) != 1 || names[0] != "Carol" {
+		t.Errorf("unmappedNames() = %v", names)
+	}
+}
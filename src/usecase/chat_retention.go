@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
+	"github.com/sirupsen/logrus"
+)
+
+// retentionBatchPause leaves room for other writers between pruning batches
+const retentionBatchPause = 200 * time.Millisecond
+
+// retentionPolicyFor returns the configured retention policy of a device.
+// An override matches the full device id or its user part.
+func retentionPolicyFor(deviceID string) domainChatStorage.RetentionPolicy {
+	policy := domainChatStorage.RetentionPolicy{
+		DeviceID:           deviceID,
+		MaxAge:             time.Duration(config.ChatStorageRetentionDays) * 24 * time.Hour,
+		MaxMessagesPerChat: config.ChatStorageRetentionMaxPerChat,
+		KeepStarred:        config.ChatStorageRetentionKeepStarred,
+	}
+
+	user, _, _ := strings.Cut(deviceID, "@")
+	for _, entry := range config.ChatStorageRetentionDeviceOverrides {
+		key, value, ok := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !ok || (key != deviceID && key != user) {
+			continue
+		}
+		days, maxPerChat, err := parseRetentionOverride(value)
+		if err != nil {
+			logrus.Warnf("Retention: ignoring override %q: %v", entry, err)
+			continue
+		}
+		policy.MaxAge = time.Duration(days) * 24 * time.Hour
+		policy.MaxMessagesPerChat = maxPerChat
+	}
+	return policy
+}
+
+// parseRetentionOverride parses the "days:max_per_chat" part of an override
+func parseRetentionOverride(value string) (days, maxPerChat int, err error) {
+	daysValue, maxValue, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected days:max_per_chat")
+	}
+	if days, err = strconv.Atoi(strings.TrimSpace(daysValue)); err != nil || days < 0 {
+		return 0, 0, fmt.Errorf("invalid days %q", daysValue)
+	}
+	if maxPerChat, err = strconv.Atoi(strings.TrimSpace(maxValue)); err != nil || maxPerChat < 0 {
+		return 0, 0, fmt.Errorf("invalid max_per_chat %q", maxValue)
+	}
+	return days, maxPerChat, nil
+}
+
+func (service serviceChat) PreviewRetention(ctx context.Context) (response domainChat.RetentionPreviewResponse, err error) {
+	policy := retentionPolicyFor(deviceIDFromContext(ctx))
+	preview, err := service.chatStorageRepo.PreviewPrune(policy)
+	if err != nil {
+		return response, err
+	}
+
+	response = domainChat.RetentionPreviewResponse{
+		DeviceID:           preview.DeviceID,
+		Enabled:            policy.Enabled(),
+		MaxAgeDays:         int(policy.MaxAge / (24 * time.Hour)),
+		MaxMessagesPerChat: policy.MaxMessagesPerChat,
+		KeepStarred:        policy.KeepStarred,
+		Messages:           preview.Messages,
+		Chats:              make([]domainChat.RetentionChatPreview, 0, len(preview.Chats)),
+	}
+	for _, chat := range preview.Chats {
+		response.Chats = append(response.Chats, domainChat.RetentionChatPreview{ChatJID: chat.ChatJID, Messages: chat.Messages})
+	}
+
+	if cutoff, ok := mediaRetentionCutoff(); ok {
+		_ = walkExpiredMedia(cutoff, func(path string, info fs.FileInfo) {
+			response.MediaFiles++
+			response.MediaBytes += info.Size()
+		})
+	}
+	return response, nil
+}
+
+// StartRetentionWorker prunes chat storage once and then every
+// ChatStorageRetentionInterval minutes until ctx is done
+func (service serviceChat) StartRetentionWorker(ctx context.Context) {
+	interval := time.Duration(config.ChatStorageRetentionInterval) * time.Minute
+	if interval <= 0 {
+		logrus.Info("Retention: worker disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		service.runRetention(ctx)
+
+		select {
+		case <-ctx.Done():
+			logrus.Info("Retention: worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runRetention applies the retention policy of every device that has stored messages
+func (service serviceChat) runRetention(ctx context.Context) {
+	deviceIDs, err := service.chatStorageRepo.ListMessageDeviceIDs()
+	if err != nil {
+		logrus.WithError(err).Warn("Retention: failed to list devices")
+		return
+	}
+
+	for _, deviceID := range deviceIDs {
+		policy := retentionPolicyFor(deviceID)
+		if !policy.Enabled() {
+			continue
+		}
+		pruned, err := service.pruneDevice(ctx, policy)
+		if err != nil {
+			logrus.WithError(err).Warnf("Retention: pruning %s stopped after %d messages", deviceID, pruned)
+			continue
+		}
+		if pruned > 0 {
+			logrus.Infof("Retention: pruned %d messages of %s", pruned, deviceID)
+		}
+	}
+
+	if cutoff, ok := mediaRetentionCutoff(); ok && ctx.Err() == nil {
+		service.pruneMedia(cutoff)
+	}
+	service.pruneSyncDeletions()
+}
+
+// pruneDevice deletes the messages selected by policy in batches, pausing
+// between batches so a large backlog never holds the database for long
+func (service serviceChat) pruneDevice(ctx context.Context, policy domainChatStorage.RetentionPolicy) (int64, error) {
+	batchSize := config.ChatStorageRetentionBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	var total int64
+	for {
+		deleted, err := service.chatStorageRepo.PruneMessages(policy, batchSize)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+		if deleted < int64(batchSize) {
+			return total, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		case <-time.After(retentionBatchPause):
+		}
+	}
+}
+
+// mediaRetentionCutoff reports the modification time before which downloaded
+// media is deleted, if media pruning is enabled. Only the local store is pruned,
+// buckets expire their objects with lifecycle rules.
+func mediaRetentionCutoff() (time.Time, bool) {
+	if !config.ChatStorageRetentionPruneMedia || config.ChatStorageRetentionDays <= 0 || config.MediaStorage != domainMediaStore.BackendLocal {
+		return time.Time{}, false
+	}
+	return time.Now().AddDate(0, 0, -config.ChatStorageRetentionDays), true
+}
+
+// walkExpiredMedia calls fn for every file under PathMedia last modified before cutoff
+func walkExpiredMedia(cutoff time.Time, fn func(path string, info fs.FileInfo)) error {
+	return filepath.WalkDir(config.PathMedia, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		fn(path, info)
+		return nil
+	})
+}
+
+// pruneMedia deletes downloaded media older than cutoff and the directories it leaves empty
+func (service serviceChat) pruneMedia(cutoff time.Time) {
+	var files int
+	var bytes int64
+	dirs := map[string]struct{}{}
+
+	err := walkExpiredMedia(cutoff, func(path string, info fs.FileInfo) {
+		if err := os.Remove(path); err != nil {
+			logrus.Warnf("Retention: failed to delete media %s: %v", path, err)
+			return
+		}
+		files++
+		bytes += info.Size()
+		dirs[filepath.Dir(path)] = struct{}{}
+	})
+	if err != nil {
+		logrus.WithError(err).Warn("Retention: failed to scan media")
+	}
+
+	// Remove emptied date and chat directories, never PathMedia itself
+	root := filepath.Clean(config.PathMedia)
+	for dir := range dirs {
+		for dir != root && strings.HasPrefix(dir, root) {
+			if os.Remove(dir) != nil {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+
+	if files > 0 {
+		logrus.Infof("Retention: deleted %d media files (%d bytes)", files, bytes)
+	}
+}
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+)
+
+func TestRetentionPolicyFor(t *testing.T) {
+	originalDays, originalMax, originalOverrides := config.ChatStorageRetentionDays, config.ChatStorageRetentionMaxPerChat, config.ChatStorageRetentionDeviceOverrides
+	defer func() {
+		config.ChatStorageRetentionDays = originalDays
+		config.ChatStorageRetentionMaxPerChat = originalMax
+		config.ChatStorageRetentionDeviceOverrides = originalOverrides
+	}()
+	config.ChatStorageRetentionDays = 90
+	config.ChatStorageRetentionMaxPerChat = 0
+	config.ChatStorageRetentionDeviceOverrides = []string{"628111=30:1000", " sales@s.whatsapp.net = 0:50", "broken=7", "628222=x:1"}
+
+	tests := []struct {
+		name       string
+		deviceID   string
+		wantAge    time.Duration
+		wantMax    int
+		wantActive bool
+	}{
+		{
+			name:       "Global",
+			deviceID:   "628999@s.whatsapp.net",
+			wantAge:    90 * 24 * time.Hour,
+			wantActive: true,
+		},
+		{
+			name:       "OverrideByUser",
+			deviceID:   "628111@s.whatsapp.net",
+			wantAge:    30 * 24 * time.Hour,
+			wantMax:    1000,
+			wantActive: true,
+		},
+		{
+			name:       "OverrideByFullID",
+			deviceID:   "sales@s.whatsapp.net",
+			wantMax:    50,
+			wantActive: true,
+		},
+		{
+			name:       "MalformedOverridesIgnored",
+			deviceID:   "628222@s.whatsapp.net",
+			wantAge:    90 * 24 * time.Hour,
+			wantActive: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := retentionPolicyFor(tt.deviceID)
+			if policy.DeviceID != tt.deviceID || policy.MaxAge != tt.wantAge || policy.MaxMessagesPerChat != tt.wantMax || policy.Enabled() != tt.wantActive {
+				t.Errorf("retentionPolicyFor(%q) = %+v", tt.deviceID, policy)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+	"github.com/sirupsen/logrus"
+)
+
+// syncSettleDelay holds back the newest changes of the feed. Writes are stamped
+// before they commit, so a change younger than this may still be followed by an
+// older one becoming visible.
+const syncSettleDelay = 2 * time.Second
+
+// Cursors are opaque to clients: base64url encoded JSON with timestamps in
+// Unix nanoseconds, so they compare exactly against the stored values.
+
+type chatPageCursor struct {
+	LastMessageTime int64  `json:"t"`
+	JID             string `json:"j"`
+}
+
+type messagePageCursor struct {
+	Timestamp int64  `json:"t"`
+	ID        string `json:"i"`
+}
+
+type changeFeedCursor struct {
+	UpdatedAt   int64  `json:"u,omitempty"`
+	ChatJID     string `json:"c,omitempty"`
+	ID          string `json:"i,omitempty"`
+	DeletionSeq int64  `json:"d,omitempty"`
+	IssuedAt    int64  `json:"at"` // Unix seconds
+}
+
+func encodeCursor(cursor any) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string, target any) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || json.Unmarshal(data, target) != nil {
+		return pkgError.ValidationError("cursor: must be a valid cursor.")
+	}
+	return nil
+}
+
+// cursorTime restores a cursor timestamp, keeping zero as the zero time
+func cursorTime(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+func cursorNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func (service serviceChat) GetMessageChanges(ctx context.Context, request domainChat.GetMessageChangesRequest) (response domainChat.GetMessageChangesResponse, err error) {
+	if err = validations.ValidateGetMessageChanges(ctx, &request); err != nil {
+		return response, err
+	}
+
+	deviceID := deviceIDFromContext(ctx)
+	if deviceID == "" {
+		return response, fmt.Errorf("device identification required")
+	}
+
+	return service.getMessageChanges(deviceID, request, time.Now())
+}
+
+func (service serviceChat) getMessageChanges(deviceID string, request domainChat.GetMessageChangesRequest, now time.Time) (response domainChat.GetMessageChangesResponse, err error) {
+	var cursor changeFeedCursor
+	if request.Cursor != "" {
+		if err := decodeCursor(request.Cursor, &cursor); err != nil {
+			return response, err
+		}
+		// Deletions older than the cursor may have been forgotten already
+		if days := config.ChatStorageSyncDeletionDays; days > 0 && time.Unix(cursor.IssuedAt, 0).Before(now.AddDate(0, 0, -days)) {
+			return response, pkgError.ValidationError("cursor: has expired, sync again without a cursor.")
+		}
+	}
+
+	filter := &domainChatStorage.MessageChangeFilter{
+		DeviceID: deviceID,
+		ChatJID:  request.ChatJID,
+		Since: domainChatStorage.ChangeCursor{
+			UpdatedAt:   cursorTime(cursor.UpdatedAt),
+			ChatJID:     cursor.ChatJID,
+			ID:          cursor.ID,
+			DeletionSeq: cursor.DeletionSeq,
+		},
+		Until: now.Add(-syncSettleDelay),
+		Limit: request.Limit,
+	}
+
+	changes, err := service.chatStorageRepo.GetMessageChanges(filter)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to get message changes")
+		return response, err
+	}
+
+	response.Messages = make([]domainChat.MessageInfo, 0, len(changes.Messages))
+	for _, message := range changes.Messages {
+		response.Messages = append(response.Messages, toMessageInfo(message))
+	}
+	if n := len(changes.Messages); n > 0 {
+		last := changes.Messages[n-1]
+		cursor.UpdatedAt, cursor.ChatJID, cursor.ID = cursorNanos(last.UpdatedAt), last.ChatJID, last.ID
+	}
+
+	response.Deleted = make([]domainChat.DeletedMessageInfo, 0, len(changes.Deletions))
+	for _, deletion := range changes.Deletions {
+		response.Deleted = append(response.Deleted, domainChat.DeletedMessageInfo{
+			ID:        deletion.MessageID,
+			ChatJID:   deletion.ChatJID,
+			DeletedAt: deletion.DeletedAt.Format(time.RFC3339),
+		})
+		cursor.DeletionSeq = deletion.Seq
+	}
+
+	cursor.IssuedAt = now.Unix()
+	response.NextCursor = encodeCursor(cursor)
+	response.HasMore = len(changes.Messages) == request.Limit || len(changes.Deletions) == request.Limit
+	return response, nil
+}
+
+// pruneSyncDeletions forgets deletions that expired from the change feed
+func (service serviceChat) pruneSyncDeletions() {
+	days := config.ChatStorageSyncDeletionDays
+	if days <= 0 {
+		return
+	}
+	pruned, err := service.chatStorageRepo.PruneMessageDeletions(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		logrus.WithError(err).Warn("Retention: failed to prune message deletions")
+		return
+	}
+	if pruned > 0 {
+		logrus.Debugf("Retention: forgot %d message deletions", pruned)
+	}
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+)
+
+// changesTestRepo returns fixed changes and records the filter it was asked for
+type changesTestRepo struct {
+	domainChatStorage.IChatStorageRepository
+	changes *domainChatStorage.MessageChanges
+	filter  *domainChatStorage.MessageChangeFilter
+}
+
+func (r *changesTestRepo) GetMessageChanges(filter *domainChatStorage.MessageChangeFilter) (*domainChatStorage.MessageChanges, error) {
+	r.filter = filter
+	return r.changes, nil
+}
+
+func TestGetMessageChangesCursor(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	updatedAt := now.Add(-time.Minute).Add(123456789)
+	repo := &changesTestRepo{changes: &domainChatStorage.MessageChanges{
+		Messages: []*domainChatStorage.Message{
+			{ID: "m1", ChatJID: "111@s.whatsapp.net", Content: "Hi", Timestamp: now.Add(-time.Hour), UpdatedAt: updatedAt.Add(-time.Second)},
+			{ID: "m2", ChatJID: "111@s.whatsapp.net", Content: "Edited", Timestamp: now.Add(-time.Hour), UpdatedAt: updatedAt},
+		},
+		Deletions: []*domainChatStorage.MessageDeletion{
+			{Seq: 7, ChatJID: "222@g.us", MessageID: "m9", DeletedAt: now.Add(-time.Minute)},
+		},
+	}}
+	service := serviceChat{chatStorageRepo: repo}
+
+	response, err := service.getMessageChanges("dev1", domainChat.GetMessageChangesRequest{Limit: 2}, now)
+	if err != nil {
+		t.Fatalf("getMessageChanges() error = %v", err)
+	}
+	if !repo.filter.Since.UpdatedAt.IsZero() || !repo.filter.Until.Equal(now.Add(-syncSettleDelay)) {
+		t.Errorf("first filter = %+v", repo.filter)
+	}
+	if len(response.Messages) != 2 || len(response.Deleted) != 1 || response.Deleted[0].ID != "m9" || !response.HasMore {
+		t.Fatalf("response = %+v", response)
+	}
+
+	// The next call continues exactly after the last message and deletion
+	repo.changes = &domainChatStorage.MessageChanges{}
+	response, err = service.getMessageChanges("dev1", domainChat.GetMessageChangesRequest{Cursor: response.NextCursor, Limit: 2}, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("getMessageChanges(cursor) error = %v", err)
+	}
+	since := repo.filter.Since
+	if !since.UpdatedAt.Equal(updatedAt) || since.ChatJID != "111@s.whatsapp.net" || since.ID != "m2" || since.DeletionSeq != 7 {
+		t.Errorf("since = %+v", since)
+	}
+	if response.HasMore || response.NextCursor == "" {
+		t.Errorf("caught up response = %+v", response)
+	}
+
+	// An empty page keeps the position
+	cursor := response.NextCursor
+	if _, err = service.getMessageChanges("dev1", domainChat.GetMessageChangesRequest{Cursor: cursor, Limit: 2}, now.Add(2*time.Minute)); err != nil || repo.filter.Since != since {
+		t.Errorf("since after empty page = %+v, %v", repo.filter.Since, err)
+	}
+
+	// Cursors outlive the recorded deletions only for ChatStorageSyncDeletionDays
+	previous := config.ChatStorageSyncDeletionDays
+	config.ChatStorageSyncDeletionDays = 30
+	defer func() { config.ChatStorageSyncDeletionDays = previous }()
+
+	_, err = service.getMessageChanges("dev1", domainChat.GetMessageChangesRequest{Cursor: cursor, Limit: 2}, now.AddDate(0, 0, 31))
+	if _, ok := err.(pkgError.ValidationError); !ok {
+		t.Errorf("expired cursor error = %v", err)
+	}
+	_, err = service.getMessageChanges("dev1", domainChat.GetMessageChangesRequest{Cursor: "not-a-cursor", Limit: 2}, now)
+	if _, ok := err.(pkgError.ValidationError); !ok {
+		t.Errorf("invalid cursor error = %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
+	"github.com/disintegration/imaging"
+)
+
+// readStoredMedia reads a downloaded file from the media store
+func readStoredMedia(ctx context.Context, store domainMediaStore.IMediaStore, key string) ([]byte, error) {
+	if store == nil {
+		return nil, fmt.Errorf("media storage is not configured")
+	}
+	reader, _, err := store.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// storedThumbnail returns a small JPEG of a downloaded image, or nil when the
+// image is not in the media store
+func storedThumbnail(ctx context.Context, store domainMediaStore.IMediaStore, key string) []byte {
+	if key == "" {
+		return nil
+	}
+	data, err := readStoredMedia(ctx, store, key)
+	if err != nil {
+		return nil
+	}
+	srcImage, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil
+	}
+
+	var thumbnail bytes.Buffer
+	if err := imaging.Encode(&thumbnail, imaging.Resize(srcImage, 100, 0, imaging.Lanczos), imaging.JPEG); err != nil {
+		return nil
+	}
+	return thumbnail.Bytes()
+}
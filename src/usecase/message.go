@@ -3,19 +3,17 @@ package usecase
 import (
 	"context"
 	"fmt"
-	"os"
-	"path/filepath"
+	"path"
 	"time"
 
-	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
 	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
 	domainMessage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/message"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
 	"github.com/sirupsen/logrus"
-	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/appstate"
 	"go.mau.fi/whatsmeow/proto/waCommon"
 	"go.mau.fi/whatsmeow/proto/waE2E"
@@ -26,11 +24,13 @@ import (
 
 type serviceMessage struct {
 	chatStorageRepo domainChatStorage.IChatStorageRepository
+	mediaStore      domainMediaStore.IMediaStore
 }
 
-func NewMessageService(chatStorageRepo domainChatStorage.IChatStorageRepository) domainMessage.IMessageUsecase {
+func NewMessageService(chatStorageRepo domainChatStorage.IChatStorageRepository, mediaStore domainMediaStore.IMediaStore) domainMessage.IMessageUsecase {
 	return &serviceMessage{
 		chatStorageRepo: chatStorageRepo,
+		mediaStore:      mediaStore,
 	}
 }
 
@@ -110,6 +110,20 @@ func (service serviceMessage) ReactMessage(ctx context.Context, request domainMe
 		return response, err
 	}
 
+	// Our own reactions are not echoed back as events, record them here
+	if client.Store.ID != nil {
+		if err := service.chatStorageRepo.StoreReaction(&domainChatStorage.MessageReaction{
+			MessageID: request.MessageID,
+			ChatJID:   dataWaRecipient.String(),
+			DeviceID:  deviceIDFromContext(ctx),
+			Sender:    client.Store.ID.ToNonAD().String(),
+			Reaction:  request.Emoji,
+			Timestamp: ts.Timestamp,
+		}); err != nil {
+			logrus.Warnf("Failed to store reaction to %s: %v", request.MessageID, err)
+		}
+	}
+
 	response.MessageID = ts.ID
 	response.Status = fmt.Sprintf("Reaction sent to %s (server timestamp: %s)", request.Phone, ts.Timestamp)
 	return response, nil
@@ -135,6 +149,10 @@ func (service serviceMessage) RevokeMessage(ctx context.Context, request domainM
 		return response, err
 	}
 
+	if err := service.chatStorageRepo.MarkMessageRevoked(deviceIDFromContext(ctx), dataWaRecipient.String(), request.MessageID, ts.Timestamp); err != nil {
+		logrus.Warnf("Failed to mark message %s revoked: %v", request.MessageID, err)
+	}
+
 	response.MessageID = ts.ID
 	response.Status = fmt.Sprintf("Revoke success %s (server timestamp: %s)", request.Phone, ts.Timestamp)
 	return response, nil
@@ -201,6 +219,10 @@ func (service serviceMessage) UpdateMessage(ctx context.Context, request domainM
 		return response, err
 	}
 
+	if err := service.chatStorageRepo.ApplyMessageEdit(deviceIDFromContext(ctx), dataWaRecipient.String(), request.MessageID, request.Message, ts.Timestamp); err != nil {
+		logrus.Warnf("Failed to store edit of message %s: %v", request.MessageID, err)
+	}
+
 	response.MessageID = ts.ID
 	response.Status = fmt.Sprintf("Update message success %s (server timestamp: %s)", request.Phone, ts.Timestamp)
 	return response, nil
@@ -232,6 +254,10 @@ func (service serviceMessage) StarMessage(ctx context.Context, request domainMes
 	if err = client.SendAppState(ctx, patchInfo); err != nil {
 		return err
 	}
+
+	if err := service.chatStorageRepo.SetMessageStarred(deviceIDFromContext(ctx), dataWaRecipient.ToNonAD().String(), request.MessageID, request.IsStarred); err != nil {
+		logrus.Warnf("Failed to store star state of message %s: %v", request.MessageID, err)
+	}
 	return nil
 }
 
@@ -271,84 +297,28 @@ func (service serviceMessage) DownloadMedia(ctx context.Context, request domainM
 		return response, fmt.Errorf("message %s does not belong to chat %s", request.MessageID, dataWaRecipient.String())
 	}
 
-	// Create directory structure for organized storage
-	chatDir := filepath.Join(config.PathMedia, utils.ExtractPhoneNumber(message.ChatJID))
-	dateDir := filepath.Join(chatDir, message.Timestamp.Format("2006-01-02"))
-
-	err = os.MkdirAll(dateDir, 0755)
+	key, err := service.downloadMessageMedia(ctx, client, message)
 	if err != nil {
-		return response, fmt.Errorf("failed to create directory: %v", err)
-	}
-
-	// Create a downloadable message interface based on media type
-	var downloadableMsg interface{}
-
-	switch message.MediaType {
-	case "image":
-		downloadableMsg = &waE2E.ImageMessage{
-			URL:           proto.String(message.URL),
-			MediaKey:      message.MediaKey,
-			FileSHA256:    message.FileSHA256,
-			FileEncSHA256: message.FileEncSHA256,
-			FileLength:    proto.Uint64(message.FileLength),
-		}
-	case "video":
-		downloadableMsg = &waE2E.VideoMessage{
-			URL:           proto.String(message.URL),
-			MediaKey:      message.MediaKey,
-			FileSHA256:    message.FileSHA256,
-			FileEncSHA256: message.FileEncSHA256,
-			FileLength:    proto.Uint64(message.FileLength),
-		}
-	case "audio":
-		downloadableMsg = &waE2E.AudioMessage{
-			URL:           proto.String(message.URL),
-			MediaKey:      message.MediaKey,
-			FileSHA256:    message.FileSHA256,
-			FileEncSHA256: message.FileEncSHA256,
-			FileLength:    proto.Uint64(message.FileLength),
-		}
-	case "document":
-		downloadableMsg = &waE2E.DocumentMessage{
-			URL:           proto.String(message.URL),
-			MediaKey:      message.MediaKey,
-			FileSHA256:    message.FileSHA256,
-			FileEncSHA256: message.FileEncSHA256,
-			FileLength:    proto.Uint64(message.FileLength),
-			FileName:      proto.String(message.Filename),
-		}
-	case "sticker":
-		downloadableMsg = &waE2E.StickerMessage{
-			URL:           proto.String(message.URL),
-			MediaKey:      message.MediaKey,
-			FileSHA256:    message.FileSHA256,
-			FileEncSHA256: message.FileEncSHA256,
-			FileLength:    proto.Uint64(message.FileLength),
-		}
-	default:
-		return response, fmt.Errorf("unsupported media type: %s", message.MediaType)
-	}
-
-	// Download the media using existing utils.ExtractMedia function
-	extractedMedia, err := utils.ExtractMedia(ctx, client, dateDir, downloadableMsg.(whatsmeow.DownloadableMessage))
-	if err != nil {
-		return response, fmt.Errorf("failed to download media: %v", err)
+		return response, err
 	}
 
 	// Get file size
-	fileInfo, err := os.Stat(extractedMedia.MediaPath)
+	object, err := service.mediaStore.Stat(ctx, key)
 	if err != nil {
-		logrus.Warnf("Could not get file size for %s: %v", extractedMedia.MediaPath, err)
+		logrus.Warnf("Could not get file size for %s: %v", key, err)
 	}
 
 	// Build response
 	response.MessageID = request.MessageID
-	response.Status = fmt.Sprintf("Media downloaded successfully to %s", extractedMedia.MediaPath)
+	response.Status = fmt.Sprintf("Media downloaded successfully to %s", key)
 	response.MediaType = message.MediaType
-	response.Filename = filepath.Base(extractedMedia.MediaPath)
-	response.FilePath = extractedMedia.MediaPath
-	if fileInfo != nil {
-		response.FileSize = fileInfo.Size()
+	response.Filename = path.Base(key)
+	response.FilePath = key
+	if object != nil {
+		response.FileSize = object.Size
+	}
+	if response.MediaURL, err = service.mediaStore.URL(ctx, key, whatsapp.MediaURLTTL()); err != nil {
+		logrus.Warnf("Could not link media %s: %v", key, err)
 	}
 
 	logrus.Info(map[string]any{
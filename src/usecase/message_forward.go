@@ -0,0 +1,291 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
+	domainMessage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/message"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+)
+
+// forwardMediaTypes maps stored media types to the upload type used when the
+// media has to be uploaded again
+var forwardMediaTypes = map[string]whatsmeow.MediaType{
+	"image":      whatsmeow.MediaImage,
+	"video":      whatsmeow.MediaVideo,
+	"video_note": whatsmeow.MediaVideo,
+	"audio":      whatsmeow.MediaAudio,
+	"document":   whatsmeow.MediaDocument,
+	"sticker":    whatsmeow.MediaImage,
+}
+
+// forwardMimetypes are the mimetypes of media stored without one
+var forwardMimetypes = map[string]string{
+	"image":      "image/jpeg",
+	"video":      "video/mp4",
+	"video_note": "video/mp4",
+	"audio":      "audio/ogg; codecs=opus",
+	"document":   "application/octet-stream",
+	"sticker":    "image/webp",
+}
+
+// ForwardMessage re-sends a stored message to each target chat, marked as forwarded
+func (service serviceMessage) ForwardMessage(ctx context.Context, request domainMessage.ForwardRequest) (response domainMessage.ForwardResponse, err error) {
+	if err = validations.ValidateForwardMessage(ctx, request); err != nil {
+		return response, err
+	}
+
+	client := whatsapp.ClientFromContext(ctx)
+	if client == nil {
+		return response, pkgError.ErrWaCLI
+	}
+
+	message, err := service.chatStorageRepo.GetMessageByID(request.MessageID)
+	if err != nil {
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to load message %s: %v", request.MessageID, err))
+	}
+	if message == nil {
+		return response, pkgError.ValidationError(fmt.Sprintf("message with ID %s not found", request.MessageID))
+	}
+	if message.IsRevoked {
+		return response, pkgError.ValidationError(fmt.Sprintf("message %s was deleted for everyone and cannot be forwarded", request.MessageID))
+	}
+
+	msg, err := forwardedMessage(ctx, client, service.mediaStore, message)
+	if err != nil {
+		return response, err
+	}
+
+	senderJID := ""
+	if client.Store.ID != nil {
+		senderJID = client.Store.ID.String()
+	}
+
+	response.MessageID = request.MessageID
+	for _, phone := range request.Phones {
+		result := domainMessage.ForwardResult{Phone: phone}
+
+		recipient, err := utils.ValidateJidWithLogin(client, phone)
+		if err == nil {
+			var ts whatsmeow.SendResponse
+			// Each send gets its own copy, the client may annotate the message it sends
+			if ts, err = client.SendMessage(ctx, recipient, proto.Clone(msg).(*waE2E.Message)); err == nil {
+				result.MessageID = ts.ID
+				result.Status = "sent"
+				response.Sent++
+
+				if err := service.chatStorageRepo.StoreSentMessageWithContext(ctx, ts.ID, senderJID, recipient.String(), forwardedContent(message), ts.Timestamp); err != nil {
+					logrus.Warnf("Failed to store forwarded message %s: %v", ts.ID, err)
+				}
+			}
+		}
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			response.Failed++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	response.Status = fmt.Sprintf("Message %s forwarded to %d of %d chats", request.MessageID, response.Sent, len(request.Phones))
+	return response, nil
+}
+
+// forwardedMessage rebuilds a stored message for forwarding. Media keeps pointing
+// at the file WhatsApp already has while its stored link is valid; otherwise it is
+// uploaded again from the media store or from a fresh download.
+func forwardedMessage(ctx context.Context, client *whatsmeow.Client, store domainMediaStore.IMediaStore, message *domainChatStorage.Message) (*waE2E.Message, error) {
+	ctxInfo := &waE2E.ContextInfo{
+		IsForwarded:     proto.Bool(true),
+		ForwardingScore: proto.Uint32(forwardingScore),
+	}
+
+	if message.MediaType == "" {
+		return &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text:        proto.String(message.Content),
+			ContextInfo: ctxInfo,
+		}}, nil
+	}
+
+	mediaType, ok := forwardMediaTypes[message.MediaType]
+	if !ok {
+		return nil, pkgError.ValidationError(fmt.Sprintf("messages of type %s cannot be forwarded", message.MediaType))
+	}
+
+	media, err := forwardedMedia(ctx, client, store, message, mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	mimetype := forwardedMimetype(message)
+	switch message.MediaType {
+	case "image":
+		return &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+			Caption:       proto.String(message.Content),
+			URL:           proto.String(media.URL),
+			DirectPath:    proto.String(media.DirectPath),
+			Mimetype:      proto.String(mimetype),
+			MediaKey:      media.MediaKey,
+			FileSHA256:    media.FileSHA256,
+			FileEncSHA256: media.FileEncSHA256,
+			FileLength:    proto.Uint64(media.FileLength),
+			JPEGThumbnail: storedThumbnail(ctx, store, message.MediaPath),
+			ContextInfo:   ctxInfo,
+		}}, nil
+	case "video", "video_note":
+		video := &waE2E.VideoMessage{
+			Caption:       proto.String(message.Content),
+			URL:           proto.String(media.URL),
+			DirectPath:    proto.String(media.DirectPath),
+			Mimetype:      proto.String(mimetype),
+			MediaKey:      media.MediaKey,
+			FileSHA256:    media.FileSHA256,
+			FileEncSHA256: media.FileEncSHA256,
+			FileLength:    proto.Uint64(media.FileLength),
+			ContextInfo:   ctxInfo,
+		}
+		if message.MediaType == "video_note" {
+			return &waE2E.Message{PtvMessage: video}, nil
+		}
+		return &waE2E.Message{VideoMessage: video}, nil
+	case "audio":
+		return &waE2E.Message{AudioMessage: &waE2E.AudioMessage{
+			URL:           proto.String(media.URL),
+			DirectPath:    proto.String(media.DirectPath),
+			Mimetype:      proto.String(mimetype),
+			MediaKey:      media.MediaKey,
+			FileSHA256:    media.FileSHA256,
+			FileEncSHA256: media.FileEncSHA256,
+			FileLength:    proto.Uint64(media.FileLength),
+			PTT:           proto.Bool(message.IsPTT),
+			ContextInfo:   ctxInfo,
+		}}, nil
+	case "document":
+		return &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
+			FileName:      proto.String(message.Filename),
+			Caption:       proto.String(message.Content),
+			URL:           proto.String(media.URL),
+			DirectPath:    proto.String(media.DirectPath),
+			Mimetype:      proto.String(mimetype),
+			MediaKey:      media.MediaKey,
+			FileSHA256:    media.FileSHA256,
+			FileEncSHA256: media.FileEncSHA256,
+			FileLength:    proto.Uint64(media.FileLength),
+			ContextInfo:   ctxInfo,
+		}}, nil
+	default:
+		return &waE2E.Message{StickerMessage: &waE2E.StickerMessage{
+			URL:           proto.String(media.URL),
+			DirectPath:    proto.String(media.DirectPath),
+			Mimetype:      proto.String(mimetype),
+			MediaKey:      media.MediaKey,
+			FileSHA256:    media.FileSHA256,
+			FileEncSHA256: media.FileEncSHA256,
+			FileLength:    proto.Uint64(media.FileLength),
+			ContextInfo:   ctxInfo,
+		}}, nil
+	}
+}
+
+// forwardedMedia returns the upload a forwarded message points at. The stored
+// upload is reused until its link expires, after which WhatsApp may have dropped
+// the file, so the media is uploaded again: from the media store when it was
+// downloaded, or downloaded first while WhatsApp still has it.
+func forwardedMedia(ctx context.Context, client *whatsmeow.Client, store domainMediaStore.IMediaStore,
+	message *domainChatStorage.Message, mediaType whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	hasKeys := message.URL != "" && len(message.MediaKey) > 0 && len(message.FileSHA256) > 0 && len(message.FileEncSHA256) > 0
+	if hasKeys && !mediaURLExpired(message.URL, time.Now()) {
+		return whatsmeow.UploadResponse{
+			URL:           message.URL,
+			DirectPath:    mediaDirectPath(message.URL),
+			MediaKey:      message.MediaKey,
+			FileSHA256:    message.FileSHA256,
+			FileEncSHA256: message.FileEncSHA256,
+			FileLength:    message.FileLength,
+		}, nil
+	}
+
+	var data []byte
+	switch {
+	case message.MediaPath != "":
+		var err error
+		if data, err = readStoredMedia(ctx, store, message.MediaPath); err != nil {
+			return whatsmeow.UploadResponse{}, pkgError.InternalServerError(fmt.Sprintf("failed to read media of message %s: %v", message.ID, err))
+		}
+	case hasKeys:
+		var err error
+		data, err = client.DownloadMediaWithPath(ctx, mediaDirectPath(message.URL), message.FileEncSHA256, message.FileSHA256,
+			message.MediaKey, int(message.FileLength), mediaType, "")
+		if err != nil {
+			return whatsmeow.UploadResponse{}, pkgError.ValidationError(fmt.Sprintf("media of message %s has expired and was not downloaded: %v", message.ID, err))
+		}
+	default:
+		return whatsmeow.UploadResponse{}, pkgError.ValidationError(fmt.Sprintf("media of message %s is not available to forward", message.ID))
+	}
+
+	media, err := client.Upload(ctx, data, mediaType)
+	if err != nil {
+		return media, pkgError.InternalServerError(fmt.Sprintf("failed to upload media of message %s: %v", message.ID, err))
+	}
+	return media, nil
+}
+
+// mediaURLExpired tells whether a WhatsApp CDN link is past the expiry in its oe
+// parameter, a hex Unix timestamp. Links without one are taken as valid.
+func mediaURLExpired(mediaURL string, now time.Time) bool {
+	parsed, err := url.Parse(mediaURL)
+	if err != nil {
+		return true
+	}
+	expiry, err := strconv.ParseInt(parsed.Query().Get("oe"), 16, 64)
+	if err != nil {
+		return false
+	}
+	return !now.Before(time.Unix(expiry, 0))
+}
+
+// forwardedMimetype is the mimetype the media was sent with, or else the one of
+// its file name or media type
+func forwardedMimetype(message *domainChatStorage.Message) string {
+	if message.Mimetype != "" {
+		return message.Mimetype
+	}
+	if message.MediaType == "document" {
+		if mimetype := mime.TypeByExtension(filepath.Ext(message.Filename)); mimetype != "" {
+			return mimetype
+		}
+	}
+	return forwardMimetypes[message.MediaType]
+}
+
+// mediaDirectPath returns the path of a media URL on the WhatsApp CDN, which is
+// what clients download the file from
+func mediaDirectPath(mediaURL string) string {
+	parsed, err := url.Parse(mediaURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.RequestURI()
+}
+
+// forwardedContent is how a forwarded message is recorded in chat storage
+func forwardedContent(message *domainChatStorage.Message) string {
+	if message.Content != "" || message.MediaType == "" {
+		return message.Content
+	}
+	return fmt.Sprintf("Forwarded %s", message.MediaType)
+}
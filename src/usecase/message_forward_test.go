@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+)
+
+func TestForwardedMessage(t *testing.T) {
+	ctx := context.Background()
+
+	text, err := forwardedMessage(ctx, nil, nil, &domainChatStorage.Message{Content: "hello"})
+	if err != nil || text.GetExtendedTextMessage().GetText() != "hello" || !text.GetExtendedTextMessage().GetContextInfo().GetIsForwarded() {
+		t.Fatalf("forwardedMessage(text) = %v, %v", text, err)
+	}
+
+	// Media with stored keys reuses the upload WhatsApp already has while its link is valid
+	directPath := fmt.Sprintf("/v/t62.7119-24/12345_n.enc?ccb=11-4&oh=01_Q5&oe=%X&_nc_sid=5e03e0", time.Now().Add(24*time.Hour).Unix())
+	stored := &domainChatStorage.Message{
+		ID:            "3EB0MEDIA",
+		Content:       "invoice for march",
+		MediaType:     "document",
+		Filename:      "invoice.pdf",
+		URL:           "https://mmg.whatsapp.net" + directPath,
+		MediaKey:      []byte("media-key"),
+		FileSHA256:    []byte("sha"),
+		FileEncSHA256: []byte("enc-sha"),
+		FileLength:    4096,
+	}
+	forwarded, err := forwardedMessage(ctx, nil, nil, stored)
+	if err != nil {
+		t.Fatalf("forwardedMessage(document) error = %v", err)
+	}
+	document := forwarded.GetDocumentMessage()
+	if document == nil || document.GetURL() != stored.URL || string(document.GetMediaKey()) != "media-key" || document.GetFileLength() != 4096 {
+		t.Fatalf("forwarded document = %v", document)
+	}
+	if document.GetDirectPath() != directPath {
+		t.Errorf("DirectPath = %q", document.GetDirectPath())
+	}
+	if document.GetFileName() != "invoice.pdf" || document.GetMimetype() != "application/pdf" || document.GetContextInfo().GetForwardingScore() != forwardingScore {
+		t.Errorf("forwarded document = %v", document)
+	}
+
+	// Voice notes stay voice notes with the mimetype they were sent with
+	voice, err := forwardedMessage(ctx, nil, nil, &domainChatStorage.Message{
+		MediaType: "audio", Mimetype: "audio/mp4", IsPTT: true, Filename: "audio.ogg",
+		URL: stored.URL, MediaKey: stored.MediaKey, FileSHA256: stored.FileSHA256, FileEncSHA256: stored.FileEncSHA256,
+	})
+	if err != nil || voice.GetAudioMessage().GetMimetype() != "audio/mp4" || !voice.GetAudioMessage().GetPTT() {
+		t.Errorf("forwardedMessage(voice note) = %v, %v", voice, err)
+	}
+
+	// Without keys or a downloaded copy there is nothing to send
+	if _, err := forwardedMessage(ctx, nil, nil, &domainChatStorage.Message{ID: "3EB0GONE", MediaType: "image"}); !isValidationError(err) {
+		t.Errorf("forwardedMessage(missing media) error = %v, want a validation error", err)
+	}
+	if _, err := forwardedMessage(ctx, nil, nil, &domainChatStorage.Message{MediaType: "hologram", URL: "x"}); !isValidationError(err) {
+		t.Errorf("forwardedMessage(unknown type) error = %v, want a validation error", err)
+	}
+}
+
+func TestMediaURLExpired(t *testing.T) {
+	now := time.Unix(0x68A10000, 0)
+	tests := []struct {
+		name string
+		url  string
+		want bool
+	}{
+		{"before expiry", "https://mmg.whatsapp.net/v/t62/1_n.enc?oh=01&oe=68A10001", false},
+		{"at expiry", "https://mmg.whatsapp.net/v/t62/1_n.enc?oh=01&oe=68A10000", true},
+		{"after expiry", "https://mmg.whatsapp.net/v/t62/1_n.enc?oh=01&oe=68A0FFFF", true},
+		{"no expiry", "https://mmg.whatsapp.net/v/t62/1_n.enc?oh=01", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mediaURLExpired(tt.url, now); got != tt.want {
+				t.Errorf("mediaURLExpired(%q) = %t, want %t", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForwardedMimetype(t *testing.T) {
+	tests := []struct {
+		name    string
+		message domainChatStorage.Message
+		want    string
+	}{
+		{"stored", domainChatStorage.Message{MediaType: "image", Mimetype: "image/png"}, "image/png"},
+		{"document by file name", domainChatStorage.Message{MediaType: "document", Filename: "invoice.pdf"}, "application/pdf"},
+		{"document without extension", domainChatStorage.Message{MediaType: "document", Filename: "invoice"}, "application/octet-stream"},
+		{"image by media type", domainChatStorage.Message{MediaType: "image"}, "image/jpeg"},
+		{"audio by media type", domainChatStorage.Message{MediaType: "audio", Filename: "audio.ogg"}, "audio/ogg; codecs=opus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := forwardedMimetype(&tt.message); got != tt.want {
+				t.Errorf("forwardedMimetype() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
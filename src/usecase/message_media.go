@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
+	domainMessage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/message"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+)
+
+// GetMedia finds the media of a stored message in the media store, downloading
+// it first when it was not downloaded yet. It returns nil for unknown messages.
+func (service serviceMessage) GetMedia(ctx context.Context, request domainMessage.MediaRequest) (*domainMessage.MediaResponse, error) {
+	if err := validations.ValidateGetMedia(ctx, request); err != nil {
+		return nil, err
+	}
+	if service.mediaStore == nil {
+		return nil, pkgError.InternalServerError("media storage is not available")
+	}
+
+	message, err := service.chatStorageRepo.GetMessageByID(request.MessageID)
+	if err != nil {
+		return nil, pkgError.InternalServerError(fmt.Sprintf("failed to load message %s: %v", request.MessageID, err))
+	}
+	if message == nil {
+		return nil, nil
+	}
+	if message.MediaType == "" {
+		return nil, pkgError.ValidationError(fmt.Sprintf("message %s does not contain media", request.MessageID))
+	}
+
+	key, object, err := service.storedMessageMedia(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	if object == nil {
+		// Not downloaded yet, or pruned since
+		if message.URL == "" {
+			return nil, pkgError.ValidationError(fmt.Sprintf("media of message %s is not available", request.MessageID))
+		}
+		client := whatsapp.ClientFromContext(ctx)
+		if client == nil {
+			return nil, pkgError.ErrWaCLI
+		}
+		if key, err = service.downloadMessageMedia(ctx, client, message); err != nil {
+			return nil, pkgError.InternalServerError(err.Error())
+		}
+		if object, err = service.mediaStore.Stat(ctx, key); err != nil {
+			return nil, pkgError.InternalServerError(fmt.Sprintf("failed to read media %s: %v", key, err))
+		}
+	}
+
+	response := &domainMessage.MediaResponse{
+		MessageID:   message.ID,
+		MediaType:   message.MediaType,
+		Filename:    message.Filename,
+		ContentType: object.ContentType,
+		FileSize:    object.Size,
+		ModTime:     object.ModTime,
+		ExpiresAt:   time.Now().Add(whatsapp.MediaURLTTL()).UTC().Truncate(time.Second),
+	}
+	if response.Filename == "" {
+		response.Filename = path.Base(key)
+	}
+	if response.ContentType == "" {
+		response.ContentType = utils.MediaContentType(key)
+	}
+	if response.MediaURL, err = service.mediaStore.URL(ctx, key, whatsapp.MediaURLTTL()); err != nil {
+		logrus.Warnf("Could not link media %s: %v", key, err)
+	}
+
+	store := service.mediaStore
+	response.Open = func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		reader, _, err := store.OpenRange(ctx, key, offset, length)
+		return reader, err
+	}
+	return response, nil
+}
+
+// storedMessageMedia looks up the downloaded media of a message, returning a nil
+// object when there is none
+func (service serviceMessage) storedMessageMedia(ctx context.Context, message *domainChatStorage.Message) (string, *domainMediaStore.Object, error) {
+	if message.MediaPath == "" {
+		return "", nil, nil
+	}
+	object, err := service.mediaStore.Stat(ctx, message.MediaPath)
+	if errors.Is(err, domainMediaStore.ErrNotFound) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, pkgError.InternalServerError(fmt.Sprintf("failed to read media %s: %v", message.MediaPath, err))
+	}
+	return message.MediaPath, object, nil
+}
+
+// downloadMessageMedia downloads the media of a stored message into the media
+// store, unless it was downloaded before, and returns its key
+func (service serviceMessage) downloadMessageMedia(ctx context.Context, client *whatsmeow.Client, message *domainChatStorage.Message) (string, error) {
+	// Create a downloadable message interface based on media type
+	var downloadableMsg interface{}
+
+	switch message.MediaType {
+	case "image":
+		downloadableMsg = &waE2E.ImageMessage{
+			URL:           proto.String(message.URL),
+			MediaKey:      message.MediaKey,
+			FileSHA256:    message.FileSHA256,
+			FileEncSHA256: message.FileEncSHA256,
+			FileLength:    proto.Uint64(message.FileLength),
+		}
+	case "video":
+		downloadableMsg = &waE2E.VideoMessage{
+			URL:           proto.String(message.URL),
+			MediaKey:      message.MediaKey,
+			FileSHA256:    message.FileSHA256,
+			FileEncSHA256: message.FileEncSHA256,
+			FileLength:    proto.Uint64(message.FileLength),
+		}
+	case "audio":
+		downloadableMsg = &waE2E.AudioMessage{
+			URL:           proto.String(message.URL),
+			MediaKey:      message.MediaKey,
+			FileSHA256:    message.FileSHA256,
+			FileEncSHA256: message.FileEncSHA256,
+			FileLength:    proto.Uint64(message.FileLength),
+		}
+	case "document":
+		downloadableMsg = &waE2E.DocumentMessage{
+			URL:           proto.String(message.URL),
+			MediaKey:      message.MediaKey,
+			FileSHA256:    message.FileSHA256,
+			FileEncSHA256: message.FileEncSHA256,
+			FileLength:    proto.Uint64(message.FileLength),
+			FileName:      proto.String(message.Filename),
+		}
+	case "sticker":
+		downloadableMsg = &waE2E.StickerMessage{
+			URL:           proto.String(message.URL),
+			MediaKey:      message.MediaKey,
+			FileSHA256:    message.FileSHA256,
+			FileEncSHA256: message.FileEncSHA256,
+			FileLength:    proto.Uint64(message.FileLength),
+		}
+	default:
+		return "", fmt.Errorf("unsupported media type: %s", message.MediaType)
+	}
+
+	// Download the media into the media store, unless it was downloaded before
+	extractedMedia, err := utils.ExtractMedia(ctx, client, service.mediaStore, utils.MediaKeyPrefix(message.ChatJID, message.Timestamp, message.ID), downloadableMsg.(whatsmeow.DownloadableMessage))
+	if err != nil {
+		return "", fmt.Errorf("failed to download media: %v", err)
+	}
+
+	if err := service.chatStorageRepo.SetMessageMediaPath(message.DeviceID, message.ChatJID, message.ID, extractedMedia.MediaPath); err != nil {
+		logrus.Warnf("Failed to store media path of message %s: %v", message.ID, err)
+	}
+	return extractedMedia.MediaPath, nil
+}
@@ -9,16 +9,15 @@ import (
 	"mime"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/domains/app"
 	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	domainMediaProc "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediaproc"
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
 	domainSend "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/send"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
@@ -26,7 +25,6 @@ import (
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/ui/rest/helpers"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
 	"github.com/disintegration/imaging"
-	fiberUtils "github.com/gofiber/fiber/v2/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/valyala/fasthttp"
 	"go.mau.fi/whatsmeow"
@@ -35,18 +33,19 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
-// webpCanvasSizeRegex is compiled once at package level for efficiency
-var webpCanvasSizeRegex = regexp.MustCompile(`Canvas size:\s*(\d+)\s*x\s*(\d+)`)
-
 type serviceSend struct {
 	appService      app.IAppUsecase
 	chatStorageRepo domainChatStorage.IChatStorageRepository
+	mediaStore      domainMediaStore.IMediaStore
+	mediaProcessor  domainMediaProc.IMediaProcessor
 }
 
-func NewSendService(appService app.IAppUsecase, chatStorageRepo domainChatStorage.IChatStorageRepository) domainSend.ISendUsecase {
+func NewSendService(appService app.IAppUsecase, chatStorageRepo domainChatStorage.IChatStorageRepository, mediaStore domainMediaStore.IMediaStore, mediaProcessor domainMediaProc.IMediaProcessor) domainSend.ISendUsecase {
 	return &serviceSend{
 		appService:      appService,
 		chatStorageRepo: chatStorageRepo,
+		mediaStore:      mediaStore,
+		mediaProcessor:  mediaProcessor,
 	}
 }
 
@@ -97,20 +96,18 @@ func (service serviceSend) SendText(ctx context.Context, request domainSend.Mess
 		return response, err
 	}
 
-	// Create base message
+	// Create base message with forwarding, reply and mention context
+	ctxInfo := service.messageContextInfo(ctx, request.BaseRequest, dataWaRecipient, request.Message)
+	if ctxInfo == nil {
+		ctxInfo = &waE2E.ContextInfo{}
+	}
 	msg := &waE2E.Message{
 		ExtendedTextMessage: &waE2E.ExtendedTextMessage{
 			Text:        proto.String(request.Message),
-			ContextInfo: &waE2E.ContextInfo{},
+			ContextInfo: ctxInfo,
 		},
 	}
 
-	// Add forwarding context if IsForwarded is true
-	if request.BaseRequest.IsForwarded {
-		msg.ExtendedTextMessage.ContextInfo.IsForwarded = proto.Bool(true)
-		msg.ExtendedTextMessage.ContextInfo.ForwardingScore = proto.Uint32(100)
-	}
-
 	// Set disappearing message duration if provided
 	if request.BaseRequest.Duration != nil && *request.BaseRequest.Duration > 0 {
 		msg.ExtendedTextMessage.ContextInfo.Expiration = proto.Uint32(uint32(*request.BaseRequest.Duration))
@@ -118,69 +115,6 @@ func (service serviceSend) SendText(ctx context.Context, request domainSend.Mess
 		msg.ExtendedTextMessage.ContextInfo.Expiration = proto.Uint32(service.getDefaultEphemeralExpiration(request.BaseRequest.Phone))
 	}
 
-	// Get mentions from text (existing behavior - parses @phone from message text)
-	parsedMentions := service.getMentionFromText(ctx, request.Message)
-
-	// Add explicit mentions from request.Mentions (ghost mentions - no @ required in text)
-	if len(request.Mentions) > 0 {
-		explicitMentions := service.getMentionsFromList(ctx, request.Mentions, dataWaRecipient)
-		parsedMentions = append(parsedMentions, explicitMentions...)
-		// Deduplicate to avoid mentioning the same person twice
-		parsedMentions = utils.UniqueStrings(parsedMentions)
-	}
-
-	if len(parsedMentions) > 0 {
-		msg.ExtendedTextMessage.ContextInfo.MentionedJID = parsedMentions
-	}
-
-	// Reply message
-	if request.ReplyMessageID != nil && *request.ReplyMessageID != "" {
-		message, err := service.chatStorageRepo.GetMessageByID(*request.ReplyMessageID)
-		if err != nil {
-			logrus.Warnf("Error retrieving reply message ID %s: %v, continuing without reply context", *request.ReplyMessageID, err)
-		} else if message != nil { // Only set reply context if we found the message
-			// Ensure we use a full JID (user@server) for the Participant field
-			// Use the sender JID from storage as-is. Modern storage should already provide
-			// fully-qualified JIDs (e.g., user@s.whatsapp.net or group@g.us). Avoid mutating
-			// the JID here to prevent corrupting valid group or special JIDs.
-			participantJID := message.Sender
-
-			// Build base ContextInfo with reply details
-			ctxInfo := &waE2E.ContextInfo{
-				StanzaID:    request.ReplyMessageID,
-				Participant: proto.String(participantJID),
-				QuotedMessage: &waE2E.Message{
-					Conversation: proto.String(message.Content),
-				},
-			}
-
-			// Preserve forwarding flag if set
-			if request.BaseRequest.IsForwarded {
-				ctxInfo.IsForwarded = proto.Bool(true)
-				ctxInfo.ForwardingScore = proto.Uint32(100)
-			}
-
-			// Preserve disappearing message duration if provided
-			if request.BaseRequest.Duration != nil && *request.BaseRequest.Duration > 0 {
-				ctxInfo.Expiration = proto.Uint32(uint32(*request.BaseRequest.Duration))
-			} else {
-				ctxInfo.Expiration = proto.Uint32(service.getDefaultEphemeralExpiration(participantJID))
-			}
-
-			// Preserve mentions
-			if len(parsedMentions) > 0 {
-				ctxInfo.MentionedJID = parsedMentions
-			}
-
-			msg.ExtendedTextMessage = &waE2E.ExtendedTextMessage{
-				Text:        proto.String(request.Message),
-				ContextInfo: ctxInfo,
-			}
-		} else {
-			logrus.Warnf("Reply message ID %s not found in storage, continuing without reply context", *request.ReplyMessageID)
-		}
-	}
-
 	ts, err := service.wrapSendMessage(ctx, client, dataWaRecipient, msg, request.Message)
 	if err != nil {
 		return response, err
@@ -324,12 +258,7 @@ func (service serviceSend) SendImage(ctx context.Context, request domainSend.Ima
 		ViewOnce:      proto.Bool(request.ViewOnce),
 	}}
 
-	if request.BaseRequest.IsForwarded {
-		msg.ImageMessage.ContextInfo = &waE2E.ContextInfo{
-			IsForwarded:     proto.Bool(true),
-			ForwardingScore: proto.Uint32(100),
-		}
-	}
+	msg.ImageMessage.ContextInfo = service.messageContextInfo(ctx, request.BaseRequest, dataWaRecipient, request.Caption)
 
 	// Set duration expiration
 	if request.BaseRequest.Duration != nil && *request.BaseRequest.Duration > 0 {
@@ -398,12 +327,7 @@ func (service serviceSend) SendFile(ctx context.Context, request domainSend.File
 		Caption:       proto.String(request.Caption),
 	}}
 
-	if request.BaseRequest.IsForwarded {
-		msg.DocumentMessage.ContextInfo = &waE2E.ContextInfo{
-			IsForwarded:     proto.Bool(true),
-			ForwardingScore: proto.Uint32(100),
-		}
-	}
+	msg.DocumentMessage.ContextInfo = service.messageContextInfo(ctx, request.BaseRequest, dataWaRecipient, request.Caption)
 
 	if request.BaseRequest.Duration != nil && *request.BaseRequest.Duration > 0 {
 		if msg.DocumentMessage.ContextInfo == nil {
@@ -465,125 +389,27 @@ func resolveAudioMIME(filename string, audioBytes []byte) string {
 	return detectedMime
 }
 
-// runFFProbe executes ffprobe with the given arguments and returns the output.
-// Returns empty output and error if ffprobe is not available or fails.
-func runFFProbe(args ...string) ([]byte, error) {
-	if _, err := exec.LookPath("ffprobe"); err != nil {
-		return nil, fmt.Errorf("ffprobe not found: %w", err)
-	}
-	return exec.Command("ffprobe", args...).Output()
-}
-
-// runFFMpeg executes ffmpeg with the given arguments and returns the output.
-// Returns empty output and error if ffmpeg is not available or fails.
-func runFFMpeg(args ...string) ([]byte, error) {
-	if _, err := exec.LookPath("ffmpeg"); err != nil {
-		return nil, fmt.Errorf("ffmpeg not found: %w", err)
-	}
-	return exec.Command("ffmpeg", args...).Output()
-}
-
-// getAudioDuration returns the duration of an audio file in seconds using ffprobe.
-// If ffprobe is not available or fails, it returns 0.
-func getAudioDuration(audioPath string) uint32 {
-	output, err := runFFProbe(
-		"-hide_banner",
-		"-show_entries", "format=duration",
-		"-of", "default=noprint_wrappers=1:nokey=1",
-		audioPath,
-	)
-	if err != nil {
-		logrus.Warnf("Failed to get audio duration: %v", err)
-		return 0
-	}
-
-	// Parse duration string (e.g., "36.266500")
-	durationStr := strings.TrimSpace(string(output))
-	duration, err := strconv.ParseFloat(durationStr, 64)
-	if err != nil {
-		logrus.Warnf("Failed to parse audio duration '%s': %v", durationStr, err)
+// mediaSeconds returns the whole seconds media plays for, 0 when the processor
+// cannot tell
+func mediaSeconds(info *domainMediaProc.MediaInfo) uint32 {
+	if info == nil {
 		return 0
 	}
-
-	return uint32(duration)
+	return uint32(info.Duration / time.Second)
 }
 
-// generateWaveform generates a waveform visualization for voice notes using ffmpeg.
-// Returns a []byte with 64 amplitude samples (0-100) for WhatsApp UI visualization.
-func generateWaveform(audioPath string) []byte {
-	// Extract audio samples as signed 8-bit PCM
-	// -ac 1: mono, -ar 8000: 8kHz sample rate, -f s8: signed 8-bit output
-	output, err := runFFMpeg(
-		"-i", audioPath,
-		"-ac", "1",
-		"-ar", "8000",
-		"-f", "s8",
-		"-acodec", "pcm_s8",
-		"pipe:1",
-	)
+// voiceWaveform returns the 64 amplitudes (0-100) WhatsApp draws for a voice
+// note, or a placeholder pattern when the processor cannot read the audio
+func (service serviceSend) voiceWaveform(ctx context.Context, audioBytes []byte) []byte {
+	waveform, err := service.mediaProcessor.Waveform(ctx, audioBytes, 64)
 	if err != nil {
-		logrus.Warnf("Failed to generate waveform: %v", err)
-		return generateDefaultWaveform()
-	}
-
-	return downsampleToWaveform(output, 64)
-}
-
-// downsampleToWaveform converts raw PCM samples to a fixed number of amplitude peaks.
-// WhatsApp expects 64 bytes with values 0-100 (percentage of max amplitude).
-// Uses RMS (Root Mean Square) for better dynamic range representation.
-func downsampleToWaveform(samples []byte, numPoints int) []byte {
-	if len(samples) == 0 {
+		logrus.Warnf("Failed to generate waveform, using a placeholder: %v", err)
 		return generateDefaultWaveform()
 	}
-
-	waveform := make([]byte, numPoints)
-	samplesPerPoint := len(samples) / numPoints
-	if samplesPerPoint == 0 {
-		samplesPerPoint = 1
-	}
-
-	// Calculate RMS for each segment
-	rmsValues := make([]float64, numPoints)
-	var maxRMS float64 = 0
-
-	for i := 0; i < numPoints; i++ {
-		start := i * samplesPerPoint
-		end := start + samplesPerPoint
-		if end > len(samples) {
-			end = len(samples)
-		}
-
-		// Calculate RMS (Root Mean Square) for this segment
-		var sumSquares float64
-		for j := start; j < end; j++ {
-			amp := float64(int8(samples[j]))
-			sumSquares += amp * amp
-		}
-		rms := math.Sqrt(sumSquares / float64(end-start))
-		rmsValues[i] = rms
-
-		if rms > maxRMS {
-			maxRMS = rms
-		}
-	}
-
-	if maxRMS == 0 {
-		maxRMS = 1 // Avoid division by zero
-	}
-
-	// Normalize to 0-100 scale with slight boost for visibility
-	for i := 0; i < numPoints; i++ {
-		// Apply slight curve to enhance dynamic range visibility
-		normalized := rmsValues[i] / maxRMS
-		// Use power curve to make quieter parts more visible while preserving peaks
-		waveform[i] = byte(math.Pow(normalized, 0.7) * 100)
-	}
-
 	return waveform
 }
 
-// generateDefaultWaveform returns a simple waveform when ffmpeg is unavailable.
+// generateDefaultWaveform returns a simple waveform when the audio cannot be read.
 // Values are 0-100 as expected by WhatsApp.
 func generateDefaultWaveform() []byte {
 	waveform := make([]byte, 64)
@@ -610,126 +436,50 @@ func (service serviceSend) SendVideo(ctx context.Context, request domainSend.Vid
 		return response, err
 	}
 
-	var (
-		videoPath      string
-		videoThumbnail string
-		deletedItems   []string
-	)
-
-	// Ensure temporary files are always removed, even on early returns
-	defer func() {
-		if len(deletedItems) > 0 {
-			// Run cleanup in background with slight delay to avoid race with open handles
-			go utils.RemoveFile(1, deletedItems...)
-		}
-	}()
-
-	generateUUID := fiberUtils.UUIDv4()
-
-	var oriVideoPath string
+	var dataWaVideo []byte
 
 	// Determine source of video (URL or uploaded file)
 	if request.VideoURL != nil && *request.VideoURL != "" {
-		// Download video bytes
-		videoBytes, fileName, errDownload := utils.DownloadVideoFromURL(*request.VideoURL)
+		videoBytes, _, errDownload := utils.DownloadVideoFromURL(*request.VideoURL)
 		if errDownload != nil {
 			return response, pkgError.InternalServerError(fmt.Sprintf("failed to download video from URL %v", errDownload))
 		}
-		// Build file path to save the downloaded video temporarily
-		oriVideoPath = fmt.Sprintf("%s/%s", config.PathSendItems, generateUUID+fileName)
-		if errWrite := os.WriteFile(oriVideoPath, videoBytes, 0644); errWrite != nil {
-			return response, pkgError.InternalServerError(fmt.Sprintf("failed to store downloaded video in server %v", errWrite))
-		}
+		dataWaVideo = videoBytes
 	} else if request.Video != nil {
-		// Save uploaded video to server
-		oriVideoPath = fmt.Sprintf("%s/%s", config.PathSendItems, generateUUID+request.Video.Filename)
-		err = fasthttp.SaveMultipartFile(request.Video, oriVideoPath)
-		if err != nil {
-			return response, pkgError.InternalServerError(fmt.Sprintf("failed to store video in server %v", err))
-		}
+		dataWaVideo = helpers.MultipartFormFileHeaderToBytes(request.Video)
 	} else {
 		// This should not happen due to validation, but guard anyway
 		return response, pkgError.ValidationError("either Video or VideoURL must be provided")
 	}
 
-	// Check if ffmpeg is installed
-	_, err = exec.LookPath("ffmpeg")
-	if err != nil {
-		return response, pkgError.InternalServerError("ffmpeg not installed")
-	}
-
-	// Generate thumbnail using ffmpeg
-	thumbnailVideoPath := fmt.Sprintf("%s/%s", config.PathSendItems, generateUUID+".png")
-	cmdThumbnail := exec.Command("ffmpeg", "-i", oriVideoPath, "-ss", "00:00:01.000", "-vframes", "1", thumbnailVideoPath)
-	err = cmdThumbnail.Run()
-	if err != nil {
-		return response, pkgError.InternalServerError(fmt.Sprintf("failed to create thumbnail %v", err))
-	}
-
-	// Resize Thumbnail
-	srcImage, err := imaging.Open(thumbnailVideoPath)
+	// Video frames need ffmpeg; without it the video is sent without a thumbnail
+	dataWaThumbnail, err := service.mediaProcessor.Thumbnail(ctx, dataWaVideo, 100)
 	if err != nil {
-		return response, pkgError.InternalServerError(fmt.Sprintf("Failed to open generated video thumbnail image '%s': %v. Possible causes: file not found, unsupported format, or permission denied.", thumbnailVideoPath, err))
-	}
-	resizedImage := imaging.Resize(srcImage, 100, 0, imaging.Lanczos)
-	thumbnailResizeVideoPath := fmt.Sprintf("%s/thumbnails-%s", config.PathSendItems, generateUUID+".png")
-	if err = imaging.Save(resizedImage, thumbnailResizeVideoPath); err != nil {
-		return response, pkgError.InternalServerError(fmt.Sprintf("failed to save thumbnail %v", err))
+		logrus.Warnf("Sending video without a thumbnail: %v", err)
 	}
 
-	deletedItems = append(deletedItems, thumbnailVideoPath)
-	deletedItems = append(deletedItems, thumbnailResizeVideoPath)
-	videoThumbnail = thumbnailResizeVideoPath
-
 	// Compress if requested
 	if request.Compress {
-		compresVideoPath := fmt.Sprintf("%s/%s", config.PathSendItems, generateUUID+".mp4")
-
-		// Use proper compression settings to reduce file size
-		// -crf 28: Constant Rate Factor (18-28 is good range, higher = smaller file)
-		// -preset medium: Balance between encoding speed and compression efficiency
-		// -c:v libx264: Use H.264 codec for video
-		// -c:a aac: Use AAC codec for audio
-		// -movflags +faststart: Optimize for web streaming
-		// -vf scale=720:-2: Scale video to max width 720px, maintain aspect ratio
-		cmdCompress := exec.Command("ffmpeg", "-i", oriVideoPath,
-			"-c:v", "libx264",
-			"-crf", "28",
-			"-preset", "fast",
-			"-vf", "scale=720:-2",
-			"-c:a", "aac",
-			"-b:a", "128k",
-			"-movflags", "+faststart",
-			"-y", // Overwrite output file if it exists
-			compresVideoPath)
-
-		// Capture both stdout and stderr for better error reporting
-		output, err := cmdCompress.CombinedOutput()
+		compressed, err := service.mediaProcessor.CompressVideo(ctx, dataWaVideo)
+		if errors.Is(err, domainMediaProc.ErrUnsupported) {
+			return response, pkgError.InternalServerError("video compression requires ffmpeg, which is not installed")
+		}
 		if err != nil {
-			logrus.Errorf("ffmpeg compression failed: %v, output: %s", err, string(output))
-			return response, pkgError.InternalServerError(fmt.Sprintf("failed to compress video: %v", err))
+			return response, pkgError.InternalServerError(err.Error())
 		}
-
-		videoPath = compresVideoPath
-		deletedItems = append(deletedItems, compresVideoPath)
-	} else {
-		videoPath = oriVideoPath
+		dataWaVideo = compressed
 	}
-	deletedItems = append(deletedItems, oriVideoPath)
 
-	//Send to WA server
-	dataWaVideo, err := os.ReadFile(videoPath)
+	videoInfo, err := service.mediaProcessor.Probe(ctx, dataWaVideo)
 	if err != nil {
-		return response, err
+		logrus.Warnf("Failed to read video duration: %v", err)
 	}
+
+	//Send to WA server
 	uploaded, err := service.uploadMedia(ctx, client, whatsmeow.MediaVideo, dataWaVideo, dataWaRecipient)
 	if err != nil {
 		return response, pkgError.InternalServerError(fmt.Sprintf("Failed to upload file: %v", err))
 	}
-	dataWaThumbnail, err := os.ReadFile(videoThumbnail)
-	if err != nil {
-		return response, err
-	}
 
 	msg := &waE2E.Message{VideoMessage: &waE2E.VideoMessage{
 		URL:                 proto.String(uploaded.URL),
@@ -741,19 +491,20 @@ func (service serviceSend) SendVideo(ctx context.Context, request domainSend.Vid
 		MediaKey:            uploaded.MediaKey,
 		DirectPath:          proto.String(uploaded.DirectPath),
 		ViewOnce:            proto.Bool(request.ViewOnce),
+		Seconds:             proto.Uint32(mediaSeconds(videoInfo)),
 		JPEGThumbnail:       dataWaThumbnail,
 		ThumbnailEncSHA256:  dataWaThumbnail,
 		ThumbnailSHA256:     dataWaThumbnail,
 		ThumbnailDirectPath: proto.String(uploaded.DirectPath),
 	}}
 
-	if request.BaseRequest.IsForwarded {
-		msg.VideoMessage.ContextInfo = &waE2E.ContextInfo{
-			IsForwarded:     proto.Bool(true),
-			ForwardingScore: proto.Uint32(100),
-		}
+	if videoInfo != nil && videoInfo.Width > 0 {
+		msg.VideoMessage.Width = proto.Uint32(uint32(videoInfo.Width))
+		msg.VideoMessage.Height = proto.Uint32(uint32(videoInfo.Height))
 	}
 
+	msg.VideoMessage.ContextInfo = service.messageContextInfo(ctx, request.BaseRequest, dataWaRecipient, request.Caption)
+
 	if request.BaseRequest.Duration != nil && *request.BaseRequest.Duration > 0 {
 		if msg.VideoMessage.ContextInfo == nil {
 			msg.VideoMessage.ContextInfo = &waE2E.ContextInfo{}
@@ -798,12 +549,7 @@ func (service serviceSend) SendContact(ctx context.Context, request domainSend.C
 		Vcard:       proto.String(msgVCard),
 	}}
 
-	if request.BaseRequest.IsForwarded {
-		msg.ContactMessage.ContextInfo = &waE2E.ContextInfo{
-			IsForwarded:     proto.Bool(true),
-			ForwardingScore: proto.Uint32(100),
-		}
-	}
+	msg.ContactMessage.ContextInfo = service.messageContextInfo(ctx, request.BaseRequest, dataWaRecipient, "")
 
 	if request.BaseRequest.Duration != nil && *request.BaseRequest.Duration > 0 {
 		if msg.ContactMessage.ContextInfo == nil {
@@ -861,12 +607,7 @@ func (service serviceSend) SendLink(ctx context.Context, request domainSend.Link
 		JPEGThumbnail: metadata.ImageThumb,
 	}}
 
-	if request.BaseRequest.IsForwarded {
-		msg.ExtendedTextMessage.ContextInfo = &waE2E.ContextInfo{
-			IsForwarded:     proto.Bool(true),
-			ForwardingScore: proto.Uint32(100),
-		}
-	}
+	msg.ExtendedTextMessage.ContextInfo = service.messageContextInfo(ctx, request.BaseRequest, dataWaRecipient, request.Caption)
 
 	if request.BaseRequest.Duration != nil && *request.BaseRequest.Duration > 0 {
 		if msg.ExtendedTextMessage.ContextInfo == nil {
@@ -929,12 +670,7 @@ func (service serviceSend) SendLocation(ctx context.Context, request domainSend.
 		},
 	}
 
-	if request.BaseRequest.IsForwarded {
-		msg.LocationMessage.ContextInfo = &waE2E.ContextInfo{
-			IsForwarded:     proto.Bool(true),
-			ForwardingScore: proto.Uint32(100),
-		}
-	}
+	msg.LocationMessage.ContextInfo = service.messageContextInfo(ctx, request.BaseRequest, dataWaRecipient, "")
 
 	if request.BaseRequest.Duration != nil && *request.BaseRequest.Duration > 0 {
 		if msg.LocationMessage.ContextInfo == nil {
@@ -974,12 +710,9 @@ func (service serviceSend) SendAudio(ctx context.Context, request domainSend.Aud
 	}
 
 	var (
-		audioBytes      []byte
-		audioMimeType   string
-		audioFilename   string
-		audioDuration   uint32
-		tempAudioPath   string
-		deleteTempFile  bool
+		audioBytes    []byte
+		audioMimeType string
+		audioFilename string
 	)
 
 	// Handle audio from URL or file
@@ -988,41 +721,29 @@ func (service serviceSend) SendAudio(ctx context.Context, request domainSend.Aud
 		if err != nil {
 			return response, pkgError.InternalServerError(fmt.Sprintf("failed to download audio from URL %v", err))
 		}
-		audioMimeType = resolveAudioMIME(audioFilename, audioBytes)
-
-		// Save to temp file to get duration
-		tempAudioPath = fmt.Sprintf("%s/temp_audio_%s", config.PathSendItems, fiberUtils.UUIDv4()+filepath.Ext(audioFilename))
-		if err = os.WriteFile(tempAudioPath, audioBytes, 0644); err == nil {
-			deleteTempFile = true
-			audioDuration = getAudioDuration(tempAudioPath)
-		}
 	} else if request.Audio != nil {
 		audioBytes = helpers.MultipartFormFileHeaderToBytes(request.Audio)
-		audioMimeType = resolveAudioMIME(request.Audio.Filename, audioBytes)
-
-		// Save to temp file to get duration
-		tempAudioPath = fmt.Sprintf("%s/temp_audio_%s", config.PathSendItems, fiberUtils.UUIDv4()+filepath.Ext(request.Audio.Filename))
-		if err = os.WriteFile(tempAudioPath, audioBytes, 0644); err == nil {
-			deleteTempFile = true
-			audioDuration = getAudioDuration(tempAudioPath)
-		}
-	}
-
-	// Clean up temp file
-	if deleteTempFile {
-		defer os.Remove(tempAudioPath)
+		audioFilename = request.Audio.Filename
 	}
+	audioMimeType = resolveAudioMIME(audioFilename, audioBytes)
 
 	// For PTT (voice notes), WhatsApp requires "audio/ogg; codecs=opus"
-	// Check if it's an OGG file and add codec info for PTT
-	if request.PTT && strings.HasPrefix(audioMimeType, "audio/ogg") {
-		audioMimeType = "audio/ogg; codecs=opus"
+	var waveformData []byte
+	if request.PTT {
+		if opus, err := service.mediaProcessor.TranscodeOpus(ctx, audioBytes); err == nil {
+			audioBytes, audioMimeType = opus, "audio/ogg; codecs=opus"
+		} else {
+			logrus.Warnf("Sending voice note as %s, it could not be converted to Opus: %v", audioMimeType, err)
+			if strings.HasPrefix(audioMimeType, "audio/ogg") {
+				audioMimeType = "audio/ogg; codecs=opus"
+			}
+		}
+		waveformData = service.voiceWaveform(ctx, audioBytes)
 	}
 
-	// Generate waveform for PTT voice notes
-	var waveformData []byte
-	if request.PTT && tempAudioPath != "" {
-		waveformData = generateWaveform(tempAudioPath)
+	audioInfo, err := service.mediaProcessor.Probe(ctx, audioBytes)
+	if err != nil {
+		logrus.Warnf("Failed to get audio duration: %v", err)
 	}
 
 	// upload to WhatsApp servers
@@ -1042,17 +763,12 @@ func (service serviceSend) SendAudio(ctx context.Context, request domainSend.Aud
 			FileEncSHA256: audioUploaded.FileEncSHA256,
 			MediaKey:      audioUploaded.MediaKey,
 			PTT:           proto.Bool(request.PTT),
-			Seconds:       proto.Uint32(audioDuration),
+			Seconds:       proto.Uint32(mediaSeconds(audioInfo)),
 			Waveform:      waveformData,
 		},
 	}
 
-	if request.BaseRequest.IsForwarded {
-		msg.AudioMessage.ContextInfo = &waE2E.ContextInfo{
-			IsForwarded:     proto.Bool(true),
-			ForwardingScore: proto.Uint32(100),
-		}
-	}
+	msg.AudioMessage.ContextInfo = service.messageContextInfo(ctx, request.BaseRequest, dataWaRecipient, "")
 
 	if request.BaseRequest.Duration != nil && *request.BaseRequest.Duration > 0 {
 		if msg.AudioMessage.ContextInfo == nil {
@@ -1092,6 +808,7 @@ func (service serviceSend) SendPoll(ctx context.Context, request domainSend.Poll
 	content := "📊 " + request.Question
 
 	msg := client.BuildPollCreation(request.Question, request.Options, request.MaxAnswer)
+	msg.PollCreationMessage.ContextInfo = service.messageContextInfo(ctx, request.BaseRequest, dataWaRecipient, "")
 
 	if request.BaseRequest.Duration != nil && *request.BaseRequest.Duration > 0 {
 		if msg.PollCreationMessage.ContextInfo == nil {
@@ -1237,70 +954,22 @@ func (service serviceSend) SendSticker(ctx context.Context, request domainSend.S
 		return response, err
 	}
 
-	var (
-		stickerPath  string
-		deletedItems []string
-		stickerBytes []byte
-	)
-
-	// Resolve absolute base directory for send items
-	absBaseDir, err := filepath.Abs(config.PathSendItems)
-	if err != nil {
-		return response, pkgError.InternalServerError(fmt.Sprintf("failed to resolve base directory: %v", err))
-	}
-
-	defer func() {
-		// Delete temporary files
-		for _, path := range deletedItems {
-			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
-				logrus.Warnf("Failed to cleanup temporary file %s: %v", path, err)
-			}
-		}
-	}()
+	var stickerBytes []byte
 
 	// Handle sticker from URL or file
 	if request.StickerURL != nil && *request.StickerURL != "" {
-		// Download sticker from URL
-		imageData, _, err := utils.DownloadImageFromURL(*request.StickerURL)
+		stickerBytes, _, err = utils.DownloadImageFromURL(*request.StickerURL)
 		if err != nil {
 			return response, pkgError.InternalServerError(fmt.Sprintf("failed to download sticker from URL: %v", err))
 		}
-
-		// Create safe temporary file within base dir
-		f, err := os.CreateTemp(absBaseDir, "sticker_*")
-		if err != nil {
-			return response, pkgError.InternalServerError(fmt.Sprintf("failed to create temp file: %v", err))
-		}
-		stickerPath = f.Name()
-		if _, err := f.Write(imageData); err != nil {
-			f.Close()
-			return response, pkgError.InternalServerError(fmt.Sprintf("failed to write sticker: %v", err))
-		}
-		_ = f.Close()
-		deletedItems = append(deletedItems, stickerPath)
 	} else if request.Sticker != nil {
-		// Create safe temporary file within base dir
-		f, err := os.CreateTemp(absBaseDir, "sticker_*")
-		if err != nil {
-			return response, pkgError.InternalServerError(fmt.Sprintf("failed to create temp file: %v", err))
-		}
-		stickerPath = f.Name()
-		_ = f.Close()
-
-		// Save uploaded file to safe path
-		err = fasthttp.SaveMultipartFile(request.Sticker, stickerPath)
-		if err != nil {
-			return response, pkgError.InternalServerError(fmt.Sprintf("failed to save sticker: %v", err))
-		}
-		deletedItems = append(deletedItems, stickerPath)
+		stickerBytes = helpers.MultipartFormFileHeaderToBytes(request.Sticker)
 	}
 
 	// Check if input is animated WebP - if so, handle it specially
-	infoCtx, infoCancel := context.WithTimeout(ctx, 5*time.Second)
-	defer infoCancel()
-	isAnimatedSticker, webpWidth, webpHeight := getWebPInfo(infoCtx, stickerPath)
-	if isAnimatedSticker {
+	if info, err := service.mediaProcessor.Probe(ctx, stickerBytes); err == nil && info.Animated {
 		logrus.Info("Detected animated WebP sticker")
+		webpWidth, webpHeight := info.Width, info.Height
 
 		// Validate dimensions - must be exactly 512x512 for animated stickers
 		if webpWidth != 512 || webpHeight != 512 {
@@ -1309,19 +978,9 @@ func (service serviceSend) SendSticker(ctx context.Context, request domainSend.S
 		}
 
 		// Validate file size - must be under 500KB
-		fileInfo, statErr := os.Stat(stickerPath)
-		if statErr != nil {
-			return response, pkgError.InternalServerError(fmt.Sprintf("failed to stat sticker file: %v", statErr))
-		}
-		if fileInfo.Size() > 500*1024 {
+		if len(stickerBytes) > 500*1024 {
 			return response, pkgError.ValidationError(
-				fmt.Sprintf("animated WebP stickers must be under 500KB (got %d KB). Please reduce the file size.", fileInfo.Size()/1024))
-		}
-
-		// Use the animated WebP file directly
-		stickerBytes, err = os.ReadFile(stickerPath)
-		if err != nil {
-			return response, pkgError.InternalServerError(fmt.Sprintf("failed to read animated sticker: %v", err))
+				fmt.Sprintf("animated WebP stickers must be under 500KB (got %d KB). Please reduce the file size.", len(stickerBytes)/1024))
 		}
 
 		logrus.Infof("Using animated WebP sticker directly: %dx%d, %d bytes", webpWidth, webpHeight, len(stickerBytes))
@@ -1348,12 +1007,7 @@ func (service serviceSend) SendSticker(ctx context.Context, request domainSend.S
 			},
 		}
 
-		if request.BaseRequest.IsForwarded {
-			msg.StickerMessage.ContextInfo = &waE2E.ContextInfo{
-				IsForwarded:     proto.Bool(true),
-				ForwardingScore: proto.Uint32(100),
-			}
-		}
+		msg.StickerMessage.ContextInfo = service.messageContextInfo(ctx, request.BaseRequest, dataWaRecipient, "")
 
 		if request.BaseRequest.Duration != nil && *request.BaseRequest.Duration > 0 {
 			if msg.StickerMessage.ContextInfo == nil {
@@ -1375,120 +1029,12 @@ func (service serviceSend) SendSticker(ctx context.Context, request domainSend.S
 		return response, nil
 	}
 
-	// Convert image to WebP format for sticker (512x512 max size)
-	srcImage, err := imaging.Open(stickerPath)
-	if err != nil {
-		// Fallback for animated WebP (imaging.Open doesn't support animated WebP)
-		logrus.Warnf("imaging.Open failed for %s: %v. Trying animated WebP fallback...", stickerPath, err)
-
-		fallbackPngPath := filepath.Join(absBaseDir, fmt.Sprintf("fallback_%s.png", fiberUtils.UUIDv4()))
-		deletedItems = append(deletedItems, fallbackPngPath)
-
-		convertCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-		defer cancel()
-
-		// Check if context was already cancelled before starting conversion
-		if convertCtx.Err() != nil {
-			return response, pkgError.InternalServerError("request cancelled during sticker processing")
-		}
-
-		conversionSuccess := false
-
-		// Try webpmux + dwebp for animated WebP (extract first frame)
-		if _, lookErr := exec.LookPath("webpmux"); lookErr == nil {
-			if _, lookErr := exec.LookPath("dwebp"); lookErr == nil {
-				logrus.Info("Trying webpmux to extract first frame from animated WebP...")
-				extractedFramePath := filepath.Join(absBaseDir, fmt.Sprintf("frame_%s.webp", fiberUtils.UUIDv4()))
-				deletedItems = append(deletedItems, extractedFramePath)
-
-				cmdWebpmux := exec.CommandContext(convertCtx, "webpmux", "-get", "frame", "1", stickerPath, "-o", extractedFramePath)
-				var stderrWebpmux bytes.Buffer
-				cmdWebpmux.Stderr = &stderrWebpmux
-				if errWebpmux := cmdWebpmux.Run(); errWebpmux == nil {
-					// Now decode the extracted frame with dwebp
-					cmdDwebp := exec.CommandContext(convertCtx, "dwebp", extractedFramePath, "-o", fallbackPngPath)
-					var stderrDwebp bytes.Buffer
-					cmdDwebp.Stderr = &stderrDwebp
-					if errDwebp := cmdDwebp.Run(); errDwebp == nil {
-						conversionSuccess = true
-						logrus.Info("webpmux + dwebp conversion successful for animated WebP")
-					} else {
-						logrus.Errorf("dwebp failed on extracted frame: %v, stderr: %s", errDwebp, stderrDwebp.String())
-					}
-				} else {
-					logrus.Errorf("webpmux frame extraction failed: %v, stderr: %s", errWebpmux, stderrWebpmux.String())
-				}
-			}
-		}
-
-		if !conversionSuccess {
-			return response, pkgError.InternalServerError(fmt.Sprintf("failed to open image for sticker conversion: %v (animated WebP requires webpmux and dwebp tools)", err))
-		}
-
-		srcImage, err = imaging.Open(fallbackPngPath)
-		if err != nil {
-			return response, pkgError.InternalServerError(fmt.Sprintf("failed to open fallback PNG image: %v", err))
-		}
-		logrus.Info("Fallback conversion successful")
-	}
-
-	// Resize image to max 512x512 maintaining aspect ratio
-	bounds := srcImage.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
-
-	if width > 512 || height > 512 {
-		if width > height {
-			srcImage = imaging.Resize(srcImage, 512, 0, imaging.Lanczos)
-		} else {
-			srcImage = imaging.Resize(srcImage, 0, 512, imaging.Lanczos)
-		}
-	}
-
-	// Convert to WebP using external command (ffmpeg or cwebp)
-	webpPath := filepath.Join(absBaseDir, fmt.Sprintf("sticker_%s.webp", fiberUtils.UUIDv4()))
-	deletedItems = append(deletedItems, webpPath)
-
-	// First save as PNG temporarily
-	pngPath := filepath.Join(absBaseDir, fmt.Sprintf("temp_%s.png", fiberUtils.UUIDv4()))
-	deletedItems = append(deletedItems, pngPath)
-
-	err = imaging.Save(srcImage, pngPath)
-	if err != nil {
-		return response, pkgError.InternalServerError(fmt.Sprintf("failed to save temporary PNG: %v", err))
-	}
-
-	// Try to use ffmpeg first (most common), then cwebp
-	var convertCmd *exec.Cmd
-
-	// Add execution timeout for conversion
-	convCtx, cancel := context.WithTimeout(ctx, 45*time.Second)
-	defer cancel()
-
-	// Check if ffmpeg is available
-	if _, err := exec.LookPath("ffmpeg"); err == nil {
-		// Use ffmpeg to convert to WebP with transparency support, overwrite if exists
-		convertCmd = exec.CommandContext(convCtx, "ffmpeg", "-y", "-i", pngPath, "-vcodec", "libwebp", "-lossless", "0", "-compression_level", "6", "-q:v", "60", "-preset", "default", "-loop", "0", "-an", "-vsync", "0", webpPath)
-	} else if _, err := exec.LookPath("cwebp"); err == nil {
-		// Use cwebp as fallback
-		convertCmd = exec.CommandContext(convCtx, "cwebp", "-q", "60", "-o", webpPath, pngPath)
-	} else {
-		// If neither tool is available, return error
-		return response, pkgError.InternalServerError("neither ffmpeg nor cwebp is installed for WebP conversion")
-	}
-
-	var stderr bytes.Buffer
-	convertCmd.Stderr = &stderr
-
-	if err := convertCmd.Run(); err != nil {
-		return response, pkgError.InternalServerError(fmt.Sprintf("failed to convert sticker to WebP: %v, stderr: %s", err, stderr.String()))
-	}
-
-	// Read the WebP file
-	stickerBytes, err = os.ReadFile(webpPath)
+	// Convert image to WebP format for sticker (512x512 max size, static stickers are limited to 100KB)
+	sticker, err := service.mediaProcessor.WebP(ctx, stickerBytes, domainMediaProc.WebPOptions{MaxDimension: 512, MaxBytes: 100 * 1024})
 	if err != nil {
-		return response, pkgError.InternalServerError(fmt.Sprintf("failed to read WebP sticker: %v", err))
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to convert sticker to WebP: %v", err))
 	}
+	stickerBytes = sticker.Data
 
 	// Upload sticker to WhatsApp servers
 	stickerUploaded, err := service.uploadMedia(ctx, client, whatsmeow.MediaImage, stickerBytes, dataWaRecipient)
@@ -1506,18 +1052,13 @@ func (service serviceSend) SendSticker(ctx context.Context, request domainSend.S
 			FileSHA256:    stickerUploaded.FileSHA256,
 			FileEncSHA256: stickerUploaded.FileEncSHA256,
 			MediaKey:      stickerUploaded.MediaKey,
-			Width:         proto.Uint32(uint32(srcImage.Bounds().Dx())),
-			Height:        proto.Uint32(uint32(srcImage.Bounds().Dy())),
+			Width:         proto.Uint32(uint32(sticker.Width)),
+			Height:        proto.Uint32(uint32(sticker.Height)),
 			IsAnimated:    proto.Bool(false),
 		},
 	}
 
-	if request.BaseRequest.IsForwarded {
-		msg.StickerMessage.ContextInfo = &waE2E.ContextInfo{
-			IsForwarded:     proto.Bool(true),
-			ForwardingScore: proto.Uint32(100),
-		}
-	}
+	msg.StickerMessage.ContextInfo = service.messageContextInfo(ctx, request.BaseRequest, dataWaRecipient, "")
 
 	if request.BaseRequest.Duration != nil && *request.BaseRequest.Duration > 0 {
 		if msg.StickerMessage.ContextInfo == nil {
@@ -1548,42 +1089,6 @@ func (service serviceSend) uploadMedia(ctx context.Context, client *whatsmeow.Cl
 	return uploaded, err
 }
 
-// getWebPInfo returns whether the file is animated WebP and its dimensions.
-// It validates the file exists and is a regular file before executing webpmux.
-func getWebPInfo(ctx context.Context, filePath string) (isAnimated bool, width int, height int) {
-	// Validate file exists and is a regular file (prevents command injection)
-	fileInfo, err := os.Stat(filePath)
-	if err != nil || !fileInfo.Mode().IsRegular() {
-		return false, 0, 0
-	}
-
-	// Clean path to prevent path traversal
-	cleanPath := filepath.Clean(filePath)
-
-	cmd := exec.CommandContext(ctx, "webpmux", "-info", cleanPath)
-	output, err := cmd.Output()
-	if err != nil {
-		return false, 0, 0
-	}
-
-	outputStr := string(output)
-	isAnimated = strings.Contains(strings.ToLower(outputStr), "animation")
-
-	// Parse "Canvas size: 512 x 512"
-	matches := webpCanvasSizeRegex.FindStringSubmatch(outputStr)
-	if len(matches) == 3 {
-		var errW, errH error
-		width, errW = strconv.Atoi(matches[1])
-		height, errH = strconv.Atoi(matches[2])
-		if errW != nil || errH != nil {
-			logrus.Warnf("Failed to parse WebP dimensions from '%s': width=%v, height=%v", outputStr, errW, errH)
-			return isAnimated, 0, 0
-		}
-	}
-
-	return isAnimated, width, height
-}
-
 func (service serviceSend) getDefaultEphemeralExpiration(jid string) (expiration uint32) {
 	expiration = 0
 	if jid == "" {
@@ -0,0 +1,237 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	domainSend "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/send"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+	"github.com/disintegration/imaging"
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// albumUploadConcurrency is how many album items are prepared and uploaded at once
+const albumUploadConcurrency = 4
+
+// albumMedia is an album item ready to send
+type albumMedia struct {
+	item      domainSend.AlbumItem
+	mimetype  string
+	thumbnail []byte
+	uploaded  whatsmeow.UploadResponse
+}
+
+// SendAlbum uploads the items concurrently, then sends an album message followed
+// by one message per item linked to it, which WhatsApp renders as a grouped album.
+// When an item fails to send, the response still lists the messages already sent.
+func (service serviceSend) SendAlbum(ctx context.Context, request domainSend.AlbumRequest) (response domainSend.AlbumResponse, err error) {
+	if err = validations.ValidateSendAlbum(ctx, request); err != nil {
+		return response, err
+	}
+
+	client := whatsapp.ClientFromContext(ctx)
+	if client == nil {
+		return response, pkgError.ErrWaCLI
+	}
+
+	dataWaRecipient, err := utils.ValidateJidWithLogin(client, request.BaseRequest.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	media := make([]albumMedia, len(request.Items))
+	errs := make([]error, len(request.Items))
+	slots := make(chan struct{}, albumUploadConcurrency)
+	var wg sync.WaitGroup
+	for i, item := range request.Items {
+		wg.Add(1)
+		go func(i int, item domainSend.AlbumItem) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			media[i], errs[i] = service.prepareAlbumItem(ctx, client, dataWaRecipient, item, request.Compress)
+		}(i, item)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		var validationErr pkgError.ValidationError
+		if errors.As(err, &validationErr) {
+			return response, pkgError.ValidationError(fmt.Sprintf("items %d: %v", i, err))
+		}
+		return response, pkgError.InternalServerError(fmt.Sprintf("items %d: %v", i, err))
+	}
+
+	var imageCount, videoCount uint32
+	for _, m := range media {
+		if m.item.Type == domainSend.AlbumItemVideo {
+			videoCount++
+		} else {
+			imageCount++
+		}
+	}
+
+	// The reply context belongs to the album, mentions to the captions
+	album := &waE2E.Message{AlbumMessage: &waE2E.AlbumMessage{
+		ExpectedImageCount: proto.Uint32(imageCount),
+		ExpectedVideoCount: proto.Uint32(videoCount),
+		ContextInfo: withExpiration(service.messageContextInfo(ctx, domainSend.BaseRequest{
+			IsForwarded:    request.IsForwarded,
+			ReplyMessageID: request.ReplyMessageID,
+		}, dataWaRecipient, ""), request.Duration),
+	}}
+	// The album message only groups the items, so unlike them it is not stored as a chat message
+	parent, err := client.SendMessage(ctx, dataWaRecipient, album)
+	if err != nil {
+		return response, err
+	}
+	response.MessageID = parent.ID
+
+	association := &waE2E.MessageContextInfo{
+		MessageAssociation: &waE2E.MessageAssociation{
+			AssociationType: waE2E.MessageAssociation_MEDIA_ALBUM.Enum(),
+			ParentMessageKey: &waCommon.MessageKey{
+				RemoteJID: proto.String(dataWaRecipient.String()),
+				FromMe:    proto.Bool(true),
+				ID:        proto.String(parent.ID),
+			},
+		},
+	}
+	for i, m := range media {
+		// Explicit mentions are attached once, to the first item
+		base := domainSend.BaseRequest{IsForwarded: request.IsForwarded}
+		if i == 0 {
+			base.Mentions = request.Mentions
+		}
+		ctxInfo := withExpiration(service.messageContextInfo(ctx, base, dataWaRecipient, m.item.Caption), request.Duration)
+
+		msg := &waE2E.Message{MessageContextInfo: proto.Clone(association).(*waE2E.MessageContextInfo)}
+		content := "🖼️ Image"
+		if m.item.Type == domainSend.AlbumItemVideo {
+			content = "🎥 Video"
+			msg.VideoMessage = &waE2E.VideoMessage{
+				URL:           proto.String(m.uploaded.URL),
+				DirectPath:    proto.String(m.uploaded.DirectPath),
+				Mimetype:      proto.String(m.mimetype),
+				Caption:       proto.String(m.item.Caption),
+				FileLength:    proto.Uint64(m.uploaded.FileLength),
+				FileSHA256:    m.uploaded.FileSHA256,
+				FileEncSHA256: m.uploaded.FileEncSHA256,
+				MediaKey:      m.uploaded.MediaKey,
+				JPEGThumbnail: m.thumbnail,
+				ContextInfo:   ctxInfo,
+			}
+		} else {
+			msg.ImageMessage = &waE2E.ImageMessage{
+				URL:           proto.String(m.uploaded.URL),
+				DirectPath:    proto.String(m.uploaded.DirectPath),
+				Mimetype:      proto.String(m.mimetype),
+				Caption:       proto.String(m.item.Caption),
+				FileLength:    proto.Uint64(m.uploaded.FileLength),
+				FileSHA256:    m.uploaded.FileSHA256,
+				FileEncSHA256: m.uploaded.FileEncSHA256,
+				MediaKey:      m.uploaded.MediaKey,
+				JPEGThumbnail: m.thumbnail,
+				ContextInfo:   ctxInfo,
+			}
+		}
+		if m.item.Caption != "" {
+			content += " " + m.item.Caption
+		}
+
+		ts, err := service.wrapSendMessage(ctx, client, dataWaRecipient, msg, content)
+		if err != nil {
+			response.Status = fmt.Sprintf("Album to %s partially sent, %d of %d items were sent", request.BaseRequest.Phone, i, len(media))
+			return response, pkgError.InternalServerError(fmt.Sprintf("failed to send album item %d, %d of %d were sent: %v", i, i, len(media), err))
+		}
+		response.MessageIDs = append(response.MessageIDs, ts.ID)
+	}
+
+	response.Status = fmt.Sprintf("Album of %d items sent to %s (server timestamp: %s)", len(media), request.BaseRequest.Phone, parent.Timestamp.String())
+	return response, nil
+}
+
+// prepareAlbumItem loads an album item, builds its thumbnail and uploads it
+func (service serviceSend) prepareAlbumItem(ctx context.Context, client *whatsmeow.Client, recipient types.JID, item domainSend.AlbumItem, compress bool) (media albumMedia, err error) {
+	media.item = item
+
+	var data []byte
+	switch {
+	case item.File != nil:
+		data, err = readAlbumUpload(item)
+	case item.Type == domainSend.AlbumItemImage:
+		data, _, err = utils.DownloadImageFromURL(*item.URL)
+	default:
+		data, _, err = utils.DownloadVideoFromURL(*item.URL)
+	}
+	if err != nil {
+		return media, err
+	}
+
+	mediaType := whatsmeow.MediaImage
+	if item.Type == domainSend.AlbumItemVideo {
+		mediaType = whatsmeow.MediaVideo
+		// Video frames need ffmpeg; without it the video is sent without a thumbnail
+		if media.thumbnail, err = service.mediaProcessor.Thumbnail(ctx, data, 100); err != nil {
+			logrus.Warnf("Sending album video without a thumbnail: %v", err)
+			err = nil
+		}
+	} else {
+		data, media.thumbnail, err = albumImage(data, compress)
+	}
+	if err != nil {
+		return media, err
+	}
+
+	media.mimetype = http.DetectContentType(data)
+	media.uploaded, err = service.uploadMedia(ctx, client, mediaType, data, recipient)
+	return media, err
+}
+
+func readAlbumUpload(item domainSend.AlbumItem) ([]byte, error) {
+	file, err := item.File.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// albumImage returns the image to upload, shrunk like SendImage does when
+// compress is set, and its thumbnail
+func albumImage(data []byte, compress bool) (image, thumbnail []byte, err error) {
+	srcImage, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, nil, pkgError.ValidationError(fmt.Sprintf("failed to decode image: %v", err))
+	}
+
+	var buffer bytes.Buffer
+	if err = imaging.Encode(&buffer, imaging.Resize(srcImage, 100, 0, imaging.Lanczos), imaging.JPEG); err != nil {
+		return nil, nil, err
+	}
+	thumbnail = buffer.Bytes()
+
+	if !compress {
+		return data, thumbnail, nil
+	}
+	var compressed bytes.Buffer
+	if err = imaging.Encode(&compressed, imaging.Resize(srcImage, 600, 0, imaging.Lanczos), imaging.JPEG); err != nil {
+		return nil, nil, err
+	}
+	return compressed.Bytes(), thumbnail, nil
+}
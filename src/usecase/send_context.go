@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"mime"
+	"path/filepath"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	domainMediaStore "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/mediastore"
+	domainSend "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/send"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// forwardingScore is set on every message sent as forwarded
+const forwardingScore = 100
+
+// messageContextInfo builds the context shared by every send type: the forwarded
+// flag, the quoted message of a reply and the mentions parsed from text plus the
+// ones listed in the request. It returns nil when there is nothing to set.
+func (service serviceSend) messageContextInfo(ctx context.Context, request domainSend.BaseRequest, recipient types.JID, text string) *waE2E.ContextInfo {
+	ctxInfo := &waE2E.ContextInfo{}
+
+	if request.IsForwarded {
+		ctxInfo.IsForwarded = proto.Bool(true)
+		ctxInfo.ForwardingScore = proto.Uint32(forwardingScore)
+	}
+
+	// Get mentions from text (parses @phone from message text or caption)
+	mentions := service.getMentionFromText(ctx, text)
+
+	// Add explicit mentions from request.Mentions (ghost mentions - no @ required in text)
+	if len(request.Mentions) > 0 {
+		mentions = append(mentions, service.getMentionsFromList(ctx, request.Mentions, recipient)...)
+		// Deduplicate to avoid mentioning the same person twice
+		mentions = utils.UniqueStrings(mentions)
+	}
+	if len(mentions) > 0 {
+		ctxInfo.MentionedJID = mentions
+	}
+
+	if request.ReplyMessageID != nil && *request.ReplyMessageID != "" {
+		message, err := service.chatStorageRepo.GetMessageByID(*request.ReplyMessageID)
+		if err != nil {
+			logrus.Warnf("Error retrieving reply message ID %s: %v, continuing without reply context", *request.ReplyMessageID, err)
+		} else if message == nil {
+			logrus.Warnf("Reply message ID %s not found in storage, continuing without reply context", *request.ReplyMessageID)
+		} else {
+			// Use the sender JID from storage as-is. Modern storage already provides
+			// fully-qualified JIDs (e.g., user@s.whatsapp.net or group@g.us).
+			ctxInfo.StanzaID = proto.String(message.ID)
+			ctxInfo.Participant = proto.String(message.Sender)
+			ctxInfo.QuotedMessage = quotedMessage(ctx, service.mediaStore, message)
+		}
+	}
+
+	if proto.Equal(ctxInfo, &waE2E.ContextInfo{}) {
+		return nil
+	}
+	return ctxInfo
+}
+
+// quotedMessage rebuilds a stored message the way WhatsApp shows it in a reply.
+// Media keeps its type, caption and file details; images in the media store
+// also get a thumbnail so the preview is not blank.
+func quotedMessage(ctx context.Context, store domainMediaStore.IMediaStore, message *domainChatStorage.Message) *waE2E.Message {
+	var mimetype *string
+	if byExtension := mime.TypeByExtension(filepath.Ext(message.Filename)); byExtension != "" {
+		mimetype = proto.String(byExtension)
+	}
+	var fileLength *uint64
+	if message.FileLength > 0 {
+		fileLength = proto.Uint64(message.FileLength)
+	}
+	var url *string
+	if message.URL != "" {
+		url = proto.String(message.URL)
+	}
+
+	switch message.MediaType {
+	case "image":
+		return &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+			Caption:       proto.String(message.Content),
+			URL:           url,
+			Mimetype:      mimetype,
+			MediaKey:      message.MediaKey,
+			FileSHA256:    message.FileSHA256,
+			FileEncSHA256: message.FileEncSHA256,
+			FileLength:    fileLength,
+			JPEGThumbnail: storedThumbnail(ctx, store, message.MediaPath),
+		}}
+	case "video":
+		return &waE2E.Message{VideoMessage: &waE2E.VideoMessage{
+			Caption:       proto.String(message.Content),
+			URL:           url,
+			Mimetype:      mimetype,
+			MediaKey:      message.MediaKey,
+			FileSHA256:    message.FileSHA256,
+			FileEncSHA256: message.FileEncSHA256,
+			FileLength:    fileLength,
+		}}
+	case "video_note":
+		return &waE2E.Message{PtvMessage: &waE2E.VideoMessage{
+			URL:           url,
+			Mimetype:      mimetype,
+			MediaKey:      message.MediaKey,
+			FileSHA256:    message.FileSHA256,
+			FileEncSHA256: message.FileEncSHA256,
+			FileLength:    fileLength,
+		}}
+	case "audio":
+		return &waE2E.Message{AudioMessage: &waE2E.AudioMessage{
+			URL:           url,
+			Mimetype:      mimetype,
+			MediaKey:      message.MediaKey,
+			FileSHA256:    message.FileSHA256,
+			FileEncSHA256: message.FileEncSHA256,
+			FileLength:    fileLength,
+		}}
+	case "document":
+		return &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
+			FileName:      proto.String(message.Filename),
+			Caption:       proto.String(message.Content),
+			URL:           url,
+			Mimetype:      mimetype,
+			MediaKey:      message.MediaKey,
+			FileSHA256:    message.FileSHA256,
+			FileEncSHA256: message.FileEncSHA256,
+			FileLength:    fileLength,
+		}}
+	case "sticker":
+		return &waE2E.Message{StickerMessage: &waE2E.StickerMessage{
+			URL:           url,
+			Mimetype:      proto.String("image/webp"),
+			MediaKey:      message.MediaKey,
+			FileSHA256:    message.FileSHA256,
+			FileEncSHA256: message.FileEncSHA256,
+			FileLength:    fileLength,
+		}}
+	}
+	return &waE2E.Message{Conversation: proto.String(message.Content)}
+}
+
+// withExpiration applies the disappearing message duration of a request
+func withExpiration(ctxInfo *waE2E.ContextInfo, duration *int) *waE2E.ContextInfo {
+	if duration == nil || *duration <= 0 {
+		return ctxInfo
+	}
+	if ctxInfo == nil {
+		ctxInfo = &waE2E.ContextInfo{}
+	}
+	ctxInfo.Expiration = proto.Uint32(uint32(*duration))
+	return ctxInfo
+}
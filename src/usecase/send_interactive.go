@@ -0,0 +1,329 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+
+	domainSend "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/send"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// SendList sends a list message, whose rows are picked from a menu the button opens
+func (service serviceSend) SendList(ctx context.Context, request domainSend.ListRequest) (response domainSend.GenericResponse, err error) {
+	if err = validations.ValidateSendList(ctx, request); err != nil {
+		return response, err
+	}
+
+	client := whatsapp.ClientFromContext(ctx)
+	if client == nil {
+		return response, pkgError.ErrWaCLI
+	}
+
+	dataWaRecipient, err := utils.ValidateJidWithLogin(client, request.BaseRequest.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	sections := make([]*waE2E.ListMessage_Section, len(request.Sections))
+	number := 0
+	for i, section := range request.Sections {
+		rows := make([]*waE2E.ListMessage_Row, len(section.Rows))
+		for j, row := range section.Rows {
+			number++
+			rows[j] = &waE2E.ListMessage_Row{
+				RowID:       proto.String(choiceID(row.ID, number)),
+				Title:       proto.String(row.Title),
+				Description: optionalString(row.Description),
+			}
+		}
+		sections[i] = &waE2E.ListMessage_Section{Title: optionalString(section.Title), Rows: rows}
+	}
+
+	msg := &waE2E.Message{ListMessage: &waE2E.ListMessage{
+		Title:       optionalString(request.Title),
+		Description: proto.String(request.Description),
+		ButtonText:  proto.String(request.ButtonText),
+		ListType:    waE2E.ListMessage_SINGLE_SELECT.Enum(),
+		Sections:    sections,
+		FooterText:  optionalString(request.Footer),
+		ContextInfo: withExpiration(service.messageContextInfo(ctx, request.BaseRequest, dataWaRecipient, request.Description), request.Duration),
+	}}
+
+	title := request.Title
+	if title == "" {
+		title = request.Description
+	}
+	ts, asText, err := service.sendInteractive(ctx, client, dataWaRecipient, request.BaseRequest, request.Fallback,
+		rendersInteractive(client), msg, "📝 "+title, listMenu(request))
+	if err != nil {
+		return response, err
+	}
+	return interactiveResponse("list", request.Phone, ts, asText), nil
+}
+
+// SendButtons sends a message with reply buttons under its text
+func (service serviceSend) SendButtons(ctx context.Context, request domainSend.ButtonsRequest) (response domainSend.GenericResponse, err error) {
+	if err = validations.ValidateSendButtons(ctx, request); err != nil {
+		return response, err
+	}
+
+	client := whatsapp.ClientFromContext(ctx)
+	if client == nil {
+		return response, pkgError.ErrWaCLI
+	}
+
+	dataWaRecipient, err := utils.ValidateJidWithLogin(client, request.BaseRequest.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	buttons := make([]*waE2E.ButtonsMessage_Button, len(request.Buttons))
+	for i, button := range request.Buttons {
+		buttons[i] = &waE2E.ButtonsMessage_Button{
+			ButtonID:   proto.String(choiceID(button.ID, i+1)),
+			ButtonText: &waE2E.ButtonsMessage_Button_ButtonText{DisplayText: proto.String(button.Text)},
+			Type:       waE2E.ButtonsMessage_Button_RESPONSE.Enum(),
+		}
+	}
+
+	buttonsMessage := &waE2E.ButtonsMessage{
+		ContentText: proto.String(request.Text),
+		FooterText:  optionalString(request.Footer),
+		Buttons:     buttons,
+		HeaderType:  waE2E.ButtonsMessage_EMPTY.Enum(),
+		ContextInfo: withExpiration(service.messageContextInfo(ctx, request.BaseRequest, dataWaRecipient, request.Text), request.Duration),
+	}
+	if request.Header != "" {
+		buttonsMessage.HeaderType = waE2E.ButtonsMessage_TEXT.Enum()
+		buttonsMessage.Header = &waE2E.ButtonsMessage_Text{Text: request.Header}
+	}
+
+	ts, asText, err := service.sendInteractive(ctx, client, dataWaRecipient, request.BaseRequest, request.Fallback,
+		rendersInteractive(client), &waE2E.Message{ButtonsMessage: buttonsMessage}, "🔘 "+request.Text, buttonsMenu(request))
+	if err != nil {
+		return response, err
+	}
+	return interactiveResponse("buttons", request.Phone, ts, asText), nil
+}
+
+// SendEvent sends an event invitation. Like polls, events carry a message secret
+// that the answers of the recipients are encrypted with.
+func (service serviceSend) SendEvent(ctx context.Context, request domainSend.EventRequest) (response domainSend.GenericResponse, err error) {
+	if err = validations.ValidateSendEvent(ctx, request); err != nil {
+		return response, err
+	}
+
+	client := whatsapp.ClientFromContext(ctx)
+	if client == nil {
+		return response, pkgError.ErrWaCLI
+	}
+
+	dataWaRecipient, err := utils.ValidateJidWithLogin(client, request.BaseRequest.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	start, end, err := eventTimes(request)
+	if err != nil {
+		return response, pkgError.ValidationError(err.Error())
+	}
+
+	messageSecret := make([]byte, 32)
+	if _, err = rand.Read(messageSecret); err != nil {
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to create event secret: %v", err))
+	}
+
+	event := &waE2E.EventMessage{
+		Name:               proto.String(request.Name),
+		Description:        optionalString(request.Description),
+		StartTime:          proto.Int64(start.Unix()),
+		JoinLink:           optionalString(request.JoinLink),
+		ExtraGuestsAllowed: proto.Bool(request.ExtraGuestsAllowed),
+		IsCanceled:         proto.Bool(false),
+		ContextInfo:        withExpiration(service.messageContextInfo(ctx, request.BaseRequest, dataWaRecipient, request.Description), request.Duration),
+	}
+	if !end.IsZero() {
+		event.EndTime = proto.Int64(end.Unix())
+	}
+	if request.Location != "" {
+		event.Location = &waE2E.LocationMessage{Name: proto.String(request.Location)}
+	}
+	msg := &waE2E.Message{
+		EventMessage:       event,
+		MessageContextInfo: &waE2E.MessageContextInfo{MessageSecret: messageSecret},
+	}
+
+	// Every current WhatsApp client shows event invitations, whoever sends them
+	ts, asText, err := service.sendInteractive(ctx, client, dataWaRecipient, request.BaseRequest, request.Fallback,
+		true, msg, "📅 "+request.Name, eventMenu(request, start, end))
+	if err != nil {
+		return response, err
+	}
+	return interactiveResponse("event", request.Phone, ts, asText), nil
+}
+
+// sendInteractive sends the interactive message, or the numbered text menu
+// standing in for it, as the fallback mode asks. WhatsApp accepts messages the
+// recipient cannot show without saying so, so "auto" only sends the interactive
+// message when rendered tells it will be shown, and still falls back when it is
+// refused.
+func (service serviceSend) sendInteractive(ctx context.Context, client *whatsmeow.Client, recipient types.JID, base domainSend.BaseRequest,
+	fallback string, rendered bool, msg *waE2E.Message, content, menu string) (ts whatsmeow.SendResponse, asText bool, err error) {
+	if sendsInteractive(fallback, rendered, recipient) {
+		ts, err = service.wrapSendMessage(ctx, client, recipient, msg, content)
+		if err == nil || fallback == domainSend.InteractiveFallbackNone {
+			return ts, false, err
+		}
+		logrus.Warnf("WhatsApp refused the interactive message to %s (%v), sending it as a numbered text menu", recipient.String(), err)
+	}
+
+	textMsg := &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+		Text:        proto.String(menu),
+		ContextInfo: withExpiration(service.messageContextInfo(ctx, base, recipient, menu), base.Duration),
+	}}
+	ts, err = service.wrapSendMessage(ctx, client, recipient, textMsg, menu)
+	return ts, true, err
+}
+
+// sendsInteractive tells whether the interactive message is tried rather than
+// the text menu. Channels cannot carry interactive messages at all.
+func sendsInteractive(fallback string, rendered bool, recipient types.JID) bool {
+	switch fallback {
+	case domainSend.InteractiveFallbackNone:
+		return true
+	case domainSend.InteractiveFallbackText:
+		return false
+	default:
+		return rendered && recipient.Server != types.NewsletterServer
+	}
+}
+
+// rendersInteractive tells whether lists and buttons from this account are shown
+// to recipients. Only business accounts get them rendered; from a personal account
+// WhatsApp delivers them but recipients see nothing.
+func rendersInteractive(client *whatsmeow.Client) bool {
+	return client.Store.BusinessName != ""
+}
+
+func interactiveResponse(kind, phone string, ts whatsmeow.SendResponse, asText bool) (response domainSend.GenericResponse) {
+	response.MessageID = ts.ID
+	response.Status = fmt.Sprintf("Send %s success %s (server timestamp: %s)", kind, phone, ts.Timestamp.String())
+	if asText {
+		response.Fallback = domainSend.InteractiveFallbackText
+		response.Status = fmt.Sprintf("Send %s as a numbered text menu success %s (server timestamp: %s)", kind, phone, ts.Timestamp.String())
+	}
+	return response
+}
+
+// choiceID is the ID of a row or button, which defaults to its number so that
+// picking it natively or answering the text menu gives the same value
+func choiceID(id string, number int) string {
+	if id != "" {
+		return id
+	}
+	return fmt.Sprint(number)
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return proto.String(value)
+}
+
+func eventTimes(request domainSend.EventRequest) (start, end time.Time, err error) {
+	if start, err = time.Parse(time.RFC3339, request.StartTime); err != nil {
+		return start, end, fmt.Errorf("start_time: %w", err)
+	}
+	if request.EndTime != "" {
+		if end, err = time.Parse(time.RFC3339, request.EndTime); err != nil {
+			return start, end, fmt.Errorf("end_time: %w", err)
+		}
+	}
+	return start, end, nil
+}
+
+// listMenu writes a list message as text, its rows numbered across sections
+func listMenu(request domainSend.ListRequest) string {
+	var menu strings.Builder
+	if request.Title != "" {
+		fmt.Fprintf(&menu, "*%s*\n", request.Title)
+	}
+	menu.WriteString(request.Description)
+
+	number := 0
+	for _, section := range request.Sections {
+		menu.WriteString("\n")
+		if section.Title != "" {
+			fmt.Fprintf(&menu, "\n*%s*", section.Title)
+		}
+		for _, row := range section.Rows {
+			number++
+			fmt.Fprintf(&menu, "\n%d. %s", number, row.Title)
+			if row.Description != "" {
+				fmt.Fprintf(&menu, " - %s", row.Description)
+			}
+		}
+	}
+
+	if request.Footer != "" {
+		fmt.Fprintf(&menu, "\n\n_%s_", request.Footer)
+	}
+	return menu.String()
+}
+
+// buttonsMenu writes a buttons message as text with numbered choices
+func buttonsMenu(request domainSend.ButtonsRequest) string {
+	var menu strings.Builder
+	if request.Header != "" {
+		fmt.Fprintf(&menu, "*%s*\n", request.Header)
+	}
+	menu.WriteString(request.Text)
+	menu.WriteString("\n")
+	for i, button := range request.Buttons {
+		fmt.Fprintf(&menu, "\n%d. %s", i+1, button.Text)
+	}
+	if request.Footer != "" {
+		fmt.Fprintf(&menu, "\n\n_%s_", request.Footer)
+	}
+	return menu.String()
+}
+
+// eventMenu writes an event invitation as text, with its times in the offset
+// they were given in
+func eventMenu(request domainSend.EventRequest, start, end time.Time) string {
+	const layout = "Mon, 2 Jan 2006 15:04 MST"
+
+	var menu strings.Builder
+	fmt.Fprintf(&menu, "📅 *%s*", request.Name)
+	if request.Description != "" {
+		fmt.Fprintf(&menu, "\n%s", request.Description)
+	}
+
+	fmt.Fprintf(&menu, "\n\n🕒 %s", start.Format(layout))
+	switch {
+	case end.IsZero():
+	case end.Format("2006-01-02") == start.In(end.Location()).Format("2006-01-02"):
+		fmt.Fprintf(&menu, " - %s", end.Format("15:04"))
+	default:
+		fmt.Fprintf(&menu, " - %s", end.Format(layout))
+	}
+	if request.Location != "" {
+		fmt.Fprintf(&menu, "\n📍 %s", request.Location)
+	}
+	if request.JoinLink != "" {
+		fmt.Fprintf(&menu, "\n🔗 %s", request.JoinLink)
+	}
+	return menu.String()
+}
@@ -0,0 +1,842 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	domainSend "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/send"
+	domainSendQueue "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/sendqueue"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/whatsapp"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+)
+
+const (
+	sendQueueEvent            = "message.queue"
+	sendQueueRetryDelay       = 30 * time.Second
+	sendQueueMaxRetryDelay    = 10 * time.Minute
+	sendQueueMaintenanceEvery = time.Minute
+	sendQueueCleanupEvery     = time.Hour
+	sendQueueSendTimeout      = 5 * time.Minute
+	// A claim older than this has no worker behind it, whichever instance made it:
+	// sends give up after sendQueueSendTimeout, well within the lease
+	sendQueueClaimLease = 3 * sendQueueSendTimeout
+	// Staged files are loaded back into memory up to this size, larger ones spill to a temp file
+	sendQueueFormMemory = 32 << 20
+
+	sendQueueInterruptedError = "interrupted while sending; it may have been delivered"
+	sendQueueExpiredError     = "expired before it could be sent"
+)
+
+// queueKind validates, stores and sends one kind of queued request
+type queueKind struct {
+	// split separates a request into its JSON payload and its uploaded files by form field
+	split func(request any) (payload []byte, files map[string]*multipart.FileHeader, err error)
+	// build rebuilds the request from its payload and files
+	build    func(payload []byte, files map[string]*multipart.FileHeader) (any, error)
+	validate func(ctx context.Context, request any) error
+	send     func(ctx context.Context, service domainSend.ISendUsecase, request any) (domainSend.GenericResponse, error)
+}
+
+func newQueueKind[T any](
+	validate func(context.Context, T) error,
+	send func(domainSend.ISendUsecase, context.Context, T) (domainSend.GenericResponse, error),
+	fileFields func(*T) map[string]**multipart.FileHeader,
+) queueKind {
+	fields := func(request *T) map[string]**multipart.FileHeader {
+		if fileFields == nil {
+			return nil
+		}
+		return fileFields(request)
+	}
+	typedRequest := func(request any) (T, error) {
+		typed, ok := request.(T)
+		if !ok {
+			return typed, fmt.Errorf("cannot queue %T as %T", request, typed)
+		}
+		return typed, nil
+	}
+
+	return queueKind{
+		split: func(request any) ([]byte, map[string]*multipart.FileHeader, error) {
+			typed, err := typedRequest(request)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			files := make(map[string]*multipart.FileHeader)
+			for field, file := range fields(&typed) {
+				if *file != nil {
+					files[field] = *file
+					*file = nil
+				}
+			}
+			payload, err := json.Marshal(typed)
+			return payload, files, err
+		},
+		build: func(payload []byte, files map[string]*multipart.FileHeader) (any, error) {
+			var typed T
+			if err := json.Unmarshal(payload, &typed); err != nil {
+				return nil, pkgError.ValidationError(fmt.Sprintf("invalid queued request: %v", err))
+			}
+			for field, file := range fields(&typed) {
+				*file = files[field]
+			}
+			return typed, nil
+		},
+		validate: func(ctx context.Context, request any) error {
+			typed, err := typedRequest(request)
+			if err != nil {
+				return err
+			}
+			return validate(ctx, typed)
+		},
+		send: func(ctx context.Context, service domainSend.ISendUsecase, request any) (domainSend.GenericResponse, error) {
+			typed, err := typedRequest(request)
+			if err != nil {
+				return domainSend.GenericResponse{}, pkgError.ValidationError(err.Error())
+			}
+			return send(service, ctx, typed)
+		},
+	}
+}
+
+var queueKinds = map[string]queueKind{
+	domainSendQueue.KindText: newQueueKind(validations.ValidateSendMessage, domainSend.ISendUsecase.SendText, nil),
+	domainSendQueue.KindImage: newQueueKind(validations.ValidateSendImage, domainSend.ISendUsecase.SendImage,
+		func(r *domainSend.ImageRequest) map[string]**multipart.FileHeader {
+			return map[string]**multipart.FileHeader{"image": &r.Image}
+		}),
+	domainSendQueue.KindFile: newQueueKind(validations.ValidateSendFile, domainSend.ISendUsecase.SendFile,
+		func(r *domainSend.FileRequest) map[string]**multipart.FileHeader {
+			return map[string]**multipart.FileHeader{"file": &r.File}
+		}),
+	domainSendQueue.KindVideo: newQueueKind(validations.ValidateSendVideo, domainSend.ISendUsecase.SendVideo,
+		func(r *domainSend.VideoRequest) map[string]**multipart.FileHeader {
+			return map[string]**multipart.FileHeader{"video": &r.Video}
+		}),
+	domainSendQueue.KindSticker: newQueueKind(validations.ValidateSendSticker, domainSend.ISendUsecase.SendSticker,
+		func(r *domainSend.StickerRequest) map[string]**multipart.FileHeader {
+			return map[string]**multipart.FileHeader{"sticker": &r.Sticker}
+		}),
+	domainSendQueue.KindContact:  newQueueKind(validations.ValidateSendContact, domainSend.ISendUsecase.SendContact, nil),
+	domainSendQueue.KindLink:     newQueueKind(validations.ValidateSendLink, domainSend.ISendUsecase.SendLink, nil),
+	domainSendQueue.KindLocation: newQueueKind(validations.ValidateSendLocation, domainSend.ISendUsecase.SendLocation, nil),
+	domainSendQueue.KindAudio: newQueueKind(validations.ValidateSendAudio, domainSend.ISendUsecase.SendAudio,
+		func(r *domainSend.AudioRequest) map[string]**multipart.FileHeader {
+			return map[string]**multipart.FileHeader{"audio": &r.Audio}
+		}),
+	domainSendQueue.KindPoll:    newQueueKind(validations.ValidateSendPoll, domainSend.ISendUsecase.SendPoll, nil),
+	domainSendQueue.KindList:    newQueueKind(validations.ValidateSendList, domainSend.ISendUsecase.SendList, nil),
+	domainSendQueue.KindButtons: newQueueKind(validations.ValidateSendButtons, domainSend.ISendUsecase.SendButtons, nil),
+	domainSendQueue.KindEvent:   newQueueKind(validations.ValidateSendEvent, domainSend.ISendUsecase.SendEvent, nil),
+}
+
+type serviceSendQueue struct {
+	repo        domainSendQueue.IQueueRepository
+	sendService domainSend.ISendUsecase
+	// stagingDir keeps the uploaded files of queued requests, one directory per item
+	stagingDir string
+
+	// Overridable for tests
+	connected func(deviceID string) bool
+	notify    func(item *domainSendQueue.Item)
+
+	// Worker control
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+	workerWg     sync.WaitGroup
+	workerMu     sync.Mutex
+
+	// Devices currently draining their queue
+	busyMu      sync.Mutex
+	busyDevices map[string]bool
+
+	lastMaintenance time.Time
+	lastCleanup     time.Time
+}
+
+// NewSendQueueService creates the outbound send queue, which sends queued
+// requests through sendService
+func NewSendQueueService(repo domainSendQueue.IQueueRepository, sendService domainSend.ISendUsecase, stagingDir string) domainSendQueue.IQueueUsecase {
+	service := &serviceSendQueue{
+		repo:        repo,
+		sendService: sendService,
+		stagingDir:  stagingDir,
+		connected:   isSenderConnected,
+		busyDevices: make(map[string]bool),
+	}
+	service.notify = service.forwardQueueEvent
+	return service
+}
+
+// queueDeviceIDFromContext returns the device manager id of the device in
+// context, which is how the worker looks the device up again
+func queueDeviceIDFromContext(ctx context.Context) (string, error) {
+	if inst, ok := whatsapp.DeviceFromContext(ctx); ok && inst != nil && inst.ID() != "" {
+		return inst.ID(), nil
+	}
+	return "", pkgError.ValidationError("device is required to use the send queue")
+}
+
+func (s *serviceSendQueue) Enqueue(ctx context.Context, request domainSendQueue.EnqueueRequest) (domainSendQueue.EnqueueResponse, error) {
+	deviceID, err := queueDeviceIDFromContext(ctx)
+	if err != nil {
+		return domainSendQueue.EnqueueResponse{}, err
+	}
+	return s.enqueue(ctx, deviceID, request, time.Now())
+}
+
+func (s *serviceSendQueue) enqueue(ctx context.Context, deviceID string, request domainSendQueue.EnqueueRequest, now time.Time) (response domainSendQueue.EnqueueResponse, err error) {
+	kind, ok := queueKinds[request.Kind]
+	if !ok {
+		return response, pkgError.ValidationError(fmt.Sprintf("unsupported send queue kind: %s", request.Kind))
+	}
+	if len(request.IdempotencyKey) > 255 {
+		return response, pkgError.ValidationError("idempotency key must be at most 255 characters")
+	}
+
+	scheduledAt, err := resolveSchedule(request.SendAt, request.Recurrence, now)
+	if err != nil {
+		return response, err
+	}
+
+	payload, uploads, err := kind.split(request.Request)
+	if err != nil {
+		return response, err
+	}
+	if err := kind.validate(ctx, request.Request); err != nil {
+		return response, err
+	}
+
+	item := newQueueItem(deviceID, request.Kind, payload, scheduledAt, now)
+	item.IdempotencyKey = request.IdempotencyKey
+	item.Recurrence = request.Recurrence
+
+	files, fileHashes, err := s.stageFiles(item.ID, uploads)
+	if err != nil {
+		s.removeStagedFiles(item.ID)
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to stage uploaded files: %v", err))
+	}
+	item.Files = files
+	item.RequestHash = queueRequestHash(item.Kind, request.Schedule, payload, fileHashes)
+
+	return s.store(ctx, item)
+}
+
+func newQueueItem(deviceID, kind string, payload []byte, scheduledAt *time.Time, now time.Time) *domainSendQueue.Item {
+	var target struct {
+		Phone string `json:"phone"`
+	}
+	_ = json.Unmarshal(payload, &target)
+
+	item := &domainSendQueue.Item{
+		ID:            uuid.NewString(),
+		DeviceID:      deviceID,
+		Kind:          kind,
+		Phone:         target.Phone,
+		Payload:       payload,
+		ScheduledAt:   scheduledAt,
+		Status:        domainSendQueue.StatusQueued,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if scheduledAt != nil {
+		item.NextAttemptAt = *scheduledAt
+	}
+	return item
+}
+
+// store queues an item whose files are staged, or returns the item queued
+// first under the same idempotency key
+func (s *serviceSendQueue) store(ctx context.Context, item *domainSendQueue.Item) (response domainSendQueue.EnqueueResponse, err error) {
+	stored, created, err := s.repo.Enqueue(ctx, item)
+	if err != nil || !created {
+		s.removeStagedFiles(item.ID)
+	}
+	if err != nil {
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to queue message: %v", err))
+	}
+
+	if !created && stored.RequestHash != item.RequestHash {
+		return response, pkgError.ValidationError("idempotency key was already used for a different request")
+	}
+
+	response.Item = stored
+	response.Duplicate = !created
+	return response, nil
+}
+
+// stageFiles copies the uploaded files of an item to its staging directory and
+// returns them along with the sha256 of each by form field
+func (s *serviceSendQueue) stageFiles(itemID string, uploads map[string]*multipart.FileHeader) ([]domainSendQueue.File, map[string]string, error) {
+	if len(uploads) == 0 {
+		return nil, nil, nil
+	}
+
+	dir := filepath.Join(s.stagingDir, itemID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, nil, err
+	}
+
+	var files []domainSendQueue.File
+	hashes := make(map[string]string, len(uploads))
+	for field, upload := range uploads {
+		path := filepath.Join(dir, field)
+		hash, err := copyUpload(upload, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		hashes[field] = hash
+		files = append(files, domainSendQueue.File{
+			Field:       field,
+			Path:        path,
+			Filename:    upload.Filename,
+			ContentType: upload.Header.Get("Content-Type"),
+		})
+	}
+	return files, hashes, nil
+}
+
+func copyUpload(upload *multipart.FileHeader, path string) (string, error) {
+	src, err := upload.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(dst, hash), src); err != nil {
+		dst.Close()
+		return "", err
+	}
+	if err := dst.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (s *serviceSendQueue) removeStagedFiles(itemID string) {
+	if err := os.RemoveAll(filepath.Join(s.stagingDir, itemID)); err != nil {
+		logrus.Warnf("Send queue: failed to remove staged files of %s: %v", itemID, err)
+	}
+}
+
+// queueRequestHash identifies a request by its kind, schedule, payload and file
+// contents so a reused idempotency key can be told apart from a retry
+func queueRequestHash(kind string, schedule domainSendQueue.Schedule, payload []byte, fileHashes map[string]string) string {
+	fields := make([]string, 0, len(fileHashes))
+	for field := range fileHashes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n%s\n", kind, schedule.SendAt, schedule.Recurrence, payload)
+	for _, field := range fields {
+		fmt.Fprintf(hash, "%s=%s\n", field, fileHashes[field])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// loadStagedFiles rebuilds the uploaded files of an item. The returned form
+// must be removed once the files are no longer needed.
+func loadStagedFiles(files []domainSendQueue.File) (map[string]*multipart.FileHeader, *multipart.Form, error) {
+	if len(files) == 0 {
+		return nil, nil, nil
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, file := range files {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+			"name":     file.Field,
+			"filename": file.Filename,
+		}))
+		if file.ContentType != "" {
+			header.Set("Content-Type", file.ContentType)
+		}
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		src, err := os.Open(file.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		_, err = io.Copy(part, src)
+		src.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(sendQueueFormMemory)
+	if err != nil {
+		return nil, nil, err
+	}
+	uploads := make(map[string]*multipart.FileHeader, len(form.File))
+	for field, headers := range form.File {
+		if len(headers) > 0 {
+			uploads[field] = headers[0]
+		}
+	}
+	return uploads, form, nil
+}
+
+func (s *serviceSendQueue) GetItem(ctx context.Context, id string) (*domainSendQueue.Item, error) {
+	deviceID, err := queueDeviceIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetItem(ctx, deviceID, id)
+}
+
+func (s *serviceSendQueue) ListItems(ctx context.Context, request domainSendQueue.ListItemsRequest) (response domainSendQueue.ListItemsResponse, err error) {
+	deviceID, err := queueDeviceIDFromContext(ctx)
+	if err != nil {
+		return response, err
+	}
+
+	switch request.Status {
+	case "", domainSendQueue.StatusQueued, domainSendQueue.StatusSending, domainSendQueue.StatusSent,
+		domainSendQueue.StatusFailed, domainSendQueue.StatusCancelled:
+	default:
+		return response, pkgError.ValidationError("status must be one of queued, sending, sent, failed or cancelled")
+	}
+	if request.Limit <= 0 {
+		request.Limit = 20
+	}
+	if request.Limit > 100 {
+		request.Limit = 100
+	}
+	if request.Offset < 0 {
+		request.Offset = 0
+	}
+
+	items, total, err := s.repo.ListItems(ctx, deviceID, request)
+	if err != nil {
+		return response, err
+	}
+	if items == nil {
+		items = []*domainSendQueue.Item{}
+	}
+	response.Items = items
+	response.Total = total
+	return response, nil
+}
+
+func (s *serviceSendQueue) CancelItem(ctx context.Context, id string) (*domainSendQueue.Item, error) {
+	deviceID, err := queueDeviceIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.repo.GetItem(ctx, deviceID, id)
+	if err != nil || item == nil {
+		return nil, err
+	}
+	if item.Status != domainSendQueue.StatusQueued {
+		return nil, pkgError.ValidationError(fmt.Sprintf("only queued messages can be cancelled, this one is %s", item.Status))
+	}
+
+	cancelled, err := s.repo.Cancel(ctx, deviceID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, pkgError.ValidationError("the message is already being sent")
+	}
+	if len(item.Files) > 0 {
+		s.removeStagedFiles(item.ID)
+	}
+	return s.repo.GetItem(ctx, deviceID, id)
+}
+
+func (s *serviceSendQueue) RescheduleItem(ctx context.Context, request domainSendQueue.RescheduleItemRequest) (*domainSendQueue.Item, error) {
+	deviceID, err := queueDeviceIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.rescheduleItem(ctx, deviceID, request, time.Now())
+}
+
+func (s *serviceSendQueue) rescheduleItem(ctx context.Context, deviceID string, request domainSendQueue.RescheduleItemRequest, now time.Time) (*domainSendQueue.Item, error) {
+	item, err := s.repo.GetItem(ctx, deviceID, request.ID)
+	if err != nil || item == nil {
+		return nil, err
+	}
+	if item.Status != domainSendQueue.StatusQueued {
+		return nil, pkgError.ValidationError(fmt.Sprintf("only queued messages can be rescheduled, this one is %s", item.Status))
+	}
+
+	recurrence := item.Recurrence
+	if request.Recurrence != nil {
+		recurrence = *request.Recurrence
+	}
+	scheduledAt, err := resolveSchedule(request.SendAt, recurrence, now)
+	if err != nil {
+		return nil, err
+	}
+	if scheduledAt == nil {
+		return nil, pkgError.ValidationError("send_at is required")
+	}
+
+	rescheduled, err := s.repo.Reschedule(ctx, deviceID, item.ID, recurrence, *scheduledAt)
+	if err != nil {
+		return nil, err
+	}
+	if !rescheduled {
+		return nil, pkgError.ValidationError("the message is already being sent")
+	}
+	return s.repo.GetItem(ctx, deviceID, item.ID)
+}
+
+// ============================================================================
+// Worker
+// ============================================================================
+
+func (s *serviceSendQueue) StartWorker(ctx context.Context) {
+	s.workerMu.Lock()
+	if s.workerCtx != nil {
+		s.workerMu.Unlock()
+		logrus.Info("Send queue worker already running")
+		return
+	}
+	s.workerCtx, s.workerCancel = context.WithCancel(ctx)
+	s.workerMu.Unlock()
+
+	s.workerWg.Add(1)
+	go s.runWorker()
+
+	logrus.Info("Send queue: worker started")
+}
+
+func (s *serviceSendQueue) StopWorker() {
+	s.workerMu.Lock()
+	if s.workerCancel != nil {
+		s.workerCancel()
+	}
+	s.workerMu.Unlock()
+
+	s.workerWg.Wait()
+	logrus.Info("Send queue: worker stopped")
+}
+
+func (s *serviceSendQueue) runWorker() {
+	defer s.workerWg.Done()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.workerCtx.Done():
+			return
+		case <-ticker.C:
+			s.maintain(time.Now())
+			s.processDueDevices()
+		}
+	}
+}
+
+// maintain fails items whose claim outlived its lease, expires items that waited
+// too long and deletes old finished items
+func (s *serviceSendQueue) maintain(now time.Time) {
+	ctx := s.workerCtx
+
+	if now.Sub(s.lastMaintenance) >= sendQueueMaintenanceEvery {
+		s.lastMaintenance = now
+		// Other instances may share the database, so only claims past the lease
+		// are known to be cut off by a stop or crash
+		failed, err := s.repo.FailInterrupted(ctx, now.Add(-sendQueueClaimLease), sendQueueInterruptedError)
+		if err != nil {
+			logrus.Errorf("Send queue: failed to recover interrupted items: %v", err)
+		}
+		s.finishFailed(ctx, failed, sendQueueInterruptedError, now)
+
+		if config.SendQueueExpireMinutes > 0 {
+			dueBefore := now.Add(-time.Duration(config.SendQueueExpireMinutes) * time.Minute)
+			expired, err := s.repo.ExpireQueued(ctx, dueBefore, sendQueueExpiredError)
+			if err != nil {
+				logrus.Errorf("Send queue: failed to expire items: %v", err)
+			}
+			s.finishFailed(ctx, expired, sendQueueExpiredError, now)
+		}
+	}
+
+	if now.Sub(s.lastCleanup) >= sendQueueCleanupEvery && config.SendQueueRetentionDays > 0 {
+		s.lastCleanup = now
+		deleted, err := s.repo.DeleteFinished(ctx, now.AddDate(0, 0, -config.SendQueueRetentionDays))
+		if err != nil {
+			logrus.Errorf("Send queue: failed to delete finished items: %v", err)
+		} else if deleted > 0 {
+			logrus.Infof("Send queue: deleted %d finished items", deleted)
+		}
+	}
+}
+
+func (s *serviceSendQueue) processDueDevices() {
+	ctx := s.workerCtx
+
+	deviceIDs, err := s.repo.GetDueDeviceIDs(ctx, time.Now())
+	if err != nil {
+		logrus.Errorf("Send queue: failed to get due devices: %v", err)
+		return
+	}
+
+	// Each device drains its own queue at its own pace; one still waiting out
+	// its rate limit from the previous tick is skipped
+	for _, deviceID := range deviceIDs {
+		if !s.markDeviceBusy(deviceID) {
+			continue
+		}
+
+		s.workerWg.Add(1)
+		go func(deviceID string) {
+			defer s.workerWg.Done()
+			defer s.markDeviceIdle(deviceID)
+			s.processDeviceQueue(ctx, deviceID)
+		}(deviceID)
+	}
+}
+
+func (s *serviceSendQueue) markDeviceBusy(deviceID string) bool {
+	s.busyMu.Lock()
+	defer s.busyMu.Unlock()
+	if s.busyDevices[deviceID] {
+		return false
+	}
+	s.busyDevices[deviceID] = true
+	return true
+}
+
+func (s *serviceSendQueue) markDeviceIdle(deviceID string) {
+	s.busyMu.Lock()
+	defer s.busyMu.Unlock()
+	delete(s.busyDevices, deviceID)
+}
+
+func (s *serviceSendQueue) processDeviceQueue(ctx context.Context, deviceID string) {
+	// A disconnected device keeps its items queued until it is back or they expire
+	if !s.connected(deviceID) {
+		return
+	}
+	dm := whatsapp.GetDeviceManager()
+	if dm == nil {
+		return
+	}
+	device, ok := dm.GetDevice(deviceID)
+	if !ok || device == nil {
+		return
+	}
+	sendCtx := whatsapp.ContextWithDevice(ctx, device)
+
+	for {
+		now := time.Now()
+		if !s.hasCapacity(ctx, deviceID, now) {
+			return
+		}
+
+		item, err := s.repo.ClaimNext(ctx, deviceID, now)
+		if err != nil {
+			logrus.Errorf("Send queue: failed to claim next item for %s: %v", deviceID, err)
+			return
+		}
+		if item == nil {
+			return
+		}
+
+		s.processItem(sendCtx, item, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(sendQueueInterval()):
+		}
+	}
+}
+
+// sendQueueInterval is the pause between two messages of a device
+func sendQueueInterval() time.Duration {
+	if config.SendQueueRatePerMinute <= 0 {
+		return 0
+	}
+	return time.Minute / time.Duration(config.SendQueueRatePerMinute)
+}
+
+// hasCapacity reports whether the device is still under the hourly limit
+func (s *serviceSendQueue) hasCapacity(ctx context.Context, deviceID string, now time.Time) bool {
+	if config.SendQueueHourlyLimit <= 0 {
+		return true
+	}
+	sent, err := s.repo.CountSentSince(ctx, deviceID, now.Add(-time.Hour))
+	if err != nil {
+		logrus.Errorf("Send queue: failed to count sent items for %s: %v", deviceID, err)
+		return false
+	}
+	return sent < config.SendQueueHourlyLimit
+}
+
+// processItem sends a claimed item and records the outcome. Failed attempts are
+// retried with backoff, except for requests WhatsApp can never accept.
+func (s *serviceSendQueue) processItem(ctx context.Context, item *domainSendQueue.Item, now time.Time) {
+	sendCtx, cancel := context.WithTimeout(ctx, sendQueueSendTimeout)
+	response, err := s.dispatch(sendCtx, item)
+	cancel()
+	if err == nil {
+		if err := s.repo.MarkSent(ctx, item.ID, response.MessageID, now); err != nil {
+			logrus.Errorf("Send queue: failed to mark %s as sent: %v", item.ID, err)
+		}
+		item.Status = domainSendQueue.StatusSent
+		item.MessageID = response.MessageID
+		item.Error = ""
+		item.SentAt = &now
+		s.finish(ctx, item, now)
+		return
+	}
+
+	var validationErr pkgError.ValidationError
+	if errors.As(err, &validationErr) || item.Attempts >= config.SendQueueMaxAttempts {
+		if err := s.repo.MarkFailed(ctx, item.ID, err.Error()); err != nil {
+			logrus.Errorf("Send queue: failed to mark %s as failed: %v", item.ID, err)
+		}
+		item.Status = domainSendQueue.StatusFailed
+		item.Error = err.Error()
+		s.finish(ctx, item, now)
+		return
+	}
+
+	nextAttemptAt := now.Add(sendQueueRetryBackoff(item.Attempts))
+	if err := s.repo.Requeue(ctx, item.ID, err.Error(), nextAttemptAt); err != nil {
+		logrus.Errorf("Send queue: failed to requeue %s: %v", item.ID, err)
+	}
+	logrus.WithFields(logrus.Fields{
+		"queue_id": item.ID,
+		"attempt":  item.Attempts,
+		"retry_at": nextAttemptAt,
+	}).Warnf("Send queue: send failed, will retry: %v", err)
+}
+
+func (s *serviceSendQueue) dispatch(ctx context.Context, item *domainSendQueue.Item) (domainSend.GenericResponse, error) {
+	kind, ok := queueKinds[item.Kind]
+	if !ok {
+		return domainSend.GenericResponse{}, pkgError.ValidationError(fmt.Sprintf("unsupported send queue kind: %s", item.Kind))
+	}
+
+	files, form, err := loadStagedFiles(item.Files)
+	if err != nil {
+		return domainSend.GenericResponse{}, fmt.Errorf("failed to load staged files: %w", err)
+	}
+	if form != nil {
+		defer form.RemoveAll()
+	}
+
+	request, err := kind.build(item.Payload, files)
+	if err != nil {
+		return domainSend.GenericResponse{}, err
+	}
+	return kind.send(ctx, s.sendService, request)
+}
+
+// sendQueueRetryBackoff doubles the delay after every failed attempt
+func sendQueueRetryBackoff(attempts int) time.Duration {
+	delay := sendQueueRetryDelay
+	for i := 1; i < attempts && delay < sendQueueMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > sendQueueMaxRetryDelay {
+		delay = sendQueueMaxRetryDelay
+	}
+	return delay
+}
+
+func (s *serviceSendQueue) finishFailed(ctx context.Context, items []*domainSendQueue.Item, errMsg string, now time.Time) {
+	for _, item := range items {
+		item.Status = domainSendQueue.StatusFailed
+		item.Error = errMsg
+		s.finish(ctx, item, now)
+	}
+}
+
+// finish reports the outcome of an item. A recurring item is queued again for
+// its next occurrence, any other drops its staged files as it will not be sent again.
+func (s *serviceSendQueue) finish(ctx context.Context, item *domainSendQueue.Item, now time.Time) {
+	s.notify(item)
+
+	if item.Recurrence != "" {
+		scheduledAt := item.NextAttemptAt
+		if item.ScheduledAt != nil {
+			scheduledAt = *item.ScheduledAt
+		}
+		next, err := nextOccurrence(item.Recurrence, scheduledAt, now)
+		if err == nil {
+			if err := s.repo.Rearm(ctx, item.ID, next); err != nil {
+				logrus.Errorf("Send queue: failed to schedule the next occurrence of %s: %v", item.ID, err)
+			}
+			return
+		}
+		logrus.Warnf("Send queue: recurring item %s has no next occurrence: %v", item.ID, err)
+	}
+
+	if len(item.Files) > 0 {
+		s.removeStagedFiles(item.ID)
+	}
+}
+
+// forwardQueueEvent reports the outcome of an item to the configured webhooks
+func (s *serviceSendQueue) forwardQueueEvent(item *domainSendQueue.Item) {
+	// Webhooks identify devices by JID like the other events, when it is known
+	deviceID := item.DeviceID
+	if dm := whatsapp.GetDeviceManager(); dm != nil {
+		if device, ok := dm.GetDevice(item.DeviceID); ok && device != nil && device.JID() != "" {
+			deviceID = device.JID()
+		}
+	}
+
+	payload := map[string]any{
+		"queue_id":        item.ID,
+		"status":          item.Status,
+		"kind":            item.Kind,
+		"phone":           item.Phone,
+		"attempts":        item.Attempts,
+		"message_id":      item.MessageID,
+		"error":           item.Error,
+		"idempotency_key": item.IdempotencyKey,
+	}
+
+	go func() {
+		if err := whatsapp.ForwardEventToWebhook(context.Background(), sendQueueEvent, deviceID, payload); err != nil {
+			logrus.Warnf("Send queue: failed to forward %s for %s: %v", sendQueueEvent, item.ID, err)
+		}
+	}()
+}
@@ -0,0 +1,275 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	domainSendQueue "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/sendqueue"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+)
+
+func (s *serviceSendQueue) EnqueueBulk(ctx context.Context, request domainSendQueue.BulkRequest) (domainSendQueue.BulkResponse, error) {
+	deviceID, err := queueDeviceIDFromContext(ctx)
+	if err != nil {
+		return domainSendQueue.BulkResponse{}, err
+	}
+	return s.enqueueBulk(ctx, deviceID, request, time.Now())
+}
+
+// enqueueBulk queues the request once per recipient under one batch. Recipients
+// the request is invalid for are reported as rejected instead of failing the batch.
+func (s *serviceSendQueue) enqueueBulk(ctx context.Context, deviceID string, request domainSendQueue.BulkRequest, now time.Time) (response domainSendQueue.BulkResponse, err error) {
+	kind, ok := queueKinds[request.Kind]
+	if !ok {
+		return response, pkgError.ValidationError(fmt.Sprintf("unsupported bulk send type: %s", request.Kind))
+	}
+	if len(request.Recipients) == 0 {
+		return response, pkgError.ValidationError("recipients are required")
+	}
+	if config.SendBulkMaxRecipients > 0 && len(request.Recipients) > config.SendBulkMaxRecipients {
+		return response, pkgError.ValidationError(fmt.Sprintf("a bulk send takes at most %d recipients", config.SendBulkMaxRecipients))
+	}
+	if request.IntervalSeconds < 0 {
+		return response, pkgError.ValidationError("interval_seconds must not be negative")
+	}
+	// Each recipient gets the key with its position appended
+	if len(request.IdempotencyKey) > 240 {
+		return response, pkgError.ValidationError("idempotency key of a bulk send must be at most 240 characters")
+	}
+
+	scheduledAt, err := resolveSchedule(request.SendAt, "", now)
+	if err != nil {
+		return response, err
+	}
+	start := now
+	if scheduledAt != nil {
+		start = *scheduledAt
+	}
+	interval := time.Duration(request.IntervalSeconds) * time.Second
+
+	payload, uploads, err := kind.split(request.Request)
+	if err != nil {
+		return response, err
+	}
+
+	// Uploads are staged once for the batch and linked into every item
+	batchID := uuid.NewString()
+	batchStage := "bulk-" + batchID
+	staged, fileHashes, err := s.stageFiles(batchStage, uploads)
+	defer s.removeStagedFiles(batchStage)
+	if err != nil {
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to stage uploaded files: %v", err))
+	}
+
+	placeholders := bulkPlaceholders(request.Recipients)
+	response.BatchID = batchID
+	queued := 0
+	for i, recipient := range request.Recipients {
+		recipientPayload, err := personalizePayload(payload, recipient, placeholders)
+		if err == nil {
+			var typed any
+			if typed, err = kind.build(recipientPayload, uploads); err == nil {
+				err = kind.validate(ctx, typed)
+			}
+		}
+		if err != nil {
+			response.Results = append(response.Results, rejectedBulkResult(recipient.Phone, err))
+			continue
+		}
+
+		item := newQueueItem(deviceID, request.Kind, recipientPayload, scheduledAt, now)
+		item.NextAttemptAt = start.Add(time.Duration(queued) * interval)
+		item.BatchID = batchID
+		item.BatchIndex = i
+		if request.IdempotencyKey != "" {
+			item.IdempotencyKey = fmt.Sprintf("%s#%d", request.IdempotencyKey, i)
+		}
+		item.RequestHash = queueRequestHash(item.Kind, domainSendQueue.Schedule{SendAt: request.SendAt}, recipientPayload, fileHashes)
+
+		item.Files, err = linkStagedFiles(staged, filepath.Join(s.stagingDir, item.ID))
+		if err != nil {
+			s.removeStagedFiles(item.ID)
+			return response, pkgError.InternalServerError(fmt.Sprintf("failed to stage uploaded files: %v", err))
+		}
+
+		stored, err := s.store(ctx, item)
+		if err != nil {
+			var validationErr pkgError.ValidationError
+			if !errors.As(err, &validationErr) {
+				return response, err
+			}
+			response.Results = append(response.Results, rejectedBulkResult(recipient.Phone, err))
+			continue
+		}
+		if stored.Duplicate {
+			// A retried batch reports the batch queued first
+			response.Duplicate = true
+			response.BatchID = stored.Item.BatchID
+		}
+		response.Results = append(response.Results, bulkResult(stored.Item))
+		queued++
+	}
+
+	response.Counts = countBulkResults(response.Results)
+	return response, nil
+}
+
+func (s *serviceSendQueue) GetBatch(ctx context.Context, batchID string) (*domainSendQueue.BulkResponse, error) {
+	deviceID, err := queueDeviceIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.getBatch(ctx, deviceID, batchID)
+}
+
+func (s *serviceSendQueue) getBatch(ctx context.Context, deviceID, batchID string) (*domainSendQueue.BulkResponse, error) {
+	items, err := s.repo.GetBatchItems(ctx, deviceID, batchID)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+
+	response := &domainSendQueue.BulkResponse{BatchID: batchID}
+	for _, item := range items {
+		response.Results = append(response.Results, bulkResult(item))
+	}
+	response.Counts = countBulkResults(response.Results)
+	return response, nil
+}
+
+func bulkResult(item *domainSendQueue.Item) domainSendQueue.BulkResult {
+	return domainSendQueue.BulkResult{
+		Phone:     item.Phone,
+		QueueID:   item.ID,
+		Status:    item.Status,
+		MessageID: item.MessageID,
+		Error:     item.Error,
+	}
+}
+
+func rejectedBulkResult(phone string, err error) domainSendQueue.BulkResult {
+	return domainSendQueue.BulkResult{Phone: phone, Status: domainSendQueue.StatusRejected, Error: err.Error()}
+}
+
+func countBulkResults(results []domainSendQueue.BulkResult) map[domainSendQueue.Status]int {
+	counts := make(map[domainSendQueue.Status]int)
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	return counts
+}
+
+// bulkPlaceholders returns every variable name used by a recipient, upper cased
+// like the [KEY] placeholders they fill
+func bulkPlaceholders(recipients []domainSendQueue.BulkRecipient) []string {
+	seen := make(map[string]bool)
+	var placeholders []string
+	for _, recipient := range recipients {
+		for key := range recipient.Variables {
+			key = strings.ToUpper(strings.TrimSpace(key))
+			if key != "" && !seen[key] {
+				seen[key] = true
+				placeholders = append(placeholders, key)
+			}
+		}
+	}
+	return placeholders
+}
+
+// personalizePayload addresses a request payload to the recipient and fills the
+// placeholders of its text fields. Placeholders the recipient has no value for
+// are removed, like campaign templates do.
+func personalizePayload(payload []byte, recipient domainSendQueue.BulkRecipient, placeholders []string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var fields map[string]any
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+
+	if len(placeholders) > 0 {
+		values := make(map[string]string, len(recipient.Variables))
+		for key, value := range recipient.Variables {
+			values[strings.ToUpper(strings.TrimSpace(key))] = value
+		}
+		pairs := make([]string, 0, len(placeholders)*2)
+		for _, key := range placeholders {
+			pairs = append(pairs, "["+key+"]", values[key])
+		}
+		replacer := strings.NewReplacer(pairs...)
+		for key, value := range fields {
+			fields[key] = fillPlaceholders(value, replacer)
+		}
+	}
+
+	fields["phone"] = recipient.Phone
+	return json.Marshal(fields)
+}
+
+func fillPlaceholders(value any, replacer *strings.Replacer) any {
+	switch v := value.(type) {
+	case string:
+		return replacer.Replace(v)
+	case []any:
+		for i := range v {
+			v[i] = fillPlaceholders(v[i], replacer)
+		}
+	case map[string]any:
+		for key := range v {
+			v[key] = fillPlaceholders(v[key], replacer)
+		}
+	}
+	return value
+}
+
+// linkStagedFiles gives an item its own copy of files staged for a batch,
+// hard linked when the file system allows so media is stored once
+func linkStagedFiles(files []domainSendQueue.File, dir string) ([]domainSendQueue.File, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	linked := make([]domainSendQueue.File, 0, len(files))
+	for _, file := range files {
+		path := filepath.Join(dir, file.Field)
+		if err := os.Link(file.Path, path); err != nil {
+			if err := copyStagedFile(file.Path, path); err != nil {
+				return nil, err
+			}
+		}
+		file.Path = path
+		linked = append(linked, file)
+	}
+	return linked, nil
+}
+
+func copyStagedFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,219 @@
+package usecase
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	domainSendQueue "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/sendqueue"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+)
+
+// scheduleClockSkew is how far in the past send_at may be, to absorb client clock drift
+const scheduleClockSkew = time.Minute
+
+// cronSearchLimit bounds the search for the next match of expressions that
+// rarely or never match, such as February 30th
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// resolveSchedule returns when a request scheduled with sendAt and recurrence
+// is first due, or nil when it is sent as soon as possible. Without sendAt a
+// cron recurrence starts at its next match and daily or weekly ones right away.
+func resolveSchedule(sendAt, recurrence string, now time.Time) (*time.Time, error) {
+	if recurrence != "" {
+		if _, err := nextOccurrence(recurrence, now, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if sendAt != "" {
+		scheduledAt, err := time.Parse(time.RFC3339, sendAt)
+		if err != nil {
+			return nil, pkgError.ValidationError("send_at must be an RFC3339 timestamp, e.g. 2025-03-04T09:30:00+07:00")
+		}
+		if scheduledAt.Before(now.Add(-scheduleClockSkew)) {
+			return nil, pkgError.ValidationError("send_at must not be in the past")
+		}
+		return &scheduledAt, nil
+	}
+
+	switch recurrence {
+	case "":
+		return nil, nil
+	case domainSendQueue.RecurrenceDaily, domainSendQueue.RecurrenceWeekly:
+		return &now, nil
+	default:
+		first, _ := nextOccurrence(recurrence, now, now)
+		return &first, nil
+	}
+}
+
+// nextOccurrence returns the first occurrence of recurrence after now. Daily and
+// weekly recurrences keep the wall clock time of scheduledAt, occurrences missed
+// while the app was down are skipped rather than sent late in a burst.
+func nextOccurrence(recurrence string, scheduledAt, now time.Time) (time.Time, error) {
+	days := 0
+	switch recurrence {
+	case domainSendQueue.RecurrenceDaily:
+		days = 1
+	case domainSendQueue.RecurrenceWeekly:
+		days = 7
+	}
+	if days > 0 {
+		next := scheduledAt.In(time.Local)
+		for !next.After(now) {
+			next = next.AddDate(0, 0, days)
+		}
+		return next, nil
+	}
+
+	schedule, err := parseCron(recurrence)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := schedule.next(now)
+	if next.IsZero() {
+		return time.Time{}, pkgError.ValidationError(fmt.Sprintf("recurrence %q never matches", recurrence))
+	}
+	return next, nil
+}
+
+// cronSchedule is a five field cron expression: minute, hour, day of month,
+// month and day of week. Each field is a bit set of the values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// Cron matches either day field when both are restricted
+	domAny, dowAny bool
+}
+
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, pkgError.ValidationError("recurrence must be daily, weekly or a five field cron expression like \"0 9 * * 1-5\"")
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, pkgError.ValidationError(fmt.Sprintf("invalid %s in recurrence: %v", cronFields[i].name, err))
+		}
+		sets[i] = set
+	}
+
+	// Sunday is both 0 and 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	return &cronSchedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField parses a comma separated list of *, n, a-b, each optionally with a /step
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangePart = part[:i]
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+			step = n
+		}
+
+		low, high := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			low, err1 = strconv.Atoi(bounds[0])
+			high, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("bad range %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q", part)
+			}
+			low = n
+			// "5/15" runs from 5 to the end of the range
+			if step == 1 {
+				high = n
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func (c *cronSchedule) matchesDay(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dowMatch
+	case c.dowAny:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+// next returns the first minute after after that the schedule matches, in the
+// server time zone, or the zero time when there is none within five years
+func (c *cronSchedule) next(after time.Time) time.Time {
+	t := after.In(time.Local).Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
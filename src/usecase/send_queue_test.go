@@ -0,0 +1,501 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	domainSend "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/send"
+	domainSendQueue "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/sendqueue"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+)
+
+// queueTestRepo keeps queued items in memory and records how the worker settled them
+type queueTestRepo struct {
+	domainSendQueue.IQueueRepository
+	items     map[string]*domainSendQueue.Item
+	requeued  map[string]time.Time
+	failed    map[string]string
+	sentAsIDs map[string]string
+	rearmed   map[string]time.Time
+}
+
+func newQueueTestRepo() *queueTestRepo {
+	return &queueTestRepo{
+		items:     make(map[string]*domainSendQueue.Item),
+		requeued:  make(map[string]time.Time),
+		failed:    make(map[string]string),
+		sentAsIDs: make(map[string]string),
+		rearmed:   make(map[string]time.Time),
+	}
+}
+
+func (r *queueTestRepo) Enqueue(_ context.Context, item *domainSendQueue.Item) (*domainSendQueue.Item, bool, error) {
+	for _, stored := range r.items {
+		if item.IdempotencyKey != "" && stored.DeviceID == item.DeviceID && stored.IdempotencyKey == item.IdempotencyKey {
+			return stored, false, nil
+		}
+	}
+	r.items[item.ID] = item
+	return item, true, nil
+}
+
+func (r *queueTestRepo) GetItem(_ context.Context, deviceID, id string) (*domainSendQueue.Item, error) {
+	if item, ok := r.items[id]; ok && item.DeviceID == deviceID {
+		return item, nil
+	}
+	return nil, nil
+}
+
+func (r *queueTestRepo) GetBatchItems(_ context.Context, deviceID, batchID string) ([]*domainSendQueue.Item, error) {
+	var items []*domainSendQueue.Item
+	for _, item := range r.items {
+		if item.DeviceID == deviceID && item.BatchID == batchID {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].BatchIndex < items[j].BatchIndex })
+	return items, nil
+}
+
+func (r *queueTestRepo) Reschedule(_ context.Context, _, id, recurrence string, scheduledAt time.Time) (bool, error) {
+	item := r.items[id]
+	item.Recurrence = recurrence
+	item.ScheduledAt = &scheduledAt
+	item.NextAttemptAt = scheduledAt
+	return true, nil
+}
+
+func (r *queueTestRepo) Rearm(_ context.Context, id string, scheduledAt time.Time) error {
+	r.rearmed[id] = scheduledAt
+	if item, ok := r.items[id]; ok {
+		item.Status = domainSendQueue.StatusQueued
+		item.ScheduledAt = &scheduledAt
+		item.NextAttemptAt = scheduledAt
+	}
+	return nil
+}
+
+func (r *queueTestRepo) MarkSent(_ context.Context, id, messageID string, _ time.Time) error {
+	r.sentAsIDs[id] = messageID
+	return nil
+}
+
+func (r *queueTestRepo) MarkFailed(_ context.Context, id, errMsg string) error {
+	r.failed[id] = errMsg
+	return nil
+}
+
+func (r *queueTestRepo) Requeue(_ context.Context, id, _ string, nextAttemptAt time.Time) error {
+	r.requeued[id] = nextAttemptAt
+	return nil
+}
+
+// queueTestSender returns err for every send and records what it was asked to send
+type queueTestSender struct {
+	domainSend.ISendUsecase
+	err       error
+	text      domainSend.MessageRequest
+	imageData []byte
+}
+
+func (s *queueTestSender) SendText(_ context.Context, request domainSend.MessageRequest) (domainSend.GenericResponse, error) {
+	s.text = request
+	return domainSend.GenericResponse{MessageID: "WAMSG1"}, s.err
+}
+
+func (s *queueTestSender) SendImage(_ context.Context, request domainSend.ImageRequest) (domainSend.GenericResponse, error) {
+	file, err := request.Image.Open()
+	if err != nil {
+		return domainSend.GenericResponse{}, err
+	}
+	defer file.Close()
+	s.imageData, err = io.ReadAll(file)
+	return domainSend.GenericResponse{MessageID: "WAMSG2"}, err
+}
+
+func newQueueTestService(t *testing.T, repo *queueTestRepo, sender *queueTestSender) (*serviceSendQueue, *[]*domainSendQueue.Item) {
+	var notified []*domainSendQueue.Item
+	service := NewSendQueueService(repo, sender, t.TempDir()).(*serviceSendQueue)
+	service.notify = func(item *domainSendQueue.Item) { notified = append(notified, item) }
+	return service, &notified
+}
+
+// uploadedFile builds a file header the way a multipart request body yields it
+func uploadedFile(t *testing.T, field, filename, contentType string, data []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="`+field+`"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, _ := writer.CreatePart(header)
+	part.Write(data)
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("ReadForm() error = %v", err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File[field][0]
+}
+
+func TestSendQueueEnqueueIdempotency(t *testing.T) {
+	repo := newQueueTestRepo()
+	service, _ := newQueueTestService(t, repo, &queueTestSender{})
+	ctx := context.Background()
+	now := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC)
+
+	request := domainSendQueue.EnqueueRequest{
+		Kind:           domainSendQueue.KindText,
+		IdempotencyKey: "order-42",
+		Request:        domainSend.MessageRequest{BaseRequest: domainSend.BaseRequest{Phone: "6281234567890@s.whatsapp.net"}, Message: "hello"},
+	}
+	first, err := service.enqueue(ctx, "dev1", request, now)
+	if err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+	if first.Duplicate || first.Item.Status != domainSendQueue.StatusQueued || first.Item.Phone != "6281234567890@s.whatsapp.net" {
+		t.Errorf("first enqueue = %+v", first.Item)
+	}
+
+	// A retry with the same key returns the item queued first
+	retry, err := service.enqueue(ctx, "dev1", request, now.Add(time.Second))
+	if err != nil {
+		t.Fatalf("enqueue(retry) error = %v", err)
+	}
+	if !retry.Duplicate || retry.Item.ID != first.Item.ID || len(repo.items) != 1 {
+		t.Errorf("retry = %+v, %d items queued", retry, len(repo.items))
+	}
+
+	// Reusing the key for another message is rejected
+	request.Request = domainSend.MessageRequest{BaseRequest: domainSend.BaseRequest{Phone: "6281234567890@s.whatsapp.net"}, Message: "bye"}
+	if _, err := service.enqueue(ctx, "dev1", request, now); !isValidationError(err) {
+		t.Errorf("enqueue(reused key) error = %v, want a validation error", err)
+	}
+
+	// Keys are scoped to the device
+	other, err := service.enqueue(ctx, "dev2", request, now)
+	if err != nil || other.Duplicate {
+		t.Errorf("enqueue(other device) = %+v, %v", other, err)
+	}
+
+	// Requests are validated before they are queued
+	request.IdempotencyKey = ""
+	request.Request = domainSend.MessageRequest{Message: "no phone"}
+	if _, err := service.enqueue(ctx, "dev1", request, now); !isValidationError(err) {
+		t.Errorf("enqueue(invalid) error = %v, want a validation error", err)
+	}
+	request.Kind = "carrier-pigeon"
+	if _, err := service.enqueue(ctx, "dev1", request, now); !isValidationError(err) {
+		t.Errorf("enqueue(unknown kind) error = %v, want a validation error", err)
+	}
+}
+
+func TestSendQueueStagedFiles(t *testing.T) {
+	repo := newQueueTestRepo()
+	sender := &queueTestSender{}
+	service, notified := newQueueTestService(t, repo, sender)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC)
+
+	image := []byte("\x89PNG fake image")
+	response, err := service.enqueue(ctx, "dev1", domainSendQueue.EnqueueRequest{
+		Kind: domainSendQueue.KindImage,
+		Request: domainSend.ImageRequest{
+			BaseRequest: domainSend.BaseRequest{Phone: "6281234567890@s.whatsapp.net"},
+			Caption:     "look",
+			Image:       uploadedFile(t, "image", "photo.png", "image/png", image),
+		},
+	}, now)
+	if err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+	item := response.Item
+	if len(item.Files) != 1 || item.Files[0].Filename != "photo.png" || item.Files[0].ContentType != "image/png" {
+		t.Fatalf("Files = %+v", item.Files)
+	}
+	if bytes.Contains(item.Payload, image) {
+		t.Errorf("payload should not embed the uploaded file")
+	}
+
+	item.Attempts = 1
+	service.processItem(ctx, item, now)
+	if !bytes.Equal(sender.imageData, image) {
+		t.Errorf("sent image = %q, want %q", sender.imageData, image)
+	}
+	if repo.sentAsIDs[item.ID] != "WAMSG2" || len(*notified) != 1 || (*notified)[0].Status != domainSendQueue.StatusSent {
+		t.Errorf("sent = %v, notified = %+v", repo.sentAsIDs, *notified)
+	}
+	if _, err := os.Stat(filepath.Join(service.stagingDir, item.ID)); !os.IsNotExist(err) {
+		t.Errorf("staged files should be removed once sent, stat error = %v", err)
+	}
+}
+
+func TestSendQueueRetries(t *testing.T) {
+	originalMaxAttempts := config.SendQueueMaxAttempts
+	config.SendQueueMaxAttempts = 3
+	t.Cleanup(func() { config.SendQueueMaxAttempts = originalMaxAttempts })
+
+	repo := newQueueTestRepo()
+	sender := &queueTestSender{err: errors.New("websocket not connected")}
+	service, notified := newQueueTestService(t, repo, sender)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC)
+
+	response, err := service.enqueue(ctx, "dev1", domainSendQueue.EnqueueRequest{
+		Kind:    domainSendQueue.KindText,
+		Request: domainSend.MessageRequest{BaseRequest: domainSend.BaseRequest{Phone: "6281234567890@s.whatsapp.net"}, Message: "hello"},
+	}, now)
+	if err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+	item := response.Item
+
+	// Failed attempts are retried with a growing delay
+	item.Attempts = 1
+	service.processItem(ctx, item, now)
+	if sender.text.Message != "hello" || !repo.requeued[item.ID].Equal(now.Add(30*time.Second)) {
+		t.Errorf("first retry at %v, sent %+v", repo.requeued[item.ID], sender.text)
+	}
+	item.Attempts = 2
+	service.processItem(ctx, item, now)
+	if !repo.requeued[item.ID].Equal(now.Add(time.Minute)) || len(*notified) != 0 {
+		t.Errorf("second retry at %v, notified %d", repo.requeued[item.ID], len(*notified))
+	}
+
+	// The last attempt fails the item
+	item.Attempts = 3
+	service.processItem(ctx, item, now)
+	if repo.failed[item.ID] != "websocket not connected" || len(*notified) != 1 || (*notified)[0].Status != domainSendQueue.StatusFailed {
+		t.Errorf("failed = %v, notified = %+v", repo.failed, *notified)
+	}
+
+	// Requests WhatsApp rejects are not retried
+	sender.err = pkgError.ValidationError("phone is not on whatsapp")
+	item.Attempts = 1
+	delete(repo.failed, item.ID)
+	service.processItem(ctx, item, now)
+	if repo.failed[item.ID] != "phone is not on whatsapp" {
+		t.Errorf("validation failure = %v", repo.failed)
+	}
+
+	if got := sendQueueRetryBackoff(20); got != sendQueueMaxRetryDelay {
+		t.Errorf("sendQueueRetryBackoff(20) = %v, want %v", got, sendQueueMaxRetryDelay)
+	}
+}
+
+func TestSendQueueSchedule(t *testing.T) {
+	repo := newQueueTestRepo()
+	service, notified := newQueueTestService(t, repo, &queueTestSender{})
+	ctx := context.Background()
+	now := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC)
+	message := domainSend.MessageRequest{BaseRequest: domainSend.BaseRequest{Phone: "6281234567890@s.whatsapp.net"}, Message: "standup"}
+
+	response, err := service.enqueue(ctx, "dev1", domainSendQueue.EnqueueRequest{
+		Schedule: domainSendQueue.Schedule{SendAt: "2025-03-05T09:00:00+07:00", Recurrence: domainSendQueue.RecurrenceDaily},
+		Kind:     domainSendQueue.KindText,
+		Request:  message,
+	}, now)
+	if err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+	item := response.Item
+	due := time.Date(2025, 3, 5, 2, 0, 0, 0, time.UTC)
+	if !item.NextAttemptAt.Equal(due) || item.ScheduledAt == nil || !item.ScheduledAt.Equal(due) || item.Recurrence != "daily" {
+		t.Fatalf("scheduled item = %+v", item)
+	}
+
+	// After an occurrence is sent the item is queued for the next one, keeping its files
+	item.Attempts = 1
+	service.processItem(ctx, item, due.Add(time.Minute))
+	if !repo.rearmed[item.ID].Equal(due.AddDate(0, 0, 1)) || len(*notified) != 1 {
+		t.Errorf("rearmed at %v, notified %d", repo.rearmed[item.ID], len(*notified))
+	}
+
+	// Rescheduling keeps the recurrence unless it is replaced
+	rescheduled, err := service.rescheduleItem(ctx, "dev1", domainSendQueue.RescheduleItemRequest{ID: item.ID, SendAt: "2025-03-06T10:00:00Z"}, now)
+	if err != nil || rescheduled.Recurrence != "daily" || !rescheduled.NextAttemptAt.Equal(time.Date(2025, 3, 6, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("rescheduleItem() = %+v, %v", rescheduled, err)
+	}
+	once := ""
+	rescheduled, err = service.rescheduleItem(ctx, "dev1", domainSendQueue.RescheduleItemRequest{ID: item.ID, SendAt: "2025-03-06T10:00:00Z", Recurrence: &once}, now)
+	if err != nil || rescheduled.Recurrence != "" {
+		t.Errorf("rescheduleItem(once) = %+v, %v", rescheduled, err)
+	}
+	if _, err := service.rescheduleItem(ctx, "dev1", domainSendQueue.RescheduleItemRequest{ID: item.ID, Recurrence: &once}, now); !isValidationError(err) {
+		t.Errorf("rescheduleItem(no send_at) error = %v, want a validation error", err)
+	}
+	if missing, err := service.rescheduleItem(ctx, "dev1", domainSendQueue.RescheduleItemRequest{ID: "missing", SendAt: "2025-03-06T10:00:00Z"}, now); missing != nil || err != nil {
+		t.Errorf("rescheduleItem(missing) = %+v, %v", missing, err)
+	}
+
+	for _, schedule := range []domainSendQueue.Schedule{
+		{SendAt: "tomorrow"},
+		{SendAt: "2025-03-01T00:00:00Z"},
+		{Recurrence: "hourly"},
+		{Recurrence: "61 * * * *"},
+	} {
+		_, err := service.enqueue(ctx, "dev1", domainSendQueue.EnqueueRequest{Schedule: schedule, Kind: domainSendQueue.KindText, Request: message}, now)
+		if !isValidationError(err) {
+			t.Errorf("enqueue(%+v) error = %v, want a validation error", schedule, err)
+		}
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	now := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC) // Tuesday
+	tests := []struct {
+		recurrence  string
+		scheduledAt time.Time
+		want        time.Time
+	}{
+		// Occurrences missed while the app was down are skipped
+		{"daily", time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC), time.Date(2025, 3, 5, 8, 0, 0, 0, time.UTC)},
+		{"weekly", time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC), time.Date(2025, 3, 11, 9, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", now, time.Date(2025, 3, 4, 9, 45, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", now, time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC)},
+		{"30 8 * * 0", now, time.Date(2025, 3, 9, 8, 30, 0, 0, time.UTC)},
+		{"30 8 * * 7", now, time.Date(2025, 3, 9, 8, 30, 0, 0, time.UTC)},
+		{"0 0 1 */3 *", now, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted match either one
+		{"0 12 15 * 5", now, time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", now, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.recurrence, func(t *testing.T) {
+			got, err := nextOccurrence(tt.recurrence, tt.scheduledAt, now)
+			if err != nil {
+				t.Fatalf("nextOccurrence() error = %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("nextOccurrence() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, recurrence := range []string{"", "* * * *", "0 24 * * *", "5-1 * * * *", "*/0 * * * *", "0 0 30 2 *"} {
+		if _, err := nextOccurrence(recurrence, now, now); !isValidationError(err) {
+			t.Errorf("nextOccurrence(%q) error = %v, want a validation error", recurrence, err)
+		}
+	}
+}
+
+func isValidationError(err error) bool {
+	var validationErr pkgError.ValidationError
+	return errors.As(err, &validationErr)
+}
+
+func TestSendQueueEnqueueBulk(t *testing.T) {
+	repo := newQueueTestRepo()
+	sender := &queueTestSender{}
+	service, _ := newQueueTestService(t, repo, sender)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC)
+
+	request := domainSendQueue.BulkRequest{
+		Kind: domainSendQueue.KindText,
+		Recipients: []domainSendQueue.BulkRecipient{
+			{Phone: "6281111111111@s.whatsapp.net", Variables: map[string]string{"name": "Ann"}},
+			{Phone: ""},
+			{Phone: "6282222222222@s.whatsapp.net", Variables: map[string]string{"Name": "Bob", "city": "Bandung"}},
+		},
+		IntervalSeconds: 10,
+		IdempotencyKey:  "promo-1",
+		Request:         domainSend.MessageRequest{Message: "Hi [NAME] from [CITY]"},
+	}
+	response, err := service.enqueueBulk(ctx, "dev1", request, now)
+	if err != nil {
+		t.Fatalf("enqueueBulk() error = %v", err)
+	}
+	if len(response.Results) != 3 || response.Counts[domainSendQueue.StatusQueued] != 2 || response.Counts[domainSendQueue.StatusRejected] != 1 {
+		t.Fatalf("response = %+v", response)
+	}
+	if rejected := response.Results[1]; rejected.Status != domainSendQueue.StatusRejected || rejected.Error == "" || rejected.QueueID != "" {
+		t.Errorf("recipient without a phone = %+v, want rejected", rejected)
+	}
+
+	// Recipients are spaced by the interval and keep their own text
+	first, second := repo.items[response.Results[0].QueueID], repo.items[response.Results[2].QueueID]
+	if !first.NextAttemptAt.Equal(now) || !second.NextAttemptAt.Equal(now.Add(10*time.Second)) {
+		t.Errorf("due = %v, %v", first.NextAttemptAt, second.NextAttemptAt)
+	}
+	if first.BatchID != response.BatchID || second.BatchIndex != 2 || second.IdempotencyKey != "promo-1#2" {
+		t.Errorf("second item = %+v", second)
+	}
+	second.Attempts = 1
+	service.processItem(ctx, second, now)
+	if sender.text.Message != "Hi Bob from Bandung" || sender.text.Phone != "6282222222222@s.whatsapp.net" {
+		t.Errorf("sent %+v", sender.text)
+	}
+	service.processItem(ctx, first, now)
+	if sender.text.Message != "Hi Ann from " {
+		t.Errorf("sent %q, want placeholders without a value removed", sender.text.Message)
+	}
+
+	// A retry with the same key reports the batch queued first
+	retry, err := service.enqueueBulk(ctx, "dev1", request, now.Add(time.Minute))
+	if err != nil || !retry.Duplicate || retry.BatchID != response.BatchID || len(repo.items) != 2 {
+		t.Errorf("retry = %+v, %v, %d items queued", retry, err, len(repo.items))
+	}
+
+	report, err := service.getBatch(ctx, "dev1", response.BatchID)
+	if err != nil || report == nil || len(report.Results) != 2 || report.Results[1].Phone != "6282222222222@s.whatsapp.net" {
+		t.Errorf("getBatch() = %+v, %v", report, err)
+	}
+	if missing, err := service.getBatch(ctx, "dev2", response.BatchID); err != nil || missing != nil {
+		t.Errorf("getBatch(other device) = %+v, %v", missing, err)
+	}
+
+	request.Kind = "carrier-pigeon"
+	if _, err := service.enqueueBulk(ctx, "dev1", request, now); !isValidationError(err) {
+		t.Errorf("enqueueBulk(unknown type) error = %v, want a validation error", err)
+	}
+	request.Kind = domainSendQueue.KindText
+	request.Recipients = nil
+	if _, err := service.enqueueBulk(ctx, "dev1", request, now); !isValidationError(err) {
+		t.Errorf("enqueueBulk(no recipients) error = %v, want a validation error", err)
+	}
+}
+
+func TestSendQueueEnqueueBulkFiles(t *testing.T) {
+	repo := newQueueTestRepo()
+	sender := &queueTestSender{}
+	service, _ := newQueueTestService(t, repo, sender)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC)
+
+	image := []byte("\x89PNG fake image")
+	response, err := service.enqueueBulk(ctx, "dev1", domainSendQueue.BulkRequest{
+		Kind:       domainSendQueue.KindImage,
+		Recipients: []domainSendQueue.BulkRecipient{{Phone: "6281111111111@s.whatsapp.net"}, {Phone: "6282222222222@s.whatsapp.net"}},
+		Request:    domainSend.ImageRequest{Image: uploadedFile(t, "image", "photo.png", "image/png", image)},
+	}, now)
+	if err != nil || response.Counts[domainSendQueue.StatusQueued] != 2 {
+		t.Fatalf("enqueueBulk() = %+v, %v", response, err)
+	}
+
+	// Every item owns its files, sending one leaves the other intact
+	for _, result := range response.Results {
+		item := repo.items[result.QueueID]
+		item.Attempts = 1
+		service.processItem(ctx, item, now)
+		if !bytes.Equal(sender.imageData, image) {
+			t.Errorf("sent image to %s = %q, want %q", item.Phone, sender.imageData, image)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(service.stagingDir, "bulk-"+response.BatchID)); !os.IsNotExist(err) {
+		t.Errorf("batch staging should be removed, stat error = %v", err)
+	}
+}
@@ -1,6 +1,17 @@
 package usecase
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"image"
+	"testing"
+
+	domainChatStorage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chatstorage"
+	domainSend "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/send"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/infrastructure/mediastore"
+	"github.com/disintegration/imaging"
+	"go.mau.fi/whatsmeow/types"
+)
 
 func TestResolveDocumentMIME(t *testing.T) {
 	tests := []struct {
@@ -39,3 +50,138 @@ func TestResolveDocumentMIME(t *testing.T) {
 		})
 	}
 }
+
+func TestQuotedMessage(t *testing.T) {
+	ctx := context.Background()
+	store := mediastore.NewLocalStore(t.TempDir(), "", []byte("secret"))
+	imageKey := "628/2025-03-04/3EB0QUOTED.png"
+	var encoded bytes.Buffer
+	if err := imaging.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 400, 300)), imaging.PNG); err != nil {
+		t.Fatalf("encode image: %v", err)
+	}
+	if err := store.Put(ctx, imageKey, encoded.Bytes(), "image/png"); err != nil {
+		t.Fatalf("store image: %v", err)
+	}
+
+	quoted := quotedMessage(ctx, store, &domainChatStorage.Message{
+		ID:         "3EB0QUOTED",
+		Content:    "look at this",
+		MediaType:  "image",
+		Filename:   "image_20250304.jpg",
+		URL:        "https://mmg.whatsapp.net/o1/v/t62.7118-24/abc",
+		MediaKey:   []byte("key"),
+		FileLength: 2048,
+		MediaPath:  imageKey,
+	})
+	img := quoted.GetImageMessage()
+	if img == nil || img.GetCaption() != "look at this" || img.GetMimetype() != "image/jpeg" || img.GetFileLength() != 2048 || string(img.GetMediaKey()) != "key" {
+		t.Fatalf("quotedMessage(image) = %v", quoted)
+	}
+	thumbnail, err := imaging.Decode(bytes.NewReader(img.GetJPEGThumbnail()))
+	if err != nil || thumbnail.Bounds().Dx() != 100 {
+		t.Errorf("thumbnail = %v, %v, want a 100px wide JPEG", thumbnail, err)
+	}
+
+	// Images that were never downloaded are quoted without a thumbnail
+	if quoted := quotedMessage(ctx, store, &domainChatStorage.Message{MediaType: "image"}); quoted.GetImageMessage() == nil || quoted.GetImageMessage().JPEGThumbnail != nil {
+		t.Errorf("quotedMessage(image without file) = %v", quoted)
+	}
+
+	document := quotedMessage(ctx, store, &domainChatStorage.Message{MediaType: "document", Filename: "invoice.pdf"}).GetDocumentMessage()
+	if document == nil || document.GetFileName() != "invoice.pdf" || document.GetMimetype() != "application/pdf" {
+		t.Errorf("quotedMessage(document) = %v", document)
+	}
+
+	if text := quotedMessage(ctx, store, &domainChatStorage.Message{Content: "hello"}); text.GetConversation() != "hello" {
+		t.Errorf("quotedMessage(text) = %v", text)
+	}
+}
+
+func TestAlbumImage(t *testing.T) {
+	var original bytes.Buffer
+	if err := imaging.Encode(&original, image.NewRGBA(image.Rect(0, 0, 1200, 800)), imaging.PNG); err != nil {
+		t.Fatalf("encode image: %v", err)
+	}
+
+	data, thumbnail, err := albumImage(original.Bytes(), false)
+	if err != nil || !bytes.Equal(data, original.Bytes()) {
+		t.Fatalf("albumImage(compress=false) changed the image: %v", err)
+	}
+	if thumb, err := imaging.Decode(bytes.NewReader(thumbnail)); err != nil || thumb.Bounds().Dx() != 100 {
+		t.Errorf("thumbnail = %v, %v, want a 100px wide JPEG", thumb, err)
+	}
+
+	data, _, err = albumImage(original.Bytes(), true)
+	if err != nil {
+		t.Fatalf("albumImage(compress=true): %v", err)
+	}
+	if compressed, err := imaging.Decode(bytes.NewReader(data)); err != nil || compressed.Bounds().Dx() != 600 {
+		t.Errorf("compressed = %v, %v, want a 600px wide JPEG", compressed, err)
+	}
+
+	if _, _, err := albumImage([]byte("not an image"), false); err == nil {
+		t.Error("albumImage accepted data that is not an image")
+	}
+}
+
+func TestInteractiveMenus(t *testing.T) {
+	list := listMenu(domainSend.ListRequest{
+		Title:       "Support",
+		Description: "How can we help?",
+		Footer:      "Reply with a number",
+		Sections: []domainSend.ListSection{
+			{Title: "Orders", Rows: []domainSend.ListRow{{ID: "track", Title: "Track", Description: "Where is my order"}, {Title: "Return"}}},
+			{Title: "Other", Rows: []domainSend.ListRow{{Title: "Agent"}}},
+		},
+	})
+	wantList := "*Support*\nHow can we help?\n\n*Orders*\n1. Track - Where is my order\n2. Return\n\n*Other*\n3. Agent\n\n_Reply with a number_"
+	if list != wantList {
+		t.Errorf("listMenu =\n%s\nwant\n%s", list, wantList)
+	}
+
+	buttons := buttonsMenu(domainSend.ButtonsRequest{Text: "Continue?", Buttons: []domainSend.Button{{Text: "Yes"}, {Text: "No"}}})
+	if want := "Continue?\n\n1. Yes\n2. No"; buttons != want {
+		t.Errorf("buttonsMenu = %q, want %q", buttons, want)
+	}
+
+	request := domainSend.EventRequest{Name: "Launch", StartTime: "2026-11-02T15:00:00+07:00", EndTime: "2026-11-02T16:30:00+07:00", Location: "Office"}
+	start, end, err := eventTimes(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event, want := eventMenu(request, start, end), "📅 *Launch*\n\n🕒 Mon, 2 Nov 2026 15:00 +0700 - 16:30\n📍 Office"; event != want {
+		t.Errorf("eventMenu = %q, want %q", event, want)
+	}
+
+	if id := choiceID("", 3); id != "3" {
+		t.Errorf("default choice ID = %q, want 3", id)
+	}
+}
+
+func TestSendsInteractive(t *testing.T) {
+	user := types.NewJID("6281234567890", types.DefaultUserServer)
+	channel := types.NewJID("120363000000000000", types.NewsletterServer)
+
+	tests := []struct {
+		name      string
+		fallback  string
+		rendered  bool
+		recipient types.JID
+		want      bool
+	}{
+		{"auto from a business account", domainSend.InteractiveFallbackAuto, true, user, true},
+		{"auto from a personal account", domainSend.InteractiveFallbackAuto, false, user, false},
+		{"default from a personal account", "", false, user, false},
+		{"auto to a channel", domainSend.InteractiveFallbackAuto, true, channel, false},
+		{"text", domainSend.InteractiveFallbackText, true, user, false},
+		{"none from a personal account", domainSend.InteractiveFallbackNone, false, user, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sendsInteractive(tt.fallback, tt.rendered, tt.recipient); got != tt.want {
+				t.Errorf("sendsInteractive() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package validations
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
@@ -45,6 +47,64 @@ func ValidateGetChatMessages(ctx context.Context, request *domainChat.GetChatMes
 	return nil
 }
 
+func ValidateSearchMessages(ctx context.Context, request *domainChat.SearchMessagesRequest) error {
+	// Set default limit if not provided
+	if request.Limit == 0 {
+		request.Limit = 20
+	}
+
+	err := validation.ValidateStructWithContext(ctx, request,
+		validation.Field(&request.Query, validation.Required, validation.Length(1, 500)),
+		validation.Field(&request.Limit, validation.Min(1), validation.Max(100)),
+		validation.Field(&request.Offset, validation.Min(0)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateGetMessageChanges(ctx context.Context, request *domainChat.GetMessageChangesRequest) error {
+	// Set default limit if not provided
+	if request.Limit == 0 {
+		request.Limit = 100
+	}
+
+	err := validation.ValidateStructWithContext(ctx, request,
+		validation.Field(&request.Limit, validation.Min(1), validation.Max(500)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateGetChatAnalytics(ctx context.Context, request *domainChat.GetChatAnalyticsRequest) error {
+	if request.Granularity == "" {
+		request.Granularity = domainChat.AnalyticsGranularityDay
+	}
+	if request.Limit == 0 {
+		request.Limit = 10
+	}
+
+	err := validation.ValidateStructWithContext(ctx, request,
+		validation.Field(&request.StartTime, validation.NilOrNotEmpty, validation.Date(time.RFC3339)),
+		validation.Field(&request.EndTime, validation.NilOrNotEmpty, validation.Date(time.RFC3339)),
+		validation.Field(&request.Granularity, validation.In(domainChat.AnalyticsGranularityDay, domainChat.AnalyticsGranularityHour)),
+		validation.Field(&request.Limit, validation.Min(1), validation.Max(100)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
 func ValidatePinChat(ctx context.Context, request *domainChat.PinChatRequest) error {
 	err := validation.ValidateStructWithContext(ctx, request,
 		validation.Field(&request.ChatJID, validation.Required),
@@ -102,3 +162,53 @@ func ValidateArchiveChat(ctx context.Context, request *domainChat.ArchiveChatReq
 
 	return nil
 }
+
+func ValidateExportChat(ctx context.Context, request *domainChat.ExportChatRequest) error {
+	// Default to JSON if no format is provided
+	if request.Format == "" {
+		request.Format = domainChat.ExportFormatJSON
+	}
+
+	// Exporting a whole device needs a date range
+	rangeRule := validation.When(request.ChatJID == "", validation.Required, validation.NilOrNotEmpty)
+
+	err := validation.ValidateStructWithContext(ctx, request,
+		validation.Field(&request.Format, validation.In(
+			domainChat.ExportFormatJSON,
+			domainChat.ExportFormatCSV,
+			domainChat.ExportFormatHTML,
+			domainChat.ExportFormatTXT,
+		)),
+		validation.Field(&request.StartTime, rangeRule, validation.Date(time.RFC3339)),
+		validation.Field(&request.EndTime, rangeRule, validation.Date(time.RFC3339)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateImportChat(ctx context.Context, request *domainChat.ImportChatRequest) error {
+	err := validation.ValidateStructWithContext(ctx, request,
+		validation.Field(&request.ChatJID, validation.Required),
+		validation.Field(&request.DateOrder, validation.In(
+			domainChat.ImportDateOrderDMY,
+			domainChat.ImportDateOrderMDY,
+		)),
+		validation.Field(&request.Timezone, validation.By(func(value interface{}) error {
+			if _, err := time.LoadLocation(value.(string)); err != nil {
+				return errors.New("must be an IANA time zone")
+			}
+			return nil
+		})),
+		validation.Field(&request.File, validation.Required.Error("chat export file is required")),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
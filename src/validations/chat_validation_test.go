@@ -2,6 +2,7 @@ package validations
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
@@ -141,6 +142,56 @@ func TestValidateGetChatMessages(t *testing.T) {
 	}
 }
 
+func TestValidateSearchMessages(t *testing.T) {
+	type args struct {
+		request domainChat.SearchMessagesRequest
+	}
+	tests := []struct {
+		name string
+		args args
+		err  any
+	}{
+		{
+			name: "should success with valid request",
+			args: args{request: domainChat.SearchMessagesRequest{
+				Query: "invoice",
+				Limit: 20,
+			}},
+			err: nil,
+		},
+		{
+			name: "should success with zero limit (auto set to default)",
+			args: args{request: domainChat.SearchMessagesRequest{
+				Query:   "invoice",
+				ChatJID: "6289685028129@s.whatsapp.net",
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with empty query",
+			args: args{request: domainChat.SearchMessagesRequest{
+				Limit: 20,
+			}},
+			err: pkgError.ValidationError("query: cannot be blank."),
+		},
+		{
+			name: "should error with limit too high",
+			args: args{request: domainChat.SearchMessagesRequest{
+				Query: "invoice",
+				Limit: 101,
+			}},
+			err: pkgError.ValidationError("limit: must be no greater than 100."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSearchMessages(context.Background(), &tt.args.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
 func TestValidatePinChat(t *testing.T) {
 	type args struct {
 		request domainChat.PinChatRequest
@@ -250,3 +301,134 @@ func TestValidateSetDisappearingTimer(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateExportChat(t *testing.T) {
+	start := "2025-01-01T00:00:00Z"
+	invalid := "yesterday"
+
+	tests := []struct {
+		name    string
+		request domainChat.ExportChatRequest
+		err     any
+	}{
+		{
+			name:    "should success with chat and default format",
+			request: domainChat.ExportChatRequest{ChatJID: "6289685028129@s.whatsapp.net"},
+			err:     nil,
+		},
+		{
+			name:    "should success with device date range",
+			request: domainChat.ExportChatRequest{Format: domainChat.ExportFormatTXT, StartTime: &start, EndTime: &start},
+			err:     nil,
+		},
+		{
+			name:    "should error without chat or date range",
+			request: domainChat.ExportChatRequest{Format: domainChat.ExportFormatCSV},
+			err:     pkgError.ValidationError("end_time: cannot be blank; start_time: cannot be blank."),
+		},
+		{
+			name:    "should error with unknown format",
+			request: domainChat.ExportChatRequest{ChatJID: "6289685028129@s.whatsapp.net", Format: "pdf"},
+			err:     pkgError.ValidationError("format: must be a valid value."),
+		},
+		{
+			name:    "should error with invalid time",
+			request: domainChat.ExportChatRequest{ChatJID: "6289685028129@s.whatsapp.net", StartTime: &invalid},
+			err:     pkgError.ValidationError("start_time: must be a valid date."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateExportChat(context.Background(), &tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateGetChatAnalytics(t *testing.T) {
+	start := "2025-01-01T00:00:00Z"
+	invalid := "last week"
+
+	tests := []struct {
+		name    string
+		request domainChat.GetChatAnalyticsRequest
+		err     any
+	}{
+		{
+			name:    "should success with defaults",
+			request: domainChat.GetChatAnalyticsRequest{},
+			err:     nil,
+		},
+		{
+			name:    "should success with chat, range and hourly volume",
+			request: domainChat.GetChatAnalyticsRequest{ChatJID: "6289685028129@s.whatsapp.net", StartTime: &start, EndTime: &start, Granularity: domainChat.AnalyticsGranularityHour, Limit: 5},
+			err:     nil,
+		},
+		{
+			name:    "should error with unknown granularity",
+			request: domainChat.GetChatAnalyticsRequest{Granularity: "week"},
+			err:     pkgError.ValidationError("granularity: must be a valid value."),
+		},
+		{
+			name:    "should error with invalid time",
+			request: domainChat.GetChatAnalyticsRequest{EndTime: &invalid},
+			err:     pkgError.ValidationError("end_time: must be a valid date."),
+		},
+		{
+			name:    "should error with limit too large",
+			request: domainChat.GetChatAnalyticsRequest{Limit: 101},
+			err:     pkgError.ValidationError("limit: must be no greater than 100."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGetChatAnalytics(context.Background(), &tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateImportChat(t *testing.T) {
+	file := strings.NewReader("01/03/2025, 09:05 - Alice: Hello")
+
+	tests := []struct {
+		name    string
+		request domainChat.ImportChatRequest
+		err     any
+	}{
+		{
+			name:    "should success with chat and file",
+			request: domainChat.ImportChatRequest{ChatJID: "6289685028129@s.whatsapp.net", File: file},
+			err:     nil,
+		},
+		{
+			name:    "should success with date order and timezone",
+			request: domainChat.ImportChatRequest{ChatJID: "6289685028129", DateOrder: domainChat.ImportDateOrderMDY, Timezone: "Asia/Jakarta", File: file},
+			err:     nil,
+		},
+		{
+			name:    "should error without chat and file",
+			request: domainChat.ImportChatRequest{},
+			err:     pkgError.ValidationError("File: chat export file is required; chat_jid: cannot be blank."),
+		},
+		{
+			name:    "should error with unknown date order",
+			request: domainChat.ImportChatRequest{ChatJID: "6289685028129@s.whatsapp.net", DateOrder: "ydm", File: file},
+			err:     pkgError.ValidationError("date_order: must be a valid value."),
+		},
+		{
+			name:    "should error with unknown timezone",
+			request: domainChat.ImportChatRequest{ChatJID: "6289685028129@s.whatsapp.net", Timezone: "Mars/Olympus", File: file},
+			err:     pkgError.ValidationError("timezone: must be an IANA time zone."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateImportChat(context.Background(), &tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
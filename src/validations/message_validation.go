@@ -2,6 +2,7 @@ package validations
 
 import (
 	"context"
+	"fmt"
 
 	domainMessage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/message"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
@@ -102,3 +103,37 @@ func ValidateDownloadMedia(ctx context.Context, request domainMessage.DownloadMe
 
 	return nil
 }
+
+func ValidateGetMedia(ctx context.Context, request domainMessage.MediaRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.MessageID, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+// maxForwardTargets bounds the chats one forward request sends to
+const maxForwardTargets = 50
+
+func ValidateForwardMessage(ctx context.Context, request domainMessage.ForwardRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.MessageID, validation.Required),
+		validation.Field(&request.Phones, validation.Required, validation.Length(1, maxForwardTargets)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	for _, phone := range request.Phones {
+		if err := validatePhoneNumber(phone); err != nil {
+			return pkgError.ValidationError(fmt.Sprintf("phones %s: %v", phone, err))
+		}
+	}
+
+	return nil
+}
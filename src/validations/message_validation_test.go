@@ -291,3 +291,61 @@ func TestValidateStarMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateForwardMessage(t *testing.T) {
+	type args struct {
+		request domainMessage.ForwardRequest
+	}
+	tests := []struct {
+		name string
+		args args
+		err  any
+	}{
+		{
+			name: "should success with several targets",
+			args: args{request: domainMessage.ForwardRequest{
+				MessageID: "3EB0789ABC123456",
+				Phones:    []string{"6281234567890@s.whatsapp.net", "120363024512399999@g.us"},
+			}},
+			err: nil,
+		},
+		{
+			name: "should error without targets",
+			args: args{request: domainMessage.ForwardRequest{
+				MessageID: "3EB0789ABC123456",
+			}},
+			err: pkgError.ValidationError("phones: cannot be blank."),
+		},
+		{
+			name: "should error with empty message id",
+			args: args{request: domainMessage.ForwardRequest{
+				Phones: []string{"6281234567890@s.whatsapp.net"},
+			}},
+			err: pkgError.ValidationError("message_id: cannot be blank."),
+		},
+		{
+			name: "should error with local format target",
+			args: args{request: domainMessage.ForwardRequest{
+				MessageID: "3EB0789ABC123456",
+				Phones:    []string{"081234567890"},
+			}},
+			err: pkgError.ValidationError("phones 081234567890: phone number must be in international format (should not start with 0). For Indonesian numbers, use 62xxx format instead of 08xxx"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateForwardMessage(context.Background(), tt.args.request)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, tt.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateGetMedia(t *testing.T) {
+	assert.NoError(t, ValidateGetMedia(context.Background(), domainMessage.MediaRequest{MessageID: "3EB0789ABC123456"}))
+	assert.Equal(t, pkgError.ValidationError("message_id: cannot be blank."), ValidateGetMedia(context.Background(), domainMessage.MediaRequest{}))
+}
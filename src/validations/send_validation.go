@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
 	domainSend "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/send"
@@ -37,6 +38,20 @@ func validateDuration(dur *int) error {
 	)
 }
 
+// validateMentions validates that mentioned phone numbers are in international format.
+// The special keyword "@everyone" mentions every group participant.
+func validateMentions(mentions []string) error {
+	for _, mention := range mentions {
+		if mention == "@everyone" {
+			continue
+		}
+		if err := validatePhoneNumber(mention); err != nil {
+			return pkgError.ValidationError(fmt.Sprintf("mention %s: phone number must be in international format", mention))
+		}
+	}
+	return nil
+}
+
 // validatePhoneNumber validates that the phone number is in international format (not starting with 0)
 func validatePhoneNumber(phone string) error {
 	if phone == "" {
@@ -77,15 +92,8 @@ func ValidateSendMessage(ctx context.Context, request domainSend.MessageRequest)
 		return err
 	}
 
-	// Validate mentions if provided
-	for _, mention := range request.Mentions {
-		// Skip validation for special @everyone keyword
-		if mention == "@everyone" {
-			continue
-		}
-		if err := validatePhoneNumber(mention); err != nil {
-			return pkgError.ValidationError(fmt.Sprintf("mention %s: phone number must be in international format", mention))
-		}
+	if err := validateMentions(request.Mentions); err != nil {
+		return err
 	}
 
 	return nil
@@ -137,6 +145,10 @@ func ValidateSendImage(ctx context.Context, request domainSend.ImageRequest) err
 		return err
 	}
 
+	if err := validateMentions(request.Mentions); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -192,6 +204,10 @@ func ValidateSendSticker(ctx context.Context, request domainSend.StickerRequest)
 		return err
 	}
 
+	if err := validateMentions(request.Mentions); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -219,6 +235,10 @@ func ValidateSendFile(ctx context.Context, request domainSend.FileRequest) error
 		return err
 	}
 
+	if err := validateMentions(request.Mentions); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -276,6 +296,71 @@ func ValidateSendVideo(ctx context.Context, request domainSend.VideoRequest) err
 		return err
 	}
 
+	if err := validateMentions(request.Mentions); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// maxAlbumItems is the most media WhatsApp groups into one album
+const maxAlbumItems = 30
+
+func ValidateSendAlbum(ctx context.Context, request domainSend.AlbumRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+		validation.Field(&request.Items, validation.Required, validation.Length(2, maxAlbumItems)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	if err := validatePhoneNumber(request.Phone); err != nil {
+		return err
+	}
+
+	imageMimes := map[string]bool{"image/jpeg": true, "image/jpg": true, "image/png": true}
+	videoMimes := map[string]bool{"video/mp4": true, "video/x-matroska": true, "video/avi": true, "video/x-msvideo": true}
+	for i, item := range request.Items {
+		hasURL := item.URL != nil && *item.URL != ""
+		if (item.File == nil) == !hasURL {
+			return pkgError.ValidationError(fmt.Sprintf("items %d: either a file or a url must be provided", i))
+		}
+		if hasURL {
+			if err := validation.Validate(*item.URL, is.URL); err != nil {
+				return pkgError.ValidationError(fmt.Sprintf("items %d: url must be a valid URL", i))
+			}
+		}
+
+		switch item.Type {
+		case domainSend.AlbumItemImage:
+			if item.File != nil && !imageMimes[item.File.Header.Get("Content-Type")] {
+				return pkgError.ValidationError(fmt.Sprintf("items %d: your image is not allowed. please use jpg/jpeg/png", i))
+			}
+			if item.File != nil && item.File.Size > config.WhatsappSettingMaxImageSize {
+				return pkgError.ValidationError(fmt.Sprintf("items %d: max image upload is %s", i, humanize.Bytes(uint64(config.WhatsappSettingMaxImageSize))))
+			}
+		case domainSend.AlbumItemVideo:
+			if item.File != nil && !videoMimes[item.File.Header.Get("Content-Type")] {
+				return pkgError.ValidationError(fmt.Sprintf("items %d: your video type is not allowed. please use mp4/mkv/avi/x-msvideo", i))
+			}
+			if item.File != nil && item.File.Size > config.WhatsappSettingMaxVideoSize {
+				return pkgError.ValidationError(fmt.Sprintf("items %d: max video upload is %s", i, humanize.Bytes(uint64(config.WhatsappSettingMaxVideoSize))))
+			}
+		default:
+			return pkgError.ValidationError(fmt.Sprintf("items %d: type must be image or video", i))
+		}
+	}
+
+	if err := validateDuration(request.Duration); err != nil {
+		return err
+	}
+
+	if err := validateMentions(request.Mentions); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -304,6 +389,10 @@ func ValidateSendContact(ctx context.Context, request domainSend.ContactRequest)
 		return err
 	}
 
+	if err := validateMentions(request.Mentions); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -327,6 +416,10 @@ func ValidateSendLink(ctx context.Context, request domainSend.LinkRequest) error
 		return err
 	}
 
+	if err := validateMentions(request.Mentions); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -350,6 +443,10 @@ func ValidateSendLocation(ctx context.Context, request domainSend.LocationReques
 		return err
 	}
 
+	if err := validateMentions(request.Mentions); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -425,6 +522,10 @@ func ValidateSendAudio(ctx context.Context, request domainSend.AudioRequest) err
 		return err
 	}
 
+	if err := validateMentions(request.Mentions); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -458,6 +559,10 @@ func ValidateSendPoll(ctx context.Context, request domainSend.PollRequest) error
 		return err
 	}
 
+	if err := validateMentions(request.Mentions); err != nil {
+		return err
+	}
+
 	// validate options should be unique each other
 	uniqueOptions := make(map[string]bool)
 	for _, option := range request.Options {
@@ -470,6 +575,140 @@ func ValidateSendPoll(ctx context.Context, request domainSend.PollRequest) error
 	return nil
 }
 
+// WhatsApp limits of interactive messages
+const (
+	maxListRows    = 10
+	maxButtons     = 3
+	maxButtonText  = 20
+	maxListRowText = 24
+)
+
+var interactiveFallbacks = []any{"", domainSend.InteractiveFallbackAuto, domainSend.InteractiveFallbackText, domainSend.InteractiveFallbackNone}
+
+// validateInteractiveBase checks the fields every interactive message shares
+func validateInteractiveBase(request domainSend.BaseRequest) error {
+	if err := validatePhoneNumber(request.Phone); err != nil {
+		return err
+	}
+
+	if err := validateDuration(request.Duration); err != nil {
+		return err
+	}
+
+	return validateMentions(request.Mentions)
+}
+
+func ValidateSendList(ctx context.Context, request domainSend.ListRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+		validation.Field(&request.Description, validation.Required),
+		validation.Field(&request.ButtonText, validation.Required, validation.RuneLength(1, maxButtonText)),
+		validation.Field(&request.Sections, validation.Required),
+		validation.Field(&request.Fallback, validation.In(interactiveFallbacks...)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	if err := validateInteractiveBase(request.BaseRequest); err != nil {
+		return err
+	}
+
+	rows := 0
+	ids := make(map[string]bool)
+	for i, section := range request.Sections {
+		if len(section.Rows) == 0 {
+			return pkgError.ValidationError(fmt.Sprintf("sections %d: rows cannot be blank.", i))
+		}
+		if len(request.Sections) > 1 && section.Title == "" {
+			return pkgError.ValidationError(fmt.Sprintf("sections %d: title is required when there are several sections", i))
+		}
+		for j, row := range section.Rows {
+			rows++
+			if err := validation.Validate(row.Title, validation.Required, validation.RuneLength(1, maxListRowText)); err != nil {
+				return pkgError.ValidationError(fmt.Sprintf("sections %d rows %d: title: %v.", i, j, err))
+			}
+			id := row.ID
+			if id == "" {
+				id = fmt.Sprint(rows)
+			}
+			if ids[id] {
+				return pkgError.ValidationError(fmt.Sprintf("sections %d rows %d: id %q is used twice", i, j, id))
+			}
+			ids[id] = true
+		}
+	}
+	if rows > maxListRows {
+		return pkgError.ValidationError(fmt.Sprintf("sections: a list has at most %d rows", maxListRows))
+	}
+
+	return nil
+}
+
+func ValidateSendButtons(ctx context.Context, request domainSend.ButtonsRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+		validation.Field(&request.Text, validation.Required),
+		validation.Field(&request.Buttons, validation.Required, validation.Length(1, maxButtons)),
+		validation.Field(&request.Fallback, validation.In(interactiveFallbacks...)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	if err := validateInteractiveBase(request.BaseRequest); err != nil {
+		return err
+	}
+
+	ids := make(map[string]bool)
+	for i, button := range request.Buttons {
+		if err := validation.Validate(button.Text, validation.Required, validation.RuneLength(1, maxButtonText)); err != nil {
+			return pkgError.ValidationError(fmt.Sprintf("buttons %d: text: %v.", i, err))
+		}
+		id := button.ID
+		if id == "" {
+			id = fmt.Sprint(i + 1)
+		}
+		if ids[id] {
+			return pkgError.ValidationError(fmt.Sprintf("buttons %d: id %q is used twice", i, id))
+		}
+		ids[id] = true
+	}
+
+	return nil
+}
+
+func ValidateSendEvent(ctx context.Context, request domainSend.EventRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+		validation.Field(&request.Name, validation.Required),
+		validation.Field(&request.StartTime, validation.Required, validation.Date(time.RFC3339)),
+		validation.Field(&request.EndTime, validation.Date(time.RFC3339)),
+		validation.Field(&request.JoinLink, is.URL),
+		validation.Field(&request.Fallback, validation.In(interactiveFallbacks...)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	if err := validateInteractiveBase(request.BaseRequest); err != nil {
+		return err
+	}
+
+	if request.EndTime != "" {
+		start, _ := time.Parse(time.RFC3339, request.StartTime)
+		end, _ := time.Parse(time.RFC3339, request.EndTime)
+		if !end.After(start) {
+			return pkgError.ValidationError("end_time: must be after start_time.")
+		}
+	}
+
+	return nil
+}
+
 func ValidateSendPresence(ctx context.Context, request domainSend.PresenceRequest) error {
 	err := validation.ValidateStructWithContext(ctx, &request,
 		validation.Field(&request.Type, validation.In("available", "unavailable")),
@@ -370,6 +370,84 @@ func TestValidateSendLink(t *testing.T) {
 	}
 }
 
+func TestValidateSendAlbum(t *testing.T) {
+	image := &multipart.FileHeader{
+		Filename: "sample-image.png",
+		Size:     100,
+		Header:   map[string][]string{"Content-Type": {"image/png"}},
+	}
+	videoURL := "https://example.com/video.mp4"
+
+	type args struct {
+		request domainSend.AlbumRequest
+	}
+	tests := []struct {
+		name string
+		args args
+		err  any
+	}{
+		{
+			name: "should success with an uploaded image and a video URL",
+			args: args{request: domainSend.AlbumRequest{
+				BaseRequest: domainSend.BaseRequest{Phone: "1728937129312@s.whatsapp.net"},
+				Items: []domainSend.AlbumItem{
+					{Type: domainSend.AlbumItemImage, File: image, Caption: "first"},
+					{Type: domainSend.AlbumItemVideo, URL: &videoURL},
+				},
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with a single item",
+			args: args{request: domainSend.AlbumRequest{
+				BaseRequest: domainSend.BaseRequest{Phone: "1728937129312@s.whatsapp.net"},
+				Items:       []domainSend.AlbumItem{{Type: domainSend.AlbumItemImage, File: image}},
+			}},
+			err: pkgError.ValidationError("items: the length must be between 2 and 30."),
+		},
+		{
+			name: "should error with an item without media",
+			args: args{request: domainSend.AlbumRequest{
+				BaseRequest: domainSend.BaseRequest{Phone: "1728937129312@s.whatsapp.net"},
+				Items: []domainSend.AlbumItem{
+					{Type: domainSend.AlbumItemImage, File: image},
+					{Type: domainSend.AlbumItemImage},
+				},
+			}},
+			err: pkgError.ValidationError("items 1: either a file or a url must be provided"),
+		},
+		{
+			name: "should error with an unsupported item type",
+			args: args{request: domainSend.AlbumRequest{
+				BaseRequest: domainSend.BaseRequest{Phone: "1728937129312@s.whatsapp.net"},
+				Items: []domainSend.AlbumItem{
+					{Type: domainSend.AlbumItemImage, File: image},
+					{Type: "document", URL: &videoURL},
+				},
+			}},
+			err: pkgError.ValidationError("items 1: type must be image or video"),
+		},
+		{
+			name: "should error with a file not matching its type",
+			args: args{request: domainSend.AlbumRequest{
+				BaseRequest: domainSend.BaseRequest{Phone: "1728937129312@s.whatsapp.net"},
+				Items: []domainSend.AlbumItem{
+					{Type: domainSend.AlbumItemVideo, File: image},
+					{Type: domainSend.AlbumItemVideo, URL: &videoURL},
+				},
+			}},
+			err: pkgError.ValidationError("items 0: your video type is not allowed. please use mp4/mkv/avi/x-msvideo"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSendAlbum(context.Background(), tt.args.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
 func TestValidateRevokeMessage(t *testing.T) {
 	type args struct {
 		request domainMessage.RevokeRequest
@@ -606,6 +684,18 @@ func TestValidateSendLocation(t *testing.T) {
 			}},
 			err: pkgError.ValidationError("longitude: must be a valid longitude."),
 		},
+		{
+			name: "should error with local format mention",
+			args: args{request: domainSend.LocationRequest{
+				BaseRequest: domainSend.BaseRequest{
+					Phone:    "1728937129312@s.whatsapp.net",
+					Mentions: []string{"@everyone", "08123456789"},
+				},
+				Latitude:  "-7.797068",
+				Longitude: "110.370529",
+			}},
+			err: pkgError.ValidationError("mention 08123456789: phone number must be in international format"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -776,6 +866,148 @@ func TestValidateSendPoll(t *testing.T) {
 	}
 }
 
+func TestValidateSendList(t *testing.T) {
+	base := domainSend.BaseRequest{Phone: "1728937129312@s.whatsapp.net"}
+	rows := func(n int) []domainSend.ListRow {
+		result := make([]domainSend.ListRow, n)
+		for i := range result {
+			result[i] = domainSend.ListRow{Title: "Row"}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name    string
+		request domainSend.ListRequest
+		err     any
+	}{
+		{
+			name: "should success with rows numbered by default",
+			request: domainSend.ListRequest{BaseRequest: base, Description: "Pick one", ButtonText: "Menu",
+				Sections: []domainSend.ListSection{{Rows: rows(3)}}},
+			err: nil,
+		},
+		{
+			name:    "should error without sections",
+			request: domainSend.ListRequest{BaseRequest: base, Description: "Pick one", ButtonText: "Menu"},
+			err:     pkgError.ValidationError("sections: cannot be blank."),
+		},
+		{
+			name: "should error with too many rows across sections",
+			request: domainSend.ListRequest{BaseRequest: base, Description: "Pick one", ButtonText: "Menu",
+				Sections: []domainSend.ListSection{{Title: "A", Rows: rows(6)}, {Title: "B", Rows: rows(5)}}},
+			err: pkgError.ValidationError("sections: a list has at most 10 rows"),
+		},
+		{
+			name: "should error with an untitled section among several",
+			request: domainSend.ListRequest{BaseRequest: base, Description: "Pick one", ButtonText: "Menu",
+				Sections: []domainSend.ListSection{{Title: "A", Rows: rows(1)}, {Rows: rows(1)}}},
+			err: pkgError.ValidationError("sections 1: title is required when there are several sections"),
+		},
+		{
+			name: "should error when an ID repeats the default ID of another row",
+			request: domainSend.ListRequest{BaseRequest: base, Description: "Pick one", ButtonText: "Menu",
+				Sections: []domainSend.ListSection{{Rows: []domainSend.ListRow{{ID: "2", Title: "First"}, {Title: "Second"}}}}},
+			err: pkgError.ValidationError(`sections 0 rows 1: id "2" is used twice`),
+		},
+		{
+			name: "should error with an unknown fallback",
+			request: domainSend.ListRequest{BaseRequest: base, Description: "Pick one", ButtonText: "Menu",
+				Sections: []domainSend.ListSection{{Rows: rows(1)}}, Fallback: "always"},
+			err: pkgError.ValidationError("fallback: must be a valid value."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSendList(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateSendButtons(t *testing.T) {
+	base := domainSend.BaseRequest{Phone: "1728937129312@s.whatsapp.net"}
+
+	tests := []struct {
+		name    string
+		request domainSend.ButtonsRequest
+		err     any
+	}{
+		{
+			name: "should success with two buttons",
+			request: domainSend.ButtonsRequest{BaseRequest: base, Text: "Continue?", Fallback: domainSend.InteractiveFallbackText,
+				Buttons: []domainSend.Button{{ID: "yes", Text: "Yes"}, {ID: "no", Text: "No"}}},
+			err: nil,
+		},
+		{
+			name: "should error with four buttons",
+			request: domainSend.ButtonsRequest{BaseRequest: base, Text: "Continue?",
+				Buttons: []domainSend.Button{{Text: "A"}, {Text: "B"}, {Text: "C"}, {Text: "D"}}},
+			err: pkgError.ValidationError("buttons: the length must be between 1 and 3."),
+		},
+		{
+			name: "should error with a long button text",
+			request: domainSend.ButtonsRequest{BaseRequest: base, Text: "Continue?",
+				Buttons: []domainSend.Button{{Text: "This button text is far too long"}}},
+			err: pkgError.ValidationError("buttons 0: text: the length must be between 1 and 20."),
+		},
+		{
+			name: "should error with duplicate IDs",
+			request: domainSend.ButtonsRequest{BaseRequest: base, Text: "Continue?",
+				Buttons: []domainSend.Button{{ID: "x", Text: "Yes"}, {ID: "x", Text: "No"}}},
+			err: pkgError.ValidationError(`buttons 1: id "x" is used twice`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSendButtons(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateSendEvent(t *testing.T) {
+	base := domainSend.BaseRequest{Phone: "120363024512399999@g.us"}
+
+	tests := []struct {
+		name    string
+		request domainSend.EventRequest
+		err     any
+	}{
+		{
+			name: "should success with a start and end time",
+			request: domainSend.EventRequest{BaseRequest: base, Name: "Launch", StartTime: "2026-11-02T15:00:00+07:00",
+				EndTime: "2026-11-02T16:30:00+07:00", JoinLink: "https://meet.example.com/launch"},
+			err: nil,
+		},
+		{
+			name:    "should error without a start time",
+			request: domainSend.EventRequest{BaseRequest: base, Name: "Launch"},
+			err:     pkgError.ValidationError("start_time: cannot be blank."),
+		},
+		{
+			name:    "should error with a start time that is not RFC3339",
+			request: domainSend.EventRequest{BaseRequest: base, Name: "Launch", StartTime: "2026-11-02 15:00"},
+			err:     pkgError.ValidationError("start_time: must be a valid date."),
+		},
+		{
+			name: "should error with an end before the start",
+			request: domainSend.EventRequest{BaseRequest: base, Name: "Launch", StartTime: "2026-11-02T15:00:00Z",
+				EndTime: "2026-11-02T14:00:00Z"},
+			err: pkgError.ValidationError("end_time: must be after start_time."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSendEvent(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
 func TestValidateSendPresence(t *testing.T) {
 	type args struct {
 		request domainSend.PresenceRequest
@@ -385,7 +385,12 @@ export default {
                             </div>
                         </td>
                         <td>{{ customer.phone }}</td>
-                        <td>{{ customer.full_name || '-' }}</td>
+                        <td>
+                            {{ customer.full_name || '-' }}
+                            <div v-if="customer.tags && customer.tags.length">
+                                <span v-for="tag in customer.tags" :key="tag" class="ui mini basic label">{{ tag }}</span>
+                            </div>
+                        </td>
                         <td>{{ customer.company || '-' }}</td>
                         <td>
                             <span :class="'ui mini ' + getStatusColor(customer.phone_valid) + ' label'" title="Phone Format">
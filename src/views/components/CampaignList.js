@@ -12,6 +12,9 @@ export default {
                 template_id: '',
                 customer_ids: [],
                 group_ids: [],
+                devices: [],
+                steps: [],
+                tags: [],
                 scheduled_at: ''
             },
             editingId: null,
@@ -37,6 +40,14 @@ export default {
         },
         totalPages() {
             return Math.ceil(this.total / this.pageSize);
+        },
+        tagsInput: {
+            get() {
+                return this.form.tags.join(', ');
+            },
+            set(value) {
+                this.form.tags = value.split(',').map(tag => tag.trim().toLowerCase()).filter(tag => tag);
+            }
         }
     },
     methods: {
@@ -96,6 +107,9 @@ export default {
                     template_id: fullCampaign.template_id,
                     customer_ids: fullCampaign.customer_ids || [],
                     group_ids: fullCampaign.group_ids || [],
+                    devices: fullCampaign.devices || [],
+                    steps: fullCampaign.steps || [],
+                    tags: fullCampaign.tags || [],
                     scheduled_at: fullCampaign.scheduled_at ? new Date(fullCampaign.scheduled_at).toISOString().slice(0, 16) : ''
                 };
                 this.editingId = fullCampaign.id;
@@ -105,7 +119,7 @@ export default {
             }
         },
         resetForm() {
-            this.form = { name: '', template_id: '', customer_ids: [], group_ids: [], scheduled_at: '' };
+            this.form = { name: '', template_id: '', customer_ids: [], group_ids: [], devices: [], steps: [], tags: [], scheduled_at: '' };
         },
         async submitForm() {
             if (!this.form.name.trim()) {
@@ -116,8 +130,8 @@ export default {
                 showErrorInfo('Please select a template');
                 return;
             }
-            if (this.form.customer_ids.length === 0 && this.form.group_ids.length === 0) {
-                showErrorInfo('Please select at least one customer or group');
+            if (this.form.customer_ids.length === 0 && this.form.group_ids.length === 0 && this.form.tags.length === 0) {
+                showErrorInfo('Please select at least one customer, group or tag');
                 return;
             }
             try {
@@ -127,6 +141,9 @@ export default {
                     template_id: this.form.template_id,
                     customer_ids: this.form.customer_ids,
                     group_ids: this.form.group_ids,
+                    devices: this.form.devices.map(({ device_id, hourly_limit, daily_limit }) => ({ device_id, hourly_limit, daily_limit })),
+                    tags: this.form.tags,
+                    steps: this.form.steps.map(({ template_id, delay_minutes, condition, keyword }) => ({ template_id, delay_minutes, condition, keyword })),
                     scheduled_at: this.form.scheduled_at ? new Date(this.form.scheduled_at).toISOString() : null
                 };
 
@@ -438,6 +455,12 @@ export default {
                         </div>
                     </div>
                     
+                    <div class="field">
+                        <label>Target Tags</label>
+                        <input type="text" v-model.lazy="tagsInput" placeholder="e.g. vip, clicked">
+                        <small>Customers carrying any of these tags are included</small>
+                    </div>
+                    
                     <div class="field">
                         <label>Select Individual Customers</label>
                         <div class="ui fluid icon input" style="margin-bottom: 10px">
@@ -470,7 +493,7 @@ export default {
                     </div>
                     
                     <div class="ui info message">
-                        <p>Selected: {{ form.group_ids.length }} groups, {{ form.customer_ids.length }} individual customers</p>
+                        <p>Selected: {{ form.group_ids.length }} groups, {{ form.customer_ids.length }} individual customers, {{ form.tags.length }} tags</p>
                     </div>
                 </div>
             </form>
@@ -14,7 +14,7 @@ export default {
       pageSize: 20,
       totalMessages: 0,
       // Media download tracking
-      downloadedMedia: {}, // messageId -> { file_path, media_type, file_size, status }
+      downloadedMedia: {}, // messageId -> { file_path (signed link), content_type, media_type, file_size, status }
       downloadingMedia: new Set(), // Set of messageIds currently downloading
       mediaDownloadErrors: {}, // messageId -> error message
       maxConcurrentDownloads: 3,
@@ -201,6 +201,7 @@ export default {
         const mediaType = downloadedInfo.media_type;
         const filename = downloadedInfo.filename;
         const fileSize = downloadedInfo.file_size;
+        const contentType = downloadedInfo.content_type;
 
         switch (mediaType.toLowerCase()) {
           case 'image':
@@ -223,9 +224,7 @@ export default {
               type: 'video',
               content: `<div class="ui fluid">
                 <video controls style="max-width: 300px; max-height: 300px; border-radius: 4px;" preload="metadata">
-                  <source src="${filePath}" type="video/mp4">
-                  <source src="${filePath}" type="video/webm">
-                  <source src="${filePath}" type="video/ogg">
+                  <source src="${filePath}" type="${contentType}">
                   Your browser does not support the video tag.
                 </video>
               </div>`
@@ -236,9 +235,7 @@ export default {
               type: 'audio',
               content: `<div class="ui fluid">
                 <audio controls style="width: 100%; max-width: 300px;">
-                  <source src="${filePath}" type="audio/mpeg">
-                  <source src="${filePath}" type="audio/ogg">
-                  <source src="${filePath}" type="audio/wav">
+                  <source src="${filePath}" type="${contentType}">
                   Your browser does not support the audio tag.
                 </audio>
               </div>`
@@ -349,13 +346,13 @@ export default {
           delete this.mediaDownloadErrors[messageId];
         }
 
-        const response = await window.http.get(
-          `/message/${messageId}/download?phone=${this.formattedJid}`
-        );
+        // Signed link that players can seek in without sending credentials
+        const response = await window.http.get(`/message/${messageId}/media/link`);
 
         if (response.data && response.data.results) {
           this.downloadedMedia[messageId] = {
-            file_path: response.data.results.file_path,
+            file_path: response.data.results.media_url,
+            content_type: response.data.results.content_type,
             media_type: response.data.results.media_type,
             file_size: response.data.results.file_size,
             filename: response.data.results.filename,